@@ -0,0 +1,293 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package kms
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/ydcloud-dy/opshub/cmd/root"
+	"github.com/ydcloud-dy/opshub/internal/conf"
+	dataPkg "github.com/ydcloud-dy/opshub/internal/data"
+	"github.com/ydcloud-dy/opshub/pkg/kms"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// encryptedField 使用 kms 加密存储的字段
+type encryptedField struct {
+	Table  string
+	Column string
+}
+
+// encryptedFields 所有加密存储的字段，新增加密字段时需要在这里登记，以便轮换密钥
+var encryptedFields = []encryptedField{
+	{Table: "credentials", Column: "password"},
+	{Table: "credentials", Column: "private_key"},
+	{Table: "credentials", Column: "passphrase"},
+	{Table: "k8s_clusters", Column: "kube_config"},
+	{Table: "mfa_settings", Column: "totp_secret"},
+	{Table: "mfa_settings", Column: "backup_codes"},
+	{Table: "user_credentials", Column: "password"},
+	{Table: "credential_rotations", Column: "pending_secret"},
+	{Table: "ssh_cert_authorities", Column: "private_key"},
+}
+
+var Cmd = &cobra.Command{
+	Use:   "kms",
+	Short: "密钥管理",
+	Long:  `管理数据加密密钥：查看密钥环、生成新密钥、使用主密钥重新加密已有数据`,
+}
+
+var keysCmd = &cobra.Command{
+	Use:   "keys",
+	Short: "查看密钥环",
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, result := mustInit()
+		fmt.Printf("密钥环文件: %s\n", result.KeyringFile)
+		fmt.Printf("主密钥: %s\n", result.Primary)
+		fmt.Printf("旧版本解密: %v\n", cfg.KMS.LegacyDecrypt)
+		fmt.Println("密钥列表:")
+		for _, id := range result.KeyIDs {
+			mark := " "
+			if id == result.Primary {
+				mark = "*"
+			}
+			fmt.Printf("  %s %s\n", mark, id)
+		}
+	},
+}
+
+var (
+	generateKeyID     string
+	generatePrimary   bool
+	generatePrintOnly bool
+	rekeyDryRun       bool
+	rekeyBatchSize    int
+)
+
+var generateKeyCmd = &cobra.Command{
+	Use:   "generate-key",
+	Short: "生成新密钥",
+	Long: `生成新的数据加密密钥。默认写入配置的密钥环文件；
+使用 --print 时只输出 "id:密钥"，可追加到 kms.keys 或环境变量 OPSHUB_KMS_KEYS`,
+	Run: func(cmd *cobra.Command, args []string) {
+		id := generateKeyID
+		if id == "" {
+			id = kms.NewKeyID()
+		}
+
+		if generatePrintOnly {
+			key, err := kms.GenerateKey(id)
+			if err != nil {
+				exitf("生成密钥失败: %v", err)
+			}
+			fmt.Printf("%s:%s\n", key.ID, kms.EncodeSecret(key.Secret))
+			return
+		}
+
+		cfg, err := conf.Load(root.GetConfigFile())
+		if err != nil {
+			exitf("加载配置失败: %v", err)
+		}
+		path := cfg.KMS.KeyringFile
+		if path == "" {
+			path = kms.DefaultKeyringFile
+		}
+		file, err := kms.LoadKeyringFile(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			file = &kms.KeyringFile{}
+		} else if err != nil {
+			exitf("读取密钥环文件失败: %v", err)
+		}
+		if _, err := file.AddKey(id, generatePrimary || file.Primary == ""); err != nil {
+			exitf("生成密钥失败: %v", err)
+		}
+		if err := file.Save(path); err != nil {
+			exitf("保存密钥环文件失败: %v", err)
+		}
+
+		fmt.Printf("✓ 已生成密钥 %s，写入 %s\n", id, path)
+		if file.Primary == id {
+			fmt.Println("该密钥已设为主密钥，重启服务后新数据将使用它加密，可执行 opshub kms rekey 重新加密已有数据")
+		}
+		if cfg.KMS.Primary != "" && cfg.KMS.Primary != file.Primary {
+			fmt.Printf("注意: 配置 kms.primary=%s 优先于密钥环文件中的主密钥\n", cfg.KMS.Primary)
+		}
+	},
+}
+
+var rekeyCmd = &cobra.Command{
+	Use:   "rekey",
+	Short: "使用主密钥重新加密数据",
+	Long: `将所有加密字段中非主密钥加密的数据（包括旧版本密文）用当前主密钥重新加密。
+轮换密钥的步骤：generate-key 生成并设为主密钥 -> 重启服务 -> rekey -> 确认没有失败记录后移除旧密钥`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, result := mustInit()
+		if rekeyBatchSize <= 0 {
+			rekeyBatchSize = 200
+		}
+
+		data, err := dataPkg.NewData(cfg)
+		if err != nil {
+			exitf("连接数据库失败: %v", err)
+		}
+		defer data.Close()
+		db := data.DB().Session(&gorm.Session{Logger: logger.Default.LogMode(logger.Warn)})
+
+		keyring := kms.Default()
+		fmt.Printf("主密钥: %s\n", result.Primary)
+		if rekeyDryRun {
+			fmt.Println("试运行模式，不会写入数据库")
+		}
+
+		failed := false
+		for _, field := range encryptedFields {
+			if !db.Migrator().HasTable(field.Table) {
+				fmt.Printf("- %s.%s: 表不存在，跳过\n", field.Table, field.Column)
+				continue
+			}
+			stats, err := rekeyField(db, keyring, field, rekeyBatchSize, rekeyDryRun)
+			if err != nil {
+				exitf("处理 %s.%s 失败: %v", field.Table, field.Column, err)
+			}
+			fmt.Printf("- %s.%s: 共 %d 条，重新加密 %d 条，已是主密钥 %d 条，失败 %d 条\n",
+				field.Table, field.Column, stats.Total, stats.Rotated, stats.Current, len(stats.FailedIDs))
+			if len(stats.FailedIDs) > 0 {
+				failed = true
+				fmt.Printf("  失败记录ID: %s\n", joinIDs(stats.FailedIDs))
+			}
+		}
+
+		if failed {
+			fmt.Println("存在无法解密的记录，请检查对应的密钥是否仍在密钥环中，移除旧密钥前需要先处理这些记录")
+			os.Exit(1)
+		}
+		fmt.Println("✓ 重新加密完成")
+	},
+}
+
+// rekeyStats 单个字段的重新加密统计
+type rekeyStats struct {
+	Total     int
+	Rotated   int
+	Current   int
+	FailedIDs []uint
+}
+
+// rekeyField 按主键分批重新加密一个字段，写回时校验原值，避免覆盖期间被修改的数据
+func rekeyField(db *gorm.DB, keyring *kms.Keyring, field encryptedField, batchSize int, dryRun bool) (*rekeyStats, error) {
+	type row struct {
+		ID    uint
+		Value string
+	}
+
+	stats := &rekeyStats{}
+	var lastID uint
+	for {
+		var rows []row
+		err := db.Table(field.Table).
+			Select(fmt.Sprintf("id, `%s` AS value", field.Column)).
+			Where("id > ? AND `"+field.Column+"` IS NOT NULL AND `"+field.Column+"` <> ''", lastID).
+			Order("id").
+			Limit(batchSize).
+			Find(&rows).Error
+		if err != nil {
+			return nil, err
+		}
+		if len(rows) == 0 {
+			return stats, nil
+		}
+
+		for _, r := range rows {
+			lastID = r.ID
+			stats.Total++
+
+			rotated, changed, err := keyring.Rotate(r.Value)
+			if err != nil {
+				stats.FailedIDs = append(stats.FailedIDs, r.ID)
+				continue
+			}
+			if !changed {
+				stats.Current++
+				continue
+			}
+			if dryRun {
+				stats.Rotated++
+				continue
+			}
+
+			res := db.Table(field.Table).
+				Where("id = ? AND `"+field.Column+"` = ?", r.ID, r.Value).
+				UpdateColumn(field.Column, rotated)
+			if res.Error != nil || res.RowsAffected == 0 {
+				stats.FailedIDs = append(stats.FailedIDs, r.ID)
+				continue
+			}
+			stats.Rotated++
+		}
+	}
+}
+
+// mustInit 加载配置并初始化密钥环
+func mustInit() (*conf.Config, *kms.InitResult) {
+	cfg, err := conf.Load(root.GetConfigFile())
+	if err != nil {
+		exitf("加载配置失败: %v", err)
+	}
+	result, err := kms.Init(cfg.KeyringConfig())
+	if err != nil {
+		exitf("初始化密钥环失败: %v", err)
+	}
+	if result.Created {
+		fmt.Printf("未配置数据加密密钥，已生成密钥环文件 %s\n", result.KeyringFile)
+	}
+	return cfg, result
+}
+
+func joinIDs(ids []uint) string {
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = fmt.Sprint(id)
+	}
+	return strings.Join(parts, ",")
+}
+
+func exitf(format string, args ...interface{}) {
+	fmt.Printf(format+"\n", args...)
+	os.Exit(1)
+}
+
+func init() {
+	root.Cmd.AddCommand(Cmd)
+	Cmd.AddCommand(keysCmd)
+	Cmd.AddCommand(generateKeyCmd)
+	Cmd.AddCommand(rekeyCmd)
+
+	generateKeyCmd.Flags().StringVar(&generateKeyID, "id", "", "密钥ID (默认按时间生成)")
+	generateKeyCmd.Flags().BoolVar(&generatePrimary, "primary", false, "设为主密钥")
+	generateKeyCmd.Flags().BoolVar(&generatePrintOnly, "print", false, "只输出密钥，不写入密钥环文件")
+	rekeyCmd.Flags().BoolVar(&rekeyDryRun, "dry-run", false, "只统计，不写入数据库")
+	rekeyCmd.Flags().IntVar(&rekeyBatchSize, "batch-size", 200, "每批处理的记录数")
+}
@@ -38,11 +38,15 @@ import (
 	systemmodel "github.com/ydcloud-dy/opshub/internal/biz/system"
 	"github.com/ydcloud-dy/opshub/internal/conf"
 	dataPkg "github.com/ydcloud-dy/opshub/internal/data"
+	assetdata "github.com/ydcloud-dy/opshub/internal/data/asset"
 	systemdata "github.com/ydcloud-dy/opshub/internal/data/system"
 	"github.com/ydcloud-dy/opshub/internal/server"
 	"github.com/ydcloud-dy/opshub/internal/service"
 	rbacservice "github.com/ydcloud-dy/opshub/internal/service/rbac"
+	"github.com/ydcloud-dy/opshub/pkg/kms"
 	appLogger "github.com/ydcloud-dy/opshub/pkg/logger"
+	"github.com/ydcloud-dy/opshub/pkg/recording"
+	sshclient "github.com/ydcloud-dy/opshub/pkg/ssh"
 	"github.com/ydcloud-dy/opshub/plugins/kubernetes/data/models"
 	k8smodel "github.com/ydcloud-dy/opshub/plugins/kubernetes/model"
 	"go.uber.org/zap"
@@ -119,6 +123,37 @@ func runServer() (*conf.Config, error) {
 	}
 	defer appLogger.Sync()
 
+	// 初始化终端录制存储
+	recordingCfg := &recording.Config{
+		Storage:       cfg.Recording.Storage,
+		LocalDir:      cfg.Recording.LocalDir,
+		Compress:      cfg.Recording.Compress,
+		RetentionDays: cfg.Recording.RetentionDays,
+		S3: recording.S3Config{
+			Endpoint:     cfg.Recording.S3.Endpoint,
+			Region:       cfg.Recording.S3.Region,
+			Bucket:       cfg.Recording.S3.Bucket,
+			AccessKey:    cfg.Recording.S3.AccessKey,
+			SecretKey:    cfg.Recording.S3.SecretKey,
+			Prefix:       cfg.Recording.S3.Prefix,
+			UsePathStyle: cfg.Recording.S3.UsePathStyle,
+		},
+	}
+	if err := recording.Init(recordingCfg); err != nil {
+		return nil, fmt.Errorf("初始化录制存储失败: %w", err)
+	}
+
+	// 初始化敏感数据加密密钥
+	kmsResult, err := kms.Init(cfg.KeyringConfig())
+	if err != nil {
+		return nil, fmt.Errorf("初始化加密密钥失败: %w", err)
+	}
+	if kmsResult.Created {
+		appLogger.Warn("未配置加密密钥，已生成新的密钥环文件，请妥善备份，多实例部署时需同步到所有实例",
+			zap.String("file", kmsResult.KeyringFile))
+	}
+	appLogger.Info("加密密钥已加载", zap.String("primary", kmsResult.Primary), zap.Strings("keys", kmsResult.KeyIDs))
+
 	appLogger.Info("服务启动中...",
 		zap.String("version", "1.0.0"),
 		zap.String("mode", cfg.Server.Mode),
@@ -157,6 +192,11 @@ func runServer() (*conf.Config, error) {
 		return nil, fmt.Errorf("初始化默认数据失败: %w", err)
 	}
 
+	// 初始化默认命令策略规则（仅在规则表为空时）
+	if err := assetdata.NewCommandRuleRepo(data.DB()).InitDefaultRules(context.Background()); err != nil {
+		appLogger.Warn("初始化默认命令规则失败", zap.Error(err))
+	}
+
 	// 初始化HTTP服务器
 	httpServer := server.NewHTTPServer(cfg, svc, data.DB())
 	globalHTTPServer = httpServer // 保存到全局变量
@@ -195,6 +235,18 @@ func autoMigrate(db *gorm.DB) error {
 		&assetmodel.Credential{},
 		&assetmodel.AssetGroup{},
 		&assetmodel.CloudAccount{},
+		&assetmodel.CommandRule{},
+		&assetmodel.TerminalCommand{},
+		&assetmodel.TerminalCommandApproval{},
+		&assetmodel.HostMetric{},
+		&assetmodel.HostAvailabilityEvent{},
+		&assetmodel.CredentialSecretAccess{},
+		&assetmodel.CredentialRotation{},
+		&assetmodel.CredentialRotationHost{},
+		&assetmodel.SSHCertAuthority{},
+		&assetmodel.SSHCertificate{},
+		&assetmodel.CloudSyncRun{},
+		&assetmodel.CloudSyncChange{},
 		// Kubernetes 集群相关表
 		&models.Cluster{},
 		&k8smodel.UserKubeConfig{},
@@ -203,6 +255,7 @@ func autoMigrate(db *gorm.DB) error {
 		&auditmodel.SysOperationLog{},
 		&auditmodel.SysLoginLog{},
 		&auditmodel.SysDataLog{},
+		&auditmodel.SysFileTransferLog{},
 		// MFA相关表
 		&mfamodel.UserMFA{},
 		&mfamodel.MFALog{},
@@ -367,6 +420,7 @@ func initDefaultData(db *gorm.DB) error {
 	auditSubMenus := []*rbacmodel.SysMenu{
 		{Name: "操作日志", Code: "operation-logs", Type: 2, ParentID: auditMenu.ID, Path: "/audit/operation-logs", Component: "audit/OperationLogs", Icon: "Document", Sort: 1, Visible: 1, Status: 1},
 		{Name: "登录日志", Code: "login-logs", Type: 2, ParentID: auditMenu.ID, Path: "/audit/login-logs", Component: "audit/LoginLogs", Icon: "CircleCheck", Sort: 2, Visible: 1, Status: 1},
+		{Name: "文件传输日志", Code: "file-transfer-logs", Type: 2, ParentID: auditMenu.ID, Path: "/audit/file-transfer-logs", Component: "audit/FileTransferLogs", Icon: "Files", Sort: 3, Visible: 1, Status: 1},
 	}
 	for _, menu := range auditSubMenus {
 		if err := createMenuWithPermission(menu); err != nil {
@@ -435,6 +489,9 @@ func stopServer(ctx context.Context, cfg *conf.Config) error {
 		}
 	}
 
+	// 关闭SSH连接池
+	sshclient.DefaultPool().Close()
+
 	// 关闭数据库连接
 	if globalData != nil {
 		if err := globalData.Close(); err != nil {
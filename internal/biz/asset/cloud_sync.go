@@ -0,0 +1,679 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package asset
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	appLogger "github.com/ydcloud-dy/opshub/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// 云库存同步状态
+const (
+	CloudSyncStatusRunning = "running" // 执行中
+	CloudSyncStatusSuccess = "success" // 所有区域同步完成
+	CloudSyncStatusPartial = "partial" // 部分区域或主机同步失败
+	CloudSyncStatusFailed  = "failed"  // 同步失败，主机未做任何变更
+)
+
+// 云库存同步中单个实例的变更类型
+const (
+	CloudSyncActionAdded      = "added"      // 新实例，已创建或关联主机
+	CloudSyncActionUpdated    = "updated"    // IP、规格、状态或标签发生变化
+	CloudSyncActionTerminated = "terminated" // 实例已不存在，主机标记为已释放
+	CloudSyncActionRestored   = "restored"   // 已标记释放的实例重新出现
+	CloudSyncActionFailed     = "failed"     // 创建或更新主机失败
+)
+
+// 云库存同步触发方式
+const (
+	CloudSyncTriggerManual   = "manual"
+	CloudSyncTriggerSchedule = "schedule"
+)
+
+// CloudStatusTerminated 云实例已释放，主机保留用于审计
+const CloudStatusTerminated = "terminated"
+
+// CloudSyncRun 云库存同步记录
+type CloudSyncRun struct {
+	ID           uint               `gorm:"primarykey" json:"id"`
+	AccountID    uint               `gorm:"column:account_id;not null;index;comment:云账号ID" json:"accountId"`
+	AccountName  string             `gorm:"type:varchar(100);comment:云账号名称" json:"accountName"`
+	Provider     string             `gorm:"type:varchar(50);comment:云厂商" json:"provider"`
+	Regions      string             `gorm:"type:varchar(500);comment:同步区域" json:"regions"`
+	Trigger      string             `gorm:"type:varchar(20);comment:触发方式 manual/schedule" json:"trigger"`
+	Status       string             `gorm:"type:varchar(20);index;comment:状态" json:"status"`
+	Added        int                `gorm:"comment:新增主机数" json:"added"`
+	Updated      int                `gorm:"comment:变更主机数" json:"updated"`
+	Terminated   int                `gorm:"comment:已释放主机数" json:"terminated"`
+	Restored     int                `gorm:"comment:恢复主机数" json:"restored"`
+	Unchanged    int                `gorm:"comment:未变化主机数" json:"unchanged"`
+	Failed       int                `gorm:"comment:失败实例数" json:"failed"`
+	Message      string             `gorm:"type:varchar(500);comment:结果说明" json:"message"`
+	OperatorID   uint               `gorm:"comment:操作人ID" json:"operatorId"`
+	OperatorName string             `gorm:"type:varchar(50);comment:操作人" json:"operatorName"`
+	StartedAt    time.Time          `json:"startedAt"`
+	FinishedAt   *time.Time         `json:"finishedAt"`
+	Changes      []*CloudSyncChange `gorm:"-" json:"changes,omitempty"`
+}
+
+// TableName 表名
+func (CloudSyncRun) TableName() string {
+	return "cloud_sync_runs"
+}
+
+// CloudSyncChange 同步中单个实例的变更
+type CloudSyncChange struct {
+	ID         uint                  `gorm:"primarykey" json:"id"`
+	RunID      uint                  `gorm:"column:run_id;not null;index;comment:同步记录ID" json:"runId"`
+	AccountID  uint                  `gorm:"column:account_id;comment:云账号ID" json:"accountId"`
+	HostID     uint                  `gorm:"column:host_id;index;comment:主机ID" json:"hostId"`
+	HostName   string                `gorm:"type:varchar(100);comment:主机名称" json:"hostName"`
+	InstanceID string                `gorm:"type:varchar(100);comment:云实例ID" json:"instanceId"`
+	Region     string                `gorm:"type:varchar(100);comment:云区域" json:"region"`
+	Action     string                `gorm:"type:varchar(20);comment:变更类型" json:"action"`
+	Fields     CloudSyncFieldChanges `gorm:"type:json;comment:字段变化" json:"fields"`
+	Error      string                `gorm:"type:varchar(500);comment:失败原因" json:"error,omitempty"`
+	CreatedAt  time.Time             `json:"createdAt"`
+}
+
+// TableName 表名
+func (CloudSyncChange) TableName() string {
+	return "cloud_sync_changes"
+}
+
+// CloudSyncFieldChange 字段变化
+type CloudSyncFieldChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+// CloudSyncFieldChanges 用于处理JSON格式的字段变化列表
+type CloudSyncFieldChanges []CloudSyncFieldChange
+
+// Value 实现 driver.Valuer 接口，用于数据库存储
+func (c CloudSyncFieldChanges) Value() (driver.Value, error) {
+	if c == nil {
+		c = CloudSyncFieldChanges{}
+	}
+	return json.Marshal(c)
+}
+
+// Scan 实现 sql.Scanner 接口，用于从数据库读取
+func (c *CloudSyncFieldChanges) Scan(value interface{}) error {
+	bytes, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(bytes, c)
+}
+
+// CloudSyncConfig 云库存同步配置，零值使用默认值
+type CloudSyncConfig struct {
+	Schedule      bool          // 是否按云账号配置的间隔自动同步
+	CheckInterval time.Duration // 检查到期账号和中断同步的间隔
+}
+
+func (c CloudSyncConfig) withDefaults() CloudSyncConfig {
+	if c.CheckInterval < time.Minute {
+		c.CheckInterval = 5 * time.Minute
+	}
+	return c
+}
+
+// cloudSyncTimeout 单次同步的最长执行时间，超过后未结束的记录视为中断
+const cloudSyncTimeout = 30 * time.Minute
+
+// CloudSyncUseCase 云库存同步：定期拉取云账号下的实例列表，与已有主机比对，
+// 新增主机、标记已释放的实例，更新IP、规格、状态和标签，并记录每次同步的变更
+type CloudSyncUseCase struct {
+	syncRepo     CloudSyncRepo
+	accountRepo  CloudAccountRepo
+	hostRepo     HostRepo
+	cloudUseCase *CloudAccountUseCase
+	config       CloudSyncConfig
+	startOnce    sync.Once
+
+	mu      sync.Mutex
+	running map[uint]bool // 正在同步的云账号
+}
+
+func NewCloudSyncUseCase(syncRepo CloudSyncRepo, accountRepo CloudAccountRepo, hostRepo HostRepo, cloudUseCase *CloudAccountUseCase, config CloudSyncConfig) *CloudSyncUseCase {
+	return &CloudSyncUseCase{
+		syncRepo:     syncRepo,
+		accountRepo:  accountRepo,
+		hostRepo:     hostRepo,
+		cloudUseCase: cloudUseCase,
+		config:       config.withDefaults(),
+		running:      make(map[uint]bool),
+	}
+}
+
+// Start 定期结束中断的同步，开启自动同步时同时同步到期的云账号，重复调用只会启动一次
+func (uc *CloudSyncUseCase) Start() {
+	uc.startOnce.Do(func() {
+		go func() {
+			ticker := time.NewTicker(uc.config.CheckInterval)
+			defer ticker.Stop()
+			for now := range ticker.C {
+				uc.RecoverInterrupted(context.Background(), now)
+				if uc.config.Schedule {
+					uc.SyncDue(context.Background(), now)
+				}
+			}
+		}()
+	})
+}
+
+// Sync 开始同步云账号，立即返回同步记录，同步在后台执行
+func (uc *CloudSyncUseCase) Sync(ctx context.Context, accountID uint, trigger string, operatorID uint, operatorName string) (*CloudSyncRun, error) {
+	account, err := uc.accountRepo.GetByID(ctx, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("云平台账号不存在")
+	}
+	if account.Status != 1 {
+		return nil, fmt.Errorf("云平台账号已禁用")
+	}
+	regions := account.SyncRegionList()
+	if len(regions) == 0 {
+		return nil, fmt.Errorf("未配置同步区域")
+	}
+
+	uc.mu.Lock()
+	if uc.running[accountID] {
+		uc.mu.Unlock()
+		return nil, fmt.Errorf("该云账号正在同步中")
+	}
+	uc.running[accountID] = true
+	uc.mu.Unlock()
+
+	run := &CloudSyncRun{
+		AccountID:    account.ID,
+		AccountName:  account.Name,
+		Provider:     account.Provider,
+		Regions:      strings.Join(regions, ","),
+		Trigger:      trigger,
+		Status:       CloudSyncStatusRunning,
+		OperatorID:   operatorID,
+		OperatorName: operatorName,
+		StartedAt:    time.Now(),
+	}
+	if err := uc.syncRepo.CreateRun(ctx, run); err != nil {
+		uc.finishRunning(accountID)
+		return nil, fmt.Errorf("创建同步记录失败: %w", err)
+	}
+
+	// 返回创建时的快照，后台执行时会继续修改记录
+	snapshot := *run
+
+	go func() {
+		defer uc.finishRunning(accountID)
+		runCtx, cancel := context.WithTimeout(context.Background(), cloudSyncTimeout)
+		defer cancel()
+		uc.run(runCtx, run, account, regions)
+	}()
+
+	return &snapshot, nil
+}
+
+func (uc *CloudSyncUseCase) finishRunning(accountID uint) {
+	uc.mu.Lock()
+	delete(uc.running, accountID)
+	uc.mu.Unlock()
+}
+
+func (uc *CloudSyncUseCase) isRunning(accountID uint) bool {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+	return uc.running[accountID]
+}
+
+// run 逐个区域拉取实例并与主机比对，最后将未出现的实例标记为已释放
+func (uc *CloudSyncUseCase) run(ctx context.Context, run *CloudSyncRun, account *CloudAccount, regions []string) {
+	hosts, err := uc.hostRepo.ListByCloudAccountID(ctx, account.ID)
+	if err != nil {
+		uc.finish(run, CloudSyncStatusFailed, "获取云账号下的主机失败: "+err.Error())
+		return
+	}
+	known := make(map[string]*Host, len(hosts))
+	for _, host := range hosts {
+		known[host.CloudInstanceID] = host
+	}
+
+	now := time.Now()
+	seen := make(map[string]bool)
+	listed := make(map[string]bool) // 实例列表完整的区域，只有这些区域会检查已释放的实例
+	failedRegions := 0
+	var messages []string
+	for _, region := range regions {
+		instances, complete, err := uc.cloudUseCase.listInstances(account, region)
+		if err != nil {
+			failedRegions++
+			messages = append(messages, fmt.Sprintf("区域 %s 获取实例失败: %v", region, err))
+			continue
+		}
+		if complete {
+			listed[region] = true
+		} else {
+			messages = append(messages, fmt.Sprintf("区域 %s 实例数超过 %d，未检查已释放的实例", region, maxCloudInstances))
+		}
+		for i := range instances {
+			instance := &instances[i]
+			if instance.InstanceID == "" || seen[instance.InstanceID] {
+				continue
+			}
+			seen[instance.InstanceID] = true
+			uc.syncInstance(ctx, run, account, known[instance.InstanceID], instance, now)
+		}
+	}
+
+	if failedRegions == len(regions) {
+		uc.finish(run, CloudSyncStatusFailed, strings.Join(messages, "; "))
+		return
+	}
+
+	// 未记录区域的主机（早期导入）只有在所有区域都完整获取时才判断是否已释放
+	allListed := len(listed) == len(regions)
+	for _, host := range hosts {
+		if seen[host.CloudInstanceID] || host.CloudStatus == CloudStatusTerminated {
+			continue
+		}
+		checked := allListed
+		if host.CloudRegion != "" {
+			checked = listed[host.CloudRegion]
+		}
+		if !checked {
+			continue
+		}
+		uc.terminateHost(ctx, run, host, now)
+	}
+
+	status := CloudSyncStatusSuccess
+	if failedRegions > 0 || run.Failed > 0 {
+		status = CloudSyncStatusPartial
+	}
+	uc.finish(run, status, strings.Join(messages, "; "))
+}
+
+// syncInstance 同步单个实例，host 为该账号下已关联此实例的主机
+func (uc *CloudSyncUseCase) syncInstance(ctx context.Context, run *CloudSyncRun, account *CloudAccount, host *Host, instance *CloudInstance, now time.Time) {
+	change := &CloudSyncChange{
+		RunID:      run.ID,
+		AccountID:  account.ID,
+		InstanceID: instance.InstanceID,
+		Region:     instance.Region,
+	}
+
+	if host == nil {
+		var skip bool
+		host, change.Fields, skip = uc.adoptHost(ctx, account, instance)
+		if skip {
+			return
+		}
+		change.Action = CloudSyncActionAdded
+		if host == nil {
+			host = newCloudHost(account, instance)
+			change.HostName = host.Name
+			if host.IP == "" {
+				uc.failInstance(ctx, run, change, fmt.Errorf("实例没有IP地址"))
+				return
+			}
+			applyCloudInstance(host, instance, now)
+			if err := uc.hostRepo.Create(ctx, host); err != nil {
+				uc.failInstance(ctx, run, change, fmt.Errorf("创建主机失败: %w", err))
+				return
+			}
+			change.HostID = host.ID
+			run.Added++
+			uc.saveChange(ctx, change)
+			return
+		}
+	}
+
+	change.HostID = host.ID
+	change.HostName = host.Name
+	restored := host.CloudStatus == CloudStatusTerminated
+	change.Fields = append(change.Fields, applyCloudInstance(host, instance, now)...)
+	if err := uc.hostRepo.UpdateCloudInventory(ctx, host); err != nil {
+		uc.failInstance(ctx, run, change, fmt.Errorf("更新主机失败: %w", err))
+		return
+	}
+
+	switch {
+	case change.Action == CloudSyncActionAdded:
+		run.Added++
+	case restored:
+		change.Action = CloudSyncActionRestored
+		run.Restored++
+	case len(change.Fields) > 0:
+		change.Action = CloudSyncActionUpdated
+		run.Updated++
+	default:
+		run.Unchanged++
+		return
+	}
+	uc.saveChange(ctx, change)
+}
+
+// adoptHost 查找可以关联到新实例的已有主机：
+// 已关联该实例但不属于任何云账号的主机，或IP相同且未关联有效实例的主机。
+// 实例已由其他云账号管理时 skip 为 true
+func (uc *CloudSyncUseCase) adoptHost(ctx context.Context, account *CloudAccount, instance *CloudInstance) (*Host, CloudSyncFieldChanges, bool) {
+	if host, err := uc.hostRepo.GetByCloudInstanceID(ctx, instance.InstanceID); err == nil && host != nil {
+		if host.CloudAccountID != 0 && host.CloudAccountID != account.ID {
+			return nil, nil, true
+		}
+		host.CloudAccountID = account.ID
+		host.CloudProvider = account.Provider
+		return host, CloudSyncFieldChanges{{Field: "cloudAccountId", Old: "0", New: strconv.FormatUint(uint64(account.ID), 10)}}, false
+	}
+
+	ip := instance.PreferredIP()
+	if ip == "" {
+		return nil, nil, false
+	}
+	host, err := uc.hostRepo.GetByIP(ctx, ip)
+	if err != nil || host == nil || host.CloudInstanceID != "" && host.CloudStatus != CloudStatusTerminated {
+		return nil, nil, false
+	}
+	fields := CloudSyncFieldChanges{{Field: "cloudInstanceId", Old: host.CloudInstanceID, New: instance.InstanceID}}
+	host.Type = "cloud"
+	host.CloudProvider = account.Provider
+	host.CloudInstanceID = instance.InstanceID
+	host.CloudAccountID = account.ID
+	// 关联新实例，重新记录基线
+	host.CloudStatus = ""
+	host.CloudSyncedAt = nil
+	return host, fields, false
+}
+
+// terminateHost 将实例已不存在的主机标记为已释放，主机记录保留
+func (uc *CloudSyncUseCase) terminateHost(ctx context.Context, run *CloudSyncRun, host *Host, now time.Time) {
+	change := &CloudSyncChange{
+		RunID:      run.ID,
+		AccountID:  run.AccountID,
+		HostID:     host.ID,
+		HostName:   host.Name,
+		InstanceID: host.CloudInstanceID,
+		Region:     host.CloudRegion,
+		Action:     CloudSyncActionTerminated,
+		Fields:     CloudSyncFieldChanges{{Field: "status", Old: host.CloudStatus, New: CloudStatusTerminated}},
+	}
+	host.CloudStatus = CloudStatusTerminated
+	host.CloudSyncedAt = &now
+	if err := uc.hostRepo.UpdateCloudInventory(ctx, host); err != nil {
+		uc.failInstance(ctx, run, change, fmt.Errorf("标记已释放失败: %w", err))
+		return
+	}
+	run.Terminated++
+	uc.saveChange(ctx, change)
+}
+
+func (uc *CloudSyncUseCase) failInstance(ctx context.Context, run *CloudSyncRun, change *CloudSyncChange, err error) {
+	change.Action = CloudSyncActionFailed
+	change.Error = limitMessage(err.Error())
+	run.Failed++
+	uc.saveChange(ctx, change)
+}
+
+func (uc *CloudSyncUseCase) saveChange(ctx context.Context, change *CloudSyncChange) {
+	if err := uc.syncRepo.CreateChange(ctx, change); err != nil {
+		appLogger.Error("保存云库存变更失败", zap.Uint("runId", change.RunID), zap.String("instanceId", change.InstanceID), zap.Error(err))
+	}
+}
+
+// finish 保存同步结果，并更新云账号的最后同步时间
+func (uc *CloudSyncUseCase) finish(run *CloudSyncRun, status, message string) {
+	now := time.Now()
+	run.Status = status
+	run.Message = limitMessage(message)
+	run.FinishedAt = &now
+	if err := uc.syncRepo.UpdateRun(context.Background(), run); err != nil {
+		appLogger.Error("保存云库存同步结果失败", zap.Uint("runId", run.ID), zap.Error(err))
+	}
+	if err := uc.accountRepo.UpdateSyncState(context.Background(), run.AccountID, now, status); err != nil {
+		appLogger.Error("更新云账号同步状态失败", zap.Uint("accountId", run.AccountID), zap.Error(err))
+	}
+	appLogger.Info("云库存同步结束",
+		zap.Uint("accountId", run.AccountID),
+		zap.String("status", status),
+		zap.Int("added", run.Added),
+		zap.Int("updated", run.Updated),
+		zap.Int("terminated", run.Terminated),
+		zap.Int("failed", run.Failed))
+}
+
+// RecoverInterrupted 结束因服务重启等原因超时未结束的同步
+func (uc *CloudSyncUseCase) RecoverInterrupted(ctx context.Context, now time.Time) {
+	runs, err := uc.syncRepo.ListRunning(ctx)
+	if err != nil {
+		appLogger.Error("获取执行中的云库存同步失败", zap.Error(err))
+		return
+	}
+	for _, run := range runs {
+		if now.Sub(run.StartedAt) < cloudSyncTimeout+time.Minute || uc.isRunning(run.AccountID) {
+			continue
+		}
+		uc.finish(run, CloudSyncStatusFailed, "同步中断")
+	}
+}
+
+// SyncDue 同步所有已到同步时间的云账号
+func (uc *CloudSyncUseCase) SyncDue(ctx context.Context, now time.Time) {
+	accounts, err := uc.accountRepo.ListSyncEnabled(ctx)
+	if err != nil {
+		appLogger.Error("获取启用同步的云账号失败", zap.Error(err))
+		return
+	}
+	for _, account := range accounts {
+		if !account.SyncDue(now) || uc.isRunning(account.ID) {
+			continue
+		}
+		if _, err := uc.Sync(ctx, account.ID, CloudSyncTriggerSchedule, 0, "system"); err != nil {
+			appLogger.Warn("定时同步云账号失败", zap.Uint("accountId", account.ID), zap.Error(err))
+		}
+	}
+}
+
+// GetRun 获取同步记录及变更明细
+func (uc *CloudSyncUseCase) GetRun(ctx context.Context, id uint) (*CloudSyncRun, error) {
+	return uc.syncRepo.GetRun(ctx, id)
+}
+
+// ListRuns 分页查询云账号的同步记录
+func (uc *CloudSyncUseCase) ListRuns(ctx context.Context, accountID uint, page, pageSize int) ([]*CloudSyncRun, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+	return uc.syncRepo.ListRuns(ctx, accountID, page, pageSize)
+}
+
+// SyncDue 是否已到自动同步时间，从未同步过的账号立即同步
+func (a *CloudAccount) SyncDue(now time.Time) bool {
+	if a.SyncInterval <= 0 {
+		return false
+	}
+	if a.LastSyncAt == nil {
+		return true
+	}
+	return !now.Before(a.LastSyncAt.Add(time.Duration(a.SyncInterval) * time.Minute))
+}
+
+// SyncRegionList 同步的区域列表，未配置时使用默认区域
+func (a *CloudAccount) SyncRegionList() []string {
+	if regions := normalizeSyncRegions(a.SyncRegions); regions != "" {
+		return strings.Split(regions, ",")
+	}
+	if a.Region != "" {
+		return []string{a.Region}
+	}
+	return nil
+}
+
+// normalizeSyncRegions 去除空白和重复的区域，以逗号分隔
+func normalizeSyncRegions(regions string) string {
+	var result []string
+	seen := make(map[string]bool)
+	for _, region := range strings.FieldsFunc(regions, func(r rune) bool { return r == ',' || r == ' ' || r == '\n' }) {
+		if !seen[region] {
+			seen[region] = true
+			result = append(result, region)
+		}
+	}
+	return strings.Join(result, ",")
+}
+
+// PreferredIP 主机使用的IP地址，优先公网IP，没有则使用私网IP
+func (inst *CloudInstance) PreferredIP() string {
+	if inst.PublicIP != "" {
+		return inst.PublicIP
+	}
+	return inst.PrivateIP
+}
+
+// newCloudHost 为新实例创建主机，默认使用 root 用户和 22 端口
+func newCloudHost(account *CloudAccount, instance *CloudInstance) *Host {
+	name := instance.Name
+	if name == "" {
+		name = instance.InstanceID
+	}
+	return &Host{
+		Name:            name,
+		GroupID:         account.SyncGroupID,
+		Type:            "cloud",
+		CloudProvider:   account.Provider,
+		CloudInstanceID: instance.InstanceID,
+		CloudAccountID:  account.ID,
+		SSHUser:         "root",
+		IP:              instance.PreferredIP(),
+		Port:            22,
+		Description:     fmt.Sprintf("从%s同步", account.Name),
+		Status:          -1, // 初始状态未知
+		OS:              instance.OS,
+	}
+}
+
+// applyCloudInstance 将实例的IP、规格、状态和标签写入主机，返回变化的字段。
+// 主机首次同步时只记录基线，除IP外不作为变化返回；
+// 主机当前IP为实例的公网或私网IP之一时视为未变化，保留手动选择的IP
+func applyCloudInstance(host *Host, instance *CloudInstance, now time.Time) CloudSyncFieldChanges {
+	var changes CloudSyncFieldChanges
+	baseline := host.CloudSyncedAt == nil
+	update := func(field string, current *string, value string) {
+		if *current == value {
+			return
+		}
+		if !baseline {
+			changes = append(changes, CloudSyncFieldChange{Field: field, Old: *current, New: value})
+		}
+		*current = value
+	}
+	updateInt := func(field string, current *int, value int) {
+		if *current == value {
+			return
+		}
+		if !baseline {
+			changes = append(changes, CloudSyncFieldChange{Field: field, Old: strconv.Itoa(*current), New: strconv.Itoa(value)})
+		}
+		*current = value
+	}
+
+	if ip := instance.PreferredIP(); ip != "" && host.IP != instance.PublicIP && host.IP != instance.PrivateIP {
+		changes = append(changes, CloudSyncFieldChange{Field: "ip", Old: host.IP, New: ip})
+		host.IP = ip
+	}
+	update("region", &host.CloudRegion, instance.Region)
+	update("instanceType", &host.CloudInstanceType, instance.InstanceType)
+	updateInt("cpu", &host.CloudCPU, instance.CPU)
+	updateInt("memoryMb", &host.CloudMemoryMB, instance.MemoryMB)
+	update("status", &host.CloudStatus, instance.Status)
+
+	var tags []string
+	for _, tag := range instance.Tags {
+		if tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	sort.Strings(tags)
+	cloudTags := strings.Join(tags, ",")
+	if host.CloudTags != cloudTags {
+		host.Tags = mergeCloudTags(host.Tags, host.CloudTags, tags)
+	}
+	update("tags", &host.CloudTags, cloudTags)
+
+	host.CloudSyncedAt = &now
+	return changes
+}
+
+// hostTagsMaxLen 主机标签列的最大长度
+const hostTagsMaxLen = 500
+
+// mergeCloudTags 用新的云标签替换主机标签中上次同步的云标签，保留手动添加的标签，
+// 超出标签列长度的云标签不写入主机标签
+func mergeCloudTags(hostTags, oldCloudTags string, cloudTags []string) string {
+	previous := make(map[string]bool)
+	for _, tag := range strings.Split(oldCloudTags, ",") {
+		previous[tag] = true
+	}
+
+	var merged []string
+	seen := make(map[string]bool)
+	length := 0
+	add := func(tag string) {
+		if tag == "" || seen[tag] || length+len(tag)+1 > hostTagsMaxLen+1 {
+			return
+		}
+		seen[tag] = true
+		merged = append(merged, tag)
+		length += len(tag) + 1
+	}
+	for _, tag := range strings.Split(hostTags, ",") {
+		if !previous[strings.TrimSpace(tag)] {
+			add(strings.TrimSpace(tag))
+		}
+	}
+	for _, tag := range cloudTags {
+		add(tag)
+	}
+	return strings.Join(merged, ",")
+}
+
+// cloudTag 将云标签转换为主机标签，格式为 key=value，值为空时只保留 key
+func cloudTag(key, value string) string {
+	key = strings.TrimSpace(strings.ReplaceAll(key, ",", " "))
+	value = strings.TrimSpace(strings.ReplaceAll(value, ",", " "))
+	if key == "" || value == "" {
+		return key
+	}
+	return key + "=" + value
+}
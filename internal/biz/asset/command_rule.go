@@ -0,0 +1,151 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package asset
+
+import "gorm.io/gorm"
+
+// 命令策略动作
+const (
+	CommandActionAllow   = "allow"   // 允许执行
+	CommandActionDeny    = "deny"    // 禁止执行
+	CommandActionApprove = "approve" // 需审批后执行
+)
+
+// 命令匹配方式
+const (
+	CommandMatchCommand  = "command"  // 命令名及参数，如 rm -r /
+	CommandMatchRegex    = "regex"    // 正则表达式匹配规范化后的命令文本
+	CommandMatchRedirect = "redirect" // 写入目标，包括重定向目标和 tee 的文件参数
+	CommandMatchPipeline = "pipeline" // 管道，如 {curl,wget} | {sh,bash}
+	CommandMatchDynamic  = "dynamic"  // 命令名含变量或命令替换，无法静态确定
+)
+
+// 命令策略作用范围
+const (
+	CommandScopeGlobal     = "global"
+	CommandScopeRole       = "role"
+	CommandScopeAssetGroup = "asset_group"
+	CommandScopeTemplate   = "template"
+)
+
+// CommandRule 命令策略规则
+type CommandRule struct {
+	gorm.Model
+	Name        string `gorm:"type:varchar(100);not null;comment:规则名称" json:"name"`
+	Action      string `gorm:"type:varchar(20);not null;comment:动作 allow/deny/approve" json:"action"`
+	MatchType   string `gorm:"type:varchar(20);not null;comment:匹配方式 command/regex/redirect/pipeline/dynamic" json:"matchType"`
+	Pattern     string `gorm:"type:varchar(500);comment:匹配模式" json:"pattern"`
+	ScopeType   string `gorm:"type:varchar(20);not null;default:'global';index:idx_command_rule_scope;comment:作用范围 global/role/asset_group/template" json:"scopeType"`
+	ScopeID     uint   `gorm:"column:scope_id;default:0;index:idx_command_rule_scope;comment:作用对象ID" json:"scopeId"`
+	Priority    int    `gorm:"type:int;not null;comment:优先级，数值越小越先匹配" json:"priority"`
+	Status      int    `gorm:"type:tinyint;not null;comment:状态 1:启用 0:禁用" json:"status"`
+	Description string `gorm:"type:varchar(500);comment:规则说明" json:"description"`
+}
+
+// TableName 表名
+func (CommandRule) TableName() string {
+	return "asset_command_rule"
+}
+
+// CommandRuleRequest 命令策略规则请求
+type CommandRuleRequest struct {
+	Name        string `json:"name" binding:"required,max=100"`
+	Action      string `json:"action" binding:"required,oneof=allow deny approve"`
+	MatchType   string `json:"matchType" binding:"required,oneof=command regex redirect pipeline dynamic"`
+	Pattern     string `json:"pattern" binding:"max=500"`
+	ScopeType   string `json:"scopeType" binding:"required,oneof=global role asset_group template"`
+	ScopeID     uint   `json:"scopeId"`
+	Priority    int    `json:"priority"`
+	Status      int    `json:"status" binding:"oneof=0 1"`
+	Description string `json:"description" binding:"max=500"`
+}
+
+// ToModel 转换为CommandRule模型
+func (r *CommandRuleRequest) ToModel() *CommandRule {
+	return &CommandRule{
+		Name:        r.Name,
+		Action:      r.Action,
+		MatchType:   r.MatchType,
+		Pattern:     r.Pattern,
+		ScopeType:   r.ScopeType,
+		ScopeID:     r.ScopeID,
+		Priority:    r.Priority,
+		Status:      r.Status,
+		Description: r.Description,
+	}
+}
+
+// CommandCheckRequest 命令策略检查请求
+type CommandCheckRequest struct {
+	Content    string `json:"content" binding:"required"`
+	UserID     uint   `json:"userId"`     // 执行用户，决定角色范围的规则
+	HostIDs    []uint `json:"hostIds"`    // 目标主机，决定资产分组范围的规则
+	TemplateID uint   `json:"templateId"` // 作业模板，决定模板范围的规则
+}
+
+// CommandCheckResult 命令策略检查结果
+type CommandCheckResult struct {
+	Action   string              `json:"action"` // 整体结论：任一命令禁止则禁止，否则任一命令需审批则需审批
+	Reason   string              `json:"reason"`
+	Commands []*CommandCheckItem `json:"commands"`
+}
+
+// CommandCheckItem 单条命令的检查结果
+type CommandCheckItem struct {
+	Line      int    `json:"line"`
+	Command   string `json:"command"` // 规范化后的命令
+	Action    string `json:"action"`
+	RuleID    uint   `json:"ruleId,omitempty"`
+	RuleName  string `json:"ruleName,omitempty"`
+	MatchType string `json:"matchType,omitempty"`
+	Pattern   string `json:"pattern,omitempty"`
+	ScopeType string `json:"scopeType,omitempty"`
+	ScopeID   uint   `json:"scopeId,omitempty"`
+}
+
+// DefaultCommandRules 默认命令策略规则（全局范围、启用状态），规则表为空时初始化
+var DefaultCommandRules = []CommandRule{
+	{Name: "禁止删除根目录", Action: CommandActionDeny, MatchType: CommandMatchCommand, Pattern: `rm -r {/,/\*}`, Priority: 10, Description: "递归删除根目录或根目录下全部文件"},
+	{Name: "禁止删除系统目录", Action: CommandActionDeny, MatchType: CommandMatchCommand, Pattern: "rm -r /{bin,boot,dev,etc,lib*,proc,root,sbin,sys,usr,var}", Priority: 10, Description: "递归删除系统关键目录"},
+	{Name: "禁止绕过根目录保护", Action: CommandActionDeny, MatchType: CommandMatchCommand, Pattern: "rm --no-preserve-root", Priority: 10},
+	{Name: "禁止格式化文件系统", Action: CommandActionDeny, MatchType: CommandMatchCommand, Pattern: "mkfs*", Priority: 10},
+	{Name: "禁止dd写入块设备", Action: CommandActionDeny, MatchType: CommandMatchCommand, Pattern: "dd of=/dev/*", Priority: 10},
+	{Name: "禁止磁盘分区操作", Action: CommandActionDeny, MatchType: CommandMatchCommand, Pattern: "{fdisk,sfdisk,parted,wipefs}", Priority: 10},
+	{Name: "禁止递归修改根目录权限", Action: CommandActionDeny, MatchType: CommandMatchCommand, Pattern: "{chmod,chown,chgrp} -R /", Priority: 10},
+	{Name: "禁止从根目录查找删除", Action: CommandActionDeny, MatchType: CommandMatchCommand, Pattern: "find / -delete", Priority: 10, Description: "从根目录查找并删除文件"},
+	{Name: "禁止下载脚本直接执行", Action: CommandActionDeny, MatchType: CommandMatchPipeline, Pattern: "{curl,wget} | {sh,bash,zsh,dash,ksh,python*,perl}", Priority: 10, Description: "通过管道执行网络下载的内容"},
+	{Name: "禁止解码后直接执行", Action: CommandActionDeny, MatchType: CommandMatchPipeline, Pattern: "base64 -d | {sh,bash,zsh,dash,ksh,python*,perl}", Priority: 10, Description: "通过管道执行 base64 解码的内容，常用于隐藏真实命令"},
+	{Name: "禁止写入块设备", Action: CommandActionDeny, MatchType: CommandMatchRedirect, Pattern: "/dev/{sd,hd,vd,xvd,nvme,mmcblk}*", Priority: 10},
+	{Name: "禁止覆盖账号文件", Action: CommandActionDeny, MatchType: CommandMatchRedirect, Pattern: "/etc/{passwd,shadow,group,gshadow,sudoers}", Priority: 10},
+	{Name: "禁止写入引导目录", Action: CommandActionDeny, MatchType: CommandMatchRedirect, Pattern: "/boot/*", Priority: 10},
+	{Name: "禁止删除用户", Action: CommandActionDeny, MatchType: CommandMatchCommand, Pattern: "userdel", Priority: 20},
+	{Name: "禁止清空用户密码", Action: CommandActionDeny, MatchType: CommandMatchCommand, Pattern: "passwd -d", Priority: 20},
+	{Name: "禁止编辑sudoers", Action: CommandActionDeny, MatchType: CommandMatchCommand, Pattern: "visudo", Priority: 20},
+	{Name: "禁止删除定时任务", Action: CommandActionDeny, MatchType: CommandMatchCommand, Pattern: "crontab -r", Priority: 20},
+	{Name: "禁止清空防火墙规则", Action: CommandActionDeny, MatchType: CommandMatchCommand, Pattern: "{iptables,ip6tables} -F", Priority: 20},
+	{Name: "禁止清除命令历史", Action: CommandActionDeny, MatchType: CommandMatchCommand, Pattern: "history -c", Priority: 20},
+	{Name: "禁止加载卸载内核模块", Action: CommandActionDeny, MatchType: CommandMatchCommand, Pattern: "{insmod,rmmod,modprobe}", Priority: 20},
+	{Name: "关机重启需审批", Action: CommandActionApprove, MatchType: CommandMatchCommand, Pattern: "{shutdown,reboot,halt,poweroff}", Priority: 50},
+	{Name: "systemctl关机重启需审批", Action: CommandActionApprove, MatchType: CommandMatchCommand, Pattern: "systemctl {poweroff,reboot,halt,kexec}", Priority: 50},
+	{Name: "切换运行级别关机重启需审批", Action: CommandActionApprove, MatchType: CommandMatchCommand, Pattern: "{init,telinit} {0,6}", Priority: 50},
+	{Name: "删除Kubernetes资源需审批", Action: CommandActionApprove, MatchType: CommandMatchCommand, Pattern: "kubectl delete", Priority: 50},
+	{Name: "清理Docker资源需审批", Action: CommandActionApprove, MatchType: CommandMatchCommand, Pattern: "docker system prune", Priority: 50},
+	{Name: "动态命令需审批", Action: CommandActionApprove, MatchType: CommandMatchDynamic, Priority: 90, Description: "命令名由变量或命令替换生成，无法静态判断"},
+}
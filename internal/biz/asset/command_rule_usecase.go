@@ -0,0 +1,478 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package asset
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/ydcloud-dy/opshub/pkg/shellparse"
+)
+
+// commandScopeWeights 优先级相同时范围越具体的规则越先匹配
+var commandScopeWeights = map[string]int{
+	CommandScopeTemplate:   4,
+	CommandScopeAssetGroup: 3,
+	CommandScopeRole:       2,
+	CommandScopeGlobal:     1,
+}
+
+var commandActionNames = map[string]string{
+	CommandActionAllow:   "允许执行",
+	CommandActionDeny:    "禁止执行",
+	CommandActionApprove: "需要审批",
+}
+
+type CommandRuleUseCase struct {
+	ruleRepo  CommandRuleRepo
+	hostRepo  HostRepo
+	groupRepo AssetGroupRepo
+}
+
+func NewCommandRuleUseCase(ruleRepo CommandRuleRepo, hostRepo HostRepo, groupRepo AssetGroupRepo) *CommandRuleUseCase {
+	return &CommandRuleUseCase{
+		ruleRepo:  ruleRepo,
+		hostRepo:  hostRepo,
+		groupRepo: groupRepo,
+	}
+}
+
+func (uc *CommandRuleUseCase) Create(ctx context.Context, rule *CommandRule) error {
+	if err := validateCommandRule(rule); err != nil {
+		return err
+	}
+	return uc.ruleRepo.Create(ctx, rule)
+}
+
+func (uc *CommandRuleUseCase) Update(ctx context.Context, rule *CommandRule) error {
+	if err := validateCommandRule(rule); err != nil {
+		return err
+	}
+	return uc.ruleRepo.Update(ctx, rule)
+}
+
+func (uc *CommandRuleUseCase) Delete(ctx context.Context, id uint) error {
+	return uc.ruleRepo.Delete(ctx, id)
+}
+
+func (uc *CommandRuleUseCase) GetByID(ctx context.Context, id uint) (*CommandRule, error) {
+	return uc.ruleRepo.GetByID(ctx, id)
+}
+
+func (uc *CommandRuleUseCase) List(ctx context.Context, page, pageSize int, keyword, scopeType, action string) ([]*CommandRule, int64, error) {
+	return uc.ruleRepo.List(ctx, page, pageSize, keyword, scopeType, action)
+}
+
+// validateCommandRule 校验规则的范围和匹配模式
+func validateCommandRule(rule *CommandRule) error {
+	if _, ok := commandActionNames[rule.Action]; !ok {
+		return fmt.Errorf("不支持的动作: %s", rule.Action)
+	}
+	if _, ok := commandScopeWeights[rule.ScopeType]; !ok {
+		return fmt.Errorf("不支持的作用范围: %s", rule.ScopeType)
+	}
+	if rule.ScopeType == CommandScopeGlobal {
+		rule.ScopeID = 0
+	} else if rule.ScopeID == 0 {
+		return fmt.Errorf("作用范围为%s时必须指定作用对象", rule.ScopeType)
+	}
+
+	rule.Pattern = strings.TrimSpace(rule.Pattern)
+	if rule.MatchType == CommandMatchDynamic {
+		rule.Pattern = ""
+		return nil
+	}
+	if rule.Pattern == "" {
+		return fmt.Errorf("匹配模式不能为空")
+	}
+	switch rule.MatchType {
+	case CommandMatchCommand:
+		return validateGlobs(strings.Fields(rule.Pattern))
+	case CommandMatchRedirect:
+		return shellparse.ValidateGlob(rule.Pattern)
+	case CommandMatchPipeline:
+		stages := splitPipelinePattern(rule.Pattern)
+		if len(stages) < 2 {
+			return fmt.Errorf("管道模式至少需要两段，如 curl | sh")
+		}
+		for _, stage := range stages {
+			if len(stage) == 0 {
+				return fmt.Errorf("管道模式中存在空的命令段")
+			}
+			if err := validateGlobs(stage); err != nil {
+				return err
+			}
+		}
+		return nil
+	case CommandMatchRegex:
+		if _, err := regexp.Compile(rule.Pattern); err != nil {
+			return fmt.Errorf("无效的正则表达式: %w", err)
+		}
+		return nil
+	}
+	return fmt.Errorf("不支持的匹配方式: %s", rule.MatchType)
+}
+
+func validateGlobs(patterns []string) error {
+	for _, pattern := range patterns {
+		if err := shellparse.ValidateGlob(pattern); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// splitPipelinePattern 按管道符拆分管道模式，花括号内的逗号和竖线不拆分
+func splitPipelinePattern(pattern string) [][]string {
+	var stages [][]string
+	depth, start := 0, 0
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+		case '|':
+			if depth == 0 {
+				stages = append(stages, strings.Fields(pattern[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	return append(stages, strings.Fields(pattern[start:]))
+}
+
+// compiledRule 预处理后的规则
+type compiledRule struct {
+	rule   *CommandRule
+	tokens []string   // command 方式的模式单词
+	stages [][]string // pipeline 方式的各段模式
+	re     *regexp.Regexp
+}
+
+// Check 按命令策略检查脚本：逐条解析脚本中的命令，
+// 每条命令按优先级取第一条命中的规则，未命中任何规则时允许执行
+func (uc *CommandRuleUseCase) Check(ctx context.Context, req *CommandCheckRequest) (*CommandCheckResult, error) {
+	commands, err := shellparse.Parse(req.Content)
+	if err != nil {
+		return &CommandCheckResult{
+			Action:   CommandActionDeny,
+			Reason:   "命令解析失败: " + err.Error(),
+			Commands: []*CommandCheckItem{},
+		}, nil
+	}
+
+	rules, err := uc.applicableRules(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &CommandCheckResult{Action: CommandActionAllow, Commands: make([]*CommandCheckItem, 0, len(commands))}
+	var denied, approval *CommandCheckItem
+	for _, cmd := range commands {
+		item := &CommandCheckItem{Line: cmd.Line, Command: cmd.String(), Action: CommandActionAllow}
+		for _, r := range rules {
+			if !r.match(cmd, commands) {
+				continue
+			}
+			item.Action = r.rule.Action
+			item.RuleID = r.rule.ID
+			item.RuleName = r.rule.Name
+			item.MatchType = r.rule.MatchType
+			item.Pattern = r.rule.Pattern
+			item.ScopeType = r.rule.ScopeType
+			item.ScopeID = r.rule.ScopeID
+			break
+		}
+		switch {
+		case item.Action == CommandActionDeny && denied == nil:
+			denied = item
+		case item.Action == CommandActionApprove && approval == nil:
+			approval = item
+		}
+		result.Commands = append(result.Commands, item)
+	}
+
+	switch {
+	case denied != nil:
+		result.Action = CommandActionDeny
+		result.Reason = describeCheckItem(denied)
+	case approval != nil:
+		result.Action = CommandActionApprove
+		result.Reason = describeCheckItem(approval)
+	}
+	return result, nil
+}
+
+func describeCheckItem(item *CommandCheckItem) string {
+	return fmt.Sprintf("第%d行命令 %s 命中规则「%s」，%s", item.Line, item.Command, item.RuleName, commandActionNames[item.Action])
+}
+
+// applicableRules 返回对本次执行生效的启用规则，按匹配顺序排列
+func (uc *CommandRuleUseCase) applicableRules(ctx context.Context, req *CommandCheckRequest) ([]*compiledRule, error) {
+	rules, err := uc.ruleRepo.ListEnabled(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("查询命令规则失败: %w", err)
+	}
+
+	var roleIDs []uint
+	if req.UserID > 0 {
+		if roleIDs, err = uc.ruleRepo.GetUserRoleIDs(ctx, req.UserID); err != nil {
+			return nil, fmt.Errorf("查询用户角色失败: %w", err)
+		}
+	}
+	groupIDs, err := uc.HostGroupIDs(ctx, req.HostIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	var compiled []*compiledRule
+	for _, rule := range rules {
+		switch rule.ScopeType {
+		case CommandScopeRole:
+			if !slices.Contains(roleIDs, rule.ScopeID) {
+				continue
+			}
+		case CommandScopeAssetGroup:
+			if !slices.Contains(groupIDs, rule.ScopeID) {
+				continue
+			}
+		case CommandScopeTemplate:
+			if req.TemplateID == 0 || rule.ScopeID != req.TemplateID {
+				continue
+			}
+		}
+		r := &compiledRule{rule: rule}
+		switch rule.MatchType {
+		case CommandMatchCommand:
+			r.tokens = strings.Fields(rule.Pattern)
+		case CommandMatchPipeline:
+			r.stages = splitPipelinePattern(rule.Pattern)
+		case CommandMatchRegex:
+			if r.re, err = regexp.Compile(rule.Pattern); err != nil {
+				continue
+			}
+		}
+		compiled = append(compiled, r)
+	}
+
+	sort.SliceStable(compiled, func(i, j int) bool {
+		a, b := compiled[i].rule, compiled[j].rule
+		if a.Priority != b.Priority {
+			return a.Priority < b.Priority
+		}
+		if wa, wb := commandScopeWeights[a.ScopeType], commandScopeWeights[b.ScopeType]; wa != wb {
+			return wa > wb
+		}
+		return a.ID < b.ID
+	})
+	return compiled, nil
+}
+
+// HostGroupIDs 返回主机所属分组及其全部上级分组
+func (uc *CommandRuleUseCase) HostGroupIDs(ctx context.Context, hostIDs []uint) ([]uint, error) {
+	if len(hostIDs) == 0 {
+		return nil, nil
+	}
+	groups, err := uc.groupRepo.GetAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("查询资产分组失败: %w", err)
+	}
+	parents := make(map[uint]uint, len(groups))
+	for _, group := range groups {
+		parents[group.ID] = group.ParentID
+	}
+
+	var groupIDs []uint
+	for _, hostID := range hostIDs {
+		host, err := uc.hostRepo.GetByID(ctx, hostID)
+		if err != nil {
+			continue
+		}
+		for id := host.GroupID; id != 0 && !slices.Contains(groupIDs, id); id = parents[id] {
+			groupIDs = append(groupIDs, id)
+		}
+	}
+	return groupIDs, nil
+}
+
+// match 判断规则是否命中命令，commands 为同一脚本中的全部命令，用于管道匹配
+func (r *compiledRule) match(cmd shellparse.Command, commands []shellparse.Command) bool {
+	switch r.rule.MatchType {
+	case CommandMatchCommand:
+		return matchCommandPattern(r.tokens, cmd)
+	case CommandMatchRegex:
+		return r.re.MatchString(cmd.String())
+	case CommandMatchRedirect:
+		for _, target := range writeTargets(cmd) {
+			if matchPath(r.rule.Pattern, target) {
+				return true
+			}
+		}
+		return false
+	case CommandMatchPipeline:
+		return matchPipeline(r.stages, cmd, commands)
+	case CommandMatchDynamic:
+		return cmd.Dynamic
+	}
+	return false
+}
+
+// commandOptionAliases 各命令等价的选项写法，模式中的选项与其任一等价写法出现即匹配
+var commandOptionAliases = map[string][][]string{
+	"rm":        {{"-r", "-R", "--recursive"}, {"-f", "--force"}},
+	"cp":        {{"-r", "-R", "--recursive"}, {"-f", "--force"}},
+	"chmod":     {{"-R", "--recursive"}},
+	"chown":     {{"-R", "--recursive"}},
+	"chgrp":     {{"-R", "--recursive"}},
+	"base64":    {{"-d", "--decode"}},
+	"passwd":    {{"-d", "--delete"}},
+	"iptables":  {{"-F", "--flush"}},
+	"ip6tables": {{"-F", "--flush"}},
+}
+
+// matchCommandPattern 匹配 command 方式的模式。
+// 第一个单词匹配命令名（包含 / 时匹配命令路径），其余单词须全部出现在参数中且与顺序无关：
+// -abc 表示短选项 a、b、c 均出现（可合并书写），--name 匹配长选项（可带 =value），其他单词匹配位置参数；
+// 选项按 commandOptionAliases 匹配等价写法，如 rm 的 -r、-R、--recursive
+func matchCommandPattern(tokens []string, cmd shellparse.Command) bool {
+	if len(tokens) == 0 || cmd.Name == "" {
+		return false
+	}
+	name := cmd.Name
+	if strings.Contains(tokens[0], "/") {
+		name = cmd.Path
+	}
+	if !shellparse.MatchGlob(tokens[0], name) {
+		return false
+	}
+
+	shorts, longs, operands := splitCommandArgs(cmd.Args)
+	for _, token := range tokens[1:] {
+		var ok bool
+		switch {
+		case strings.HasPrefix(token, "--"):
+			ok = hasCommandOption(cmd.Name, token, shorts, longs)
+		case len(token) > 1 && token[0] == '-':
+			ok = slices.Contains(cmd.Args, token) || !strings.ContainsFunc(token[1:], func(c rune) bool {
+				return !hasCommandOption(cmd.Name, "-"+string(c), shorts, longs)
+			})
+		default:
+			ok = slices.ContainsFunc(operands, func(arg string) bool {
+				return matchPath(token, arg)
+			})
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// hasCommandOption 判断选项或其等价写法是否出现，option 为单个短选项 -x 或长选项 --name
+func hasCommandOption(name, option string, shorts string, longs []string) bool {
+	options := []string{option}
+	for _, group := range commandOptionAliases[name] {
+		if slices.Contains(group, option) {
+			options = group
+			break
+		}
+	}
+	for _, opt := range options {
+		if strings.HasPrefix(opt, "--") {
+			if slices.ContainsFunc(longs, func(arg string) bool {
+				argName, _, _ := strings.Cut(arg, "=")
+				return shellparse.MatchGlob(opt, arg) || shellparse.MatchGlob(opt, argName)
+			}) {
+				return true
+			}
+		} else if strings.ContainsRune(shorts, rune(opt[1])) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitCommandArgs 将参数拆分为短选项字母、长选项和位置参数，-- 之后均为位置参数
+func splitCommandArgs(args []string) (shorts string, longs, operands []string) {
+	for i, arg := range args {
+		switch {
+		case arg == "--":
+			return shorts, longs, append(operands, args[i+1:]...)
+		case strings.HasPrefix(arg, "--"):
+			longs = append(longs, arg)
+		case len(arg) > 1 && arg[0] == '-':
+			shorts += arg[1:]
+		default:
+			operands = append(operands, arg)
+		}
+	}
+	return shorts, longs, operands
+}
+
+// matchPath 匹配参数或路径，绝对路径同时匹配规范化后的形式（如 //、/. 视为 /）
+func matchPath(pattern, value string) bool {
+	if shellparse.MatchGlob(pattern, value) {
+		return true
+	}
+	return strings.HasPrefix(value, "/") && shellparse.MatchGlob(pattern, path.Clean(value))
+}
+
+// writeTargets 返回命令写入的文件：输出重定向目标以及 tee 的文件参数
+func writeTargets(cmd shellparse.Command) []string {
+	var targets []string
+	for _, redirect := range cmd.Redirects {
+		if redirect.Writes() {
+			targets = append(targets, redirect.Target)
+		}
+	}
+	if cmd.Name == "tee" {
+		_, _, operands := splitCommandArgs(cmd.Args)
+		targets = append(targets, operands...)
+	}
+	return targets
+}
+
+// matchPipeline 当命令匹配管道模式的最后一段，且同一管道中前面相邻的各段命令依次匹配时命中
+func matchPipeline(stages [][]string, cmd shellparse.Command, commands []shellparse.Command) bool {
+	last := len(stages) - 1
+	if cmd.Stage < last || !matchCommandPattern(stages[last], cmd) {
+		return false
+	}
+	for k := 1; k <= last; k++ {
+		matched := slices.ContainsFunc(commands, func(other shellparse.Command) bool {
+			return other.Pipeline == cmd.Pipeline && other.Stage == cmd.Stage-k && matchCommandPattern(stages[last-k], other)
+		})
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// InitDefaultRules 规则表为空时初始化默认规则
+func (uc *CommandRuleUseCase) InitDefaultRules(ctx context.Context) error {
+	return uc.ruleRepo.InitDefaultRules(ctx)
+}
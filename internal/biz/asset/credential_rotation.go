@@ -0,0 +1,860 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package asset
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ydcloud-dy/opshub/pkg/kms"
+	appLogger "github.com/ydcloud-dy/opshub/pkg/logger"
+	sshclient "github.com/ydcloud-dy/opshub/pkg/ssh"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/ssh"
+)
+
+// 凭证轮换状态
+const (
+	RotationStatusRunning        = "running"         // 执行中
+	RotationStatusSuccess        = "success"         // 所有主机已更新，新密钥已保存
+	RotationStatusFailed         = "failed"          // 部分主机失败，已全部回滚，凭证保持不变
+	RotationStatusRollbackFailed = "rollback_failed" // 部分主机回滚失败，这些主机仍在使用新密钥，需要重试回滚
+)
+
+// 凭证轮换中单台主机的状态
+const (
+	RotationHostPending        = "pending"         // 等待执行
+	RotationHostUpdated        = "updated"         // 新密钥已写入主机，尚未验证
+	RotationHostVerified       = "verified"        // 已使用新密钥登录验证
+	RotationHostSuccess        = "success"         // 轮换完成
+	RotationHostFailed         = "failed"          // 写入或验证失败，主机保持原密钥
+	RotationHostRolledBack     = "rolled_back"     // 其他主机失败，已恢复原密钥
+	RotationHostRollbackFailed = "rollback_failed" // 恢复原密钥失败
+	RotationHostCleanupFailed  = "cleanup_failed"  // 轮换完成，但旧公钥未能从主机移除
+)
+
+// 凭证轮换触发方式
+const (
+	RotationTriggerManual   = "manual"
+	RotationTriggerSchedule = "schedule"
+)
+
+// CredentialRotation 凭证轮换记录
+type CredentialRotation struct {
+	ID             uint                      `gorm:"primarykey" json:"id"`
+	CredentialID   uint                      `gorm:"column:credential_id;not null;index;comment:凭证ID" json:"credentialId"`
+	CredentialName string                    `gorm:"type:varchar(100);comment:凭证名称" json:"credentialName"`
+	CredentialType string                    `gorm:"type:varchar(20);comment:认证方式 password/key" json:"credentialType"`
+	Trigger        string                    `gorm:"type:varchar(20);comment:触发方式 manual/schedule" json:"trigger"`
+	Status         string                    `gorm:"type:varchar(20);index;comment:状态" json:"status"`
+	TotalHosts     int                       `gorm:"comment:主机数" json:"totalHosts"`
+	SuccessHosts   int                       `gorm:"comment:成功主机数" json:"successHosts"`
+	FailedHosts    int                       `gorm:"comment:失败主机数" json:"failedHosts"`
+	Message        string                    `gorm:"type:varchar(500);comment:结果说明" json:"message"`
+	PendingSecret  string                    `gorm:"type:text;comment:尚未保存到凭证的新密钥(加密)" json:"-"`
+	OperatorID     uint                      `gorm:"comment:操作人ID" json:"operatorId"`
+	OperatorName   string                    `gorm:"type:varchar(50);comment:操作人" json:"operatorName"`
+	StartedAt      time.Time                 `json:"startedAt"`
+	FinishedAt     *time.Time                `json:"finishedAt"`
+	Hosts          []*CredentialRotationHost `gorm:"-" json:"hosts,omitempty"`
+}
+
+// TableName 表名
+func (CredentialRotation) TableName() string {
+	return "credential_rotations"
+}
+
+// CredentialRotationHost 凭证轮换中单台主机的执行结果
+type CredentialRotationHost struct {
+	ID         uint      `gorm:"primarykey" json:"id"`
+	RotationID uint      `gorm:"column:rotation_id;not null;index;comment:轮换记录ID" json:"rotationId"`
+	HostID     uint      `gorm:"column:host_id;comment:主机ID" json:"hostId"`
+	HostName   string    `gorm:"type:varchar(100);comment:主机名称" json:"hostName"`
+	HostIP     string    `gorm:"type:varchar(50);comment:主机IP" json:"hostIp"`
+	SSHUser    string    `gorm:"type:varchar(50);comment:SSH用户" json:"sshUser"`
+	Status     string    `gorm:"type:varchar(20);comment:状态" json:"status"`
+	Error      string    `gorm:"type:varchar(500);comment:失败原因" json:"error"`
+	DurationMs int64     `gorm:"comment:耗时(毫秒)" json:"durationMs"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
+
+// TableName 表名
+func (CredentialRotationHost) TableName() string {
+	return "credential_rotation_hosts"
+}
+
+// CredentialRotationConfig 凭证轮换配置，零值使用默认值
+type CredentialRotationConfig struct {
+	Schedule       bool          // 是否按凭证配置的周期自动轮换
+	CheckInterval  time.Duration // 检查到期凭证和中断轮换的间隔
+	Concurrency    int           // 同时更新的主机数
+	CommandTimeout time.Duration // 单条命令超时
+	RetryAfter     time.Duration // 定时轮换失败后多久再次尝试
+}
+
+func (c CredentialRotationConfig) withDefaults() CredentialRotationConfig {
+	if c.CheckInterval < time.Minute {
+		c.CheckInterval = time.Hour
+	}
+	if c.Concurrency <= 0 {
+		c.Concurrency = 5
+	}
+	if c.CommandTimeout <= 0 {
+		c.CommandTimeout = 30 * time.Second
+	}
+	if c.RetryAfter <= 0 {
+		c.RetryAfter = 24 * time.Hour
+	}
+	return c
+}
+
+// rotationTimeout 单次轮换的最长执行时间，超过后未结束的记录视为中断
+const rotationTimeout = time.Hour
+
+// 修改主机上的密码和 authorized_keys，敏感内容都通过标准输入传入
+const (
+	chpasswdCommand            = "chpasswd"
+	passwdCommand              = "LC_ALL=C passwd"
+	addAuthorizedKeyCommand    = `sh -c 'read -r key; [ -n "$key" ] || exit 1; umask 077; mkdir -p "$HOME/.ssh" && touch "$HOME/.ssh/authorized_keys" && chmod 700 "$HOME/.ssh" && chmod 600 "$HOME/.ssh/authorized_keys" || exit 1; grep -qF "$key" "$HOME/.ssh/authorized_keys" || printf "%s\n" "$key" >> "$HOME/.ssh/authorized_keys"'`
+	removeAuthorizedKeyCommand = `sh -c 'read -r blob; f="$HOME/.ssh/authorized_keys"; [ -n "$blob" ] && [ -f "$f" ] || exit 0; tmp=$(mktemp "$f.XXXXXX") || exit 1; grep -vF "$blob" "$f" > "$tmp"; chmod 600 "$tmp" && mv -f "$tmp" "$f"'`
+)
+
+// CredentialRotationUseCase 凭证轮换：生成新密码或密钥对，推送到所有使用该凭证的主机并验证登录，
+// 全部成功后保存到凭证，任一主机失败则将已更新的主机恢复为原密钥
+type CredentialRotationUseCase struct {
+	rotationRepo   CredentialRotationRepo
+	credentialRepo CredentialRepo
+	hostRepo       HostRepo
+	hostUseCase    *HostUseCase
+	config         CredentialRotationConfig
+	startOnce      sync.Once
+
+	mu      sync.Mutex
+	running map[uint]bool // 正在轮换的凭证
+}
+
+func NewCredentialRotationUseCase(rotationRepo CredentialRotationRepo, credentialRepo CredentialRepo, hostRepo HostRepo, hostUseCase *HostUseCase, config CredentialRotationConfig) *CredentialRotationUseCase {
+	return &CredentialRotationUseCase{
+		rotationRepo:   rotationRepo,
+		credentialRepo: credentialRepo,
+		hostRepo:       hostRepo,
+		hostUseCase:    hostUseCase,
+		config:         config.withDefaults(),
+		running:        make(map[uint]bool),
+	}
+}
+
+// Start 定期恢复中断的轮换，开启自动轮换时同时轮换到期的凭证，重复调用只会启动一次
+func (uc *CredentialRotationUseCase) Start() {
+	uc.startOnce.Do(func() {
+		go func() {
+			ticker := time.NewTicker(uc.config.CheckInterval)
+			defer ticker.Stop()
+			for now := range ticker.C {
+				uc.RecoverInterrupted(context.Background(), now)
+				if uc.config.Schedule {
+					uc.RotateDue(context.Background(), now)
+				}
+			}
+		}()
+	})
+}
+
+// rotationHost 执行中的主机，连接使用轮换前的凭证建立，回滚时优先复用
+type rotationHost struct {
+	host   *Host
+	record *CredentialRotationHost
+	client *sshclient.Client
+}
+
+// rotationSecret 一次轮换生成的新密钥
+type rotationSecret struct {
+	credential *Credential // 使用新密钥的凭证副本
+	publicKey  string      // 密钥认证时新公钥的 authorized_keys 行
+}
+
+// Rotate 开始轮换凭证，立即返回轮换记录，轮换在后台执行
+func (uc *CredentialRotationUseCase) Rotate(ctx context.Context, credentialID uint, trigger string, operatorID uint, operatorName string) (*CredentialRotation, error) {
+	credential, err := uc.credentialRepo.GetByID(ctx, credentialID)
+	if err != nil {
+		return nil, fmt.Errorf("凭证不存在")
+	}
+	if credential.IsExternal() {
+		return nil, fmt.Errorf("凭证引用外部密钥存储，请在 %s 中轮换", credential.Source)
+	}
+	if credential.IsCertificate() {
+		return nil, fmt.Errorf("证书凭证每次连接都会签发新的短期证书，无需轮换")
+	}
+	credential, err = uc.credentialRepo.GetByIDDecrypted(ctx, credentialID)
+	if err != nil {
+		return nil, fmt.Errorf("获取凭证失败: %w", err)
+	}
+	if credential.Type == "password" && credential.Password == "" {
+		return nil, fmt.Errorf("凭证未设置密码")
+	}
+	if credential.Type == "key" && credential.PrivateKey == "" {
+		return nil, fmt.Errorf("凭证未设置私钥")
+	}
+
+	hosts, err := uc.hostRepo.ListByCredentialID(ctx, credentialID)
+	if err != nil {
+		return nil, fmt.Errorf("获取使用该凭证的主机失败: %w", err)
+	}
+
+	uc.mu.Lock()
+	if uc.running[credentialID] {
+		uc.mu.Unlock()
+		return nil, fmt.Errorf("该凭证正在轮换中")
+	}
+	uc.running[credentialID] = true
+	uc.mu.Unlock()
+
+	secret, err := newRotationSecret(credential)
+	if err != nil {
+		uc.finishRunning(credentialID)
+		return nil, err
+	}
+	pending, err := kms.Encrypt(secret.value())
+	if err != nil {
+		uc.finishRunning(credentialID)
+		return nil, fmt.Errorf("加密新密钥失败: %w", err)
+	}
+
+	rotation := &CredentialRotation{
+		CredentialID:   credential.ID,
+		CredentialName: credential.Name,
+		CredentialType: credential.Type,
+		Trigger:        trigger,
+		Status:         RotationStatusRunning,
+		TotalHosts:     len(hosts),
+		PendingSecret:  pending,
+		OperatorID:     operatorID,
+		OperatorName:   operatorName,
+		StartedAt:      time.Now(),
+	}
+	if err := uc.rotationRepo.Create(ctx, rotation); err != nil {
+		uc.finishRunning(credentialID)
+		return nil, fmt.Errorf("创建轮换记录失败: %w", err)
+	}
+
+	states := make([]*rotationHost, 0, len(hosts))
+	for _, host := range orderForRotation(hosts) {
+		record := &CredentialRotationHost{
+			RotationID: rotation.ID,
+			HostID:     host.ID,
+			HostName:   host.Name,
+			HostIP:     host.IP,
+			SSHUser:    host.SSHUser,
+			Status:     RotationHostPending,
+		}
+		if err := uc.rotationRepo.SaveHost(ctx, record); err != nil {
+			uc.finishRunning(credentialID)
+			return nil, fmt.Errorf("创建轮换记录失败: %w", err)
+		}
+		states = append(states, &rotationHost{host: host, record: record})
+		rotation.Hosts = append(rotation.Hosts, record)
+	}
+
+	// 返回创建时的快照，后台执行时会继续修改记录
+	snapshot := *rotation
+	snapshot.Hosts = make([]*CredentialRotationHost, len(rotation.Hosts))
+	for i, record := range rotation.Hosts {
+		copied := *record
+		snapshot.Hosts[i] = &copied
+	}
+
+	go func() {
+		defer uc.finishRunning(credentialID)
+		runCtx, cancel := context.WithTimeout(context.Background(), rotationTimeout)
+		defer cancel()
+		uc.run(runCtx, rotation, credential, secret, states)
+	}()
+
+	return &snapshot, nil
+}
+
+func (uc *CredentialRotationUseCase) finishRunning(credentialID uint) {
+	uc.mu.Lock()
+	delete(uc.running, credentialID)
+	uc.mu.Unlock()
+}
+
+// run 依次推送、验证、保存，失败时回滚
+func (uc *CredentialRotationUseCase) run(ctx context.Context, rotation *CredentialRotation, old *Credential, secret *rotationSecret, states []*rotationHost) {
+	defer func() {
+		for _, state := range states {
+			if state.client != nil {
+				state.client.Close()
+			}
+		}
+	}()
+
+	// 作为其他主机跳板机的主机排在最后，保证其他主机更新期间仍能用原凭证经过跳板机
+	jumpIndex := len(states)
+	for i, state := range states {
+		if isRotationJumpHost(state.host, states) {
+			jumpIndex = i
+			break
+		}
+	}
+	uc.forEach(states[:jumpIndex], func(state *rotationHost) { uc.applyHost(ctx, state, old, secret) })
+	if !hasFailedHost(states[:jumpIndex]) {
+		uc.forEach(states[jumpIndex:], func(state *rotationHost) { uc.applyHost(ctx, state, old, secret) })
+	}
+
+	if hasFailedHost(states) {
+		uc.rollback(ctx, rotation, old, secret.credential, states)
+		return
+	}
+
+	// 所有主机验证通过，保存新密钥
+	now := time.Now()
+	updated := *secret.credential
+	updated.RotatedAt = &now
+	if err := uc.credentialRepo.Update(ctx, &updated); err != nil {
+		appLogger.Error("保存轮换后的凭证失败，开始回滚", zap.Uint("credentialId", old.ID), zap.Error(err))
+		rotation.Message = "保存新密钥失败: " + err.Error()
+		uc.rollback(ctx, rotation, old, secret.credential, states)
+		return
+	}
+	InvalidateCredentialSecret(old.ID)
+
+	rotation.PendingSecret = ""
+	for _, state := range states {
+		state.record.Status = RotationHostSuccess
+		// 密钥认证在新公钥生效后移除旧公钥，失败不影响轮换结果
+		if old.Type == "key" {
+			if err := uc.removeOldKey(ctx, state, old, secret.credential); err != nil {
+				state.record.Status = RotationHostCleanupFailed
+				state.record.Error = limitMessage("移除旧公钥失败: " + err.Error())
+			}
+		}
+		uc.saveHost(state.record)
+	}
+	uc.finish(rotation, RotationStatusSuccess, "")
+}
+
+// forEach 按并发限制对主机执行操作
+func (uc *CredentialRotationUseCase) forEach(states []*rotationHost, fn func(state *rotationHost)) {
+	sem := make(chan struct{}, uc.config.Concurrency)
+	var wg sync.WaitGroup
+	for _, state := range states {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(state *rotationHost) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			fn(state)
+		}(state)
+	}
+	wg.Wait()
+}
+
+// applyHost 使用原凭证连接主机写入新密钥，再使用新密钥重新登录验证，验证失败时立即恢复该主机
+func (uc *CredentialRotationUseCase) applyHost(ctx context.Context, state *rotationHost, old *Credential, secret *rotationSecret) {
+	start := time.Now()
+	defer func() {
+		state.record.DurationMs = time.Since(start).Milliseconds()
+		uc.saveHost(state.record)
+	}()
+
+	client, err := uc.hostUseCase.dialHost(ctx, state.host, old)
+	if err != nil {
+		uc.failHost(state, "使用原凭证连接失败: ", err)
+		return
+	}
+	state.client = client
+
+	if err := uc.pushSecret(client, state.host, old, secret); err != nil {
+		uc.failHost(state, "写入新密钥失败: ", err)
+		return
+	}
+	state.record.Status = RotationHostUpdated
+	uc.saveHost(state.record)
+
+	verify, err := uc.hostUseCase.dialHost(ctx, state.host, secret.credential)
+	if err == nil {
+		_, err = verify.ExecuteWithTimeout("echo ok", uc.config.CommandTimeout)
+		verify.Close()
+	}
+	if err != nil {
+		uc.failHost(state, "使用新密钥登录验证失败: ", err)
+		if revertErr := uc.revertHost(ctx, state, old, secret.credential); revertErr != nil {
+			state.record.Status = RotationHostRollbackFailed
+			state.record.Error = limitMessage(state.record.Error + "; 恢复原密钥失败: " + revertErr.Error())
+		}
+		return
+	}
+	state.record.Status = RotationHostVerified
+}
+
+func (uc *CredentialRotationUseCase) failHost(state *rotationHost, prefix string, err error) {
+	state.record.Status = RotationHostFailed
+	state.record.Error = limitMessage(prefix + err.Error())
+}
+
+// pushSecret 在主机上设置新密码或追加新公钥
+func (uc *CredentialRotationUseCase) pushSecret(client *sshclient.Client, host *Host, old *Credential, secret *rotationSecret) error {
+	if old.Type == "key" {
+		_, err := client.ExecuteWithInput(addAuthorizedKeyCommand, []byte(secret.publicKey+"\n"), uc.config.CommandTimeout)
+		return err
+	}
+	return uc.changePassword(client, host.SSHUser, old.Password, secret.credential.Password)
+}
+
+// changePassword root 用户通过 chpasswd 修改，其他用户通过 passwd 修改自己的密码
+func (uc *CredentialRotationUseCase) changePassword(client *sshclient.Client, user, current, password string) error {
+	if user == "root" {
+		_, err := client.ExecuteWithInput(chpasswdCommand, []byte(user+":"+password+"\n"), uc.config.CommandTimeout)
+		return err
+	}
+	_, err := client.ExecuteWithInput(passwdCommand, []byte(current+"\n"+password+"\n"+password+"\n"), uc.config.CommandTimeout)
+	return err
+}
+
+// revertHost 将主机恢复为原密钥：密码认证改回原密码，密钥认证移除新公钥
+func (uc *CredentialRotationUseCase) revertHost(ctx context.Context, state *rotationHost, old, rotated *Credential) error {
+	revert := func(client *sshclient.Client) error {
+		if old.Type == "key" {
+			blob, err := publicKeyBlob(rotated)
+			if err != nil {
+				return err
+			}
+			_, err = client.ExecuteWithInput(removeAuthorizedKeyCommand, []byte(blob+"\n"), uc.config.CommandTimeout)
+			return err
+		}
+		return uc.changePassword(client, state.host.SSHUser, rotated.Password, old.Password)
+	}
+
+	// 优先复用轮换前建立的连接，连接已断开时重新连接：密码已改为新密码，密钥认证原公钥仍然有效
+	if state.client != nil {
+		if err := revert(state.client); err == nil {
+			return nil
+		}
+	}
+	reconnect := rotated
+	if old.Type == "key" {
+		reconnect = old
+	}
+	client, err := uc.hostUseCase.dialHost(ctx, state.host, reconnect)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	return revert(client)
+}
+
+// removeOldKey 使用新密钥连接主机并移除旧公钥
+func (uc *CredentialRotationUseCase) removeOldKey(ctx context.Context, state *rotationHost, old, rotated *Credential) error {
+	blob, err := publicKeyBlob(old)
+	if err != nil {
+		return err
+	}
+	client, err := uc.hostUseCase.dialHost(ctx, state.host, rotated)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	_, err = client.ExecuteWithInput(removeAuthorizedKeyCommand, []byte(blob+"\n"), uc.config.CommandTimeout)
+	return err
+}
+
+// rollback 将已写入新密钥的主机恢复为原密钥，新密钥只在有主机回滚失败时保留
+func (uc *CredentialRotationUseCase) rollback(ctx context.Context, rotation *CredentialRotation, old, rotated *Credential, states []*rotationHost) {
+	for i := len(states) - 1; i >= 0; i-- {
+		state := states[i]
+		if state.record.Status != RotationHostUpdated && state.record.Status != RotationHostVerified &&
+			state.record.Status != RotationHostRollbackFailed {
+			continue
+		}
+		if err := uc.revertHost(ctx, state, old, rotated); err != nil {
+			state.record.Status = RotationHostRollbackFailed
+			state.record.Error = limitMessage("恢复原密钥失败: " + err.Error())
+		} else {
+			state.record.Status = RotationHostRolledBack
+			state.record.Error = ""
+		}
+		uc.saveHost(state.record)
+	}
+
+	status := RotationStatusFailed
+	message := rotation.Message
+	if message == "" {
+		message = "部分主机更新失败，已恢复原密钥"
+	}
+	for _, state := range states {
+		if state.record.Status == RotationHostRollbackFailed {
+			status = RotationStatusRollbackFailed
+			message = "部分主机恢复原密钥失败，这些主机仍在使用新密钥，请检查后重试回滚"
+			break
+		}
+	}
+	if status == RotationStatusFailed {
+		rotation.PendingSecret = ""
+	}
+	uc.finish(rotation, status, message)
+}
+
+// RetryRollback 重试回滚失败的轮换
+func (uc *CredentialRotationUseCase) RetryRollback(ctx context.Context, rotationID uint) (*CredentialRotation, error) {
+	rotation, err := uc.rotationRepo.GetByID(ctx, rotationID)
+	if err != nil {
+		return nil, fmt.Errorf("轮换记录不存在")
+	}
+	if rotation.Status != RotationStatusRollbackFailed {
+		return nil, fmt.Errorf("只有回滚失败的轮换可以重试回滚")
+	}
+
+	uc.mu.Lock()
+	if uc.running[rotation.CredentialID] {
+		uc.mu.Unlock()
+		return nil, fmt.Errorf("该凭证正在轮换中")
+	}
+	uc.running[rotation.CredentialID] = true
+	uc.mu.Unlock()
+	defer uc.finishRunning(rotation.CredentialID)
+
+	if err := uc.recover(ctx, rotation, "重试回滚成功，已恢复原密钥"); err != nil {
+		return nil, err
+	}
+	return uc.rotationRepo.GetByID(ctx, rotationID)
+}
+
+// RecoverInterrupted 处理因服务重启等原因超时未结束的轮换：
+// 新密钥已保存到凭证时补记为成功，否则将已更新的主机恢复为原密钥
+func (uc *CredentialRotationUseCase) RecoverInterrupted(ctx context.Context, now time.Time) {
+	rotations, err := uc.rotationRepo.ListRunning(ctx)
+	if err != nil {
+		appLogger.Error("获取执行中的凭证轮换失败", zap.Error(err))
+		return
+	}
+	for _, rotation := range rotations {
+		if now.Sub(rotation.StartedAt) < rotationTimeout+time.Minute {
+			continue
+		}
+		uc.mu.Lock()
+		busy := uc.running[rotation.CredentialID]
+		uc.mu.Unlock()
+		if busy {
+			continue
+		}
+		full, err := uc.rotationRepo.GetByID(ctx, rotation.ID)
+		if err != nil {
+			continue
+		}
+		if err := uc.recover(ctx, full, "轮换中断，已恢复原密钥"); err != nil {
+			appLogger.Error("恢复中断的凭证轮换失败", zap.Uint("rotationId", rotation.ID), zap.Error(err))
+		}
+	}
+}
+
+// recover 根据轮换记录中保留的新密钥恢复主机，message 为全部恢复成功时的结果说明
+func (uc *CredentialRotationUseCase) recover(ctx context.Context, rotation *CredentialRotation, message string) error {
+	if rotation.PendingSecret == "" {
+		uc.finish(rotation, RotationStatusFailed, "轮换中断，未保留新密钥，无法回滚")
+		return nil
+	}
+	pending, err := kms.Decrypt(rotation.PendingSecret)
+	if err != nil {
+		return fmt.Errorf("解密新密钥失败: %w", err)
+	}
+	old, err := uc.credentialRepo.GetByIDDecrypted(ctx, rotation.CredentialID)
+	if err != nil {
+		return fmt.Errorf("获取凭证失败: %w", err)
+	}
+
+	rotated := *old
+	if old.Type == "key" {
+		rotated.PrivateKey = pending
+	} else {
+		rotated.Password = pending
+	}
+	// 凭证中已经是新密钥，说明中断发生在保存之后
+	if (old.Type == "key" && old.PrivateKey == pending) || (old.Type != "key" && old.Password == pending) {
+		rotation.PendingSecret = ""
+		for _, record := range rotation.Hosts {
+			if record.Status == RotationHostUpdated || record.Status == RotationHostVerified {
+				record.Status = RotationHostSuccess
+				uc.saveHost(record)
+			}
+		}
+		uc.finish(rotation, RotationStatusSuccess, "轮换中断，新密钥已保存，旧公钥可能未移除")
+		return nil
+	}
+
+	states := make([]*rotationHost, 0, len(rotation.Hosts))
+	for _, record := range rotation.Hosts {
+		host, err := uc.hostRepo.GetByID(ctx, record.HostID)
+		if err != nil {
+			host = &Host{Name: record.HostName, IP: record.HostIP, SSHUser: record.SSHUser}
+			host.ID = record.HostID
+		}
+		states = append(states, &rotationHost{host: host, record: record})
+	}
+	rotation.Message = message
+	uc.rollback(ctx, rotation, old, &rotated, states)
+	return nil
+}
+
+// RotateDue 轮换所有已到期的凭证，定时轮换失败后间隔 RetryAfter 再次尝试
+func (uc *CredentialRotationUseCase) RotateDue(ctx context.Context, now time.Time) {
+	credentials, err := uc.credentialRepo.ListRotationEnabled(ctx)
+	if err != nil {
+		appLogger.Error("获取启用轮换的凭证失败", zap.Error(err))
+		return
+	}
+	for _, credential := range credentials {
+		if credential.IsExternal() || !credential.RotationDue(now) {
+			continue
+		}
+		last, err := uc.rotationRepo.Last(ctx, credential.ID)
+		if err != nil {
+			continue
+		}
+		if last != nil && (last.Status == RotationStatusRunning || last.Status == RotationStatusRollbackFailed ||
+			(last.Status == RotationStatusFailed && now.Sub(last.StartedAt) < uc.config.RetryAfter)) {
+			continue
+		}
+		if _, err := uc.Rotate(ctx, credential.ID, RotationTriggerSchedule, 0, "system"); err != nil {
+			appLogger.Warn("定时轮换凭证失败", zap.Uint("credentialId", credential.ID), zap.Error(err))
+		}
+	}
+}
+
+// RotationDue 是否已到自动轮换时间，从未轮换过的凭证从创建时间开始计算
+func (c *Credential) RotationDue(now time.Time) bool {
+	if c.RotationDays <= 0 {
+		return false
+	}
+	base := c.CreatedAt
+	if c.RotatedAt != nil {
+		base = *c.RotatedAt
+	}
+	return !now.Before(base.AddDate(0, 0, c.RotationDays))
+}
+
+// GetRotation 获取轮换记录及各主机结果
+func (uc *CredentialRotationUseCase) GetRotation(ctx context.Context, id uint) (*CredentialRotation, error) {
+	return uc.rotationRepo.GetByID(ctx, id)
+}
+
+// ListRotations 分页查询凭证的轮换记录
+func (uc *CredentialRotationUseCase) ListRotations(ctx context.Context, credentialID uint, page, pageSize int) ([]*CredentialRotation, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+	return uc.rotationRepo.List(ctx, credentialID, page, pageSize)
+}
+
+func (uc *CredentialRotationUseCase) saveHost(record *CredentialRotationHost) {
+	if err := uc.rotationRepo.SaveHost(context.Background(), record); err != nil {
+		appLogger.Error("保存凭证轮换主机结果失败", zap.Uint("rotationId", record.RotationID), zap.Error(err))
+	}
+}
+
+// finish 统计主机结果并结束轮换
+func (uc *CredentialRotationUseCase) finish(rotation *CredentialRotation, status, message string) {
+	now := time.Now()
+	rotation.Status = status
+	rotation.Message = limitMessage(message)
+	rotation.FinishedAt = &now
+	rotation.SuccessHosts, rotation.FailedHosts = 0, 0
+	for _, record := range rotation.Hosts {
+		switch record.Status {
+		case RotationHostSuccess, RotationHostCleanupFailed:
+			rotation.SuccessHosts++
+		case RotationHostFailed, RotationHostRollbackFailed:
+			rotation.FailedHosts++
+		}
+	}
+	if err := uc.rotationRepo.Update(context.Background(), rotation); err != nil {
+		appLogger.Error("保存凭证轮换结果失败", zap.Uint("rotationId", rotation.ID), zap.Error(err))
+	}
+	appLogger.Info("凭证轮换结束",
+		zap.Uint("credentialId", rotation.CredentialID),
+		zap.String("status", status),
+		zap.Int("successHosts", rotation.SuccessHosts),
+		zap.Int("failedHosts", rotation.FailedHosts))
+}
+
+// dialHost 建立不经过连接池的SSH连接，确保使用指定凭证重新认证
+func (uc *HostUseCase) dialHost(ctx context.Context, host *Host, credential *Credential) (*sshclient.Client, error) {
+	dial, err := uc.sshDialFunc(ctx, host, credential)
+	if err != nil {
+		return nil, err
+	}
+	client, err := dial()
+	if err != nil {
+		return nil, err
+	}
+	return sshclient.WrapClient(client), nil
+}
+
+// newRotationSecret 生成新密码或新密钥对，新私钥沿用原私钥密码
+func newRotationSecret(credential *Credential) (*rotationSecret, error) {
+	rotated := *credential
+	secret := &rotationSecret{credential: &rotated}
+	if credential.Type != "key" {
+		password, err := generatePassword(24)
+		if err != nil {
+			return nil, fmt.Errorf("生成密码失败: %w", err)
+		}
+		rotated.Password = password
+		return secret, nil
+	}
+
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("生成密钥对失败: %w", err)
+	}
+	comment := fmt.Sprintf("opshub-%s-%s", strings.ReplaceAll(credential.Name, " ", "_"), time.Now().Format("20060102"))
+	var block *pem.Block
+	if credential.Passphrase != "" {
+		block, err = ssh.MarshalPrivateKeyWithPassphrase(privateKey, comment, []byte(credential.Passphrase))
+	} else {
+		block, err = ssh.MarshalPrivateKey(privateKey, comment)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("编码私钥失败: %w", err)
+	}
+	sshPublicKey, err := ssh.NewPublicKey(publicKey)
+	if err != nil {
+		return nil, fmt.Errorf("编码公钥失败: %w", err)
+	}
+	rotated.PrivateKey = string(pem.EncodeToMemory(block))
+	secret.publicKey = strings.TrimSpace(string(ssh.MarshalAuthorizedKey(sshPublicKey))) + " " + comment
+	return secret, nil
+}
+
+// value 保存在轮换记录中的新密钥
+func (s *rotationSecret) value() string {
+	if s.credential.Type == "key" {
+		return s.credential.PrivateKey
+	}
+	return s.credential.Password
+}
+
+// publicKeyBlob 私钥对应公钥的 base64 部分，用于在 authorized_keys 中定位
+func publicKeyBlob(credential *Credential) (string, error) {
+	var signer ssh.Signer
+	var err error
+	if credential.Passphrase != "" {
+		signer, err = ssh.ParsePrivateKeyWithPassphrase([]byte(credential.PrivateKey), []byte(credential.Passphrase))
+	} else {
+		signer, err = ssh.ParsePrivateKey([]byte(credential.PrivateKey))
+	}
+	if err != nil {
+		return "", fmt.Errorf("解析私钥失败: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(signer.PublicKey().Marshal()), nil
+}
+
+const (
+	passwordLower  = "abcdefghijkmnpqrstuvwxyz"
+	passwordUpper  = "ABCDEFGHJKLMNPQRSTUVWXYZ"
+	passwordDigit  = "23456789"
+	passwordSymbol = "!@#%^&*-_=+"
+)
+
+// generatePassword 生成包含大小写字母、数字和符号的随机密码，不含冒号、引号和空白
+func generatePassword(length int) (string, error) {
+	classes := []string{passwordLower, passwordUpper, passwordDigit, passwordSymbol}
+	all := strings.Join(classes, "")
+	password := make([]byte, length)
+	for i := range password {
+		charset := all
+		if i < len(classes) {
+			charset = classes[i]
+		}
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(charset))))
+		if err != nil {
+			return "", err
+		}
+		password[i] = charset[n.Int64()]
+	}
+	// 打乱顺序，避免前几位固定为各类字符
+	for i := len(password) - 1; i > 0; i-- {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(i+1)))
+		if err != nil {
+			return "", err
+		}
+		j := n.Int64()
+		password[i], password[j] = password[j], password[i]
+	}
+	return string(password), nil
+}
+
+// orderForRotation 作为其他主机跳板机的主机排在最后
+func orderForRotation(hosts []*Host) []*Host {
+	jumps := make(map[uint]bool)
+	for _, host := range hosts {
+		if host.JumpHostID > 0 {
+			jumps[host.JumpHostID] = true
+		}
+	}
+	ordered := make([]*Host, 0, len(hosts))
+	var last []*Host
+	for _, host := range hosts {
+		if jumps[host.ID] {
+			last = append(last, host)
+		} else {
+			ordered = append(ordered, host)
+		}
+	}
+	return append(ordered, last...)
+}
+
+func isRotationJumpHost(host *Host, states []*rotationHost) bool {
+	for _, state := range states {
+		if state.host.JumpHostID == host.ID {
+			return true
+		}
+	}
+	return false
+}
+
+func hasFailedHost(states []*rotationHost) bool {
+	for _, state := range states {
+		if state.record.Status == RotationHostFailed || state.record.Status == RotationHostRollbackFailed {
+			return true
+		}
+	}
+	return false
+}
+
+func limitMessage(message string) string {
+	if len(message) > 500 {
+		return strings.ToValidUTF8(message[:500], "")
+	}
+	return message
+}
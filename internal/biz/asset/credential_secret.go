@@ -0,0 +1,227 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package asset
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	appLogger "github.com/ydcloud-dy/opshub/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// 凭证来源
+const (
+	CredentialSourceLocal = "local" // 敏感字段加密存储在数据库
+	CredentialSourceVault = "vault" // 敏感字段存放在 Vault KV v2，连接时读取
+)
+
+// 外部密钥中的字段名
+const (
+	SecretFieldUsername   = "username"
+	SecretFieldPassword   = "password"
+	SecretFieldPrivateKey = "private_key"
+	SecretFieldPassphrase = "passphrase"
+)
+
+// IsExternal 敏感字段是否存放在外部密钥存储
+func (c *Credential) IsExternal() bool {
+	return c.Source != "" && c.Source != CredentialSourceLocal
+}
+
+// ExternalSecret 从外部密钥存储读取到的密钥
+type ExternalSecret struct {
+	Data    map[string]string
+	Version int
+}
+
+// SecretBackend 外部密钥存储
+type SecretBackend interface {
+	Name() string
+	Read(ctx context.Context, path string) (*ExternalSecret, error)
+}
+
+// CredentialSecretAccess 外部密钥读取审计记录，每次实际请求外部存储记录一条，命中缓存不记录
+type CredentialSecretAccess struct {
+	ID             uint      `gorm:"primarykey" json:"id"`
+	CredentialID   uint      `gorm:"column:credential_id;not null;index;comment:凭证ID" json:"credentialId"`
+	CredentialName string    `gorm:"type:varchar(100);comment:凭证名称" json:"credentialName"`
+	Backend        string    `gorm:"type:varchar(20);comment:密钥存储" json:"backend"`
+	Path           string    `gorm:"type:varchar(255);comment:密钥路径" json:"path"`
+	Version        int       `gorm:"comment:密钥版本" json:"version"`
+	Success        bool      `gorm:"comment:是否成功" json:"success"`
+	Error          string    `gorm:"type:varchar(500);comment:失败原因" json:"error"`
+	DurationMs     int64     `gorm:"comment:耗时(毫秒)" json:"durationMs"`
+	CreatedAt      time.Time `gorm:"index;comment:读取时间" json:"createdAt"`
+}
+
+// TableName 表名
+func (CredentialSecretAccess) TableName() string {
+	return "credential_secret_accesses"
+}
+
+type cachedSecret struct {
+	path      string
+	secret    *ExternalSecret
+	expiresAt time.Time
+}
+
+// CredentialSecretResolver 解析引用外部密钥的凭证，读取结果按凭证缓存
+type CredentialSecretResolver struct {
+	backend    SecretBackend
+	accessRepo CredentialSecretAccessRepo
+	cacheTTL   time.Duration
+
+	mu    sync.Mutex
+	cache map[uint]*cachedSecret
+}
+
+// NewCredentialSecretResolver 创建外部密钥解析器，cacheTTL 为0时不缓存
+func NewCredentialSecretResolver(backend SecretBackend, accessRepo CredentialSecretAccessRepo, cacheTTL time.Duration) *CredentialSecretResolver {
+	return &CredentialSecretResolver{
+		backend:    backend,
+		accessRepo: accessRepo,
+		cacheTTL:   cacheTTL,
+		cache:      make(map[uint]*cachedSecret),
+	}
+}
+
+var (
+	secretResolverMu sync.RWMutex
+	secretResolver   *CredentialSecretResolver
+)
+
+// SetCredentialSecretResolver 设置全局外部密钥解析器，插件直接读取凭证时同样通过它解析
+func SetCredentialSecretResolver(r *CredentialSecretResolver) {
+	secretResolverMu.Lock()
+	secretResolver = r
+	secretResolverMu.Unlock()
+}
+
+// ResolveCredentialSecret 凭证引用外部密钥时，读取密钥填充到凭证的敏感字段
+func ResolveCredentialSecret(ctx context.Context, credential *Credential) error {
+	if !credential.IsExternal() {
+		return nil
+	}
+	secretResolverMu.RLock()
+	r := secretResolver
+	secretResolverMu.RUnlock()
+	if r == nil {
+		return fmt.Errorf("凭证 %s 引用外部密钥存储 %s，但未启用该存储", credential.Name, credential.Source)
+	}
+	return r.Resolve(ctx, credential)
+}
+
+// InvalidateCredentialSecret 清除凭证的密钥缓存，凭证修改或删除后调用
+func InvalidateCredentialSecret(credentialID uint) {
+	secretResolverMu.RLock()
+	r := secretResolver
+	secretResolverMu.RUnlock()
+	if r != nil {
+		r.Invalidate(credentialID)
+	}
+}
+
+// Resolve 读取凭证引用的外部密钥，填充用户名、密码、私钥和私钥密码
+func (r *CredentialSecretResolver) Resolve(ctx context.Context, credential *Credential) error {
+	if credential.Source != r.backend.Name() {
+		return fmt.Errorf("不支持的凭证来源: %s", credential.Source)
+	}
+	path := strings.Trim(credential.SecretPath, "/")
+	if path == "" {
+		return fmt.Errorf("凭证 %s 未配置外部密钥路径", credential.Name)
+	}
+
+	secret, err := r.fetch(ctx, credential, path)
+	if err != nil {
+		return fmt.Errorf("读取外部密钥 %s 失败: %w", path, err)
+	}
+
+	if v := secret.Data[SecretFieldUsername]; v != "" {
+		credential.Username = v
+	}
+	credential.Password = secret.Data[SecretFieldPassword]
+	credential.PrivateKey = secret.Data[SecretFieldPrivateKey]
+	credential.Passphrase = secret.Data[SecretFieldPassphrase]
+
+	switch {
+	case credential.Type == "password" && credential.Password == "":
+		return fmt.Errorf("外部密钥 %s 缺少 %s 字段", path, SecretFieldPassword)
+	case credential.Type == "key" && credential.PrivateKey == "":
+		return fmt.Errorf("外部密钥 %s 缺少 %s 字段", path, SecretFieldPrivateKey)
+	}
+	return nil
+}
+
+// fetch 优先使用缓存，缓存失效时请求外部存储并记录审计
+func (r *CredentialSecretResolver) fetch(ctx context.Context, credential *Credential, path string) (*ExternalSecret, error) {
+	now := time.Now()
+	r.mu.Lock()
+	if cached, ok := r.cache[credential.ID]; ok && cached.path == path && now.Before(cached.expiresAt) {
+		r.mu.Unlock()
+		return cached.secret, nil
+	}
+	r.mu.Unlock()
+
+	secret, err := r.backend.Read(ctx, path)
+
+	access := &CredentialSecretAccess{
+		CredentialID:   credential.ID,
+		CredentialName: credential.Name,
+		Backend:        r.backend.Name(),
+		Path:           path,
+		Success:        err == nil,
+		DurationMs:     time.Since(now).Milliseconds(),
+	}
+	if err != nil {
+		access.Error = err.Error()
+		if len(access.Error) > 500 {
+			access.Error = strings.ToValidUTF8(access.Error[:500], "")
+		}
+	} else {
+		access.Version = secret.Version
+	}
+	if r.accessRepo != nil {
+		// 审计记录不能因为调用方的 ctx 取消而丢失
+		if auditErr := r.accessRepo.Create(context.Background(), access); auditErr != nil {
+			appLogger.Error("记录外部密钥读取审计失败", zap.Uint("credentialId", credential.ID), zap.Error(auditErr))
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if r.cacheTTL > 0 {
+		r.mu.Lock()
+		r.cache[credential.ID] = &cachedSecret{path: path, secret: secret, expiresAt: now.Add(r.cacheTTL)}
+		r.mu.Unlock()
+	}
+	return secret, nil
+}
+
+// Invalidate 清除凭证的密钥缓存
+func (r *CredentialSecretResolver) Invalidate(credentialID uint) {
+	r.mu.Lock()
+	delete(r.cache, credentialID)
+	r.mu.Unlock()
+}
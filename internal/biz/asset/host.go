@@ -35,11 +35,20 @@ type Host struct {
 	CloudProvider    string        `gorm:"type:varchar(50);comment:云厂商 aliyun/tencent/aws" json:"cloudProvider,omitempty"`
 	CloudInstanceID  string        `gorm:"type:varchar(100);comment:云实例ID" json:"cloudInstanceId,omitempty"`
 	CloudAccountID   uint          `gorm:"column:cloud_account_id;comment:云账号ID" json:"cloudAccountId,omitempty"`
+	// 云主机库存信息（由云账号同步维护）
+	CloudRegion       string     `gorm:"type:varchar(100);comment:云区域" json:"cloudRegion,omitempty"`
+	CloudInstanceType string     `gorm:"type:varchar(100);comment:云实例规格" json:"cloudInstanceType,omitempty"`
+	CloudCPU          int        `gorm:"column:cloud_cpu;comment:云实例vCPU数" json:"cloudCpu,omitempty"`
+	CloudMemoryMB     int        `gorm:"column:cloud_memory_mb;comment:云实例内存(MB)" json:"cloudMemoryMb,omitempty"`
+	CloudStatus       string     `gorm:"type:varchar(50);default:'';comment:云实例状态，实例已释放时为 terminated" json:"cloudStatus,omitempty"`
+	CloudTags         string     `gorm:"type:varchar(500);comment:上次同步的云标签(逗号分隔)" json:"-"`
+	CloudSyncedAt     *time.Time `gorm:"column:cloud_synced_at;comment:最后同步时间" json:"cloudSyncedAt,omitempty"`
 	SSHUser          string        `gorm:"type:varchar(50);not null;comment:SSH用户名" json:"sshUser"`
 	IP               string        `gorm:"type:varchar(50);not null;comment:IP地址" json:"ip"`
 	Port             int           `gorm:"type:int;default:22;comment:SSH端口" json:"port"`
 	CredentialID     uint          `gorm:"column:credential_id;comment:凭证ID" json:"credentialId"`
 	Credential       *Credential   `gorm:"-" json:"credential,omitempty"`
+	JumpHostID       uint          `gorm:"column:jump_host_id;default:0;comment:跳板机ID(0表示直连)" json:"jumpHostId"`
 	Tags             string        `gorm:"type:varchar(500);comment:主机标签(逗号分隔)" json:"tags"`
 	Description      string        `gorm:"type:varchar(500);comment:备注" json:"description"`
 	Status           int           `gorm:"type:tinyint;default:1;comment:状态 1:在线 0:离线 -1:未知" json:"status"`
@@ -59,6 +68,19 @@ type Host struct {
 	DiskUsage        float64       `gorm:"type:float;comment:磁盘使用率" json:"diskUsage"`
 	Uptime           string        `gorm:"type:varchar(100);comment:运行时间" json:"uptime"`
 	Hostname         string        `gorm:"type:varchar(100);comment:主机名" json:"hostname"`
+	// SSH主机密钥（首次连接时记录，或由管理员手动固定）
+	HostKeyType        string     `gorm:"type:varchar(50);comment:SSH主机密钥类型" json:"hostKeyType,omitempty"`
+	HostKeyFingerprint string     `gorm:"type:varchar(100);comment:SSH主机密钥指纹(SHA256)" json:"hostKeyFingerprint,omitempty"`
+	HostKeyPinned      bool       `gorm:"default:false;comment:SSH主机密钥是否手动固定" json:"hostKeyPinned"`
+	HostKeyUpdatedAt   *time.Time `gorm:"column:host_key_updated_at;comment:SSH主机密钥记录时间" json:"hostKeyUpdatedAt,omitempty"`
+}
+
+// hostKeyColumns 主机密钥相关列，只能通过 UpdateHostKey 修改
+var hostKeyColumns = []string{"host_key_type", "host_key_fingerprint", "host_key_pinned", "host_key_updated_at"}
+
+// HostKeyColumns 返回主机密钥相关列名
+func HostKeyColumns() []string {
+	return hostKeyColumns
 }
 
 // HostRequest 主机请求
@@ -74,6 +96,7 @@ type HostRequest struct {
 	IP            string `json:"ip" binding:"required,ip"`
 	Port          int    `json:"port" binding:"required,min=1,max=65535"`
 	CredentialID  uint   `json:"credentialId"`
+	JumpHostID    uint   `json:"jumpHostId"`
 	Tags          string `json:"tags"`
 	Description   string `json:"description"`
 }
@@ -89,11 +112,17 @@ type HostInfoVO struct {
 	CloudProvider    string         `json:"cloudProvider,omitempty"`
 	CloudProviderText string        `json:"cloudProviderText,omitempty"`
 	CloudInstanceID  string         `json:"cloudInstanceId,omitempty"`
+	CloudRegion       string        `json:"cloudRegion,omitempty"`
+	CloudInstanceType string        `json:"cloudInstanceType,omitempty"`
+	CloudStatus       string        `json:"cloudStatus,omitempty"`
+	CloudSyncedAt     string        `json:"cloudSyncedAt,omitempty"`
 	SSHUser          string         `json:"sshUser"`
 	IP               string         `json:"ip"`
 	Port             int            `json:"port"`
 	CredentialID     uint           `json:"credentialId"`
 	Credential       *CredentialVO  `json:"credential,omitempty"`
+	JumpHostID       uint           `json:"jumpHostId"`
+	JumpHostName     string         `json:"jumpHostName,omitempty"`
 	Tags             []string       `json:"tags"`
 	Description      string         `json:"description"`
 	Status           int            `json:"status"`
@@ -115,6 +144,37 @@ type HostInfoVO struct {
 	DiskUsage        float64 `json:"diskUsage"`
 	Uptime           string  `json:"uptime"`
 	Hostname         string  `json:"hostname"`
+	// SSH主机密钥
+	HostKeyType        string `json:"hostKeyType,omitempty"`
+	HostKeyFingerprint string `json:"hostKeyFingerprint,omitempty"`
+	HostKeyPinned      bool   `json:"hostKeyPinned"`
+	HostKeyUpdatedAt   string `json:"hostKeyUpdatedAt,omitempty"`
+}
+
+// HostKey 主机密钥记录
+type HostKey struct {
+	KeyType     string
+	Fingerprint string
+	Pinned      bool
+}
+
+// HostKeyRequest 手动固定主机密钥请求
+type HostKeyRequest struct {
+	// 公钥（authorized_keys/known_hosts 格式）或 SHA256 指纹
+	HostKey string `json:"hostKey" binding:"required"`
+}
+
+// HostKeyVO 主机密钥信息VO
+type HostKeyVO struct {
+	HostID      uint   `json:"hostId"`
+	KeyType     string `json:"keyType"`
+	Fingerprint string `json:"fingerprint"`
+	Pinned      bool   `json:"pinned"`
+	UpdatedAt   string `json:"updatedAt,omitempty"`
+	// 扫描得到的主机当前密钥
+	CurrentKeyType     string `json:"currentKeyType,omitempty"`
+	CurrentFingerprint string `json:"currentFingerprint,omitempty"`
+	Matched            bool   `json:"matched"`
 }
 
 // HostListVO 主机列表VO（用于分组下的主机列表）
@@ -141,6 +201,7 @@ func (req *HostRequest) ToModel() *Host {
 		IP:              req.IP,
 		Port:            req.Port,
 		CredentialID:    req.CredentialID,
+		JumpHostID:      req.JumpHostID,
 		Tags:            req.Tags,
 		Description:     req.Description,
 		Status:          -1, // 初始状态未知
@@ -154,24 +215,31 @@ type Credential struct {
 	UpdatedAt   time.Time `json:"updatedAt"`
 	DeletedAt   gorm.DeletedAt `gorm:"index" json:"deletedAt,omitempty"`
 	Name        string `gorm:"type:varchar(100);not null;comment:凭证名称" json:"name"`
-	Type        string `gorm:"type:varchar(20);not null;comment:认证方式 password/key" json:"type"`
+	Type        string `gorm:"type:varchar(20);not null;comment:认证方式 password/key/certificate" json:"type"`
 	Username    string `gorm:"type:varchar(100);comment:用户名" json:"username"`
 	Password    string `gorm:"type:varchar(500);comment:密码(加密)" json:"password,omitempty"`
 	PrivateKey  string `gorm:"type:text;comment:私钥(加密)" json:"privateKey,omitempty"`
 	Passphrase  string `gorm:"type:varchar(500);comment:私钥密码(加密)" json:"passphrase,omitempty"`
+	Source      string `gorm:"type:varchar(20);default:local;comment:凭证来源 local/vault" json:"source"`
+	SecretPath  string `gorm:"type:varchar(255);comment:外部密钥路径" json:"secretPath"`
 	Description string `gorm:"type:varchar(500);comment:备注" json:"description"`
+	RotationDays int        `gorm:"default:0;comment:自动轮换周期(天) 0:不自动轮换" json:"rotationDays"`
+	RotatedAt    *time.Time `gorm:"comment:最后轮换时间" json:"rotatedAt"`
 }
 
 // CredentialRequest 凭证请求
 type CredentialRequest struct {
 	ID          uint   `json:"id"`
 	Name        string `json:"name" binding:"required,min=2,max=100"`
-	Type        string `json:"type" binding:"required,oneof=password key"`
+	Type        string `json:"type" binding:"required,oneof=password key certificate"`
 	Username    string `json:"username"`
 	Password    string `json:"password"`
 	PrivateKey  string `json:"privateKey"`
 	Passphrase  string `json:"passphrase"`
+	Source      string `json:"source" binding:"omitempty,oneof=local vault"`
+	SecretPath  string `json:"secretPath" binding:"max=255"`
 	Description string `json:"description"`
+	RotationDays int   `json:"rotationDays" binding:"min=0,max=3650"`
 }
 
 // CredentialVO 凭证VO
@@ -181,22 +249,47 @@ type CredentialVO struct {
 	Type        string `json:"type"`
 	TypeText    string `json:"typeText"`
 	Username    string `json:"username"`
+	Source      string `json:"source"`
+	SecretPath  string `json:"secretPath"`
 	Description string `json:"description"`
 	CreateTime  string `json:"createTime"`
 	HostCount   int64  `json:"hostCount"` // 使用该凭证的主机数量
+	RotationDays int   `json:"rotationDays"`
+	RotatedAt    string `json:"rotatedAt"`
 }
 
 // ToModel 转换为模型
 func (req *CredentialRequest) ToModel() *Credential {
-	return &Credential{
+	credential := &Credential{
 		Name:        req.Name,
 		Type:        req.Type,
 		Username:    req.Username,
 		Password:    req.Password,
 		PrivateKey:  req.PrivateKey,
 		Passphrase:  req.Passphrase,
+		Source:      req.Source,
+		SecretPath:  req.SecretPath,
 		Description: req.Description,
+		RotationDays: req.RotationDays,
+	}
+	if credential.Source == "" || credential.IsCertificate() {
+		credential.Source = CredentialSourceLocal
+	}
+	// 证书由平台CA在连接时签发，登录用户取主机配置，无需保存密钥也无需轮换
+	if credential.IsCertificate() {
+		credential.Username = ""
+		credential.SecretPath = ""
+		credential.RotationDays = 0
 	}
+	// 引用外部密钥或使用证书时数据库中不保存任何敏感字段
+	if credential.IsExternal() || credential.IsCertificate() {
+		credential.Password = ""
+		credential.PrivateKey = ""
+		credential.Passphrase = ""
+	} else {
+		credential.SecretPath = ""
+	}
+	return credential
 }
 
 // CloudAccount 云平台账号模型
@@ -209,6 +302,12 @@ type CloudAccount struct {
 	Region      string `gorm:"type:varchar(100);comment:默认区域" json:"region"`
 	Description string `gorm:"type:varchar(500);comment:备注" json:"description"`
 	Status      int    `gorm:"type:tinyint;default:1;comment:状态 1:启用 0:禁用" json:"status"`
+	// 库存同步：定期拉取实例列表，新增主机、标记已释放实例并更新IP、规格和标签
+	SyncInterval   int        `gorm:"default:0;comment:自动同步间隔(分钟)，0表示不自动同步" json:"syncInterval"`
+	SyncRegions    string     `gorm:"type:varchar(500);comment:同步区域(逗号分隔)，为空时使用默认区域" json:"syncRegions"`
+	SyncGroupID    uint       `gorm:"column:sync_group_id;default:0;comment:同步新增主机的分组ID" json:"syncGroupId"`
+	LastSyncAt     *time.Time `gorm:"column:last_sync_at;comment:最后同步时间" json:"lastSyncAt"`
+	LastSyncStatus string     `gorm:"type:varchar(20);comment:最后同步状态" json:"lastSyncStatus"`
 }
 
 // CloudAccountRequest 云平台账号请求
@@ -221,30 +320,42 @@ type CloudAccountRequest struct {
 	Region      string `json:"region"`
 	Description string `json:"description"`
 	Status      int    `json:"status"`
+	// 自动同步间隔(分钟)，0表示不自动同步
+	SyncInterval int    `json:"syncInterval" binding:"min=0"`
+	SyncRegions  string `json:"syncRegions"`
+	SyncGroupID  uint   `json:"syncGroupId"`
 }
 
 // CloudAccountVO 云平台账号VO
 type CloudAccountVO struct {
-	ID           uint   `json:"id"`
-	Name         string `json:"name"`
-	Provider     string `json:"provider"`
-	ProviderText string `json:"providerText"`
-	Region       string `json:"region"`
-	Description  string `json:"description"`
-	Status       int    `json:"status"`
-	CreateTime   string `json:"createTime"`
+	ID             uint   `json:"id"`
+	Name           string `json:"name"`
+	Provider       string `json:"provider"`
+	ProviderText   string `json:"providerText"`
+	Region         string `json:"region"`
+	Description    string `json:"description"`
+	Status         int    `json:"status"`
+	SyncInterval   int    `json:"syncInterval"`
+	SyncRegions    string `json:"syncRegions"`
+	SyncGroupID    uint   `json:"syncGroupId"`
+	LastSyncAt     string `json:"lastSyncAt,omitempty"`
+	LastSyncStatus string `json:"lastSyncStatus,omitempty"`
+	CreateTime     string `json:"createTime"`
 }
 
 // ToModel 转换为模型
 func (req *CloudAccountRequest) ToModel() *CloudAccount {
 	return &CloudAccount{
-		Name:        req.Name,
-		Provider:    req.Provider,
-		AccessKey:   req.AccessKey,
-		SecretKey:   req.SecretKey,
-		Region:      req.Region,
-		Description: req.Description,
-		Status:      req.Status,
+		Name:         req.Name,
+		Provider:     req.Provider,
+		AccessKey:    req.AccessKey,
+		SecretKey:    req.SecretKey,
+		Region:       req.Region,
+		Description:  req.Description,
+		Status:       req.Status,
+		SyncInterval: req.SyncInterval,
+		SyncRegions:  normalizeSyncRegions(req.SyncRegions),
+		SyncGroupID:  req.SyncGroupID,
 	}
 }
 
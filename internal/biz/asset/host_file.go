@@ -0,0 +1,308 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package asset
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	sshclient "github.com/ydcloud-dy/opshub/pkg/ssh"
+)
+
+const (
+	// MaxEditFileSize 在线编辑的文件大小上限
+	MaxEditFileSize = 2 << 20
+	// uploadPartSuffix 分片上传未完成时的临时文件后缀
+	uploadPartSuffix = ".opshub-part"
+)
+
+var (
+	// ErrFileModified 在线编辑保存时文件已被修改
+	ErrFileModified = errors.New("文件已被修改，请重新加载后再编辑")
+	// ErrChunkOffset 分片偏移与已上传的大小不一致，需要按已上传大小续传
+	ErrChunkOffset = sshclient.ErrChunkOffset
+
+	// accountNamePattern 合法的用户名/组名，解析为ID时作为命令参数使用
+	accountNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*$`)
+)
+
+// FileContent 在线编辑的文件内容
+type FileContent struct {
+	Path    string `json:"path"`
+	Content string `json:"content"`
+	Size    int64  `json:"size"`
+	MTime   int64  `json:"mtime"` // 保存时原样提交，用于检测并发修改
+}
+
+// ChunkUploadStatus 分片上传进度
+type ChunkUploadStatus struct {
+	Uploaded  int64  `json:"uploaded"`         // 已上传的字节数，续传时作为下一个分片的偏移
+	Completed bool   `json:"completed"`        // 是否已上传完成
+	SHA256    string `json:"sha256,omitempty"` // 上传完成后文件的 SHA-256
+}
+
+// openHostFiles 建立主机SSH连接用于文件操作
+func (uc *HostUseCase) openHostFiles(ctx context.Context, hostID uint) (*sshclient.Client, error) {
+	host, err := uc.hostRepo.GetByID(ctx, hostID)
+	if err != nil {
+		return nil, fmt.Errorf("获取主机信息失败: %w", err)
+	}
+
+	if host.CredentialID == 0 {
+		return nil, fmt.Errorf("主机未配置凭证")
+	}
+
+	credential, err := uc.credentialRepo.GetByIDDecrypted(ctx, host.CredentialID)
+	if err != nil {
+		return nil, fmt.Errorf("获取凭证失败: %w", err)
+	}
+
+	sshClient, err := uc.createSSHClient(ctx, host, credential)
+	if err != nil {
+		return nil, fmt.Errorf("创建SSH连接失败: %w", err)
+	}
+	return sshClient, nil
+}
+
+// expandHomePath 将 ~ 开头的路径替换为用户主目录
+func expandHomePath(sshClient *sshclient.Client, remotePath string) (string, error) {
+	if !strings.HasPrefix(remotePath, "~") {
+		return remotePath, nil
+	}
+	homeDir, err := sshClient.Execute("echo $HOME")
+	if err != nil {
+		return "", fmt.Errorf("获取用户主目录失败: %w", err)
+	}
+	homeDir = strings.TrimSpace(homeDir)
+	return strings.Replace(remotePath, "~", homeDir, 1), nil
+}
+
+// withHostFiles 建立主机SSH连接并展开路径中的 ~ 后执行 fn
+func (uc *HostUseCase) withHostFiles(ctx context.Context, hostID uint, paths []string, fn func(sshClient *sshclient.Client, paths []string) error) error {
+	sshClient, err := uc.openHostFiles(ctx, hostID)
+	if err != nil {
+		return err
+	}
+	defer sshClient.Close()
+
+	expanded := make([]string, len(paths))
+	for i, p := range paths {
+		if expanded[i], err = expandHomePath(sshClient, p); err != nil {
+			return err
+		}
+	}
+	return fn(sshClient, expanded)
+}
+
+// RenameFile 重命名或移动主机上的文件
+func (uc *HostUseCase) RenameFile(ctx context.Context, hostID uint, oldPath, newPath string) error {
+	return uc.withHostFiles(ctx, hostID, []string{oldPath, newPath}, func(sshClient *sshclient.Client, paths []string) error {
+		return sshClient.Rename(paths[0], paths[1])
+	})
+}
+
+// ChmodFile 修改主机上文件的权限，mode 为八进制字符串（如 755、0644）
+func (uc *HostUseCase) ChmodFile(ctx context.Context, hostID uint, remotePath, mode string, recursive bool) error {
+	perm, err := strconv.ParseUint(mode, 8, 32)
+	if err != nil || perm > 0o7777 {
+		return fmt.Errorf("无效的权限: %s", mode)
+	}
+	return uc.withHostFiles(ctx, hostID, []string{remotePath}, func(sshClient *sshclient.Client, paths []string) error {
+		return sshClient.Chmod(paths[0], sftpFileMode(uint32(perm)), recursive)
+	})
+}
+
+// sftpFileMode 将八进制权限（含 setuid/setgid/sticky 位）转换为 os.FileMode
+func sftpFileMode(perm uint32) os.FileMode {
+	mode := os.FileMode(perm & 0o777)
+	if perm&0o4000 != 0 {
+		mode |= os.ModeSetuid
+	}
+	if perm&0o2000 != 0 {
+		mode |= os.ModeSetgid
+	}
+	if perm&0o1000 != 0 {
+		mode |= os.ModeSticky
+	}
+	return mode
+}
+
+// ChownFile 修改主机上文件的属主和属组，可以是名称或数字ID，为空时保持不变
+func (uc *HostUseCase) ChownFile(ctx context.Context, hostID uint, remotePath, owner, group string, recursive bool) error {
+	if owner == "" && group == "" {
+		return fmt.Errorf("请指定属主或属组")
+	}
+	return uc.withHostFiles(ctx, hostID, []string{remotePath}, func(sshClient *sshclient.Client, paths []string) error {
+		uid, err := resolveAccountID(sshClient, owner, "id -u %s")
+		if err != nil {
+			return fmt.Errorf("无效的属主 %s: %w", owner, err)
+		}
+		gid, err := resolveAccountID(sshClient, group, "getent group %s | cut -d: -f3")
+		if err != nil {
+			return fmt.Errorf("无效的属组 %s: %w", group, err)
+		}
+		return sshClient.Chown(paths[0], uid, gid, recursive)
+	})
+}
+
+// resolveAccountID 将用户名/组名解析为主机上的数字ID，为空时返回 -1
+func resolveAccountID(sshClient *sshclient.Client, name, command string) (int, error) {
+	if name == "" {
+		return -1, nil
+	}
+	if id, err := strconv.Atoi(name); err == nil && id >= 0 {
+		return id, nil
+	}
+	if !accountNamePattern.MatchString(name) {
+		return 0, fmt.Errorf("名称不合法")
+	}
+	output, err := sshClient.Execute(fmt.Sprintf(command, name))
+	if err != nil {
+		return 0, err
+	}
+	id, err := strconv.Atoi(strings.TrimSpace(output))
+	if err != nil {
+		return 0, fmt.Errorf("主机上不存在")
+	}
+	return id, nil
+}
+
+// ArchiveDir 将主机上的目录打包为 tar.gz 或 zip 写入 writer
+func (uc *HostUseCase) ArchiveDir(ctx context.Context, hostID uint, remotePath, format string, writer io.Writer) error {
+	return uc.withHostFiles(ctx, hostID, []string{remotePath}, func(sshClient *sshclient.Client, paths []string) error {
+		return sshClient.ArchiveDir(paths[0], format, writer)
+	})
+}
+
+// UploadStatus 查询分片上传的进度，用于断点续传
+func (uc *HostUseCase) UploadStatus(ctx context.Context, hostID uint, remoteDir, filename string) (*ChunkUploadStatus, error) {
+	status := &ChunkUploadStatus{}
+	err := uc.withHostFiles(ctx, hostID, []string{remoteDir}, func(sshClient *sshclient.Client, paths []string) error {
+		info, err := sshClient.StatFile(path.Join(paths[0], filename) + uploadPartSuffix)
+		if err != nil {
+			// 没有未完成的上传
+			return nil
+		}
+		status.Uploaded = info.Size
+		return nil
+	})
+	return status, err
+}
+
+// UploadChunk 上传文件分片：写入临时文件的 offset 处，全部上传后重命名为目标文件。
+// offset 与已上传的大小不一致时返回 ErrChunkOffset 及已上传的大小
+func (uc *HostUseCase) UploadChunk(ctx context.Context, hostID uint, reader io.Reader, remoteDir, filename string, offset, total int64) (*ChunkUploadStatus, error) {
+	status := &ChunkUploadStatus{}
+	err := uc.withHostFiles(ctx, hostID, []string{remoteDir}, func(sshClient *sshclient.Client, paths []string) error {
+		fullPath := path.Join(paths[0], filename)
+		partPath := fullPath + uploadPartSuffix
+
+		uploaded, err := sshClient.UploadChunk(reader, partPath, offset)
+		status.Uploaded = uploaded
+		if err != nil {
+			return err
+		}
+		if uploaded > total {
+			return fmt.Errorf("上传的数据超过文件大小")
+		}
+		if uploaded < total {
+			return nil
+		}
+
+		// 上传完成，与普通上传一样覆盖同名文件
+		if _, err := sshClient.StatFile(fullPath); err == nil {
+			if err := sshClient.RemoveFile(fullPath); err != nil {
+				return err
+			}
+		}
+		if err := sshClient.Rename(partPath, fullPath); err != nil {
+			return err
+		}
+		status.Completed = true
+
+		// 分片上传无法在传输过程中计算整体哈希，完成后读取远程文件计算
+		hash := sha256.New()
+		if err := sshClient.DownloadToWriter(fullPath, hash); err == nil {
+			status.SHA256 = hex.EncodeToString(hash.Sum(nil))
+		}
+		return nil
+	})
+	return status, err
+}
+
+// ReadFileContent 读取主机上的文本文件用于在线编辑
+func (uc *HostUseCase) ReadFileContent(ctx context.Context, hostID uint, remotePath string) (*FileContent, error) {
+	var content *FileContent
+	err := uc.withHostFiles(ctx, hostID, []string{remotePath}, func(sshClient *sshclient.Client, paths []string) error {
+		data, info, err := sshClient.ReadFile(paths[0], MaxEditFileSize)
+		if err != nil {
+			return err
+		}
+		if !utf8.Valid(data) || strings.IndexByte(string(data), 0) >= 0 {
+			return fmt.Errorf("不支持在线编辑二进制文件")
+		}
+		content = &FileContent{
+			Path:    paths[0],
+			Content: string(data),
+			Size:    info.Size,
+			MTime:   info.MTime,
+		}
+		return nil
+	})
+	return content, err
+}
+
+// SaveFileContent 保存在线编辑的文件，mtime 为读取时的修改时间，文件已被修改时返回 ErrFileModified
+func (uc *HostUseCase) SaveFileContent(ctx context.Context, hostID uint, remotePath, content string, mtime int64) (*FileContent, error) {
+	if len(content) > MaxEditFileSize {
+		return nil, fmt.Errorf("文件内容超过 %d 字节", MaxEditFileSize)
+	}
+	var saved *FileContent
+	err := uc.withHostFiles(ctx, hostID, []string{remotePath}, func(sshClient *sshclient.Client, paths []string) error {
+		current, err := sshClient.StatFile(paths[0])
+		if err != nil {
+			return err
+		}
+		if current.IsDir {
+			return fmt.Errorf("路径不是文件: %s", paths[0])
+		}
+		if current.MTime != mtime {
+			return ErrFileModified
+		}
+
+		info, err := sshClient.WriteFile(paths[0], []byte(content))
+		if err != nil {
+			return err
+		}
+		saved = &FileContent{Path: paths[0], Size: info.Size, MTime: info.MTime}
+		return nil
+	})
+	return saved, err
+}
@@ -0,0 +1,440 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package asset
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	appLogger "github.com/ydcloud-dy/opshub/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// 主机状态
+const (
+	HostStatusOnline  = 1
+	HostStatusOffline = 0
+	HostStatusUnknown = -1
+)
+
+// 在线状态探测方式
+const (
+	HeartbeatModeTCP = "tcp" // 只检测SSH端口是否可以建立TCP连接
+	HeartbeatModeSSH = "ssh" // 使用主机凭证建立SSH会话并执行空命令
+)
+
+// HostAvailabilityEvent 主机在线状态变化记录，两次变化之间主机保持前一次变化后的状态
+type HostAvailabilityEvent struct {
+	ID         uint      `gorm:"primarykey" json:"id"`
+	HostID     uint      `gorm:"column:host_id;not null;index:idx_host_availability,priority:1;comment:主机ID" json:"hostId"`
+	Status     int       `gorm:"type:tinyint;not null;comment:变化后的状态 1:在线 0:离线" json:"status"`
+	PrevStatus int       `gorm:"type:tinyint;not null;comment:变化前的状态 1:在线 0:离线 -1:未知" json:"prevStatus"`
+	ProbeMode  string    `gorm:"type:varchar(10);comment:探测方式 tcp/ssh" json:"probeMode"`
+	LatencyMs  int64     `gorm:"comment:探测耗时(毫秒)" json:"latencyMs"`
+	Failures   int       `gorm:"type:int;default:0;comment:判定离线时的连续失败次数" json:"failures"`
+	Reason     string    `gorm:"type:varchar(500);comment:离线原因" json:"reason"`
+	CreatedAt  time.Time `gorm:"index:idx_host_availability,priority:2;index;comment:变化时间" json:"createdAt"`
+}
+
+// TableName 表名
+func (HostAvailabilityEvent) TableName() string {
+	return "host_availability_events"
+}
+
+// AvailabilitySegment 时间范围内主机保持同一状态的区间
+type AvailabilitySegment struct {
+	Status int       `json:"status"`
+	Start  time.Time `json:"start"`
+	End    time.Time `json:"end"`
+}
+
+// HostAvailability 主机在时间范围内的可用性统计
+type HostAvailability struct {
+	HostID         uint                     `json:"hostId"`
+	Status         int                      `json:"status"`
+	LastSeen       *time.Time               `json:"lastSeen,omitempty"`
+	Start          time.Time                `json:"start"`
+	End            time.Time                `json:"end"`
+	UptimePercent  float64                  `json:"uptimePercent"` // 在线时长占已知状态时长的百分比，没有已知状态时为-1
+	OnlineSeconds  int64                    `json:"onlineSeconds"`
+	OfflineSeconds int64                    `json:"offlineSeconds"`
+	UnknownSeconds int64                    `json:"unknownSeconds"`
+	Segments       []AvailabilitySegment    `json:"segments"`
+	Events         []*HostAvailabilityEvent `json:"events"`
+}
+
+// HostStatusNotifier 主机离线或恢复在线时发送通知
+type HostStatusNotifier interface {
+	NotifyHostStatus(ctx context.Context, host *Host, event *HostAvailabilityEvent) error
+}
+
+// HeartbeatConfig 在线状态探测配置，零值使用默认值
+type HeartbeatConfig struct {
+	Mode             string        // 探测方式 tcp/ssh
+	Interval         time.Duration // 探测间隔
+	Concurrency      int           // 同时探测的主机数
+	Timeout          time.Duration // 单次探测超时
+	FailureThreshold int           // 连续失败多少次判定离线
+	Retention        time.Duration // 状态变化记录保留时长
+}
+
+func (c HeartbeatConfig) withDefaults() HeartbeatConfig {
+	if c.Mode != HeartbeatModeSSH {
+		c.Mode = HeartbeatModeTCP
+	}
+	if c.Interval < 5*time.Second {
+		c.Interval = 30 * time.Second
+	}
+	if c.Concurrency <= 0 {
+		c.Concurrency = 20
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = 5 * time.Second
+	}
+	if c.FailureThreshold <= 0 {
+		c.FailureThreshold = 3
+	}
+	if c.Retention <= 0 {
+		c.Retention = 90 * 24 * time.Hour
+	}
+	return c
+}
+
+type HostHeartbeatUseCase struct {
+	availabilityRepo HostAvailabilityRepo
+	hostRepo         HostRepo
+	hostUseCase      *HostUseCase
+	config           HeartbeatConfig
+	notifier         HostStatusNotifier
+	probing          atomic.Bool
+	startOnce        sync.Once
+
+	mu       sync.Mutex
+	failures map[uint]int // 各主机当前的连续失败次数
+}
+
+func NewHostHeartbeatUseCase(availabilityRepo HostAvailabilityRepo, hostRepo HostRepo, hostUseCase *HostUseCase, config HeartbeatConfig) *HostHeartbeatUseCase {
+	return &HostHeartbeatUseCase{
+		availabilityRepo: availabilityRepo,
+		hostRepo:         hostRepo,
+		hostUseCase:      hostUseCase,
+		config:           config.withDefaults(),
+		failures:         make(map[uint]int),
+	}
+}
+
+// SetNotifier 设置主机状态变化通知，未设置时只记录不通知
+func (uc *HostHeartbeatUseCase) SetNotifier(notifier HostStatusNotifier) {
+	uc.notifier = notifier
+}
+
+// Start 启动定时探测和过期记录清理，重复调用只会启动一次
+func (uc *HostHeartbeatUseCase) Start() {
+	uc.startOnce.Do(func() {
+		go func() {
+			ticker := time.NewTicker(uc.config.Interval)
+			defer ticker.Stop()
+			for range ticker.C {
+				uc.ProbeAll(context.Background())
+			}
+		}()
+
+		go func() {
+			ticker := time.NewTicker(time.Hour)
+			defer ticker.Stop()
+			for now := range ticker.C {
+				uc.Purge(context.Background(), now)
+			}
+		}()
+	})
+}
+
+// ProbeAll 探测所有主机，上一轮尚未结束时跳过本轮
+func (uc *HostHeartbeatUseCase) ProbeAll(ctx context.Context) {
+	if !uc.probing.CompareAndSwap(false, true) {
+		appLogger.Warn("上一轮主机在线状态探测尚未完成，跳过本轮")
+		return
+	}
+	defer uc.probing.Store(false)
+
+	hosts, err := uc.hostRepo.ListAll(ctx)
+	if err != nil {
+		appLogger.Error("获取待探测主机失败", zap.Error(err))
+		return
+	}
+	uc.retainFailures(hosts)
+
+	sem := make(chan struct{}, uc.config.Concurrency)
+	var wg sync.WaitGroup
+	for _, host := range hosts {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(host *Host) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			probeCtx, cancel := context.WithTimeout(ctx, 2*uc.config.Timeout)
+			defer cancel()
+			if err := uc.ProbeHost(probeCtx, host); err != nil {
+				appLogger.Warn("更新主机在线状态失败", zap.Uint("hostId", host.ID), zap.Error(err))
+			}
+		}(host)
+	}
+	wg.Wait()
+}
+
+// ProbeHost 探测单台主机并维护在线状态：探测成功立即判定在线，
+// 连续失败达到阈值才判定离线，状态发生变化时记录并发送通知
+func (uc *HostHeartbeatUseCase) ProbeHost(ctx context.Context, host *Host) error {
+	mode, latency, probeErr := uc.probe(ctx, host)
+	if mode == "" {
+		return nil
+	}
+
+	if probeErr == nil {
+		uc.setFailures(host.ID, 0)
+		now := time.Now()
+		changed, err := uc.hostRepo.UpdateStatus(ctx, host.ID, host.Status, HostStatusOnline, &now)
+		if err != nil || !changed || host.Status == HostStatusOnline {
+			return err
+		}
+		return uc.recordTransition(ctx, host, &HostAvailabilityEvent{
+			HostID:     host.ID,
+			Status:     HostStatusOnline,
+			PrevStatus: host.Status,
+			ProbeMode:  mode,
+			LatencyMs:  latency.Milliseconds(),
+		})
+	}
+
+	failures := uc.setFailures(host.ID, 1)
+	appLogger.Debug("主机在线状态探测失败", zap.Uint("hostId", host.ID), zap.Int("failures", failures), zap.Error(probeErr))
+	if failures < uc.config.FailureThreshold || host.Status == HostStatusOffline {
+		return nil
+	}
+	changed, err := uc.hostRepo.UpdateStatus(ctx, host.ID, host.Status, HostStatusOffline, nil)
+	if err != nil || !changed {
+		return err
+	}
+	reason := probeErr.Error()
+	if len(reason) > 500 {
+		reason = reason[:500]
+	}
+	return uc.recordTransition(ctx, host, &HostAvailabilityEvent{
+		HostID:     host.ID,
+		Status:     HostStatusOffline,
+		PrevStatus: host.Status,
+		ProbeMode:  mode,
+		LatencyMs:  latency.Milliseconds(),
+		Failures:   failures,
+		Reason:     reason,
+	})
+}
+
+// probe 探测主机是否可达，返回使用的探测方式，无法探测时返回空字符串。
+// 通过跳板机访问的主机无法直接建立TCP连接，只能使用凭证经跳板机探测
+func (uc *HostHeartbeatUseCase) probe(ctx context.Context, host *Host) (string, time.Duration, error) {
+	useSSH := host.CredentialID > 0 && (uc.config.Mode == HeartbeatModeSSH || host.JumpHostID > 0)
+	if !useSSH && host.JumpHostID > 0 {
+		return "", 0, nil
+	}
+
+	start := time.Now()
+	if useSSH {
+		err := uc.hostUseCase.probeSSH(ctx, host, uc.config.Timeout)
+		return HeartbeatModeSSH, time.Since(start), err
+	}
+
+	port := host.Port
+	if port == 0 {
+		port = 22
+	}
+	dialer := net.Dialer{Timeout: uc.config.Timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(host.IP, strconv.Itoa(port)))
+	if err != nil {
+		return HeartbeatModeTCP, time.Since(start), err
+	}
+	conn.Close()
+	return HeartbeatModeTCP, time.Since(start), nil
+}
+
+// probeSSH 通过连接池获取主机SSH连接并执行空命令，确认主机可以登录
+func (uc *HostUseCase) probeSSH(ctx context.Context, host *Host, timeout time.Duration) error {
+	credential, err := uc.credentialRepo.GetByIDDecrypted(ctx, host.CredentialID)
+	if err != nil {
+		return fmt.Errorf("获取凭证失败: %w", err)
+	}
+
+	sshClient, err := uc.createSSHClient(ctx, host, credential)
+	if err != nil {
+		return fmt.Errorf("创建SSH连接失败: %w", err)
+	}
+	defer sshClient.Close()
+
+	_, err = sshClient.ExecuteWithTimeout("true", timeout)
+	return err
+}
+
+// recordTransition 记录状态变化，除首次探测到在线外都发送通知
+func (uc *HostHeartbeatUseCase) recordTransition(ctx context.Context, host *Host, event *HostAvailabilityEvent) error {
+	if err := uc.availabilityRepo.Create(ctx, event); err != nil {
+		return fmt.Errorf("记录主机状态变化失败: %w", err)
+	}
+	appLogger.Info("主机在线状态变化",
+		zap.Uint("hostId", host.ID),
+		zap.String("ip", host.IP),
+		zap.Int("prevStatus", event.PrevStatus),
+		zap.Int("status", event.Status),
+		zap.String("reason", event.Reason),
+	)
+
+	if uc.notifier == nil || (event.PrevStatus == HostStatusUnknown && event.Status == HostStatusOnline) {
+		return nil
+	}
+	go func() {
+		if err := uc.notifier.NotifyHostStatus(context.Background(), host, event); err != nil {
+			appLogger.Warn("发送主机状态通知失败", zap.Uint("hostId", host.ID), zap.Error(err))
+		}
+	}()
+	return nil
+}
+
+// setFailures 更新主机的连续失败次数，delta 为0时清零，返回更新后的次数
+func (uc *HostHeartbeatUseCase) setFailures(hostID uint, delta int) int {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+	if delta == 0 {
+		delete(uc.failures, hostID)
+		return 0
+	}
+	uc.failures[hostID] += delta
+	return uc.failures[hostID]
+}
+
+// retainFailures 丢弃已删除主机的失败计数
+func (uc *HostHeartbeatUseCase) retainFailures(hosts []*Host) {
+	exists := make(map[uint]bool, len(hosts))
+	for _, host := range hosts {
+		exists[host.ID] = true
+	}
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+	for hostID := range uc.failures {
+		if !exists[hostID] {
+			delete(uc.failures, hostID)
+		}
+	}
+}
+
+// Purge 删除超过保留时长的状态变化记录
+func (uc *HostHeartbeatUseCase) Purge(ctx context.Context, now time.Time) {
+	deleted, err := uc.availabilityRepo.DeleteBefore(ctx, now.Add(-uc.config.Retention))
+	if err != nil {
+		appLogger.Error("清理主机可用性记录失败", zap.Error(err))
+		return
+	}
+	if deleted > 0 {
+		appLogger.Info("清理过期主机可用性记录", zap.Int64("deleted", deleted))
+	}
+}
+
+// GetAvailability 统计主机在时间范围 [start, end) 内的可用性
+func (uc *HostHeartbeatUseCase) GetAvailability(ctx context.Context, hostID uint, start, end time.Time) (*HostAvailability, error) {
+	host, err := uc.hostRepo.GetByID(ctx, hostID)
+	if err != nil {
+		return nil, fmt.Errorf("获取主机信息失败: %w", err)
+	}
+	now := time.Now()
+	if end.After(now) {
+		end = now
+	}
+	if !start.Before(end) {
+		return nil, fmt.Errorf("开始时间必须早于结束时间")
+	}
+
+	events, err := uc.availabilityRepo.List(ctx, hostID, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("查询主机可用性记录失败: %w", err)
+	}
+
+	// 时间范围起点的状态：优先取之前最后一次变化后的状态，早于保留时长的记录被清理后，
+	// 取范围内第一次变化前的状态；范围内没有变化则一直是当前状态
+	status := host.Status
+	last, err := uc.availabilityRepo.LastBefore(ctx, hostID, start)
+	if err != nil {
+		return nil, fmt.Errorf("查询主机可用性记录失败: %w", err)
+	}
+	if last != nil {
+		status = last.Status
+	} else if len(events) > 0 {
+		status = events[0].PrevStatus
+	}
+	// 主机创建之前的时间不计入统计
+	if host.CreatedAt.After(start) {
+		start = host.CreatedAt
+		if !start.Before(end) {
+			start = end
+		}
+	}
+
+	result := &HostAvailability{
+		HostID:        host.ID,
+		Status:        host.Status,
+		LastSeen:      host.LastSeen,
+		Start:         start,
+		End:           end,
+		UptimePercent: -1,
+		Segments:      []AvailabilitySegment{},
+		Events:        events,
+	}
+	segmentStart := start
+	addSegment := func(segmentEnd time.Time) {
+		if !segmentEnd.After(segmentStart) {
+			return
+		}
+		seconds := int64(segmentEnd.Sub(segmentStart).Seconds())
+		switch status {
+		case HostStatusOnline:
+			result.OnlineSeconds += seconds
+		case HostStatusOffline:
+			result.OfflineSeconds += seconds
+		default:
+			result.UnknownSeconds += seconds
+		}
+		result.Segments = append(result.Segments, AvailabilitySegment{Status: status, Start: segmentStart, End: segmentEnd})
+		segmentStart = segmentEnd
+	}
+	for _, event := range events {
+		addSegment(event.CreatedAt)
+		status = event.Status
+	}
+	addSegment(end)
+
+	if known := result.OnlineSeconds + result.OfflineSeconds; known > 0 {
+		result.UptimePercent = float64(result.OnlineSeconds) / float64(known) * 100
+	}
+	return result, nil
+}
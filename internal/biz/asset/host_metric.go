@@ -0,0 +1,523 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package asset
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ydcloud-dy/opshub/pkg/collector"
+	appLogger "github.com/ydcloud-dy/opshub/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// 监控指标聚合粒度(秒)，原始样本为0
+const (
+	MetricResolutionRaw    = 0
+	MetricResolutionMinute = 300
+	MetricResolutionHour   = 3600
+)
+
+// maxMetricDisks 每个样本最多保存的挂载点数量
+const maxMetricDisks = 32
+
+// HostMetric 主机监控指标时序数据，原始样本和降采样后的聚合数据存在同一张表中
+type HostMetric struct {
+	ID          uint         `gorm:"primarykey" json:"-"`
+	HostID      uint         `gorm:"column:host_id;not null;uniqueIndex:uk_host_metric,priority:1;comment:主机ID" json:"hostId"`
+	Resolution  int          `gorm:"not null;uniqueIndex:uk_host_metric,priority:2;index:idx_host_metric_ts,priority:1;comment:聚合粒度(秒) 0:原始样本" json:"resolution"`
+	Timestamp   time.Time    `gorm:"column:ts;not null;uniqueIndex:uk_host_metric,priority:3;index:idx_host_metric_ts,priority:2;comment:采样时间或聚合区间起点" json:"timestamp"`
+	Samples     int          `gorm:"type:int;default:1;comment:聚合的原始样本数" json:"samples"`
+	CPUUsage    float32      `gorm:"comment:CPU使用率" json:"cpuUsage"`
+	CPUMax      float32      `gorm:"comment:区间内CPU最高使用率" json:"cpuMax"`
+	MemoryTotal uint64       `gorm:"type:bigint;comment:内存总容量(字节)" json:"memoryTotal"`
+	MemoryUsed  uint64       `gorm:"type:bigint;comment:已用内存(字节)" json:"memoryUsed"`
+	MemoryUsage float32      `gorm:"comment:内存使用率" json:"memoryUsage"`
+	Load1       float32      `gorm:"comment:1分钟平均负载" json:"load1"`
+	Load5       float32      `gorm:"comment:5分钟平均负载" json:"load5"`
+	Load15      float32      `gorm:"comment:15分钟平均负载" json:"load15"`
+	NetRxRate   float32      `gorm:"comment:网络接收速率(字节/秒)" json:"netRxRate"`
+	NetTxRate   float32      `gorm:"comment:网络发送速率(字节/秒)" json:"netTxRate"`
+	DiskUsage   float32      `gorm:"comment:使用率最高的磁盘的使用率" json:"diskUsage"`
+	Disks       string       `gorm:"type:text;comment:各挂载点磁盘使用情况JSON" json:"-"`
+	DiskList    []DiskMetric `gorm:"-" json:"disks"`
+}
+
+// TableName 表名
+func (HostMetric) TableName() string {
+	return "host_metrics"
+}
+
+// DiskMetric 单个挂载点的磁盘使用情况
+type DiskMetric struct {
+	MountPoint string  `json:"mountPoint"`
+	Total      uint64  `json:"total"`
+	Used       uint64  `json:"used"`
+	Usage      float32 `json:"usage"`
+}
+
+// MetricPoint 对比图中的一个数据点，Time 为毫秒时间戳
+type MetricPoint struct {
+	Time  int64   `json:"time"`
+	Value float64 `json:"value"`
+}
+
+// HostMetricSeries 多主机对比时单台主机的指标序列
+type HostMetricSeries struct {
+	HostID   uint          `json:"hostId"`
+	HostName string        `json:"hostName"`
+	HostIP   string        `json:"hostIp"`
+	Points   []MetricPoint `json:"points"`
+}
+
+// metricValues 可用于多主机对比的指标
+var metricValues = map[string]func(m *HostMetric) float32{
+	"cpu":    func(m *HostMetric) float32 { return m.CPUUsage },
+	"cpuMax": func(m *HostMetric) float32 { return m.CPUMax },
+	"memory": func(m *HostMetric) float32 { return m.MemoryUsage },
+	"disk":   func(m *HostMetric) float32 { return m.DiskUsage },
+	"load1":  func(m *HostMetric) float32 { return m.Load1 },
+	"load5":  func(m *HostMetric) float32 { return m.Load5 },
+	"load15": func(m *HostMetric) float32 { return m.Load15 },
+	"netRx":  func(m *HostMetric) float32 { return m.NetRxRate },
+	"netTx":  func(m *HostMetric) float32 { return m.NetTxRate },
+}
+
+// IsValidMetric 是否为可对比的指标名
+func IsValidMetric(metric string) bool {
+	_, ok := metricValues[metric]
+	return ok
+}
+
+// MetricSchedulerConfig 监控指标采集和保留配置，零值使用默认值
+type MetricSchedulerConfig struct {
+	Interval        time.Duration // 采集间隔
+	Concurrency     int           // 同时采集的主机数
+	RawRetention    time.Duration // 原始样本保留时长
+	MinuteRetention time.Duration // 5分钟聚合数据保留时长
+	HourRetention   time.Duration // 1小时聚合数据保留时长
+}
+
+func (c MetricSchedulerConfig) withDefaults() MetricSchedulerConfig {
+	if c.Interval < 10*time.Second {
+		c.Interval = time.Minute
+	}
+	if c.Concurrency <= 0 {
+		c.Concurrency = 10
+	}
+	if c.RawRetention <= 0 {
+		c.RawRetention = 48 * time.Hour
+	}
+	if c.MinuteRetention <= 0 {
+		c.MinuteRetention = 14 * 24 * time.Hour
+	}
+	if c.HourRetention <= 0 {
+		c.HourRetention = 180 * 24 * time.Hour
+	}
+	return c
+}
+
+// metricRollups 降采样层级，依次由原始样本聚合为5分钟数据、由5分钟数据聚合为1小时数据
+var metricRollups = []struct{ from, to int }{
+	{MetricResolutionRaw, MetricResolutionMinute},
+	{MetricResolutionMinute, MetricResolutionHour},
+}
+
+type HostMetricUseCase struct {
+	metricRepo  HostMetricRepo
+	hostRepo    HostRepo
+	hostUseCase *HostUseCase
+	config      MetricSchedulerConfig
+	collecting  atomic.Bool
+	startOnce   sync.Once
+}
+
+func NewHostMetricUseCase(metricRepo HostMetricRepo, hostRepo HostRepo, hostUseCase *HostUseCase, config MetricSchedulerConfig) *HostMetricUseCase {
+	return &HostMetricUseCase{
+		metricRepo:  metricRepo,
+		hostRepo:    hostRepo,
+		hostUseCase: hostUseCase,
+		config:      config.withDefaults(),
+	}
+}
+
+// Start 启动定时采集、降采样和过期数据清理，重复调用只会启动一次
+func (uc *HostMetricUseCase) Start() {
+	uc.startOnce.Do(func() {
+		go func() {
+			ticker := time.NewTicker(uc.config.Interval)
+			defer ticker.Stop()
+			for now := range ticker.C {
+				uc.CollectAll(context.Background(), now)
+			}
+		}()
+
+		go func() {
+			ticker := time.NewTicker(MetricResolutionMinute * time.Second)
+			defer ticker.Stop()
+			for now := range ticker.C {
+				ctx := context.Background()
+				if err := uc.Downsample(ctx, now); err != nil {
+					appLogger.Error("主机监控指标降采样失败", zap.Error(err))
+				}
+				uc.Purge(ctx, now)
+			}
+		}()
+	})
+}
+
+// CollectAll 采集所有已配置凭证的主机，上一轮尚未结束时跳过本轮
+func (uc *HostMetricUseCase) CollectAll(ctx context.Context, now time.Time) {
+	if !uc.collecting.CompareAndSwap(false, true) {
+		appLogger.Warn("上一轮主机监控指标采集尚未完成，跳过本轮")
+		return
+	}
+	defer uc.collecting.Store(false)
+
+	hosts, err := uc.hostRepo.ListWithCredential(ctx)
+	if err != nil {
+		appLogger.Error("获取待采集主机失败", zap.Error(err))
+		return
+	}
+
+	// 样本时间按采集间隔对齐，多个实例同时采集时由唯一索引去重
+	ts := now.Truncate(uc.config.Interval)
+	sem := make(chan struct{}, uc.config.Concurrency)
+	var wg sync.WaitGroup
+	for _, host := range hosts {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(host *Host) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			collectCtx, cancel := context.WithTimeout(ctx, uc.config.Interval)
+			defer cancel()
+			if err := uc.CollectHost(collectCtx, host, ts); err != nil {
+				appLogger.Debug("采集主机监控指标失败", zap.Uint("hostId", host.ID), zap.Error(err))
+			}
+		}(host)
+	}
+	wg.Wait()
+}
+
+// CollectHost 采集单台主机的监控指标，保存原始样本并更新主机上的最新使用率
+func (uc *HostMetricUseCase) CollectHost(ctx context.Context, host *Host, ts time.Time) error {
+	sample, err := uc.hostUseCase.collectMetrics(ctx, host)
+	if err != nil {
+		return err
+	}
+
+	metric := newHostMetric(host.ID, ts, sample)
+	encodeDisks(metric)
+	if err := uc.metricRepo.BatchCreate(ctx, []*HostMetric{metric}); err != nil {
+		return fmt.Errorf("保存监控指标失败: %w", err)
+	}
+
+	// 主机列表中的使用率与 CollectHostInfo 保持一致，磁盘只统计根目录
+	now := time.Now()
+	host.CPUUsage = sample.CPUUsage
+	host.MemoryTotal = sample.MemoryTotal
+	host.MemoryUsed = sample.MemoryUsed
+	host.MemoryUsage = sample.MemoryUsage
+	for _, disk := range sample.Disks {
+		if disk.MountPoint == "/" {
+			host.DiskTotal = disk.Total
+			host.DiskUsed = disk.Used
+			host.DiskUsage = disk.Usage
+		}
+	}
+	host.LastSeen = &now
+	return uc.hostRepo.UpdateMetrics(ctx, host)
+}
+
+// collectMetrics 连接主机采集一次监控指标
+func (uc *HostUseCase) collectMetrics(ctx context.Context, host *Host) (*collector.MetricSample, error) {
+	credential, err := uc.credentialRepo.GetByIDDecrypted(ctx, host.CredentialID)
+	if err != nil {
+		return nil, fmt.Errorf("获取凭证失败: %w", err)
+	}
+
+	sshClient, err := uc.createSSHClient(ctx, host, credential)
+	if err != nil {
+		return nil, fmt.Errorf("创建SSH连接失败: %w", err)
+	}
+	defer sshClient.Close()
+
+	return collector.NewCollector(sshClient).CollectMetrics()
+}
+
+// newHostMetric 由一次采样构造原始样本
+func newHostMetric(hostID uint, ts time.Time, sample *collector.MetricSample) *HostMetric {
+	metric := &HostMetric{
+		HostID:      hostID,
+		Resolution:  MetricResolutionRaw,
+		Timestamp:   ts,
+		Samples:     1,
+		CPUUsage:    float32(sample.CPUUsage),
+		CPUMax:      float32(sample.CPUUsage),
+		MemoryTotal: sample.MemoryTotal,
+		MemoryUsed:  sample.MemoryUsed,
+		MemoryUsage: float32(sample.MemoryUsage),
+		Load1:       float32(sample.Load1),
+		Load5:       float32(sample.Load5),
+		Load15:      float32(sample.Load15),
+		NetRxRate:   float32(sample.NetRxRate),
+		NetTxRate:   float32(sample.NetTxRate),
+	}
+	for _, disk := range sample.Disks {
+		if len(metric.DiskList) >= maxMetricDisks {
+			break
+		}
+		metric.DiskList = append(metric.DiskList, DiskMetric{
+			MountPoint: disk.MountPoint,
+			Total:      disk.Total,
+			Used:       disk.Used,
+			Usage:      float32(disk.Usage),
+		})
+		if float32(disk.Usage) > metric.DiskUsage {
+			metric.DiskUsage = float32(disk.Usage)
+		}
+	}
+	return metric
+}
+
+// Downsample 将已结束的时间区间聚合为更粗粒度的数据，从上次聚合的位置继续
+func (uc *HostMetricUseCase) Downsample(ctx context.Context, now time.Time) error {
+	// 留出一个采集间隔，等待区间末尾的采集完成
+	now = now.Add(-uc.config.Interval)
+	for _, rollup := range metricRollups {
+		if err := uc.rollup(ctx, rollup.from, rollup.to, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (uc *HostMetricUseCase) rollup(ctx context.Context, from, to int, now time.Time) error {
+	step := time.Duration(to) * time.Second
+	end := now.Truncate(step)
+
+	// 首次聚合时从源数据的保留期开始
+	start := end.Add(-uc.retention(from)).Truncate(step)
+	latest, err := uc.metricRepo.LatestTimestamp(ctx, to)
+	if err != nil {
+		return err
+	}
+	if latest != nil && !latest.Before(start) {
+		start = latest.Add(step)
+	}
+
+	for bucket := start; bucket.Before(end); bucket = bucket.Add(step) {
+		metrics, err := uc.metricRepo.List(ctx, nil, from, bucket, bucket.Add(step))
+		if err != nil {
+			return err
+		}
+		if len(metrics) == 0 {
+			continue
+		}
+		for _, metric := range metrics {
+			decodeDisks(metric)
+		}
+		aggregated := aggregateMetrics(metrics, to, bucket)
+		for _, metric := range aggregated {
+			encodeDisks(metric)
+		}
+		if err := uc.metricRepo.BatchCreate(ctx, aggregated); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// aggregateMetrics 按主机聚合同一区间内的数据，按样本数加权平均，CPU峰值取最大值
+func aggregateMetrics(metrics []*HostMetric, resolution int, bucket time.Time) []*HostMetric {
+	groups := make(map[uint][]*HostMetric)
+	var hostIDs []uint
+	for _, metric := range metrics {
+		if _, ok := groups[metric.HostID]; !ok {
+			hostIDs = append(hostIDs, metric.HostID)
+		}
+		groups[metric.HostID] = append(groups[metric.HostID], metric)
+	}
+
+	result := make([]*HostMetric, 0, len(hostIDs))
+	for _, hostID := range hostIDs {
+		group := groups[hostID]
+		agg := &HostMetric{HostID: hostID, Resolution: resolution, Timestamp: bucket}
+
+		var samples int
+		var cpu, memory, memoryUsed, load1, load5, load15, rx, tx, diskUsage float64
+		diskSums := make(map[string]float64)
+		diskLast := make(map[string]DiskMetric)
+		var mounts []string
+		for _, m := range group {
+			weight := float64(max(m.Samples, 1))
+			samples += int(weight)
+			cpu += float64(m.CPUUsage) * weight
+			memory += float64(m.MemoryUsage) * weight
+			memoryUsed += float64(m.MemoryUsed) * weight
+			load1 += float64(m.Load1) * weight
+			load5 += float64(m.Load5) * weight
+			load15 += float64(m.Load15) * weight
+			rx += float64(m.NetRxRate) * weight
+			tx += float64(m.NetTxRate) * weight
+			diskUsage += float64(m.DiskUsage) * weight
+			if m.CPUMax > agg.CPUMax {
+				agg.CPUMax = m.CPUMax
+			}
+			agg.MemoryTotal = m.MemoryTotal
+			for _, disk := range m.DiskList {
+				if _, ok := diskLast[disk.MountPoint]; !ok {
+					mounts = append(mounts, disk.MountPoint)
+				}
+				diskSums[disk.MountPoint] += float64(disk.Usage) * weight
+				diskLast[disk.MountPoint] = disk
+			}
+		}
+
+		total := float64(samples)
+		agg.Samples = samples
+		agg.CPUUsage = float32(cpu / total)
+		agg.MemoryUsage = float32(memory / total)
+		agg.MemoryUsed = uint64(memoryUsed / total)
+		agg.Load1 = float32(load1 / total)
+		agg.Load5 = float32(load5 / total)
+		agg.Load15 = float32(load15 / total)
+		agg.NetRxRate = float32(rx / total)
+		agg.NetTxRate = float32(tx / total)
+		agg.DiskUsage = float32(diskUsage / total)
+		// 挂载点的容量取区间内最后一次的值，使用率取平均值
+		for _, mount := range mounts {
+			disk := diskLast[mount]
+			disk.Usage = float32(diskSums[mount] / total)
+			agg.DiskList = append(agg.DiskList, disk)
+		}
+		result = append(result, agg)
+	}
+	return result
+}
+
+// Purge 按保留策略清理过期的监控指标
+func (uc *HostMetricUseCase) Purge(ctx context.Context, now time.Time) {
+	for _, resolution := range []int{MetricResolutionRaw, MetricResolutionMinute, MetricResolutionHour} {
+		count, err := uc.metricRepo.DeleteBefore(ctx, resolution, now.Add(-uc.retention(resolution)))
+		if err != nil {
+			appLogger.Error("清理过期主机监控指标失败", zap.Int("resolution", resolution), zap.Error(err))
+			continue
+		}
+		if count > 0 {
+			appLogger.Info("已清理过期主机监控指标", zap.Int("resolution", resolution), zap.Int64("count", count))
+		}
+	}
+}
+
+// retention 指定粒度的数据保留时长
+func (uc *HostMetricUseCase) retention(resolution int) time.Duration {
+	switch resolution {
+	case MetricResolutionRaw:
+		return uc.config.RawRetention
+	case MetricResolutionMinute:
+		return uc.config.MinuteRetention
+	default:
+		return uc.config.HourRetention
+	}
+}
+
+// ResolveResolution 根据查询的时间范围选择数据粒度：
+// 优先使用最细的、保留期覆盖查询起点且点数不过多的粒度
+func (uc *HostMetricUseCase) ResolveResolution(start, end time.Time) int {
+	now := time.Now()
+	span := end.Sub(start)
+	if span <= 6*time.Hour && !start.Before(now.Add(-uc.config.RawRetention)) {
+		return MetricResolutionRaw
+	}
+	if span <= 7*24*time.Hour && !start.Before(now.Add(-uc.config.MinuteRetention)) {
+		return MetricResolutionMinute
+	}
+	return MetricResolutionHour
+}
+
+// Query 查询单台主机在时间范围内的监控指标
+func (uc *HostMetricUseCase) Query(ctx context.Context, hostID uint, resolution int, start, end time.Time) ([]*HostMetric, error) {
+	metrics, err := uc.metricRepo.List(ctx, []uint{hostID}, resolution, start, end)
+	if err != nil {
+		return nil, err
+	}
+	for _, metric := range metrics {
+		decodeDisks(metric)
+	}
+	return metrics, nil
+}
+
+// Compare 查询多台主机同一指标的序列用于对比
+func (uc *HostMetricUseCase) Compare(ctx context.Context, hostIDs []uint, metric string, resolution int, start, end time.Time) ([]*HostMetricSeries, error) {
+	value, ok := metricValues[metric]
+	if !ok {
+		return nil, fmt.Errorf("不支持的指标: %s", metric)
+	}
+	if len(hostIDs) == 0 {
+		return []*HostMetricSeries{}, nil
+	}
+
+	metrics, err := uc.metricRepo.List(ctx, hostIDs, resolution, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	series := make([]*HostMetricSeries, 0, len(hostIDs))
+	byHost := make(map[uint]*HostMetricSeries, len(hostIDs))
+	for _, hostID := range hostIDs {
+		item := &HostMetricSeries{HostID: hostID, Points: []MetricPoint{}}
+		if host, err := uc.hostRepo.GetByID(ctx, hostID); err == nil {
+			item.HostName = host.Name
+			item.HostIP = host.IP
+		}
+		byHost[hostID] = item
+		series = append(series, item)
+	}
+	for _, m := range metrics {
+		if item, ok := byHost[m.HostID]; ok {
+			item.Points = append(item.Points, MetricPoint{Time: m.Timestamp.UnixMilli(), Value: float64(value(m))})
+		}
+	}
+	return series, nil
+}
+
+func encodeDisks(metric *HostMetric) {
+	metric.Disks = ""
+	if len(metric.DiskList) > 0 {
+		if data, err := json.Marshal(metric.DiskList); err == nil {
+			metric.Disks = string(data)
+		}
+	}
+}
+
+func decodeDisks(metric *HostMetric) {
+	metric.DiskList = []DiskMetric{}
+	if metric.Disks != "" {
+		_ = json.Unmarshal([]byte(metric.Disks), &metric.DiskList)
+	}
+}
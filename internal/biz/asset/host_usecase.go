@@ -26,6 +26,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"path"
 	"path/filepath"
 	"strconv"
 	"strings"
@@ -40,6 +41,7 @@ import (
 	"github.com/ydcloud-dy/opshub/pkg/collector"
 	sshclient "github.com/ydcloud-dy/opshub/pkg/ssh"
 	"github.com/ydcloud-dy/opshub/pkg/utils"
+	"golang.org/x/crypto/ssh"
 )
 
 type HostUseCase struct {
@@ -47,14 +49,16 @@ type HostUseCase struct {
 	credentialRepo CredentialRepo
 	groupRepo      AssetGroupRepo
 	cloudRepo      CloudAccountRepo
+	hostKeyStore   sshclient.HostKeyStore
 }
 
-func NewHostUseCase(hostRepo HostRepo, credentialRepo CredentialRepo, groupRepo AssetGroupRepo, cloudRepo CloudAccountRepo) *HostUseCase {
+func NewHostUseCase(hostRepo HostRepo, credentialRepo CredentialRepo, groupRepo AssetGroupRepo, cloudRepo CloudAccountRepo, hostKeyStore sshclient.HostKeyStore) *HostUseCase {
 	return &HostUseCase{
 		hostRepo:       hostRepo,
 		credentialRepo: credentialRepo,
 		groupRepo:      groupRepo,
 		cloudRepo:      cloudRepo,
+		hostKeyStore:   hostKeyStore,
 	}
 }
 
@@ -62,6 +66,10 @@ func NewHostUseCase(hostRepo HostRepo, credentialRepo CredentialRepo, groupRepo
 func (uc *HostUseCase) Create(ctx context.Context, req *HostRequest) (*Host, error) {
 	host := req.ToModel()
 
+	if err := validateJumpHost(ctx, uc.hostRepo, 0, req.JumpHostID); err != nil {
+		return nil, err
+	}
+
 	if err := uc.hostRepo.CreateOrUpdate(ctx, host); err != nil {
 		return nil, err
 	}
@@ -82,6 +90,13 @@ func (uc *HostUseCase) Update(ctx context.Context, req *HostRequest) error {
 		return fmt.Errorf("IP地址 %s 已被其他主机使用", req.IP)
 	}
 
+	if err := validateJumpHost(ctx, uc.hostRepo, host.ID, req.JumpHostID); err != nil {
+		return err
+	}
+
+	// 地址变更后原主机密钥不再适用，未固定的密钥需要重新信任
+	addressChanged := host.IP != req.IP || host.Port != req.Port
+
 	host.Name = req.Name
 	host.GroupID = req.GroupID
 	host.Type = req.Type
@@ -92,14 +107,30 @@ func (uc *HostUseCase) Update(ctx context.Context, req *HostRequest) error {
 	host.IP = req.IP
 	host.Port = req.Port
 	host.CredentialID = req.CredentialID
+	host.JumpHostID = req.JumpHostID
 	host.Tags = req.Tags
 	host.Description = req.Description
 
-	return uc.hostRepo.Update(ctx, host)
+	if err := uc.hostRepo.Update(ctx, host); err != nil {
+		return err
+	}
+
+	if addressChanged && !host.HostKeyPinned && host.HostKeyFingerprint != "" {
+		return uc.hostRepo.UpdateHostKey(ctx, host.ID, nil)
+	}
+	return nil
 }
 
 // Delete 删除主机
 func (uc *HostUseCase) Delete(ctx context.Context, id uint) error {
+	// 检查是否有主机使用该主机作为跳板机
+	count, err := uc.hostRepo.CountByJumpHostID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("该主机正被 %d 台主机用作跳板机，无法删除", count)
+	}
 	return uc.hostRepo.Delete(ctx, id)
 }
 
@@ -128,6 +159,14 @@ func (uc *HostUseCase) GetByID(ctx context.Context, id uint) (*HostInfoVO, error
 		}
 	}
 
+	// 加载跳板机信息
+	if host.JumpHostID > 0 {
+		jumpHost, err := uc.hostRepo.GetByID(ctx, host.JumpHostID)
+		if err == nil && jumpHost != nil {
+			vo.JumpHostName = jumpHost.Name
+		}
+	}
+
 	return vo, nil
 }
 
@@ -215,6 +254,16 @@ func (uc *HostUseCase) toInfoVO(host *Host) *HostInfoVO {
 		lastSeen = host.LastSeen.Format("2006-01-02 15:04:05")
 	}
 
+	var hostKeyUpdatedAt string
+	if host.HostKeyUpdatedAt != nil {
+		hostKeyUpdatedAt = host.HostKeyUpdatedAt.Format("2006-01-02 15:04:05")
+	}
+
+	var cloudSyncedAt string
+	if host.CloudSyncedAt != nil {
+		cloudSyncedAt = host.CloudSyncedAt.Format("2006-01-02 15:04:05")
+	}
+
 	return &HostInfoVO{
 		ID:                host.ID,
 		Name:              host.Name,
@@ -224,10 +273,15 @@ func (uc *HostUseCase) toInfoVO(host *Host) *HostInfoVO {
 		CloudProvider:     host.CloudProvider,
 		CloudProviderText: cloudProviderText,
 		CloudInstanceID:   host.CloudInstanceID,
+		CloudRegion:       host.CloudRegion,
+		CloudInstanceType: host.CloudInstanceType,
+		CloudStatus:       host.CloudStatus,
+		CloudSyncedAt:     cloudSyncedAt,
 		SSHUser:           host.SSHUser,
 		IP:                host.IP,
 		Port:              host.Port,
 		CredentialID:      host.CredentialID,
+		JumpHostID:        host.JumpHostID,
 		Tags:              tags,
 		Description:       host.Description,
 		Status:            host.Status,
@@ -249,6 +303,11 @@ func (uc *HostUseCase) toInfoVO(host *Host) *HostInfoVO {
 		DiskUsage:   host.DiskUsage,
 		Uptime:      host.Uptime,
 		Hostname:    host.Hostname,
+		// SSH主机密钥
+		HostKeyType:        host.HostKeyType,
+		HostKeyFingerprint: host.HostKeyFingerprint,
+		HostKeyPinned:      host.HostKeyPinned,
+		HostKeyUpdatedAt:   hostKeyUpdatedAt,
 	}
 }
 
@@ -271,7 +330,7 @@ func (uc *HostUseCase) CollectHostInfo(ctx context.Context, hostID uint) error {
 	}
 
 	// 创建SSH客户端
-	sshClient, err := uc.createSSHClient(host, credential)
+	sshClient, err := uc.createSSHClient(ctx, host, credential)
 	if err != nil {
 		// 连接失败，更新主机状态为离线
 		host.Status = 0
@@ -327,8 +386,23 @@ func (uc *HostUseCase) CollectHostInfo(ctx context.Context, hostID uint) error {
 	return uc.hostRepo.Update(ctx, host)
 }
 
-// createSSHClient 创建SSH客户端
-func (uc *HostUseCase) createSSHClient(host *Host, credential *Credential) (*sshclient.Client, error) {
+// createSSHClient 从连接池获取SSH客户端，使用完毕后调用 Close 归还连接池
+func (uc *HostUseCase) createSSHClient(ctx context.Context, host *Host, credential *Credential) (*sshclient.Client, error) {
+	dial, err := uc.sshDialFunc(ctx, host, credential)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := sshclient.DefaultPool().Client(ctx, HostPoolKey(ctx, host, credential, host.SSHUser), dial)
+	if err != nil {
+		return nil, fmt.Errorf("创建SSH客户端失败: %w", err)
+	}
+
+	return client, nil
+}
+
+// sshDialFunc 构造建立主机SSH连接的函数
+func (uc *HostUseCase) sshDialFunc(ctx context.Context, host *Host, credential *Credential) (sshclient.DialFunc, error) {
 	var privateKey []byte
 
 	// 检查凭证信息是否完整
@@ -348,19 +422,129 @@ func (uc *HostUseCase) createSSHClient(host *Host, credential *Credential) (*ssh
 		privateKey = []byte(credential.PrivateKey)
 	}
 
-	client, err := sshclient.NewClient(
-		host.IP,
-		host.Port,
-		host.SSHUser,
-		credential.Password,
-		privateKey,
-		credential.Passphrase,
-	)
+	// 解析跳板机链
+	jumpHosts, err := ResolveJumpHosts(ctx, uc.hostRepo, uc.credentialRepo, uc.hostKeyStore, host)
 	if err != nil {
-		return nil, fmt.Errorf("创建SSH客户端失败: %w", err)
+		return nil, err
 	}
 
-	return client, nil
+	// 证书凭证在建立连接时才签发，避免连接池复用时使用过期证书
+	if credential.IsCertificate() {
+		return func() (*ssh.Client, error) {
+			signer, err := SSHCertSigner(ctx, host, host.SSHUser)
+			if err != nil {
+				return nil, err
+			}
+			return sshclient.DialClientWithSigner(
+				host.IP,
+				host.Port,
+				host.SSHUser,
+				signer,
+				sshclient.NewHostKeyCallback(uc.hostKeyStore, host.ID),
+				sshclient.HostKeyAlgorithms(uc.hostKeyStore, host.ID),
+				jumpHosts...,
+			)
+		}, nil
+	}
+
+	return func() (*ssh.Client, error) {
+		return sshclient.DialClient(
+			host.IP,
+			host.Port,
+			host.SSHUser,
+			credential.Password,
+			privateKey,
+			credential.Passphrase,
+			sshclient.NewHostKeyCallback(uc.hostKeyStore, host.ID),
+			sshclient.HostKeyAlgorithms(uc.hostKeyStore, host.ID),
+			jumpHosts...,
+		)
+	}, nil
+}
+
+// HostPoolKey 连接池键：主机地址、登录用户、凭证及其更新时间、跳板机共同决定一条连接。
+// 证书凭证按 ctx 中的证书身份签发，不同身份的证书权限不同，身份也作为键的一部分
+func HostPoolKey(ctx context.Context, host *Host, credential *Credential, user string) string {
+	key := fmt.Sprintf("%d|%s:%d|%s|%d@%d|%d",
+		host.ID, host.IP, host.Port, user, credential.ID, credential.UpdatedAt.UnixNano(), host.JumpHostID)
+	if credential.IsCertificate() {
+		key += "|" + SSHCertIdentityFrom(ctx).PoolKey()
+	}
+	return key
+}
+
+// GetHostKeyStore 获取主机密钥信任存储（用于终端功能）
+func (uc *HostUseCase) GetHostKeyStore() sshclient.HostKeyStore {
+	return uc.hostKeyStore
+}
+
+// GetJumpHosts 获取主机的跳板机链（用于终端功能）
+func (uc *HostUseCase) GetJumpHosts(ctx context.Context, hostID uint) ([]*sshclient.JumpHost, error) {
+	host, err := uc.hostRepo.GetByID(ctx, hostID)
+	if err != nil {
+		return nil, fmt.Errorf("获取主机信息失败: %w", err)
+	}
+	return ResolveJumpHosts(ctx, uc.hostRepo, uc.credentialRepo, uc.hostKeyStore, host)
+}
+
+// GetHostKey 获取主机记录的密钥，并扫描主机当前密钥进行比对
+func (uc *HostUseCase) GetHostKey(ctx context.Context, hostID uint, scan bool) (*HostKeyVO, error) {
+	host, err := uc.hostRepo.GetByID(ctx, hostID)
+	if err != nil {
+		return nil, fmt.Errorf("获取主机信息失败: %w", err)
+	}
+
+	vo := &HostKeyVO{
+		HostID:      host.ID,
+		KeyType:     host.HostKeyType,
+		Fingerprint: host.HostKeyFingerprint,
+		Pinned:      host.HostKeyPinned,
+	}
+	if host.HostKeyUpdatedAt != nil {
+		vo.UpdatedAt = host.HostKeyUpdatedAt.Format("2006-01-02 15:04:05")
+	}
+
+	if scan {
+		jumpHosts, err := ResolveJumpHosts(ctx, uc.hostRepo, uc.credentialRepo, uc.hostKeyStore, host)
+		if err != nil {
+			return nil, err
+		}
+		current, err := sshclient.ScanHostKey(host.IP, host.Port, jumpHosts...)
+		if err != nil {
+			return nil, err
+		}
+		vo.CurrentKeyType = current.KeyType
+		vo.CurrentFingerprint = current.Fingerprint
+		vo.Matched = host.HostKeyFingerprint != "" && host.HostKeyFingerprint == current.Fingerprint
+	}
+
+	return vo, nil
+}
+
+// PinHostKey 手动固定主机密钥
+func (uc *HostUseCase) PinHostKey(ctx context.Context, hostID uint, req *HostKeyRequest) error {
+	if _, err := uc.hostRepo.GetByID(ctx, hostID); err != nil {
+		return fmt.Errorf("获取主机信息失败: %w", err)
+	}
+
+	key, err := sshclient.ParseHostKey(req.HostKey)
+	if err != nil {
+		return err
+	}
+
+	return uc.hostRepo.UpdateHostKey(ctx, hostID, &HostKey{
+		KeyType:     key.KeyType,
+		Fingerprint: key.Fingerprint,
+		Pinned:      true,
+	})
+}
+
+// ResetHostKey 清除主机记录的密钥，下次连接时重新信任
+func (uc *HostUseCase) ResetHostKey(ctx context.Context, hostID uint) error {
+	if _, err := uc.hostRepo.GetByID(ctx, hostID); err != nil {
+		return fmt.Errorf("获取主机信息失败: %w", err)
+	}
+	return uc.hostRepo.UpdateHostKey(ctx, hostID, nil)
 }
 
 func min(a, b int) int {
@@ -395,15 +579,24 @@ func (uc *HostUseCase) TestConnection(ctx context.Context, hostID uint) error {
 		return fmt.Errorf("获取凭证失败: %w", err)
 	}
 
-	// 创建SSH客户端
-	sshClient, err := uc.createSSHClient(host, credential)
+	// 测试连接不使用连接池，每次都重新握手
+	dial, err := uc.sshDialFunc(ctx, host, credential)
 	if err != nil {
 		return fmt.Errorf("创建SSH连接失败: %w", err)
 	}
-	defer sshClient.Close()
+	client, err := dial()
+	if err != nil {
+		return fmt.Errorf("创建SSH连接失败: %w", err)
+	}
+	defer client.Close()
 
 	// 测试连接
-	if err := sshClient.TestConnection(); err != nil {
+	session, err := client.NewSession()
+	if err != nil {
+		return fmt.Errorf("连接测试失败: %w", err)
+	}
+	defer session.Close()
+	if err := session.Run("echo ok"); err != nil {
 		return fmt.Errorf("连接测试失败: %w", err)
 	}
 
@@ -422,7 +615,7 @@ func (uc *HostUseCase) BatchCollectHostInfo(ctx context.Context, hostIDs []uint)
 // BatchDelete 批量删除主机
 func (uc *HostUseCase) BatchDelete(ctx context.Context, hostIDs []uint) error {
 	for _, hostID := range hostIDs {
-		if err := uc.hostRepo.Delete(ctx, hostID); err != nil {
+		if err := uc.Delete(ctx, hostID); err != nil {
 			return fmt.Errorf("删除主机 %d 失败: %w", hostID, err)
 		}
 	}
@@ -434,17 +627,31 @@ func (uc *HostUseCase) toCredentialVO(credential *Credential) *CredentialVO {
 	typeText := "密码"
 	if credential.Type == "key" {
 		typeText = "密钥"
+	} else if credential.IsCertificate() {
+		typeText = "证书"
 	}
 
 	return &CredentialVO{
-		ID:          credential.ID,
-		Name:        credential.Name,
-		Type:        credential.Type,
-		TypeText:    typeText,
-		Username:    credential.Username,
-		Description: credential.Description,
-		CreateTime:  credential.CreatedAt.Format("2006-01-02 15:04:05"),
+		ID:           credential.ID,
+		Name:         credential.Name,
+		Type:         credential.Type,
+		TypeText:     typeText,
+		Username:     credential.Username,
+		Source:       credential.Source,
+		SecretPath:   credential.SecretPath,
+		Description:  credential.Description,
+		CreateTime:   credential.CreatedAt.Format("2006-01-02 15:04:05"),
+		RotationDays: credential.RotationDays,
+		RotatedAt:    formatRotatedAt(credential.RotatedAt),
+	}
+}
+
+// formatRotatedAt 格式化最后轮换时间，从未轮换时为空
+func formatRotatedAt(t *time.Time) string {
+	if t == nil {
+		return ""
 	}
+	return t.Format("2006-01-02 15:04:05")
 }
 
 // GetCredentialRepo 获取凭证Repo（用于终端功能）
@@ -452,7 +659,7 @@ func (uc *HostUseCase) GetCredentialRepo() CredentialRepo {
 	return uc.credentialRepo
 }
 
-// GetByIDDecrypted 根据ID获取凭证（解密后的，用于编辑时回显）
+// GetByIDDecrypted 根据ID获取凭证（解密后的，用于建立连接），引用外部密钥的凭证在这里读取外部密钥
 func (uc *CredentialUseCase) GetByIDDecrypted(ctx context.Context, id uint) (*Credential, error) {
 	credential, err := uc.repo.GetByIDDecrypted(ctx, id)
 	if err != nil {
@@ -462,10 +669,24 @@ func (uc *CredentialUseCase) GetByIDDecrypted(ctx context.Context, id uint) (*Cr
 	return credential, nil
 }
 
+// GetForEdit 根据ID获取凭证用于编辑时回显，引用外部密钥的凭证不读取外部密钥
+func (uc *CredentialUseCase) GetForEdit(ctx context.Context, id uint) (*Credential, error) {
+	credential, err := uc.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if credential.IsExternal() {
+		return credential, nil
+	}
+
+	return uc.repo.GetByIDDecrypted(ctx, id)
+}
+
 // CredentialUseCase 凭证用例
 type CredentialUseCase struct {
-	repo     CredentialRepo
-	hostRepo HostRepo
+	repo       CredentialRepo
+	hostRepo   HostRepo
+	accessRepo CredentialSecretAccessRepo
 }
 
 func NewCredentialUseCase(repo CredentialRepo, hostRepo HostRepo) *CredentialUseCase {
@@ -475,9 +696,31 @@ func NewCredentialUseCase(repo CredentialRepo, hostRepo HostRepo) *CredentialUse
 	}
 }
 
+// SetSecretAccessRepo 设置外部密钥读取审计仓库
+func (uc *CredentialUseCase) SetSecretAccessRepo(repo CredentialSecretAccessRepo) {
+	uc.accessRepo = repo
+}
+
+// ListSecretAccesses 分页查询凭证的外部密钥读取记录
+func (uc *CredentialUseCase) ListSecretAccesses(ctx context.Context, id uint, page, pageSize int) ([]*CredentialSecretAccess, int64, error) {
+	if uc.accessRepo == nil {
+		return []*CredentialSecretAccess{}, 0, nil
+	}
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+	return uc.accessRepo.List(ctx, id, page, pageSize)
+}
+
 // Create 创建凭证
 func (uc *CredentialUseCase) Create(ctx context.Context, req *CredentialRequest) (*Credential, error) {
 	credential := req.ToModel()
+	if credential.IsExternal() && credential.SecretPath == "" {
+		return nil, fmt.Errorf("引用外部密钥时必须填写密钥路径")
+	}
 
 	if err := uc.repo.Create(ctx, credential); err != nil {
 		return nil, err
@@ -488,15 +731,32 @@ func (uc *CredentialUseCase) Create(ctx context.Context, req *CredentialRequest)
 
 // Update 更新凭证
 func (uc *CredentialUseCase) Update(ctx context.Context, req *CredentialRequest) error {
-	credential, err := uc.repo.GetByID(ctx, req.ID)
+	// 保存时会重新加密全部敏感字段，需要先取出明文，避免未修改的字段被重复加密
+	credential, err := uc.GetForEdit(ctx, req.ID)
 	if err != nil {
 		return fmt.Errorf("凭证不存在")
 	}
 
-	credential.Name = req.Name
-	credential.Type = req.Type
-	credential.Username = req.Username
-	credential.Description = req.Description
+	updated := req.ToModel()
+	if updated.IsExternal() && updated.SecretPath == "" {
+		return fmt.Errorf("引用外部密钥时必须填写密钥路径")
+	}
+	credential.Name = updated.Name
+	credential.Type = updated.Type
+	credential.Username = updated.Username
+	credential.Source = updated.Source
+	credential.SecretPath = updated.SecretPath
+	credential.Description = updated.Description
+	credential.RotationDays = updated.RotationDays
+	defer InvalidateCredentialSecret(credential.ID)
+
+	// 改为引用外部密钥或证书凭证时清除数据库中的敏感字段
+	if credential.IsExternal() || credential.IsCertificate() {
+		credential.Password = ""
+		credential.PrivateKey = ""
+		credential.Passphrase = ""
+		return uc.repo.Update(ctx, credential)
+	}
 
 	// 如果提供了新的密码或私钥，更新它们
 	if req.Password != "" {
@@ -514,7 +774,11 @@ func (uc *CredentialUseCase) Update(ctx context.Context, req *CredentialRequest)
 
 // Delete 删除凭证
 func (uc *CredentialUseCase) Delete(ctx context.Context, id uint) error {
-	return uc.repo.Delete(ctx, id)
+	if err := uc.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+	InvalidateCredentialSecret(id)
+	return nil
 }
 
 // GetByID 根据ID获取凭证
@@ -534,20 +798,26 @@ func (uc *CredentialUseCase) List(ctx context.Context, page, pageSize int, keywo
 		typeText := "密码"
 		if cred.Type == "key" {
 			typeText = "密钥"
+		} else if cred.IsCertificate() {
+			typeText = "证书"
 		}
 
 		// 统计使用该凭证的主机数量
 		usedCount, _ := uc.hostRepo.CountByCredentialID(ctx, cred.ID)
 
 		vo := &CredentialVO{
-			ID:          cred.ID,
-			Name:        cred.Name,
-			Type:        cred.Type,
-			TypeText:    typeText,
-			Username:    cred.Username,
-			Description: cred.Description,
-			CreateTime:  cred.CreatedAt.Format("2006-01-02 15:04:05"),
-			HostCount:   usedCount,
+			ID:           cred.ID,
+			Name:         cred.Name,
+			Type:         cred.Type,
+			TypeText:     typeText,
+			Username:     cred.Username,
+			Source:       cred.Source,
+			SecretPath:   cred.SecretPath,
+			Description:  cred.Description,
+			CreateTime:   cred.CreatedAt.Format("2006-01-02 15:04:05"),
+			HostCount:    usedCount,
+			RotationDays: cred.RotationDays,
+			RotatedAt:    formatRotatedAt(cred.RotatedAt),
 		}
 		vos = append(vos, vo)
 	}
@@ -567,20 +837,26 @@ func (uc *CredentialUseCase) GetAll(ctx context.Context) ([]*CredentialVO, error
 		typeText := "密码"
 		if cred.Type == "key" {
 			typeText = "密钥"
+		} else if cred.IsCertificate() {
+			typeText = "证书"
 		}
 
 		// 统计使用该凭证的主机数量
 		usedCount, _ := uc.hostRepo.CountByCredentialID(ctx, cred.ID)
 
 		vo := &CredentialVO{
-			ID:          cred.ID,
-			Name:        cred.Name,
-			Type:        cred.Type,
-			TypeText:    typeText,
-			Username:    cred.Username,
-			Description: cred.Description,
-			CreateTime:  cred.CreatedAt.Format("2006-01-02 15:04:05"),
-			HostCount:   usedCount,
+			ID:           cred.ID,
+			Name:         cred.Name,
+			Type:         cred.Type,
+			TypeText:     typeText,
+			Username:     cred.Username,
+			Source:       cred.Source,
+			SecretPath:   cred.SecretPath,
+			Description:  cred.Description,
+			CreateTime:   cred.CreatedAt.Format("2006-01-02 15:04:05"),
+			HostCount:    usedCount,
+			RotationDays: cred.RotationDays,
+			RotatedAt:    formatRotatedAt(cred.RotatedAt),
 		}
 		vos = append(vos, vo)
 	}
@@ -635,6 +911,9 @@ func (uc *CloudAccountUseCase) Update(ctx context.Context, req *CloudAccountRequ
 	account.Region = req.Region
 	account.Description = req.Description
 	account.Status = req.Status
+	account.SyncInterval = req.SyncInterval
+	account.SyncRegions = normalizeSyncRegions(req.SyncRegions)
+	account.SyncGroupID = req.SyncGroupID
 
 	return uc.repo.Update(ctx, account)
 }
@@ -726,17 +1005,7 @@ func (uc *CloudAccountUseCase) GetInstances(ctx context.Context, accountID uint,
 	}
 
 	// 根据不同的云厂商调用不同的SDK获取实例列表
-	var instances []CloudInstance
-
-	switch account.Provider {
-	case "aliyun":
-		instances, err = uc.listAliyunInstances(account, region)
-	case "tencent":
-		instances, err = uc.listTencentInstances(account, region)
-	default:
-		return nil, fmt.Errorf("暂不支持该云平台")
-	}
-
+	instances, _, err := uc.listInstances(account, region)
 	if err != nil {
 		return nil, fmt.Errorf("获取云主机列表失败: %w", err)
 	}
@@ -767,17 +1036,29 @@ func (uc *CloudAccountUseCase) toVO(account *CloudAccount) *CloudAccountVO {
 		providerText = "AWS"
 	case "huawei":
 		providerText = "华为云"
+	case "jdcloud":
+		providerText = "京东云"
+	}
+
+	var lastSyncAt string
+	if account.LastSyncAt != nil {
+		lastSyncAt = account.LastSyncAt.Format("2006-01-02 15:04:05")
 	}
 
 	return &CloudAccountVO{
-		ID:           account.ID,
-		Name:         account.Name,
-		Provider:     account.Provider,
-		ProviderText: providerText,
-		Region:       account.Region,
-		Description:  account.Description,
-		Status:       account.Status,
-		CreateTime:   account.CreatedAt.Format("2006-01-02 15:04:05"),
+		ID:             account.ID,
+		Name:           account.Name,
+		Provider:       account.Provider,
+		ProviderText:   providerText,
+		Region:         account.Region,
+		Description:    account.Description,
+		Status:         account.Status,
+		SyncInterval:   account.SyncInterval,
+		SyncRegions:    account.SyncRegions,
+		SyncGroupID:    account.SyncGroupID,
+		LastSyncAt:     lastSyncAt,
+		LastSyncStatus: account.LastSyncStatus,
+		CreateTime:     account.CreatedAt.Format("2006-01-02 15:04:05"),
 	}
 }
 
@@ -789,23 +1070,13 @@ func (uc *CloudAccountUseCase) ImportFromCloud(ctx context.Context, req *CloudIm
 	}
 
 	// 根据不同的云厂商调用不同的SDK获取实例列表
-	// 这里先实现阿里云的导入
-	var instances []CloudInstance
-
-	switch account.Provider {
-	case "aliyun":
-		instances, err = uc.listAliyunInstances(account, req.Region)
-	case "tencent":
-		instances, err = uc.listTencentInstances(account, req.Region)
-	default:
-		return fmt.Errorf("暂不支持该云平台")
-	}
-
+	instances, _, err := uc.listInstances(account, req.Region)
 	if err != nil {
 		return fmt.Errorf("获取云主机列表失败: %w", err)
 	}
 
 	// 批量导入主机
+	now := time.Now()
 	successCount := 0
 	var importErrors []string
 
@@ -821,6 +1092,7 @@ func (uc *CloudAccountUseCase) ImportFromCloud(ctx context.Context, req *CloudIm
 			// 主机已存在，更新分组
 			existHost.GroupID = req.GroupID
 			existHost.Name = instance.Name
+			applyCloudInstance(existHost, &instance, now)
 			if err := hostUseCase.hostRepo.Update(ctx, existHost); err != nil {
 				importErrors = append(importErrors, fmt.Sprintf("实例 %s 更新失败: %v", instance.InstanceID, err))
 			} else {
@@ -854,6 +1126,7 @@ func (uc *CloudAccountUseCase) ImportFromCloud(ctx context.Context, req *CloudIm
 			if instance.OS != "" {
 				existByIP.OS = instance.OS
 			}
+			applyCloudInstance(existByIP, &instance, now)
 			if err := hostUseCase.hostRepo.Update(ctx, existByIP); err != nil {
 				importErrors = append(importErrors, fmt.Sprintf("实例 %s 关联IP失败: %v", instance.InstanceID, err))
 			} else {
@@ -879,6 +1152,7 @@ func (uc *CloudAccountUseCase) ImportFromCloud(ctx context.Context, req *CloudIm
 		host := hostReq.ToModel()
 		host.Status = -1 // 初始状态未知
 		host.OS = instance.OS
+		applyCloudInstance(host, &instance, now)
 
 		if err := hostUseCase.hostRepo.Create(ctx, host); err != nil {
 			importErrors = append(importErrors, fmt.Sprintf("实例 %s 创建失败: %v", instance.InstanceID, err))
@@ -896,12 +1170,39 @@ func (uc *CloudAccountUseCase) ImportFromCloud(ctx context.Context, req *CloudIm
 
 // CloudInstance 云主机实例
 type CloudInstance struct {
-	InstanceID string
-	Name       string
-	PublicIP   string
-	PrivateIP  string
-	OS         string
-	Status     string
+	InstanceID   string
+	Name         string
+	PublicIP     string
+	PrivateIP    string
+	OS           string
+	Status       string
+	Region       string
+	InstanceType string
+	CPU          int
+	MemoryMB     int
+	Tags         []string // key=value 格式
+}
+
+// maxCloudInstances 单个区域最多获取的实例数，达到上限时实例列表可能不完整
+const maxCloudInstances = 1000
+
+// listInstances 根据云厂商获取区域内的实例列表，complete 表示列表是否完整
+func (uc *CloudAccountUseCase) listInstances(account *CloudAccount, region string) (instances []CloudInstance, complete bool, err error) {
+	switch account.Provider {
+	case "aliyun":
+		instances, err = uc.listAliyunInstances(account, region)
+		complete = len(instances) < maxCloudInstances
+	case "tencent":
+		instances, err = uc.listTencentInstances(account, region)
+		complete = len(instances) < maxCloudInstances
+	case "jdcloud":
+		// 京东云只获取第一页（100条）
+		instances, err = uc.listJDCloudInstances(account, region)
+		complete = len(instances) < 100
+	default:
+		return nil, false, fmt.Errorf("暂不支持该云平台")
+	}
+	return instances, complete, err
 }
 
 // CloudRegion 云区域
@@ -1044,13 +1345,23 @@ func (uc *CloudAccountUseCase) listAliyunInstances(account *CloudAccount, region
 				privateIP = instance.InnerIpAddress.IpAddress[0]
 			}
 
+			var tags []string
+			for _, tag := range instance.Tags.Tag {
+				tags = append(tags, cloudTag(tag.TagKey, tag.TagValue))
+			}
+
 			allInstances = append(allInstances, CloudInstance{
-				InstanceID: instance.InstanceId,
-				Name:       instance.InstanceName,
-				PublicIP:   publicIP,
-				PrivateIP:  privateIP,
-				OS:         instance.OSName,
-				Status:     instance.Status,
+				InstanceID:   instance.InstanceId,
+				Name:         instance.InstanceName,
+				PublicIP:     publicIP,
+				PrivateIP:    privateIP,
+				OS:           instance.OSName,
+				Status:       instance.Status,
+				Region:       region,
+				InstanceType: instance.InstanceType,
+				CPU:          instance.Cpu,
+				MemoryMB:     instance.Memory,
+				Tags:         tags,
 			})
 		}
 
@@ -1062,7 +1373,7 @@ func (uc *CloudAccountUseCase) listAliyunInstances(account *CloudAccount, region
 		pageNumber++
 
 		// 最多获取10页（1000条）
-		if pageNumber > 10 {
+		if pageNumber*pageSize > maxCloudInstances {
 			break
 		}
 	}
@@ -1126,13 +1437,42 @@ func (uc *CloudAccountUseCase) listTencentInstances(account *CloudAccount, regio
 				status = *inst.InstanceState
 			}
 
+			var instanceType string
+			var cpu, memoryMB int
+			if inst.InstanceType != nil {
+				instanceType = *inst.InstanceType
+			}
+			if inst.CPU != nil {
+				cpu = int(*inst.CPU)
+			}
+			if inst.Memory != nil {
+				memoryMB = int(*inst.Memory) * 1024 // 腾讯云返回的内存单位为GB
+			}
+
+			var tags []string
+			for _, tag := range inst.Tags {
+				if tag == nil || tag.Key == nil {
+					continue
+				}
+				var value string
+				if tag.Value != nil {
+					value = *tag.Value
+				}
+				tags = append(tags, cloudTag(*tag.Key, value))
+			}
+
 			allInstances = append(allInstances, CloudInstance{
-				InstanceID: instanceID,
-				Name:       instanceName,
-				PublicIP:   publicIP,
-				PrivateIP:  privateIP,
-				OS:         osName,
-				Status:     status,
+				InstanceID:   instanceID,
+				Name:         instanceName,
+				PublicIP:     publicIP,
+				PrivateIP:    privateIP,
+				OS:           osName,
+				Status:       status,
+				Region:       region,
+				InstanceType: instanceType,
+				CPU:          cpu,
+				MemoryMB:     memoryMB,
+				Tags:         tags,
 			})
 		}
 
@@ -1140,7 +1480,7 @@ func (uc *CloudAccountUseCase) listTencentInstances(account *CloudAccount, regio
 			break
 		}
 		offset += 100
-		if offset >= 1000 { // 最多获取1000个实例
+		if offset >= maxCloudInstances { // 最多获取1000个实例
 			break
 		}
 	}
@@ -1181,12 +1521,17 @@ func (uc *CloudAccountUseCase) listJDCloudInstances(account *CloudAccount, regio
 	var result struct {
 		Result struct {
 			Instances []struct {
-				InstanceID string `json:"instanceId"`
-				Name       string `json:"name"`
-				Status     string `json:"status"`
-				PrimaryIP  string `json:"primaryIpAddress"`
-				PrivateIP  string `json:"privateIpAddress"`
-				OSName     string `json:"osName"`
+				InstanceID   string `json:"instanceId"`
+				Name         string `json:"name"`
+				Status       string `json:"status"`
+				PrimaryIP    string `json:"primaryIpAddress"`
+				PrivateIP    string `json:"privateIpAddress"`
+				OSName       string `json:"osName"`
+				InstanceType string `json:"instanceType"`
+				Tags         []struct {
+					Key   string `json:"key"`
+					Value string `json:"value"`
+				} `json:"tags"`
 			} `json:"instances"`
 		} `json:"result"`
 	}
@@ -1197,13 +1542,21 @@ func (uc *CloudAccountUseCase) listJDCloudInstances(account *CloudAccount, regio
 
 	var instances []CloudInstance
 	for _, inst := range result.Result.Instances {
+		var tags []string
+		for _, tag := range inst.Tags {
+			tags = append(tags, cloudTag(tag.Key, tag.Value))
+		}
+
 		instances = append(instances, CloudInstance{
-			InstanceID: inst.InstanceID,
-			Name:       inst.Name,
-			PublicIP:   inst.PrimaryIP,
-			PrivateIP:  inst.PrivateIP,
-			OS:         inst.OSName,
-			Status:     inst.Status,
+			InstanceID:   inst.InstanceID,
+			Name:         inst.Name,
+			PublicIP:     inst.PrimaryIP,
+			PrivateIP:    inst.PrivateIP,
+			OS:           inst.OSName,
+			Status:       inst.Status,
+			Region:       region,
+			InstanceType: inst.InstanceType,
+			Tags:         tags,
 		})
 	}
 
@@ -1509,7 +1862,7 @@ func (uc *HostUseCase) ListFiles(ctx context.Context, hostID uint, remotePath st
 		return nil, fmt.Errorf("获取凭证失败: %w", err)
 	}
 
-	sshClient, err := uc.createSSHClient(host, credential)
+	sshClient, err := uc.createSSHClient(ctx, host, credential)
 	if err != nil {
 		return nil, fmt.Errorf("创建SSH连接失败: %w", err)
 	}
@@ -1545,123 +1898,70 @@ func (uc *HostUseCase) ListFiles(ctx context.Context, hostID uint, remotePath st
 
 // UploadFile 上传文件到主机
 func (uc *HostUseCase) UploadFile(ctx context.Context, hostID uint, reader io.Reader, remotePath, filename string) error {
-	host, err := uc.hostRepo.GetByID(ctx, hostID)
-	if err != nil {
-		return fmt.Errorf("获取主机信息失败: %w", err)
-	}
-
-	if host.CredentialID == 0 {
-		return fmt.Errorf("主机未配置凭证")
-	}
+	return uc.withHostFiles(ctx, hostID, []string{remotePath}, func(sshClient *sshclient.Client, paths []string) error {
+		// 构造完整的远程文件路径
+		fullPath := filepath.Join(paths[0], filename)
 
-	credential, err := uc.credentialRepo.GetByIDDecrypted(ctx, host.CredentialID)
-	if err != nil {
-		return fmt.Errorf("获取凭证失败: %w", err)
-	}
-
-	sshClient, err := uc.createSSHClient(host, credential)
-	if err != nil {
-		return fmt.Errorf("创建SSH连接失败: %w", err)
-	}
-	defer sshClient.Close()
-
-	// 如果路径以 ~ 开头，替换为用户主目录
-	if strings.HasPrefix(remotePath, "~") {
-		homeDir, err := sshClient.Execute("echo $HOME")
-		if err != nil {
-			return fmt.Errorf("获取用户主目录失败: %w", err)
+		// 上传文件
+		if err := sshClient.UploadFromReader(reader, fullPath); err != nil {
+			return fmt.Errorf("上传文件失败: %w", err)
 		}
-		homeDir = strings.TrimSpace(homeDir)
-		remotePath = strings.Replace(remotePath, "~", homeDir, 1)
-	}
-
-	// 构造完整的远程文件路径
-	fullPath := filepath.Join(remotePath, filename)
-
-	// 上传文件
-	if err := sshClient.UploadFromReader(reader, fullPath); err != nil {
-		return fmt.Errorf("上传文件失败: %w", err)
-	}
-
-	return nil
+		return nil
+	})
 }
 
 // DownloadFile 从主机下载文件
 func (uc *HostUseCase) DownloadFile(ctx context.Context, hostID uint, remotePath string, writer io.Writer) error {
-	host, err := uc.hostRepo.GetByID(ctx, hostID)
-	if err != nil {
-		return fmt.Errorf("获取主机信息失败: %w", err)
-	}
-
-	if host.CredentialID == 0 {
-		return fmt.Errorf("主机未配置凭证")
-	}
-
-	credential, err := uc.credentialRepo.GetByIDDecrypted(ctx, host.CredentialID)
-	if err != nil {
-		return fmt.Errorf("获取凭证失败: %w", err)
-	}
-
-	sshClient, err := uc.createSSHClient(host, credential)
-	if err != nil {
-		return fmt.Errorf("创建SSH连接失败: %w", err)
-	}
-	defer sshClient.Close()
-
-	// 如果路径以 ~ 开头，替换为用户主目录
-	if strings.HasPrefix(remotePath, "~") {
-		homeDir, err := sshClient.Execute("echo $HOME")
-		if err != nil {
-			return fmt.Errorf("获取用户主目录失败: %w", err)
+	return uc.withHostFiles(ctx, hostID, []string{remotePath}, func(sshClient *sshclient.Client, paths []string) error {
+		// 下载文件
+		if err := sshClient.DownloadToWriter(paths[0], writer); err != nil {
+			return fmt.Errorf("下载文件失败: %w", err)
 		}
-		homeDir = strings.TrimSpace(homeDir)
-		remotePath = strings.Replace(remotePath, "~", homeDir, 1)
-	}
-
-	// 下载文件
-	if err := sshClient.DownloadToWriter(remotePath, writer); err != nil {
-		return fmt.Errorf("下载文件失败: %w", err)
-	}
-
-	return nil
+		return nil
+	})
 }
 
-// DeleteFile 删除主机上的文件
-func (uc *HostUseCase) DeleteFile(ctx context.Context, hostID uint, remotePath string) error {
-	host, err := uc.hostRepo.GetByID(ctx, hostID)
-	if err != nil {
-		return fmt.Errorf("获取主机信息失败: %w", err)
-	}
-
-	if host.CredentialID == 0 {
-		return fmt.Errorf("主机未配置凭证")
-	}
-
-	credential, err := uc.credentialRepo.GetByIDDecrypted(ctx, host.CredentialID)
-	if err != nil {
-		return fmt.Errorf("获取凭证失败: %w", err)
-	}
+// protectedDeletePaths 不允许递归删除的系统目录
+var protectedDeletePaths = map[string]bool{
+	"/": true, "/bin": true, "/boot": true, "/dev": true, "/etc": true, "/home": true,
+	"/lib": true, "/lib32": true, "/lib64": true, "/libx32": true, "/media": true, "/mnt": true,
+	"/opt": true, "/proc": true, "/root": true, "/run": true, "/sbin": true, "/srv": true,
+	"/sys": true, "/tmp": true, "/usr": true, "/var": true,
+	"/usr/bin": true, "/usr/lib": true, "/usr/lib64": true, "/usr/local": true, "/usr/sbin": true,
+	"/var/lib": true, "/var/log": true,
+}
 
-	sshClient, err := uc.createSSHClient(host, credential)
-	if err != nil {
-		return fmt.Errorf("创建SSH连接失败: %w", err)
-	}
-	defer sshClient.Close()
+// DeleteFile 删除主机上的文件，recursive 为 true 时递归删除目录
+func (uc *HostUseCase) DeleteFile(ctx context.Context, hostID uint, remotePath string, recursive bool) error {
+	return uc.withHostFiles(ctx, hostID, []string{remotePath, "~"}, func(sshClient *sshclient.Client, paths []string) error {
+		if !path.IsAbs(paths[0]) {
+			return fmt.Errorf("请使用绝对路径: %s", remotePath)
+		}
+		target := path.Clean(paths[0])
+		if !recursive {
+			// 删除文件
+			if err := sshClient.RemoveFile(target); err != nil {
+				if info, statErr := sshClient.StatFile(target); statErr == nil && info.IsDir {
+					return fmt.Errorf("目录不为空，需确认后递归删除")
+				}
+				return fmt.Errorf("删除文件失败: %w", err)
+			}
+			return nil
+		}
 
-	// 如果路径以 ~ 开头，替换为用户主目录
-	if strings.HasPrefix(remotePath, "~") {
-		homeDir, err := sshClient.Execute("echo $HOME")
+		// 递归删除时保护系统目录和用户主目录，上级目录中的符号链接解析后再比较
+		parent, err := sshClient.RealPath(path.Dir(target))
 		if err != nil {
-			return fmt.Errorf("获取用户主目录失败: %w", err)
+			return err
 		}
-		homeDir = strings.TrimSpace(homeDir)
-		remotePath = strings.Replace(remotePath, "~", homeDir, 1)
-	}
-
-	// 删除文件
-	if err := sshClient.RemoveFile(remotePath); err != nil {
-		return fmt.Errorf("删除文件失败: %w", err)
-	}
-
-	return nil
+		home, err := sshClient.RealPath(paths[1])
+		if err != nil {
+			return err
+		}
+		resolved := path.Join(parent, path.Base(target))
+		if protectedDeletePaths[target] || protectedDeletePaths[resolved] || target == path.Clean(paths[1]) || resolved == home {
+			return fmt.Errorf("不允许删除目录: %s", target)
+		}
+		return sshClient.RemoveAll(target)
+	})
 }
@@ -0,0 +1,123 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package asset
+
+import (
+	"context"
+	"encoding/pem"
+	"fmt"
+
+	sshclient "github.com/ydcloud-dy/opshub/pkg/ssh"
+	"golang.org/x/crypto/ssh"
+)
+
+// maxJumpHostDepth 跳板机链最大层数
+const maxJumpHostDepth = 5
+
+// ResolveJumpHosts 解析主机的跳板机链，返回顺序为由外到内（第一个为直接可达的跳板机）
+// 每一跳都使用各自的凭证和主机密钥记录
+func ResolveJumpHosts(ctx context.Context, hostRepo HostRepo, credentialRepo CredentialRepo, hostKeyStore sshclient.HostKeyStore, host *Host) ([]*sshclient.JumpHost, error) {
+	var chain []*sshclient.JumpHost
+	visited := map[uint]bool{host.ID: true}
+
+	for jumpID := host.JumpHostID; jumpID > 0; {
+		if visited[jumpID] {
+			return nil, fmt.Errorf("跳板机配置存在循环引用")
+		}
+		if len(chain) >= maxJumpHostDepth {
+			return nil, fmt.Errorf("跳板机链超过最大层数 %d", maxJumpHostDepth)
+		}
+		visited[jumpID] = true
+
+		jump, err := hostRepo.GetByID(ctx, jumpID)
+		if err != nil {
+			return nil, fmt.Errorf("获取跳板机(ID:%d)失败: %w", jumpID, err)
+		}
+		if jump.CredentialID == 0 {
+			return nil, fmt.Errorf("跳板机 %s 未配置凭证", jump.Name)
+		}
+
+		credential, err := credentialRepo.GetByIDDecrypted(ctx, jump.CredentialID)
+		if err != nil {
+			return nil, fmt.Errorf("获取跳板机 %s 凭证失败: %w", jump.Name, err)
+		}
+
+		jumpHost := &sshclient.JumpHost{
+			Name:              jump.Name,
+			Host:              jump.IP,
+			Port:              jump.Port,
+			Username:          jump.SSHUser,
+			Password:          credential.Password,
+			HostKeyCallback:   sshclient.NewHostKeyCallback(hostKeyStore, jump.ID),
+			HostKeyAlgorithms: sshclient.HostKeyAlgorithms(hostKeyStore, jump.ID),
+		}
+		if credential.Type == "key" {
+			jumpHost.PrivateKey = []byte(credential.PrivateKey)
+			jumpHost.Passphrase = credential.Passphrase
+		}
+		if credential.IsCertificate() {
+			issued, err := IssueSSHCertificate(jumpHostIdentity(ctx), jump, jump.SSHUser)
+			if err != nil {
+				return nil, fmt.Errorf("跳板机 %s 签发证书失败: %w", jump.Name, err)
+			}
+			block, err := ssh.MarshalPrivateKey(issued.PrivateKey, "")
+			if err != nil {
+				return nil, fmt.Errorf("跳板机 %s 证书私钥编码失败: %w", jump.Name, err)
+			}
+			jumpHost.Signer = issued.Signer
+			jumpHost.PrivateKey = pem.EncodeToMemory(block)
+		}
+
+		// 越靠后解析到的跳板机越靠外，放在链的最前面
+		chain = append([]*sshclient.JumpHost{jumpHost}, chain...)
+		jumpID = jump.JumpHostID
+	}
+
+	return chain, nil
+}
+
+// validateJumpHost 校验跳板机配置：不能指向自身，不能形成循环，层数不超过限制
+func validateJumpHost(ctx context.Context, hostRepo HostRepo, hostID, jumpHostID uint) error {
+	if jumpHostID == 0 {
+		return nil
+	}
+	if hostID > 0 && jumpHostID == hostID {
+		return fmt.Errorf("跳板机不能是主机自身")
+	}
+
+	visited := map[uint]bool{}
+	depth := 0
+	for jumpID := jumpHostID; jumpID > 0; depth++ {
+		if (hostID > 0 && jumpID == hostID) || visited[jumpID] {
+			return fmt.Errorf("跳板机配置存在循环引用")
+		}
+		if depth >= maxJumpHostDepth {
+			return fmt.Errorf("跳板机链超过最大层数 %d", maxJumpHostDepth)
+		}
+		visited[jumpID] = true
+
+		jump, err := hostRepo.GetByID(ctx, jumpID)
+		if err != nil {
+			return fmt.Errorf("跳板机(ID:%d)不存在", jumpID)
+		}
+		jumpID = jump.JumpHostID
+	}
+	return nil
+}
@@ -19,7 +19,10 @@
 
 package asset
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 type AssetGroupRepo interface {
 	Create(ctx context.Context, group *AssetGroup) error
@@ -43,6 +46,29 @@ type HostRepo interface {
 	GetByIP(ctx context.Context, ip string) (*Host, error)
 	GetByCloudInstanceID(ctx context.Context, instanceID string) (*Host, error)
 	CountByCredentialID(ctx context.Context, credentialID uint) (int64, error)
+	ListByCredentialID(ctx context.Context, credentialID uint) ([]*Host, error)
+	CountByJumpHostID(ctx context.Context, jumpHostID uint) (int64, error)
+	UpdateHostKey(ctx context.Context, id uint, key *HostKey) error
+	UpdateMetrics(ctx context.Context, host *Host) error
+	ListWithCredential(ctx context.Context) ([]*Host, error)
+	ListAll(ctx context.Context) ([]*Host, error)
+	UpdateStatus(ctx context.Context, id uint, prevStatus, status int, lastSeen *time.Time) (bool, error)
+	ListByCloudAccountID(ctx context.Context, accountID uint) ([]*Host, error)
+	UpdateCloudInventory(ctx context.Context, host *Host) error
+}
+
+type HostMetricRepo interface {
+	BatchCreate(ctx context.Context, metrics []*HostMetric) error
+	List(ctx context.Context, hostIDs []uint, resolution int, start, end time.Time) ([]*HostMetric, error)
+	LatestTimestamp(ctx context.Context, resolution int) (*time.Time, error)
+	DeleteBefore(ctx context.Context, resolution int, before time.Time) (int64, error)
+}
+
+type HostAvailabilityRepo interface {
+	Create(ctx context.Context, event *HostAvailabilityEvent) error
+	List(ctx context.Context, hostID uint, start, end time.Time) ([]*HostAvailabilityEvent, error)
+	LastBefore(ctx context.Context, hostID uint, before time.Time) (*HostAvailabilityEvent, error)
+	DeleteBefore(ctx context.Context, before time.Time) (int64, error)
 }
 
 type CredentialRepo interface {
@@ -53,6 +79,33 @@ type CredentialRepo interface {
 	GetByIDDecrypted(ctx context.Context, id uint) (*Credential, error)
 	List(ctx context.Context, page, pageSize int, keyword string) ([]*Credential, int64, error)
 	GetAll(ctx context.Context) ([]*Credential, error)
+	ListRotationEnabled(ctx context.Context) ([]*Credential, error)
+}
+
+type CredentialRotationRepo interface {
+	Create(ctx context.Context, rotation *CredentialRotation) error
+	Update(ctx context.Context, rotation *CredentialRotation) error
+	SaveHost(ctx context.Context, host *CredentialRotationHost) error
+	GetByID(ctx context.Context, id uint) (*CredentialRotation, error)
+	List(ctx context.Context, credentialID uint, page, pageSize int) ([]*CredentialRotation, int64, error)
+	Last(ctx context.Context, credentialID uint) (*CredentialRotation, error)
+	ListRunning(ctx context.Context) ([]*CredentialRotation, error)
+}
+
+type SSHCARepo interface {
+	Create(ctx context.Context, authority *SSHCertAuthority) error
+	GetByID(ctx context.Context, id uint) (*SSHCertAuthority, error)
+	GetActive(ctx context.Context) (*SSHCertAuthority, error)
+	List(ctx context.Context) ([]*SSHCertAuthority, error)
+	Activate(ctx context.Context, id uint) error
+	Delete(ctx context.Context, id uint) error
+	CreateCertificate(ctx context.Context, cert *SSHCertificate) error
+	ListCertificates(ctx context.Context, query *SSHCertificateQuery) ([]*SSHCertificate, int64, error)
+}
+
+type CredentialSecretAccessRepo interface {
+	Create(ctx context.Context, access *CredentialSecretAccess) error
+	List(ctx context.Context, credentialID uint, page, pageSize int) ([]*CredentialSecretAccess, int64, error)
 }
 
 type CloudAccountRepo interface {
@@ -62,4 +115,26 @@ type CloudAccountRepo interface {
 	GetByID(ctx context.Context, id uint) (*CloudAccount, error)
 	List(ctx context.Context, page, pageSize int) ([]*CloudAccount, int64, error)
 	GetAll(ctx context.Context) ([]*CloudAccount, error)
+	ListSyncEnabled(ctx context.Context) ([]*CloudAccount, error)
+	UpdateSyncState(ctx context.Context, id uint, syncedAt time.Time, status string) error
+}
+
+type CloudSyncRepo interface {
+	CreateRun(ctx context.Context, run *CloudSyncRun) error
+	UpdateRun(ctx context.Context, run *CloudSyncRun) error
+	CreateChange(ctx context.Context, change *CloudSyncChange) error
+	GetRun(ctx context.Context, id uint) (*CloudSyncRun, error)
+	ListRuns(ctx context.Context, accountID uint, page, pageSize int) ([]*CloudSyncRun, int64, error)
+	ListRunning(ctx context.Context) ([]*CloudSyncRun, error)
+}
+
+type CommandRuleRepo interface {
+	Create(ctx context.Context, rule *CommandRule) error
+	Update(ctx context.Context, rule *CommandRule) error
+	Delete(ctx context.Context, id uint) error
+	GetByID(ctx context.Context, id uint) (*CommandRule, error)
+	List(ctx context.Context, page, pageSize int, keyword, scopeType, action string) ([]*CommandRule, int64, error)
+	ListEnabled(ctx context.Context) ([]*CommandRule, error)
+	GetUserRoleIDs(ctx context.Context, userID uint) ([]uint, error)
+	InitDefaultRules(ctx context.Context) error
 }
@@ -0,0 +1,497 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package asset
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/pem"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ydcloud-dy/opshub/pkg/kms"
+	"golang.org/x/crypto/ssh"
+	"gorm.io/gorm"
+)
+
+// CredentialTypeCertificate 证书凭证：不保存任何密钥，连接时由平台SSH CA签发短期证书
+const CredentialTypeCertificate = "certificate"
+
+// 证书签发用途
+const (
+	SSHCertPurposeSystem   = "system"   // 平台自身连接：信息采集、巡检、文件管理等
+	SSHCertPurposeTerminal = "terminal" // Web终端
+	SSHCertPurposeTask     = "task"     // 任务执行、文件分发
+	SSHCertPurposeAnsible  = "ansible"  // Ansible Playbook
+)
+
+// certClockSkew 证书生效时间提前量，容忍主机与平台的时钟偏差
+const certClockSkew = time.Minute
+
+// IsCertificate 是否为平台签发证书的凭证
+func (c *Credential) IsCertificate() bool {
+	return c.Type == CredentialTypeCertificate
+}
+
+// SSHCertAuthority SSH证书签发机构，同一时间只有一个启用的CA用于签发，
+// 主机通过 TrustedUserCAKeys 信任所有未删除的CA公钥，便于更换CA时平滑过渡
+type SSHCertAuthority struct {
+	ID          uint           `gorm:"primarykey" json:"id"`
+	CreatedAt   time.Time      `json:"createdAt"`
+	UpdatedAt   time.Time      `json:"updatedAt"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+	Name        string         `gorm:"type:varchar(100);not null;comment:名称" json:"name"`
+	PublicKey   string         `gorm:"type:text;comment:CA公钥" json:"publicKey"`
+	PrivateKey  string         `gorm:"type:text;comment:CA私钥(加密)" json:"-"`
+	Fingerprint string         `gorm:"type:varchar(100);comment:公钥指纹" json:"fingerprint"`
+	Active      bool           `gorm:"index;comment:是否用于签发" json:"active"`
+	CreatedBy   string         `gorm:"type:varchar(50);comment:创建人" json:"createdBy"`
+}
+
+// TableName 表名
+func (SSHCertAuthority) TableName() string {
+	return "ssh_cert_authorities"
+}
+
+// SSHCertificate 证书签发记录
+type SSHCertificate struct {
+	ID          uint      `gorm:"primarykey" json:"id"`
+	Serial      uint64    `gorm:"index;comment:证书序列号" json:"serial"`
+	AuthorityID uint      `gorm:"index;comment:签发CA" json:"authorityId"`
+	KeyID       string    `gorm:"type:varchar(255);comment:证书标识" json:"keyId"`
+	Purpose     string    `gorm:"type:varchar(20);index;comment:用途" json:"purpose"`
+	UserID      uint      `gorm:"index;comment:用户ID 0:平台" json:"userId"`
+	Username    string    `gorm:"type:varchar(50);comment:用户名" json:"username"`
+	HostID      uint      `gorm:"index;comment:主机ID" json:"hostId"`
+	HostName    string    `gorm:"type:varchar(100);comment:主机名称" json:"hostName"`
+	HostIP      string    `gorm:"type:varchar(50);comment:主机IP" json:"hostIp"`
+	LoginUser   string    `gorm:"type:varchar(100);comment:登录用户" json:"loginUser"`
+	Principals  string    `gorm:"type:varchar(1000);comment:证书principals" json:"principals"`
+	ValidAfter  time.Time `json:"validAfter"`
+	ValidBefore time.Time `json:"validBefore"`
+	CreatedAt   time.Time `gorm:"index" json:"createdAt"`
+}
+
+// TableName 表名
+func (SSHCertificate) TableName() string {
+	return "ssh_certificates"
+}
+
+// SSHCertificateQuery 签发记录查询条件
+type SSHCertificateQuery struct {
+	HostID   uint
+	UserID   uint
+	Purpose  string
+	Keyword  string // 用户名、主机名或IP
+	Page     int
+	PageSize int
+}
+
+// SSHCertIdentity 申请证书的身份
+type SSHCertIdentity struct {
+	UserID    uint // 0 表示平台自身
+	Username  string
+	Purpose   string
+	Operation uint          // 需要校验的主机操作权限（rbac.Permission*），0 表示不校验
+	TTL       time.Duration // 证书有效期，0 使用默认值
+	JumpHost  bool          // 作为跳板机转发到目标主机的连接
+}
+
+// PoolKey 连接池键的身份部分，不同身份或校验不同操作权限的证书连接不能共用
+func (i SSHCertIdentity) PoolKey() string {
+	return fmt.Sprintf("%s:%d:%d", i.Purpose, i.UserID, i.Operation)
+}
+
+type sshCertIdentityKey struct{}
+
+// WithSSHCertIdentity 指定后续连接签发证书使用的身份
+func WithSSHCertIdentity(ctx context.Context, identity SSHCertIdentity) context.Context {
+	return context.WithValue(ctx, sshCertIdentityKey{}, identity)
+}
+
+// SSHCertIdentityFrom 获取 ctx 中的证书身份，未指定时为平台自身
+func SSHCertIdentityFrom(ctx context.Context) SSHCertIdentity {
+	if identity, ok := ctx.Value(sshCertIdentityKey{}).(SSHCertIdentity); ok {
+		return identity
+	}
+	return SSHCertIdentity{Username: "opshub", Purpose: SSHCertPurposeSystem}
+}
+
+// jumpHostIdentity 跳板机只用于转发，不要求用户有跳板机本身的操作权限，证书允许端口转发
+func jumpHostIdentity(ctx context.Context) context.Context {
+	identity := SSHCertIdentityFrom(ctx)
+	identity.Operation = 0
+	identity.JumpHost = true
+	return WithSSHCertIdentity(ctx, identity)
+}
+
+// SSHCertPrincipalSource 签发证书时需要的用户权限信息
+type SSHCertPrincipalSource interface {
+	CheckHostOperationPermission(ctx context.Context, userID, hostID uint, operation uint) (bool, error)
+	UserRoleCodes(ctx context.Context, userID uint) ([]string, error)
+}
+
+// SSHCAConfig SSH证书签发配置
+type SSHCAConfig struct {
+	CertTTL         time.Duration
+	MaxCertTTL      time.Duration
+	PrincipalPrefix string
+	SystemPrincipal string
+	ForwardingRoles []string // 允许端口转发的角色编码，经跳板机转发的连接总是允许
+}
+
+func (c SSHCAConfig) withDefaults() SSHCAConfig {
+	if c.CertTTL <= 0 {
+		c.CertTTL = 5 * time.Minute
+	}
+	if c.MaxCertTTL <= 0 {
+		c.MaxCertTTL = 24 * time.Hour
+	}
+	if c.MaxCertTTL < c.CertTTL {
+		c.MaxCertTTL = c.CertTTL
+	}
+	if c.PrincipalPrefix == "" {
+		c.PrincipalPrefix = "opshub-role-"
+	}
+	if c.SystemPrincipal == "" {
+		c.SystemPrincipal = "opshub-system"
+	}
+	return c
+}
+
+// IssuedSSHCertificate 签发结果，PrivateKey 为本次连接临时生成的私钥
+type IssuedSSHCertificate struct {
+	Certificate *ssh.Certificate
+	PrivateKey  ed25519.PrivateKey
+	Signer      ssh.Signer
+}
+
+type cachedAuthority struct {
+	updatedAt time.Time
+	signer    ssh.Signer
+}
+
+// SSHCAUseCase SSH证书签发：每次连接生成临时密钥，由启用的CA签发短期用户证书。
+// principals 只包含用户各角色对应的 principal（平台自身连接为系统 principal），不包含登录用户，
+// 主机通过 sshd 的 AuthorizedPrincipalsFile 配置各登录用户接受的 principal
+type SSHCAUseCase struct {
+	repo       SSHCARepo
+	principals SSHCertPrincipalSource
+	config     SSHCAConfig
+
+	mu      sync.Mutex
+	signers map[uint]*cachedAuthority
+}
+
+// NewSSHCAUseCase 创建SSH证书签发用例
+func NewSSHCAUseCase(repo SSHCARepo, principals SSHCertPrincipalSource, config SSHCAConfig) *SSHCAUseCase {
+	return &SSHCAUseCase{
+		repo:       repo,
+		principals: principals,
+		config:     config.withDefaults(),
+		signers:    make(map[uint]*cachedAuthority),
+	}
+}
+
+var (
+	sshCAMu sync.RWMutex
+	sshCA   *SSHCAUseCase
+)
+
+// SetSSHCA 设置全局证书签发用例，插件连接证书凭证的主机时同样通过它签发
+func SetSSHCA(uc *SSHCAUseCase) {
+	sshCAMu.Lock()
+	sshCA = uc
+	sshCAMu.Unlock()
+}
+
+// IssueSSHCertificate 按 ctx 中的身份为登录主机签发证书
+func IssueSSHCertificate(ctx context.Context, host *Host, loginUser string) (*IssuedSSHCertificate, error) {
+	sshCAMu.RLock()
+	uc := sshCA
+	sshCAMu.RUnlock()
+	if uc == nil {
+		return nil, fmt.Errorf("未启用SSH证书签发")
+	}
+	return uc.Issue(ctx, host, loginUser, SSHCertIdentityFrom(ctx))
+}
+
+// SSHCertSigner 按 ctx 中的身份为登录主机签发证书，返回用于认证的签名器
+func SSHCertSigner(ctx context.Context, host *Host, loginUser string) (ssh.Signer, error) {
+	issued, err := IssueSSHCertificate(ctx, host, loginUser)
+	if err != nil {
+		return nil, err
+	}
+	return issued.Signer, nil
+}
+
+// GenerateAuthority 生成新的CA，activate 为 true 或当前没有启用的CA时立即用于签发
+func (uc *SSHCAUseCase) GenerateAuthority(ctx context.Context, name, operator string, activate bool) (*SSHCertAuthority, error) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("生成CA密钥失败: %w", err)
+	}
+	sshPub, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		return nil, err
+	}
+	block, err := ssh.MarshalPrivateKey(priv, "opshub-ca")
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := kms.Encrypt(string(pem.EncodeToMemory(block)))
+	if err != nil {
+		return nil, fmt.Errorf("加密CA私钥失败: %w", err)
+	}
+
+	if name == "" {
+		name = "OpsHub CA " + time.Now().Format("2006-01-02")
+	}
+	authority := &SSHCertAuthority{
+		Name:        name,
+		PublicKey:   strings.TrimSpace(string(ssh.MarshalAuthorizedKey(sshPub))) + " opshub-ca",
+		PrivateKey:  encrypted,
+		Fingerprint: ssh.FingerprintSHA256(sshPub),
+		CreatedBy:   operator,
+	}
+	if !activate {
+		active, err := uc.repo.GetActive(ctx)
+		if err != nil {
+			return nil, err
+		}
+		activate = active == nil
+	}
+	if err := uc.repo.Create(ctx, authority); err != nil {
+		return nil, err
+	}
+	if activate {
+		if err := uc.repo.Activate(ctx, authority.ID); err != nil {
+			return nil, err
+		}
+		authority.Active = true
+	}
+	return authority, nil
+}
+
+// ListAuthorities 获取所有CA
+func (uc *SSHCAUseCase) ListAuthorities(ctx context.Context) ([]*SSHCertAuthority, error) {
+	return uc.repo.List(ctx)
+}
+
+// ActivateAuthority 切换用于签发的CA，主机需提前信任新CA的公钥
+func (uc *SSHCAUseCase) ActivateAuthority(ctx context.Context, id uint) error {
+	if _, err := uc.repo.GetByID(ctx, id); err != nil {
+		return fmt.Errorf("CA不存在")
+	}
+	return uc.repo.Activate(ctx, id)
+}
+
+// DeleteAuthority 删除CA，正在用于签发的CA不能删除
+func (uc *SSHCAUseCase) DeleteAuthority(ctx context.Context, id uint) error {
+	authority, err := uc.repo.GetByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("CA不存在")
+	}
+	if authority.Active {
+		return fmt.Errorf("CA正在用于签发证书，请先启用其他CA")
+	}
+	if err := uc.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+	uc.mu.Lock()
+	delete(uc.signers, id)
+	uc.mu.Unlock()
+	return nil
+}
+
+// TrustedKeys 主机 TrustedUserCAKeys 文件内容，包含所有未删除的CA公钥
+func (uc *SSHCAUseCase) TrustedKeys(ctx context.Context) (string, error) {
+	authorities, err := uc.repo.List(ctx)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	for _, authority := range authorities {
+		b.WriteString(authority.PublicKey)
+		b.WriteString("\n")
+	}
+	return b.String(), nil
+}
+
+// ListCertificates 分页查询签发记录
+func (uc *SSHCAUseCase) ListCertificates(ctx context.Context, query *SSHCertificateQuery) ([]*SSHCertificate, int64, error) {
+	if query.Page <= 0 {
+		query.Page = 1
+	}
+	if query.PageSize <= 0 {
+		query.PageSize = 20
+	}
+	return uc.repo.ListCertificates(ctx, query)
+}
+
+// Issue 为登录主机签发证书并记录，用户身份需要有主机的对应操作权限
+func (uc *SSHCAUseCase) Issue(ctx context.Context, host *Host, loginUser string, identity SSHCertIdentity) (*IssuedSSHCertificate, error) {
+	if loginUser == "" {
+		return nil, fmt.Errorf("主机 %s 未配置登录用户", host.Name)
+	}
+
+	var principals []string
+	forwarding := identity.JumpHost
+	if identity.UserID > 0 {
+		if identity.Operation > 0 {
+			allowed, err := uc.principals.CheckHostOperationPermission(ctx, identity.UserID, host.ID, identity.Operation)
+			if err != nil {
+				return nil, fmt.Errorf("检查主机权限失败: %w", err)
+			}
+			if !allowed {
+				return nil, fmt.Errorf("用户 %s 没有主机 %s 的操作权限，拒绝签发证书", identity.Username, host.Name)
+			}
+		}
+		roles, err := uc.principals.UserRoleCodes(ctx, identity.UserID)
+		if err != nil {
+			return nil, fmt.Errorf("获取用户角色失败: %w", err)
+		}
+		for _, role := range roles {
+			principals = append(principals, uc.config.PrincipalPrefix+role)
+			if slices.Contains(uc.config.ForwardingRoles, role) {
+				forwarding = true
+			}
+		}
+		if len(principals) == 0 {
+			return nil, fmt.Errorf("用户 %s 没有启用的角色，无法签发证书", identity.Username)
+		}
+	} else {
+		principals = append(principals, uc.config.SystemPrincipal)
+	}
+
+	authority, caSigner, err := uc.activeSigner(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("生成临时密钥失败: %w", err)
+	}
+	sshPub, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		return nil, err
+	}
+	var serialBytes [8]byte
+	if _, err := rand.Read(serialBytes[:]); err != nil {
+		return nil, err
+	}
+	serial := binary.BigEndian.Uint64(serialBytes[:])
+
+	ttl := identity.TTL
+	if ttl <= 0 {
+		ttl = uc.config.CertTTL
+	}
+	if ttl > uc.config.MaxCertTTL {
+		ttl = uc.config.MaxCertTTL
+	}
+	now := time.Now()
+	validAfter := now.Add(-certClockSkew)
+	validBefore := now.Add(ttl)
+
+	// 终端需要伪终端；端口转发只授予跳板机连接和允许转发的角色
+	extensions := map[string]string{
+		"permit-pty":     "",
+		"permit-user-rc": "",
+	}
+	if forwarding {
+		extensions["permit-port-forwarding"] = ""
+	}
+	cert := &ssh.Certificate{
+		Key:             sshPub,
+		Serial:          serial,
+		CertType:        ssh.UserCert,
+		KeyId:           fmt.Sprintf("opshub:%s:%s:host-%d:%d", identity.Purpose, identity.Username, host.ID, serial),
+		ValidPrincipals: principals,
+		ValidAfter:      uint64(validAfter.Unix()),
+		ValidBefore:     uint64(validBefore.Unix()),
+		Permissions:     ssh.Permissions{Extensions: extensions},
+	}
+	if err := cert.SignCert(rand.Reader, caSigner); err != nil {
+		return nil, fmt.Errorf("签发证书失败: %w", err)
+	}
+
+	record := &SSHCertificate{
+		Serial:      serial,
+		AuthorityID: authority.ID,
+		KeyID:       cert.KeyId,
+		Purpose:     identity.Purpose,
+		UserID:      identity.UserID,
+		Username:    identity.Username,
+		HostID:      host.ID,
+		HostName:    host.Name,
+		HostIP:      host.IP,
+		LoginUser:   loginUser,
+		Principals:  strings.Join(principals, ","),
+		ValidAfter:  validAfter,
+		ValidBefore: validBefore,
+	}
+	if err := uc.repo.CreateCertificate(ctx, record); err != nil {
+		return nil, fmt.Errorf("记录证书签发失败: %w", err)
+	}
+
+	keySigner, err := ssh.NewSignerFromKey(priv)
+	if err != nil {
+		return nil, err
+	}
+	certSigner, err := ssh.NewCertSigner(cert, keySigner)
+	if err != nil {
+		return nil, err
+	}
+	return &IssuedSSHCertificate{Certificate: cert, PrivateKey: priv, Signer: certSigner}, nil
+}
+
+// activeSigner 获取启用的CA及其签名器，解密后的私钥按CA缓存
+func (uc *SSHCAUseCase) activeSigner(ctx context.Context) (*SSHCertAuthority, ssh.Signer, error) {
+	authority, err := uc.repo.GetActive(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("获取SSH CA失败: %w", err)
+	}
+	if authority == nil {
+		return nil, nil, fmt.Errorf("未配置SSH CA，请先在凭证管理中生成CA并让主机信任其公钥")
+	}
+
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+	if cached, ok := uc.signers[authority.ID]; ok && cached.updatedAt.Equal(authority.UpdatedAt) {
+		return authority, cached.signer, nil
+	}
+
+	privateKey, err := kms.Decrypt(authority.PrivateKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("解密CA私钥失败: %w", err)
+	}
+	signer, err := ssh.ParsePrivateKey([]byte(privateKey))
+	if err != nil {
+		return nil, nil, fmt.Errorf("解析CA私钥失败: %w", err)
+	}
+	uc.signers[authority.ID] = &cachedAuthority{updatedAt: authority.UpdatedAt, signer: signer}
+	return authority, signer, nil
+}
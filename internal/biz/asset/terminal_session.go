@@ -22,6 +22,7 @@ package asset
 import (
 	"time"
 
+	"github.com/ydcloud-dy/opshub/pkg/recording"
 	"gorm.io/gorm"
 )
 
@@ -39,7 +40,15 @@ type TerminalSession struct {
 	RecordingPath string         `gorm:"type:varchar(500);comment:录制文件路径" json:"recordingPath"`
 	Duration      int            `gorm:"type:int;comment:会话时长(秒)" json:"duration"`
 	FileSize      int64          `gorm:"type:bigint;comment:文件大小(字节)" json:"fileSize"`
-	Status        string         `gorm:"type:varchar(20);default:'recording';comment:会话状态 recording/completed/failed" json:"status"`
+	Status        string         `gorm:"type:varchar(20);default:'recording';comment:会话状态 recording/completed/failed/terminated" json:"status"`
+	// 录制存储信息，StorageType 为空表示旧版本直接写在 RecordingPath 上的本地文件
+	StorageType string `gorm:"type:varchar(20);comment:录制存储类型 local/s3" json:"storageType"`
+	Compressed  bool   `gorm:"comment:录制文件是否gzip压缩" json:"compressed"`
+	Checksum    string `gorm:"type:varchar(64);comment:录制文件SHA-256校验值" json:"checksum"`
+	// 管理员强制终止会话时记录
+	TerminatedBy     uint   `gorm:"comment:终止操作人ID" json:"terminatedBy,omitempty"`
+	TerminatedByName string `gorm:"type:varchar(100);comment:终止操作人" json:"terminatedByName,omitempty"`
+	TerminateReason  string `gorm:"type:varchar(500);comment:终止原因" json:"terminateReason,omitempty"`
 }
 
 // TableName 表名
@@ -47,6 +56,84 @@ func (TerminalSession) TableName() string {
 	return "ssh_terminal_sessions"
 }
 
+// Recording 会话录制在存储中的位置
+func (s *TerminalSession) Recording() recording.Object {
+	return recording.Object{
+		Storage:    s.StorageType,
+		Key:        s.RecordingPath,
+		Size:       s.FileSize,
+		Checksum:   s.Checksum,
+		Compressed: s.Compressed,
+	}
+}
+
+// TerminalCommand 从终端会话中还原出的命令，用于命令检索和跳转回放
+type TerminalCommand struct {
+	ID         uint      `gorm:"primarykey" json:"id"`
+	CreatedAt  time.Time `json:"createdAt"`
+	SessionID  uint      `gorm:"column:session_id;not null;index;comment:终端会话ID" json:"sessionId"`
+	HostID     uint      `gorm:"column:host_id;not null;index;comment:主机ID" json:"hostId"`
+	HostName   string    `gorm:"type:varchar(100);comment:主机名称" json:"hostName"`
+	HostIP     string    `gorm:"type:varchar(50);comment:主机IP" json:"hostIp"`
+	UserID     uint      `gorm:"column:user_id;not null;index;comment:操作用户ID" json:"userId"`
+	Username   string    `gorm:"type:varchar(100);index;comment:用户名" json:"username"`
+	Command    string    `gorm:"type:text;comment:命令" json:"command"`
+	Action     string    `gorm:"type:varchar(20);default:'allow';index;comment:命令过滤结果 allow/deny/approved/rejected/timeout/cancelled" json:"action"`
+	Reason     string    `gorm:"type:varchar(500);comment:拦截或审批原因" json:"reason"`
+	Offset     float64   `gorm:"column:offset_seconds;comment:相对录制开始的时间(秒)" json:"offset"`
+	ExecutedAt time.Time `gorm:"index;comment:执行时间" json:"executedAt"`
+}
+
+// TableName 表名
+func (TerminalCommand) TableName() string {
+	return "ssh_terminal_commands"
+}
+
+// 终端命令过滤结果
+const (
+	TerminalCommandActionAllow     = "allow"     // 放行
+	TerminalCommandActionDeny      = "deny"      // 拦截
+	TerminalCommandActionApproved  = "approved"  // 审批通过后执行
+	TerminalCommandActionRejected  = "rejected"  // 审批驳回
+	TerminalCommandActionTimeout   = "timeout"   // 审批超时
+	TerminalCommandActionCancelled = "cancelled" // 用户撤回或会话断开
+)
+
+// 终端命令审批状态
+const (
+	TerminalApprovalPending   = "pending"
+	TerminalApprovalApproved  = "approved"
+	TerminalApprovalRejected  = "rejected"
+	TerminalApprovalTimeout   = "timeout"
+	TerminalApprovalCancelled = "cancelled"
+)
+
+// TerminalCommandApproval 交互式终端中命中审批规则、等待审批的命令
+type TerminalCommandApproval struct {
+	ID              uint       `gorm:"primarykey" json:"id"`
+	CreatedAt       time.Time  `json:"createdAt"`
+	UpdatedAt       time.Time  `json:"updatedAt"`
+	SessionKey      string     `gorm:"type:varchar(100);index;comment:在线终端会话标识" json:"sessionKey"`
+	HostID          uint       `gorm:"column:host_id;not null;index;comment:主机ID" json:"hostId"`
+	HostName        string     `gorm:"type:varchar(100);comment:主机名称" json:"hostName"`
+	HostIP          string     `gorm:"type:varchar(50);comment:主机IP" json:"hostIp"`
+	UserID          uint       `gorm:"column:user_id;not null;index;comment:申请用户ID" json:"userId"`
+	Username        string     `gorm:"type:varchar(100);comment:申请用户名" json:"username"`
+	Command         string     `gorm:"type:text;comment:命令" json:"command"`
+	Reason          string     `gorm:"type:varchar(500);comment:需要审批的原因" json:"reason"`
+	Status          string     `gorm:"type:varchar(20);default:'pending';index;comment:状态 pending/approved/rejected/timeout/cancelled" json:"status"`
+	ApproverID      uint       `gorm:"comment:审批人ID" json:"approverId"`
+	ApproverName    string     `gorm:"type:varchar(100);comment:审批人" json:"approverName"`
+	ApprovalTime    *time.Time `gorm:"comment:审批时间" json:"approvalTime"`
+	ApprovalComment string     `gorm:"type:varchar(500);comment:审批意见" json:"approvalComment"`
+	ExpiresAt       time.Time  `gorm:"comment:审批截止时间" json:"expiresAt"`
+}
+
+// TableName 表名
+func (TerminalCommandApproval) TableName() string {
+	return "ssh_terminal_command_approvals"
+}
+
 // TerminalSessionInfo 终端会话信息VO
 type TerminalSessionInfo struct {
 	ID            uint      `json:"id"`
@@ -63,6 +150,9 @@ type TerminalSessionInfo struct {
 	StatusText    string    `json:"statusText"`
 	CreatedAt     time.Time `json:"createdAt"`
 	CreatedAtText string    `json:"createdAtText"` // 格式化的创建时间
+
+	TerminatedByName string `json:"terminatedByName,omitempty"` // 强制终止会话的管理员
+	TerminateReason  string `json:"terminateReason,omitempty"`
 }
 
 // TerminalSessionListRequest 终端会话列表请求
@@ -0,0 +1,54 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package audit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+)
+
+// TransferDigest 统计传输的字节数并计算内容的 SHA-256，
+// 与 io.TeeReader / io.MultiWriter 配合在传输过程中计算，不需要额外读取文件
+type TransferDigest struct {
+	hash hash.Hash
+	size int64
+}
+
+// NewTransferDigest 创建 TransferDigest
+func NewTransferDigest() *TransferDigest {
+	return &TransferDigest{hash: sha256.New()}
+}
+
+// Write 实现 io.Writer
+func (d *TransferDigest) Write(p []byte) (int, error) {
+	d.size += int64(len(p))
+	return d.hash.Write(p)
+}
+
+// Size 已传输的字节数
+func (d *TransferDigest) Size() int64 {
+	return d.size
+}
+
+// Sum 已传输内容的 SHA-256（十六进制）
+func (d *TransferDigest) Sum() string {
+	return hex.EncodeToString(d.hash.Sum(nil))
+}
@@ -108,6 +108,59 @@ type SysDataLog struct {
 	UserAgent string `gorm:"type:varchar(500);comment:用户代理" json:"userAgent"`
 }
 
+// 文件传输方向
+const (
+	TransferUpload   = "upload"
+	TransferDownload = "download"
+	TransferDelete   = "delete"
+)
+
+// 文件传输来源
+const (
+	TransferSourceFileManager = "file_manager"    // 主机文件管理
+	TransferSourceDistribute  = "task_distribute" // 任务中心文件分发
+)
+
+// 文件传输结果
+const (
+	TransferSuccess = "success"
+	TransferFailed  = "failed"
+)
+
+// SysFileTransferLog 文件传输日志表，记录主机文件的上传、下载和删除
+type SysFileTransferLog struct {
+	ID        uint           `gorm:"primaryKey;autoIncrement" json:"id"`
+	CreatedAt time.Time      `gorm:"index" json:"createdAt"`
+	UpdatedAt time.Time      `json:"updatedAt"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deletedAt,omitempty"`
+
+	// 用户信息
+	UserID   uint   `gorm:"index;comment:用户ID" json:"userId"`
+	Username string `gorm:"type:varchar(50);index;comment:用户名" json:"username"`
+
+	// 主机信息（记录传输时的名称和IP，主机删除后仍可追溯）
+	HostID   uint   `gorm:"index;comment:主机ID" json:"hostId"`
+	HostName string `gorm:"type:varchar(100);comment:主机名称" json:"hostName"`
+	HostIP   string `gorm:"type:varchar(50);comment:主机IP" json:"hostIp"`
+
+	// 传输信息
+	Direction  string `gorm:"type:varchar(20);index;comment:传输方向" json:"direction"` // upload, download, delete
+	Source     string `gorm:"type:varchar(30);comment:来源" json:"source"`            // file_manager, task_distribute
+	RemotePath string `gorm:"type:varchar(1024);comment:远程路径" json:"remotePath"`
+	FileSize   int64  `gorm:"comment:传输字节数" json:"fileSize"`
+	SHA256     string `gorm:"column:sha256;type:varchar(64);index;comment:内容SHA-256" json:"sha256"`
+	Status     string `gorm:"type:varchar(20);index;comment:结果" json:"status"` // success, failed
+	ErrorMsg   string `gorm:"type:varchar(500);comment:错误信息" json:"errorMsg"`
+
+	// 环境信息
+	IP string `gorm:"type:varchar(50);comment:IP地址" json:"ip"`
+}
+
+// TableName 指定表名
+func (SysFileTransferLog) TableName() string {
+	return "sys_file_transfer_log"
+}
+
 // Table 指定表名
 func (SysDataLog) Table() string {
 	return "sys_data_log"
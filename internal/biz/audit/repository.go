@@ -50,3 +50,23 @@ type DataLogRepo interface {
 	Delete(ctx context.Context, id uint) error
 	DeleteBatch(ctx context.Context, ids []uint) error
 }
+
+// FileTransferLogQuery 文件传输日志查询条件
+type FileTransferLogQuery struct {
+	Username   string
+	HostID     uint
+	Host       string // 主机名称或IP
+	Direction  string
+	Status     string
+	RemotePath string
+	SHA256     string
+	StartTime  string
+	EndTime    string
+}
+
+// FileTransferLogRepo 文件传输日志仓储接口
+type FileTransferLogRepo interface {
+	Create(ctx context.Context, log *SysFileTransferLog) error
+	GetByID(ctx context.Context, id uint) (*SysFileTransferLog, error)
+	List(ctx context.Context, page, pageSize int, query *FileTransferLogQuery) ([]*SysFileTransferLog, int64, error)
+}
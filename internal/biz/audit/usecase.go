@@ -21,6 +21,7 @@ package audit
 
 import (
 	"context"
+	"strings"
 )
 
 // OperationLogUseCase 操作日志用例
@@ -119,3 +120,29 @@ func (uc *DataLogUseCase) Delete(ctx context.Context, id uint) error {
 func (uc *DataLogUseCase) DeleteBatch(ctx context.Context, ids []uint) error {
 	return uc.repo.DeleteBatch(ctx, ids)
 }
+
+// FileTransferLogUseCase 文件传输日志用例
+type FileTransferLogUseCase struct {
+	repo FileTransferLogRepo
+}
+
+func NewFileTransferLogUseCase(repo FileTransferLogRepo) *FileTransferLogUseCase {
+	return &FileTransferLogUseCase{
+		repo: repo,
+	}
+}
+
+func (uc *FileTransferLogUseCase) Create(ctx context.Context, log *SysFileTransferLog) error {
+	if len(log.ErrorMsg) > 500 {
+		log.ErrorMsg = strings.ToValidUTF8(log.ErrorMsg[:500], "")
+	}
+	return uc.repo.Create(ctx, log)
+}
+
+func (uc *FileTransferLogUseCase) GetByID(ctx context.Context, id uint) (*SysFileTransferLog, error) {
+	return uc.repo.GetByID(ctx, id)
+}
+
+func (uc *FileTransferLogUseCase) List(ctx context.Context, page, pageSize int, query *FileTransferLogQuery) ([]*SysFileTransferLog, int64, error) {
+	return uc.repo.List(ctx, page, pageSize, query)
+}
@@ -83,7 +83,6 @@ type MFAUseCase struct {
 	settingsRepo  MFASettingsRepo
 	challengeRepo MFAChallengeRepo
 	issuer        string
-	encryptionKey string
 }
 
 // NewMFAUseCase 创建MFA用例
@@ -91,13 +90,11 @@ func NewMFAUseCase(
 	settingsRepo MFASettingsRepo,
 	challengeRepo MFAChallengeRepo,
 	issuer string,
-	encryptionKey string,
 ) *MFAUseCase {
 	return &MFAUseCase{
 		settingsRepo:  settingsRepo,
 		challengeRepo: challengeRepo,
 		issuer:        issuer,
-		encryptionKey: encryptionKey,
 	}
 }
 
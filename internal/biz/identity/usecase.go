@@ -20,11 +20,12 @@
 package identity
 
 import (
+	"bytes"
 	"context"
 	"encoding/base64"
 	"errors"
 
-	"golang.org/x/crypto/bcrypt"
+	"github.com/ydcloud-dy/opshub/pkg/kms"
 )
 
 // IdentitySourceUseCase 身份源用例
@@ -391,31 +392,21 @@ func (uc *UserFavoriteAppUseCase) IsFavorite(ctx context.Context, userID, appID
 	return uc.repo.IsFavorite(ctx, userID, appID)
 }
 
-// 密码加密辅助函数
+// 应用密码用于表单代填，需要可逆加密，通过 kms 加密存储
 func encryptPassword(password string) (string, error) {
 	if password == "" {
 		return "", errors.New("密码不能为空")
 	}
-	// 使用bcrypt加密
-	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	if err != nil {
-		return "", err
-	}
-	// 再用base64编码，便于存储
-	return base64.StdEncoding.EncodeToString(hashed), nil
+	return kms.Encrypt(password)
 }
 
 func decryptPassword(encrypted string) (string, error) {
 	if encrypted == "" {
 		return "", nil
 	}
-	// base64解码
-	_, err := base64.StdEncoding.DecodeString(encrypted)
-	if err != nil {
-		return "", err
+	// 旧版本使用 bcrypt 单向加密后 base64 编码存储，无法还原
+	if hashed, err := base64.StdEncoding.DecodeString(encrypted); err == nil && bytes.HasPrefix(hashed, []byte("$2")) {
+		return "", errors.New("该应用密码为旧版本单向加密存储，无法解密，请重新保存密码")
 	}
-	// 注意：bcrypt是单向加密，无法解密
-	// 这里返回加密后的值，实际使用时需要在应用配置中存储可解密的密码
-	// 对于表单代填场景，需要使用对称加密算法
-	return encrypted, nil
+	return kms.Decrypt(encrypted)
 }
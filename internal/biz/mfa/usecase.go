@@ -21,8 +21,6 @@ package mfa
 
 import (
 	"context"
-	"crypto/aes"
-	"crypto/cipher"
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/hex"
@@ -30,31 +28,22 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/ydcloud-dy/opshub/pkg/kms"
 	appLogger "github.com/ydcloud-dy/opshub/pkg/logger"
 	"go.uber.org/zap"
 )
 
-// UseCase MFA用例
+// UseCase MFA用例，TOTP密钥和备用码通过 kms 加密存储
 type UseCase struct {
-	repo      Repository
-	issuer    string // TOTP发行者名称
-	encryptKey []byte // AES加密密钥（32字节）
+	repo   Repository
+	issuer string // TOTP发行者名称
 }
 
 // NewUseCase 创建MFA用例
-func NewUseCase(repo Repository, issuer string, encryptKey []byte) *UseCase {
-	// 确保密钥长度为32字节
-	if len(encryptKey) > 32 {
-		encryptKey = encryptKey[:32]
-	} else if len(encryptKey) < 32 {
-		padded := make([]byte, 32)
-		copy(padded, encryptKey)
-		encryptKey = padded
-	}
+func NewUseCase(repo Repository, issuer string) *UseCase {
 	return &UseCase{
-		repo:      repo,
-		issuer:    issuer,
-		encryptKey: encryptKey,
+		repo:   repo,
+		issuer: issuer,
 	}
 }
 
@@ -73,14 +62,14 @@ func (uc *UseCase) SetupMFA(ctx context.Context, userID uint, username string) (
 	}
 
 	// 加密密钥
-	encryptedSecret, err := uc.encrypt(secret)
+	encryptedSecret, err := kms.Encrypt(secret)
 	if err != nil {
 		return nil, fmt.Errorf("加密密钥失败: %w", err)
 	}
 
 	// 加密备用码
 	backupCodesJSON, _ := json.Marshal(backupCodes)
-	encryptedBackupCodes, err := uc.encrypt(string(backupCodesJSON))
+	encryptedBackupCodes, err := kms.Encrypt(string(backupCodesJSON))
 	if err != nil {
 		return nil, fmt.Errorf("加密备用码失败: %w", err)
 	}
@@ -134,7 +123,7 @@ func (uc *UseCase) VerifyAndEnableMFA(ctx context.Context, userID uint, code, ip
 	}
 
 	// 解密密钥
-	secret, err := uc.decrypt(mfa.TOTPSecret)
+	secret, err := kms.Decrypt(mfa.TOTPSecret)
 	if err != nil {
 		return false, fmt.Errorf("解密密钥失败: %w", err)
 	}
@@ -184,7 +173,7 @@ func (uc *UseCase) ValidateMFACode(ctx context.Context, userID uint, code, ipAdd
 	}
 
 	// 解密密钥
-	secret, err := uc.decrypt(mfa.TOTPSecret)
+	secret, err := kms.Decrypt(mfa.TOTPSecret)
 	if err != nil {
 		return false, fmt.Errorf("解密密钥失败: %w", err)
 	}
@@ -219,7 +208,7 @@ func (uc *UseCase) ValidateMFACode(ctx context.Context, userID uint, code, ipAdd
 // validateBackupCode 验证备用码
 func (uc *UseCase) validateBackupCode(ctx context.Context, mfa *UserMFA, code string) bool {
 	// 解密备用码
-	backupCodesJSON, err := uc.decrypt(mfa.BackupCodes)
+	backupCodesJSON, err := kms.Decrypt(mfa.BackupCodes)
 	if err != nil {
 		return false
 	}
@@ -239,7 +228,7 @@ func (uc *UseCase) validateBackupCode(ctx context.Context, mfa *UserMFA, code st
 
 			// 更新数据库
 			updatedJSON, _ := json.Marshal(backupCodes)
-			encryptedBackupCodes, err := uc.encrypt(string(updatedJSON))
+			encryptedBackupCodes, err := kms.Encrypt(string(updatedJSON))
 			if err == nil {
 				mfa.BackupCodes = encryptedBackupCodes
 				uc.repo.UpdateMFA(ctx, mfa)
@@ -318,7 +307,7 @@ func (uc *UseCase) RegenerateBackupCodes(ctx context.Context, userID uint, code,
 	mfa, _ := uc.repo.GetUserMFA(ctx, userID)
 	if mfa != nil {
 		backupCodesJSON, _ := json.Marshal(backupCodes)
-		encryptedBackupCodes, err := uc.encrypt(string(backupCodesJSON))
+		encryptedBackupCodes, err := kms.Encrypt(string(backupCodesJSON))
 		if err != nil {
 			return nil, err
 		}
@@ -329,71 +318,6 @@ func (uc *UseCase) RegenerateBackupCodes(ctx context.Context, userID uint, code,
 	return backupCodes, nil
 }
 
-// encrypt AES-GCM加密
-func (uc *UseCase) encrypt(plaintext string) (string, error) {
-	block, err := aes.NewCipher(uc.encryptKey)
-	if err != nil {
-		return "", err
-	}
-
-	gcm, err := cipher.NewGCM(block)
-	if err != nil {
-		return "", err
-	}
-
-	nonce := make([]byte, gcm.NonceSize())
-	if _, err := rand.Read(nonce); err != nil {
-		return "", err
-	}
-
-	ciphertext := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
-	return fmt.Sprintf("%x", ciphertext), nil
-}
-
-// decrypt AES-GCM解密
-func (uc *UseCase) decrypt(ciphertext string) (string, error) {
-	block, err := aes.NewCipher(uc.encryptKey)
-	if err != nil {
-		return "", err
-	}
-
-	gcm, err := cipher.NewGCM(block)
-	if err != nil {
-		return "", err
-	}
-
-	nonceSize := gcm.NonceSize()
-	data := []byte(ciphertext)
-	if len(data) < nonceSize*2 {
-		return "", fmt.Errorf("密文太短")
-	}
-
-	// 解析十六进制
-	nonce := make([]byte, nonceSize)
-	ciphertextBytes := make([]byte, (len(data)-nonceSize*2)/2)
-
-	for i := 0; i < nonceSize; i++ {
-		_, err := fmt.Sscanf(string(data[i*2:(i+1)*2]), "%02x", &nonce[i])
-		if err != nil {
-			return "", err
-		}
-	}
-
-	for i := 0; i < len(ciphertextBytes); i++ {
-		_, err := fmt.Sscanf(string(data[(nonceSize+i)*2:(nonceSize+i+1)*2]), "%02x", &ciphertextBytes[i])
-		if err != nil {
-			return "", err
-		}
-	}
-
-	plaintext, err := gcm.Open(nil, nonce, ciphertextBytes, nil)
-	if err != nil {
-		return "", err
-	}
-
-	return string(plaintext), nil
-}
-
 // IsMFAEnabled 检查用户是否启用了MFA
 func (uc *UseCase) IsMFAEnabled(ctx context.Context, userID uint) bool {
 	mfa, err := uc.repo.GetUserMFA(ctx, userID)
@@ -24,14 +24,24 @@ import (
 	"strings"
 
 	"github.com/spf13/viper"
+	"github.com/ydcloud-dy/opshub/pkg/kms"
 )
 
 // Config 全局配置
 type Config struct {
-	Server   ServerConfig   `mapstructure:"server"`
-	Database DatabaseConfig `mapstructure:"database"`
-	Redis    RedisConfig    `mapstructure:"redis"`
-	Log      LogConfig      `mapstructure:"log"`
+	Server             ServerConfig             `mapstructure:"server"`
+	Database           DatabaseConfig           `mapstructure:"database"`
+	Redis              RedisConfig              `mapstructure:"redis"`
+	Log                LogConfig                `mapstructure:"log"`
+	Recording          RecordingConfig          `mapstructure:"recording"`
+	Metrics            MetricsConfig            `mapstructure:"metrics"`
+	Heartbeat          HeartbeatConfig          `mapstructure:"heartbeat"`
+	KMS                KMSConfig                `mapstructure:"kms"`
+	Vault              VaultConfig              `mapstructure:"vault"`
+	CredentialRotation CredentialRotationConfig `mapstructure:"credential_rotation"`
+	SSHCA              SSHCAConfig              `mapstructure:"ssh_ca"`
+	CloudSync          CloudSyncConfig          `mapstructure:"cloud_sync"`
+	Ansible            AnsibleConfig            `mapstructure:"ansible"`
 }
 
 // ServerConfig 服务器配置
@@ -102,6 +112,111 @@ type LogConfig struct {
 	Console    bool   `mapstructure:"console"`
 }
 
+// RecordingConfig 终端录制存储配置
+type RecordingConfig struct {
+	Storage       string            `mapstructure:"storage"` // local, s3
+	LocalDir      string            `mapstructure:"local_dir"`
+	Compress      bool              `mapstructure:"compress"`
+	RetentionDays int               `mapstructure:"retention_days"` // 0 表示永久保留
+	S3            RecordingS3Config `mapstructure:"s3"`
+}
+
+// RecordingS3Config S3兼容对象存储配置
+type RecordingS3Config struct {
+	Endpoint     string `mapstructure:"endpoint"`
+	Region       string `mapstructure:"region"`
+	Bucket       string `mapstructure:"bucket"`
+	AccessKey    string `mapstructure:"access_key"`
+	SecretKey    string `mapstructure:"secret_key"`
+	Prefix       string `mapstructure:"prefix"`
+	UsePathStyle bool   `mapstructure:"use_path_style"`
+}
+
+// MetricsConfig 主机监控指标采集配置，数值为0时使用默认值
+type MetricsConfig struct {
+	Enabled             bool `mapstructure:"enabled"`
+	Interval            int  `mapstructure:"interval"`              // 采集间隔(秒)，默认60
+	Concurrency         int  `mapstructure:"concurrency"`           // 同时采集的主机数，默认10
+	RawRetentionHours   int  `mapstructure:"raw_retention_hours"`   // 原始样本保留小时数，默认48
+	MinuteRetentionDays int  `mapstructure:"minute_retention_days"` // 5分钟聚合数据保留天数，默认14
+	HourRetentionDays   int  `mapstructure:"hour_retention_days"`   // 1小时聚合数据保留天数，默认180
+}
+
+// HeartbeatConfig 主机在线状态探测配置，数值为0时使用默认值
+type HeartbeatConfig struct {
+	Enabled          bool   `mapstructure:"enabled"`
+	Mode             string `mapstructure:"mode"`              // 探测方式 tcp/ssh，默认tcp
+	Interval         int    `mapstructure:"interval"`          // 探测间隔(秒)，默认30
+	Concurrency      int    `mapstructure:"concurrency"`       // 同时探测的主机数，默认20
+	Timeout          int    `mapstructure:"timeout"`           // 单次探测超时(秒)，默认5
+	FailureThreshold int    `mapstructure:"failure_threshold"` // 连续失败多少次判定离线，默认3
+	RetentionDays    int    `mapstructure:"retention_days"`    // 可用性记录保留天数，默认90
+	Alert            bool   `mapstructure:"alert"`             // 主机离线和恢复时通过监控插件的告警通道通知
+}
+
+// KMSConfig 敏感数据加密密钥配置
+type KMSConfig struct {
+	Primary       string `mapstructure:"primary"`        // 加密新数据使用的密钥ID
+	Keys          string `mapstructure:"keys"`           // 密钥列表 id:base64密钥,...，建议通过环境变量 OPSHUB_KMS_KEYS 设置
+	KeyringFile   string `mapstructure:"keyring_file"`   // 文件密钥环路径
+	AutoGenerate  bool   `mapstructure:"auto_generate"`  // 未配置密钥且密钥环文件不存在时自动生成
+	LegacyDecrypt bool   `mapstructure:"legacy_decrypt"` // 是否允许解密旧版本使用内置密钥加密的数据
+}
+
+// VaultConfig 凭证外部密钥存储 HashiCorp Vault KV v2 配置
+type VaultConfig struct {
+	Enabled       bool   `mapstructure:"enabled"`
+	Address       string `mapstructure:"address"`         // 为空时使用环境变量 VAULT_ADDR
+	Token         string `mapstructure:"token"`           // 为空时使用环境变量 VAULT_TOKEN，建议通过环境变量 OPSHUB_VAULT_TOKEN 设置
+	Namespace     string `mapstructure:"namespace"`       // 企业版命名空间
+	Mount         string `mapstructure:"mount"`           // KV v2 引擎挂载路径，默认secret
+	Timeout       int    `mapstructure:"timeout"`         // 请求超时(秒)，默认10
+	CacheTTL      int    `mapstructure:"cache_ttl"`       // 密钥缓存时间(秒)，0表示不缓存
+	TLSSkipVerify bool   `mapstructure:"tls_skip_verify"` // 跳过证书校验，仅用于测试环境
+}
+
+// CredentialRotationConfig 凭证轮换配置，数值为0时使用默认值
+type CredentialRotationConfig struct {
+	Schedule       bool `mapstructure:"schedule"`        // 按凭证配置的周期自动轮换，多实例部署时只在一个实例开启
+	CheckInterval  int  `mapstructure:"check_interval"`  // 检查到期凭证的间隔(秒)，默认3600
+	Concurrency    int  `mapstructure:"concurrency"`     // 同时更新的主机数，默认5
+	CommandTimeout int  `mapstructure:"command_timeout"` // 单条命令超时(秒)，默认30
+	RetryHours     int  `mapstructure:"retry_hours"`     // 自动轮换失败后多久再次尝试(小时)，默认24
+}
+
+// SSHCAConfig SSH证书签发配置，数值为空时使用默认值
+type SSHCAConfig struct {
+	CertTTL         int      `mapstructure:"cert_ttl"`         // 证书有效期(秒)，默认300
+	MaxCertTTL      int      `mapstructure:"max_cert_ttl"`     // Ansible等长时间任务的最长有效期(秒)，默认86400
+	PrincipalPrefix string   `mapstructure:"principal_prefix"` // 由角色生成的 principal 前缀，默认 opshub-role-
+	SystemPrincipal string   `mapstructure:"system_principal"` // 平台自身连接（信息采集、巡检等）使用的 principal，默认 opshub-system
+	ForwardingRoles []string `mapstructure:"forwarding_roles"` // 证书允许端口转发的角色编码，经跳板机转发的连接总是允许
+}
+
+// CloudSyncConfig 云库存同步配置，数值为0时使用默认值
+type CloudSyncConfig struct {
+	Schedule      bool `mapstructure:"schedule"`       // 按云账号配置的间隔自动同步，多实例部署时只在一个实例开启
+	CheckInterval int  `mapstructure:"check_interval"` // 检查到期云账号的间隔(秒)，默认300
+}
+
+// AnsibleConfig Ansible任务执行配置，数值为空时使用默认值
+type AnsibleConfig struct {
+	PlaybookDir string `mapstructure:"playbook_dir"` // 托管Playbook的目录，任务只能引用该目录下的Playbook文件，默认 ./data/playbooks
+	RunAsUser   string `mapstructure:"run_as_user"`  // 执行 ansible-playbook 的系统用户，默认 nobody
+}
+
+// KeyringConfig 转换为密钥环配置，MFA 旧版本使用 JWT 密钥加密，需要作为旧密钥解密
+func (c *Config) KeyringConfig() *kms.Config {
+	return &kms.Config{
+		Primary:       c.KMS.Primary,
+		Keys:          c.KMS.Keys,
+		KeyringFile:   c.KMS.KeyringFile,
+		AutoGenerate:  c.KMS.AutoGenerate,
+		LegacyDecrypt: c.KMS.LegacyDecrypt,
+		LegacySecrets: []string{c.Server.JWTSecret},
+	}
+}
+
 var globalConfig *Config
 
 // Load 加载配置
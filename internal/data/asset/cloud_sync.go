@@ -0,0 +1,85 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package asset
+
+import (
+	"context"
+
+	"github.com/ydcloud-dy/opshub/internal/biz/asset"
+	"gorm.io/gorm"
+)
+
+type cloudSyncRepo struct {
+	db *gorm.DB
+}
+
+// NewCloudSyncRepo 创建云库存同步记录仓库
+func NewCloudSyncRepo(db *gorm.DB) asset.CloudSyncRepo {
+	return &cloudSyncRepo{db: db}
+}
+
+// CreateRun 创建同步记录
+func (r *cloudSyncRepo) CreateRun(ctx context.Context, run *asset.CloudSyncRun) error {
+	return r.db.WithContext(ctx).Create(run).Error
+}
+
+// UpdateRun 更新同步记录
+func (r *cloudSyncRepo) UpdateRun(ctx context.Context, run *asset.CloudSyncRun) error {
+	return r.db.WithContext(ctx).Save(run).Error
+}
+
+// CreateChange 保存单个实例的变更
+func (r *cloudSyncRepo) CreateChange(ctx context.Context, change *asset.CloudSyncChange) error {
+	return r.db.WithContext(ctx).Create(change).Error
+}
+
+// GetRun 获取同步记录及变更明细
+func (r *cloudSyncRepo) GetRun(ctx context.Context, id uint) (*asset.CloudSyncRun, error) {
+	var run asset.CloudSyncRun
+	if err := r.db.WithContext(ctx).First(&run, id).Error; err != nil {
+		return nil, err
+	}
+	if err := r.db.WithContext(ctx).Where("run_id = ?", id).Order("id ASC").Find(&run.Changes).Error; err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+// ListRuns 分页查询云账号的同步记录，按时间倒序
+func (r *cloudSyncRepo) ListRuns(ctx context.Context, accountID uint, page, pageSize int) ([]*asset.CloudSyncRun, int64, error) {
+	var runs []*asset.CloudSyncRun
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&asset.CloudSyncRun{}).Where("account_id = ?", accountID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	err := query.Order("id DESC").Offset(offset).Limit(pageSize).Find(&runs).Error
+	return runs, total, err
+}
+
+// ListRunning 获取所有执行中的同步记录
+func (r *cloudSyncRepo) ListRunning(ctx context.Context) ([]*asset.CloudSyncRun, error) {
+	var runs []*asset.CloudSyncRun
+	err := r.db.WithContext(ctx).Where("status = ?", asset.CloudSyncStatusRunning).Find(&runs).Error
+	return runs, err
+}
@@ -0,0 +1,118 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package asset
+
+import (
+	"context"
+
+	"github.com/ydcloud-dy/opshub/internal/biz/asset"
+	"github.com/ydcloud-dy/opshub/internal/biz/rbac"
+	"gorm.io/gorm"
+)
+
+type commandRuleRepo struct {
+	db *gorm.DB
+}
+
+func NewCommandRuleRepo(db *gorm.DB) asset.CommandRuleRepo {
+	return &commandRuleRepo{db: db}
+}
+
+func (r *commandRuleRepo) Create(ctx context.Context, rule *asset.CommandRule) error {
+	return r.db.WithContext(ctx).Create(rule).Error
+}
+
+func (r *commandRuleRepo) Update(ctx context.Context, rule *asset.CommandRule) error {
+	return r.db.WithContext(ctx).Model(rule).Select(
+		"name", "action", "match_type", "pattern", "scope_type", "scope_id", "priority", "status", "description",
+	).Updates(rule).Error
+}
+
+func (r *commandRuleRepo) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&asset.CommandRule{}, id).Error
+}
+
+func (r *commandRuleRepo) GetByID(ctx context.Context, id uint) (*asset.CommandRule, error) {
+	var rule asset.CommandRule
+	err := r.db.WithContext(ctx).First(&rule, id).Error
+	return &rule, err
+}
+
+func (r *commandRuleRepo) List(ctx context.Context, page, pageSize int, keyword, scopeType, action string) ([]*asset.CommandRule, int64, error) {
+	var rules []*asset.CommandRule
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&asset.CommandRule{})
+	if keyword != "" {
+		query = query.Where("name LIKE ? OR pattern LIKE ?", "%"+keyword+"%", "%"+keyword+"%")
+	}
+	if scopeType != "" {
+		query = query.Where("scope_type = ?", scopeType)
+	}
+	if action != "" {
+		query = query.Where("action = ?", action)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Order("priority ASC, id ASC").Offset((page - 1) * pageSize).Limit(pageSize).Find(&rules).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return rules, total, nil
+}
+
+// ListEnabled 获取全部启用的规则
+func (r *commandRuleRepo) ListEnabled(ctx context.Context) ([]*asset.CommandRule, error) {
+	var rules []*asset.CommandRule
+	err := r.db.WithContext(ctx).Where("status = ?", 1).Order("priority ASC, id ASC").Find(&rules).Error
+	return rules, err
+}
+
+// GetUserRoleIDs 获取用户的角色ID
+func (r *commandRuleRepo) GetUserRoleIDs(ctx context.Context, userID uint) ([]uint, error) {
+	var roleIDs []uint
+	err := r.db.WithContext(ctx).Model(&rbac.SysUserRole{}).Where("user_id = ?", userID).Pluck("role_id", &roleIDs).Error
+	return roleIDs, err
+}
+
+// InitDefaultRules 规则表为空时初始化默认规则，已有规则（包括被删除的默认规则）时不做处理
+func (r *commandRuleRepo) InitDefaultRules(ctx context.Context) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Unscoped().Model(&asset.CommandRule{}).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return nil
+		}
+		for _, rule := range asset.DefaultCommandRules {
+			rule.ScopeType = asset.CommandScopeGlobal
+			rule.Status = 1
+			if err := tx.Create(&rule).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,95 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package asset
+
+import (
+	"context"
+
+	"github.com/ydcloud-dy/opshub/internal/biz/asset"
+	"gorm.io/gorm"
+)
+
+type credentialRotationRepo struct {
+	db *gorm.DB
+}
+
+// NewCredentialRotationRepo 创建凭证轮换记录仓库
+func NewCredentialRotationRepo(db *gorm.DB) asset.CredentialRotationRepo {
+	return &credentialRotationRepo{db: db}
+}
+
+// Create 创建轮换记录
+func (r *credentialRotationRepo) Create(ctx context.Context, rotation *asset.CredentialRotation) error {
+	return r.db.WithContext(ctx).Create(rotation).Error
+}
+
+// Update 更新轮换记录
+func (r *credentialRotationRepo) Update(ctx context.Context, rotation *asset.CredentialRotation) error {
+	return r.db.WithContext(ctx).Save(rotation).Error
+}
+
+// SaveHost 保存单台主机的执行结果
+func (r *credentialRotationRepo) SaveHost(ctx context.Context, host *asset.CredentialRotationHost) error {
+	return r.db.WithContext(ctx).Save(host).Error
+}
+
+// GetByID 获取轮换记录及各主机结果
+func (r *credentialRotationRepo) GetByID(ctx context.Context, id uint) (*asset.CredentialRotation, error) {
+	var rotation asset.CredentialRotation
+	if err := r.db.WithContext(ctx).First(&rotation, id).Error; err != nil {
+		return nil, err
+	}
+	if err := r.db.WithContext(ctx).Where("rotation_id = ?", id).Order("id ASC").Find(&rotation.Hosts).Error; err != nil {
+		return nil, err
+	}
+	return &rotation, nil
+}
+
+// List 分页查询凭证的轮换记录，按时间倒序
+func (r *credentialRotationRepo) List(ctx context.Context, credentialID uint, page, pageSize int) ([]*asset.CredentialRotation, int64, error) {
+	var rotations []*asset.CredentialRotation
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&asset.CredentialRotation{}).Where("credential_id = ?", credentialID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	err := query.Order("id DESC").Offset(offset).Limit(pageSize).Find(&rotations).Error
+	return rotations, total, err
+}
+
+// Last 获取凭证最近一次轮换记录，没有记录时返回 nil
+func (r *credentialRotationRepo) Last(ctx context.Context, credentialID uint) (*asset.CredentialRotation, error) {
+	var rotations []*asset.CredentialRotation
+	err := r.db.WithContext(ctx).Where("credential_id = ?", credentialID).Order("id DESC").Limit(1).Find(&rotations).Error
+	if err != nil || len(rotations) == 0 {
+		return nil, err
+	}
+	return rotations[0], nil
+}
+
+// ListRunning 获取所有执行中的轮换记录
+func (r *credentialRotationRepo) ListRunning(ctx context.Context) ([]*asset.CredentialRotation, error) {
+	var rotations []*asset.CredentialRotation
+	err := r.db.WithContext(ctx).Where("status = ?", asset.RotationStatusRunning).Find(&rotations).Error
+	return rotations, err
+}
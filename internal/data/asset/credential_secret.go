@@ -0,0 +1,85 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package asset
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ydcloud-dy/opshub/internal/biz/asset"
+	"github.com/ydcloud-dy/opshub/pkg/vault"
+	"gorm.io/gorm"
+)
+
+type credentialSecretAccessRepo struct {
+	db *gorm.DB
+}
+
+// NewCredentialSecretAccessRepo 创建外部密钥读取审计仓库
+func NewCredentialSecretAccessRepo(db *gorm.DB) asset.CredentialSecretAccessRepo {
+	return &credentialSecretAccessRepo{db: db}
+}
+
+// Create 记录一次外部密钥读取
+func (r *credentialSecretAccessRepo) Create(ctx context.Context, access *asset.CredentialSecretAccess) error {
+	return r.db.WithContext(ctx).Create(access).Error
+}
+
+// List 分页查询凭证的外部密钥读取记录，按时间倒序
+func (r *credentialSecretAccessRepo) List(ctx context.Context, credentialID uint, page, pageSize int) ([]*asset.CredentialSecretAccess, int64, error) {
+	var accesses []*asset.CredentialSecretAccess
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&asset.CredentialSecretAccess{}).Where("credential_id = ?", credentialID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	err := query.Order("id DESC").Offset(offset).Limit(pageSize).Find(&accesses).Error
+	return accesses, total, err
+}
+
+// vaultSecretBackend 使用 Vault KV v2 存放凭证敏感字段
+type vaultSecretBackend struct {
+	client *vault.Client
+}
+
+// NewVaultSecretBackend 创建 Vault 外部密钥存储
+func NewVaultSecretBackend(client *vault.Client) asset.SecretBackend {
+	return &vaultSecretBackend{client: client}
+}
+
+// Name 存储名称，对应凭证的来源
+func (b *vaultSecretBackend) Name() string {
+	return asset.CredentialSourceVault
+}
+
+// Read 读取密钥最新版本
+func (b *vaultSecretBackend) Read(ctx context.Context, path string) (*asset.ExternalSecret, error) {
+	secret, err := b.client.ReadKV(ctx, path)
+	if errors.Is(err, vault.ErrNotFound) {
+		return nil, errors.New("密钥不存在")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &asset.ExternalSecret{Data: secret.Data, Version: secret.Version}, nil
+}
@@ -21,15 +21,11 @@ package asset
 
 import (
 	"context"
-	"crypto/aes"
-	"crypto/cipher"
-	"crypto/rand"
-	"encoding/base64"
 	"fmt"
-	"io"
 	"time"
 
 	"github.com/ydcloud-dy/opshub/internal/biz/asset"
+	"github.com/ydcloud-dy/opshub/pkg/kms"
 	"gorm.io/gorm"
 )
 
@@ -63,9 +59,15 @@ func (r *hostRepo) CreateOrUpdate(ctx context.Context, host *asset.Host) error {
 			existing.IP = host.IP
 			existing.Port = host.Port
 			existing.CredentialID = host.CredentialID
+			existing.JumpHostID = host.JumpHostID
 			existing.Tags = host.Tags
 			existing.Description = host.Description
 			existing.Status = host.Status
+			// 恢复的主机可能已重装，清除之前记录的主机密钥
+			existing.HostKeyType = ""
+			existing.HostKeyFingerprint = ""
+			existing.HostKeyPinned = false
+			existing.HostKeyUpdatedAt = nil
 			existing.DeletedAt.Time = *new(time.Time) // 清除删除时间
 			existing.DeletedAt.Valid = false
 			return r.db.WithContext(ctx).Unscoped().Save(&existing).Error
@@ -80,7 +82,75 @@ func (r *hostRepo) CreateOrUpdate(ctx context.Context, host *asset.Host) error {
 
 // Update 更新主机
 func (r *hostRepo) Update(ctx context.Context, host *asset.Host) error {
-	return r.db.WithContext(ctx).Save(host).Error
+	// 主机密钥列由 UpdateHostKey 单独维护，避免被过期数据覆盖
+	return r.db.WithContext(ctx).Omit(asset.HostKeyColumns()...).Save(host).Error
+}
+
+// UpdateHostKey 更新主机密钥，key 为 nil 时清除记录
+func (r *hostRepo) UpdateHostKey(ctx context.Context, id uint, key *asset.HostKey) error {
+	updates := map[string]interface{}{
+		"host_key_type":        "",
+		"host_key_fingerprint": "",
+		"host_key_pinned":      false,
+		"host_key_updated_at":  nil,
+	}
+	if key != nil {
+		now := time.Now()
+		updates["host_key_type"] = key.KeyType
+		updates["host_key_fingerprint"] = key.Fingerprint
+		updates["host_key_pinned"] = key.Pinned
+		updates["host_key_updated_at"] = &now
+	}
+	return r.db.WithContext(ctx).Model(&asset.Host{}).Where("id = ?", id).Updates(updates).Error
+}
+
+// UpdateMetrics 只更新监控指标相关列，避免定时采集覆盖用户对主机的修改
+func (r *hostRepo) UpdateMetrics(ctx context.Context, host *asset.Host) error {
+	return r.db.WithContext(ctx).Model(&asset.Host{}).Where("id = ?", host.ID).
+		Select("cpu_usage", "memory_total", "memory_used", "memory_usage", "disk_total", "disk_used", "disk_usage", "last_seen").
+		Updates(host).Error
+}
+
+// ListWithCredential 获取所有已配置凭证、可以通过SSH采集的主机
+func (r *hostRepo) ListWithCredential(ctx context.Context) ([]*asset.Host, error) {
+	var hosts []*asset.Host
+	err := r.db.WithContext(ctx).Where("credential_id > 0 AND cloud_status <> ?", asset.CloudStatusTerminated).Order("id ASC").Find(&hosts).Error
+	return hosts, err
+}
+
+// ListAll 获取所有主机，云实例已释放的主机除外
+func (r *hostRepo) ListAll(ctx context.Context) ([]*asset.Host, error) {
+	var hosts []*asset.Host
+	err := r.db.WithContext(ctx).Where("cloud_status <> ?", asset.CloudStatusTerminated).Order("id ASC").Find(&hosts).Error
+	return hosts, err
+}
+
+// ListByCloudAccountID 获取云账号下已关联云实例的主机
+func (r *hostRepo) ListByCloudAccountID(ctx context.Context, accountID uint) ([]*asset.Host, error) {
+	var hosts []*asset.Host
+	err := r.db.WithContext(ctx).Where("cloud_account_id = ? AND cloud_instance_id <> ''", accountID).Order("id ASC").Find(&hosts).Error
+	return hosts, err
+}
+
+// UpdateCloudInventory 更新云同步维护的字段，不覆盖采集和探测写入的数据
+func (r *hostRepo) UpdateCloudInventory(ctx context.Context, host *asset.Host) error {
+	return r.db.WithContext(ctx).Model(&asset.Host{}).Where("id = ?", host.ID).
+		Select("type", "cloud_provider", "cloud_instance_id", "cloud_account_id", "ip", "tags",
+			"cloud_region", "cloud_instance_type", "cloud_cpu", "cloud_memory_mb", "cloud_status", "cloud_tags", "cloud_synced_at").
+		Updates(host).Error
+}
+
+// UpdateStatus 仅当主机当前状态仍为 prevStatus 时更新状态和最后连接时间，
+// 返回是否更新成功，多个实例同时探测时只有一个实例会记录状态变化
+func (r *hostRepo) UpdateStatus(ctx context.Context, id uint, prevStatus, status int, lastSeen *time.Time) (bool, error) {
+	updates := map[string]interface{}{"status": status}
+	if lastSeen != nil {
+		updates["last_seen"] = lastSeen
+	}
+	result := r.db.WithContext(ctx).Model(&asset.Host{}).
+		Where("id = ? AND status = ?", id, prevStatus).
+		Updates(updates)
+	return result.RowsAffected > 0, result.Error
 }
 
 // Delete 删除主机
@@ -180,87 +250,35 @@ func (r *hostRepo) CountByCredentialID(ctx context.Context, credentialID uint) (
 	return count, err
 }
 
-// credentialRepo 凭证仓库
-type credentialRepo struct {
-	db            *gorm.DB
-	encryptionKey []byte
+// ListByCredentialID 获取使用指定凭证的所有主机
+func (r *hostRepo) ListByCredentialID(ctx context.Context, credentialID uint) ([]*asset.Host, error) {
+	var hosts []*asset.Host
+	err := r.db.WithContext(ctx).Where("credential_id = ?", credentialID).Order("id ASC").Find(&hosts).Error
+	return hosts, err
 }
 
-// NewCredentialRepo 创建凭证仓库
-func NewCredentialRepo(db *gorm.DB) asset.CredentialRepo {
-	// AES-256要求密钥长度必须是32字节（256位）
-	encryptionKey := []byte("opshub-enc-key-32-bytes-long!!!!")
-	return &credentialRepo{
-		db:            db,
-		encryptionKey: encryptionKey,
-	}
+// CountByJumpHostID 统计使用指定跳板机的主机数量
+func (r *hostRepo) CountByJumpHostID(ctx context.Context, jumpHostID uint) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&asset.Host{}).Where("jump_host_id = ?", jumpHostID).Count(&count).Error
+	return count, err
 }
 
-// encrypt 加密
-func (r *credentialRepo) encrypt(plaintext string) (string, error) {
-	if plaintext == "" {
-		return "", nil
-	}
-
-	block, err := aes.NewCipher(r.encryptionKey)
-	if err != nil {
-		return "", err
-	}
-
-	gcm, err := cipher.NewGCM(block)
-	if err != nil {
-		return "", err
-	}
-
-	nonce := make([]byte, gcm.NonceSize())
-	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
-		return "", err
-	}
-
-	ciphertext := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
-	return base64.StdEncoding.EncodeToString(ciphertext), nil
+// credentialRepo 凭证仓库，密码、私钥和私钥密码通过 kms 加密存储
+type credentialRepo struct {
+	db *gorm.DB
 }
 
-// decrypt 解密
-func (r *credentialRepo) decrypt(ciphertext string) (string, error) {
-	if ciphertext == "" {
-		return "", nil
-	}
-
-	data, err := base64.StdEncoding.DecodeString(ciphertext)
-	if err != nil {
-		return "", err
-	}
-
-	block, err := aes.NewCipher(r.encryptionKey)
-	if err != nil {
-		return "", err
-	}
-
-	gcm, err := cipher.NewGCM(block)
-	if err != nil {
-		return "", err
-	}
-
-	nonceSize := gcm.NonceSize()
-	if len(data) < nonceSize {
-		return "", fmt.Errorf("ciphertext too short")
-	}
-
-	nonce, cipherData := data[:nonceSize], data[nonceSize:]
-	plaintext, err := gcm.Open(nil, nonce, cipherData, nil)
-	if err != nil {
-		return "", err
-	}
-
-	return string(plaintext), nil
+// NewCredentialRepo 创建凭证仓库
+func NewCredentialRepo(db *gorm.DB) asset.CredentialRepo {
+	return &credentialRepo{db: db}
 }
 
 // Create 创建凭证
 func (r *credentialRepo) Create(ctx context.Context, credential *asset.Credential) error {
 	// 加密敏感信息
 	if credential.Password != "" {
-		encrypted, err := r.encrypt(credential.Password)
+		encrypted, err := kms.Encrypt(credential.Password)
 		if err != nil {
 			return fmt.Errorf("加密密码失败: %w", err)
 		}
@@ -268,7 +286,7 @@ func (r *credentialRepo) Create(ctx context.Context, credential *asset.Credentia
 	}
 
 	if credential.PrivateKey != "" {
-		encrypted, err := r.encrypt(credential.PrivateKey)
+		encrypted, err := kms.Encrypt(credential.PrivateKey)
 		if err != nil {
 			return fmt.Errorf("加密私钥失败: %w", err)
 		}
@@ -276,7 +294,7 @@ func (r *credentialRepo) Create(ctx context.Context, credential *asset.Credentia
 	}
 
 	if credential.Passphrase != "" {
-		encrypted, err := r.encrypt(credential.Passphrase)
+		encrypted, err := kms.Encrypt(credential.Passphrase)
 		if err != nil {
 			return fmt.Errorf("加密私钥密码失败: %w", err)
 		}
@@ -290,7 +308,7 @@ func (r *credentialRepo) Create(ctx context.Context, credential *asset.Credentia
 func (r *credentialRepo) Update(ctx context.Context, credential *asset.Credential) error {
 	// 加密敏感信息
 	if credential.Password != "" {
-		encrypted, err := r.encrypt(credential.Password)
+		encrypted, err := kms.Encrypt(credential.Password)
 		if err != nil {
 			return fmt.Errorf("加密密码失败: %w", err)
 		}
@@ -298,7 +316,7 @@ func (r *credentialRepo) Update(ctx context.Context, credential *asset.Credentia
 	}
 
 	if credential.PrivateKey != "" {
-		encrypted, err := r.encrypt(credential.PrivateKey)
+		encrypted, err := kms.Encrypt(credential.PrivateKey)
 		if err != nil {
 			return fmt.Errorf("加密私钥失败: %w", err)
 		}
@@ -306,7 +324,7 @@ func (r *credentialRepo) Update(ctx context.Context, credential *asset.Credentia
 	}
 
 	if credential.Passphrase != "" {
-		encrypted, err := r.encrypt(credential.Passphrase)
+		encrypted, err := kms.Encrypt(credential.Passphrase)
 		if err != nil {
 			return fmt.Errorf("加密私钥密码失败: %w", err)
 		}
@@ -340,39 +358,42 @@ func (r *credentialRepo) GetByID(ctx context.Context, id uint) (*asset.Credentia
 	return &credential, nil
 }
 
-// GetByIDDecrypted 根据ID获取凭证（解密后的）
+// GetByIDDecrypted 根据ID获取凭证（解密后的），引用外部密钥的凭证会读取外部密钥
 func (r *credentialRepo) GetByIDDecrypted(ctx context.Context, id uint) (*asset.Credential, error) {
 	credential, err := r.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 
-	// 解密敏感信息
-	if credential.Password != "" {
-		decrypted, err := r.decrypt(credential.Password)
-		if err != nil {
-			return nil, fmt.Errorf("解密密码失败: %w", err)
-		}
-		credential.Password = decrypted
+	if err := DecryptCredential(ctx, credential); err != nil {
+		return nil, err
 	}
+	return credential, nil
+}
 
-	if credential.PrivateKey != "" {
-		decrypted, err := r.decrypt(credential.PrivateKey)
-		if err != nil {
-			return nil, fmt.Errorf("解密私钥失败: %w", err)
-		}
-		credential.PrivateKey = decrypted
+// DecryptCredential 解密直接从数据库读取的凭证中的密码、私钥和私钥密码，
+// 引用外部密钥的凭证从外部存储读取这些字段
+func DecryptCredential(ctx context.Context, credential *asset.Credential) error {
+	if credential.IsExternal() {
+		return asset.ResolveCredentialSecret(ctx, credential)
 	}
 
-	if credential.Passphrase != "" {
-		decrypted, err := r.decrypt(credential.Passphrase)
+	fields := []struct {
+		name  string
+		value *string
+	}{
+		{"密码", &credential.Password},
+		{"私钥", &credential.PrivateKey},
+		{"私钥密码", &credential.Passphrase},
+	}
+	for _, field := range fields {
+		decrypted, err := kms.Decrypt(*field.value)
 		if err != nil {
-			return nil, fmt.Errorf("解密私钥密码失败: %w", err)
+			return fmt.Errorf("解密%s失败: %w", field.name, err)
 		}
-		credential.Passphrase = decrypted
+		*field.value = decrypted
 	}
-
-	return credential, nil
+	return nil
 }
 
 // List 列表查询
@@ -409,6 +430,13 @@ func (r *credentialRepo) GetAll(ctx context.Context) ([]*asset.Credential, error
 	return credentials, nil
 }
 
+// ListRotationEnabled 获取配置了自动轮换周期的凭证
+func (r *credentialRepo) ListRotationEnabled(ctx context.Context) ([]*asset.Credential, error) {
+	var credentials []*asset.Credential
+	err := r.db.WithContext(ctx).Where("rotation_days > 0").Order("id ASC").Find(&credentials).Error
+	return credentials, err
+}
+
 // cloudAccountRepo 云平台账号仓库
 type cloudAccountRepo struct {
 	db *gorm.DB
@@ -473,3 +501,16 @@ func (r *cloudAccountRepo) GetAll(ctx context.Context) ([]*asset.CloudAccount, e
 	}
 	return accounts, nil
 }
+
+// ListSyncEnabled 获取启用且配置了自动同步间隔的云平台账号
+func (r *cloudAccountRepo) ListSyncEnabled(ctx context.Context) ([]*asset.CloudAccount, error) {
+	var accounts []*asset.CloudAccount
+	err := r.db.WithContext(ctx).Where("sync_interval > 0 AND status = 1").Order("id ASC").Find(&accounts).Error
+	return accounts, err
+}
+
+// UpdateSyncState 更新最后同步时间和状态
+func (r *cloudAccountRepo) UpdateSyncState(ctx context.Context, id uint, syncedAt time.Time, status string) error {
+	return r.db.WithContext(ctx).Model(&asset.CloudAccount{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"last_sync_at": syncedAt, "last_sync_status": status}).Error
+}
@@ -0,0 +1,69 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package asset
+
+import (
+	"context"
+	"time"
+
+	"github.com/ydcloud-dy/opshub/internal/biz/asset"
+	"gorm.io/gorm"
+)
+
+type hostAvailabilityRepo struct {
+	db *gorm.DB
+}
+
+// NewHostAvailabilityRepo 创建主机可用性记录仓库
+func NewHostAvailabilityRepo(db *gorm.DB) asset.HostAvailabilityRepo {
+	return &hostAvailabilityRepo{db: db}
+}
+
+// Create 记录一次状态变化
+func (r *hostAvailabilityRepo) Create(ctx context.Context, event *asset.HostAvailabilityEvent) error {
+	return r.db.WithContext(ctx).Create(event).Error
+}
+
+// List 查询主机在时间范围 [start, end) 内的状态变化，按时间升序
+func (r *hostAvailabilityRepo) List(ctx context.Context, hostID uint, start, end time.Time) ([]*asset.HostAvailabilityEvent, error) {
+	var events []*asset.HostAvailabilityEvent
+	err := r.db.WithContext(ctx).
+		Where("host_id = ? AND created_at >= ? AND created_at < ?", hostID, start, end).
+		Order("created_at ASC, id ASC").Find(&events).Error
+	return events, err
+}
+
+// LastBefore 获取主机在 before 之前的最后一次状态变化，没有记录时返回 nil
+func (r *hostAvailabilityRepo) LastBefore(ctx context.Context, hostID uint, before time.Time) (*asset.HostAvailabilityEvent, error) {
+	var events []*asset.HostAvailabilityEvent
+	err := r.db.WithContext(ctx).
+		Where("host_id = ? AND created_at < ?", hostID, before).
+		Order("created_at DESC, id DESC").Limit(1).Find(&events).Error
+	if err != nil || len(events) == 0 {
+		return nil, err
+	}
+	return events[0], nil
+}
+
+// DeleteBefore 删除早于 before 的记录
+func (r *hostAvailabilityRepo) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("created_at < ?", before).Delete(&asset.HostAvailabilityEvent{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,123 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package asset
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/ydcloud-dy/opshub/internal/biz/asset"
+	"github.com/ydcloud-dy/opshub/internal/biz/audit"
+	appLogger "github.com/ydcloud-dy/opshub/pkg/logger"
+	sshclient "github.com/ydcloud-dy/opshub/pkg/ssh"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type hostKeyStore struct {
+	db *gorm.DB
+}
+
+// NewHostKeyStore 创建基于主机表的SSH主机密钥信任存储
+func NewHostKeyStore(db *gorm.DB) sshclient.HostKeyStore {
+	return &hostKeyStore{db: db}
+}
+
+// GetHostKey 获取主机已信任的密钥
+func (s *hostKeyStore) GetHostKey(hostID uint) (*sshclient.KnownHostKey, error) {
+	var host asset.Host
+	err := s.db.Select("id", "host_key_type", "host_key_fingerprint", "host_key_pinned").
+		Where("id = ?", hostID).First(&host).Error
+	if err != nil {
+		return nil, err
+	}
+	if host.HostKeyFingerprint == "" {
+		return nil, nil
+	}
+	return &sshclient.KnownHostKey{
+		KeyType:     host.HostKeyType,
+		Fingerprint: host.HostKeyFingerprint,
+		Pinned:      host.HostKeyPinned,
+	}, nil
+}
+
+// TrustHostKey 首次连接时记录主机密钥
+func (s *hostKeyStore) TrustHostKey(hostID uint, key *sshclient.KnownHostKey) error {
+	// 仅在尚未记录时写入，避免并发连接相互覆盖
+	result := s.db.Model(&asset.Host{}).
+		Where("id = ? AND (host_key_fingerprint = '' OR host_key_fingerprint IS NULL)", hostID).
+		Updates(map[string]interface{}{
+			"host_key_type":        key.KeyType,
+			"host_key_fingerprint": key.Fingerprint,
+			"host_key_pinned":      false,
+			"host_key_updated_at":  gorm.Expr("NOW()"),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		return nil
+	}
+
+	// 已被其他连接抢先记录，重新比对
+	known, err := s.GetHostKey(hostID)
+	if err != nil {
+		return err
+	}
+	if known != nil && known.Fingerprint != key.Fingerprint {
+		return fmt.Errorf("主机密钥已被记录为 %s", known.Fingerprint)
+	}
+	return nil
+}
+
+// ReportMismatch 写入主机密钥不匹配审计事件
+func (s *hostKeyStore) ReportMismatch(hostID uint, address string, expected, actual *sshclient.KnownHostKey) {
+	appLogger.Warn("SSH主机密钥不匹配，已拒绝连接",
+		zap.Uint("hostId", hostID),
+		zap.String("address", address),
+		zap.String("expected", expected.Fingerprint),
+		zap.String("actual", actual.Fingerprint),
+	)
+
+	params, _ := json.Marshal(map[string]interface{}{
+		"hostId":              hostID,
+		"address":             address,
+		"expectedKeyType":     expected.KeyType,
+		"expectedFingerprint": expected.Fingerprint,
+		"actualKeyType":       actual.KeyType,
+		"actualFingerprint":   actual.Fingerprint,
+		"pinned":              expected.Pinned,
+	})
+
+	log := &audit.SysOperationLog{
+		Username:    "system",
+		Module:      "主机管理",
+		Action:      "主机密钥校验",
+		Description: fmt.Sprintf("主机 %s SSH主机密钥不匹配，连接已拒绝", address),
+		Method:      "SSH",
+		Path:        fmt.Sprintf("/hosts/%d/host-key", hostID),
+		Params:      string(params),
+		Status:      403,
+		ErrorMsg:    fmt.Sprintf("expected %s, got %s", expected.Fingerprint, actual.Fingerprint),
+	}
+	if err := s.db.Create(log).Error; err != nil {
+		appLogger.Error("保存主机密钥审计事件失败", zap.Error(err))
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package asset
+
+import (
+	"context"
+	"time"
+
+	"github.com/ydcloud-dy/opshub/internal/biz/asset"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type hostMetricRepo struct {
+	db *gorm.DB
+}
+
+// NewHostMetricRepo 创建主机监控指标仓库
+func NewHostMetricRepo(db *gorm.DB) asset.HostMetricRepo {
+	return &hostMetricRepo{db: db}
+}
+
+// BatchCreate 批量写入监控指标，同一主机、粒度和时间的记录已存在时跳过
+func (r *hostMetricRepo) BatchCreate(ctx context.Context, metrics []*asset.HostMetric) error {
+	if len(metrics) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).
+		CreateInBatches(metrics, 200).Error
+}
+
+// List 查询时间范围 [start, end) 内的监控指标，hostIDs 为空时查询所有主机
+func (r *hostMetricRepo) List(ctx context.Context, hostIDs []uint, resolution int, start, end time.Time) ([]*asset.HostMetric, error) {
+	var metrics []*asset.HostMetric
+	query := r.db.WithContext(ctx).Where("resolution = ? AND ts >= ? AND ts < ?", resolution, start, end)
+	if len(hostIDs) > 0 {
+		query = query.Where("host_id IN ?", hostIDs)
+	}
+	err := query.Order("host_id ASC, ts ASC").Find(&metrics).Error
+	return metrics, err
+}
+
+// LatestTimestamp 获取指定粒度最新一条记录的时间，没有记录时返回 nil
+func (r *hostMetricRepo) LatestTimestamp(ctx context.Context, resolution int) (*time.Time, error) {
+	var metric asset.HostMetric
+	err := r.db.WithContext(ctx).Select("ts").Where("resolution = ?", resolution).
+		Order("ts DESC").Limit(1).Find(&metric).Error
+	if err != nil || metric.Timestamp.IsZero() {
+		return nil, err
+	}
+	return &metric.Timestamp, nil
+}
+
+// DeleteBefore 删除指定粒度早于 before 的记录
+func (r *hostMetricRepo) DeleteBefore(ctx context.Context, resolution int, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("resolution = ? AND ts < ?", resolution, before).Delete(&asset.HostMetric{})
+	return result.RowsAffected, result.Error
+}
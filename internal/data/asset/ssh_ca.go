@@ -0,0 +1,115 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package asset
+
+import (
+	"context"
+
+	"github.com/ydcloud-dy/opshub/internal/biz/asset"
+	"gorm.io/gorm"
+)
+
+type sshCARepo struct {
+	db *gorm.DB
+}
+
+// NewSSHCARepo 创建SSH CA与证书签发记录仓库
+func NewSSHCARepo(db *gorm.DB) asset.SSHCARepo {
+	return &sshCARepo{db: db}
+}
+
+// Create 创建CA
+func (r *sshCARepo) Create(ctx context.Context, authority *asset.SSHCertAuthority) error {
+	return r.db.WithContext(ctx).Create(authority).Error
+}
+
+// GetByID 根据ID获取CA
+func (r *sshCARepo) GetByID(ctx context.Context, id uint) (*asset.SSHCertAuthority, error) {
+	var authority asset.SSHCertAuthority
+	if err := r.db.WithContext(ctx).First(&authority, id).Error; err != nil {
+		return nil, err
+	}
+	return &authority, nil
+}
+
+// GetActive 获取用于签发的CA，没有时返回 nil
+func (r *sshCARepo) GetActive(ctx context.Context) (*asset.SSHCertAuthority, error) {
+	var authorities []*asset.SSHCertAuthority
+	err := r.db.WithContext(ctx).Where("active = ?", true).Order("id DESC").Limit(1).Find(&authorities).Error
+	if err != nil || len(authorities) == 0 {
+		return nil, err
+	}
+	return authorities[0], nil
+}
+
+// List 获取所有CA，启用的排在最前
+func (r *sshCARepo) List(ctx context.Context) ([]*asset.SSHCertAuthority, error) {
+	var authorities []*asset.SSHCertAuthority
+	err := r.db.WithContext(ctx).Order("active DESC, id DESC").Find(&authorities).Error
+	return authorities, err
+}
+
+// Activate 启用指定CA并停用其他CA
+func (r *sshCARepo) Activate(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&asset.SSHCertAuthority{}).Where("active = ? AND id <> ?", true, id).Update("active", false).Error; err != nil {
+			return err
+		}
+		return tx.Model(&asset.SSHCertAuthority{}).Where("id = ?", id).Update("active", true).Error
+	})
+}
+
+// Delete 删除CA
+func (r *sshCARepo) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&asset.SSHCertAuthority{}, id).Error
+}
+
+// CreateCertificate 记录签发的证书
+func (r *sshCARepo) CreateCertificate(ctx context.Context, cert *asset.SSHCertificate) error {
+	return r.db.WithContext(ctx).Create(cert).Error
+}
+
+// ListCertificates 分页查询签发记录，按签发时间倒序
+func (r *sshCARepo) ListCertificates(ctx context.Context, query *asset.SSHCertificateQuery) ([]*asset.SSHCertificate, int64, error) {
+	var certs []*asset.SSHCertificate
+	var total int64
+
+	db := r.db.WithContext(ctx).Model(&asset.SSHCertificate{})
+	if query.HostID > 0 {
+		db = db.Where("host_id = ?", query.HostID)
+	}
+	if query.UserID > 0 {
+		db = db.Where("user_id = ?", query.UserID)
+	}
+	if query.Purpose != "" {
+		db = db.Where("purpose = ?", query.Purpose)
+	}
+	if query.Keyword != "" {
+		keyword := "%" + query.Keyword + "%"
+		db = db.Where("username LIKE ? OR host_name LIKE ? OR host_ip LIKE ?", keyword, keyword, keyword)
+	}
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (query.Page - 1) * query.PageSize
+	err := db.Order("id DESC").Offset(offset).Limit(query.PageSize).Find(&certs).Error
+	return certs, total, err
+}
@@ -0,0 +1,99 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package audit
+
+import (
+	"context"
+	"time"
+
+	"github.com/ydcloud-dy/opshub/internal/biz/audit"
+	"gorm.io/gorm"
+)
+
+type fileTransferLogRepo struct {
+	db *gorm.DB
+}
+
+func NewFileTransferLogRepo(db *gorm.DB) audit.FileTransferLogRepo {
+	return &fileTransferLogRepo{db: db}
+}
+
+func (r *fileTransferLogRepo) Create(ctx context.Context, log *audit.SysFileTransferLog) error {
+	return r.db.WithContext(ctx).Create(log).Error
+}
+
+func (r *fileTransferLogRepo) GetByID(ctx context.Context, id uint) (*audit.SysFileTransferLog, error) {
+	var log audit.SysFileTransferLog
+	err := r.db.WithContext(ctx).First(&log, id).Error
+	return &log, err
+}
+
+func (r *fileTransferLogRepo) List(ctx context.Context, page, pageSize int, q *audit.FileTransferLogQuery) ([]*audit.SysFileTransferLog, int64, error) {
+	var logs []*audit.SysFileTransferLog
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&audit.SysFileTransferLog{})
+
+	if q.Username != "" {
+		query = query.Where("username LIKE ?", "%"+q.Username+"%")
+	}
+	if q.HostID > 0 {
+		query = query.Where("host_id = ?", q.HostID)
+	}
+	if q.Host != "" {
+		query = query.Where("host_name LIKE ? OR host_ip LIKE ?", "%"+q.Host+"%", "%"+q.Host+"%")
+	}
+	if q.Direction != "" {
+		query = query.Where("direction = ?", q.Direction)
+	}
+	if q.Status != "" {
+		query = query.Where("status = ?", q.Status)
+	}
+	if q.RemotePath != "" {
+		query = query.Where("remote_path LIKE ?", "%"+q.RemotePath+"%")
+	}
+	if q.SHA256 != "" {
+		query = query.Where("sha256 = ?", q.SHA256)
+	}
+	if q.StartTime != "" {
+		t, err := time.Parse("2006-01-02", q.StartTime)
+		if err == nil {
+			query = query.Where("created_at >= ?", t)
+		}
+	}
+	if q.EndTime != "" {
+		t, err := time.Parse("2006-01-02", q.EndTime)
+		if err == nil {
+			t = t.AddDate(0, 0, 1)
+			query = query.Where("created_at < ?", t)
+		}
+	}
+
+	err := query.Count(&total).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	err = query.Offset((page-1)*pageSize).Limit(pageSize).
+		Order("created_at DESC").
+		Find(&logs).Error
+
+	return logs, total, err
+}
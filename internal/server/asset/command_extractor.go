@@ -0,0 +1,410 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package asset
+
+import (
+	"strings"
+	"unicode"
+)
+
+// maxCommandLength 单条命令保存的最大长度（字符）
+const maxCommandLength = 2000
+
+// ExtractedCommand 从终端会话中还原出的一条命令
+type ExtractedCommand struct {
+	Command string
+	Offset  float64 // 按下回车时相对录制开始的时间（秒），用于跳转回放
+	Action  string  // 命令过滤结果，见 assetbiz.TerminalCommandAction* 常量
+	Reason  string
+}
+
+// commandExtractor 从终端输入输出流中还原执行的命令行。
+// 命令以回显为准：维护光标所在行的内容，处理退格、光标移动、行内擦除等控制序列，
+// 因此行编辑、Tab 补全和历史命令的回显都能正确还原；不回显的输入（如密码）不会被记录。
+// 按下回车后，在回显换行时将提示符之后的内容作为命令。超过终端宽度自动折行的命令按一行处理
+type commandExtractor struct {
+	cols      int
+	line      []rune // 光标所在的逻辑行（含自动折行），宽字符后跟一个 0 占位
+	cursor    int
+	wrapNext  bool // 光标停在行尾，下一个字符才折行
+	promptLen int  // 开始输入当前命令时的光标位置，之前的内容视为提示符
+	editing   bool // 已开始输入当前命令
+	altScreen bool // 全屏程序（vim、top 等）运行中，不提取命令
+
+	pending  []ExtractedCommand // 已按下回车、等待回显换行的命令
+	decision ExtractedCommand   // 下一次回车对应的命令过滤决策
+	escape   []rune             // 未处理完的输出控制序列
+	input    []byte             // 未处理完的输入控制序列
+
+	commands []ExtractedCommand
+}
+
+func newCommandExtractor(cols int) *commandExtractor {
+	e := &commandExtractor{}
+	e.Resize(cols)
+	return e
+}
+
+// Resize 更新终端宽度
+func (e *commandExtractor) Resize(cols int) {
+	if cols <= 0 {
+		cols = 80
+	}
+	e.cols = cols
+}
+
+// Input 处理用户输入
+func (e *commandExtractor) Input(offset float64, data []byte) {
+	for i := 0; i < len(data); i++ {
+		b := data[i]
+		if len(e.input) > 0 {
+			// 跳过方向键等转义序列，光标变化以回显为准
+			e.input = append(e.input, b)
+			if len(e.input) == 2 && b != '[' && b != 'O' {
+				e.input = e.input[:0]
+			} else if len(e.input) > 2 && b >= 0x40 && b <= 0x7e {
+				e.input = e.input[:0]
+			}
+			continue
+		}
+		switch b {
+		case 0x1b:
+			e.input = append(e.input, b)
+			e.startEditing()
+		case '\r', '\n':
+			if b == '\n' && i > 0 && data[i-1] == '\r' {
+				continue
+			}
+			if !e.altScreen {
+				cmd := e.decision
+				cmd.Offset = offset
+				e.pending = append(e.pending, cmd)
+			}
+			e.decision = ExtractedCommand{}
+			e.editing = false
+		case 0x03, 0x04:
+			// Ctrl-C 放弃当前行，Ctrl-D 退出
+			e.pending = e.pending[:0]
+			e.editing = false
+		default:
+			e.startEditing()
+		}
+	}
+}
+
+// startEditing 记录开始输入命令时的光标位置作为提示符长度
+func (e *commandExtractor) startEditing() {
+	if !e.editing && len(e.pending) == 0 {
+		e.editing = true
+		e.promptLen = e.cursor
+	}
+}
+
+// Output 处理终端输出
+func (e *commandExtractor) Output(data []byte) {
+	for _, r := range string(data) {
+		if len(e.escape) > 0 {
+			e.escape = append(e.escape, r)
+			if e.escapeDone() {
+				e.handleEscape()
+				e.escape = e.escape[:0]
+			}
+			continue
+		}
+		switch r {
+		case 0x1b:
+			e.escape = append(e.escape, r)
+		case '\r':
+			e.cursor = e.rowStart()
+			e.wrapNext = false
+		case '\n':
+			e.newline()
+		case '\b':
+			if e.cursor > e.rowStart() {
+				e.cursor--
+				if e.cursor > 0 && e.cursor < len(e.line) && e.line[e.cursor] == 0 {
+					e.cursor--
+				}
+			}
+			e.wrapNext = false
+		case 0x07:
+		case '\t':
+			e.cursor = min(e.rowStart()+((e.cursor-e.rowStart())/8+1)*8, e.rowStart()+e.cols-1)
+		default:
+			if !unicode.IsControl(r) {
+				e.put(r)
+			}
+		}
+	}
+}
+
+// Commands 返回已还原的命令
+func (e *commandExtractor) Commands() []ExtractedCommand {
+	return e.commands
+}
+
+// Current 返回当前正在输入的命令行，全屏程序运行中或未开始输入时返回空
+func (e *commandExtractor) Current() string {
+	if e.altScreen || !e.editing {
+		return ""
+	}
+	return e.lineText()
+}
+
+// Settled 按下的回车是否都已回显换行
+func (e *commandExtractor) Settled() bool {
+	return len(e.pending) == 0
+}
+
+// Decide 设置下一次回车对应命令的过滤决策
+func (e *commandExtractor) Decide(action, reason string) {
+	e.decision = ExtractedCommand{Action: action, Reason: reason}
+}
+
+// Append 直接记录一条命令，用于被拦截、未实际执行因而不会回显换行的命令
+func (e *commandExtractor) Append(cmd ExtractedCommand) {
+	cmd.Command = truncateCommand(strings.TrimSpace(cmd.Command))
+	if cmd.Command != "" {
+		e.commands = append(e.commands, cmd)
+	}
+}
+
+// lineText 返回当前行提示符之后的内容
+func (e *commandExtractor) lineText() string {
+	if e.promptLen > len(e.line) {
+		return ""
+	}
+	return strings.TrimSpace(strings.ReplaceAll(string(e.line[e.promptLen:]), "\x00", ""))
+}
+
+// newline 换行：有等待回显的回车时提取命令
+func (e *commandExtractor) newline() {
+	if len(e.pending) > 0 {
+		cmd := e.pending[0]
+		e.pending = e.pending[1:]
+		if !e.altScreen {
+			cmd.Command = e.lineText()
+			e.Append(cmd)
+		}
+		e.promptLen = 0
+	}
+	e.line = e.line[:0]
+	e.cursor = 0
+	e.wrapNext = false
+}
+
+// truncateCommand 截断超长的命令
+func truncateCommand(command string) string {
+	if runes := []rune(command); len(runes) > maxCommandLength {
+		return string(runes[:maxCommandLength])
+	}
+	return command
+}
+
+// rowStart 返回光标所在物理行在逻辑行中的起始位置
+func (e *commandExtractor) rowStart() int {
+	if e.wrapNext {
+		return (e.cursor - 1) / e.cols * e.cols
+	}
+	return e.cursor / e.cols * e.cols
+}
+
+// put 在光标处写入字符（覆盖模式），写满一行后自动折行
+func (e *commandExtractor) put(r rune) {
+	width := 1
+	if isWideRune(r) {
+		width = 2
+	}
+	e.wrapNext = false
+	for len(e.line) < e.cursor+width+1 {
+		e.line = append(e.line, ' ')
+	}
+	if col := e.cursor % e.cols; width == 2 && col == e.cols-1 {
+		// 行尾放不下宽字符时折到下一行，空出的一列不属于命令
+		e.line[e.cursor] = 0
+		e.cursor++
+	}
+	e.line[e.cursor] = r
+	if width == 2 {
+		e.line[e.cursor+1] = 0
+	}
+	e.cursor += width
+	e.wrapNext = e.cursor%e.cols == 0
+}
+
+// escapeDone 判断输出控制序列是否完整
+func (e *commandExtractor) escapeDone() bool {
+	return escapeComplete(e.escape)
+}
+
+// escapeComplete 判断以 ESC 开头的控制序列是否完整
+func escapeComplete(seq []rune) bool {
+	if len(seq) < 2 {
+		return false
+	}
+	switch seq[1] {
+	case '[':
+		last := seq[len(seq)-1]
+		return len(seq) > 2 && last >= 0x40 && last <= 0x7e
+	case ']', 'P', '_', '^':
+		// OSC 等字符串序列以 BEL 或 ESC \ 结束
+		last := seq[len(seq)-1]
+		if last == 0x07 {
+			return true
+		}
+		return len(seq) > 3 && last == '\\' && seq[len(seq)-2] == 0x1b
+	case '(', ')', '#', '%':
+		return len(seq) >= 3
+	}
+	return true
+}
+
+// handleEscape 处理影响当前行内容的 CSI 控制序列
+func (e *commandExtractor) handleEscape() {
+	if e.escape[1] != '[' {
+		return
+	}
+	params := string(e.escape[2 : len(e.escape)-1])
+	final := e.escape[len(e.escape)-1]
+
+	if strings.HasPrefix(params, "?") {
+		// 备用屏幕：全屏程序运行期间不提取命令
+		if final == 'h' || final == 'l' {
+			for _, mode := range strings.Split(params[1:], ";") {
+				if mode == "1049" || mode == "47" || mode == "1047" {
+					e.altScreen = final == 'h'
+					e.pending = e.pending[:0]
+				}
+			}
+		}
+		return
+	}
+
+	n := 1
+	if params != "" {
+		n = 0
+		for _, c := range params {
+			if c < '0' || c > '9' {
+				break
+			}
+			n = n*10 + int(c-'0')
+		}
+	}
+	first := n
+	if params == "" || params == "0" {
+		first = 0
+	}
+
+	start := e.rowStart()
+	e.wrapNext = false
+	switch final {
+	case 'D': // 光标左移
+		e.cursor = max(e.cursor-max(n, 1), start)
+	case 'C': // 光标右移
+		e.cursor = min(e.cursor+max(n, 1), start+e.cols-1)
+	case 'A': // 光标上移，回到折行前的行
+		e.cursor = max(e.cursor-max(n, 1)*e.cols, e.cursor%e.cols)
+	case 'B': // 光标下移
+		e.cursor += max(n, 1) * e.cols
+	case 'G': // 光标移到指定列
+		e.cursor = start + min(max(n, 1), e.cols) - 1
+	case 'K': // 擦除行
+		end := start + e.cols
+		switch first {
+		case 0:
+			e.erase(e.cursor, end)
+		case 1:
+			e.erase(start, e.cursor+1)
+		case 2:
+			e.erase(start, end)
+		}
+	case 'J': // 擦除屏幕
+		switch first {
+		case 0:
+			if e.cursor < len(e.line) {
+				e.line = e.line[:e.cursor]
+			}
+		case 2, 3:
+			// 清屏后提示符会重新输出
+			e.line = e.line[:0]
+			e.cursor = 0
+		}
+	case 'P': // 删除字符，行内后续字符左移
+		end := start + e.cols
+		if e.cursor < len(e.line) {
+			n = min(max(n, 1), end-e.cursor)
+			row := e.line[e.cursor:min(end, len(e.line))]
+			copy(row, row[min(n, len(row)):])
+			for i := max(len(row)-n, 0); i < len(row); i++ {
+				row[i] = ' '
+			}
+		}
+	case '@': // 插入空白，行内后续字符右移
+		end := min(start+e.cols, len(e.line))
+		if e.cursor < end {
+			row := e.line[e.cursor:end]
+			n = min(max(n, 1), len(row))
+			copy(row[n:], row)
+			for i := 0; i < n; i++ {
+				row[i] = ' '
+			}
+		}
+	case 'X': // 擦除字符
+		e.erase(e.cursor, e.cursor+max(n, 1))
+	case 'H', 'f':
+		// 光标定位（如 Ctrl-L 清屏），只关注列
+		col := 1
+		if i := strings.IndexByte(params, ';'); i >= 0 {
+			col = 0
+			for _, c := range params[i+1:] {
+				if c < '0' || c > '9' {
+					break
+				}
+				col = col*10 + int(c-'0')
+			}
+		}
+		e.cursor = min(max(col, 1), e.cols) - 1
+	}
+}
+
+// erase 将 [from, to) 范围擦除为空白，范围到达行尾时直接截断
+func (e *commandExtractor) erase(from, to int) {
+	if from >= len(e.line) {
+		return
+	}
+	if to >= len(e.line) {
+		e.line = e.line[:from]
+		return
+	}
+	for i := from; i < to; i++ {
+		e.line[i] = ' '
+	}
+}
+
+// isWideRune 判断是否为占两列的宽字符
+func isWideRune(r rune) bool {
+	return unicode.Is(unicode.Han, r) ||
+		unicode.Is(unicode.Hangul, r) ||
+		unicode.Is(unicode.Hiragana, r) ||
+		unicode.Is(unicode.Katakana, r) ||
+		(r >= 0xff00 && r <= 0xff60) || // 全角字符
+		(r >= 0x3000 && r <= 0x303f) || // CJK 标点
+		(r >= 0x1f300 && r <= 0x1faff) // emoji
+}
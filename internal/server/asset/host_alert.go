@@ -0,0 +1,100 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package asset
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	assetbiz "github.com/ydcloud-dy/opshub/internal/biz/asset"
+	monitormodel "github.com/ydcloud-dy/opshub/plugins/monitor/model"
+	monitorservice "github.com/ydcloud-dy/opshub/plugins/monitor/service"
+	"gorm.io/gorm"
+)
+
+// hostStatusNotifier 通过监控插件的告警通道发送主机离线和恢复通知，并记录到告警日志
+type hostStatusNotifier struct {
+	db *gorm.DB
+}
+
+func newHostStatusNotifier(db *gorm.DB) *hostStatusNotifier {
+	return &hostStatusNotifier{db: db}
+}
+
+// NotifyHostStatus 发送主机状态变化通知
+func (n *hostStatusNotifier) NotifyHostStatus(ctx context.Context, host *assetbiz.Host, event *assetbiz.HostAvailabilityEvent) error {
+	db := n.db.WithContext(ctx)
+	targets, err := monitorservice.LoadAlertTargets(db, nil)
+	if err != nil {
+		return err
+	}
+	if len(targets.Channels) == 0 {
+		return fmt.Errorf("未配置启用的告警通道")
+	}
+
+	notification := monitorservice.Notification{
+		Lines: []string{
+			"主机名称: " + host.Name,
+			fmt.Sprintf("主机地址: %s:%d", host.IP, host.Port),
+		},
+		Timestamp: event.CreatedAt.Format("2006-01-02 15:04:05"),
+	}
+	if event.Status == assetbiz.HostStatusOffline {
+		notification.Type = "host_offline"
+		notification.Title = "主机离线告警"
+		notification.Lines = append(notification.Lines,
+			fmt.Sprintf("探测方式: %s，连续失败 %d 次", event.ProbeMode, event.Failures),
+			"失败原因: "+event.Reason,
+		)
+		if host.LastSeen != nil {
+			notification.Lines = append(notification.Lines, "最后在线时间: "+host.LastSeen.Format("2006-01-02 15:04:05"))
+		}
+	} else {
+		notification.Type = "host_recovered"
+		notification.Title = "主机恢复在线"
+		notification.Lines = append(notification.Lines,
+			fmt.Sprintf("探测方式: %s，耗时 %dms", event.ProbeMode, event.LatencyMs),
+		)
+		if host.LastSeen != nil {
+			offline := event.CreatedAt.Sub(*host.LastSeen).Round(time.Second)
+			notification.Lines = append(notification.Lines, "离线时长: 约"+offline.String())
+		}
+	}
+
+	sendErr := monitorservice.NewAlertService().SendNotification(notification, targets)
+
+	alertLog := &monitormodel.AlertLog{
+		AlertType:   notification.Type,
+		Domain:      fmt.Sprintf("%s (%s)", host.Name, host.IP),
+		Status:      "success",
+		Message:     notification.Title + "\n" + strings.Join(notification.Lines, "\n"),
+		ChannelType: targets.Channels[0].ChannelType,
+		SentAt:      time.Now(),
+	}
+	if sendErr != nil {
+		alertLog.Status = "failed"
+		alertLog.ErrorMsg = sendErr.Error()
+	}
+	db.Create(alertLog)
+
+	return sendErr
+}
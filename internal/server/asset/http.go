@@ -20,6 +20,8 @@
 package asset
 
 import (
+	"time"
+
 	"github.com/gin-gonic/gin"
 	assetService "github.com/ydcloud-dy/opshub/internal/service/asset"
 	assetdata "github.com/ydcloud-dy/opshub/internal/data/asset"
@@ -27,12 +29,20 @@ import (
 	rbacService "github.com/ydcloud-dy/opshub/internal/service/rbac"
 	rbacdata "github.com/ydcloud-dy/opshub/internal/data/rbac"
 	rbacbiz "github.com/ydcloud-dy/opshub/internal/biz/rbac"
+	auditdata "github.com/ydcloud-dy/opshub/internal/data/audit"
+	auditbiz "github.com/ydcloud-dy/opshub/internal/biz/audit"
+	"github.com/ydcloud-dy/opshub/internal/conf"
+	appLogger "github.com/ydcloud-dy/opshub/pkg/logger"
+	"github.com/ydcloud-dy/opshub/pkg/recording"
+	"github.com/ydcloud-dy/opshub/pkg/vault"
+	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
 
 type HTTPServer struct {
 	assetGroupService    *assetService.AssetGroupService
 	hostService          *assetService.HostService
+	commandRuleService   *assetService.CommandRuleService
 	terminalManager      *TerminalManager
 	terminalAuditHandler *TerminalAuditHandler
 	authMiddleware       *rbacService.AuthMiddleware
@@ -41,6 +51,7 @@ type HTTPServer struct {
 func NewHTTPServer(
 	assetGroupService *assetService.AssetGroupService,
 	hostService *assetService.HostService,
+	commandRuleService *assetService.CommandRuleService,
 	terminalManager *TerminalManager,
 	db *gorm.DB,
 	authMiddleware *rbacService.AuthMiddleware,
@@ -48,8 +59,9 @@ func NewHTTPServer(
 	return &HTTPServer{
 		assetGroupService:    assetGroupService,
 		hostService:          hostService,
+		commandRuleService:   commandRuleService,
 		terminalManager:      terminalManager,
-		terminalAuditHandler: NewTerminalAuditHandler(db),
+		terminalAuditHandler: NewTerminalAuditHandler(db, terminalManager),
 		authMiddleware:       authMiddleware,
 	}
 }
@@ -74,6 +86,7 @@ func (s *HTTPServer) RegisterRoutes(r *gin.RouterGroup) {
 		hosts.POST("/import", s.hostService.ImportFromExcel)
 		hosts.POST("/batch-collect", s.hostService.BatchCollectHostInfo)
 		hosts.POST("/batch-delete", s.hostService.BatchDeleteHosts)
+		hosts.GET("/metrics/compare", s.hostService.CompareHostMetrics)
 
 		// 查看权限 - 查看主机详情
 		hosts.GET("/:id",
@@ -99,19 +112,62 @@ func (s *HTTPServer) RegisterRoutes(r *gin.RouterGroup) {
 			s.hostService.CollectHostInfo)
 		hosts.POST("/:id/test", s.hostService.TestHostConnection)
 
-		// 文件管理权限 - 文件上传、下载、删除
+		// SSH主机密钥 - 查看需查看权限，固定/重置需编辑权限
+		hosts.GET("/:id/host-key",
+			s.authMiddleware.RequireHostPermission(rbacbiz.PermissionView),
+			s.hostService.GetHostKey)
+		hosts.PUT("/:id/host-key",
+			s.authMiddleware.RequireHostPermission(rbacbiz.PermissionEdit),
+			s.hostService.PinHostKey)
+		hosts.DELETE("/:id/host-key",
+			s.authMiddleware.RequireHostPermission(rbacbiz.PermissionEdit),
+			s.hostService.ResetHostKey)
+
+		// 监控指标历史和可用性 - 需查看权限
+		hosts.GET("/:id/metrics",
+			s.authMiddleware.RequireHostPermission(rbacbiz.PermissionView),
+			s.hostService.GetHostMetrics)
+		hosts.GET("/:id/availability",
+			s.authMiddleware.RequireHostPermission(rbacbiz.PermissionView),
+			s.hostService.GetHostAvailability)
+
+		// 文件管理权限 - 文件上传、下载、删除、重命名、权限修改、在线编辑
 		hosts.GET("/:id/files",
 			s.authMiddleware.RequireHostPermission(rbacbiz.PermissionFile),
 			s.hostService.ListHostFiles)
 		hosts.POST("/:id/files/upload",
 			s.authMiddleware.RequireHostPermission(rbacbiz.PermissionFile),
 			s.hostService.UploadHostFile)
+		hosts.GET("/:id/files/upload/status",
+			s.authMiddleware.RequireHostPermission(rbacbiz.PermissionFile),
+			s.hostService.GetHostUploadStatus)
+		hosts.POST("/:id/files/upload/chunk",
+			s.authMiddleware.RequireHostPermission(rbacbiz.PermissionFile),
+			s.hostService.UploadHostFileChunk)
 		hosts.GET("/:id/files/download",
 			s.authMiddleware.RequireHostPermission(rbacbiz.PermissionFile),
 			s.hostService.DownloadHostFile)
+		hosts.GET("/:id/files/archive",
+			s.authMiddleware.RequireHostPermission(rbacbiz.PermissionFile),
+			s.hostService.DownloadHostArchive)
 		hosts.DELETE("/:id/files",
 			s.authMiddleware.RequireHostPermission(rbacbiz.PermissionFile),
 			s.hostService.DeleteHostFile)
+		hosts.PUT("/:id/files/rename",
+			s.authMiddleware.RequireHostPermission(rbacbiz.PermissionFile),
+			s.hostService.RenameHostFile)
+		hosts.PUT("/:id/files/chmod",
+			s.authMiddleware.RequireHostPermission(rbacbiz.PermissionFile),
+			s.hostService.ChmodHostFile)
+		hosts.PUT("/:id/files/chown",
+			s.authMiddleware.RequireHostPermission(rbacbiz.PermissionFile),
+			s.hostService.ChownHostFile)
+		hosts.GET("/:id/files/content",
+			s.authMiddleware.RequireHostPermission(rbacbiz.PermissionFile),
+			s.hostService.GetHostFileContent)
+		hosts.PUT("/:id/files/content",
+			s.authMiddleware.RequireHostPermission(rbacbiz.PermissionFile),
+			s.hostService.SaveHostFileContent)
 	}
 
 	// 凭证管理
@@ -120,11 +176,27 @@ func (s *HTTPServer) RegisterRoutes(r *gin.RouterGroup) {
 		credentials.GET("", s.hostService.ListCredentials)
 		credentials.GET("/all", s.hostService.GetAllCredentials)
 		credentials.GET("/:id", s.hostService.GetCredential)
+		credentials.GET("/:id/secret-accesses", s.hostService.ListCredentialSecretAccesses)
+		credentials.POST("/:id/rotate", s.hostService.RotateCredential)
+		credentials.GET("/:id/rotations", s.hostService.ListCredentialRotations)
+		credentials.GET("/rotations/:rotationId", s.hostService.GetCredentialRotation)
+		credentials.POST("/rotations/:rotationId/rollback", s.hostService.RetryCredentialRotationRollback)
 		credentials.POST("", s.hostService.CreateCredential)
 		credentials.PUT("/:id", s.hostService.UpdateCredential)
 		credentials.DELETE("/:id", s.hostService.DeleteCredential)
 	}
 
+	// SSH证书签发 - 修改仅管理员
+	sshCA := r.Group("/ssh-ca")
+	{
+		sshCA.GET("", s.hostService.ListSSHCAs)
+		sshCA.GET("/trusted-keys", s.hostService.GetSSHCATrustedKeys)
+		sshCA.GET("/certificates", s.hostService.ListSSHCertificates)
+		sshCA.POST("", s.authMiddleware.RequireAdmin(), s.hostService.CreateSSHCA)
+		sshCA.POST("/:id/activate", s.authMiddleware.RequireAdmin(), s.hostService.ActivateSSHCA)
+		sshCA.DELETE("/:id", s.authMiddleware.RequireAdmin(), s.hostService.DeleteSSHCA)
+	}
+
 	// 云平台账号管理
 	cloudAccounts := r.Group("/cloud-accounts")
 	{
@@ -137,6 +209,20 @@ func (s *HTTPServer) RegisterRoutes(r *gin.RouterGroup) {
 		cloudAccounts.PUT("/:id", s.hostService.UpdateCloudAccount)
 		cloudAccounts.DELETE("/:id", s.hostService.DeleteCloudAccount)
 		cloudAccounts.POST("/import", s.hostService.ImportFromCloud)
+		cloudAccounts.POST("/:id/sync", s.hostService.SyncCloudAccount)
+		cloudAccounts.GET("/:id/sync-runs", s.hostService.ListCloudSyncRuns)
+		cloudAccounts.GET("/sync-runs/:runId", s.hostService.GetCloudSyncRun)
+	}
+
+	// 命令策略 - 修改仅管理员
+	commandRules := r.Group("/command-rules")
+	{
+		commandRules.GET("", s.commandRuleService.ListRules)
+		commandRules.POST("/check", s.commandRuleService.CheckCommand)
+		commandRules.GET("/:id", s.commandRuleService.GetRule)
+		commandRules.POST("", s.authMiddleware.RequireAdmin(), s.commandRuleService.CreateRule)
+		commandRules.PUT("/:id", s.authMiddleware.RequireAdmin(), s.commandRuleService.UpdateRule)
+		commandRules.DELETE("/:id", s.authMiddleware.RequireAdmin(), s.commandRuleService.DeleteRule)
 	}
 
 	// SSH终端 - 终端权限
@@ -153,14 +239,37 @@ func (s *HTTPServer) RegisterRoutes(r *gin.RouterGroup) {
 	{
 		terminalSessions.GET("", s.terminalAuditHandler.ListTerminalSessions)
 		terminalSessions.GET("/:id/play", s.terminalAuditHandler.PlayTerminalSession)
+		terminalSessions.GET("/:id/commands", s.terminalAuditHandler.ListSessionCommands)
+		terminalSessions.GET("/:id/export", s.terminalAuditHandler.ExportTerminalSession)
+		terminalSessions.GET("/k8s/:id/export", s.terminalAuditHandler.ExportK8sTerminalSession)
 		terminalSessions.DELETE("/:id", s.terminalAuditHandler.DeleteTerminalSession)
 	}
+
+	// 在线终端会话监控 - 仅管理员
+	activeSessions := r.Group("/terminal-sessions/active", s.authMiddleware.RequireAdmin())
+	{
+		activeSessions.GET("", s.terminalAuditHandler.ListActiveSessions)
+		activeSessions.GET("/:id/watch", s.terminalAuditHandler.WatchSession)
+		activeSessions.POST("/:id/kill", s.terminalAuditHandler.TerminateSession)
+	}
+
+	// 终端命令检索
+	r.GET("/terminal-commands", s.terminalAuditHandler.SearchTerminalCommands)
+
+	// 终端命令审批
+	terminalApprovals := r.Group("/terminal-approvals")
+	{
+		terminalApprovals.GET("", s.terminalAuditHandler.ListTerminalApprovals)
+		terminalApprovals.POST("/:id/approve", s.terminalAuditHandler.ApproveTerminalCommand)
+		terminalApprovals.POST("/:id/reject", s.terminalAuditHandler.RejectTerminalCommand)
+	}
 }
 
 // NewAssetServices 创建asset相关的服务
-func NewAssetServices(db *gorm.DB) (
+func NewAssetServices(db *gorm.DB, cfg *conf.Config) (
 	*assetService.AssetGroupService,
 	*assetService.HostService,
+	*assetService.CommandRuleService,
 	*TerminalManager,
 ) {
 	// 初始化Repository
@@ -169,20 +278,109 @@ func NewAssetServices(db *gorm.DB) (
 	credentialRepo := assetdata.NewCredentialRepo(db)
 	cloudAccountRepo := assetdata.NewCloudAccountRepo(db)
 	assetPermissionRepo := rbacdata.NewAssetPermissionRepo(db)
+	hostKeyStore := assetdata.NewHostKeyStore(db)
 
 	// 初始化UseCase
 	assetGroupUseCase := assetbiz.NewAssetGroupUseCase(assetGroupRepo)
 	credentialUseCase := assetbiz.NewCredentialUseCase(credentialRepo, hostRepo)
 	cloudAccountUseCase := assetbiz.NewCloudAccountUseCase(cloudAccountRepo)
-	hostUseCase := assetbiz.NewHostUseCase(hostRepo, credentialRepo, assetGroupRepo, cloudAccountRepo)
+	hostUseCase := assetbiz.NewHostUseCase(hostRepo, credentialRepo, assetGroupRepo, cloudAccountRepo, hostKeyStore)
 	assetPermissionUseCase := rbacbiz.NewAssetPermissionUseCase(assetPermissionRepo)
+	commandRuleUseCase := assetbiz.NewCommandRuleUseCase(assetdata.NewCommandRuleRepo(db), hostRepo, assetGroupRepo)
 
 	// 初始化Service
 	assetGroupService := assetService.NewAssetGroupService(assetGroupUseCase)
 	hostService := assetService.NewHostService(hostUseCase, credentialUseCase, cloudAccountUseCase, assetPermissionUseCase)
+	commandRuleService := assetService.NewCommandRuleService(commandRuleUseCase)
+
+	// 凭证外部密钥存储
+	credentialSecretAccessRepo := assetdata.NewCredentialSecretAccessRepo(db)
+	credentialUseCase.SetSecretAccessRepo(credentialSecretAccessRepo)
+	if cfg.Vault.Enabled {
+		client, err := vault.NewClient(vault.Config{
+			Address:       cfg.Vault.Address,
+			Token:         cfg.Vault.Token,
+			Namespace:     cfg.Vault.Namespace,
+			Mount:         cfg.Vault.Mount,
+			Timeout:       time.Duration(cfg.Vault.Timeout) * time.Second,
+			TLSSkipVerify: cfg.Vault.TLSSkipVerify,
+		})
+		if err != nil {
+			appLogger.Error("初始化Vault客户端失败，引用Vault密钥的凭证将无法使用", zap.Error(err))
+		} else {
+			assetbiz.SetCredentialSecretResolver(assetbiz.NewCredentialSecretResolver(
+				assetdata.NewVaultSecretBackend(client), credentialSecretAccessRepo, time.Duration(cfg.Vault.CacheTTL)*time.Second))
+		}
+	}
+
+	// SSH证书签发：证书凭证的主机连接时按用户身份签发短期证书
+	sshCAUseCase := assetbiz.NewSSHCAUseCase(assetdata.NewSSHCARepo(db), newSSHCertPrincipals(assetPermissionUseCase, rbacdata.NewRoleRepo(db)), assetbiz.SSHCAConfig{
+		CertTTL:         time.Duration(cfg.SSHCA.CertTTL) * time.Second,
+		MaxCertTTL:      time.Duration(cfg.SSHCA.MaxCertTTL) * time.Second,
+		PrincipalPrefix: cfg.SSHCA.PrincipalPrefix,
+		SystemPrincipal: cfg.SSHCA.SystemPrincipal,
+		ForwardingRoles: cfg.SSHCA.ForwardingRoles,
+	})
+	assetbiz.SetSSHCA(sshCAUseCase)
+	hostService.SetSSHCAUseCase(sshCAUseCase)
+
+	// 凭证轮换
+	rotationUseCase := assetbiz.NewCredentialRotationUseCase(assetdata.NewCredentialRotationRepo(db), credentialRepo, hostRepo, hostUseCase, assetbiz.CredentialRotationConfig{
+		Schedule:       cfg.CredentialRotation.Schedule,
+		CheckInterval:  time.Duration(cfg.CredentialRotation.CheckInterval) * time.Second,
+		Concurrency:    cfg.CredentialRotation.Concurrency,
+		CommandTimeout: time.Duration(cfg.CredentialRotation.CommandTimeout) * time.Second,
+		RetryAfter:     time.Duration(cfg.CredentialRotation.RetryHours) * time.Hour,
+	})
+	hostService.SetCredentialRotationUseCase(rotationUseCase)
+	rotationUseCase.Start()
+
+	// 云库存同步
+	cloudSyncUseCase := assetbiz.NewCloudSyncUseCase(assetdata.NewCloudSyncRepo(db), cloudAccountRepo, hostRepo, cloudAccountUseCase, assetbiz.CloudSyncConfig{
+		Schedule:      cfg.CloudSync.Schedule,
+		CheckInterval: time.Duration(cfg.CloudSync.CheckInterval) * time.Second,
+	})
+	hostService.SetCloudSyncUseCase(cloudSyncUseCase)
+	cloudSyncUseCase.Start()
+
+	// 设置文件传输日志用例到主机服务
+	hostService.SetFileTransferLogUseCase(auditbiz.NewFileTransferLogUseCase(auditdata.NewFileTransferLogRepo(db)))
+
+	// 主机监控指标定时采集
+	hostMetricUseCase := assetbiz.NewHostMetricUseCase(assetdata.NewHostMetricRepo(db), hostRepo, hostUseCase, assetbiz.MetricSchedulerConfig{
+		Interval:        time.Duration(cfg.Metrics.Interval) * time.Second,
+		Concurrency:     cfg.Metrics.Concurrency,
+		RawRetention:    time.Duration(cfg.Metrics.RawRetentionHours) * time.Hour,
+		MinuteRetention: time.Duration(cfg.Metrics.MinuteRetentionDays) * 24 * time.Hour,
+		HourRetention:   time.Duration(cfg.Metrics.HourRetentionDays) * 24 * time.Hour,
+	})
+	hostService.SetHostMetricUseCase(hostMetricUseCase)
+	if cfg.Metrics.Enabled {
+		hostMetricUseCase.Start()
+	}
+
+	// 主机在线状态定时探测
+	hostHeartbeatUseCase := assetbiz.NewHostHeartbeatUseCase(assetdata.NewHostAvailabilityRepo(db), hostRepo, hostUseCase, assetbiz.HeartbeatConfig{
+		Mode:             cfg.Heartbeat.Mode,
+		Interval:         time.Duration(cfg.Heartbeat.Interval) * time.Second,
+		Concurrency:      cfg.Heartbeat.Concurrency,
+		Timeout:          time.Duration(cfg.Heartbeat.Timeout) * time.Second,
+		FailureThreshold: cfg.Heartbeat.FailureThreshold,
+		Retention:        time.Duration(cfg.Heartbeat.RetentionDays) * 24 * time.Hour,
+	})
+	if cfg.Heartbeat.Alert {
+		hostHeartbeatUseCase.SetNotifier(newHostStatusNotifier(db))
+	}
+	hostService.SetHostHeartbeatUseCase(hostHeartbeatUseCase)
+	if cfg.Heartbeat.Enabled {
+		hostHeartbeatUseCase.Start()
+	}
 
 	// 初始化TerminalManager
-	terminalManager := NewTerminalManager(hostUseCase, db)
+	terminalManager := NewTerminalManager(hostUseCase, commandRuleUseCase, db)
+
+	// 按录制保留策略定期清理过期的终端会话
+	recording.StartRetention("host-terminal", purgeTerminalSessions(db))
 
-	return assetGroupService, hostService, terminalManager
+	return assetGroupService, hostService, commandRuleService, terminalManager
 }
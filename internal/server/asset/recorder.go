@@ -20,23 +20,31 @@
 package asset
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
-	"path/filepath"
+	"slices"
 	"sync"
 	"time"
+
+	"github.com/ydcloud-dy/opshub/pkg/recording"
 )
 
+// recordingCategory 主机终端录制在存储中的目录
+const recordingCategory = "host"
+
 // AsciinemaRecorder 实现终端录制功能，以asciinema格式保存
 type AsciinemaRecorder struct {
 	mu            sync.Mutex
 	file          *os.File
 	startTime     time.Time
-	recordingPath string
+	recordingPath string            // 录制中的临时文件路径
+	object        *recording.Object // 关闭后转存到录制存储的位置
 	lastTime      float64
 	cols          int
 	rows          int
+	commands      *commandExtractor // 从输入输出中还原执行的命令
 }
 
 // AsciinemaHeader asciinema文件头部
@@ -54,22 +62,20 @@ type AsciinemaEvent struct {
 	Data string
 }
 
-// NewAsciinemaRecorder 创建新的录制器
-func NewAsciinemaRecorder(recordingDir string, cols, rows int) (*AsciinemaRecorder, error) {
+// NewAsciinemaRecorder 创建新的录制器，录制过程中写入临时目录，关闭时转存到配置的录制存储
+func NewAsciinemaRecorder(cols, rows int) (*AsciinemaRecorder, error) {
 	// 确保录制目录存在
-	if err := os.MkdirAll(recordingDir, 0755); err != nil {
+	spoolDir := recording.SpoolDir()
+	if err := os.MkdirAll(spoolDir, 0755); err != nil {
 		return nil, fmt.Errorf("创建录制目录失败: %w", err)
 	}
 
-	// 生成录制文件名：时间戳.cast
-	filename := time.Now().Format("20060102-150405") + ".cast"
-	recordingPath := filepath.Join(recordingDir, filename)
-
-	// 创建录制文件
-	file, err := os.Create(recordingPath)
+	// 生成录制文件名：时间戳-随机串.cast，避免同一秒内的会话互相覆盖
+	file, err := os.CreateTemp(spoolDir, time.Now().Format("20060102-150405")+"-*.cast")
 	if err != nil {
 		return nil, fmt.Errorf("创建录制文件失败: %w", err)
 	}
+	recordingPath := file.Name()
 
 	recorder := &AsciinemaRecorder{
 		file:          file,
@@ -78,6 +84,7 @@ func NewAsciinemaRecorder(recordingDir string, cols, rows int) (*AsciinemaRecord
 		lastTime:      0,
 		cols:          cols,
 		rows:          rows,
+		commands:      newCommandExtractor(cols),
 	}
 
 	// 写入文件头部
@@ -90,6 +97,16 @@ func NewAsciinemaRecorder(recordingDir string, cols, rows int) (*AsciinemaRecord
 	return recorder, nil
 }
 
+// newCommandRecorder 创建不写录制文件的录制器，录制文件创建失败时仍用于命令还原和命令过滤
+func newCommandRecorder(cols, rows int) *AsciinemaRecorder {
+	return &AsciinemaRecorder{
+		startTime: time.Now(),
+		cols:      cols,
+		rows:      rows,
+		commands:  newCommandExtractor(cols),
+	}
+}
+
 // writeHeader 写入asciinema文件头部
 func (r *AsciinemaRecorder) writeHeader() error {
 	header := AsciinemaHeader{
@@ -121,7 +138,9 @@ func (r *AsciinemaRecorder) RecordOutput(data []byte) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	return r.recordEvent("o", data)
+	currentTime := time.Since(r.startTime).Seconds()
+	r.commands.Output(data)
+	return r.recordEvent(currentTime, "o", data)
 }
 
 // RecordInput 记录用户输入
@@ -133,18 +152,88 @@ func (r *AsciinemaRecorder) RecordInput(data []byte) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	return r.recordEvent("i", data)
+	currentTime := time.Since(r.startTime).Seconds()
+	r.commands.Input(currentTime, data)
+	return r.recordEvent(currentTime, "i", data)
+}
+
+// Resize 终端尺寸变化，用于还原自动折行的命令
+func (r *AsciinemaRecorder) Resize(cols, rows int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.cols = cols
+	r.rows = rows
+	r.commands.Resize(cols)
+}
+
+// RecordNotice 记录平台向终端输出的提示信息，不参与命令还原
+func (r *AsciinemaRecorder) RecordNotice(data []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.recordEvent(time.Since(r.startTime).Seconds(), "o", data)
+}
+
+// CurrentCommand 返回当前正在输入的命令行
+func (r *AsciinemaRecorder) CurrentCommand() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.commands.Current()
+}
+
+// Settled 按下的回车是否都已回显换行
+func (r *AsciinemaRecorder) Settled() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.commands.Settled()
+}
+
+// Decide 设置下一次回车对应命令的过滤结果
+func (r *AsciinemaRecorder) Decide(action, reason string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.commands.Decide(action, reason)
+}
+
+// RecordDecision 记录未实际执行的命令（被拦截、审批驳回等）
+func (r *AsciinemaRecorder) RecordDecision(command, action, reason string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.commands.Append(ExtractedCommand{
+		Command: command,
+		Offset:  time.Since(r.startTime).Seconds(),
+		Action:  action,
+		Reason:  reason,
+	})
+}
+
+// Size 返回当前终端尺寸
+func (r *AsciinemaRecorder) Size() (cols, rows int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.cols, r.rows
+}
+
+// Commands 返回已还原的命令，偏移时间与录制文件中的事件时间一致
+func (r *AsciinemaRecorder) Commands() []ExtractedCommand {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return slices.Clone(r.commands.Commands())
 }
 
-// recordEvent 记录事件
-func (r *AsciinemaRecorder) recordEvent(eventType string, data []byte) error {
+// recordEvent 记录事件，currentTime 为相对录制开始的时间（秒）
+func (r *AsciinemaRecorder) recordEvent(currentTime float64, eventType string, data []byte) error {
 	if r.file == nil {
 		return fmt.Errorf("录制器已关闭")
 	}
 
-	// 计算相对时间（秒）
-	currentTime := time.Since(r.startTime).Seconds()
-
 	// 构建事件数组：[时间, 类型, 数据]
 	event := []interface{}{currentTime, eventType, string(data)}
 
@@ -162,7 +251,7 @@ func (r *AsciinemaRecorder) recordEvent(eventType string, data []byte) error {
 	return nil
 }
 
-// Close 关闭录制器
+// Close 关闭录制器并将录制文件转存到录制存储
 func (r *AsciinemaRecorder) Close() error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
@@ -174,17 +263,42 @@ func (r *AsciinemaRecorder) Close() error {
 	err := r.file.Sync()
 	if err != nil {
 		r.file.Close()
+		r.file = nil
 		return fmt.Errorf("同步文件失败: %w", err)
 	}
 
 	err = r.file.Close()
 	r.file = nil
-	return err
+	if err != nil {
+		return err
+	}
+
+	object, err := recording.Save(context.Background(), r.recordingPath, recordingCategory)
+	if err != nil {
+		return err
+	}
+	r.object = object
+	return nil
 }
 
-// GetRecordingPath 获取录制文件路径
+// GetRecording 获取转存后的录制文件位置，录制未成功保存时返回nil
+func (r *AsciinemaRecorder) GetRecording() *recording.Object {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.object
+}
+
+// GetRecordingPath 获取录制文件在存储中的路径
 func (r *AsciinemaRecorder) GetRecordingPath() string {
-	return r.recordingPath
+	if object := r.GetRecording(); object != nil {
+		return object.Key
+	}
+	return ""
+}
+
+// GetStartTime 获取录制开始时间
+func (r *AsciinemaRecorder) GetStartTime() time.Time {
+	return r.startTime
 }
 
 // GetDuration 获取录制时长（秒）
@@ -192,11 +306,10 @@ func (r *AsciinemaRecorder) GetDuration() int {
 	return int(time.Since(r.startTime).Seconds())
 }
 
-// GetFileSize 获取文件大小（字节）
+// GetFileSize 获取录制文件在存储中的大小（字节）
 func (r *AsciinemaRecorder) GetFileSize() int64 {
-	fileInfo, err := os.Stat(r.recordingPath)
-	if err != nil {
-		return 0
+	if object := r.GetRecording(); object != nil {
+		return object.Size
 	}
-	return fileInfo.Size()
+	return 0
 }
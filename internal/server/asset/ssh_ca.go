@@ -0,0 +1,56 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package asset
+
+import (
+	"context"
+
+	rbacbiz "github.com/ydcloud-dy/opshub/internal/biz/rbac"
+)
+
+// sshCertPrincipals 签发SSH证书时校验用户的主机操作权限，并按用户角色生成 principal
+type sshCertPrincipals struct {
+	permissions *rbacbiz.AssetPermissionUseCase
+	roles       rbacbiz.RoleRepo
+}
+
+func newSSHCertPrincipals(permissions *rbacbiz.AssetPermissionUseCase, roles rbacbiz.RoleRepo) *sshCertPrincipals {
+	return &sshCertPrincipals{permissions: permissions, roles: roles}
+}
+
+// CheckHostOperationPermission 检查用户是否有主机的指定操作权限
+func (p *sshCertPrincipals) CheckHostOperationPermission(ctx context.Context, userID, hostID uint, operation uint) (bool, error) {
+	return p.permissions.CheckHostOperationPermission(ctx, userID, hostID, operation)
+}
+
+// UserRoleCodes 获取用户的启用角色编码
+func (p *sshCertPrincipals) UserRoleCodes(ctx context.Context, userID uint) ([]string, error) {
+	roles, err := p.roles.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	codes := make([]string, 0, len(roles))
+	for _, role := range roles {
+		if role.Status == 1 {
+			codes = append(codes, role.Code)
+		}
+	}
+	return codes, nil
+}
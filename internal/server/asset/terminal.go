@@ -20,6 +20,7 @@
 package asset
 
 import (
+	"cmp"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -33,7 +34,9 @@ import (
 	"github.com/gorilla/websocket"
 	"golang.org/x/crypto/ssh"
 	assetbiz "github.com/ydcloud-dy/opshub/internal/biz/asset"
+	rbacbiz "github.com/ydcloud-dy/opshub/internal/biz/rbac"
 	appLogger "github.com/ydcloud-dy/opshub/pkg/logger"
+	sshclient "github.com/ydcloud-dy/opshub/pkg/ssh"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
@@ -78,23 +81,47 @@ type TerminalSession struct {
 	StdoutPipe  io.Reader
 	StderrPipe  io.Reader
 	Recorder    *AsciinemaRecorder // 录制器
+	Guard       *commandGuard      // 命令过滤
 	CreatedAt   time.Time
+
+	connMu      sync.Mutex
+	conn        *websocket.Conn      // 用户的 WebSocket 连接，断开等待重连期间为 nil
+	output      outputBuffer         // 最近的输出，重连时补发
+	graceTimer  *time.Timer          // 等待重连的计时器
+	mirror      *sessionMirror       // 向旁观的管理员转发输出
+	termination *sessionTermination // 管理员强制终止的信息
 }
 
 // TerminalManager 终端管理器
 type TerminalManager struct {
-	sessions    map[string]*TerminalSession
-	mu          sync.RWMutex
-	hostUseCase *assetbiz.HostUseCase
-	db          *gorm.DB
+	sessions     map[string]*TerminalSession
+	mu           sync.RWMutex
+	hostUseCase  *assetbiz.HostUseCase
+	commandRules *assetbiz.CommandRuleUseCase
+	db           *gorm.DB
+
+	approvalMu sync.Mutex
+	approvals  map[uint]chan terminalApprovalDecision // 等待审批的终端命令
 }
 
 // NewTerminalManager 创建终端管理器
-func NewTerminalManager(hostUseCase *assetbiz.HostUseCase, db *gorm.DB) *TerminalManager {
+func NewTerminalManager(hostUseCase *assetbiz.HostUseCase, commandRules *assetbiz.CommandRuleUseCase, db *gorm.DB) *TerminalManager {
+	// 服务重启后原有终端会话均已断开，等待中的审批不再有效
+	if err := db.Model(&assetbiz.TerminalCommandApproval{}).
+		Where("status = ?", assetbiz.TerminalApprovalPending).
+		Updates(map[string]interface{}{
+			"status":           assetbiz.TerminalApprovalCancelled,
+			"approval_comment": "服务重启，终端会话已断开",
+		}).Error; err != nil {
+		appLogger.Warn("清理未完成的终端命令审批失败", zap.Error(err))
+	}
+
 	return &TerminalManager{
-		sessions:    make(map[string]*TerminalSession),
-		hostUseCase: hostUseCase,
-		db:          db,
+		sessions:     make(map[string]*TerminalSession),
+		hostUseCase:  hostUseCase,
+		commandRules: commandRules,
+		db:           db,
+		approvals:    make(map[uint]chan terminalApprovalDecision),
 	}
 }
 
@@ -118,11 +145,27 @@ func (tm *TerminalManager) CreateSession(ctx context.Context, hostID uint, userI
 		return nil, fmt.Errorf("主机未配置凭证")
 	}
 
+	// 证书凭证按当前用户签发短期证书，跳板机同样使用该用户的证书
+	ctx = assetbiz.WithSSHCertIdentity(ctx, assetbiz.SSHCertIdentity{
+		UserID:    userID,
+		Username:  username,
+		Purpose:   assetbiz.SSHCertPurposeTerminal,
+		Operation: rbacbiz.PermissionTerminal,
+	})
+
 	// 解析私钥
 	var signer ssh.Signer
 	var authMethod ssh.AuthMethod
 
-	if credential.Type == "key" {
+	if credential.IsCertificate() {
+		host := &assetbiz.Host{Name: hostVO.Name, IP: hostVO.IP, Port: hostVO.Port, SSHUser: hostVO.SSHUser}
+		host.ID = hostVO.ID
+		signer, err = assetbiz.SSHCertSigner(ctx, host, hostVO.SSHUser)
+		if err != nil {
+			return nil, fmt.Errorf("签发SSH证书失败: %w", err)
+		}
+		authMethod = ssh.PublicKeys(signer)
+	} else if credential.Type == "key" {
 		// 使用私钥认证
 		if credential.Password != "" {
 			signer, err = ssh.ParsePrivateKeyWithPassphrase([]byte(credential.PrivateKey), []byte(credential.Password))
@@ -140,15 +183,22 @@ func (tm *TerminalManager) CreateSession(ctx context.Context, hostID uint, userI
 
 	// SSH配置
 	config := &ssh.ClientConfig{
-		User:            hostVO.SSHUser,
-		Auth:            []ssh.AuthMethod{authMethod},
-		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
-		Timeout:         10 * time.Second,
+		User:              hostVO.SSHUser,
+		Auth:              []ssh.AuthMethod{authMethod},
+		HostKeyCallback:   sshclient.NewHostKeyCallback(tm.hostUseCase.GetHostKeyStore(), hostVO.ID),
+		HostKeyAlgorithms: sshclient.HostKeyAlgorithms(tm.hostUseCase.GetHostKeyStore(), hostVO.ID),
+		Timeout:           10 * time.Second,
+	}
+
+	// 解析跳板机链
+	jumpHosts, err := tm.hostUseCase.GetJumpHosts(ctx, hostID)
+	if err != nil {
+		return nil, err
 	}
 
-	// 连接SSH
+	// 连接SSH（配置了跳板机时经跳板机建立隧道）
 	address := fmt.Sprintf("%s:%d", hostVO.IP, hostVO.Port)
-	client, err := ssh.Dial("tcp", address, config)
+	client, err := sshclient.Dial(address, config, jumpHosts)
 	if err != nil {
 		return nil, fmt.Errorf("SSH连接失败: %w", err)
 	}
@@ -203,11 +253,11 @@ func (tm *TerminalManager) CreateSession(ctx context.Context, hostID uint, userI
 	}
 
 	// 创建录制器
-	recordingDir := "./data/terminal-recordings"
-	recorder, err := NewAsciinemaRecorder(recordingDir, int(cols), int(rows))
+	recorder, err := NewAsciinemaRecorder(int(cols), int(rows))
 	if err != nil {
-		// 录制失败不影响终端连接，继续
-		recorder = nil
+		// 录制失败不影响终端连接，继续还原命令以便命令过滤和审计
+		appLogger.Error("创建终端录制器失败", zap.Error(err))
+		recorder = newCommandRecorder(int(cols), int(rows))
 	}
 
 	// 创建会话对象
@@ -225,6 +275,7 @@ func (tm *TerminalManager) CreateSession(ctx context.Context, hostID uint, userI
 		StderrPipe: stderrPipe,
 		Recorder:   recorder,
 		CreatedAt:  time.Now(),
+		mirror:     newSessionMirror(),
 	}
 
 	// 保存会话
@@ -245,10 +296,11 @@ func (tm *TerminalManager) GetSession(sessionID string) (*TerminalSession, bool)
 
 // CloseSession 关闭会话
 func (tm *TerminalManager) CloseSession(sessionID string) error {
+	// 先从会话表中移除再收尾，录制转存到远端存储时不阻塞其他会话
 	tm.mu.Lock()
-	defer tm.mu.Unlock()
-
 	session, ok := tm.sessions[sessionID]
+	delete(tm.sessions, sessionID)
+	tm.mu.Unlock()
 	if !ok {
 		appLogger.Warn("尝试关闭不存在的会话", zap.String("sessionID", sessionID))
 		return fmt.Errorf("会话不存在")
@@ -261,6 +313,11 @@ func (tm *TerminalManager) CloseSession(sessionID string) error {
 		zap.Uint("userID", session.UserID),
 		zap.String("username", session.Username))
 
+	if session.Guard != nil {
+		session.Guard.Close()
+	}
+	session.mirror.Close()
+
 	// 关闭录制器并保存会话信息
 	if session.Recorder != nil {
 		// 关闭录制器
@@ -272,6 +329,7 @@ func (tm *TerminalManager) CloseSession(sessionID string) error {
 		duration := session.Recorder.GetDuration()
 		fileSize := session.Recorder.GetFileSize()
 		recordingPath := session.Recorder.GetRecordingPath()
+		object := session.Recorder.GetRecording()
 
 		appLogger.Info("录制信息",
 			zap.String("recordingPath", recordingPath),
@@ -290,6 +348,19 @@ func (tm *TerminalManager) CloseSession(sessionID string) error {
 			FileSize:      fileSize,
 			Status:        "completed",
 		}
+		if object != nil {
+			terminalSession.StorageType = object.Storage
+			terminalSession.Compressed = object.Compressed
+			terminalSession.Checksum = object.Checksum
+		} else {
+			terminalSession.Status = "failed"
+		}
+		if t := session.terminated(); t != nil {
+			terminalSession.Status = "terminated"
+			terminalSession.TerminatedBy = t.operatorID
+			terminalSession.TerminatedByName = t.operatorName
+			terminalSession.TerminateReason = t.reason
+		}
 
 		appLogger.Info("准备保存终端会话记录到数据库",
 			zap.Uint("hostID", terminalSession.HostID),
@@ -309,6 +380,7 @@ func (tm *TerminalManager) CloseSession(sessionID string) error {
 				zap.String("username", terminalSession.Username),
 				zap.String("hostName", terminalSession.HostName),
 				zap.Int("duration", duration))
+			tm.saveCommands(terminalSession, session.Recorder)
 		}
 	} else {
 		appLogger.Warn("会话没有录制器", zap.String("sessionID", sessionID))
@@ -322,11 +394,42 @@ func (tm *TerminalManager) CloseSession(sessionID string) error {
 		session.SSHClient.Close()
 	}
 
-	delete(tm.sessions, sessionID)
 	appLogger.Info("终端会话已关闭", zap.String("sessionID", sessionID))
 	return nil
 }
 
+// saveCommands 保存会话中还原出的命令
+func (tm *TerminalManager) saveCommands(terminalSession *assetbiz.TerminalSession, recorder *AsciinemaRecorder) {
+	extracted := recorder.Commands()
+	if len(extracted) == 0 {
+		return
+	}
+
+	startTime := recorder.GetStartTime()
+	commands := make([]*assetbiz.TerminalCommand, 0, len(extracted))
+	for _, cmd := range extracted {
+		commands = append(commands, &assetbiz.TerminalCommand{
+			SessionID:  terminalSession.ID,
+			HostID:     terminalSession.HostID,
+			HostName:   terminalSession.HostName,
+			HostIP:     terminalSession.HostIP,
+			UserID:     terminalSession.UserID,
+			Username:   terminalSession.Username,
+			Command:    cmd.Command,
+			Action:     cmp.Or(cmd.Action, assetbiz.TerminalCommandActionAllow),
+			Reason:     cmd.Reason,
+			Offset:     cmd.Offset,
+			ExecutedAt: startTime.Add(time.Duration(cmd.Offset * float64(time.Second))),
+		})
+	}
+	if err := tm.db.CreateInBatches(commands, 100).Error; err != nil {
+		appLogger.Error("保存终端命令记录失败",
+			zap.Error(err),
+			zap.Uint("sessionID", terminalSession.ID),
+			zap.Int("count", len(commands)))
+	}
+}
+
 // HandleSSHConnection 处理SSH WebSocket连接
 func (s *HTTPServer) HandleSSHConnection(c *gin.Context) {
 	hostIdStr := c.Param("id")
@@ -379,6 +482,13 @@ func (s *HTTPServer) HandleSSHConnection(c *gin.Context) {
 		zap.String("colsStr", colsStr),
 		zap.String("rowsStr", rowsStr))
 
+	// 重连参数：会话ID及已收到的输出字节数
+	resumeID := c.Query("session")
+	since, err := strconv.ParseInt(c.DefaultQuery("since", "0"), 10, 64)
+	if err != nil || since < 0 {
+		since = 0
+	}
+
 	// 升级到WebSocket
 	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
@@ -395,69 +505,48 @@ func (s *HTTPServer) HandleSSHConnection(c *gin.Context) {
 		return nil
 	})
 
-	// 创建SSH会话
-	session, err := s.terminalManager.CreateSession(c.Request.Context(), uint(hostId), uid, uname, uint16(cols), uint16(rows))
-	if err != nil {
-		appLogger.Error("SSH会话创建失败", zap.Error(err), zap.Int("hostId", hostId))
-		conn.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf("连接失败: %s\r\n", err.Error())))
-		return
-	}
-
-	// 确保会话被关闭 - 使用显式调用而不是 defer
-	sessionClosed := false
-	closeSession := func() {
-		if !sessionClosed {
-			sessionClosed = true
-			s.terminalManager.CloseSession(session.ID)
+	var session *TerminalSession
+	if resumeID != "" {
+		// 重连到断开前的会话
+		session, err = s.terminalManager.ResumeSession(resumeID, uint(hostId), uid)
+		if err != nil {
+			conn.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf("重新连接失败: %s\r\n", err.Error())))
+			conn.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseNormalClosure, err.Error()),
+				time.Now().Add(time.Second))
+			return
 		}
-	}
-	defer closeSession()
-
-	appLogger.Info("SSH会话创建成功", zap.String("sessionID", session.ID), zap.Int("hostId", hostId))
+		if err := session.SSHSession.WindowChange(int(rows), int(cols)); err != nil {
+			appLogger.Error("调整窗口大小失败", zap.Error(err))
+		}
+		session.Recorder.Resize(int(cols), int(rows))
+		appLogger.Info("SSH会话重新连接", zap.String("sessionID", session.ID), zap.Int64("since", since))
+	} else {
+		// 创建SSH会话
+		session, err = s.terminalManager.CreateSession(c.Request.Context(), uint(hostId), uid, uname, uint16(cols), uint16(rows))
+		if err != nil {
+			appLogger.Error("SSH会话创建失败", zap.Error(err), zap.Int("hostId", hostId))
+			conn.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf("连接失败: %s\r\n", err.Error())))
+			return
+		}
+		appLogger.Info("SSH会话创建成功", zap.String("sessionID", session.ID), zap.Int("hostId", hostId))
 
-	// 启动goroutine从SSH读取输出并发送到WebSocket
-	var wg sync.WaitGroup
-	wg.Add(2)
+		session.Guard = newCommandGuard(s.terminalManager, session)
+		// 启动goroutine从SSH读取输出并发送到WebSocket，SSH会话结束时关闭会话
+		go s.terminalManager.pumpOutput(session)
+	}
 
-	// 读取stdout
-	go func() {
-		defer wg.Done()
-		buf := make([]byte, 1024)
-		for {
-			n, err := session.StdoutPipe.Read(buf)
-			if n > 0 {
-				// 录制输出
-				if session.Recorder != nil {
-					session.Recorder.RecordOutput(buf[:n])
-				}
-				// 使用二进制消息以保留原始字节（包括CR/LF控制字符）
-				conn.WriteMessage(websocket.BinaryMessage, buf[:n])
-			}
-			if err != nil {
-				return
-			}
-		}
-	}()
-
-	// 读取stderr
-	go func() {
-		defer wg.Done()
-		buf := make([]byte, 1024)
-		for {
-			n, err := session.StderrPipe.Read(buf)
-			if n > 0 {
-				// 录制输出
-				if session.Recorder != nil {
-					session.Recorder.RecordOutput(buf[:n])
-				}
-				// 使用二进制消息以保留原始字节（包括CR/LF控制字符）
-				conn.WriteMessage(websocket.BinaryMessage, buf[:n])
-			}
-			if err != nil {
-				return
-			}
+	if err := session.attach(conn, since); err != nil {
+		appLogger.Info("WebSocket连接关闭", zap.String("sessionID", session.ID), zap.Error(err))
+		if session.detached() {
+			session.waitReconnect()
 		}
-	}()
+		return
+	}
+	if resumeID != "" {
+		session.notice(32, "[已重新连接]")
+	}
+	guard := session.Guard
 
 	// 处理来自WebSocket的消息并发送到SSH
 	for {
@@ -467,12 +556,16 @@ func (s *HTTPServer) HandleSSHConnection(c *gin.Context) {
 		messageType, data, err := conn.ReadMessage()
 		if err != nil {
 			appLogger.Info("WebSocket连接关闭", zap.String("sessionID", session.ID), zap.Error(err))
-			// 立即关闭SSH连接，让所有阻塞的Read操作返回
-			if session.SSHSession != nil {
-				session.SSHSession.Close()
+			if !session.detach(conn) {
+				// 已被新的连接接管
+				break
 			}
-			if session.SSHClient != nil {
-				session.SSHClient.Close()
+			if websocket.IsCloseError(err, websocket.CloseNormalClosure) || session.terminated() != nil {
+				// 用户主动关闭终端，立即断开SSH连接
+				session.closeSSH()
+			} else {
+				// 网络中断等意外断开，保留会话等待重连
+				session.waitReconnect()
 			}
 			break
 		}
@@ -489,27 +582,17 @@ func (s *HTTPServer) HandleSSHConnection(c *gin.Context) {
 						if err := session.SSHSession.WindowChange(int(rows), int(cols)); err != nil {
 							appLogger.Error("调整窗口大小失败", zap.Error(err))
 						}
+						session.Recorder.Resize(int(cols), int(rows))
 						continue
 					}
 				}
 			}
-			// 如果不是resize命令，当作普通输入发送到SSH
-			// 录制输入
-			if session.Recorder != nil {
-				session.Recorder.RecordInput(data)
-			}
-			session.StdinPipe.Write(data)
+			// 如果不是resize命令，当作普通输入经命令过滤后发送到SSH
+			guard.Input(data)
 		} else if messageType == websocket.BinaryMessage {
-			// 录制输入
-			if session.Recorder != nil {
-				session.Recorder.RecordInput(data)
-			}
-			session.StdinPipe.Write(data)
+			guard.Input(data)
 		}
 	}
-
-	wg.Wait()
-	appLogger.Info("终端会话结束", zap.String("sessionID", session.ID))
 }
 
 // ResizeTerminal 调整终端大小
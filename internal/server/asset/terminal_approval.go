@@ -0,0 +1,180 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package asset
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	assetbiz "github.com/ydcloud-dy/opshub/internal/biz/asset"
+	rbacService "github.com/ydcloud-dy/opshub/internal/service/rbac"
+	"github.com/ydcloud-dy/opshub/pkg/response"
+	"gorm.io/gorm"
+)
+
+// TerminalApprovalRequest 终端命令审批请求
+type TerminalApprovalRequest struct {
+	Comment string `json:"comment" binding:"max=500"`
+}
+
+// ListTerminalApprovals 获取终端命令审批列表
+// @Summary 获取终端命令审批列表
+// @Description 分页获取交互式终端中命中审批规则的命令
+// @Tags 终端审计
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param page query int false "页码" default(1)
+// @Param pageSize query int false "每页数量" default(10)
+// @Param status query string false "状态 pending/approved/rejected/timeout/cancelled"
+// @Param username query string false "申请用户名"
+// @Success 200 {object} response.Response "获取成功"
+// @Router /api/v1/terminal-approvals [get]
+func (h *TerminalAuditHandler) ListTerminalApprovals(c *gin.Context) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	pageSize, err := strconv.Atoi(c.DefaultQuery("pageSize", "10"))
+	if err != nil || pageSize < 1 || pageSize > 100 {
+		pageSize = 10
+	}
+
+	query := h.db.Model(&assetbiz.TerminalCommandApproval{})
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if username := c.Query("username"); username != "" {
+		query = query.Where("username LIKE ?", "%"+username+"%")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "查询失败")
+		return
+	}
+
+	var approvals []*assetbiz.TerminalCommandApproval
+	if err := query.Order("id DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&approvals).Error; err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "查询失败")
+		return
+	}
+
+	response.Success(c, gin.H{
+		"total": total,
+		"list":  approvals,
+	})
+}
+
+// ApproveTerminalCommand 审批通过终端命令
+// @Summary 审批通过终端命令
+// @Description 审批通过后命令在等待中的终端会话里继续执行，申请人不能审批自己的命令
+// @Tags 终端审计
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "审批单ID"
+// @Param body body TerminalApprovalRequest false "审批意见"
+// @Success 200 {object} response.Response "审批成功"
+// @Router /api/v1/terminal-approvals/{id}/approve [post]
+func (h *TerminalAuditHandler) ApproveTerminalCommand(c *gin.Context) {
+	h.decideTerminalApproval(c, assetbiz.TerminalApprovalApproved)
+}
+
+// RejectTerminalCommand 驳回终端命令
+// @Summary 驳回终端命令
+// @Description 驳回后终端会话放弃该命令
+// @Tags 终端审计
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "审批单ID"
+// @Param body body TerminalApprovalRequest false "审批意见"
+// @Success 200 {object} response.Response "驳回成功"
+// @Router /api/v1/terminal-approvals/{id}/reject [post]
+func (h *TerminalAuditHandler) RejectTerminalCommand(c *gin.Context) {
+	h.decideTerminalApproval(c, assetbiz.TerminalApprovalRejected)
+}
+
+// decideTerminalApproval 审批终端命令，仅管理员可审批
+func (h *TerminalAuditHandler) decideTerminalApproval(c *gin.Context, status string) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "无效的审批单ID")
+		return
+	}
+	var req TerminalApprovalRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.ErrorCode(c, http.StatusBadRequest, "参数错误: "+err.Error())
+			return
+		}
+	}
+
+	var approval assetbiz.TerminalCommandApproval
+	if err := h.db.First(&approval, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			response.ErrorCode(c, http.StatusNotFound, "审批单不存在")
+			return
+		}
+		response.ErrorCode(c, http.StatusInternalServerError, "查询失败")
+		return
+	}
+	if approval.Status != assetbiz.TerminalApprovalPending {
+		response.ErrorCode(c, http.StatusConflict, "审批单已处理")
+		return
+	}
+
+	userID := rbacService.GetUserID(c)
+	if userID == approval.UserID {
+		response.ErrorCode(c, http.StatusForbidden, "不能审批自己提交的命令")
+		return
+	}
+	isAdmin, err := h.isAdmin(userID)
+	if err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "查询用户角色失败")
+		return
+	}
+	if !isAdmin {
+		response.ErrorCode(c, http.StatusForbidden, "只有管理员可以审批终端命令")
+		return
+	}
+
+	if !h.terminalManager.resolveApproval(approval.ID, terminalApprovalDecision{
+		status:       status,
+		approverID:   userID,
+		approverName: rbacService.GetUsername(c),
+		comment:      req.Comment,
+	}) {
+		response.ErrorCode(c, http.StatusConflict, "审批单已处理或终端会话已结束")
+		return
+	}
+
+	if status == assetbiz.TerminalApprovalApproved {
+		response.SuccessWithMessage(c, "审批通过，命令已在终端中执行", nil)
+		return
+	}
+	response.SuccessWithMessage(c, "已驳回", nil)
+}
@@ -20,25 +20,43 @@
 package asset
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
-	"os"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	assetbiz "github.com/ydcloud-dy/opshub/internal/biz/asset"
+	appLogger "github.com/ydcloud-dy/opshub/pkg/logger"
+	"github.com/ydcloud-dy/opshub/pkg/recording"
 	"github.com/ydcloud-dy/opshub/pkg/response"
+	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
 
 // TerminalAuditHandler 终端审计处理器
 type TerminalAuditHandler struct {
-	db *gorm.DB
+	db              *gorm.DB
+	terminalManager *TerminalManager
 }
 
 // NewTerminalAuditHandler 创建终端审计处理器
-func NewTerminalAuditHandler(db *gorm.DB) *TerminalAuditHandler {
-	return &TerminalAuditHandler{db: db}
+func NewTerminalAuditHandler(db *gorm.DB, terminalManager *TerminalManager) *TerminalAuditHandler {
+	return &TerminalAuditHandler{db: db, terminalManager: terminalManager}
+}
+
+// isAdmin 判断用户是否拥有管理员角色
+func (h *TerminalAuditHandler) isAdmin(userID uint) (bool, error) {
+	var count int64
+	if err := h.db.Table("sys_user_role AS ur").
+		Joins("JOIN sys_role AS r ON ur.role_id = r.id").
+		Where("ur.user_id = ? AND r.code = ?", userID, "admin").
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
 }
 
 // ListTerminalSessions 获取终端会话列表
@@ -113,6 +131,9 @@ func (h *TerminalAuditHandler) ListTerminalSessions(c *gin.Context) {
 			StatusText:    getStatusText(session.Status),
 			CreatedAt:     session.CreatedAt,
 			CreatedAtText: session.CreatedAt.Format("2006-01-02 15:04:05"),
+
+			TerminatedByName: session.TerminatedByName,
+			TerminateReason:  session.TerminateReason,
 		}
 		list = append(list, info)
 	}
@@ -133,6 +154,7 @@ func (h *TerminalAuditHandler) ListTerminalSessions(c *gin.Context) {
 // @Param id path int true "会话ID"
 // @Success 200 {string} string "录制文件内容"
 // @Failure 404 {object} response.Response "会话不存在"
+// @Failure 409 {object} response.Response "录制文件校验失败"
 // @Router /api/v1/terminal-sessions/{id}/play [get]
 func (h *TerminalAuditHandler) PlayTerminalSession(c *gin.Context) {
 	idStr := c.Param("id")
@@ -153,9 +175,13 @@ func (h *TerminalAuditHandler) PlayTerminalSession(c *gin.Context) {
 		return
 	}
 
-	// 读取录制文件
-	content, err := os.ReadFile(session.RecordingPath)
+	// 读取录制文件并校验完整性
+	content, err := recording.ReadAll(c.Request.Context(), session.Recording())
 	if err != nil {
+		if errors.Is(err, recording.ErrChecksumMismatch) {
+			response.ErrorCode(c, http.StatusConflict, err.Error())
+			return
+		}
 		response.ErrorCode(c, http.StatusInternalServerError, "读取录制文件失败")
 		return
 	}
@@ -195,12 +221,7 @@ func (h *TerminalAuditHandler) DeleteTerminalSession(c *gin.Context) {
 		return
 	}
 
-	// 删除录制文件
-	// 即使文件删除失败，仍然继续删除数据库记录
-	_ = os.Remove(session.RecordingPath)
-
-	// 删除数据库记录
-	if err := h.db.Delete(&session).Error; err != nil {
+	if err := deleteTerminalSession(c.Request.Context(), h.db, &session); err != nil {
 		response.ErrorCode(c, http.StatusInternalServerError, "删除失败")
 		return
 	}
@@ -208,6 +229,166 @@ func (h *TerminalAuditHandler) DeleteTerminalSession(c *gin.Context) {
 	response.SuccessWithMessage(c, "删除成功", nil)
 }
 
+// deleteTerminalSession 删除会话的录制文件、数据库记录及会话中的命令
+func deleteTerminalSession(ctx context.Context, db *gorm.DB, session *assetbiz.TerminalSession) error {
+	// 即使文件删除失败，仍然继续删除数据库记录
+	if err := recording.Remove(ctx, session.Recording()); err != nil {
+		appLogger.Warn("删除录制文件失败",
+			zap.Uint("sessionID", session.ID),
+			zap.String("recordingPath", session.RecordingPath),
+			zap.Error(err))
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("session_id = ?", session.ID).Delete(&assetbiz.TerminalCommand{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(session).Error
+	})
+}
+
+// purgeTerminalSessions 按录制保留策略清理过期的终端会话
+func purgeTerminalSessions(db *gorm.DB) recording.PurgeFunc {
+	return func(ctx context.Context, cutoff time.Time) (int, error) {
+		var sessions []assetbiz.TerminalSession
+		if err := db.Where("created_at < ?", cutoff).Find(&sessions).Error; err != nil {
+			return 0, err
+		}
+		for i := range sessions {
+			if err := deleteTerminalSession(ctx, db, &sessions[i]); err != nil {
+				return i, err
+			}
+		}
+		return len(sessions), nil
+	}
+}
+
+// SearchTerminalCommands 检索终端命令
+// @Summary 检索终端命令
+// @Description 按命令内容、主机、用户和时间范围检索终端会话中执行过的命令，返回的会话ID和偏移时间可用于跳转回放
+// @Tags 终端审计
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param page query int false "页码" default(1)
+// @Param pageSize query int false "每页数量" default(10)
+// @Param command query string false "命令关键字"
+// @Param host query string false "主机名或IP"
+// @Param hostId query int false "主机ID"
+// @Param username query string false "用户名"
+// @Param sessionId query int false "会话ID"
+// @Param action query string false "过滤结果 allow/deny/approved/rejected/timeout/cancelled"
+// @Param startTime query string false "开始时间，格式 2006-01-02 15:04:05 或 2006-01-02"
+// @Param endTime query string false "结束时间，格式 2006-01-02 15:04:05 或 2006-01-02"
+// @Success 200 {object} response.Response "获取成功"
+// @Router /api/v1/terminal-commands [get]
+func (h *TerminalAuditHandler) SearchTerminalCommands(c *gin.Context) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	pageSize, err := strconv.Atoi(c.DefaultQuery("pageSize", "10"))
+	if err != nil || pageSize < 1 || pageSize > 100 {
+		pageSize = 10
+	}
+
+	query := h.db.Model(&assetbiz.TerminalCommand{})
+	if command := c.Query("command"); command != "" {
+		query = query.Where("command LIKE ?", "%"+command+"%")
+	}
+	if host := c.Query("host"); host != "" {
+		query = query.Where("host_name LIKE ? OR host_ip LIKE ?", "%"+host+"%", "%"+host+"%")
+	}
+	if hostID := c.Query("hostId"); hostID != "" {
+		query = query.Where("host_id = ?", hostID)
+	}
+	if username := c.Query("username"); username != "" {
+		query = query.Where("username LIKE ?", "%"+username+"%")
+	}
+	if sessionID := c.Query("sessionId"); sessionID != "" {
+		query = query.Where("session_id = ?", sessionID)
+	}
+	if action := c.Query("action"); action != "" {
+		query = query.Where("action = ?", action)
+	}
+	if startTime := c.Query("startTime"); startTime != "" {
+		t, err := parseQueryTime(startTime, false)
+		if err != nil {
+			response.ErrorCode(c, http.StatusBadRequest, "无效的开始时间")
+			return
+		}
+		query = query.Where("executed_at >= ?", t)
+	}
+	if endTime := c.Query("endTime"); endTime != "" {
+		t, err := parseQueryTime(endTime, true)
+		if err != nil {
+			response.ErrorCode(c, http.StatusBadRequest, "无效的结束时间")
+			return
+		}
+		query = query.Where("executed_at <= ?", t)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "查询失败")
+		return
+	}
+
+	var commands []*assetbiz.TerminalCommand
+	if err := query.Order("executed_at DESC, id DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&commands).Error; err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "查询失败")
+		return
+	}
+
+	response.Success(c, gin.H{
+		"total": total,
+		"list":  commands,
+	})
+}
+
+// ListSessionCommands 获取终端会话的命令列表
+// @Summary 获取会话命令列表
+// @Description 按执行顺序返回终端会话中还原出的命令，用于回放时按命令跳转
+// @Tags 终端审计
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "会话ID"
+// @Success 200 {object} response.Response "获取成功"
+// @Router /api/v1/terminal-sessions/{id}/commands [get]
+func (h *TerminalAuditHandler) ListSessionCommands(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "无效的会话ID")
+		return
+	}
+
+	var commands []*assetbiz.TerminalCommand
+	if err := h.db.Where("session_id = ?", id).Order("offset_seconds ASC, id ASC").Find(&commands).Error; err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "查询失败")
+		return
+	}
+	response.Success(c, commands)
+}
+
+// parseQueryTime 解析查询时间，只有日期时 endOfDay 为 true 取当天结束时间
+func parseQueryTime(value string, endOfDay bool) (time.Time, error) {
+	if t, err := time.ParseInLocation("2006-01-02 15:04:05", value, time.Local); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return t, err
+	}
+	if endOfDay {
+		t = t.Add(24*time.Hour - time.Second)
+	}
+	return t, nil
+}
+
 // formatDuration 格式化时长
 func formatDuration(seconds int) string {
 	if seconds < 60 {
@@ -243,6 +424,7 @@ func getStatusText(status string) string {
 		"recording":  "录制中",
 		"completed":  "已完成",
 		"failed":     "失败",
+		"terminated": "已终止",
 	}
 	if text, ok := statusMap[status]; ok {
 		return text
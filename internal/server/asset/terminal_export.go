@@ -0,0 +1,614 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package asset
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+	assetbiz "github.com/ydcloud-dy/opshub/internal/biz/asset"
+	rbacService "github.com/ydcloud-dy/opshub/internal/service/rbac"
+	"github.com/ydcloud-dy/opshub/pkg/recording"
+	"github.com/ydcloud-dy/opshub/pkg/response"
+	k8smodel "github.com/ydcloud-dy/opshub/plugins/kubernetes/model"
+	"gorm.io/gorm"
+)
+
+// 终端会话导出格式
+const (
+	exportFormatText  = "text"  // 纯文本记录
+	exportFormatHTML  = "html"  // 自包含的HTML回放页面
+	exportFormatInput = "input" // 仅包含输入事件的JSON
+)
+
+// altScreenPlaceholder 全屏程序的输出无法还原为文本，在记录中以一行提示代替
+const altScreenPlaceholder = "[全屏程序输出已省略]"
+
+// terminalExport 导出文件中的会话信息
+type terminalExport struct {
+	Filename  string    `json:"-"` // 下载文件名（不含扩展名）
+	Title     string    `json:"title"`
+	Username  string    `json:"username"`
+	StartedAt time.Time `json:"startedAt"`
+	Duration  int       `json:"duration"` // 秒
+}
+
+// castHeader asciinema录制头部，主机录制的时间戳为整数，Pod录制为浮点数
+type castHeader struct {
+	Version   int     `json:"version"`
+	Width     int     `json:"width"`
+	Height    int     `json:"height"`
+	Timestamp float64 `json:"timestamp"`
+}
+
+// parseCast 解析asciinema v2录制文件，跳过无法解析的事件行
+func parseCast(data []byte) (castHeader, []AsciinemaEvent, error) {
+	var header castHeader
+	lines := bytes.Split(data, []byte{'\n'})
+	if err := json.Unmarshal(lines[0], &header); err != nil || header.Version != 2 {
+		return header, nil, fmt.Errorf("录制文件格式错误")
+	}
+
+	events := make([]AsciinemaEvent, 0, len(lines)-1)
+	for _, line := range lines[1:] {
+		var raw []interface{}
+		if len(bytes.TrimSpace(line)) == 0 || json.Unmarshal(line, &raw) != nil || len(raw) != 3 {
+			continue
+		}
+		t, ok1 := raw[0].(float64)
+		typ, ok2 := raw[1].(string)
+		text, ok3 := raw[2].(string)
+		if ok1 && ok2 && ok3 {
+			events = append(events, AsciinemaEvent{Time: t, Type: typ, Data: text})
+		}
+	}
+	return header, events, nil
+}
+
+// transcriptLine 文本记录中的一行
+type transcriptLine struct {
+	Time float64 `json:"t"` // 该行开始输出的时间（秒）
+	Text string  `json:"s"`
+}
+
+// transcriptFrame 回放帧：到 Time 为止已完成 Lines 行，光标所在行内容为 Current
+type transcriptFrame struct {
+	Time    float64 `json:"t"`
+	Lines   int     `json:"n"`
+	Current string  `json:"c"`
+}
+
+// transcriptBuilder 将终端输出还原为纯文本行：去除ANSI控制序列，处理回车、退格和行内擦除，
+// 全屏程序（vim、top 等）运行期间的输出只保留一行提示
+type transcriptBuilder struct {
+	lines     []transcriptLine
+	frames    []transcriptFrame
+	line      []rune // 宽字符后跟一个 0 占位
+	cursor    int
+	lineTime  float64
+	started   bool // 当前行已有输出
+	altScreen bool
+	escape    []rune
+}
+
+// Output 处理一个输出事件
+func (b *transcriptBuilder) Output(t float64, data string) {
+	for _, r := range data {
+		if len(b.escape) > 0 {
+			b.escape = append(b.escape, r)
+			if escapeComplete(b.escape) {
+				b.handleEscape(t)
+				b.escape = b.escape[:0]
+			}
+			continue
+		}
+		if r == 0x1b {
+			b.escape = append(b.escape, r)
+			continue
+		}
+		if b.altScreen {
+			continue
+		}
+		switch r {
+		case '\r':
+			b.cursor = 0
+		case '\n':
+			b.newline()
+		case '\b':
+			if b.cursor > 0 {
+				b.cursor--
+			}
+		case '\t':
+			b.cursor = (b.cursor/8 + 1) * 8
+		default:
+			if !unicode.IsControl(r) {
+				b.put(t, r)
+			}
+		}
+	}
+
+	frame := transcriptFrame{Time: t, Lines: len(b.lines), Current: b.current()}
+	if n := len(b.frames); n > 0 && b.frames[n-1].Lines == frame.Lines && b.frames[n-1].Current == frame.Current {
+		return
+	}
+	b.frames = append(b.frames, frame)
+}
+
+// Lines 返回全部文本行，包括未换行的最后一行
+func (b *transcriptBuilder) Lines() []transcriptLine {
+	if b.started {
+		return append(b.lines, transcriptLine{Time: b.lineTime, Text: b.current()})
+	}
+	return b.lines
+}
+
+func (b *transcriptBuilder) put(t float64, r rune) {
+	if !b.started {
+		b.started = true
+		b.lineTime = t
+	}
+	cells := []rune{r}
+	if isWideRune(r) {
+		cells = append(cells, 0)
+	}
+	for _, cell := range cells {
+		for len(b.line) < b.cursor {
+			b.line = append(b.line, ' ')
+		}
+		if b.cursor < len(b.line) {
+			b.line[b.cursor] = cell
+		} else {
+			b.line = append(b.line, cell)
+		}
+		b.cursor++
+	}
+}
+
+func (b *transcriptBuilder) current() string {
+	return strings.TrimRight(strings.ReplaceAll(string(b.line), "\x00", ""), " ")
+}
+
+func (b *transcriptBuilder) newline() {
+	b.lines = append(b.lines, transcriptLine{Time: b.lineTime, Text: b.current()})
+	b.line = b.line[:0]
+	b.cursor = 0
+	b.started = false
+}
+
+// handleEscape 处理影响当前行内容的CSI控制序列，其余控制序列（颜色等）直接丢弃
+func (b *transcriptBuilder) handleEscape(t float64) {
+	if b.escape[1] != '[' {
+		return
+	}
+	params := string(b.escape[2 : len(b.escape)-1])
+	final := b.escape[len(b.escape)-1]
+
+	if strings.HasPrefix(params, "?") {
+		if final != 'h' && final != 'l' {
+			return
+		}
+		for _, mode := range strings.Split(params[1:], ";") {
+			if mode != "1049" && mode != "47" && mode != "1047" {
+				continue
+			}
+			if final == 'h' && !b.altScreen {
+				if b.started {
+					b.newline()
+				}
+				b.lines = append(b.lines, transcriptLine{Time: t, Text: altScreenPlaceholder})
+			}
+			b.altScreen = final == 'h'
+			b.line = b.line[:0]
+			b.cursor = 0
+			b.started = false
+		}
+		return
+	}
+	if b.altScreen {
+		return
+	}
+
+	n, err := strconv.Atoi(strings.SplitN(params, ";", 2)[0])
+	if err != nil {
+		n = 0
+	}
+	count := max(n, 1)
+	switch final {
+	case 'K':
+		switch n {
+		case 0:
+			if b.cursor < len(b.line) {
+				b.line = b.line[:b.cursor]
+			}
+		case 1:
+			for i := 0; i <= b.cursor && i < len(b.line); i++ {
+				b.line[i] = ' '
+			}
+		case 2:
+			b.line = b.line[:0]
+		}
+	case 'C':
+		b.cursor += count
+	case 'D':
+		b.cursor = max(b.cursor-count, 0)
+	case 'G':
+		b.cursor = count - 1
+	case 'P':
+		if b.cursor < len(b.line) {
+			end := min(b.cursor+count, len(b.line))
+			b.line = append(b.line[:b.cursor], b.line[end:]...)
+		}
+	case 'X':
+		for i := b.cursor; i < b.cursor+count && i < len(b.line); i++ {
+			b.line[i] = ' '
+		}
+	case '@':
+		if b.cursor < len(b.line) {
+			blanks := []rune(strings.Repeat(" ", count))
+			b.line = append(b.line[:b.cursor], append(blanks, b.line[b.cursor:]...)...)
+		}
+	}
+}
+
+// buildTranscript 将录制的输出事件还原为文本行和回放帧
+func buildTranscript(events []AsciinemaEvent) *transcriptBuilder {
+	b := &transcriptBuilder{}
+	for _, event := range events {
+		if event.Type == "o" {
+			b.Output(event.Time, event.Data)
+		}
+	}
+	return b
+}
+
+// renderTextTranscript 生成带时间戳的纯文本记录
+func renderTextTranscript(meta terminalExport, events []AsciinemaEvent) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "会话: %s\n", meta.Title)
+	fmt.Fprintf(&buf, "用户: %s\n", meta.Username)
+	fmt.Fprintf(&buf, "开始时间: %s\n", meta.StartedAt.Format("2006-01-02 15:04:05"))
+	fmt.Fprintf(&buf, "时长: %s\n", formatDuration(meta.Duration))
+	buf.WriteString(strings.Repeat("-", 60) + "\n")
+
+	for _, line := range buildTranscript(events).Lines() {
+		at := meta.StartedAt.Add(time.Duration(line.Time * float64(time.Second)))
+		fmt.Fprintf(&buf, "[%s] %s\n", at.Format("2006-01-02 15:04:05"), line.Text)
+	}
+	return buf.Bytes()
+}
+
+// inputExportEvent 输入事件
+type inputExportEvent struct {
+	Time float64   `json:"time"` // 相对录制开始的时间（秒）
+	At   time.Time `json:"at"`
+	Data string    `json:"data"`
+}
+
+// renderInputEvents 生成仅包含输入事件的JSON
+func renderInputEvents(meta terminalExport, events []AsciinemaEvent) ([]byte, error) {
+	inputs := make([]inputExportEvent, 0)
+	for _, event := range events {
+		if event.Type != "i" {
+			continue
+		}
+		inputs = append(inputs, inputExportEvent{
+			Time: event.Time,
+			At:   meta.StartedAt.Add(time.Duration(event.Time * float64(time.Second))),
+			Data: event.Data,
+		})
+	}
+	return json.MarshalIndent(struct {
+		Session terminalExport     `json:"session"`
+		Events  []inputExportEvent `json:"events"`
+	}{meta, inputs}, "", "  ")
+}
+
+// renderHTMLReplay 生成自包含的HTML回放页面，不依赖外部脚本和样式
+func renderHTMLReplay(meta terminalExport, events []AsciinemaEvent) ([]byte, error) {
+	transcript := buildTranscript(events)
+	var buf bytes.Buffer
+	err := replayTemplate.Execute(&buf, gin.H{
+		"Meta":      meta,
+		"StartedAt": meta.StartedAt.Format("2006-01-02 15:04:05"),
+		"Duration":  formatDuration(meta.Duration),
+		"Data": gin.H{
+			"lines":  transcript.Lines(),
+			"frames": transcript.frames,
+		},
+	})
+	return buf.Bytes(), err
+}
+
+var replayTemplate = template.Must(template.New("replay").Parse(`<!DOCTYPE html>
+<html lang="zh-CN">
+<head>
+<meta charset="utf-8">
+<title>{{.Meta.Title}} - 终端会话回放</title>
+<style>
+body{margin:0;font-family:-apple-system,"Segoe UI","PingFang SC","Microsoft YaHei",sans-serif;background:#f5f7fa;color:#303133}
+header{padding:16px 24px;background:#fff;border-bottom:1px solid #e4e7ed}
+header h1{margin:0 0 8px;font-size:18px}
+header span{margin-right:24px;font-size:13px;color:#606266}
+.controls{display:flex;align-items:center;gap:12px;padding:12px 24px;background:#fff;border-bottom:1px solid #e4e7ed;font-size:13px}
+.controls button{min-width:64px;padding:4px 12px;border:1px solid #409eff;border-radius:4px;background:#409eff;color:#fff;cursor:pointer}
+.controls input[type=range]{flex:1}
+#screen{margin:16px 24px;padding:12px;height:calc(100vh - 190px);overflow:auto;background:#1e1e1e;color:#d4d4d4;border-radius:4px;
+font:13px/1.4 Menlo,Consolas,"DejaVu Sans Mono",monospace;white-space:pre-wrap;word-break:break-all}
+</style>
+</head>
+<body>
+<header>
+<h1>{{.Meta.Title}}</h1>
+<span>用户：{{.Meta.Username}}</span><span>开始时间：{{.StartedAt}}</span><span>时长：{{.Duration}}</span>
+</header>
+<div class="controls">
+<button id="play">播放</button>
+<input id="progress" type="range" min="0" max="0" step="0.01" value="0">
+<span id="time">00:00 / 00:00</span>
+<label>速度 <select id="speed"><option value="1">1x</option><option value="2">2x</option><option value="4">4x</option><option value="8">8x</option></select></label>
+<label><input id="idle" type="checkbox" checked> 跳过空闲（超过2秒）</label>
+</div>
+<div id="screen"><span id="done"></span><span id="current"></span></div>
+<script>
+(function () {
+  var data = {{.Data}};
+  var lines = data.lines || [], frames = data.frames || [];
+  var screen = document.getElementById('screen'), done = document.getElementById('done'), current = document.getElementById('current');
+  var playBtn = document.getElementById('play'), progress = document.getElementById('progress');
+  var timeEl = document.getElementById('time'), speedEl = document.getElementById('speed'), idleEl = document.getElementById('idle');
+  var timeline = [], total = 0, pos = 0, shown = 0, playing = false, last = 0;
+
+  function buildTimeline() {
+    var prev = 0, acc = 0;
+    timeline = frames.map(function (f) {
+      var gap = f.t - prev;
+      if (idleEl.checked && gap > 2) gap = 2;
+      prev = f.t;
+      acc += gap;
+      return acc;
+    });
+    total = timeline.length ? timeline[timeline.length - 1] : 0;
+    progress.max = total;
+  }
+  function frameAt(p) {
+    var lo = 0, hi = timeline.length - 1, idx = -1;
+    while (lo <= hi) {
+      var mid = (lo + hi) >> 1;
+      if (timeline[mid] <= p) { idx = mid; lo = mid + 1; } else { hi = mid - 1; }
+    }
+    return idx;
+  }
+  function fmt(s) {
+    s = Math.floor(s);
+    return String(Math.floor(s / 60)).padStart(2, '0') + ':' + String(s % 60).padStart(2, '0');
+  }
+  function render() {
+    var i = frameAt(pos), n = i >= 0 ? frames[i].n : 0;
+    if (n < shown) { done.textContent = ''; shown = 0; }
+    if (n > shown) {
+      done.appendChild(document.createTextNode(lines.slice(shown, n).map(function (l) { return l.s + '\n'; }).join('')));
+      shown = n;
+    }
+    current.textContent = i >= 0 ? frames[i].c : '';
+    screen.scrollTop = screen.scrollHeight;
+    progress.value = pos;
+    timeEl.textContent = fmt(pos) + ' / ' + fmt(total);
+  }
+  function tick(now) {
+    if (!playing) return;
+    pos += (now - last) / 1000 * Number(speedEl.value);
+    last = now;
+    if (pos >= total) { pos = total; playing = false; playBtn.textContent = '重播'; }
+    render();
+    if (playing) requestAnimationFrame(tick);
+  }
+  playBtn.onclick = function () {
+    if (playing) { playing = false; playBtn.textContent = '播放'; return; }
+    if (pos >= total) pos = 0;
+    playing = true;
+    playBtn.textContent = '暂停';
+    last = performance.now();
+    requestAnimationFrame(tick);
+  };
+  progress.oninput = function () { pos = Number(progress.value); render(); };
+  idleEl.onchange = function () {
+    var i = frameAt(pos);
+    buildTimeline();
+    pos = i >= 0 ? timeline[i] : 0;
+    render();
+  };
+  buildTimeline();
+  render();
+})();
+</script>
+</body>
+</html>
+`))
+
+// exportRecording 读取录制文件并按格式导出为下载文件
+func exportRecording(c *gin.Context, format string, meta terminalExport, object recording.Object) {
+	data, err := recording.ReadAll(c.Request.Context(), object)
+	if err != nil {
+		if errors.Is(err, recording.ErrChecksumMismatch) {
+			response.ErrorCode(c, http.StatusConflict, err.Error())
+			return
+		}
+		response.ErrorCode(c, http.StatusInternalServerError, "读取录制文件失败")
+		return
+	}
+	header, events, err := parseCast(data)
+	if err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if header.Timestamp > 0 {
+		meta.StartedAt = time.Unix(0, int64(header.Timestamp*float64(time.Second)))
+	}
+
+	var (
+		content     []byte
+		contentType string
+		ext         string
+	)
+	switch format {
+	case exportFormatText:
+		content, contentType, ext = renderTextTranscript(meta, events), "text/plain; charset=utf-8", "txt"
+	case exportFormatHTML:
+		content, err = renderHTMLReplay(meta, events)
+		contentType, ext = "text/html; charset=utf-8", "html"
+	case exportFormatInput:
+		content, err = renderInputEvents(meta, events)
+		contentType, ext = "application/json; charset=utf-8", "json"
+	}
+	if err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "导出失败")
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s.%s", meta.Filename, ext))
+	c.Data(http.StatusOK, contentType, content)
+}
+
+// parseExportFormat 校验导出格式，默认导出纯文本记录
+func parseExportFormat(c *gin.Context) (string, bool) {
+	format := c.DefaultQuery("format", exportFormatText)
+	switch format {
+	case exportFormatText, exportFormatHTML, exportFormatInput:
+		return format, true
+	}
+	response.ErrorCode(c, http.StatusBadRequest, "不支持的导出格式，可选 text/html/input")
+	return "", false
+}
+
+// ExportTerminalSession 导出主机终端会话
+// @Summary 导出主机终端会话
+// @Description 将终端会话录制导出为带时间戳的纯文本记录（去除ANSI控制序列）、自包含的HTML回放页面或仅包含输入事件的JSON，无需安装asciinema播放器
+// @Tags 终端审计
+// @Produce plain
+// @Produce html
+// @Produce json
+// @Security Bearer
+// @Param id path int true "会话ID"
+// @Param format query string false "导出格式 text/html/input" default(text)
+// @Success 200 {string} string "导出文件"
+// @Failure 400 {object} response.Response "参数错误"
+// @Failure 404 {object} response.Response "会话不存在"
+// @Failure 409 {object} response.Response "录制文件校验失败"
+// @Router /api/v1/terminal-sessions/{id}/export [get]
+func (h *TerminalAuditHandler) ExportTerminalSession(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "无效的会话ID")
+		return
+	}
+	format, ok := parseExportFormat(c)
+	if !ok {
+		return
+	}
+
+	var session assetbiz.TerminalSession
+	if err := h.db.First(&session, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			response.ErrorCode(c, http.StatusNotFound, "会话不存在")
+		} else {
+			response.ErrorCode(c, http.StatusInternalServerError, "查询失败")
+		}
+		return
+	}
+
+	exportRecording(c, format, terminalExport{
+		Filename:  fmt.Sprintf("terminal-%d", session.ID),
+		Title:     fmt.Sprintf("主机 %s (%s)", session.HostName, session.HostIP),
+		Username:  session.Username,
+		StartedAt: session.CreatedAt.Add(-time.Duration(session.Duration) * time.Second),
+		Duration:  session.Duration,
+	}, session.Recording())
+}
+
+// ExportK8sTerminalSession 导出Kubernetes终端会话
+// @Summary 导出Kubernetes终端会话
+// @Description 导出Pod终端或节点终端的会话录制，格式同主机终端会话导出。仅会话所属用户和管理员可以导出
+// @Tags 终端审计
+// @Produce plain
+// @Produce html
+// @Produce json
+// @Security Bearer
+// @Param id path int true "会话ID"
+// @Param format query string false "导出格式 text/html/input" default(text)
+// @Success 200 {string} string "导出文件"
+// @Failure 400 {object} response.Response "参数错误"
+// @Failure 403 {object} response.Response "无权导出"
+// @Failure 404 {object} response.Response "会话不存在"
+// @Failure 409 {object} response.Response "录制文件校验失败"
+// @Router /api/v1/terminal-sessions/k8s/{id}/export [get]
+func (h *TerminalAuditHandler) ExportK8sTerminalSession(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "无效的会话ID")
+		return
+	}
+	format, ok := parseExportFormat(c)
+	if !ok {
+		return
+	}
+
+	var session k8smodel.TerminalSession
+	if err := h.db.First(&session, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			response.ErrorCode(c, http.StatusNotFound, "会话不存在")
+		} else {
+			response.ErrorCode(c, http.StatusInternalServerError, "查询失败")
+		}
+		return
+	}
+
+	if userID := rbacService.GetUserID(c); userID != session.UserID {
+		isAdmin, err := h.isAdmin(userID)
+		if err != nil {
+			response.ErrorCode(c, http.StatusInternalServerError, "查询用户角色失败")
+			return
+		}
+		if !isAdmin {
+			response.ErrorCode(c, http.StatusForbidden, "只能导出自己的终端会话")
+			return
+		}
+	}
+
+	title := fmt.Sprintf("集群 %s Pod %s/%s 容器 %s", session.ClusterName, session.Namespace, session.PodName, session.ContainerName)
+	if session.SessionType == k8smodel.SessionTypeNode {
+		title = fmt.Sprintf("集群 %s 节点 %s", session.ClusterName, session.NodeName)
+	}
+	exportRecording(c, format, terminalExport{
+		Filename:  fmt.Sprintf("k8s-terminal-%d", session.ID),
+		Title:     title,
+		Username:  session.Username,
+		StartedAt: session.CreatedAt.Add(-time.Duration(session.Duration) * time.Second),
+		Duration:  session.Duration,
+	}, session.Recording())
+}
@@ -0,0 +1,396 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package asset
+
+import (
+	"bytes"
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+
+	assetbiz "github.com/ydcloud-dy/opshub/internal/biz/asset"
+	appLogger "github.com/ydcloud-dy/opshub/pkg/logger"
+	"github.com/ydcloud-dy/opshub/pkg/shellparse"
+	"go.uber.org/zap"
+)
+
+const (
+	// terminalApprovalTimeout 终端命令等待审批的最长时间
+	terminalApprovalTimeout = 5 * time.Minute
+	// echoSettleTimeout 检查命令前等待回显的最长时间
+	echoSettleTimeout = 500 * time.Millisecond
+	// echoIdleInterval 输出空闲超过该时间视为回显完成
+	echoIdleInterval = 30 * time.Millisecond
+)
+
+// bracketedPasteMarkers 括号粘贴模式的起止标记。去除后粘贴内容中的换行按回车逐行检查，
+// 避免多行粘贴在 shell 中合并为一次执行而绕过检查
+var bracketedPasteMarkers = [][]byte{[]byte("\x1b[200~"), []byte("\x1b[201~")}
+
+// terminalApprovalDecision 终端命令的审批结果
+type terminalApprovalDecision struct {
+	status       string
+	approverID   uint
+	approverName string
+	comment      string
+}
+
+// commandGuard 交互式终端的命令过滤器。
+// 用户按下回车时，以回显还原出的当前命令行按命令策略检查，关闭回显或处于备用屏幕时改用输入还原：放行时才将回车发送到远端；
+// 拦截时在终端中提示并发送 Ctrl-C 放弃当前行；需要审批时暂停输入直到审批通过、驳回或超时。
+// 每次决策都记录到终端审计的命令记录中
+type commandGuard struct {
+	tm      *TerminalManager
+	session *TerminalSession
+
+	lastOutput atomic.Int64 // 最近一次远端输出的时间（纳秒）
+
+	mu        sync.Mutex
+	lastInput int64  // 最近一次发送输入的时间（纳秒）
+	entered   bool   // 已发送回车，处理后续输入前需等待回显
+	typed     []byte // 上次回车之后用户输入的内容
+	script    string // 尚未输入完整的多行命令，如未闭合的引号或以管道符结尾的行
+	waiting   *assetbiz.TerminalCommandApproval
+	closed    bool
+}
+
+func newCommandGuard(tm *TerminalManager, session *TerminalSession) *commandGuard {
+	return &commandGuard{tm: tm, session: session}
+}
+
+// Output 记录远端输出时间，用于判断回显是否完成
+func (g *commandGuard) Output() {
+	g.lastOutput.Store(time.Now().UnixNano())
+}
+
+// Input 处理用户输入，回车之前的内容直接发送到远端
+func (g *commandGuard) Input(data []byte) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.closed {
+		return
+	}
+	if g.waiting != nil {
+		// 等待审批期间只接受 Ctrl-C 撤回
+		if bytes.IndexByte(data, 0x03) >= 0 {
+			g.tm.resolveApproval(g.waiting.ID, terminalApprovalDecision{
+				status:  assetbiz.TerminalApprovalCancelled,
+				comment: "用户撤回",
+			})
+		}
+		return
+	}
+
+	for _, marker := range bracketedPasteMarkers {
+		data = bytes.ReplaceAll(data, marker, nil)
+	}
+	for len(data) > 0 {
+		if g.entered {
+			g.settle()
+			g.entered = false
+		}
+		i := bytes.IndexAny(data, "\r\n")
+		if i < 0 {
+			g.sendTyped(data)
+			return
+		}
+		g.sendTyped(data[:i])
+		n := 1
+		if data[i] == '\r' && i+1 < len(data) && data[i+1] == '\n' {
+			n = 2
+		}
+		enter := data[i : i+n]
+		data = data[i+n:]
+		if !g.enter(enter) {
+			// 命令被拦截或等待审批，丢弃之后的输入
+			return
+		}
+	}
+}
+
+// enter 检查当前命令行，放行时发送回车并返回 true
+func (g *commandGuard) enter(enter []byte) bool {
+	g.settle()
+	line := g.session.Recorder.CurrentCommand()
+	typed := g.typed
+	g.typed = nil
+	if strings.TrimSpace(line) == "" && len(typed) > 0 {
+		// 关闭回显（stty -echo）或切换到备用屏幕时无法从回显取得命令，改由输入还原；
+		// 输入中有方向键、Tab 补全等无法还原的编辑时不能确定执行的命令，需要审批
+		var ok bool
+		if line, ok = typedLine(typed); !ok {
+			script := strconv.Quote(string(typed))
+			if g.script != "" {
+				script = g.script + "\n" + script
+			}
+			g.script = ""
+			g.requestApproval(script, "终端未回显输入的命令，无法按命令策略检查")
+			return false
+		}
+	}
+	script := line
+	if g.script != "" {
+		script = g.script + "\n" + script
+	}
+	if strings.TrimSpace(script) == "" {
+		g.sendEnter(enter)
+		return true
+	}
+	if shellparse.Incomplete(script) {
+		// shell 将等待继续输入，输入完整后整体检查
+		g.script = script
+		g.sendEnter(enter)
+		return true
+	}
+	g.script = ""
+
+	result, err := g.tm.commandRules.Check(context.Background(), &assetbiz.CommandCheckRequest{
+		Content: script,
+		UserID:  g.session.UserID,
+		HostIDs: []uint{g.session.HostID},
+	})
+	if err != nil {
+		appLogger.Error("终端命令策略检查失败", zap.String("sessionID", g.session.ID), zap.Error(err))
+		g.block(script, "命令策略检查失败")
+		return false
+	}
+	switch result.Action {
+	case assetbiz.CommandActionDeny:
+		g.block(script, result.Reason)
+		return false
+	case assetbiz.CommandActionApprove:
+		g.requestApproval(script, result.Reason)
+		return false
+	}
+	g.session.Recorder.Decide(assetbiz.TerminalCommandActionAllow, "")
+	g.sendEnter(enter)
+	return true
+}
+
+// settle 等待已发送的输入回显完成，以便从回显中取得完整的命令行
+func (g *commandGuard) settle() {
+	deadline := time.Now().Add(echoSettleTimeout)
+	for time.Now().Before(deadline) {
+		last := g.lastOutput.Load()
+		if last >= g.lastInput && time.Now().UnixNano()-last >= int64(echoIdleInterval) && g.session.Recorder.Settled() {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// send 发送输入到远端并录制
+func (g *commandGuard) send(data []byte) {
+	if len(data) == 0 {
+		return
+	}
+	if bytes.ContainsAny(data, "\x03\x04") {
+		g.script = ""
+	}
+	g.lastInput = time.Now().UnixNano()
+	g.session.Recorder.RecordInput(data)
+	g.session.StdinPipe.Write(data)
+}
+
+// sendTyped 发送用户输入的内容，并记录用于还原未回显的命令行
+func (g *commandGuard) sendTyped(data []byte) {
+	g.typed = append(g.typed, data...)
+	g.send(data)
+}
+
+// typedLine 由输入内容还原命令行，支持退格、Ctrl-U、Ctrl-W 和 Ctrl-C；
+// 含有方向键、Tab 补全等其他控制字符时无法还原，ok 为 false
+func typedLine(input []byte) (line string, ok bool) {
+	var buf []rune
+	for _, r := range string(input) {
+		switch {
+		case r == 0x7f || r == 0x08:
+			if len(buf) > 0 {
+				buf = buf[:len(buf)-1]
+			}
+		case r == 0x15 || r == 0x03:
+			buf = buf[:0]
+		case r == 0x17:
+			for len(buf) > 0 && buf[len(buf)-1] == ' ' {
+				buf = buf[:len(buf)-1]
+			}
+			for len(buf) > 0 && buf[len(buf)-1] != ' ' {
+				buf = buf[:len(buf)-1]
+			}
+		case r < 0x20 || r == utf8.RuneError:
+			return "", false
+		default:
+			buf = append(buf, r)
+		}
+	}
+	return string(buf), true
+}
+
+func (g *commandGuard) sendEnter(enter []byte) {
+	g.send(enter)
+	g.entered = true
+}
+
+// block 拦截命令：提示原因并发送 Ctrl-C 放弃当前命令行
+func (g *commandGuard) block(script, reason string) {
+	g.session.Recorder.RecordDecision(script, assetbiz.TerminalCommandActionDeny, reason)
+	g.session.notice(31, "[命令已拦截] %s", reason)
+	g.send([]byte{0x03})
+}
+
+// requestApproval 提交命令审批，审批结果返回前暂停输入
+func (g *commandGuard) requestApproval(script, reason string) {
+	approval := &assetbiz.TerminalCommandApproval{
+		SessionKey: g.session.ID,
+		HostID:     g.session.HostID,
+		HostName:   g.session.HostName,
+		HostIP:     g.session.HostIP,
+		UserID:     g.session.UserID,
+		Username:   g.session.Username,
+		Command:    script,
+		Reason:     reason,
+		Status:     assetbiz.TerminalApprovalPending,
+		ExpiresAt:  time.Now().Add(terminalApprovalTimeout),
+	}
+	if err := g.tm.db.Create(approval).Error; err != nil {
+		appLogger.Error("提交终端命令审批失败", zap.String("sessionID", g.session.ID), zap.Error(err))
+		g.block(script, "提交命令审批失败")
+		return
+	}
+
+	ch := g.tm.registerApproval(approval.ID)
+	g.waiting = approval
+	g.session.notice(33, "[命令需审批] %s\r\n已提交审批单 #%d，%d 分钟内未审批将自动取消，按 Ctrl-C 撤回",
+		reason, approval.ID, int(terminalApprovalTimeout.Minutes()))
+	go g.awaitApproval(approval, ch)
+}
+
+// awaitApproval 等待审批结果，通过时发送回车执行命令，否则放弃当前命令行
+func (g *commandGuard) awaitApproval(approval *assetbiz.TerminalCommandApproval, ch <-chan terminalApprovalDecision) {
+	timer := time.NewTimer(terminalApprovalTimeout)
+	defer timer.Stop()
+
+	var d terminalApprovalDecision
+	select {
+	case d = <-ch:
+	case <-timer.C:
+		g.tm.resolveApproval(approval.ID, terminalApprovalDecision{status: assetbiz.TerminalApprovalTimeout})
+		d = <-ch
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.waiting != approval {
+		// 会话已关闭，决策已在关闭时记录
+		return
+	}
+	g.waiting = nil
+
+	switch d.status {
+	case assetbiz.TerminalApprovalApproved:
+		reason := "审批人: " + d.approverName
+		if d.comment != "" {
+			reason += "，审批意见: " + d.comment
+		}
+		g.session.notice(32, "[审批通过] %s", reason)
+		g.session.Recorder.Decide(assetbiz.TerminalCommandActionApproved, reason)
+		g.sendEnter([]byte{'\r'})
+		return
+	case assetbiz.TerminalApprovalRejected:
+		reason := "审批人 " + d.approverName + " 驳回"
+		if d.comment != "" {
+			reason += "，审批意见: " + d.comment
+		}
+		g.discard(approval.Command, d.status, reason)
+	case assetbiz.TerminalApprovalTimeout:
+		g.discard(approval.Command, d.status, "审批超时")
+	default:
+		g.discard(approval.Command, d.status, d.comment)
+	}
+}
+
+// discard 记录未执行的命令并放弃当前命令行
+func (g *commandGuard) discard(script, action, reason string) {
+	g.session.Recorder.RecordDecision(script, action, reason)
+	g.session.notice(31, "[命令未执行] %s", reason)
+	g.send([]byte{0x03})
+}
+
+// Close 会话关闭时撤回等待中的审批
+func (g *commandGuard) Close() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.closed = true
+	if g.waiting != nil {
+		const reason = "终端会话已断开"
+		g.tm.resolveApproval(g.waiting.ID, terminalApprovalDecision{
+			status:  assetbiz.TerminalApprovalCancelled,
+			comment: reason,
+		})
+		g.session.Recorder.RecordDecision(g.waiting.Command, assetbiz.TerminalCommandActionCancelled, reason)
+		g.waiting = nil
+	}
+}
+
+// registerApproval 登记等待审批的命令，返回接收审批结果的通道
+func (tm *TerminalManager) registerApproval(id uint) <-chan terminalApprovalDecision {
+	ch := make(chan terminalApprovalDecision, 1)
+	tm.approvalMu.Lock()
+	tm.approvals[id] = ch
+	tm.approvalMu.Unlock()
+	return ch
+}
+
+// resolveApproval 保存审批结果并通知等待中的终端会话。
+// 审批、驳回、超时和撤回可能同时发生，只有第一个生效，其余返回 false
+func (tm *TerminalManager) resolveApproval(id uint, d terminalApprovalDecision) bool {
+	tm.approvalMu.Lock()
+	ch, ok := tm.approvals[id]
+	delete(tm.approvals, id)
+	tm.approvalMu.Unlock()
+	if !ok {
+		return false
+	}
+
+	updates := map[string]interface{}{
+		"status":           d.status,
+		"approval_comment": d.comment,
+	}
+	if d.approverID > 0 {
+		now := time.Now()
+		updates["approver_id"] = d.approverID
+		updates["approver_name"] = d.approverName
+		updates["approval_time"] = &now
+	}
+	if err := tm.db.Model(&assetbiz.TerminalCommandApproval{}).
+		Where("id = ? AND status = ?", id, assetbiz.TerminalApprovalPending).
+		Updates(updates).Error; err != nil {
+		appLogger.Error("保存终端命令审批结果失败", zap.Uint("approvalID", id), zap.Error(err))
+	}
+	ch <- d
+	return true
+}
@@ -0,0 +1,406 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package asset
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	rbacService "github.com/ydcloud-dy/opshub/internal/service/rbac"
+	appLogger "github.com/ydcloud-dy/opshub/pkg/logger"
+	"github.com/ydcloud-dy/opshub/pkg/response"
+	"go.uber.org/zap"
+)
+
+const (
+	// mirrorHistorySize 保留的最近输出大小，旁观者加入时先回放以还原当前屏幕
+	mirrorHistorySize = 64 * 1024
+	// watcherBufferSize 旁观者待发送的输出块数，跟不上输出时断开
+	watcherBufferSize = 256
+)
+
+// 旁观模式
+const (
+	WatchModeReadonly    = "readonly"    // 只读，仅查看输出
+	WatchModeCollaborate = "collaborate" // 协作，可以输入，输入同样经过命令过滤
+)
+
+// sessionTermination 管理员强制终止会话的信息
+type sessionTermination struct {
+	operatorID   uint
+	operatorName string
+	reason       string
+}
+
+// terminalWatcher 旁观会话的管理员
+type terminalWatcher struct {
+	userID   uint
+	username string
+	mode     string
+	joinedAt time.Time
+	send     chan []byte
+}
+
+// TerminalWatcherInfo 旁观者信息
+type TerminalWatcherInfo struct {
+	UserID   uint      `json:"userId"`
+	Username string    `json:"username"`
+	Mode     string    `json:"mode"`
+	JoinedAt time.Time `json:"joinedAt"`
+}
+
+// ActiveTerminalSession 在线终端会话
+type ActiveTerminalSession struct {
+	ID        string                `json:"id"`
+	HostID    uint                  `json:"hostId"`
+	HostName  string                `json:"hostName"`
+	HostIP    string                `json:"hostIp"`
+	UserID    uint                  `json:"userId"`
+	Username  string                `json:"username"`
+	CreatedAt time.Time             `json:"createdAt"`
+	Duration  int                   `json:"duration"` // 已连接时长（秒）
+	Cols      int                   `json:"cols"`     // 用户终端尺寸，旁观时按此尺寸显示
+	Rows      int                   `json:"rows"`
+	Detached  bool                  `json:"detached"` // 用户连接已断开，正在等待重连
+	Watchers  []TerminalWatcherInfo `json:"watchers"`
+}
+
+// TerminateSessionRequest 强制终止会话请求
+type TerminateSessionRequest struct {
+	Reason string `json:"reason" binding:"required,max=500"`
+}
+
+// sessionMirror 将会话输出转发给旁观的管理员
+type sessionMirror struct {
+	mu       sync.Mutex
+	history  []byte
+	watchers map[*terminalWatcher]struct{}
+	closed   bool
+}
+
+func newSessionMirror() *sessionMirror {
+	return &sessionMirror{watchers: make(map[*terminalWatcher]struct{})}
+}
+
+// Write 记录输出并转发给旁观者，跟不上输出的旁观者被断开
+func (m *sessionMirror) Write(data []byte) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.closed {
+		return
+	}
+	m.history = append(m.history, data...)
+	if len(m.history) > 2*mirrorHistorySize {
+		m.history = slices.Clone(m.history[len(m.history)-mirrorHistorySize:])
+	}
+	for w := range m.watchers {
+		select {
+		case w.send <- slices.Clone(data):
+		default:
+			delete(m.watchers, w)
+			close(w.send)
+		}
+	}
+}
+
+// join 加入旁观，返回最近的输出；会话已结束时返回 false
+func (m *sessionMirror) join(w *terminalWatcher) ([]byte, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.closed {
+		return nil, false
+	}
+	m.watchers[w] = struct{}{}
+	return slices.Clone(m.history), true
+}
+
+// leave 退出旁观
+func (m *sessionMirror) leave(w *terminalWatcher) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.watchers[w]; ok {
+		delete(m.watchers, w)
+		close(w.send)
+	}
+}
+
+// Watchers 返回当前旁观者
+func (m *sessionMirror) Watchers() []TerminalWatcherInfo {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	watchers := make([]TerminalWatcherInfo, 0, len(m.watchers))
+	for w := range m.watchers {
+		watchers = append(watchers, TerminalWatcherInfo{
+			UserID:   w.userID,
+			Username: w.username,
+			Mode:     w.mode,
+			JoinedAt: w.joinedAt,
+		})
+	}
+	slices.SortFunc(watchers, func(a, b TerminalWatcherInfo) int {
+		return a.JoinedAt.Compare(b.JoinedAt)
+	})
+	return watchers
+}
+
+// Close 会话结束，断开全部旁观者
+func (m *sessionMirror) Close() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.closed = true
+	for w := range m.watchers {
+		close(w.send)
+	}
+	clear(m.watchers)
+	m.history = nil
+}
+
+// Output 向用户终端输出并转发给旁观者，连接断开期间的输出保留在缓冲区中等待重连
+func (s *TerminalSession) Output(data []byte) error {
+	s.mirror.Write(data)
+
+	s.connMu.Lock()
+	defer s.connMu.Unlock()
+	s.output.Write(data)
+	if s.conn == nil {
+		return fmt.Errorf("终端连接已断开")
+	}
+	// 使用二进制消息以保留原始字节（包括CR/LF控制字符）
+	return s.conn.WriteMessage(websocket.BinaryMessage, data)
+}
+
+// notice 在终端中输出平台提示信息
+func (s *TerminalSession) notice(color int, format string, args ...any) {
+	msg := fmt.Sprintf("\r\n\x1b[%dm%s\x1b[0m\r\n", color, fmt.Sprintf(format, args...))
+	s.Recorder.RecordNotice([]byte(msg))
+	s.Output([]byte(msg))
+}
+
+// terminated 返回强制终止的信息，未被终止时返回 nil
+func (s *TerminalSession) terminated() *sessionTermination {
+	s.connMu.Lock()
+	defer s.connMu.Unlock()
+	return s.termination
+}
+
+// terminate 强制终止会话：提示用户后断开 SSH 和 WebSocket 连接，会话记录在输出转发结束时保存
+func (s *TerminalSession) terminate(t *sessionTermination) {
+	s.connMu.Lock()
+	if s.termination != nil {
+		s.connMu.Unlock()
+		return
+	}
+	s.termination = t
+	s.connMu.Unlock()
+
+	s.notice(31, "[会话已被管理员 %s 强制终止] 原因: %s", t.operatorName, t.reason)
+	s.closeSSH()
+
+	s.connMu.Lock()
+	defer s.connMu.Unlock()
+	if s.conn != nil {
+		s.conn.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "会话已被管理员终止"),
+			time.Now().Add(time.Second))
+		s.conn.Close()
+	}
+}
+
+// ActiveSessions 返回在线终端会话，按连接时间倒序
+func (tm *TerminalManager) ActiveSessions() []*ActiveTerminalSession {
+	tm.mu.RLock()
+	defer tm.mu.RUnlock()
+
+	sessions := make([]*ActiveTerminalSession, 0, len(tm.sessions))
+	for _, session := range tm.sessions {
+		cols, rows := session.Recorder.Size()
+		sessions = append(sessions, &ActiveTerminalSession{
+			ID:        session.ID,
+			HostID:    session.HostID,
+			HostName:  session.HostName,
+			HostIP:    session.HostIP,
+			UserID:    session.UserID,
+			Username:  session.Username,
+			CreatedAt: session.CreatedAt,
+			Duration:  int(time.Since(session.CreatedAt).Seconds()),
+			Cols:      cols,
+			Rows:      rows,
+			Detached:  session.detached(),
+			Watchers:  session.mirror.Watchers(),
+		})
+	}
+	slices.SortFunc(sessions, func(a, b *ActiveTerminalSession) int {
+		return b.CreatedAt.Compare(a.CreatedAt)
+	})
+	return sessions
+}
+
+// ListActiveSessions 获取在线终端会话
+// @Summary 获取在线终端会话
+// @Description 列出所有主机上正在进行的终端会话及其旁观者，仅管理员可用
+// @Tags 终端审计
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} response.Response "获取成功"
+// @Router /api/v1/terminal-sessions/active [get]
+func (h *TerminalAuditHandler) ListActiveSessions(c *gin.Context) {
+	response.Success(c, h.terminalManager.ActiveSessions())
+}
+
+// TerminateSession 强制终止终端会话
+// @Summary 强制终止终端会话
+// @Description 断开在线终端会话，终止原因显示在用户终端并保存到会话记录，仅管理员可用
+// @Tags 终端审计
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path string true "在线会话ID"
+// @Param body body TerminateSessionRequest true "终止原因"
+// @Success 200 {object} response.Response "终止成功"
+// @Router /api/v1/terminal-sessions/active/{id}/kill [post]
+func (h *TerminalAuditHandler) TerminateSession(c *gin.Context) {
+	var req TerminateSessionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "参数错误: "+err.Error())
+		return
+	}
+
+	session, ok := h.terminalManager.GetSession(c.Param("id"))
+	if !ok {
+		response.ErrorCode(c, http.StatusNotFound, "会话不存在或已结束")
+		return
+	}
+
+	operatorName := rbacService.GetUsername(c)
+	appLogger.Info("管理员强制终止终端会话",
+		zap.String("sessionID", session.ID),
+		zap.String("operator", operatorName),
+		zap.String("reason", req.Reason))
+	session.terminate(&sessionTermination{
+		operatorID:   rbacService.GetUserID(c),
+		operatorName: operatorName,
+		reason:       req.Reason,
+	})
+	response.SuccessWithMessage(c, "会话已终止", nil)
+}
+
+// WatchSession 旁观在线终端会话
+// @Summary 旁观在线终端会话
+// @Description WebSocket 接口，实时转发会话输出；mode=collaborate 时可以协作输入，输入同样经过命令过滤。仅管理员可用
+// @Tags 终端审计
+// @Security Bearer
+// @Param id path string true "在线会话ID"
+// @Param mode query string false "旁观模式 readonly/collaborate" default(readonly)
+// @Router /api/v1/terminal-sessions/active/{id}/watch [get]
+func (h *TerminalAuditHandler) WatchSession(c *gin.Context) {
+	mode := c.DefaultQuery("mode", WatchModeReadonly)
+	if mode != WatchModeReadonly && mode != WatchModeCollaborate {
+		response.ErrorCode(c, http.StatusBadRequest, "无效的旁观模式")
+		return
+	}
+	session, ok := h.terminalManager.GetSession(c.Param("id"))
+	if !ok {
+		response.ErrorCode(c, http.StatusNotFound, "会话不存在或已结束")
+		return
+	}
+
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		appLogger.Error("WebSocket升级失败", zap.Error(err))
+		return
+	}
+	defer conn.Close()
+
+	watcher := &terminalWatcher{
+		userID:   rbacService.GetUserID(c),
+		username: rbacService.GetUsername(c),
+		mode:     mode,
+		joinedAt: time.Now(),
+		send:     make(chan []byte, watcherBufferSize),
+	}
+	history, ok := session.mirror.join(watcher)
+	if !ok {
+		conn.WriteMessage(websocket.TextMessage, []byte("会话已结束\r\n"))
+		return
+	}
+	defer session.mirror.leave(watcher)
+
+	appLogger.Info("管理员旁观终端会话",
+		zap.String("sessionID", session.ID),
+		zap.String("watcher", watcher.username),
+		zap.String("mode", mode))
+	if mode == WatchModeCollaborate {
+		session.notice(33, "[管理员 %s 已加入协作]", watcher.username)
+	}
+
+	// 转发会话输出，会话结束或跟不上输出时断开
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if len(history) > 0 {
+			if err := conn.WriteMessage(websocket.BinaryMessage, history); err != nil {
+				return
+			}
+		}
+		for data := range watcher.send {
+			if err := conn.WriteMessage(websocket.BinaryMessage, data); err != nil {
+				return
+			}
+		}
+		conn.WriteMessage(websocket.BinaryMessage, []byte("\r\n\x1b[33m[会话已结束]\x1b[0m\r\n"))
+		conn.Close()
+	}()
+
+	for {
+		messageType, data, err := conn.ReadMessage()
+		if err != nil {
+			break
+		}
+		if mode != WatchModeCollaborate || session.Guard == nil {
+			continue
+		}
+		if messageType == websocket.TextMessage {
+			// 旁观者的窗口尺寸不影响会话
+			var msg map[string]interface{}
+			if json.Unmarshal(data, &msg) == nil && msg["type"] == "resize" {
+				continue
+			}
+		}
+		session.Guard.Input(data)
+	}
+
+	if _, active := h.terminalManager.GetSession(session.ID); active && mode == WatchModeCollaborate {
+		session.notice(33, "[管理员 %s 已退出协作]", watcher.username)
+	}
+	session.mirror.leave(watcher)
+	<-done
+}
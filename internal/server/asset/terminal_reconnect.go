@@ -0,0 +1,211 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package asset
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	appLogger "github.com/ydcloud-dy/opshub/pkg/logger"
+	"go.uber.org/zap"
+)
+
+const (
+	// reconnectGracePeriod 连接意外断开后保留会话等待重连的时间
+	reconnectGracePeriod = 2 * time.Minute
+	// reconnectBufferSize 保留的最近输出大小，重连时补发断线期间的输出
+	reconnectBufferSize = 256 * 1024
+)
+
+// terminalControlMessage 发送给用户终端的控制消息（文本消息），终端输出使用二进制消息
+type terminalControlMessage struct {
+	Type      string `json:"type"`
+	SessionID string `json:"sessionId"` // 重连时作为 session 参数，since 参数为已收到的输出字节数
+}
+
+// outputBuffer 保留最近的终端输出，按输出的总字节数定位重连时需要补发的内容
+type outputBuffer struct {
+	data  []byte
+	total int64 // 已写入的总字节数
+}
+
+// Write 追加输出，超过容量时丢弃最早的输出
+func (b *outputBuffer) Write(p []byte) {
+	b.data = append(b.data, p...)
+	b.total += int64(len(p))
+	if len(b.data) > 2*reconnectBufferSize {
+		b.data = slices.Clone(b.data[len(b.data)-reconnectBufferSize:])
+	}
+}
+
+// Since 返回 offset 之后的输出；部分输出已被丢弃时返回保留的全部输出和 false
+func (b *outputBuffer) Since(offset int64) ([]byte, bool) {
+	start := b.total - int64(len(b.data))
+	if offset < start || offset > b.total {
+		return b.data, false
+	}
+	return b.data[offset-start:], true
+}
+
+// attach 绑定用户的 WebSocket 连接：告知会话ID，补发 since 之后的输出。
+// 已有连接时（如网络切换后旧连接尚未超时）由新连接接管，旧连接被关闭
+func (s *TerminalSession) attach(conn *websocket.Conn, since int64) error {
+	s.connMu.Lock()
+	defer s.connMu.Unlock()
+
+	if s.graceTimer != nil {
+		s.graceTimer.Stop()
+		s.graceTimer = nil
+	}
+	if s.conn != nil && s.conn != conn {
+		s.conn.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseNormalClosure, "会话已在其他连接中打开"),
+			time.Now().Add(time.Second))
+		s.conn.Close()
+	}
+	s.conn = nil
+
+	msg, _ := json.Marshal(terminalControlMessage{Type: "session", SessionID: s.ID})
+	if err := conn.WriteMessage(websocket.TextMessage, msg); err != nil {
+		return err
+	}
+	if missed, complete := s.output.Since(since); len(missed) > 0 || !complete {
+		if !complete {
+			missed = append([]byte("\r\n\x1b[33m[部分断线期间的输出已丢失]\x1b[0m\r\n"), missed...)
+		}
+		if err := conn.WriteMessage(websocket.BinaryMessage, missed); err != nil {
+			return err
+		}
+	}
+	s.conn = conn
+	return nil
+}
+
+// detach 解除 WebSocket 连接绑定，连接已被新连接接管时返回 false
+func (s *TerminalSession) detach(conn *websocket.Conn) bool {
+	s.connMu.Lock()
+	defer s.connMu.Unlock()
+	if s.conn != conn {
+		return false
+	}
+	s.conn = nil
+	return true
+}
+
+// detached 是否正在等待重连
+func (s *TerminalSession) detached() bool {
+	s.connMu.Lock()
+	defer s.connMu.Unlock()
+	return s.conn == nil
+}
+
+// waitReconnect 等待用户重连，超过宽限期仍未重连时断开SSH连接
+func (s *TerminalSession) waitReconnect() {
+	s.notice(33, "[连接已断开，会话将保留 %d 秒等待重新连接]", int(reconnectGracePeriod.Seconds()))
+
+	s.connMu.Lock()
+	defer s.connMu.Unlock()
+	if s.graceTimer != nil {
+		s.graceTimer.Stop()
+	}
+	s.graceTimer = time.AfterFunc(reconnectGracePeriod, func() {
+		s.connMu.Lock()
+		expired := s.conn == nil
+		s.connMu.Unlock()
+		if expired {
+			appLogger.Info("终端会话重连超时", zap.String("sessionID", s.ID))
+			s.closeSSH()
+		}
+	})
+}
+
+// closeSSH 断开SSH连接，输出转发结束后由 pumpOutput 保存会话记录
+func (s *TerminalSession) closeSSH() {
+	if s.SSHSession != nil {
+		s.SSHSession.Close()
+	}
+	if s.SSHClient != nil {
+		s.SSHClient.Close()
+	}
+}
+
+// end SSH会话已结束，关闭用户的 WebSocket 连接，用户终端不再重连
+func (s *TerminalSession) end() {
+	s.connMu.Lock()
+	defer s.connMu.Unlock()
+
+	if s.graceTimer != nil {
+		s.graceTimer.Stop()
+		s.graceTimer = nil
+	}
+	if s.conn != nil {
+		s.conn.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseNormalClosure, "会话已结束"),
+			time.Now().Add(time.Second))
+		s.conn.Close()
+		s.conn = nil
+	}
+}
+
+// ResumeSession 查找可以重连的会话，只有会话所属用户可以重连
+func (tm *TerminalManager) ResumeSession(sessionID string, hostID, userID uint) (*TerminalSession, error) {
+	session, ok := tm.GetSession(sessionID)
+	if !ok || session.HostID != hostID || session.UserID != userID || session.terminated() != nil {
+		return nil, fmt.Errorf("会话不存在或已结束")
+	}
+	return session, nil
+}
+
+// pumpOutput 将SSH输出转发给用户终端，SSH会话结束后关闭会话并保存记录。
+// 输出转发与 WebSocket 连接解耦，连接断开期间的输出保留在缓冲区中，重连后补发
+func (tm *TerminalManager) pumpOutput(session *TerminalSession) {
+	var wg sync.WaitGroup
+	for _, pipe := range []io.Reader{session.StdoutPipe, session.StderrPipe} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			buf := make([]byte, 1024)
+			for {
+				n, err := pipe.Read(buf)
+				if n > 0 {
+					// 录制输出
+					session.Recorder.RecordOutput(buf[:n])
+					session.Guard.Output()
+					session.Output(buf[:n])
+				}
+				if err != nil {
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	session.end()
+	if err := tm.CloseSession(session.ID); err != nil {
+		appLogger.Warn("关闭终端会话失败", zap.String("sessionID", session.ID), zap.Error(err))
+	}
+	appLogger.Info("终端会话结束", zap.String("sessionID", session.ID))
+}
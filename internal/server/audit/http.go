@@ -25,20 +25,23 @@ import (
 )
 
 type HTTPService struct {
-	operationLogService *audit.OperationLogService
-	loginLogService     *audit.LoginLogService
-	dataLogService      *audit.DataLogService
+	operationLogService    *audit.OperationLogService
+	loginLogService        *audit.LoginLogService
+	dataLogService         *audit.DataLogService
+	fileTransferLogService *audit.FileTransferLogService
 }
 
 func NewHTTPService(
 	operationLogService *audit.OperationLogService,
 	loginLogService *audit.LoginLogService,
 	dataLogService *audit.DataLogService,
+	fileTransferLogService *audit.FileTransferLogService,
 ) *HTTPService {
 	return &HTTPService{
-		operationLogService: operationLogService,
-		loginLogService:     loginLogService,
-		dataLogService:      dataLogService,
+		operationLogService:    operationLogService,
+		loginLogService:        loginLogService,
+		dataLogService:         dataLogService,
+		fileTransferLogService: fileTransferLogService,
 	}
 }
 
@@ -73,5 +76,13 @@ func (s *HTTPService) RegisterRoutes(r *gin.RouterGroup) {
 			dataLogs.DELETE("/:id", s.dataLogService.DeleteDataLog)
 			dataLogs.POST("/batch-delete", s.dataLogService.DeleteDataLogsBatch)
 		}
+
+		// 文件传输日志路由（只读，不提供删除）
+		fileTransferLogs := audit.Group("/file-transfer-logs")
+		{
+			fileTransferLogs.GET("", s.fileTransferLogService.ListFileTransferLogs)
+			fileTransferLogs.GET("/export", s.fileTransferLogService.ExportFileTransferLogs)
+			fileTransferLogs.GET("/:id", s.fileTransferLogService.GetFileTransferLog)
+		}
 	}
 }
@@ -167,7 +167,7 @@ func (s *HTTPServer) registerRoutes(router *gin.Engine, jwtSecret string) {
 
 	// 创建 MFA 服务
 	mfaRepo := mfadata.NewRepository(s.db)
-	mfaUseCase := mfabiz.NewUseCase(mfaRepo, "OpsHub", []byte(jwtSecret))
+	mfaUseCase := mfabiz.NewUseCase(mfaRepo, "OpsHub")
 	userService.SetMFAUseCase(mfaUseCase)
 
 	// MFA HTTP服务
@@ -176,17 +176,17 @@ func (s *HTTPServer) registerRoutes(router *gin.Engine, jwtSecret string) {
 	mfaHTTPServer.SetConfigUseCase(configUseCase)
 
 	// 创建 Audit 服务
-	operationLogService, loginLogService, dataLogService := auditserver.NewAuditServices(s.db)
+	operationLogService, loginLogService, dataLogService, fileTransferLogService := auditserver.NewAuditServices(s.db)
 
 	// 创建 Asset 服务
-	assetGroupService, hostService, terminalManager := assetserver.NewAssetServices(s.db)
+	assetGroupService, hostService, commandRuleService, terminalManager := assetserver.NewAssetServices(s.db, s.conf)
 
 	// 设置authMiddleware的assetPermissionRepo
 	assetPermissionRepo := rbacdata.NewAssetPermissionRepo(s.db)
 	authMiddleware.SetAssetPermissionRepo(assetPermissionRepo)
 
 	// Asset 路由
-	assetServer := assetserver.NewHTTPServer(assetGroupService, hostService, terminalManager, s.db, authMiddleware)
+	assetServer := assetserver.NewHTTPServer(assetGroupService, hostService, commandRuleService, terminalManager, s.db, authMiddleware)
 
 	// API v1 - 公开接口(不需要认证)
 	public := router.Group("/api/v1/public")
@@ -210,7 +210,7 @@ func (s *HTTPServer) registerRoutes(router *gin.Engine, jwtSecret string) {
 	v1.Use(authMiddleware.AuthRequired())
 	{
 		// Audit 路由
-		auditHTTPServer := auditserver.NewHTTPService(operationLogService, loginLogService, dataLogService)
+		auditHTTPServer := auditserver.NewHTTPService(operationLogService, loginLogService, dataLogService, fileTransferLogService)
 		auditHTTPServer.RegisterRoutes(v1)
 
 		// 注册 Asset 路由
@@ -0,0 +1,124 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package asset
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ydcloud-dy/opshub/internal/biz/asset"
+	rbacService "github.com/ydcloud-dy/opshub/internal/service/rbac"
+	"github.com/ydcloud-dy/opshub/pkg/response"
+)
+
+// SetCloudSyncUseCase 设置云库存同步用例（通过依赖注入）
+func (s *HostService) SetCloudSyncUseCase(cloudSyncUseCase *asset.CloudSyncUseCase) {
+	s.cloudSyncUseCase = cloudSyncUseCase
+}
+
+// SyncCloudAccount 同步云账号库存
+// @Summary 同步云账号库存
+// @Description 拉取云账号同步区域内的实例列表，新增主机、标记已释放的实例，并更新IP、规格、状态和标签。同步在后台执行，返回同步记录
+// @Tags 资产管理-云账号
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "云账号ID"
+// @Success 200 {object} response.Response "同步已开始"
+// @Failure 400 {object} response.Response "账号已禁用、未配置区域或正在同步中"
+// @Router /api/v1/cloud-accounts/{id}/sync [post]
+func (s *HostService) SyncCloudAccount(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "无效的云账号ID")
+		return
+	}
+
+	run, err := s.cloudSyncUseCase.Sync(c.Request.Context(), uint(id), asset.CloudSyncTriggerManual,
+		rbacService.GetUserID(c), rbacService.GetUsername(c))
+	if err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	response.Success(c, run)
+}
+
+// ListCloudSyncRuns 云账号同步记录
+// @Summary 获取云账号同步记录
+// @Description 分页获取云账号的库存同步记录及各类变更数量
+// @Tags 资产管理-云账号
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "云账号ID"
+// @Param page query int false "页码" default(1)
+// @Param pageSize query int false "每页数量" default(20)
+// @Success 200 {object} response.Response "获取成功"
+// @Router /api/v1/cloud-accounts/{id}/sync-runs [get]
+func (s *HostService) ListCloudSyncRuns(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "无效的云账号ID")
+		return
+	}
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
+
+	runs, total, err := s.cloudSyncUseCase.ListRuns(c.Request.Context(), uint(id), page, pageSize)
+	if err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "查询失败: "+err.Error())
+		return
+	}
+
+	response.Success(c, gin.H{
+		"list":     runs,
+		"total":    total,
+		"page":     page,
+		"pageSize": pageSize,
+	})
+}
+
+// GetCloudSyncRun 云账号同步详情
+// @Summary 获取云账号同步详情
+// @Description 获取同步记录及相对上次同步的变更明细：新增、变化的字段、已释放和恢复的实例
+// @Tags 资产管理-云账号
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param runId path int true "同步记录ID"
+// @Success 200 {object} response.Response "获取成功"
+// @Router /api/v1/cloud-accounts/sync-runs/{runId} [get]
+func (s *HostService) GetCloudSyncRun(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("runId"), 10, 32)
+	if err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "无效的同步记录ID")
+		return
+	}
+
+	run, err := s.cloudSyncUseCase.GetRun(c.Request.Context(), uint(id))
+	if err != nil {
+		response.ErrorCode(c, http.StatusNotFound, "同步记录不存在")
+		return
+	}
+
+	response.Success(c, run)
+}
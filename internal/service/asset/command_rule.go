@@ -0,0 +1,222 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package asset
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ydcloud-dy/opshub/internal/biz/asset"
+	rbacService "github.com/ydcloud-dy/opshub/internal/service/rbac"
+	"github.com/ydcloud-dy/opshub/pkg/response"
+)
+
+type CommandRuleService struct {
+	ruleUseCase *asset.CommandRuleUseCase
+}
+
+func NewCommandRuleService(ruleUseCase *asset.CommandRuleUseCase) *CommandRuleService {
+	return &CommandRuleService{
+		ruleUseCase: ruleUseCase,
+	}
+}
+
+// ListRules 获取命令规则列表
+// @Summary 获取命令规则列表
+// @Description 分页获取命令策略规则，按优先级排序
+// @Tags 命令策略
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param page query int false "页码" default(1)
+// @Param pageSize query int false "每页数量" default(10)
+// @Param keyword query string false "名称或模式关键字"
+// @Param scopeType query string false "作用范围 global/role/asset_group/template"
+// @Param action query string false "动作 allow/deny/approve"
+// @Success 200 {object} response.Response "获取成功"
+// @Router /api/v1/command-rules [get]
+func (s *CommandRuleService) ListRules(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "10"))
+
+	rules, total, err := s.ruleUseCase.List(c.Request.Context(), page, pageSize, c.Query("keyword"), c.Query("scopeType"), c.Query("action"))
+	if err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "查询失败: "+err.Error())
+		return
+	}
+
+	response.Success(c, gin.H{
+		"list":     rules,
+		"total":    total,
+		"page":     page,
+		"pageSize": pageSize,
+	})
+}
+
+// GetRule 获取命令规则详情
+// @Summary 获取命令规则详情
+// @Description 获取指定命令策略规则
+// @Tags 命令策略
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "规则ID"
+// @Success 200 {object} response.Response "获取成功"
+// @Failure 404 {object} response.Response "规则不存在"
+// @Router /api/v1/command-rules/{id} [get]
+func (s *CommandRuleService) GetRule(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "无效的规则ID")
+		return
+	}
+
+	rule, err := s.ruleUseCase.GetByID(c.Request.Context(), uint(id))
+	if err != nil {
+		response.ErrorCode(c, http.StatusNotFound, "规则不存在")
+		return
+	}
+
+	response.Success(c, rule)
+}
+
+// CreateRule 创建命令规则
+// @Summary 创建命令规则
+// @Description 创建命令策略规则，可作用于全局、角色、资产分组或作业模板
+// @Tags 命令策略
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param body body asset.CommandRuleRequest true "规则信息"
+// @Success 200 {object} response.Response "创建成功"
+// @Failure 400 {object} response.Response "参数错误"
+// @Router /api/v1/command-rules [post]
+func (s *CommandRuleService) CreateRule(c *gin.Context) {
+	var req asset.CommandRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "参数错误: "+err.Error())
+		return
+	}
+
+	rule := req.ToModel()
+	if err := s.ruleUseCase.Create(c.Request.Context(), rule); err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "创建失败: "+err.Error())
+		return
+	}
+
+	response.Success(c, rule)
+}
+
+// UpdateRule 更新命令规则
+// @Summary 更新命令规则
+// @Description 更新指定的命令策略规则
+// @Tags 命令策略
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "规则ID"
+// @Param body body asset.CommandRuleRequest true "规则信息"
+// @Success 200 {object} response.Response "更新成功"
+// @Failure 400 {object} response.Response "参数错误"
+// @Router /api/v1/command-rules/{id} [put]
+func (s *CommandRuleService) UpdateRule(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "无效的规则ID")
+		return
+	}
+
+	var req asset.CommandRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "参数错误: "+err.Error())
+		return
+	}
+
+	if _, err := s.ruleUseCase.GetByID(c.Request.Context(), uint(id)); err != nil {
+		response.ErrorCode(c, http.StatusNotFound, "规则不存在")
+		return
+	}
+
+	rule := req.ToModel()
+	rule.ID = uint(id)
+	if err := s.ruleUseCase.Update(c.Request.Context(), rule); err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "更新失败: "+err.Error())
+		return
+	}
+
+	response.Success(c, rule)
+}
+
+// DeleteRule 删除命令规则
+// @Summary 删除命令规则
+// @Description 删除指定的命令策略规则
+// @Tags 命令策略
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "规则ID"
+// @Success 200 {object} response.Response "删除成功"
+// @Failure 400 {object} response.Response "参数错误"
+// @Router /api/v1/command-rules/{id} [delete]
+func (s *CommandRuleService) DeleteRule(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "无效的规则ID")
+		return
+	}
+
+	if err := s.ruleUseCase.Delete(c.Request.Context(), uint(id)); err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "删除失败: "+err.Error())
+		return
+	}
+
+	response.SuccessWithMessage(c, "删除成功", nil)
+}
+
+// CheckCommand 命令策略试运行
+// @Summary 命令策略试运行
+// @Description 按当前规则检查脚本但不执行，返回每条命令命中的规则及整体结论（allow/deny/approve）。未指定用户时按当前登录用户的角色匹配
+// @Tags 命令策略
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param body body asset.CommandCheckRequest true "检查内容"
+// @Success 200 {object} response.Response{data=asset.CommandCheckResult} "检查完成"
+// @Failure 400 {object} response.Response "参数错误"
+// @Router /api/v1/command-rules/check [post]
+func (s *CommandRuleService) CheckCommand(c *gin.Context) {
+	var req asset.CommandCheckRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "参数错误: "+err.Error())
+		return
+	}
+	if req.UserID == 0 {
+		req.UserID = rbacService.GetUserID(c)
+	}
+
+	result, err := s.ruleUseCase.Check(c.Request.Context(), &req)
+	if err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "检查失败: "+err.Error())
+		return
+	}
+
+	response.Success(c, result)
+}
@@ -0,0 +1,150 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package asset
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ydcloud-dy/opshub/internal/biz/asset"
+	rbacService "github.com/ydcloud-dy/opshub/internal/service/rbac"
+	"github.com/ydcloud-dy/opshub/pkg/response"
+)
+
+// SetCredentialRotationUseCase 设置凭证轮换用例（通过依赖注入）
+func (s *HostService) SetCredentialRotationUseCase(rotationUseCase *asset.CredentialRotationUseCase) {
+	s.rotationUseCase = rotationUseCase
+}
+
+// RotateCredential 轮换凭证
+// @Summary 轮换凭证
+// @Description 生成新的密码或密钥对，推送到所有使用该凭证的主机并验证登录，全部成功后保存到凭证，任一主机失败则恢复所有主机的原密钥。轮换在后台执行，返回轮换记录
+// @Tags 资产管理-凭证
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "凭证ID"
+// @Success 200 {object} response.Response "轮换已开始"
+// @Failure 400 {object} response.Response "凭证不支持轮换或正在轮换中"
+// @Router /api/v1/credentials/{id}/rotate [post]
+func (s *HostService) RotateCredential(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "无效的凭证ID")
+		return
+	}
+
+	rotation, err := s.rotationUseCase.Rotate(c.Request.Context(), uint(id), asset.RotationTriggerManual,
+		rbacService.GetUserID(c), rbacService.GetUsername(c))
+	if err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	response.Success(c, rotation)
+}
+
+// ListCredentialRotations 凭证轮换记录
+// @Summary 获取凭证轮换记录
+// @Description 分页获取凭证的轮换记录
+// @Tags 资产管理-凭证
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "凭证ID"
+// @Param page query int false "页码" default(1)
+// @Param pageSize query int false "每页数量" default(20)
+// @Success 200 {object} response.Response "获取成功"
+// @Router /api/v1/credentials/{id}/rotations [get]
+func (s *HostService) ListCredentialRotations(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "无效的凭证ID")
+		return
+	}
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
+
+	rotations, total, err := s.rotationUseCase.ListRotations(c.Request.Context(), uint(id), page, pageSize)
+	if err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "查询失败: "+err.Error())
+		return
+	}
+
+	response.Success(c, gin.H{
+		"list":     rotations,
+		"total":    total,
+		"page":     page,
+		"pageSize": pageSize,
+	})
+}
+
+// GetCredentialRotation 凭证轮换详情
+// @Summary 获取凭证轮换详情
+// @Description 获取轮换记录及每台主机的执行结果
+// @Tags 资产管理-凭证
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param rotationId path int true "轮换记录ID"
+// @Success 200 {object} response.Response "获取成功"
+// @Router /api/v1/credentials/rotations/{rotationId} [get]
+func (s *HostService) GetCredentialRotation(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("rotationId"), 10, 32)
+	if err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "无效的轮换记录ID")
+		return
+	}
+
+	rotation, err := s.rotationUseCase.GetRotation(c.Request.Context(), uint(id))
+	if err != nil {
+		response.ErrorCode(c, http.StatusNotFound, "轮换记录不存在")
+		return
+	}
+
+	response.Success(c, rotation)
+}
+
+// RetryCredentialRotationRollback 重试回滚
+// @Summary 重试凭证轮换回滚
+// @Description 对回滚失败的轮换，重新将仍在使用新密钥的主机恢复为原密钥
+// @Tags 资产管理-凭证
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param rotationId path int true "轮换记录ID"
+// @Success 200 {object} response.Response "执行完成"
+// @Router /api/v1/credentials/rotations/{rotationId}/rollback [post]
+func (s *HostService) RetryCredentialRotationRollback(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("rotationId"), 10, 32)
+	if err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "无效的轮换记录ID")
+		return
+	}
+
+	rotation, err := s.rotationUseCase.RetryRollback(c.Request.Context(), uint(id))
+	if err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	response.Success(c, rotation)
+}
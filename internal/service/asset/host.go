@@ -22,13 +22,16 @@ package asset
 import (
 	"bytes"
 	"fmt"
+	"io"
 	"net/http"
+	"path"
 	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/xuri/excelize/v2"
 	"github.com/ydcloud-dy/opshub/internal/biz/asset"
+	"github.com/ydcloud-dy/opshub/internal/biz/audit"
 	"github.com/ydcloud-dy/opshub/internal/biz/rbac"
 	rbacService "github.com/ydcloud-dy/opshub/internal/service/rbac"
 	"github.com/ydcloud-dy/opshub/pkg/response"
@@ -39,6 +42,12 @@ type HostService struct {
 	credentialUseCase      *asset.CredentialUseCase
 	cloudUseCase           *asset.CloudAccountUseCase
 	assetPermissionUseCase *rbac.AssetPermissionUseCase
+	fileTransferLogUseCase *audit.FileTransferLogUseCase
+	hostMetricUseCase      *asset.HostMetricUseCase
+	hostHeartbeatUseCase   *asset.HostHeartbeatUseCase
+	rotationUseCase        *asset.CredentialRotationUseCase
+	sshCAUseCase           *asset.SSHCAUseCase
+	cloudSyncUseCase       *asset.CloudSyncUseCase
 }
 
 func NewHostService(hostUseCase *asset.HostUseCase, credentialUseCase *asset.CredentialUseCase, cloudUseCase *asset.CloudAccountUseCase, assetPermissionUseCase *rbac.AssetPermissionUseCase) *HostService {
@@ -333,8 +342,8 @@ func (s *HostService) GetCredential(c *gin.Context) {
 		return
 	}
 
-	// 获取解密后的凭证详情（用于编辑时回显私钥）
-	credential, err := s.credentialUseCase.GetByIDDecrypted(c.Request.Context(), uint(id))
+	// 获取解密后的凭证详情（用于编辑时回显私钥），引用外部密钥的凭证不返回敏感字段
+	credential, err := s.credentialUseCase.GetForEdit(c.Request.Context(), uint(id))
 	if err != nil {
 		response.ErrorCode(c, http.StatusNotFound, "凭证不存在")
 		return
@@ -374,6 +383,41 @@ func (s *HostService) ListCredentials(c *gin.Context) {
 	})
 }
 
+// ListCredentialSecretAccesses 外部密钥读取记录
+// @Summary 获取凭证的外部密钥读取记录
+// @Description 分页获取引用外部密钥的凭证每次从外部存储读取密钥的审计记录
+// @Tags 资产管理-凭证
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "凭证ID"
+// @Param page query int false "页码" default(1)
+// @Param pageSize query int false "每页数量" default(20)
+// @Success 200 {object} response.Response "获取成功"
+// @Router /api/v1/credentials/{id}/secret-accesses [get]
+func (s *HostService) ListCredentialSecretAccesses(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "无效的凭证ID")
+		return
+	}
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
+
+	accesses, total, err := s.credentialUseCase.ListSecretAccesses(c.Request.Context(), uint(id), page, pageSize)
+	if err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "查询失败: "+err.Error())
+		return
+	}
+
+	response.Success(c, gin.H{
+		"list":     accesses,
+		"total":    total,
+		"page":     page,
+		"pageSize": pageSize,
+	})
+}
+
 // GetAllCredentials 获取所有凭证（用于下拉选择）
 // @Summary 获取所有凭证
 // @Description 获取所有凭证列表，用于下拉选择
@@ -702,6 +746,94 @@ func (s *HostService) TestHostConnection(c *gin.Context) {
 	response.SuccessWithMessage(c, "连接成功", nil)
 }
 
+// GetHostKey 获取主机SSH主机密钥
+// @Summary 获取主机SSH主机密钥
+// @Description 获取主机已信任的SSH主机密钥指纹，scan=true 时同时扫描主机当前密钥进行比对
+// @Tags 资产管理-主机
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "主机ID"
+// @Param scan query bool false "是否扫描主机当前密钥"
+// @Success 200 {object} response.Response{data=asset.HostKeyVO} "获取成功"
+// @Failure 400 {object} response.Response "参数错误"
+// @Router /api/v1/hosts/{id}/host-key [get]
+func (s *HostService) GetHostKey(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "无效的主机ID")
+		return
+	}
+
+	scan := c.Query("scan") == "true"
+	vo, err := s.hostUseCase.GetHostKey(c.Request.Context(), uint(id), scan)
+	if err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "获取主机密钥失败: "+err.Error())
+		return
+	}
+
+	response.Success(c, vo)
+}
+
+// PinHostKey 手动固定主机SSH主机密钥
+// @Summary 固定主机SSH主机密钥
+// @Description 手动指定主机的SSH公钥或SHA256指纹，之后的连接必须与之匹配
+// @Tags 资产管理-主机
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "主机ID"
+// @Param body body asset.HostKeyRequest true "主机密钥"
+// @Success 200 {object} response.Response "固定成功"
+// @Failure 400 {object} response.Response "参数错误"
+// @Router /api/v1/hosts/{id}/host-key [put]
+func (s *HostService) PinHostKey(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "无效的主机ID")
+		return
+	}
+
+	var req asset.HostKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "参数错误: "+err.Error())
+		return
+	}
+
+	if err := s.hostUseCase.PinHostKey(c.Request.Context(), uint(id), &req); err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "固定主机密钥失败: "+err.Error())
+		return
+	}
+
+	response.SuccessWithMessage(c, "固定成功", nil)
+}
+
+// ResetHostKey 重置主机SSH主机密钥
+// @Summary 重置主机SSH主机密钥
+// @Description 清除主机记录的SSH主机密钥，下次连接时重新信任（用于主机重装后）
+// @Tags 资产管理-主机
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "主机ID"
+// @Success 200 {object} response.Response "重置成功"
+// @Failure 400 {object} response.Response "参数错误"
+// @Router /api/v1/hosts/{id}/host-key [delete]
+func (s *HostService) ResetHostKey(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "无效的主机ID")
+		return
+	}
+
+	if err := s.hostUseCase.ResetHostKey(c.Request.Context(), uint(id)); err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "重置主机密钥失败: "+err.Error())
+		return
+	}
+
+	response.SuccessWithMessage(c, "重置成功", nil)
+}
+
 // BatchCollectHostInfo 批量采集主机信息
 // @Summary 批量采集主机信息
 // @Description 批量采集多个主机的系统信息
@@ -979,8 +1111,11 @@ func (s *HostService) UploadHostFile(c *gin.Context) {
 	}
 	defer src.Close()
 
-	// 上传文件
-	if err := s.hostUseCase.UploadFile(c.Request.Context(), uint(id), src, remotePath, file.Filename); err != nil {
+	// 上传文件，同时计算内容哈希用于审计
+	digest := audit.NewTransferDigest()
+	err = s.hostUseCase.UploadFile(c.Request.Context(), uint(id), io.TeeReader(src, digest), remotePath, file.Filename)
+	s.recordFileTransfer(c, uint(id), audit.TransferUpload, path.Join(remotePath, file.Filename), digest.Size(), digest.Sum(), err)
+	if err != nil {
 		response.ErrorCode(c, http.StatusInternalServerError, "上传文件失败: "+err.Error())
 		return
 	}
@@ -1026,8 +1161,11 @@ func (s *HostService) DownloadHostFile(c *gin.Context) {
 	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", fileName))
 	c.Header("Content-Transfer-Encoding", "binary")
 
-	// 下载文件
-	if err := s.hostUseCase.DownloadFile(c.Request.Context(), uint(id), remotePath, c.Writer); err != nil {
+	// 下载文件，同时计算内容哈希用于审计
+	digest := audit.NewTransferDigest()
+	err = s.hostUseCase.DownloadFile(c.Request.Context(), uint(id), remotePath, io.MultiWriter(c.Writer, digest))
+	s.recordFileTransfer(c, uint(id), audit.TransferDownload, remotePath, digest.Size(), digest.Sum(), err)
+	if err != nil {
 		response.ErrorCode(c, http.StatusInternalServerError, "下载文件失败: "+err.Error())
 		return
 	}
@@ -1041,7 +1179,7 @@ func (s *HostService) DownloadHostFile(c *gin.Context) {
 // @Produce json
 // @Security Bearer
 // @Param id path int true "主机ID"
-// @Param body body object true "文件路径 {path: string}"
+// @Param body body object true "文件路径 {path: string, recursive: bool, confirm: string}，递归删除目录时 confirm 需填写目录名"
 // @Success 200 {object} response.Response "删除成功"
 // @Failure 400 {object} response.Response "参数错误"
 // @Router /api/v1/hosts/{id}/files [delete]
@@ -1054,14 +1192,22 @@ func (s *HostService) DeleteHostFile(c *gin.Context) {
 	}
 
 	var req struct {
-		Path string `json:"path" binding:"required"`
+		Path      string `json:"path" binding:"required"`
+		Recursive bool   `json:"recursive"` // 递归删除目录
+		Confirm   string `json:"confirm"`   // 递归删除时需填写目录名确认
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		response.ErrorCode(c, http.StatusBadRequest, "参数错误: "+err.Error())
 		return
 	}
+	if req.Recursive && req.Confirm != path.Base(strings.TrimSuffix(req.Path, "/")) {
+		response.ErrorCode(c, http.StatusBadRequest, "递归删除目录需输入目录名确认")
+		return
+	}
 
-	if err := s.hostUseCase.DeleteFile(c.Request.Context(), uint(id), req.Path); err != nil {
+	err = s.hostUseCase.DeleteFile(c.Request.Context(), uint(id), req.Path, req.Recursive)
+	s.recordFileTransfer(c, uint(id), audit.TransferDelete, req.Path, 0, "", err)
+	if err != nil {
 		response.ErrorCode(c, http.StatusInternalServerError, "删除文件失败: "+err.Error())
 		return
 	}
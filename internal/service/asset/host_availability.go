@@ -0,0 +1,66 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package asset
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ydcloud-dy/opshub/internal/biz/asset"
+	"github.com/ydcloud-dy/opshub/pkg/response"
+)
+
+// SetHostHeartbeatUseCase 设置主机在线状态探测用例（通过依赖注入）
+func (s *HostService) SetHostHeartbeatUseCase(hostHeartbeatUseCase *asset.HostHeartbeatUseCase) {
+	s.hostHeartbeatUseCase = hostHeartbeatUseCase
+}
+
+// GetHostAvailability 获取主机可用性
+// @Summary 获取主机可用性
+// @Description 统计主机在时间范围内的在线时长和在线率，并返回状态变化记录和各状态区间
+// @Tags 资产管理-主机监控
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "主机ID"
+// @Param start query int false "开始时间(秒级时间戳)，默认为结束时间前24小时"
+// @Param end query int false "结束时间(秒级时间戳)，默认为当前时间"
+// @Success 200 {object} response.Response "获取成功"
+// @Failure 400 {object} response.Response "参数错误"
+// @Router /api/v1/hosts/{id}/availability [get]
+func (s *HostService) GetHostAvailability(c *gin.Context) {
+	id, ok := parseHostID(c)
+	if !ok {
+		return
+	}
+	start, end, ok := parseTimeRange(c, 24*time.Hour)
+	if !ok {
+		return
+	}
+
+	availability, err := s.hostHeartbeatUseCase.GetAvailability(c.Request.Context(), id, start, end)
+	if err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "查询主机可用性失败: "+err.Error())
+		return
+	}
+
+	response.Success(c, availability)
+}
@@ -0,0 +1,478 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package asset
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ydcloud-dy/opshub/internal/biz/asset"
+	"github.com/ydcloud-dy/opshub/internal/biz/audit"
+	rbacService "github.com/ydcloud-dy/opshub/internal/service/rbac"
+	appLogger "github.com/ydcloud-dy/opshub/pkg/logger"
+	"github.com/ydcloud-dy/opshub/pkg/response"
+	sshclient "github.com/ydcloud-dy/opshub/pkg/ssh"
+	"go.uber.org/zap"
+)
+
+// maxUploadChunkSize 单个上传分片的大小上限
+const maxUploadChunkSize = 64 << 20
+
+// RenameFileRequest 重命名/移动文件请求
+type RenameFileRequest struct {
+	OldPath string `json:"oldPath" binding:"required"`
+	NewPath string `json:"newPath" binding:"required"`
+}
+
+// ChmodFileRequest 修改文件权限请求
+type ChmodFileRequest struct {
+	Path      string `json:"path" binding:"required"`
+	Mode      string `json:"mode" binding:"required"` // 八进制权限，如 755
+	Recursive bool   `json:"recursive"`
+}
+
+// ChownFileRequest 修改文件属主请求
+type ChownFileRequest struct {
+	Path      string `json:"path" binding:"required"`
+	Owner     string `json:"owner"` // 用户名或UID，为空时不修改
+	Group     string `json:"group"` // 组名或GID，为空时不修改
+	Recursive bool   `json:"recursive"`
+}
+
+// SaveFileContentRequest 保存在线编辑的文件请求
+type SaveFileContentRequest struct {
+	Path    string `json:"path" binding:"required"`
+	Content string `json:"content"`
+	MTime   int64  `json:"mtime"` // 读取文件时返回的修改时间
+}
+
+// SetFileTransferLogUseCase 设置文件传输日志用例（通过依赖注入）
+func (s *HostService) SetFileTransferLogUseCase(fileTransferLogUseCase *audit.FileTransferLogUseCase) {
+	s.fileTransferLogUseCase = fileTransferLogUseCase
+}
+
+// recordFileTransfer 记录文件传输日志，删除操作的 size 和 sha256 为空
+func (s *HostService) recordFileTransfer(c *gin.Context, hostID uint, direction, remotePath string, size int64, sha256 string, err error) {
+	if s.fileTransferLogUseCase == nil {
+		return
+	}
+
+	log := &audit.SysFileTransferLog{
+		UserID:     rbacService.GetUserID(c),
+		Username:   rbacService.GetUsername(c),
+		HostID:     hostID,
+		Direction:  direction,
+		Source:     audit.TransferSourceFileManager,
+		RemotePath: remotePath,
+		FileSize:   size,
+		SHA256:     sha256,
+		Status:     audit.TransferSuccess,
+		IP:         c.ClientIP(),
+	}
+	if err != nil {
+		log.Status = audit.TransferFailed
+		log.ErrorMsg = err.Error()
+	}
+
+	// 异步保存文件传输日志
+	go func() {
+		ctx := context.Background()
+		if host, err := s.hostUseCase.GetByID(ctx, hostID); err == nil {
+			log.HostName = host.Name
+			log.HostIP = host.IP
+		}
+		if err := s.fileTransferLogUseCase.Create(ctx, log); err != nil {
+			appLogger.Error("保存文件传输日志失败",
+				zap.Error(err),
+				zap.Uint("hostId", hostID),
+				zap.String("path", remotePath),
+			)
+		}
+	}()
+}
+
+// parseHostID 解析路径中的主机ID
+func parseHostID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "无效的主机ID")
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// RenameHostFile 重命名或移动主机文件
+// @Summary 重命名主机文件
+// @Description 重命名或移动指定主机上的文件或目录，目标已存在时失败
+// @Tags 资产管理-主机文件
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "主机ID"
+// @Param body body RenameFileRequest true "原路径和新路径"
+// @Success 200 {object} response.Response "重命名成功"
+// @Failure 400 {object} response.Response "参数错误"
+// @Router /api/v1/hosts/{id}/files/rename [put]
+func (s *HostService) RenameHostFile(c *gin.Context) {
+	id, ok := parseHostID(c)
+	if !ok {
+		return
+	}
+
+	var req RenameFileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "参数错误: "+err.Error())
+		return
+	}
+
+	if err := s.hostUseCase.RenameFile(c.Request.Context(), id, req.OldPath, req.NewPath); err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "重命名失败: "+err.Error())
+		return
+	}
+
+	response.SuccessWithMessage(c, "重命名成功", nil)
+}
+
+// ChmodHostFile 修改主机文件权限
+// @Summary 修改主机文件权限
+// @Description 修改指定主机上文件或目录的权限，可递归修改目录下的所有文件
+// @Tags 资产管理-主机文件
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "主机ID"
+// @Param body body ChmodFileRequest true "路径和权限"
+// @Success 200 {object} response.Response "修改成功"
+// @Failure 400 {object} response.Response "参数错误"
+// @Router /api/v1/hosts/{id}/files/chmod [put]
+func (s *HostService) ChmodHostFile(c *gin.Context) {
+	id, ok := parseHostID(c)
+	if !ok {
+		return
+	}
+
+	var req ChmodFileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "参数错误: "+err.Error())
+		return
+	}
+
+	if err := s.hostUseCase.ChmodFile(c.Request.Context(), id, req.Path, req.Mode, req.Recursive); err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "修改权限失败: "+err.Error())
+		return
+	}
+
+	response.SuccessWithMessage(c, "权限修改成功", nil)
+}
+
+// ChownHostFile 修改主机文件属主
+// @Summary 修改主机文件属主
+// @Description 修改指定主机上文件或目录的属主和属组，可递归修改目录下的所有文件
+// @Tags 资产管理-主机文件
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "主机ID"
+// @Param body body ChownFileRequest true "路径、属主和属组"
+// @Success 200 {object} response.Response "修改成功"
+// @Failure 400 {object} response.Response "参数错误"
+// @Router /api/v1/hosts/{id}/files/chown [put]
+func (s *HostService) ChownHostFile(c *gin.Context) {
+	id, ok := parseHostID(c)
+	if !ok {
+		return
+	}
+
+	var req ChownFileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "参数错误: "+err.Error())
+		return
+	}
+
+	if err := s.hostUseCase.ChownFile(c.Request.Context(), id, req.Path, req.Owner, req.Group, req.Recursive); err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "修改属主失败: "+err.Error())
+		return
+	}
+
+	response.SuccessWithMessage(c, "属主修改成功", nil)
+}
+
+// DownloadHostArchive 打包下载主机目录
+// @Summary 打包下载主机目录
+// @Description 将指定主机上的目录打包为 tar.gz 或 zip 流式下载
+// @Tags 资产管理-主机文件
+// @Accept json
+// @Produce application/octet-stream
+// @Security Bearer
+// @Param id path int true "主机ID"
+// @Param path query string true "目录路径"
+// @Param format query string false "打包格式：tar.gz、zip" default(tar.gz)
+// @Success 200 {file} file "打包文件"
+// @Failure 400 {object} response.Response "参数错误"
+// @Router /api/v1/hosts/{id}/files/archive [get]
+func (s *HostService) DownloadHostArchive(c *gin.Context) {
+	id, ok := parseHostID(c)
+	if !ok {
+		return
+	}
+
+	remotePath := strings.TrimSuffix(c.Query("path"), "/")
+	if remotePath == "" {
+		response.ErrorCode(c, http.StatusBadRequest, "请指定目录路径")
+		return
+	}
+	format := c.DefaultQuery("format", sshclient.ArchiveTarGz)
+	if format != sshclient.ArchiveTarGz && format != sshclient.ArchiveZip {
+		response.ErrorCode(c, http.StatusBadRequest, "不支持的打包格式: "+format)
+		return
+	}
+
+	name := path.Base(remotePath)
+	if name == "~" || name == "/" || name == "." {
+		name = "home"
+	}
+	contentType := "application/gzip"
+	if format == sshclient.ArchiveZip {
+		contentType = "application/zip"
+	}
+	writer := &attachmentWriter{c: c, filename: name + "." + format, contentType: contentType}
+
+	// 打包内容同时计算哈希用于审计
+	digest := audit.NewTransferDigest()
+	err := s.hostUseCase.ArchiveDir(c.Request.Context(), id, remotePath, format, io.MultiWriter(writer, digest))
+	s.recordFileTransfer(c, id, audit.TransferDownload, remotePath, digest.Size(), digest.Sum(), err)
+	if err != nil {
+		if !writer.started {
+			response.ErrorCode(c, http.StatusInternalServerError, "打包下载失败: "+err.Error())
+			return
+		}
+		// 已开始输出，只能中断下载
+		c.Error(err)
+		c.Abort()
+	}
+}
+
+// attachmentWriter 首次写入时才设置下载响应头，出错时若尚未输出仍可返回错误信息
+type attachmentWriter struct {
+	c           *gin.Context
+	filename    string
+	contentType string
+	started     bool
+}
+
+func (w *attachmentWriter) Write(p []byte) (int, error) {
+	if !w.started {
+		w.started = true
+		w.c.Header("Content-Type", w.contentType)
+		w.c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"; filename*=UTF-8''%s", w.filename, url.PathEscape(w.filename)))
+		w.c.Status(http.StatusOK)
+	}
+	return w.c.Writer.Write(p)
+}
+
+// GetHostUploadStatus 查询分片上传进度
+// @Summary 查询分片上传进度
+// @Description 查询未完成的分片上传已上传的字节数，用于断点续传
+// @Tags 资产管理-主机文件
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "主机ID"
+// @Param path query string true "远程目录路径"
+// @Param filename query string true "文件名"
+// @Success 200 {object} response.Response{data=asset.ChunkUploadStatus} "获取成功"
+// @Failure 400 {object} response.Response "参数错误"
+// @Router /api/v1/hosts/{id}/files/upload/status [get]
+func (s *HostService) GetHostUploadStatus(c *gin.Context) {
+	id, ok := parseHostID(c)
+	if !ok {
+		return
+	}
+
+	remoteDir, filename, ok := uploadTarget(c)
+	if !ok {
+		return
+	}
+
+	status, err := s.hostUseCase.UploadStatus(c.Request.Context(), id, remoteDir, filename)
+	if err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "查询上传进度失败: "+err.Error())
+		return
+	}
+
+	response.Success(c, status)
+}
+
+// UploadHostFileChunk 分片上传文件到主机
+// @Summary 分片上传文件到主机
+// @Description 将文件分片写入主机上的临时文件，全部上传后重命名为目标文件；偏移与已上传大小不一致时返回409，需查询进度后续传
+// @Tags 资产管理-主机文件
+// @Accept application/octet-stream
+// @Produce json
+// @Security Bearer
+// @Param id path int true "主机ID"
+// @Param path query string true "远程目录路径"
+// @Param filename query string true "文件名"
+// @Param offset query int true "分片在文件中的偏移"
+// @Param total query int true "文件总大小"
+// @Success 200 {object} response.Response{data=asset.ChunkUploadStatus} "上传成功"
+// @Failure 400 {object} response.Response "参数错误"
+// @Router /api/v1/hosts/{id}/files/upload/chunk [post]
+func (s *HostService) UploadHostFileChunk(c *gin.Context) {
+	id, ok := parseHostID(c)
+	if !ok {
+		return
+	}
+
+	remoteDir, filename, ok := uploadTarget(c)
+	if !ok {
+		return
+	}
+	offset, err := strconv.ParseInt(c.Query("offset"), 10, 64)
+	if err != nil || offset < 0 {
+		response.ErrorCode(c, http.StatusBadRequest, "无效的分片偏移")
+		return
+	}
+	total, err := strconv.ParseInt(c.Query("total"), 10, 64)
+	if err != nil || total < offset {
+		response.ErrorCode(c, http.StatusBadRequest, "无效的文件大小")
+		return
+	}
+
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, maxUploadChunkSize)
+	status, err := s.hostUseCase.UploadChunk(c.Request.Context(), id, body, remoteDir, filename, offset, total)
+	if err != nil {
+		if errors.Is(err, asset.ErrChunkOffset) {
+			response.ErrorCode(c, http.StatusConflict, fmt.Sprintf("%s，已上传 %d 字节", err.Error(), status.Uploaded))
+			return
+		}
+		s.recordFileTransfer(c, id, audit.TransferUpload, path.Join(remoteDir, filename), status.Uploaded, "", err)
+		response.ErrorCode(c, http.StatusInternalServerError, "上传分片失败: "+err.Error())
+		return
+	}
+	// 全部分片上传完成后记录一次，哈希为完整文件的哈希
+	if status.Completed {
+		s.recordFileTransfer(c, id, audit.TransferUpload, path.Join(remoteDir, filename), total, status.SHA256, nil)
+	}
+
+	response.Success(c, status)
+}
+
+// uploadTarget 解析分片上传的目录和文件名
+func uploadTarget(c *gin.Context) (string, string, bool) {
+	remoteDir := c.Query("path")
+	if remoteDir == "" {
+		remoteDir = "~/"
+	}
+	filename := c.Query("filename")
+	if filename == "" || filename != path.Base(filename) || filename == "." || filename == ".." {
+		response.ErrorCode(c, http.StatusBadRequest, "无效的文件名")
+		return "", "", false
+	}
+	return remoteDir, filename, true
+}
+
+// GetHostFileContent 读取主机文本文件
+// @Summary 读取主机文本文件
+// @Description 读取指定主机上的文本文件用于在线编辑，返回的修改时间在保存时原样提交
+// @Tags 资产管理-主机文件
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "主机ID"
+// @Param path query string true "文件路径"
+// @Success 200 {object} response.Response{data=asset.FileContent} "获取成功"
+// @Failure 400 {object} response.Response "参数错误"
+// @Router /api/v1/hosts/{id}/files/content [get]
+func (s *HostService) GetHostFileContent(c *gin.Context) {
+	id, ok := parseHostID(c)
+	if !ok {
+		return
+	}
+
+	remotePath := c.Query("path")
+	if remotePath == "" {
+		response.ErrorCode(c, http.StatusBadRequest, "请指定文件路径")
+		return
+	}
+
+	content, err := s.hostUseCase.ReadFileContent(c.Request.Context(), id, remotePath)
+	digest := audit.NewTransferDigest()
+	if content != nil {
+		io.WriteString(digest, content.Content)
+	}
+	s.recordFileTransfer(c, id, audit.TransferDownload, remotePath, digest.Size(), digest.Sum(), err)
+	if err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "读取文件失败: "+err.Error())
+		return
+	}
+
+	response.Success(c, content)
+}
+
+// SaveHostFileContent 保存主机文本文件
+// @Summary 保存主机文本文件
+// @Description 保存在线编辑的文件，文件在读取后已被修改时返回409
+// @Tags 资产管理-主机文件
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "主机ID"
+// @Param body body SaveFileContentRequest true "文件路径、内容和读取时的修改时间"
+// @Success 200 {object} response.Response{data=asset.FileContent} "保存成功"
+// @Failure 400 {object} response.Response "参数错误"
+// @Router /api/v1/hosts/{id}/files/content [put]
+func (s *HostService) SaveHostFileContent(c *gin.Context) {
+	id, ok := parseHostID(c)
+	if !ok {
+		return
+	}
+
+	var req SaveFileContentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "参数错误: "+err.Error())
+		return
+	}
+
+	saved, err := s.hostUseCase.SaveFileContent(c.Request.Context(), id, req.Path, req.Content, req.MTime)
+	if !errors.Is(err, asset.ErrFileModified) {
+		digest := audit.NewTransferDigest()
+		io.WriteString(digest, req.Content)
+		s.recordFileTransfer(c, id, audit.TransferUpload, req.Path, digest.Size(), digest.Sum(), err)
+	}
+	if err != nil {
+		if errors.Is(err, asset.ErrFileModified) {
+			response.ErrorCode(c, http.StatusConflict, err.Error())
+			return
+		}
+		response.ErrorCode(c, http.StatusInternalServerError, "保存文件失败: "+err.Error())
+		return
+	}
+
+	response.SuccessWithMessage(c, "文件保存成功", saved)
+}
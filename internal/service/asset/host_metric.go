@@ -0,0 +1,215 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package asset
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ydcloud-dy/opshub/internal/biz/asset"
+	rbacService "github.com/ydcloud-dy/opshub/internal/service/rbac"
+	"github.com/ydcloud-dy/opshub/pkg/response"
+)
+
+const (
+	// maxMetricQueryRange 单次查询的最大时间范围
+	maxMetricQueryRange = 366 * 24 * time.Hour
+	// maxCompareHosts 多主机对比的最大主机数
+	maxCompareHosts = 20
+)
+
+// metricResolutions 查询参数中的粒度名称
+var metricResolutions = map[string]int{
+	"raw": asset.MetricResolutionRaw,
+	"5m":  asset.MetricResolutionMinute,
+	"1h":  asset.MetricResolutionHour,
+}
+
+// SetHostMetricUseCase 设置主机监控指标用例（通过依赖注入）
+func (s *HostService) SetHostMetricUseCase(hostMetricUseCase *asset.HostMetricUseCase) {
+	s.hostMetricUseCase = hostMetricUseCase
+}
+
+// parseTimeRange 解析查询的时间范围，start/end 为秒级时间戳，默认查询结束时间前 defaultRange
+func parseTimeRange(c *gin.Context, defaultRange time.Duration) (start, end time.Time, ok bool) {
+	end = time.Now()
+	if v := c.Query("end"); v != "" {
+		ts, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			response.ErrorCode(c, http.StatusBadRequest, "结束时间格式错误")
+			return
+		}
+		end = time.Unix(ts, 0)
+	}
+	start = end.Add(-defaultRange)
+	if v := c.Query("start"); v != "" {
+		ts, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			response.ErrorCode(c, http.StatusBadRequest, "开始时间格式错误")
+			return
+		}
+		start = time.Unix(ts, 0)
+	}
+	if !start.Before(end) {
+		response.ErrorCode(c, http.StatusBadRequest, "开始时间必须早于结束时间")
+		return
+	}
+	if end.Sub(start) > maxMetricQueryRange {
+		response.ErrorCode(c, http.StatusBadRequest, "查询时间范围不能超过一年")
+		return
+	}
+	return start, end, true
+}
+
+// parseMetricRange 解析查询的时间范围和粒度，默认查询最近1小时
+func (s *HostService) parseMetricRange(c *gin.Context) (start, end time.Time, resolution int, ok bool) {
+	start, end, ok = parseTimeRange(c, time.Hour)
+	if !ok {
+		return
+	}
+
+	if v := c.Query("resolution"); v != "" {
+		r, exists := metricResolutions[v]
+		if !exists {
+			response.ErrorCode(c, http.StatusBadRequest, "粒度只能为 raw、5m 或 1h")
+			return start, end, 0, false
+		}
+		resolution = r
+	} else {
+		resolution = s.hostMetricUseCase.ResolveResolution(start, end)
+	}
+	return start, end, resolution, true
+}
+
+// GetHostMetrics 获取主机监控指标历史
+// @Summary 获取主机监控指标历史
+// @Description 查询主机在时间范围内的CPU、内存、各磁盘、负载和网络吞吐时序数据，未指定粒度时根据时间范围自动选择
+// @Tags 资产管理-主机监控
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "主机ID"
+// @Param start query int false "开始时间(秒级时间戳)，默认为结束时间前1小时"
+// @Param end query int false "结束时间(秒级时间戳)，默认为当前时间"
+// @Param resolution query string false "粒度 raw/5m/1h"
+// @Success 200 {object} response.Response "获取成功"
+// @Failure 400 {object} response.Response "参数错误"
+// @Router /api/v1/hosts/{id}/metrics [get]
+func (s *HostService) GetHostMetrics(c *gin.Context) {
+	id, ok := parseHostID(c)
+	if !ok {
+		return
+	}
+	start, end, resolution, ok := s.parseMetricRange(c)
+	if !ok {
+		return
+	}
+
+	metrics, err := s.hostMetricUseCase.Query(c.Request.Context(), id, resolution, start, end)
+	if err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "查询监控指标失败: "+err.Error())
+		return
+	}
+
+	response.Success(c, gin.H{
+		"hostId":     id,
+		"resolution": resolution,
+		"start":      start.Unix(),
+		"end":        end.Unix(),
+		"list":       metrics,
+	})
+}
+
+// CompareHostMetrics 多主机监控指标对比
+// @Summary 多主机监控指标对比
+// @Description 查询多台主机同一指标在时间范围内的序列，只返回当前用户有权限的主机
+// @Tags 资产管理-主机监控
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param hostIds query string true "主机ID列表，逗号分隔，最多20台"
+// @Param metric query string false "指标 cpu/cpuMax/memory/disk/load1/load5/load15/netRx/netTx" default(cpu)
+// @Param start query int false "开始时间(秒级时间戳)，默认为结束时间前1小时"
+// @Param end query int false "结束时间(秒级时间戳)，默认为当前时间"
+// @Param resolution query string false "粒度 raw/5m/1h"
+// @Success 200 {object} response.Response "获取成功"
+// @Failure 400 {object} response.Response "参数错误"
+// @Router /api/v1/hosts/metrics/compare [get]
+func (s *HostService) CompareHostMetrics(c *gin.Context) {
+	metric := c.DefaultQuery("metric", "cpu")
+	if !asset.IsValidMetric(metric) {
+		response.ErrorCode(c, http.StatusBadRequest, "不支持的指标: "+metric)
+		return
+	}
+
+	var hostIDs []uint
+	for _, v := range strings.Split(c.Query("hostIds"), ",") {
+		id, err := strconv.ParseUint(strings.TrimSpace(v), 10, 32)
+		if err != nil {
+			continue
+		}
+		if !slices.Contains(hostIDs, uint(id)) {
+			hostIDs = append(hostIDs, uint(id))
+		}
+	}
+	if len(hostIDs) == 0 {
+		response.ErrorCode(c, http.StatusBadRequest, "请选择要对比的主机")
+		return
+	}
+	if len(hostIDs) > maxCompareHosts {
+		response.ErrorCode(c, http.StatusBadRequest, "最多同时对比20台主机")
+		return
+	}
+
+	// 过滤掉当前用户无权访问的主机
+	if userID := rbacService.GetUserID(c); userID > 0 {
+		accessible, err := s.assetPermissionUseCase.GetUserAccessibleHostIDs(c.Request.Context(), userID)
+		if err != nil {
+			response.ErrorCode(c, http.StatusInternalServerError, "获取主机权限失败: "+err.Error())
+			return
+		}
+		hostIDs = slices.DeleteFunc(hostIDs, func(id uint) bool {
+			return !slices.Contains(accessible, id)
+		})
+	}
+
+	start, end, resolution, ok := s.parseMetricRange(c)
+	if !ok {
+		return
+	}
+
+	series, err := s.hostMetricUseCase.Compare(c.Request.Context(), hostIDs, metric, resolution, start, end)
+	if err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "查询监控指标失败: "+err.Error())
+		return
+	}
+
+	response.Success(c, gin.H{
+		"metric":     metric,
+		"resolution": resolution,
+		"start":      start.Unix(),
+		"end":        end.Unix(),
+		"series":     series,
+	})
+}
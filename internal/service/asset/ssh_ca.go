@@ -0,0 +1,198 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package asset
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ydcloud-dy/opshub/internal/biz/asset"
+	rbacService "github.com/ydcloud-dy/opshub/internal/service/rbac"
+	"github.com/ydcloud-dy/opshub/pkg/response"
+)
+
+// SetSSHCAUseCase 设置SSH证书签发用例（通过依赖注入）
+func (s *HostService) SetSSHCAUseCase(sshCAUseCase *asset.SSHCAUseCase) {
+	s.sshCAUseCase = sshCAUseCase
+}
+
+// CreateSSHCARequest 生成SSH CA请求
+type CreateSSHCARequest struct {
+	Name     string `json:"name" binding:"max=100"`
+	Activate bool   `json:"activate"` // 立即用于签发，当前没有启用的CA时总是启用
+}
+
+// ListSSHCAs SSH CA列表
+// @Summary 获取SSH CA列表
+// @Description 获取所有SSH证书签发机构及其公钥，启用的CA用于签发证书
+// @Tags 资产管理-SSH证书
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} response.Response "获取成功"
+// @Router /api/v1/ssh-ca [get]
+func (s *HostService) ListSSHCAs(c *gin.Context) {
+	authorities, err := s.sshCAUseCase.ListAuthorities(c.Request.Context())
+	if err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "查询失败: "+err.Error())
+		return
+	}
+
+	response.Success(c, authorities)
+}
+
+// CreateSSHCA 生成SSH CA
+// @Summary 生成SSH CA
+// @Description 生成新的 ed25519 CA 密钥，私钥加密保存在平台。主机需要在 sshd 的 TrustedUserCAKeys 中信任其公钥，并通过 AuthorizedPrincipalsFile 为登录用户配置接受的角色 principal 后才能使用证书凭证登录
+// @Tags 资产管理-SSH证书
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param body body CreateSSHCARequest true "CA信息"
+// @Success 200 {object} response.Response "生成成功"
+// @Router /api/v1/ssh-ca [post]
+func (s *HostService) CreateSSHCA(c *gin.Context) {
+	var req CreateSSHCARequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "参数错误: "+err.Error())
+		return
+	}
+
+	authority, err := s.sshCAUseCase.GenerateAuthority(c.Request.Context(), req.Name, rbacService.GetUsername(c), req.Activate)
+	if err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "生成失败: "+err.Error())
+		return
+	}
+
+	response.Success(c, authority)
+}
+
+// ActivateSSHCA 启用SSH CA
+// @Summary 启用SSH CA
+// @Description 切换用于签发证书的CA，切换前需确认所有主机已信任新CA的公钥
+// @Tags 资产管理-SSH证书
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "CA ID"
+// @Success 200 {object} response.Response "启用成功"
+// @Router /api/v1/ssh-ca/{id}/activate [post]
+func (s *HostService) ActivateSSHCA(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "无效的CA ID")
+		return
+	}
+
+	if err := s.sshCAUseCase.ActivateAuthority(c.Request.Context(), uint(id)); err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	response.SuccessWithMessage(c, "启用成功", nil)
+}
+
+// DeleteSSHCA 删除SSH CA
+// @Summary 删除SSH CA
+// @Description 删除不再使用的CA，正在用于签发的CA不能删除。删除后应从主机的 TrustedUserCAKeys 中移除其公钥
+// @Tags 资产管理-SSH证书
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "CA ID"
+// @Success 200 {object} response.Response "删除成功"
+// @Router /api/v1/ssh-ca/{id} [delete]
+func (s *HostService) DeleteSSHCA(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "无效的CA ID")
+		return
+	}
+
+	if err := s.sshCAUseCase.DeleteAuthority(c.Request.Context(), uint(id)); err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	response.SuccessWithMessage(c, "删除成功", nil)
+}
+
+// GetSSHCATrustedKeys 主机信任的CA公钥
+// @Summary 下载CA公钥文件
+// @Description 返回所有CA公钥，每行一个，可直接保存为主机 sshd 的 TrustedUserCAKeys 文件
+// @Tags 资产管理-SSH证书
+// @Produce plain
+// @Security Bearer
+// @Success 200 {string} string "CA公钥"
+// @Router /api/v1/ssh-ca/trusted-keys [get]
+func (s *HostService) GetSSHCATrustedKeys(c *gin.Context) {
+	keys, err := s.sshCAUseCase.TrustedKeys(c.Request.Context())
+	if err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "查询失败: "+err.Error())
+		return
+	}
+
+	c.Header("Content-Disposition", "attachment; filename=opshub_user_ca.pub")
+	c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(keys))
+}
+
+// ListSSHCertificates 证书签发记录
+// @Summary 获取证书签发记录
+// @Description 分页获取SSH证书签发记录，可按主机、用户、用途筛选
+// @Tags 资产管理-SSH证书
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param hostId query int false "主机ID"
+// @Param userId query int false "用户ID"
+// @Param purpose query string false "用途 system/terminal/task/ansible"
+// @Param keyword query string false "用户名、主机名或IP"
+// @Param page query int false "页码" default(1)
+// @Param pageSize query int false "每页数量" default(20)
+// @Success 200 {object} response.Response "获取成功"
+// @Router /api/v1/ssh-ca/certificates [get]
+func (s *HostService) ListSSHCertificates(c *gin.Context) {
+	hostID, _ := strconv.ParseUint(c.Query("hostId"), 10, 32)
+	userID, _ := strconv.ParseUint(c.Query("userId"), 10, 32)
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
+
+	query := &asset.SSHCertificateQuery{
+		HostID:   uint(hostID),
+		UserID:   uint(userID),
+		Purpose:  c.Query("purpose"),
+		Keyword:  c.Query("keyword"),
+		Page:     page,
+		PageSize: pageSize,
+	}
+	certs, total, err := s.sshCAUseCase.ListCertificates(c.Request.Context(), query)
+	if err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "查询失败: "+err.Error())
+		return
+	}
+
+	response.Success(c, gin.H{
+		"list":     certs,
+		"total":    total,
+		"page":     query.Page,
+		"pageSize": query.PageSize,
+	})
+}
@@ -0,0 +1,224 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package audit
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xuri/excelize/v2"
+	"github.com/ydcloud-dy/opshub/internal/biz/audit"
+	"github.com/ydcloud-dy/opshub/pkg/response"
+)
+
+// maxFileTransferExportRows 单次导出的最大记录数
+const maxFileTransferExportRows = 10000
+
+var transferDirectionText = map[string]string{
+	audit.TransferUpload:   "上传",
+	audit.TransferDownload: "下载",
+	audit.TransferDelete:   "删除",
+}
+
+var transferSourceText = map[string]string{
+	audit.TransferSourceFileManager: "文件管理",
+	audit.TransferSourceDistribute:  "文件分发",
+}
+
+type FileTransferLogService struct {
+	useCase *audit.FileTransferLogUseCase
+}
+
+func NewFileTransferLogService(useCase *audit.FileTransferLogUseCase) *FileTransferLogService {
+	return &FileTransferLogService{
+		useCase: useCase,
+	}
+}
+
+// fileTransferLogQuery 从请求参数构造查询条件
+func fileTransferLogQuery(c *gin.Context) *audit.FileTransferLogQuery {
+	hostID, _ := strconv.ParseUint(c.Query("hostId"), 10, 32)
+	return &audit.FileTransferLogQuery{
+		Username:   c.Query("username"),
+		HostID:     uint(hostID),
+		Host:       c.Query("host"),
+		Direction:  c.Query("direction"),
+		Status:     c.Query("status"),
+		RemotePath: c.Query("remotePath"),
+		SHA256:     c.Query("sha256"),
+		StartTime:  c.Query("startTime"),
+		EndTime:    c.Query("endTime"),
+	}
+}
+
+// ListFileTransferLogs 文件传输日志列表
+// @Summary 获取文件传输日志列表
+// @Description 分页获取主机文件上传、下载和删除记录，支持按用户、主机、方向、路径、内容哈希和时间范围筛选
+// @Tags 审计管理-文件传输日志
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param page query int false "页码" default(1)
+// @Param pageSize query int false "每页数量" default(10)
+// @Param username query string false "用户名"
+// @Param hostId query int false "主机ID"
+// @Param host query string false "主机名称或IP"
+// @Param direction query string false "传输方向：upload、download、delete"
+// @Param status query string false "结果：success、failed"
+// @Param remotePath query string false "远程路径"
+// @Param sha256 query string false "内容SHA-256"
+// @Param startTime query string false "开始时间"
+// @Param endTime query string false "结束时间"
+// @Success 200 {object} response.Response "获取成功"
+// @Router /api/v1/audit/file-transfer-logs [get]
+func (s *FileTransferLogService) ListFileTransferLogs(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "10"))
+
+	logs, total, err := s.useCase.List(c.Request.Context(), page, pageSize, fileTransferLogQuery(c))
+	if err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "查询失败: "+err.Error())
+		return
+	}
+
+	response.Success(c, gin.H{
+		"list":     logs,
+		"page":     page,
+		"pageSize": pageSize,
+		"total":    total,
+	})
+}
+
+// GetFileTransferLog 获取文件传输日志详情
+// @Summary 获取文件传输日志详情
+// @Description 获取单条文件传输日志的详细信息
+// @Tags 审计管理-文件传输日志
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "文件传输日志ID"
+// @Success 200 {object} response.Response "获取成功"
+// @Failure 404 {object} response.Response "日志不存在"
+// @Router /api/v1/audit/file-transfer-logs/{id} [get]
+func (s *FileTransferLogService) GetFileTransferLog(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "无效的日志ID")
+		return
+	}
+
+	log, err := s.useCase.GetByID(c.Request.Context(), uint(id))
+	if err != nil {
+		response.ErrorCode(c, http.StatusNotFound, "日志不存在")
+		return
+	}
+
+	response.Success(c, log)
+}
+
+// ExportFileTransferLogs 导出文件传输日志
+// @Summary 导出文件传输日志
+// @Description 按筛选条件导出文件传输日志为Excel，最多导出10000条
+// @Tags 审计管理-文件传输日志
+// @Accept json
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Security Bearer
+// @Param username query string false "用户名"
+// @Param hostId query int false "主机ID"
+// @Param host query string false "主机名称或IP"
+// @Param direction query string false "传输方向：upload、download、delete"
+// @Param status query string false "结果：success、failed"
+// @Param remotePath query string false "远程路径"
+// @Param sha256 query string false "内容SHA-256"
+// @Param startTime query string false "开始时间"
+// @Param endTime query string false "结束时间"
+// @Success 200 {file} file "Excel文件"
+// @Router /api/v1/audit/file-transfer-logs/export [get]
+func (s *FileTransferLogService) ExportFileTransferLogs(c *gin.Context) {
+	logs, _, err := s.useCase.List(c.Request.Context(), 1, maxFileTransferExportRows, fileTransferLogQuery(c))
+	if err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "查询失败: "+err.Error())
+		return
+	}
+
+	f := excelize.NewFile()
+	sheetName := f.GetSheetName(0)
+
+	headers := []string{"时间", "用户", "主机", "主机IP", "方向", "来源", "远程路径", "大小(字节)", "SHA-256", "结果", "错误信息", "客户端IP"}
+	headerStyle, _ := f.NewStyle(&excelize.Style{
+		Font: &excelize.Font{
+			Bold: true,
+		},
+		Fill: excelize.Fill{
+			Type:    "pattern",
+			Color:   []string{"#E6E6FA"},
+			Pattern: 1,
+		},
+	})
+	f.SetColWidth(sheetName, "A", "F", 18)
+	f.SetColWidth(sheetName, "G", "G", 50)
+	f.SetColWidth(sheetName, "H", "H", 14)
+	f.SetColWidth(sheetName, "I", "I", 68)
+	f.SetColWidth(sheetName, "J", "L", 18)
+
+	for i, header := range headers {
+		cell, _ := excelize.CoordinatesToCellName(i+1, 1)
+		f.SetCellValue(sheetName, cell, header)
+		f.SetCellStyle(sheetName, cell, cell, headerStyle)
+	}
+
+	for i, log := range logs {
+		status := "成功"
+		if log.Status != audit.TransferSuccess {
+			status = "失败"
+		}
+		row := []any{
+			log.CreatedAt.Format("2006-01-02 15:04:05"),
+			log.Username,
+			log.HostName,
+			log.HostIP,
+			transferDirectionText[log.Direction],
+			transferSourceText[log.Source],
+			log.RemotePath,
+			log.FileSize,
+			log.SHA256,
+			status,
+			log.ErrorMsg,
+			log.IP,
+		}
+		cell, _ := excelize.CoordinatesToCellName(1, i+2)
+		f.SetSheetRow(sheetName, cell, &row)
+	}
+
+	var buf bytes.Buffer
+	if err := f.Write(&buf); err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "生成导出文件失败")
+		return
+	}
+
+	filename := fmt.Sprintf("file_transfer_logs_%s.xlsx", time.Now().Format("20060102150405"))
+	c.Header("Content-Disposition", "attachment; filename="+filename)
+	c.Data(http.StatusOK, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", buf.Bytes())
+}
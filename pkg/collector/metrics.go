@@ -0,0 +1,231 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package collector
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// metricsSampleSeconds 计算CPU使用率和网络吞吐时两次读取之间的间隔
+const metricsSampleSeconds = 1
+
+// metricsSection 分隔 metricsCommand 各段输出
+const metricsSection = "--opshub-metrics--"
+
+// metricsCommand 一次SSH执行采集全部时序指标，CPU和网卡计数器读取两次用于计算差值
+var metricsCommand = strings.Join([]string{
+	"head -1 /proc/stat",
+	"echo " + metricsSection,
+	"cat /proc/net/dev",
+	"echo " + metricsSection,
+	fmt.Sprintf("sleep %d", metricsSampleSeconds),
+	"head -1 /proc/stat",
+	"echo " + metricsSection,
+	"cat /proc/net/dev",
+	"echo " + metricsSection,
+	"cat /proc/loadavg",
+	"echo " + metricsSection,
+	"cat /proc/meminfo",
+	"echo " + metricsSection,
+	"df -P -B1 -x tmpfs -x devtmpfs -x overlay -x squashfs 2>/dev/null",
+}, "; ")
+
+// MetricSample 一次时序指标采样
+type MetricSample struct {
+	CPUUsage    float64     `json:"cpuUsage"`    // CPU使用率百分比
+	MemoryTotal uint64      `json:"memoryTotal"` // 总内存(字节)
+	MemoryUsed  uint64      `json:"memoryUsed"`  // 已用内存(字节)，不含缓存
+	MemoryUsage float64     `json:"memoryUsage"` // 内存使用率百分比
+	Load1       float64     `json:"load1"`       // 1分钟平均负载
+	Load5       float64     `json:"load5"`       // 5分钟平均负载
+	Load15      float64     `json:"load15"`      // 15分钟平均负载
+	NetRxRate   float64     `json:"netRxRate"`   // 网络接收速率(字节/秒)，不含回环网卡
+	NetTxRate   float64     `json:"netTxRate"`   // 网络发送速率(字节/秒)，不含回环网卡
+	Disks       []DiskUsage `json:"disks"`       // 各挂载点磁盘使用情况
+}
+
+// DiskUsage 单个挂载点的磁盘使用情况
+type DiskUsage struct {
+	MountPoint string  `json:"mountPoint"` // 挂载点
+	Total      uint64  `json:"total"`      // 总容量(字节)
+	Used       uint64  `json:"used"`       // 已用(字节)
+	Usage      float64 `json:"usage"`      // 使用率百分比
+}
+
+// CollectMetrics 采集CPU、内存、各磁盘、负载和网络吞吐等时序指标
+// 与 CollectAll 不同，只执行一次远程命令，适合定时采集
+func (c *Collector) CollectMetrics() (*MetricSample, error) {
+	output, err := c.sshClient.ExecuteWithTimeout(metricsCommand, c.timeout)
+	if err != nil {
+		return nil, fmt.Errorf("采集监控指标失败: %w", err)
+	}
+	return parseMetrics(output)
+}
+
+// parseMetrics 解析 metricsCommand 的输出
+func parseMetrics(output string) (*MetricSample, error) {
+	sections := strings.Split(output, metricsSection+"\n")
+	if len(sections) != 7 {
+		return nil, fmt.Errorf("监控指标输出格式错误")
+	}
+
+	sample := &MetricSample{}
+
+	// CPU使用率：两次 /proc/stat 之间非空闲时间的占比
+	busy1, total1 := parseCPUStat(sections[0])
+	busy2, total2 := parseCPUStat(sections[2])
+	if total2 > total1 && busy2 >= busy1 {
+		sample.CPUUsage = float64(busy2-busy1) / float64(total2-total1) * 100
+	}
+
+	// 网络吞吐：两次网卡计数器的差值，计数器回绕时记为0
+	rx1, tx1 := parseNetDev(sections[1])
+	rx2, tx2 := parseNetDev(sections[3])
+	if rx2 >= rx1 {
+		sample.NetRxRate = float64(rx2-rx1) / metricsSampleSeconds
+	}
+	if tx2 >= tx1 {
+		sample.NetTxRate = float64(tx2-tx1) / metricsSampleSeconds
+	}
+
+	if fields := strings.Fields(sections[4]); len(fields) >= 3 {
+		sample.Load1, _ = strconv.ParseFloat(fields[0], 64)
+		sample.Load5, _ = strconv.ParseFloat(fields[1], 64)
+		sample.Load15, _ = strconv.ParseFloat(fields[2], 64)
+	}
+
+	sample.MemoryTotal, sample.MemoryUsed = parseMemInfo(sections[5])
+	if sample.MemoryTotal > 0 {
+		sample.MemoryUsage = float64(sample.MemoryUsed) / float64(sample.MemoryTotal) * 100
+	}
+
+	sample.Disks = parseDiskUsage(sections[6])
+	return sample, nil
+}
+
+// parseCPUStat 解析 /proc/stat 的 cpu 汇总行，返回非空闲时间和总时间
+func parseCPUStat(output string) (busy, total uint64) {
+	fields := strings.Fields(output)
+	if len(fields) < 5 || fields[0] != "cpu" {
+		return 0, 0
+	}
+	// user nice system idle iowait irq softirq steal，guest 已计入 user
+	for i, field := range fields[1:] {
+		if i >= 8 {
+			break
+		}
+		value, _ := strconv.ParseUint(field, 10, 64)
+		total += value
+		if i != 3 && i != 4 {
+			busy += value
+		}
+	}
+	return busy, total
+}
+
+// parseNetDev 解析 /proc/net/dev，返回除回环网卡外的接收和发送字节数之和
+func parseNetDev(output string) (rx, tx uint64) {
+	for _, line := range strings.Split(output, "\n") {
+		name, counters, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		name = strings.TrimSpace(name)
+		if name == "lo" {
+			continue
+		}
+		fields := strings.Fields(counters)
+		if len(fields) < 9 {
+			continue
+		}
+		received, _ := strconv.ParseUint(fields[0], 10, 64)
+		transmitted, _ := strconv.ParseUint(fields[8], 10, 64)
+		rx += received
+		tx += transmitted
+	}
+	return rx, tx
+}
+
+// parseMemInfo 解析 /proc/meminfo，已用内存为总内存减去可用内存
+func parseMemInfo(output string) (total, used uint64) {
+	var available, free, buffers, cached uint64
+	hasAvailable := false
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		value, _ := strconv.ParseUint(fields[1], 10, 64)
+		value *= 1024 // kB
+		switch fields[0] {
+		case "MemTotal:":
+			total = value
+		case "MemAvailable:":
+			available = value
+			hasAvailable = true
+		case "MemFree:":
+			free = value
+		case "Buffers:":
+			buffers = value
+		case "Cached:":
+			cached = value
+		}
+	}
+	// 3.14 之前的内核没有 MemAvailable
+	if !hasAvailable {
+		available = free + buffers + cached
+	}
+	if available > total {
+		available = total
+	}
+	return total, total - available
+}
+
+// parseDiskUsage 解析 df -P -B1 的输出，同一设备多次挂载时只保留第一个挂载点
+func parseDiskUsage(output string) []DiskUsage {
+	var disks []DiskUsage
+	devices := make(map[string]bool)
+	for i, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if i == 0 || len(fields) < 6 {
+			continue
+		}
+		if devices[fields[0]] {
+			continue
+		}
+		total, _ := strconv.ParseUint(fields[1], 10, 64)
+		if total == 0 {
+			continue
+		}
+		devices[fields[0]] = true
+
+		disk := DiskUsage{
+			// 挂载点中可能包含空格
+			MountPoint: strings.Join(fields[5:], " "),
+			Total:      total,
+		}
+		disk.Used, _ = strconv.ParseUint(fields[2], 10, 64)
+		disk.Usage = float64(disk.Used) / float64(disk.Total) * 100
+		disks = append(disks, disk)
+	}
+	return disks
+}
@@ -0,0 +1,241 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package kms
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// KeySize 数据密钥长度（AES-256）
+const KeySize = 32
+
+// envelopePrefix 密文信封前缀，完整格式为 enc:v1:<密钥ID>:<base64(nonce+密文)>
+const envelopePrefix = "enc:v1:"
+
+var keyIDPattern = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,32}$`)
+
+var (
+	// ErrNoPrimaryKey 没有配置用于加密的主密钥
+	ErrNoPrimaryKey = errors.New("未配置加密主密钥")
+	// ErrUnknownKey 密文使用的密钥不在密钥环中
+	ErrUnknownKey = errors.New("密文使用的密钥不存在")
+	// ErrDecrypt 密文无法解密
+	ErrDecrypt = errors.New("解密失败，密钥不匹配或数据已损坏")
+)
+
+// Key 数据密钥
+type Key struct {
+	ID     string
+	Secret []byte
+}
+
+// Keyring 密钥环：用主密钥加密，按密文信封中的密钥ID解密，
+// 旧版本没有信封的密文依次尝试旧版本密钥解密
+type Keyring struct {
+	primary string
+	keys    map[string]cipher.AEAD
+	legacy  []cipher.AEAD
+}
+
+// NewKeyring 创建密钥环，primary 为空时只能解密
+func NewKeyring(primary string, keys []Key, legacyKeys [][]byte) (*Keyring, error) {
+	k := &Keyring{primary: primary, keys: make(map[string]cipher.AEAD, len(keys))}
+	for _, key := range keys {
+		if !keyIDPattern.MatchString(key.ID) {
+			return nil, fmt.Errorf("密钥ID %q 不合法，只能包含字母、数字、下划线、点和中划线", key.ID)
+		}
+		if _, exists := k.keys[key.ID]; exists {
+			return nil, fmt.Errorf("密钥ID %s 重复", key.ID)
+		}
+		aead, err := newAEAD(key.Secret)
+		if err != nil {
+			return nil, fmt.Errorf("密钥 %s 无效: %w", key.ID, err)
+		}
+		k.keys[key.ID] = aead
+	}
+	if primary != "" && k.keys[primary] == nil {
+		return nil, fmt.Errorf("主密钥 %s 不在密钥列表中", primary)
+	}
+	for _, secret := range legacyKeys {
+		aead, err := newAEAD(secret)
+		if err != nil {
+			return nil, fmt.Errorf("旧版本密钥无效: %w", err)
+		}
+		k.legacy = append(k.legacy, aead)
+	}
+	return k, nil
+}
+
+func newAEAD(secret []byte) (cipher.AEAD, error) {
+	if len(secret) != KeySize {
+		return nil, fmt.Errorf("密钥长度必须为%d字节，当前为%d字节", KeySize, len(secret))
+	}
+	block, err := aes.NewCipher(secret)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Primary 主密钥ID
+func (k *Keyring) Primary() string {
+	return k.primary
+}
+
+// KeyIDs 密钥环中的所有密钥ID
+func (k *Keyring) KeyIDs() []string {
+	ids := make([]string, 0, len(k.keys))
+	for id := range k.keys {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Encrypt 使用主密钥加密，空字符串原样返回
+func (k *Keyring) Encrypt(plaintext string) (string, error) {
+	if plaintext == "" {
+		return "", nil
+	}
+	aead := k.keys[k.primary]
+	if aead == nil {
+		return "", ErrNoPrimaryKey
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	header := envelopePrefix + k.primary + ":"
+	// 信封头作为附加数据参与认证，防止密文被改标为其他密钥
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), []byte(header))
+	return header + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt 解密信封密文或旧版本密文，空字符串原样返回
+func (k *Keyring) Decrypt(ciphertext string) (string, error) {
+	if ciphertext == "" {
+		return "", nil
+	}
+
+	if keyID, payload, ok := parseEnvelope(ciphertext); ok {
+		aead := k.keys[keyID]
+		if aead == nil {
+			return "", fmt.Errorf("%w: %s", ErrUnknownKey, keyID)
+		}
+		data, err := base64.StdEncoding.DecodeString(payload)
+		if err != nil {
+			return "", ErrDecrypt
+		}
+		plaintext, err := open(aead, data, []byte(envelopePrefix+keyID+":"))
+		if err != nil {
+			return "", ErrDecrypt
+		}
+		return string(plaintext), nil
+	}
+
+	// 旧版本密文为 base64 或十六进制编码的 nonce+密文，没有记录密钥
+	for _, data := range decodeLegacy(ciphertext) {
+		for _, aead := range k.legacy {
+			if plaintext, err := open(aead, data, nil); err == nil {
+				return string(plaintext), nil
+			}
+		}
+	}
+	return "", ErrDecrypt
+}
+
+// NeedsRotation 密文是否需要用当前主密钥重新加密
+func (k *Keyring) NeedsRotation(ciphertext string) bool {
+	if ciphertext == "" {
+		return false
+	}
+	keyID, _, ok := parseEnvelope(ciphertext)
+	return !ok || keyID != k.primary
+}
+
+// Rotate 用当前主密钥重新加密，已经是主密钥加密的密文原样返回
+func (k *Keyring) Rotate(ciphertext string) (string, bool, error) {
+	if !k.NeedsRotation(ciphertext) {
+		return ciphertext, false, nil
+	}
+	plaintext, err := k.Decrypt(ciphertext)
+	if err != nil {
+		return "", false, err
+	}
+	rotated, err := k.Encrypt(plaintext)
+	if err != nil {
+		return "", false, err
+	}
+	return rotated, true, nil
+}
+
+// KeyIDOf 返回信封密文使用的密钥ID，旧版本密文返回空字符串
+func KeyIDOf(ciphertext string) string {
+	keyID, _, _ := parseEnvelope(ciphertext)
+	return keyID
+}
+
+// IsEnvelope 是否为信封格式的密文
+func IsEnvelope(s string) bool {
+	_, _, ok := parseEnvelope(s)
+	return ok
+}
+
+func parseEnvelope(s string) (keyID, payload string, ok bool) {
+	rest, found := strings.CutPrefix(s, envelopePrefix)
+	if !found {
+		return "", "", false
+	}
+	keyID, payload, found = strings.Cut(rest, ":")
+	if !found || keyID == "" {
+		return "", "", false
+	}
+	return keyID, payload, true
+}
+
+func open(aead cipher.AEAD, data, additionalData []byte) ([]byte, error) {
+	nonceSize := aead.NonceSize()
+	if len(data) < nonceSize+aead.Overhead() {
+		return nil, ErrDecrypt
+	}
+	return aead.Open(nil, data[:nonceSize], data[nonceSize:], additionalData)
+}
+
+// decodeLegacy 按旧版本的编码方式解码，凭证和 kubeconfig 使用 base64，MFA 使用十六进制
+func decodeLegacy(s string) [][]byte {
+	var candidates [][]byte
+	if data, err := base64.StdEncoding.DecodeString(s); err == nil {
+		candidates = append(candidates, data)
+	}
+	if data, err := hex.DecodeString(s); err == nil {
+		candidates = append(candidates, data)
+	}
+	return candidates
+}
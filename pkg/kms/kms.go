@@ -0,0 +1,312 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package kms
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultKeyringFile 默认的文件密钥环路径
+const DefaultKeyringFile = "./data/kms/keyring.json"
+
+// builtinLegacyKeys 旧版本硬编码在代码中的密钥，仅用于解密升级前写入的数据
+var builtinLegacyKeys = []string{
+	"opshub-enc-key-32-bytes-long!!!!", // 主机凭证、任务、Nginx、SSL证书
+	"opshub-k8s-encrypt-key-32bytes!!", // Kubernetes 集群 kubeconfig
+}
+
+// Config 密钥管理配置
+type Config struct {
+	Primary       string   // 加密新数据使用的密钥ID，为空时使用密钥环文件中的主密钥
+	Keys          string   // 密钥列表，格式为 id:base64密钥,id:base64密钥，也支持64位十六进制密钥
+	KeyringFile   string   // 文件密钥环路径，与 Keys 合并使用
+	AutoGenerate  bool     // 没有配置密钥且密钥环文件不存在时是否自动生成密钥环文件
+	LegacyDecrypt bool     // 是否允许解密旧版本使用内置密钥加密的数据
+	LegacySecrets []string // 旧版本使用的其他密钥（如 MFA 使用的 JWT 密钥），不足32字节补零，超过截断
+}
+
+// InitResult 初始化结果
+type InitResult struct {
+	Primary     string
+	KeyIDs      []string
+	KeyringFile string
+	Created     bool // 是否新生成了密钥环文件
+}
+
+var (
+	mu      sync.RWMutex
+	current = mustLegacyKeyring()
+)
+
+func mustLegacyKeyring() *Keyring {
+	k, err := NewKeyring("", nil, legacyKeys(nil))
+	if err != nil {
+		panic(err)
+	}
+	return k
+}
+
+// Init 加载密钥并设置全局密钥环。没有配置任何密钥且密钥环文件不存在时返回错误，
+// 开启 AutoGenerate 时改为生成新的密钥环文件，该文件需要持久化并同步到所有实例，
+// 否则重启或切换实例后无法解密已有数据
+func Init(c *Config) (*InitResult, error) {
+	if c == nil {
+		c = &Config{}
+	}
+	keys, err := ParseKeys(c.Keys)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &InitResult{KeyringFile: c.KeyringFile}
+	if result.KeyringFile == "" && len(keys) == 0 {
+		result.KeyringFile = DefaultKeyringFile
+	}
+
+	primary := c.Primary
+	if result.KeyringFile != "" {
+		file, err := LoadKeyringFile(result.KeyringFile)
+		switch {
+		case errors.Is(err, fs.ErrNotExist) && len(keys) == 0 && !c.AutoGenerate:
+			return nil, fmt.Errorf("未配置加密密钥且密钥环文件 %s 不存在，请执行 opshub kms generate-key 生成密钥，"+
+				"或通过 kms.keys 配置密钥，首次部署也可开启 kms.auto_generate 自动生成", result.KeyringFile)
+		case errors.Is(err, fs.ErrNotExist) && len(keys) == 0:
+			file = &KeyringFile{}
+			if _, err := file.AddKey(NewKeyID(), true); err != nil {
+				return nil, err
+			}
+			if err := file.Save(result.KeyringFile); err != nil {
+				return nil, err
+			}
+			result.Created = true
+		case errors.Is(err, fs.ErrNotExist):
+			file = &KeyringFile{}
+		case err != nil:
+			return nil, err
+		}
+		fileKeys, err := file.decode()
+		if err != nil {
+			return nil, fmt.Errorf("密钥环文件 %s 无效: %w", result.KeyringFile, err)
+		}
+		keys = append(keys, fileKeys...)
+		if primary == "" {
+			primary = file.Primary
+		}
+	}
+	if primary == "" && len(keys) == 1 {
+		primary = keys[0].ID
+	}
+	if primary == "" {
+		return nil, ErrNoPrimaryKey
+	}
+
+	var legacy [][]byte
+	if c.LegacyDecrypt {
+		legacy = legacyKeys(c.LegacySecrets)
+	}
+	keyring, err := NewKeyring(primary, keys, legacy)
+	if err != nil {
+		return nil, err
+	}
+
+	mu.Lock()
+	current = keyring
+	mu.Unlock()
+
+	result.Primary = primary
+	result.KeyIDs = keyring.KeyIDs()
+	return result, nil
+}
+
+// legacyKeys 内置旧密钥加上额外的旧密钥，按旧版本的规则补齐或截断为32字节
+func legacyKeys(secrets []string) [][]byte {
+	var keys [][]byte
+	for _, secret := range append(append([]string{}, builtinLegacyKeys...), secrets...) {
+		if secret == "" {
+			continue
+		}
+		key := make([]byte, KeySize)
+		copy(key, secret)
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// Default 当前的全局密钥环，未初始化时只能解密旧版本数据
+func Default() *Keyring {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current
+}
+
+// Encrypt 使用全局密钥环加密
+func Encrypt(plaintext string) (string, error) {
+	return Default().Encrypt(plaintext)
+}
+
+// Decrypt 使用全局密钥环解密
+func Decrypt(ciphertext string) (string, error) {
+	return Default().Decrypt(ciphertext)
+}
+
+// GenerateKey 生成随机数据密钥
+func GenerateKey(id string) (Key, error) {
+	if !keyIDPattern.MatchString(id) {
+		return Key{}, fmt.Errorf("密钥ID %q 不合法，只能包含字母、数字、下划线、点和中划线", id)
+	}
+	secret := make([]byte, KeySize)
+	if _, err := rand.Read(secret); err != nil {
+		return Key{}, err
+	}
+	return Key{ID: id, Secret: secret}, nil
+}
+
+// NewKeyID 按当前时间生成密钥ID
+func NewKeyID() string {
+	return "k" + time.Now().Format("20060102150405")
+}
+
+// EncodeSecret 将密钥编码为配置中使用的 base64 字符串
+func EncodeSecret(secret []byte) string {
+	return base64.StdEncoding.EncodeToString(secret)
+}
+
+// decodeSecret 解析 base64 或64位十六进制的密钥
+func decodeSecret(s string) ([]byte, error) {
+	s = strings.TrimSpace(s)
+	if len(s) == KeySize*2 {
+		if secret, err := hex.DecodeString(s); err == nil {
+			return secret, nil
+		}
+	}
+	secret, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("密钥必须为 base64 或十六进制编码")
+	}
+	return secret, nil
+}
+
+// ParseKeys 解析 id:密钥,id:密钥 格式的密钥列表
+func ParseKeys(s string) ([]Key, error) {
+	var keys []Key
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		id, encoded, ok := strings.Cut(item, ":")
+		if !ok {
+			return nil, fmt.Errorf("密钥配置格式应为 id:密钥")
+		}
+		secret, err := decodeSecret(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("密钥 %s: %w", id, err)
+		}
+		keys = append(keys, Key{ID: strings.TrimSpace(id), Secret: secret})
+	}
+	return keys, nil
+}
+
+// KeyringFile 文件密钥环
+type KeyringFile struct {
+	Primary string           `json:"primary"`
+	Keys    []KeyringFileKey `json:"keys"`
+}
+
+// KeyringFileKey 文件密钥环中的密钥
+type KeyringFileKey struct {
+	ID        string    `json:"id"`
+	Secret    string    `json:"secret"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// LoadKeyringFile 读取密钥环文件
+func LoadKeyringFile(path string) (*KeyringFile, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file KeyringFile
+	if err := json.Unmarshal(content, &file); err != nil {
+		return nil, fmt.Errorf("解析密钥环文件失败: %w", err)
+	}
+	return &file, nil
+}
+
+// AddKey 生成新密钥加入密钥环，primary 为 true 时设为主密钥
+func (f *KeyringFile) AddKey(id string, primary bool) (Key, error) {
+	for _, key := range f.Keys {
+		if key.ID == id {
+			return Key{}, fmt.Errorf("密钥ID %s 已存在", id)
+		}
+	}
+	key, err := GenerateKey(id)
+	if err != nil {
+		return Key{}, err
+	}
+	f.Keys = append(f.Keys, KeyringFileKey{ID: id, Secret: EncodeSecret(key.Secret), CreatedAt: time.Now()})
+	if primary || f.Primary == "" {
+		f.Primary = id
+	}
+	return key, nil
+}
+
+// Save 以仅所有者可读写的权限写入密钥环文件
+func (f *KeyringFile) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("创建密钥环目录失败: %w", err)
+	}
+	content, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, content, 0600); err != nil {
+		return fmt.Errorf("写入密钥环文件失败: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("写入密钥环文件失败: %w", err)
+	}
+	return nil
+}
+
+func (f *KeyringFile) decode() ([]Key, error) {
+	keys := make([]Key, 0, len(f.Keys))
+	for _, key := range f.Keys {
+		secret, err := decodeSecret(key.Secret)
+		if err != nil {
+			return nil, fmt.Errorf("密钥 %s: %w", key.ID, err)
+		}
+		keys = append(keys, Key{ID: key.ID, Secret: secret})
+	}
+	return keys, nil
+}
@@ -42,7 +42,7 @@ func AuditLogOperation(db *gorm.DB) gin.HandlerFunc {
 
 		// 读取请求体（因为可能需要记录参数）
 		var bodyBytes []byte
-		if c.Request.Body != nil && c.Request.Method != "GET" {
+		if c.Request.Body != nil && c.Request.Method != "GET" && shouldRecordBody(c.ContentType()) {
 			bodyBytes, _ = io.ReadAll(c.Request.Body)
 			// 重新设置请求体，以便后续处理器可以读取
 			c.Request.Body = io.NopCloser(bytes.NewBuffer(bodyBytes))
@@ -284,6 +284,15 @@ func getOperationInfo(path string, method string) (module, action, description s
 	case strings.HasPrefix(path, "/api/v1/plugins/task") || strings.HasPrefix(path, "/api/v1/plugins/jobs") || strings.HasPrefix(path, "/api/v1/plugins/templates") || strings.HasPrefix(path, "/api/v1/plugins/ansible"):
 		module = "任务中心"
 		action = getActionFromMethod(method)
+		if strings.HasSuffix(path, "/cancel") {
+			action = "取消"
+		} else if strings.HasSuffix(path, "/run") || strings.HasSuffix(path, "/execute") {
+			action = "执行"
+		} else if strings.HasSuffix(path, "/approve") {
+			action = "审批"
+		} else if strings.HasSuffix(path, "/reject") {
+			action = "驳回"
+		}
 		description = getTaskOperationDescription(path, method)
 	// 监控中心
 	case strings.HasPrefix(path, "/api/v1/plugins/monitor"):
@@ -291,9 +300,24 @@ func getOperationInfo(path string, method string) (module, action, description s
 		action = getActionFromMethod(method)
 		description = getMonitorOperationDescription(path, method)
 	// 资产管理
-	case strings.HasPrefix(path, "/api/v1/hosts") || strings.HasPrefix(path, "/api/v1/asset") || strings.HasPrefix(path, "/api/v1/terminal") || strings.HasPrefix(path, "/api/v1/cloud-accounts") || strings.HasPrefix(path, "/api/v1/credentials"):
+	case strings.HasPrefix(path, "/api/v1/hosts") || strings.HasPrefix(path, "/api/v1/asset") || strings.HasPrefix(path, "/api/v1/terminal") || strings.HasPrefix(path, "/api/v1/cloud-accounts") || strings.HasPrefix(path, "/api/v1/credentials") || strings.HasPrefix(path, "/api/v1/command-rules"):
 		module = "资产管理"
 		action = getActionFromMethod(method)
+		if strings.HasSuffix(path, "/command-rules/check") {
+			action = "查询"
+		} else if strings.HasSuffix(path, "/approve") {
+			action = "审批"
+		} else if strings.HasSuffix(path, "/reject") {
+			action = "驳回"
+		} else if strings.HasSuffix(path, "/kill") {
+			action = "终止"
+		} else if strings.HasSuffix(path, "/export") {
+			action = "导出"
+		} else if strings.HasSuffix(path, "/files/download") || strings.HasSuffix(path, "/files/archive") {
+			action = "下载"
+		} else if strings.Contains(path, "/files/upload") && method == "POST" {
+			action = "上传"
+		}
 		description = getAssetOperationDescription(path, method)
 	// 登录接口
 	case path == "/api/v1/public/login":
@@ -379,6 +403,30 @@ func getMonitorOperationDescription(path string, method string) string {
 
 // getTaskOperationDescription 获取任务操作描述
 func getTaskOperationDescription(path string, method string) string {
+	if strings.HasSuffix(path, "/cancel") {
+		if strings.Contains(path, "/ansible") {
+			return "取消Ansible任务"
+		}
+		return "取消任务执行"
+	}
+	if strings.HasSuffix(path, "/run") && strings.Contains(path, "/ansible") {
+		return "执行Ansible任务"
+	}
+	if strings.Contains(path, "/templates") && strings.HasSuffix(path, "/execute") {
+		return "按模板执行任务"
+	}
+	if strings.Contains(path, "/schedules") {
+		return "定时任务操作"
+	}
+	if strings.HasSuffix(path, "/approve") {
+		return "审批通过任务执行"
+	}
+	if strings.HasSuffix(path, "/reject") {
+		return "驳回任务执行"
+	}
+	if strings.Contains(path, "/approval-policies") {
+		return "审批策略操作"
+	}
 	if strings.Contains(path, "/ansible") {
 		return "Ansible任务操作"
 	}
@@ -390,9 +438,30 @@ func getTaskOperationDescription(path string, method string) string {
 
 // getAssetOperationDescription 获取资产操作描述
 func getAssetOperationDescription(path string, method string) string {
+	if strings.Contains(path, "/hosts") && strings.Contains(path, "/files") {
+		return getHostFileOperationDescription(path, method)
+	}
 	if strings.Contains(path, "/hosts") {
 		return "主机管理操作"
 	}
+	if strings.Contains(path, "/command-rules") {
+		if strings.HasSuffix(path, "/check") {
+			return "命令策略试运行"
+		}
+		return "命令策略操作"
+	}
+	if strings.Contains(path, "/terminal-sessions/active") {
+		return "强制终止终端会话"
+	}
+	if strings.Contains(path, "/terminal-approvals") {
+		if strings.HasSuffix(path, "/approve") {
+			return "审批通过终端命令"
+		}
+		return "驳回终端命令"
+	}
+	if strings.Contains(path, "/terminal-sessions") && strings.HasSuffix(path, "/export") {
+		return "导出终端会话"
+	}
 	if strings.Contains(path, "/terminal") {
 		return "终端操作"
 	}
@@ -402,6 +471,52 @@ func getAssetOperationDescription(path string, method string) string {
 	return "资产管理操作"
 }
 
+// getHostFileOperationDescription 获取主机文件操作描述，主机ID见请求路径，文件路径见请求参数
+func getHostFileOperationDescription(path string, method string) string {
+	switch {
+	case strings.HasSuffix(path, "/files/upload/status"):
+		return "查询主机文件上传进度"
+	case strings.HasSuffix(path, "/files/upload/chunk"):
+		return "分片上传主机文件"
+	case strings.HasSuffix(path, "/files/upload"):
+		return "上传主机文件"
+	case strings.HasSuffix(path, "/files/download"):
+		return "下载主机文件"
+	case strings.HasSuffix(path, "/files/archive"):
+		return "打包下载主机目录"
+	case strings.HasSuffix(path, "/files/rename"):
+		return "重命名主机文件"
+	case strings.HasSuffix(path, "/files/chmod"):
+		return "修改主机文件权限"
+	case strings.HasSuffix(path, "/files/chown"):
+		return "修改主机文件属主"
+	case strings.HasSuffix(path, "/files/content"):
+		if method == "GET" {
+			return "读取主机文件"
+		}
+		return "编辑主机文件"
+	case method == "DELETE":
+		return "删除主机文件"
+	}
+	return "浏览主机文件"
+}
+
+// shouldRecordBody 是否记录请求体，文件上传等二进制内容不记录
+func shouldRecordBody(contentType string) bool {
+	return contentType != "multipart/form-data" && contentType != "application/octet-stream"
+}
+
+// maxParamsLength 记录的请求参数最大长度，超出部分截断（如在线编辑的文件内容）
+const maxParamsLength = 8 << 10
+
+// truncateParams 截断过长的请求参数
+func truncateParams(params string) string {
+	if len(params) <= maxParamsLength {
+		return params
+	}
+	return strings.ToValidUTF8(params[:maxParamsLength], "") + "...(已截断)"
+}
+
 // getRequestParams 获取请求参数
 func getRequestParams(c *gin.Context, bodyBytes []byte) string {
 	// 对于GET请求，记录查询参数
@@ -416,13 +531,32 @@ func getRequestParams(c *gin.Context, bodyBytes []byte) string {
 			// 过滤敏感字段
 			filterSensitiveFields(params)
 			if filtered, err := json.Marshal(params); err == nil {
-				return string(filtered)
+				return truncateParams(string(filtered))
+			}
+		}
+		return truncateParams(string(bodyBytes))
+	}
+
+	// 文件上传只记录表单字段和文件名
+	if form := c.Request.MultipartForm; form != nil {
+		params := make(map[string]interface{}, len(form.Value)+len(form.File))
+		for key, values := range form.Value {
+			params[key] = strings.Join(values, ",")
+		}
+		for key, files := range form.File {
+			names := make([]string, 0, len(files))
+			for _, file := range files {
+				names = append(names, file.Filename)
 			}
+			params[key] = strings.Join(names, ",")
+		}
+		filterSensitiveFields(params)
+		if filtered, err := json.Marshal(params); err == nil {
+			return truncateParams(string(filtered))
 		}
-		return string(bodyBytes)
 	}
 
-	return ""
+	return c.Request.URL.RawQuery
 }
 
 // filterSensitiveFields 过滤敏感字段
@@ -0,0 +1,304 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package recording
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	appLogger "github.com/ydcloud-dy/opshub/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// 存储类型
+const (
+	StorageLocal = "local"
+	StorageS3    = "s3"
+)
+
+// Config 录制存储配置
+type Config struct {
+	Storage       string   // 存储类型: local, s3
+	LocalDir      string   // 本地存储目录，同时用于存放录制中的临时文件
+	Compress      bool     // 是否使用gzip压缩录制文件
+	RetentionDays int      // 录制保留天数，0表示永久保留
+	S3            S3Config // S3兼容对象存储配置
+}
+
+// DefaultConfig 默认配置
+func DefaultConfig() *Config {
+	return &Config{
+		Storage:  StorageLocal,
+		LocalDir: "./data/terminal-recordings",
+		Compress: true,
+	}
+}
+
+// Object 已持久化的录制文件，对应会话记录上的存储字段
+type Object struct {
+	Storage    string // 存储类型，为空表示旧版本直接写在本地的录制文件
+	Key        string // 存储键，旧版本为本地文件路径
+	Size       int64  // 存储后的大小（字节）
+	Checksum   string // 存储内容的SHA-256
+	Compressed bool   // 是否经过gzip压缩
+}
+
+var (
+	mu     sync.RWMutex
+	cfg    = DefaultConfig()
+	local  = NewLocalStorage(cfg.LocalDir)
+	remote Storage
+)
+
+// Init 初始化录制存储
+func Init(c *Config) error {
+	if c == nil {
+		c = DefaultConfig()
+	}
+	conf := *c
+	if conf.Storage == "" {
+		conf.Storage = StorageLocal
+	}
+	if conf.LocalDir == "" {
+		conf.LocalDir = DefaultConfig().LocalDir
+	}
+
+	var st Storage
+	switch conf.Storage {
+	case StorageLocal:
+	case StorageS3:
+		s3Storage, err := NewS3Storage(conf.S3)
+		if err != nil {
+			return err
+		}
+		st = s3Storage
+	default:
+		return fmt.Errorf("不支持的录制存储类型: %s", conf.Storage)
+	}
+
+	if err := os.MkdirAll(spoolDir(conf.LocalDir), 0755); err != nil {
+		return fmt.Errorf("创建录制目录失败: %w", err)
+	}
+
+	mu.Lock()
+	cfg = &conf
+	local = NewLocalStorage(conf.LocalDir)
+	remote = st
+	mu.Unlock()
+	return nil
+}
+
+// SpoolDir 录制进行中的临时文件目录，会话结束后由 Save 转存到配置的存储
+func SpoolDir() string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return spoolDir(cfg.LocalDir)
+}
+
+func spoolDir(localDir string) string {
+	return filepath.Join(localDir, ".spool")
+}
+
+// RetentionDays 录制保留天数
+func RetentionDays() int {
+	mu.RLock()
+	defer mu.RUnlock()
+	return cfg.RetentionDays
+}
+
+// current 返回当前的配置和用于写入的存储
+func current() (Config, Storage) {
+	mu.RLock()
+	defer mu.RUnlock()
+	if remote != nil {
+		return *cfg, remote
+	}
+	return *cfg, local
+}
+
+// storageFor 按会话记录上的存储类型找到对应的存储
+func storageFor(name string) (Storage, error) {
+	mu.RLock()
+	defer mu.RUnlock()
+	switch {
+	case name == StorageLocal:
+		return local, nil
+	case remote != nil && remote.Name() == name:
+		return remote, nil
+	}
+	return nil, fmt.Errorf("录制存储 %s 未配置", name)
+}
+
+// Save 将录制临时文件按配置压缩、计算校验值后转存，category 用于区分录制来源（如 host、kubernetes）
+// 远端存储写入失败时回退到本地存储，避免丢失录制；转存成功后删除临时文件
+func Save(ctx context.Context, spoolPath, category string) (*Object, error) {
+	conf, st := current()
+
+	src, err := os.Open(spoolPath)
+	if err != nil {
+		return nil, fmt.Errorf("打开录制文件失败: %w", err)
+	}
+	defer src.Close()
+
+	payload := src
+	name := filepath.Base(spoolPath)
+	if conf.Compress {
+		gzPath := spoolPath + ".gz"
+		gzFile, err := os.Create(gzPath)
+		if err != nil {
+			return nil, fmt.Errorf("创建压缩文件失败: %w", err)
+		}
+		defer os.Remove(gzPath)
+		defer gzFile.Close()
+
+		gw := gzip.NewWriter(gzFile)
+		if _, err := io.Copy(gw, src); err != nil {
+			return nil, fmt.Errorf("压缩录制文件失败: %w", err)
+		}
+		if err := gw.Close(); err != nil {
+			return nil, fmt.Errorf("压缩录制文件失败: %w", err)
+		}
+		payload = gzFile
+		name += ".gz"
+	}
+
+	if _, err := payload.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	hash := sha256.New()
+	size, err := io.Copy(hash, payload)
+	if err != nil {
+		return nil, fmt.Errorf("计算录制文件校验值失败: %w", err)
+	}
+
+	obj := &Object{
+		Storage:    st.Name(),
+		Key:        path.Join(category, time.Now().Format("2006/01/02"), name),
+		Size:       size,
+		Checksum:   hex.EncodeToString(hash.Sum(nil)),
+		Compressed: conf.Compress,
+	}
+
+	if err := put(ctx, st, obj.Key, payload, size); err != nil {
+		if st.Name() == StorageLocal {
+			return nil, err
+		}
+		appLogger.Warn("录制文件上传失败，回退到本地存储",
+			zap.String("storage", st.Name()),
+			zap.String("key", obj.Key),
+			zap.Error(err))
+		mu.RLock()
+		st = local
+		mu.RUnlock()
+		if err := put(ctx, st, obj.Key, payload, size); err != nil {
+			return nil, err
+		}
+		obj.Storage = st.Name()
+	}
+
+	src.Close()
+	if err := os.Remove(spoolPath); err != nil {
+		appLogger.Warn("删除录制临时文件失败", zap.String("path", spoolPath), zap.Error(err))
+	}
+	return obj, nil
+}
+
+func put(ctx context.Context, st Storage, key string, payload *os.File, size int64) error {
+	if _, err := payload.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	if err := st.Put(ctx, key, payload, size); err != nil {
+		return fmt.Errorf("保存录制文件失败: %w", err)
+	}
+	return nil
+}
+
+// ReadAll 读取录制文件内容，校验完整性并解压
+func ReadAll(ctx context.Context, obj Object) ([]byte, error) {
+	// 旧版本的录制直接写在本地路径上，没有校验值
+	if obj.Storage == "" {
+		return os.ReadFile(obj.Key)
+	}
+
+	st, err := storageFor(obj.Storage)
+	if err != nil {
+		return nil, err
+	}
+	rc, err := st.Get(ctx, obj.Key)
+	if err != nil {
+		return nil, fmt.Errorf("读取录制文件失败: %w", err)
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, fmt.Errorf("读取录制文件失败: %w", err)
+	}
+	if obj.Checksum != "" {
+		sum := sha256.Sum256(data)
+		if !strings.EqualFold(hex.EncodeToString(sum[:]), obj.Checksum) {
+			return nil, ErrChecksumMismatch
+		}
+	}
+	if !obj.Compressed {
+		return data, nil
+	}
+
+	gr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("解压录制文件失败: %w", err)
+	}
+	defer gr.Close()
+	content, err := io.ReadAll(gr)
+	if err != nil {
+		return nil, fmt.Errorf("解压录制文件失败: %w", err)
+	}
+	return content, nil
+}
+
+// Remove 删除录制文件，文件不存在时不返回错误
+func Remove(ctx context.Context, obj Object) error {
+	if obj.Key == "" {
+		return nil
+	}
+	if obj.Storage == "" {
+		if err := os.Remove(obj.Key); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+
+	st, err := storageFor(obj.Storage)
+	if err != nil {
+		return err
+	}
+	return st.Delete(ctx, obj.Key)
+}
@@ -0,0 +1,80 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package recording
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	appLogger "github.com/ydcloud-dy/opshub/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// PurgeFunc 清理早于 cutoff 的会话记录及录制文件，返回清理的会话数
+type PurgeFunc func(ctx context.Context, cutoff time.Time) (int, error)
+
+const retentionInterval = time.Hour
+
+var (
+	retentionMu   sync.Mutex
+	retentionJobs = map[string]bool{}
+)
+
+// StartRetention 启动录制保留策略的后台清理，同名任务只会启动一次
+// 每次清理时读取当前的保留天数，保留天数为0时不清理
+func StartRetention(name string, purge PurgeFunc) {
+	retentionMu.Lock()
+	defer retentionMu.Unlock()
+	if retentionJobs[name] {
+		return
+	}
+	retentionJobs[name] = true
+
+	go func() {
+		// 启动后稍作延迟再执行第一次清理，避免与服务初始化争抢数据库
+		timer := time.NewTimer(time.Minute)
+		defer timer.Stop()
+		for range timer.C {
+			runRetention(name, purge)
+			timer.Reset(retentionInterval)
+		}
+	}()
+}
+
+func runRetention(name string, purge PurgeFunc) {
+	days := RetentionDays()
+	if days <= 0 {
+		return
+	}
+
+	cutoff := time.Now().AddDate(0, 0, -days)
+	count, err := purge(context.Background(), cutoff)
+	if err != nil {
+		appLogger.Error("清理过期终端录制失败", zap.String("job", name), zap.Error(err))
+		return
+	}
+	if count > 0 {
+		appLogger.Info("已清理过期终端录制",
+			zap.String("job", name),
+			zap.Int("count", count),
+			zap.Time("cutoff", cutoff))
+	}
+}
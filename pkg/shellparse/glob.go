@@ -0,0 +1,119 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package shellparse
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// globCache 已编译的通配符表达式
+var globCache sync.Map
+
+// MatchGlob 通配符匹配。* 可匹配包括 / 在内的任意字符，
+// 支持 ?、[...]、[!...]、{a,b} 以及使用 \ 转义的字面字符
+func MatchGlob(pattern, s string) bool {
+	re, err := compileGlob(pattern)
+	if err != nil {
+		return pattern == s
+	}
+	return re.MatchString(s)
+}
+
+// ValidateGlob 校验通配符表达式
+func ValidateGlob(pattern string) error {
+	_, err := compileGlob(pattern)
+	return err
+}
+
+func compileGlob(pattern string) (*regexp.Regexp, error) {
+	if re, ok := globCache.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	runes := []rune(pattern)
+	var b strings.Builder
+	b.WriteString("^")
+	depth := 0
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch r {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteString(".")
+		case '\\':
+			if i+1 < len(runes) {
+				i++
+				r = runes[i]
+			}
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		case '[':
+			end := i + 1
+			if end < len(runes) && (runes[end] == '!' || runes[end] == '^') {
+				end++
+			}
+			if end < len(runes) && runes[end] == ']' {
+				end++
+			}
+			for end < len(runes) && runes[end] != ']' {
+				end++
+			}
+			if end >= len(runes) {
+				return nil, fmt.Errorf("通配符 %q 中的 [ 未闭合", pattern)
+			}
+			class := string(runes[i+1 : end])
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i = end
+		case '{':
+			depth++
+			b.WriteString("(?:")
+		case '}':
+			if depth == 0 {
+				b.WriteString(`\}`)
+				continue
+			}
+			depth--
+			b.WriteString(")")
+		case ',':
+			if depth > 0 {
+				b.WriteString("|")
+			} else {
+				b.WriteString(",")
+			}
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	if depth > 0 {
+		return nil, fmt.Errorf("通配符 %q 中的 { 未闭合", pattern)
+	}
+	b.WriteString("$")
+	re, err := regexp.Compile(b.String())
+	if err != nil {
+		return nil, fmt.Errorf("无效的通配符 %q: %w", pattern, err)
+	}
+	globCache.Store(pattern, re)
+	return re, nil
+}
@@ -0,0 +1,555 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package shellparse
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// tokenKind 词法单元类型
+type tokenKind int
+
+const (
+	tokWord tokenKind = iota
+	tokOp
+	tokNewline
+)
+
+// token 词法单元
+type token struct {
+	kind    tokenKind
+	value   string   // 单词去除引号后的值或操作符
+	quoted  bool     // 单词中是否包含引号或转义
+	dynamic bool     // 单词中是否包含变量展开或命令替换
+	glob    bool     // 单词中是否包含未加引号的通配符
+	subs    []string // 命令替换、进程替换中的脚本
+	line    int
+	heredoc *heredoc // here-document 分隔符对应的正文
+}
+
+// heredoc here-document 正文
+type heredoc struct {
+	delim  string
+	strip  bool // <<- 去除行首制表符
+	body   string
+	closed bool // 是否读到结束分隔符
+}
+
+// operators 操作符，按长度从长到短排列以便最长匹配
+var operators = []string{
+	"<<<", "<<-", "&>>",
+	"&&", "||", ";;", "|&", ">>", ">|", ">&", "<<", "<&", "<>", "&>",
+	"|", "&", ";", "(", ")", "<", ">",
+}
+
+// lexer 词法分析器
+type lexer struct {
+	src       string
+	pos       int
+	line      int
+	tokens    []token
+	pending   []*heredoc
+	wantDelim string // 上一个操作符为 << 或 <<- 时等待读取分隔符
+}
+
+// lex 将脚本切分为词法单元
+func lex(src string) ([]token, error) {
+	l := &lexer{src: src, line: 1}
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch {
+		case c == ' ' || c == '\t' || c == '\r':
+			l.pos++
+		case c == '\\' && l.peek(1) == '\n':
+			l.pos += 2
+			l.line++
+		case c == '#':
+			for l.pos < len(l.src) && l.src[l.pos] != '\n' {
+				l.pos++
+			}
+		case c == '\n':
+			l.pos++
+			l.tokens = append(l.tokens, token{kind: tokNewline, line: l.line})
+			l.line++
+			l.readHeredocs()
+		case c == '(' && l.peek(1) == '(':
+			// 算术求值 ((...))
+			line := l.line
+			body, err := l.readBalanced('(', ')')
+			if err != nil {
+				return nil, err
+			}
+			l.tokens = append(l.tokens, token{kind: tokWord, value: "(" + body + ")", dynamic: true, line: line})
+		case (c == '<' || c == '>') && l.peek(1) == '(':
+			// 进程替换 <(...) 和 >(...)
+			line := l.line
+			l.pos++
+			body, err := l.readBalanced('(', ')')
+			if err != nil {
+				return nil, err
+			}
+			l.tokens = append(l.tokens, token{kind: tokWord, value: "/dev/fd/63", dynamic: true, subs: []string{body}, line: line})
+		case strings.IndexByte("|&;()<>", c) >= 0:
+			l.emitOp("")
+		default:
+			if l.readFdRedirect() {
+				continue
+			}
+			tok, err := l.readWord()
+			if err != nil {
+				return nil, err
+			}
+			if l.wantDelim != "" {
+				doc := &heredoc{delim: tok.value, strip: l.wantDelim == "<<-"}
+				tok.heredoc = doc
+				l.pending = append(l.pending, doc)
+				l.wantDelim = ""
+			}
+			l.tokens = append(l.tokens, tok)
+		}
+	}
+	// 脚本在 here-document 正文前结束时按空正文处理
+	l.readHeredocs()
+	return l.tokens, nil
+}
+
+func (l *lexer) peek(n int) byte {
+	if l.pos+n < len(l.src) {
+		return l.src[l.pos+n]
+	}
+	return 0
+}
+
+// emitOp 读取操作符，prefix 为重定向前的文件描述符
+func (l *lexer) emitOp(prefix string) {
+	for _, op := range operators {
+		if strings.HasPrefix(l.src[l.pos:], op) {
+			l.pos += len(op)
+			l.tokens = append(l.tokens, token{kind: tokOp, value: prefix + op, line: l.line})
+			if op == "<<" || op == "<<-" {
+				l.wantDelim = op
+			}
+			return
+		}
+	}
+}
+
+// readFdRedirect 读取带文件描述符的重定向，如 2> 和 1>&2
+func (l *lexer) readFdRedirect() bool {
+	end := l.pos
+	for end < len(l.src) && l.src[end] >= '0' && l.src[end] <= '9' {
+		end++
+	}
+	if end == l.pos || end >= len(l.src) || (l.src[end] != '<' && l.src[end] != '>') {
+		return false
+	}
+	prefix := l.src[l.pos:end]
+	l.pos = end
+	l.emitOp(prefix)
+	return true
+}
+
+// readWord 读取一个单词，处理引号、转义和各类展开
+func (l *lexer) readWord() (token, error) {
+	tok := token{kind: tokWord, line: l.line}
+	var buf strings.Builder
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		if strings.IndexByte(" \t\r\n|&;()<>", c) >= 0 {
+			break
+		}
+		switch c {
+		case '\\':
+			tok.quoted = true
+			if l.peek(1) == '\n' {
+				l.pos += 2
+				l.line++
+				continue
+			}
+			if l.pos+1 < len(l.src) {
+				_, size := utf8.DecodeRuneInString(l.src[l.pos+1:])
+				buf.WriteString(l.src[l.pos+1 : l.pos+1+size])
+				l.pos += 1 + size
+			} else {
+				buf.WriteByte('\\')
+				l.pos++
+			}
+		case '\'':
+			tok.quoted = true
+			end := strings.IndexByte(l.src[l.pos+1:], '\'')
+			if end < 0 {
+				return tok, incompleteErrorf("第%d行: 未闭合的单引号", tok.line)
+			}
+			s := l.src[l.pos+1 : l.pos+1+end]
+			l.line += strings.Count(s, "\n")
+			buf.WriteString(s)
+			l.pos += end + 2
+		case '"':
+			tok.quoted = true
+			if err := l.readDouble(&tok, &buf); err != nil {
+				return tok, err
+			}
+		case '$':
+			if err := l.readDollar(&tok, &buf, false); err != nil {
+				return tok, err
+			}
+		case '`':
+			body, err := l.readBacktick()
+			if err != nil {
+				return tok, err
+			}
+			tok.dynamic = true
+			tok.subs = append(tok.subs, body)
+			buf.WriteString("`" + body + "`")
+		case '*', '?':
+			tok.glob = true
+			buf.WriteByte(c)
+			l.pos++
+		case '[':
+			// 仅当同一单词中存在 ] 时才是字符类通配符，[ 和 [[ 是命令
+			rest := l.src[l.pos+1:]
+			if end := strings.IndexAny(rest, " \t\r\n|&;()<>"); end >= 0 {
+				rest = rest[:end]
+			}
+			if strings.IndexByte(rest, ']') >= 0 {
+				tok.glob = true
+			}
+			buf.WriteByte(c)
+			l.pos++
+		default:
+			buf.WriteByte(c)
+			l.pos++
+		}
+	}
+	tok.value = buf.String()
+	return tok, nil
+}
+
+// readDouble 读取双引号字符串
+func (l *lexer) readDouble(tok *token, buf *strings.Builder) error {
+	line := l.line
+	l.pos++
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch c {
+		case '"':
+			l.pos++
+			return nil
+		case '\\':
+			next := l.peek(1)
+			switch {
+			case next == '\n':
+				l.pos += 2
+				l.line++
+			case next == '$' || next == '`' || next == '"' || next == '\\':
+				buf.WriteByte(next)
+				l.pos += 2
+			default:
+				buf.WriteByte('\\')
+				l.pos++
+			}
+		case '$':
+			if err := l.readDollar(tok, buf, true); err != nil {
+				return err
+			}
+		case '`':
+			body, err := l.readBacktick()
+			if err != nil {
+				return err
+			}
+			tok.dynamic = true
+			tok.subs = append(tok.subs, body)
+			buf.WriteString("`" + body + "`")
+		default:
+			if c == '\n' {
+				l.line++
+			}
+			buf.WriteByte(c)
+			l.pos++
+		}
+	}
+	return incompleteErrorf("第%d行: 未闭合的双引号", line)
+}
+
+// readDollar 读取以 $ 开头的展开
+func (l *lexer) readDollar(tok *token, buf *strings.Builder, inDouble bool) error {
+	next := l.peek(1)
+	switch {
+	case next == '\'' && !inDouble:
+		tok.quoted = true
+		l.pos++
+		s, err := l.readANSIC()
+		if err != nil {
+			return err
+		}
+		buf.WriteString(s)
+	case next == '"' && !inDouble:
+		tok.quoted = true
+		l.pos++
+		return l.readDouble(tok, buf)
+	case next == '(':
+		l.pos++
+		body, err := l.readBalanced('(', ')')
+		if err != nil {
+			return err
+		}
+		tok.dynamic = true
+		// $((...)) 为算术求值，不是命令替换
+		if !(strings.HasPrefix(body, "(") && strings.HasSuffix(body, ")")) {
+			tok.subs = append(tok.subs, body)
+		}
+		buf.WriteString("$(" + body + ")")
+	case next == '{':
+		l.pos++
+		body, err := l.readBalanced('{', '}')
+		if err != nil {
+			return err
+		}
+		tok.dynamic = true
+		buf.WriteString("${" + body + "}")
+	case next == '_' || isAlnum(next):
+		start := l.pos
+		l.pos++
+		if next >= '0' && next <= '9' {
+			l.pos++
+		} else {
+			for l.pos < len(l.src) && (l.src[l.pos] == '_' || isAlnum(l.src[l.pos])) {
+				l.pos++
+			}
+		}
+		tok.dynamic = true
+		buf.WriteString(l.src[start:l.pos])
+	case next != 0 && strings.IndexByte("@*#?$!-", next) >= 0:
+		tok.dynamic = true
+		buf.WriteString(l.src[l.pos : l.pos+2])
+		l.pos += 2
+	default:
+		buf.WriteByte('$')
+		l.pos++
+	}
+	return nil
+}
+
+// readBacktick 读取反引号命令替换，返回其中的脚本
+func (l *lexer) readBacktick() (string, error) {
+	line := l.line
+	l.pos++
+	var buf strings.Builder
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch {
+		case c == '`':
+			l.pos++
+			return buf.String(), nil
+		case c == '\\' && (l.peek(1) == '`' || l.peek(1) == '\\' || l.peek(1) == '$'):
+			buf.WriteByte(l.peek(1))
+			l.pos += 2
+		default:
+			if c == '\n' {
+				l.line++
+			}
+			buf.WriteByte(c)
+			l.pos++
+		}
+	}
+	return "", incompleteErrorf("第%d行: 未闭合的反引号", line)
+}
+
+// readBalanced 读取成对括号中的内容，当前位置须为左括号
+func (l *lexer) readBalanced(open, close byte) (string, error) {
+	line := l.line
+	l.pos++
+	start := l.pos
+	depth := 1
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch c {
+		case '\\':
+			l.pos++
+		case '\n':
+			l.line++
+		case '\'':
+			end := strings.IndexByte(l.src[l.pos+1:], '\'')
+			if end < 0 {
+				return "", incompleteErrorf("第%d行: 未闭合的单引号", line)
+			}
+			l.line += strings.Count(l.src[l.pos+1:l.pos+1+end], "\n")
+			l.pos += end + 1
+		case '"', '`':
+			end := l.pos + 1
+			for end < len(l.src) && l.src[end] != c {
+				if l.src[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(l.src) {
+				return "", incompleteErrorf("第%d行: 未闭合的引号", line)
+			}
+			l.line += strings.Count(l.src[l.pos:end], "\n")
+			l.pos = end
+		case open:
+			depth++
+		case close:
+			depth--
+			if depth == 0 {
+				body := l.src[start:l.pos]
+				l.pos++
+				return body, nil
+			}
+		}
+		l.pos++
+	}
+	return "", incompleteErrorf("第%d行: 未闭合的括号 %c", line, open)
+}
+
+// readANSIC 读取 $'...' 字符串并解码其中的转义序列
+func (l *lexer) readANSIC() (string, error) {
+	line := l.line
+	l.pos++
+	var buf strings.Builder
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		if c == '\'' {
+			l.pos++
+			return buf.String(), nil
+		}
+		if c != '\\' || l.pos+1 >= len(l.src) {
+			if c == '\n' {
+				l.line++
+			}
+			buf.WriteByte(c)
+			l.pos++
+			continue
+		}
+		l.pos++
+		e := l.src[l.pos]
+		l.pos++
+		switch e {
+		case 'a':
+			buf.WriteByte('\a')
+		case 'b':
+			buf.WriteByte('\b')
+		case 'e', 'E':
+			buf.WriteByte(0x1b)
+		case 'f':
+			buf.WriteByte('\f')
+		case 'n':
+			buf.WriteByte('\n')
+		case 'r':
+			buf.WriteByte('\r')
+		case 't':
+			buf.WriteByte('\t')
+		case 'v':
+			buf.WriteByte('\v')
+		case 'c':
+			if l.pos < len(l.src) {
+				buf.WriteByte(l.src[l.pos] & 0x1f)
+				l.pos++
+			}
+		case 'x':
+			buf.WriteString(l.readCodePoint(16, 2, false))
+		case 'u':
+			buf.WriteString(l.readCodePoint(16, 4, true))
+		case 'U':
+			buf.WriteString(l.readCodePoint(16, 8, true))
+		default:
+			if e >= '0' && e <= '7' {
+				l.pos--
+				buf.WriteString(l.readCodePoint(8, 3, false))
+			} else {
+				buf.WriteByte(e)
+			}
+		}
+	}
+	return "", incompleteErrorf("第%d行: 未闭合的 $'...' 字符串", line)
+}
+
+// readCodePoint 读取最多 maxLen 位的数字并转换为字符
+func (l *lexer) readCodePoint(base, maxLen int, unicode bool) string {
+	start := l.pos
+	for l.pos < len(l.src) && l.pos-start < maxLen && isDigit(l.src[l.pos], base) {
+		l.pos++
+	}
+	n, err := strconv.ParseUint(l.src[start:l.pos], base, 32)
+	if err != nil {
+		return ""
+	}
+	if unicode {
+		return string(rune(n))
+	}
+	return string([]byte{byte(n)})
+}
+
+// readHeredocs 在换行后读取等待中的 here-document 正文
+func (l *lexer) readHeredocs() {
+	for _, doc := range l.pending {
+		var lines []string
+		for l.pos < len(l.src) {
+			end := strings.IndexByte(l.src[l.pos:], '\n')
+			var text string
+			if end < 0 {
+				text = l.src[l.pos:]
+				l.pos = len(l.src)
+			} else {
+				text = l.src[l.pos : l.pos+end]
+				l.pos += end + 1
+			}
+			l.line++
+			if doc.strip {
+				text = strings.TrimLeft(text, "\t")
+			}
+			if text == doc.delim {
+				doc.closed = true
+				break
+			}
+			lines = append(lines, text)
+		}
+		doc.body = strings.Join(lines, "\n")
+	}
+	l.pending = nil
+}
+
+// incompleteError 因脚本未输入完整导致的词法错误
+type incompleteError struct {
+	msg string
+}
+
+func (e *incompleteError) Error() string { return e.msg }
+
+func (e *incompleteError) Unwrap() error { return ErrIncomplete }
+
+func incompleteErrorf(format string, args ...any) error {
+	return &incompleteError{msg: fmt.Sprintf(format, args...)}
+}
+
+func isAlnum(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+func isDigit(c byte, base int) bool {
+	if base == 8 {
+		return c >= '0' && c <= '7'
+	}
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F'
+}
@@ -0,0 +1,646 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package shellparse
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// ErrIncomplete 脚本未输入完整，如引号或括号未闭合
+var ErrIncomplete = errors.New("命令未输入完整")
+
+// maxDepth 嵌套解析（命令替换、sh -c、eval 等）的最大层级
+const maxDepth = 16
+
+// Redirect 重定向
+type Redirect struct {
+	Op     string `json:"op"`
+	Target string `json:"target"`
+	Body   string `json:"body,omitempty"` // here-document 正文
+}
+
+// Writes 是否为写入文件的重定向
+func (r Redirect) Writes() bool {
+	op := strings.TrimLeft(r.Op, "0123456789")
+	if !strings.Contains(op, ">") {
+		return false
+	}
+	// 2>&1、>&- 等为文件描述符复制，不写入文件
+	if strings.HasSuffix(op, ">&") {
+		return strings.Trim(r.Target, "0123456789-") != ""
+	}
+	return true
+}
+
+// Command 解析后的简单命令
+type Command struct {
+	Name        string     `json:"name"` // 命令名，已去除路径
+	Path        string     `json:"path"` // 原始命令路径
+	Args        []string   `json:"args"`
+	Assignments []string   `json:"assignments,omitempty"`
+	Redirects   []Redirect `json:"redirects,omitempty"`
+	Pipeline    int        `json:"pipeline"` // 所属管道编号
+	Stage       int        `json:"stage"`    // 在管道中的位置，从0开始
+	Line        int        `json:"line"`
+	Dynamic     bool       `json:"dynamic"` // 命令名含变量、命令替换或通配符，无法静态确定
+}
+
+// String 返回规范化的命令文本
+func (c Command) String() string {
+	var parts []string
+	if c.Name != "" {
+		parts = append(parts, quote(c.Name))
+	}
+	for _, arg := range c.Args {
+		parts = append(parts, quote(arg))
+	}
+	for _, r := range c.Redirects {
+		if strings.HasSuffix(r.Op, "&") {
+			parts = append(parts, r.Op+quote(r.Target))
+		} else {
+			parts = append(parts, r.Op+" "+quote(r.Target))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+func quote(s string) string {
+	if s != "" && !strings.ContainsAny(s, " \t\n'\"\\") {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// word 命令中的单词
+type word struct {
+	value   string
+	dynamic bool
+}
+
+// redirectToken 重定向操作符与目标
+type redirectToken struct {
+	op     string
+	target token
+}
+
+var assignmentRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\[[^]]*\])?\+?=`)
+
+// keywords 出现在命令前的 shell 保留字，跳过后继续解析其后的命令
+var keywords = map[string]bool{
+	"if": true, "then": true, "else": true, "elif": true, "fi": true,
+	"do": true, "done": true, "while": true, "until": true, "esac": true,
+	"!": true, "{": true, "}": true,
+}
+
+// headers 结构语句头部，整条语句不执行命令
+var headers = map[string]bool{"for": true, "select": true, "case": true, "function": true}
+
+// shells 支持 -c 参数执行脚本的 shell
+var shells = map[string]bool{
+	"sh": true, "bash": true, "zsh": true, "dash": true, "ksh": true,
+	"ash": true, "mksh": true, "fish": true,
+}
+
+// parser 语法分析器
+type parser struct {
+	commands  []Command
+	pipelines int
+}
+
+// Parse 解析 shell 脚本，返回其中将被执行的全部简单命令。
+// 命令替换、进程替换、sh -c、eval 以及 sudo、env 等包装命令中的命令会被递归展开。
+func Parse(script string) ([]Command, error) {
+	p := &parser{}
+	if err := p.parse(script, 1, 0); err != nil {
+		return nil, err
+	}
+	return p.commands, nil
+}
+
+// Incomplete 判断交互式输入的命令行是否尚未结束、shell 将等待继续输入：
+// 引号或括号未闭合、here-document 缺少结束分隔符、行尾为续行符或管道及 &&、|| 操作符
+func Incomplete(script string) bool {
+	toks, err := lex(script)
+	if err != nil {
+		return errors.Is(err, ErrIncomplete)
+	}
+	line := strings.TrimRight(script, "\r\n")
+	if n := len(line) - len(strings.TrimRight(line, "\\")); n%2 == 1 {
+		return true
+	}
+	for _, tok := range toks {
+		if tok.heredoc != nil && !tok.heredoc.closed {
+			return true
+		}
+	}
+	if len(toks) > 0 {
+		last := toks[len(toks)-1]
+		if last.kind == tokOp && slices.Contains([]string{"|", "|&", "&&", "||"}, last.value) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *parser) newPipeline() int {
+	p.pipelines++
+	return p.pipelines
+}
+
+// parse 解析脚本，line 为脚本第一行在最外层脚本中的行号
+func (p *parser) parse(script string, line, depth int) error {
+	if depth > maxDepth {
+		return fmt.Errorf("第%d行: 命令嵌套层级超过%d层", line, maxDepth)
+	}
+	toks, err := lex(script)
+	if err != nil {
+		if depth > 0 {
+			return fmt.Errorf("第%d行的嵌套命令: %w", line, err)
+		}
+		return err
+	}
+	toks = stripCasePatterns(toks)
+
+	offset := line - 1
+	pipeline := p.newPipeline()
+	stage := 0
+	var words []token
+	var redirects []redirectToken
+	flush := func() error {
+		err := p.build(words, redirects, pipeline, stage, offset, depth)
+		words, redirects = nil, nil
+		return err
+	}
+
+	continued := false // 管道符或 &&、|| 之后的换行不结束语句
+	for i := 0; i < len(toks); i++ {
+		t := toks[i]
+		switch {
+		case t.kind == tokWord:
+			words = append(words, t)
+		case t.kind == tokNewline && continued:
+			continue
+		case t.kind == tokOp && isRedirect(t.value):
+			// [[ a < b ]] 中的 < 和 > 是比较运算符
+			if len(words) > 0 && !words[0].quoted && words[0].value == "[[" && (t.value == "<" || t.value == ">") {
+				words = append(words, token{kind: tokWord, value: t.value, line: t.line})
+				continue
+			}
+			if i+1 >= len(toks) || toks[i+1].kind != tokWord {
+				return fmt.Errorf("第%d行: 重定向 %s 缺少目标", t.line+offset, t.value)
+			}
+			redirects = append(redirects, redirectToken{op: t.value, target: toks[i+1]})
+			i++
+		case t.kind == tokOp && (t.value == "|" || t.value == "|&"):
+			if err := flush(); err != nil {
+				return err
+			}
+			stage++
+		case t.kind == tokOp && t.value == "(" && len(words) > 0 &&
+			i+1 < len(toks) && toks[i+1].kind == tokOp && toks[i+1].value == ")":
+			// 函数定义 name() { ... }，函数名不是命令
+			if err := p.parseSubs(words, offset, depth); err != nil {
+				return err
+			}
+			words = nil
+			i++
+		default:
+			if err := flush(); err != nil {
+				return err
+			}
+			pipeline = p.newPipeline()
+			stage = 0
+		}
+		continued = t.kind == tokOp && (t.value == "|" || t.value == "|&" || t.value == "&&" || t.value == "||")
+	}
+	return flush()
+}
+
+// parseSubs 解析单词中的命令替换
+func (p *parser) parseSubs(words []token, offset, depth int) error {
+	for _, w := range words {
+		for _, sub := range w.subs {
+			if err := p.parse(sub, w.line+offset, depth+1); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// build 由单词和重定向构造简单命令
+func (p *parser) build(words []token, redirects []redirectToken, pipeline, stage, offset, depth int) error {
+	// 命令替换在命令执行前展开，无论命令本身是什么都会执行
+	if err := p.parseSubs(words, offset, depth); err != nil {
+		return err
+	}
+	targets := make([]token, 0, len(redirects))
+	for _, r := range redirects {
+		targets = append(targets, r.target)
+		// 分隔符未加引号的 here-document 正文会进行命令替换
+		if doc := r.target.heredoc; doc != nil && !r.target.quoted {
+			targets[len(targets)-1].subs = append(targets[len(targets)-1].subs, expansions(doc.body)...)
+		}
+	}
+	if err := p.parseSubs(targets, offset, depth); err != nil {
+		return err
+	}
+
+	for len(words) > 0 && !words[0].quoted && keywords[words[0].value] {
+		words = words[1:]
+	}
+	if len(words) > 0 && !words[0].quoted && headers[words[0].value] {
+		return nil
+	}
+
+	cmd := Command{Pipeline: pipeline, Stage: stage}
+	for len(words) > 0 && assignmentRegexp.MatchString(words[0].value) {
+		cmd.Assignments = append(cmd.Assignments, words[0].value)
+		words = words[1:]
+	}
+	for _, r := range redirects {
+		redirect := Redirect{Op: r.op, Target: r.target.value}
+		if r.target.heredoc != nil {
+			redirect.Body = r.target.heredoc.body
+		}
+		cmd.Redirects = append(cmd.Redirects, redirect)
+	}
+
+	if len(words) == 0 {
+		// 仅包含重定向的命令（如 > file）同样会写入文件
+		if len(cmd.Redirects) > 0 {
+			cmd.Line = redirects[0].target.line + offset
+			p.commands = append(p.commands, cmd)
+		}
+		return nil
+	}
+	cmd.Line = words[0].line + offset
+	ws := make([]word, 0, len(words))
+	for _, w := range words {
+		ws = append(ws, word{value: w.value, dynamic: w.dynamic || w.glob})
+	}
+	return p.emit(cmd, ws, depth)
+}
+
+// emit 记录命令，并展开包装命令中实际执行的命令
+func (p *parser) emit(cmd Command, words []word, depth int) error {
+	if depth > maxDepth {
+		return fmt.Errorf("第%d行: 命令嵌套层级超过%d层", cmd.Line, maxDepth)
+	}
+	cmd.Path = words[0].value
+	cmd.Name = baseName(cmd.Path)
+	cmd.Dynamic = words[0].dynamic
+	for _, w := range words[1:] {
+		cmd.Args = append(cmd.Args, w.value)
+	}
+	p.commands = append(p.commands, cmd)
+	if cmd.Dynamic {
+		return nil
+	}
+
+	inner, scripts := unwrap(cmd, words[1:])
+	for _, in := range inner {
+		if len(in) == 0 {
+			continue
+		}
+		next := Command{Pipeline: cmd.Pipeline, Stage: cmd.Stage, Line: cmd.Line}
+		if err := p.emit(next, in, depth+1); err != nil {
+			return err
+		}
+	}
+	for _, script := range scripts {
+		if err := p.parse(script, cmd.Line, depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func baseName(name string) string {
+	if !strings.Contains(name, "/") {
+		return name
+	}
+	return path.Base(name)
+}
+
+// isRedirect 是否为重定向操作符
+func isRedirect(op string) bool {
+	switch strings.TrimLeft(op, "0123456789") {
+	case "<", ">", ">>", ">|", ">&", "<&", "<>", "&>", "&>>", "<<", "<<-", "<<<":
+		return true
+	}
+	return false
+}
+
+// stripCasePatterns 去除 case 语句中的模式部分，避免将模式误认为命令
+func stripCasePatterns(toks []token) []token {
+	out := make([]token, 0, len(toks))
+	depth := 0
+	inPattern := false
+	atStart := true
+	for i := 0; i < len(toks); i++ {
+		t := toks[i]
+		isWord := t.kind == tokWord && !t.quoted
+		if inPattern {
+			if (isWord && t.value == "esac") || (t.kind == tokOp && t.value == ")") {
+				if isWord {
+					depth--
+				}
+				inPattern = false
+				atStart = true
+				out = append(out, token{kind: tokOp, value: ";", line: t.line})
+			}
+			continue
+		}
+		switch {
+		case isWord && atStart && t.value == "case":
+			// 保留 case 头部以便解析其中的命令替换
+			depth++
+			for ; i < len(toks); i++ {
+				out = append(out, toks[i])
+				if toks[i].kind == tokWord && !toks[i].quoted && toks[i].value == "in" {
+					break
+				}
+			}
+			out = append(out, token{kind: tokOp, value: ";", line: t.line})
+			inPattern = true
+			continue
+		case depth > 0 && t.kind == tokOp && t.value == ";;":
+			inPattern = true
+		case depth > 0 && isWord && atStart && t.value == "esac":
+			depth--
+			continue
+		}
+		out = append(out, t)
+		atStart = t.kind == tokNewline || (t.kind == tokOp && !isRedirect(t.value)) || (isWord && keywords[t.value])
+	}
+	return out
+}
+
+// expansions 提取 here-document 正文中的命令替换
+func expansions(body string) []string {
+	l := &lexer{src: body, line: 1}
+	var subs []string
+	for l.pos < len(l.src) {
+		switch {
+		case l.src[l.pos] == '\\':
+			l.pos += 2
+		case l.src[l.pos] == '$' && l.peek(1) == '(':
+			l.pos++
+			sub, err := l.readBalanced('(', ')')
+			if err != nil {
+				return subs
+			}
+			if !(strings.HasPrefix(sub, "(") && strings.HasSuffix(sub, ")")) {
+				subs = append(subs, sub)
+			}
+		case l.src[l.pos] == '`':
+			sub, err := l.readBacktick()
+			if err != nil {
+				return subs
+			}
+			subs = append(subs, sub)
+		default:
+			l.pos++
+		}
+	}
+	return subs
+}
+
+// wrapperSpec 包装命令（将其余参数作为命令执行的命令）的参数格式
+type wrapperSpec struct {
+	shortArgs string   // 需要参数的短选项
+	longArgs  []string // 需要参数的长选项
+	operands  int      // 选项之后、被执行命令之前的位置参数数量
+	assigns   bool     // 是否接受 NAME=VALUE 参数
+	shell     bool     // 其余参数拼接后作为 shell 脚本执行
+}
+
+var wrappers = map[string]wrapperSpec{
+	"sudo": {shortArgs: "CDghpRrTtUu", longArgs: []string{
+		"--chdir", "--chroot", "--close-from", "--command-timeout", "--group",
+		"--host", "--other-user", "--prompt", "--role", "--type", "--user",
+	}},
+	"doas":    {shortArgs: "Cu"},
+	"env":     {shortArgs: "CSu", longArgs: []string{"--chdir", "--split-string", "--unset"}, assigns: true},
+	"nohup":   {},
+	"time":    {shortArgs: "fo", longArgs: []string{"--format", "--output"}},
+	"command": {},
+	"builtin": {},
+	"exec":    {shortArgs: "a"},
+	"nice":    {shortArgs: "n", longArgs: []string{"--adjustment"}},
+	"ionice":  {shortArgs: "cn", longArgs: []string{"--class", "--classdata"}},
+	"timeout": {shortArgs: "ks", longArgs: []string{"--kill-after", "--signal"}, operands: 1},
+	"stdbuf":  {shortArgs: "eio", longArgs: []string{"--error", "--input", "--output"}},
+	"setsid":  {},
+	"taskset": {operands: 1},
+	"chrt":    {operands: 1},
+	"chroot":  {longArgs: []string{"--groups", "--userspec"}, operands: 1},
+	"busybox": {},
+	"xargs": {shortArgs: "adEILnPs", longArgs: []string{
+		"--arg-file", "--delimiter", "--max-args", "--max-chars", "--max-procs", "--process-slot-var",
+	}},
+	"watch": {shortArgs: "n", longArgs: []string{"--interval"}, shell: true},
+}
+
+// unwrap 返回包装命令实际执行的命令，以及需要作为脚本继续解析的参数
+func unwrap(cmd Command, args []word) (inner [][]word, scripts []string) {
+	switch {
+	case shells[cmd.Name]:
+		return nil, shellScripts(cmd, args)
+	case cmd.Name == "eval":
+		return nil, []string{joinWords(args)}
+	case cmd.Name == "su" || cmd.Name == "runuser":
+		if script, ok := suScript(args); ok {
+			return nil, []string{script}
+		}
+		return nil, nil
+	case cmd.Name == "find":
+		return findExecs(args), nil
+	case cmd.Name == "command" && slices.ContainsFunc(args, func(w word) bool { return w.value == "-v" || w.value == "-V" }):
+		// command -v 仅查找命令，不执行
+		return nil, nil
+	}
+
+	spec, ok := wrappers[cmd.Name]
+	if !ok {
+		return nil, nil
+	}
+	rest, split := spec.split(args)
+	switch {
+	case split != "":
+		return nil, []string{strings.TrimSpace(split + " " + joinWords(rest))}
+	case spec.shell && len(rest) > 0:
+		return nil, []string{joinWords(rest)}
+	}
+	return [][]word{rest}, nil
+}
+
+// split 跳过包装命令自身的选项，返回被执行的命令；env -S 的参数通过 split 返回
+func (s wrapperSpec) split(args []word) (rest []word, split string) {
+	i := 0
+	for i < len(args) {
+		a := args[i].value
+		if a == "--" {
+			i++
+			break
+		}
+		if s.assigns && assignmentRegexp.MatchString(a) {
+			i++
+			continue
+		}
+		if len(a) < 2 || a[0] != '-' {
+			break
+		}
+		i++
+		if strings.HasPrefix(a, "--") {
+			name, value, hasValue := strings.Cut(a, "=")
+			if !hasValue && slices.Contains(s.longArgs, name) && i < len(args) {
+				value = args[i].value
+				i++
+			}
+			if name == "--split-string" {
+				split = value
+			}
+			continue
+		}
+		// 短选项组合，如 -u root、-uroot 或 -iu root
+		for j := 1; j < len(a); j++ {
+			if strings.IndexByte(s.shortArgs, a[j]) < 0 {
+				continue
+			}
+			value := a[j+1:]
+			if value == "" && i < len(args) {
+				value = args[i].value
+				i++
+			}
+			if a[j] == 'S' && s.assigns {
+				split = value
+			}
+			break
+		}
+	}
+	if i+s.operands > len(args) {
+		return nil, split
+	}
+	return args[i+s.operands:], split
+}
+
+// shellScripts 返回 shell 执行的脚本：-c 参数，或未指定脚本文件时来自 here-document 的标准输入
+func shellScripts(cmd Command, args []word) []string {
+	hasC := false
+	var operands []word
+	for i := 0; i < len(args); i++ {
+		a := args[i].value
+		if a == "--" || a == "-" {
+			operands = args[i+1:]
+			break
+		}
+		if len(a) < 2 || (a[0] != '-' && a[0] != '+') {
+			operands = args[i:]
+			break
+		}
+		switch {
+		case a == "-o" || a == "+o" || a == "-O" || a == "+O" || a == "--rcfile" || a == "--init-file":
+			i++
+		case a == "--command":
+			hasC = true
+		case a[0] == '-' && a[1] != '-' && strings.ContainsRune(a[1:], 'c'):
+			hasC = true
+		}
+	}
+	if hasC {
+		if len(operands) > 0 {
+			return []string{operands[0].value}
+		}
+		return nil
+	}
+	if len(operands) > 0 {
+		// 执行脚本文件，无法静态分析
+		return nil
+	}
+	var scripts []string
+	for _, r := range cmd.Redirects {
+		switch r.Op {
+		case "<<", "<<-", "0<<", "0<<-":
+			scripts = append(scripts, r.Body)
+		case "<<<", "0<<<":
+			scripts = append(scripts, r.Target)
+		}
+	}
+	return scripts
+}
+
+// suScript 返回 su -c 执行的命令
+func suScript(args []word) (string, bool) {
+	for i := 0; i < len(args); i++ {
+		a := args[i].value
+		switch {
+		case a == "-c" || a == "--command":
+			if i+1 < len(args) {
+				return args[i+1].value, true
+			}
+			return "", false
+		case strings.HasPrefix(a, "--command="):
+			return strings.TrimPrefix(a, "--command="), true
+		case a == "-s" || a == "--shell" || a == "-g" || a == "--group" || a == "-G" || a == "--supp-group" ||
+			a == "-w" || a == "--whitelist-environment":
+			i++
+		case len(a) > 2 && a[0] == '-' && a[1] != '-':
+			if idx := strings.IndexByte(a, 'c'); idx > 0 {
+				if value := a[idx+1:]; value != "" {
+					return value, true
+				}
+				if i+1 < len(args) {
+					return args[i+1].value, true
+				}
+			}
+		}
+	}
+	return "", false
+}
+
+// findExecs 返回 find -exec 等动作执行的命令
+func findExecs(args []word) [][]word {
+	var inner [][]word
+	for i := 0; i < len(args); i++ {
+		switch args[i].value {
+		case "-exec", "-execdir", "-ok", "-okdir":
+			start := i + 1
+			for i++; i < len(args) && args[i].value != ";" && args[i].value != "+"; i++ {
+			}
+			inner = append(inner, args[start:min(i, len(args))])
+		}
+	}
+	return inner
+}
+
+func joinWords(words []word) string {
+	values := make([]string, 0, len(words))
+	for _, w := range words {
+		values = append(values, w.value)
+	}
+	return strings.Join(values, " ")
+}
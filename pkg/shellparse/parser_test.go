@@ -0,0 +1,173 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package shellparse
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func commandStrings(cmds []Command) []string {
+	out := make([]string, 0, len(cmds))
+	for _, c := range cmds {
+		out = append(out, c.String())
+	}
+	return out
+}
+
+func TestParseUnwrap(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		want   []string
+	}{
+		{"plain", "rm -rf /", []string{"rm -rf /"}},
+		{"sudo", "sudo rm -rf /", []string{"sudo rm -rf /", "rm -rf /"}},
+		{"sudo options", "sudo -u root -E -- rm -rf /", []string{"sudo -u root -E -- rm -rf /", "rm -rf /"}},
+		{"nested sudo", "sudo sudo rm -rf /", []string{"sudo sudo rm -rf /", "sudo rm -rf /", "rm -rf /"}},
+		{"env", "env FOO=1 -u BAR rm -rf /", []string{"env FOO=1 -u BAR rm -rf /", "rm -rf /"}},
+		{"env split string", "env -S 'rm -rf /'", []string{"env -S 'rm -rf /'", "rm -rf /"}},
+		{"bash -c", "bash -c 'rm -rf /'", []string{"bash -c 'rm -rf /'", "rm -rf /"}},
+		{"sh -c combined flags", "sh -ec \"rm -rf /\"", []string{"sh -ec 'rm -rf /'", "rm -rf /"}},
+		{"bash here-string", "bash <<< 'rm -rf /'", []string{"bash <<< 'rm -rf /'", "rm -rf /"}},
+		{"sudo bash -c", "sudo bash -c 'rm -rf /'", []string{"sudo bash -c 'rm -rf /'", "bash -c 'rm -rf /'", "rm -rf /"}},
+		{"eval", "eval 'rm -rf /'", []string{"eval 'rm -rf /'", "rm -rf /"}},
+		{"eval words", "eval rm -rf /", []string{"eval rm -rf /", "rm -rf /"}},
+		{"su -c", "su - root -c 'rm -rf /'", []string{"su - root -c 'rm -rf /'", "rm -rf /"}},
+		{"find -exec", `find /data -exec rm -rf {} \;`, []string{"find /data -exec rm -rf {} ;", "rm -rf {}"}},
+		{"xargs", "echo / | xargs rm -rf", []string{"echo /", "xargs rm -rf", "rm -rf"}},
+		{"command substitution", "echo $(rm -rf /)", []string{"rm -rf /", "echo '$(rm -rf /)'"}},
+		{"backticks", "echo `rm -rf /`", []string{"rm -rf /", "echo '`rm -rf /`'"}},
+		{"command -v", "command -v rm", []string{"command -v rm"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmds, err := Parse(tt.script)
+			if err != nil {
+				t.Fatalf("Parse(%q) error: %v", tt.script, err)
+			}
+			if got := commandStrings(cmds); !slices.Equal(got, tt.want) {
+				t.Errorf("Parse(%q) = %q, want %q", tt.script, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseQuoting(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		want   string
+	}{
+		{"empty quotes", "r''m -rf /", "rm -rf /"},
+		{"double quoted name", `"rm" -rf /`, "rm -rf /"},
+		{"backslash", `r\m -rf /`, "rm -rf /"},
+		{"split quotes", `'r'"m" -r'f' /`, "rm -rf /"},
+		{"ansi-c", `$'\x72\x6d' -rf /`, "rm -rf /"},
+		{"absolute path", "/bin/rm -rf /", "rm -rf /"},
+		{"line continuation", "rm \\\n -rf /", "rm -rf /"},
+		{"quoted argument", `rm -rf "/ "`, "rm -rf '/ '"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmds, err := Parse(tt.script)
+			if err != nil {
+				t.Fatalf("Parse(%q) error: %v", tt.script, err)
+			}
+			if len(cmds) != 1 || cmds[0].String() != tt.want {
+				t.Errorf("Parse(%q) = %q, want [%q]", tt.script, commandStrings(cmds), tt.want)
+			}
+		})
+	}
+}
+
+func TestParseStructure(t *testing.T) {
+	cmds, err := Parse("FOO=1 curl -s http://x | sh > /tmp/out\nls; reboot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Command{
+		{Name: "curl", Path: "curl", Args: []string{"-s", "http://x"}, Assignments: []string{"FOO=1"}, Pipeline: 1, Stage: 0, Line: 1},
+		{Name: "sh", Path: "sh", Redirects: []Redirect{{Op: ">", Target: "/tmp/out"}}, Pipeline: 1, Stage: 1, Line: 1},
+		{Name: "ls", Path: "ls", Pipeline: 2, Stage: 0, Line: 2},
+		{Name: "reboot", Path: "reboot", Pipeline: 3, Stage: 0, Line: 2},
+	}
+	if len(cmds) != len(want) {
+		t.Fatalf("got %d commands %q, want %d", len(cmds), commandStrings(cmds), len(want))
+	}
+	for i, c := range cmds {
+		w := want[i]
+		if c.Name != w.Name || c.Path != w.Path || !slices.Equal(c.Args, w.Args) ||
+			!slices.Equal(c.Assignments, w.Assignments) || !slices.Equal(c.Redirects, w.Redirects) ||
+			c.Pipeline != w.Pipeline || c.Stage != w.Stage || c.Line != w.Line {
+			t.Errorf("command %d = %#v, want %#v", i, c, w)
+		}
+	}
+}
+
+func TestParseDynamic(t *testing.T) {
+	tests := []struct {
+		script  string
+		dynamic bool
+	}{
+		{"rm -rf /", false},
+		{"$CMD -rf /", true},
+		{"${CMD} -rf /", true},
+		{"$(echo rm) -rf /", true},
+		{"r* -rf /", true},
+		{"rm -rf $DIR", false},
+	}
+	for _, tt := range tests {
+		cmds, err := Parse(tt.script)
+		if err != nil {
+			t.Fatalf("Parse(%q) error: %v", tt.script, err)
+		}
+		if got := slices.ContainsFunc(cmds, func(c Command) bool { return c.Dynamic }); got != tt.dynamic {
+			t.Errorf("Parse(%q) dynamic = %v, want %v (%q)", tt.script, got, tt.dynamic, commandStrings(cmds))
+		}
+	}
+}
+
+func TestParseIncomplete(t *testing.T) {
+	tests := []struct {
+		script     string
+		incomplete bool
+		parseErr   bool
+	}{
+		{"echo 'abc", true, true},
+		{`echo "abc`, true, true},
+		{"echo $(ls", true, true},
+		{"cat <<EOF\nabc", true, false},
+		{"ls |", true, false},
+		{"ls \\", true, false},
+		{"echo 'abc'", false, false},
+		{"cat <<EOF\nabc\nEOF", false, false},
+	}
+	for _, tt := range tests {
+		if got := Incomplete(tt.script); got != tt.incomplete {
+			t.Errorf("Incomplete(%q) = %v, want %v", tt.script, got, tt.incomplete)
+		}
+		_, err := Parse(tt.script)
+		if tt.parseErr != errors.Is(err, ErrIncomplete) {
+			t.Errorf("Parse(%q) error = %v, want ErrIncomplete: %v", tt.script, err, tt.parseErr)
+		}
+	}
+}
@@ -31,60 +31,75 @@ import (
 	"golang.org/x/crypto/ssh"
 )
 
+// defaultTimeout 默认连接超时时间
+const defaultTimeout = 10 * time.Second
+
 // Client SSH客户端
 type Client struct {
 	client *ssh.Client
+	lease  *Lease // 来自连接池时不为空，Close 时归还而不是关闭
 }
 
 // NewClient 创建SSH客户端
-func NewClient(host string, port int, username, password string, privateKey []byte, passphrase string) (*Client, error) {
-	var authMethods []ssh.AuthMethod
-
-	// 优先使用私钥认证
-	if len(privateKey) > 0 {
-		var signer ssh.Signer
-		var err error
-
-		if passphrase != "" {
-			signer, err = ssh.ParsePrivateKeyWithPassphrase(privateKey, []byte(passphrase))
-		} else {
-			signer, err = ssh.ParsePrivateKey(privateKey)
-		}
+// hostKeyCallback 用于校验主机密钥，通常由 NewHostKeyCallback 创建；
+// hostKeyAlgorithms 限定协商的主机密钥算法，通常由 HostKeyAlgorithms 获取，为空时使用默认算法；
+// jumpHosts 为可选的跳板机链（由外到内）
+func NewClient(host string, port int, username, password string, privateKey []byte, passphrase string, hostKeyCallback ssh.HostKeyCallback, hostKeyAlgorithms []string, jumpHosts ...*JumpHost) (*Client, error) {
+	client, err := DialClient(host, port, username, password, privateKey, passphrase, hostKeyCallback, hostKeyAlgorithms, jumpHosts...)
+	if err != nil {
+		return nil, err
+	}
 
-		if err != nil {
-			return nil, fmt.Errorf("解析私钥失败: %w", err)
-		}
+	return &Client{client: client}, nil
+}
 
-		authMethods = append(authMethods, ssh.PublicKeys(signer))
-	}
+// WrapClient 包装已建立的SSH连接，调用 Close 时关闭连接
+func WrapClient(client *ssh.Client) *Client {
+	return &Client{client: client}
+}
 
-	// 密码认证
-	if password != "" {
-		authMethods = append(authMethods, ssh.Password(password))
+// DialClient 建立SSH连接，参数同 NewClient，可作为连接池的 DialFunc 使用
+func DialClient(host string, port int, username, password string, privateKey []byte, passphrase string, hostKeyCallback ssh.HostKeyCallback, hostKeyAlgorithms []string, jumpHosts ...*JumpHost) (*ssh.Client, error) {
+	authMethods, err := AuthMethods(password, privateKey, passphrase)
+	if err != nil {
+		return nil, err
 	}
+	return dialWithAuth(host, port, username, authMethods, hostKeyCallback, hostKeyAlgorithms, jumpHosts)
+}
 
-	if len(authMethods) == 0 {
-		return nil, fmt.Errorf("至少需要一种认证方式")
+// DialClientWithSigner 使用指定的签名器（如SSH证书）建立SSH连接，可作为连接池的 DialFunc 使用
+func DialClientWithSigner(host string, port int, username string, signer ssh.Signer, hostKeyCallback ssh.HostKeyCallback, hostKeyAlgorithms []string, jumpHosts ...*JumpHost) (*ssh.Client, error) {
+	return dialWithAuth(host, port, username, []ssh.AuthMethod{ssh.PublicKeys(signer)}, hostKeyCallback, hostKeyAlgorithms, jumpHosts)
+}
+
+// dialWithAuth 使用给定的认证方式建立SSH连接
+func dialWithAuth(host string, port int, username string, authMethods []ssh.AuthMethod, hostKeyCallback ssh.HostKeyCallback, hostKeyAlgorithms []string, jumpHosts []*JumpHost) (*ssh.Client, error) {
+	if hostKeyCallback == nil {
+		return nil, fmt.Errorf("未配置主机密钥校验")
 	}
 
 	config := &ssh.ClientConfig{
-		User:            username,
-		Auth:            authMethods,
-		HostKeyCallback: ssh.InsecureIgnoreHostKey(), // 生产环境应该验证主机密钥
-		Timeout:         10 * time.Second,
+		User:              username,
+		Auth:              authMethods,
+		HostKeyCallback:   hostKeyCallback,
+		HostKeyAlgorithms: hostKeyAlgorithms,
+		Timeout:           defaultTimeout,
 	}
 
 	address := fmt.Sprintf("%s:%d", host, port)
-	client, err := ssh.Dial("tcp", address, config)
+	client, err := Dial(address, config, jumpHosts)
 	if err != nil {
 		return nil, fmt.Errorf("SSH连接失败: %w", err)
 	}
 
-	return &Client{client: client}, nil
+	return client, nil
 }
 
-// Close 关闭连接
+// Close 关闭连接，来自连接池的连接则归还连接池
 func (c *Client) Close() error {
+	if c.lease != nil {
+		return c.lease.Close()
+	}
 	if c.client != nil {
 		return c.client.Close()
 	}
@@ -146,6 +161,39 @@ func (c *Client) ExecuteWithTimeout(command string, timeout time.Duration) (stri
 	return stdout.String(), nil
 }
 
+// ExecuteWithInput 执行命令并通过标准输入传入数据（带超时），用于传递不应出现在命令行中的敏感内容
+func (c *Client) ExecuteWithInput(command string, input []byte, timeout time.Duration) (string, error) {
+	session, err := c.client.NewSession()
+	if err != nil {
+		return "", fmt.Errorf("创建session失败: %w", err)
+	}
+	defer session.Close()
+
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	session.Stdin = bytes.NewReader(input)
+	session.Stdout = &stdout
+	session.Stderr = &stderr
+
+	done := make(chan error, 1)
+	go func() {
+		done <- session.Run(command)
+	}()
+
+	select {
+	case <-time.After(timeout):
+		session.Signal(ssh.SIGTERM)
+		session.Close()
+		return "", fmt.Errorf("命令执行超时")
+	case err := <-done:
+		if err != nil {
+			return "", fmt.Errorf("命令执行失败: %s, stderr: %s", err, stderr.String())
+		}
+	}
+
+	return stdout.String(), nil
+}
+
 // TestConnection 测试连接
 func (c *Client) TestConnection() error {
 	_, err := c.Execute("echo ok")
@@ -162,8 +210,30 @@ type FileInfo struct {
 	Name    string `json:"name"`
 	Size    int64  `json:"size"`
 	Mode    string `json:"mode"`
+	Perm    string `json:"perm"` // 八进制权限，如 0644
 	IsDir   bool   `json:"isDir"`
 	ModTime string `json:"modTime"`
+	MTime   int64  `json:"mtime"` // 修改时间（Unix 秒），在线编辑时用于检测并发修改
+	UID     uint32 `json:"uid"`
+	GID     uint32 `json:"gid"`
+}
+
+// newFileInfo 从 SFTP 文件信息构造 FileInfo
+func newFileInfo(name string, info os.FileInfo) *FileInfo {
+	fileInfo := &FileInfo{
+		Name:    name,
+		Size:    info.Size(),
+		Mode:    info.Mode().String(),
+		Perm:    fmt.Sprintf("%04o", info.Mode().Perm()),
+		IsDir:   info.IsDir(),
+		ModTime: info.ModTime().Format("2006-01-02 15:04:05"),
+		MTime:   info.ModTime().Unix(),
+	}
+	if stat, ok := info.Sys().(*sftp.FileStat); ok {
+		fileInfo.UID = stat.UID
+		fileInfo.GID = stat.GID
+	}
+	return fileInfo
 }
 
 // ListDir 列出目录内容
@@ -181,14 +251,7 @@ func (c *Client) ListDir(remotePath string) ([]*FileInfo, error) {
 
 	var fileList []*FileInfo
 	for _, file := range files {
-		fileInfo := &FileInfo{
-			Name:    file.Name(),
-			Size:    file.Size(),
-			Mode:    file.Mode().String(),
-			IsDir:   file.IsDir(),
-			ModTime: file.ModTime().Format("2006-01-02 15:04:05"),
-		}
-		fileList = append(fileList, fileInfo)
+		fileList = append(fileList, newFileInfo(file.Name(), file))
 	}
 
 	return fileList, nil
@@ -365,11 +428,5 @@ func (c *Client) StatFile(remotePath string) (*FileInfo, error) {
 		return nil, fmt.Errorf("获取文件信息失败: %w", err)
 	}
 
-	return &FileInfo{
-		Name:    filepath.Base(remotePath),
-		Size:    fileInfo.Size(),
-		Mode:    fileInfo.Mode().String(),
-		IsDir:   fileInfo.IsDir(),
-		ModTime: fileInfo.ModTime().Format("2006-01-02 15:04:05"),
-	}, nil
+	return newFileInfo(filepath.Base(remotePath), fileInfo), nil
 }
@@ -0,0 +1,229 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package sshclient
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// KnownHostKey 已信任的主机密钥
+type KnownHostKey struct {
+	KeyType     string // 密钥类型，如 ssh-ed25519
+	Fingerprint string // SHA256指纹，如 SHA256:xxxx
+	Pinned      bool   // 是否为手动固定的密钥
+}
+
+// HostKeyStore 主机密钥信任存储
+// 首次连接时记录主机密钥（TOFU），之后每次连接都与记录的指纹比对
+type HostKeyStore interface {
+	// GetHostKey 获取主机已信任的密钥，尚未记录时返回 nil
+	GetHostKey(hostID uint) (*KnownHostKey, error)
+	// TrustHostKey 首次连接时记录主机密钥
+	TrustHostKey(hostID uint, key *KnownHostKey) error
+	// ReportMismatch 主机密钥与记录不一致时回调，用于写入审计事件
+	ReportMismatch(hostID uint, address string, expected, actual *KnownHostKey)
+}
+
+// HostKeyMismatchError 主机密钥不匹配错误
+type HostKeyMismatchError struct {
+	HostID   uint
+	Address  string
+	Expected *KnownHostKey
+	Actual   *KnownHostKey
+}
+
+func (e *HostKeyMismatchError) Error() string {
+	return fmt.Sprintf("主机 %s 的SSH主机密钥已变更（记录: %s %s，实际: %s %s），可能存在中间人攻击，连接已被拒绝；如确认主机已重装，请在主机管理中重置主机密钥",
+		e.Address, e.Expected.KeyType, e.Expected.Fingerprint, e.Actual.KeyType, e.Actual.Fingerprint)
+}
+
+// IsHostKeyMismatch 判断错误是否为主机密钥不匹配
+func IsHostKeyMismatch(err error) bool {
+	var mismatch *HostKeyMismatchError
+	return errors.As(err, &mismatch)
+}
+
+// NewHostKeyCallback 创建基于信任存储的主机密钥校验回调
+// 所有SSH连接（主机管理、Web终端、任务执行、插件）都应使用该回调
+func NewHostKeyCallback(store HostKeyStore, hostID uint) ssh.HostKeyCallback {
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		if store == nil {
+			return fmt.Errorf("未配置主机密钥存储，拒绝连接")
+		}
+		if hostID == 0 {
+			return fmt.Errorf("无法确定主机ID，拒绝连接 %s", hostname)
+		}
+
+		actual := &KnownHostKey{
+			KeyType:     key.Type(),
+			Fingerprint: ssh.FingerprintSHA256(key),
+		}
+
+		known, err := store.GetHostKey(hostID)
+		if err != nil {
+			return fmt.Errorf("读取主机密钥失败: %w", err)
+		}
+
+		// 首次连接，信任并记录
+		if known == nil || known.Fingerprint == "" {
+			if err := store.TrustHostKey(hostID, actual); err != nil {
+				return fmt.Errorf("记录主机密钥失败: %w", err)
+			}
+			return nil
+		}
+
+		if known.Fingerprint == actual.Fingerprint {
+			return nil
+		}
+
+		store.ReportMismatch(hostID, hostname, known, actual)
+		return &HostKeyMismatchError{
+			HostID:   hostID,
+			Address:  hostname,
+			Expected: known,
+			Actual:   actual,
+		}
+	}
+}
+
+// HostKeyAlgorithms 返回主机已记录密钥的类型对应的主机密钥算法，用于 ssh.ClientConfig.HostKeyAlgorithms
+// 主机有多种类型的主机密钥时只协商已记录的类型，避免协商到其他类型而被误判为密钥变更；
+// 尚未记录、只固定了指纹或类型不受支持时返回 nil，使用默认算法
+func HostKeyAlgorithms(store HostKeyStore, hostID uint) []string {
+	if store == nil || hostID == 0 {
+		return nil
+	}
+	known, err := store.GetHostKey(hostID)
+	if err != nil || known == nil || known.Fingerprint == "" {
+		return nil
+	}
+
+	algorithms := []string{known.KeyType}
+	// RSA 密钥可以使用 SHA-2 签名算法协商
+	if known.KeyType == ssh.KeyAlgoRSA {
+		algorithms = []string{ssh.KeyAlgoRSASHA512, ssh.KeyAlgoRSASHA256, ssh.KeyAlgoRSA}
+	}
+	supported := ssh.SupportedAlgorithms().HostKeys
+	if !slices.Contains(supported, algorithms[0]) {
+		return nil
+	}
+	return algorithms
+}
+
+// ParseHostKey 解析手动固定的主机密钥
+// 支持 authorized_keys / known_hosts 格式的公钥，或 SHA256:xxxx 格式的指纹
+func ParseHostKey(value string) (*KnownHostKey, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, fmt.Errorf("主机密钥不能为空")
+	}
+
+	if strings.HasPrefix(value, "SHA256:") {
+		return &KnownHostKey{Fingerprint: value, Pinned: true}, nil
+	}
+
+	// known_hosts 格式: host keytype base64
+	if _, _, pubKey, _, _, err := ssh.ParseKnownHosts([]byte(value)); err == nil {
+		return &KnownHostKey{KeyType: pubKey.Type(), Fingerprint: ssh.FingerprintSHA256(pubKey), Pinned: true}, nil
+	}
+
+	pubKey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(value))
+	if err != nil {
+		return nil, fmt.Errorf("无法解析主机公钥: %w", err)
+	}
+
+	return &KnownHostKey{KeyType: pubKey.Type(), Fingerprint: ssh.FingerprintSHA256(pubKey), Pinned: true}, nil
+}
+
+// ScanHostKey 获取主机当前提供的主机密钥（不进行认证，也不写入信任存储）
+// jumpHosts 不为空时经跳板机扫描
+func ScanHostKey(host string, port int, jumpHosts ...*JumpHost) (*KnownHostKey, error) {
+	var scanned *KnownHostKey
+	errScanned := errors.New("host key scanned")
+
+	config := &ssh.ClientConfig{
+		User: "opshub-scan",
+		HostKeyCallback: func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+			scanned = &KnownHostKey{KeyType: key.Type(), Fingerprint: ssh.FingerprintSHA256(key)}
+			return errScanned
+		},
+		Timeout: defaultTimeout,
+	}
+
+	client, err := Dial(net.JoinHostPort(host, fmt.Sprintf("%d", port)), config, jumpHosts)
+	if client != nil {
+		client.Close()
+	}
+	if scanned != nil {
+		return scanned, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("获取主机密钥失败: %w", err)
+	}
+	return nil, fmt.Errorf("获取主机密钥失败")
+}
+
+// FetchHostKey 获取主机当前的公钥，并经 hostKeyCallback 校验后返回（不进行认证）
+// 用于为 ansible-playbook 等外部工具生成 known_hosts，使其与平台共用同一份信任记录；
+// hostKeyAlgorithms 通常由 HostKeyAlgorithms 获取，为空时使用默认算法；jumpHosts 不为空时经跳板机获取
+func FetchHostKey(host string, port int, hostKeyCallback ssh.HostKeyCallback, hostKeyAlgorithms []string, jumpHosts ...*JumpHost) (ssh.PublicKey, error) {
+	if hostKeyCallback == nil {
+		return nil, fmt.Errorf("未配置主机密钥校验")
+	}
+
+	var fetched ssh.PublicKey
+	var verifyErr error
+	errFetched := errors.New("host key fetched")
+
+	config := &ssh.ClientConfig{
+		User: "opshub-scan",
+		HostKeyCallback: func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+			if err := hostKeyCallback(hostname, remote, key); err != nil {
+				verifyErr = err
+				return err
+			}
+			fetched = key
+			return errFetched
+		},
+		HostKeyAlgorithms: hostKeyAlgorithms,
+		Timeout:           defaultTimeout,
+	}
+
+	client, err := Dial(net.JoinHostPort(host, fmt.Sprintf("%d", port)), config, jumpHosts)
+	if client != nil {
+		client.Close()
+	}
+	if verifyErr != nil {
+		return nil, verifyErr
+	}
+	if fetched != nil {
+		return fetched, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("获取主机密钥失败: %w", err)
+	}
+	return nil, fmt.Errorf("获取主机密钥失败")
+}
@@ -0,0 +1,200 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package sshclient
+
+import (
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// JumpHost 跳板机
+type JumpHost struct {
+	Name            string
+	Host            string
+	Port            int
+	Username        string
+	Password        string
+	PrivateKey      []byte // Signer 为证书签名器时是证书对应的私钥，供 ssh 等需要密钥文件的外部工具使用
+	Passphrase      string
+	Signer          ssh.Signer // 不为空时优先使用，如平台签发的SSH证书
+	HostKeyCallback ssh.HostKeyCallback
+	// HostKeyAlgorithms 限定协商的主机密钥算法，为空时使用默认算法
+	HostKeyAlgorithms []string
+}
+
+// Address 跳板机地址
+func (j *JumpHost) Address() string {
+	return net.JoinHostPort(j.Host, fmt.Sprintf("%d", j.Port))
+}
+
+// authMethods 跳板机的认证方式
+func (j *JumpHost) authMethods() ([]ssh.AuthMethod, error) {
+	if j.Signer != nil {
+		return []ssh.AuthMethod{ssh.PublicKeys(j.Signer)}, nil
+	}
+	return AuthMethods(j.Password, j.PrivateKey, j.Passphrase)
+}
+
+// AuthMethods 根据凭证构造SSH认证方式，私钥优先
+func AuthMethods(password string, privateKey []byte, passphrase string) ([]ssh.AuthMethod, error) {
+	var authMethods []ssh.AuthMethod
+
+	// 优先使用私钥认证
+	if len(privateKey) > 0 {
+		var signer ssh.Signer
+		var err error
+
+		if passphrase != "" {
+			signer, err = ssh.ParsePrivateKeyWithPassphrase(privateKey, []byte(passphrase))
+		} else {
+			signer, err = ssh.ParsePrivateKey(privateKey)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("解析私钥失败: %w", err)
+		}
+
+		authMethods = append(authMethods, ssh.PublicKeys(signer))
+	}
+
+	// 密码认证
+	if password != "" {
+		authMethods = append(authMethods, ssh.Password(password))
+	}
+
+	if len(authMethods) == 0 {
+		return nil, fmt.Errorf("至少需要一种认证方式")
+	}
+
+	return authMethods, nil
+}
+
+// Dial 连接目标主机，jumpHosts 不为空时按顺序（由外到内）经跳板机建立隧道
+// 返回的客户端关闭时会一并关闭整条跳板机链
+func Dial(address string, config *ssh.ClientConfig, jumpHosts []*JumpHost) (*ssh.Client, error) {
+	if len(jumpHosts) == 0 {
+		return ssh.Dial("tcp", address, config)
+	}
+
+	var client *ssh.Client
+	for i, jump := range jumpHosts {
+		authMethods, err := jump.authMethods()
+		if err != nil {
+			closeClient(client)
+			return nil, fmt.Errorf("跳板机 %s: %w", jump.Name, err)
+		}
+		if jump.HostKeyCallback == nil {
+			closeClient(client)
+			return nil, fmt.Errorf("跳板机 %s: 未配置主机密钥校验", jump.Name)
+		}
+
+		jumpConfig := &ssh.ClientConfig{
+			User:              jump.Username,
+			Auth:              authMethods,
+			HostKeyCallback:   jump.HostKeyCallback,
+			HostKeyAlgorithms: jump.HostKeyAlgorithms,
+			Timeout:           config.Timeout,
+		}
+
+		if i == 0 {
+			client, err = ssh.Dial("tcp", jump.Address(), jumpConfig)
+		} else {
+			client, err = dialThrough(client, jump.Address(), jumpConfig)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("连接跳板机 %s(%s) 失败: %w", jump.Name, jump.Address(), err)
+		}
+	}
+
+	target, err := dialThrough(client, address, config)
+	if err != nil {
+		return nil, fmt.Errorf("经跳板机连接 %s 失败: %w", address, err)
+	}
+	return target, nil
+}
+
+// dialThrough 通过已建立的SSH连接转发到下一跳，失败时关闭上一跳
+func dialThrough(via *ssh.Client, address string, config *ssh.ClientConfig) (*ssh.Client, error) {
+	conn, err := via.Dial("tcp", address)
+	if err != nil {
+		via.Close()
+		return nil, err
+	}
+
+	chained := &chainedConn{Conn: conn, parent: via}
+
+	type handshakeResult struct {
+		conn  ssh.Conn
+		chans <-chan ssh.NewChannel
+		reqs  <-chan *ssh.Request
+		err   error
+	}
+	done := make(chan handshakeResult, 1)
+	go func() {
+		c, chans, reqs, err := ssh.NewClientConn(chained, address, config)
+		done <- handshakeResult{c, chans, reqs, err}
+	}()
+
+	// 隧道连接不支持设置超时，握手超时后关闭连接使握手返回
+	timeout := config.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case res := <-done:
+		if res.err != nil {
+			chained.Close()
+			return nil, res.err
+		}
+		return ssh.NewClient(res.conn, res.chans, res.reqs), nil
+	case <-timer.C:
+		chained.Close()
+		<-done
+		return nil, fmt.Errorf("连接超时")
+	}
+}
+
+// chainedConn 经跳板机转发的连接，关闭时同时关闭上一跳
+type chainedConn struct {
+	net.Conn
+	parent *ssh.Client
+	once   sync.Once
+}
+
+func (c *chainedConn) Close() error {
+	err := c.Conn.Close()
+	c.once.Do(func() {
+		c.parent.Close()
+	})
+	return err
+}
+
+func closeClient(client *ssh.Client) {
+	if client != nil {
+		client.Close()
+	}
+}
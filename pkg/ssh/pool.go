@@ -0,0 +1,314 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package sshclient
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// PoolConfig 连接池配置
+type PoolConfig struct {
+	// IdleTimeout 连接空闲超过该时间后被关闭
+	IdleTimeout time.Duration
+	// KeepAliveInterval 保活探测间隔，同时也是空闲回收的检查间隔
+	KeepAliveInterval time.Duration
+	// ProbeAfter 连接空闲超过该时间后，复用前先进行健康探测
+	ProbeAfter time.Duration
+	// MaxSessionsPerHost 每个主机同时使用的最大会话数（sshd 默认 MaxSessions 为 10）
+	MaxSessionsPerHost int
+}
+
+// DefaultPoolConfig 默认连接池配置
+func DefaultPoolConfig() PoolConfig {
+	return PoolConfig{
+		IdleTimeout:        5 * time.Minute,
+		KeepAliveInterval:  30 * time.Second,
+		ProbeAfter:         10 * time.Second,
+		MaxSessionsPerHost: 8,
+	}
+}
+
+// DialFunc 建立新SSH连接的函数
+type DialFunc func() (*ssh.Client, error)
+
+// Pool SSH连接池
+// 同一个 key（主机+凭证）复用一条SSH连接，在其上多路复用会话
+type Pool struct {
+	config  PoolConfig
+	mu      sync.Mutex
+	entries map[string]*poolEntry
+	stop    chan struct{}
+	once    sync.Once
+}
+
+type poolEntry struct {
+	key string
+	sem chan struct{} // 每主机并发限制
+
+	mu       sync.Mutex
+	client   *ssh.Client
+	lastUsed time.Time
+
+	refs int // 等待中与使用中的租用数，由 Pool.mu 保护
+}
+
+// Lease 从连接池租用的SSH连接
+// Close/Release 只归还连接，不会关闭底层连接
+type Lease struct {
+	*ssh.Client
+	pool  *Pool
+	entry *poolEntry
+	once  sync.Once
+}
+
+var (
+	defaultPool     *Pool
+	defaultPoolOnce sync.Once
+)
+
+// DefaultPool 全局共享的连接池，主机管理、任务执行与各插件共用
+func DefaultPool() *Pool {
+	defaultPoolOnce.Do(func() {
+		defaultPool = NewPool(DefaultPoolConfig())
+	})
+	return defaultPool
+}
+
+// NewPool 创建连接池
+func NewPool(config PoolConfig) *Pool {
+	defaults := DefaultPoolConfig()
+	if config.IdleTimeout <= 0 {
+		config.IdleTimeout = defaults.IdleTimeout
+	}
+	if config.KeepAliveInterval <= 0 {
+		config.KeepAliveInterval = defaults.KeepAliveInterval
+	}
+	if config.ProbeAfter <= 0 {
+		config.ProbeAfter = defaults.ProbeAfter
+	}
+	if config.MaxSessionsPerHost <= 0 {
+		config.MaxSessionsPerHost = defaults.MaxSessionsPerHost
+	}
+
+	p := &Pool{
+		config:  config,
+		entries: make(map[string]*poolEntry),
+		stop:    make(chan struct{}),
+	}
+	go p.maintain()
+	return p
+}
+
+// Acquire 租用连接，key 相同的请求共用一条连接
+// 超过每主机并发限制时阻塞等待，直到有会话归还或 ctx 结束
+func (p *Pool) Acquire(ctx context.Context, key string, dial DialFunc) (*Lease, error) {
+	p.mu.Lock()
+	entry, ok := p.entries[key]
+	if !ok {
+		entry = &poolEntry{key: key, sem: make(chan struct{}, p.config.MaxSessionsPerHost)}
+		p.entries[key] = entry
+	}
+	entry.refs++
+	p.mu.Unlock()
+
+	select {
+	case entry.sem <- struct{}{}:
+	case <-ctx.Done():
+		p.unref(entry)
+		return nil, fmt.Errorf("等待SSH连接超时: %w", ctx.Err())
+	}
+
+	client, err := p.connect(entry, dial)
+	if err != nil {
+		<-entry.sem
+		p.unref(entry)
+		return nil, err
+	}
+
+	return &Lease{Client: client, pool: p, entry: entry}, nil
+}
+
+// Client 租用连接并包装为 Client，调用 Close 时归还连接
+func (p *Pool) Client(ctx context.Context, key string, dial DialFunc) (*Client, error) {
+	lease, err := p.Acquire(ctx, key, dial)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{client: lease.Client, lease: lease}, nil
+}
+
+// Close 关闭连接池及所有连接
+func (p *Pool) Close() {
+	p.once.Do(func() {
+		close(p.stop)
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		for key, entry := range p.entries {
+			entry.closeClient(nil)
+			delete(p.entries, key)
+		}
+	})
+}
+
+// connect 获取条目上的可用连接，必要时探测或重新建立
+func (p *Pool) connect(entry *poolEntry, dial DialFunc) (*ssh.Client, error) {
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	if entry.client != nil && time.Since(entry.lastUsed) > p.config.ProbeAfter {
+		if err := probe(entry.client); err != nil {
+			entry.client.Close()
+			entry.client = nil
+		}
+	}
+
+	if entry.client == nil {
+		client, err := dial()
+		if err != nil {
+			return nil, err
+		}
+		entry.client = client
+
+		// 连接断开时从条目中移除，下次租用时重新建立
+		go func() {
+			client.Wait()
+			entry.closeClient(client)
+		}()
+	}
+
+	entry.lastUsed = time.Now()
+	return entry.client, nil
+}
+
+func (p *Pool) unref(entry *poolEntry) {
+	p.mu.Lock()
+	entry.refs--
+	p.mu.Unlock()
+}
+
+// maintain 定期回收空闲连接并对保留的连接发送保活请求
+func (p *Pool) maintain() {
+	ticker := time.NewTicker(p.config.KeepAliveInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+			p.evictIdle()
+			p.keepAlive()
+		}
+	}
+}
+
+func (p *Pool) evictIdle() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for key, entry := range p.entries {
+		if entry.refs > 0 {
+			continue
+		}
+		entry.mu.Lock()
+		idle := entry.client == nil || time.Since(entry.lastUsed) > p.config.IdleTimeout
+		entry.mu.Unlock()
+		if idle {
+			entry.closeClient(nil)
+			delete(p.entries, key)
+		}
+	}
+}
+
+func (p *Pool) keepAlive() {
+	p.mu.Lock()
+	entries := make([]*poolEntry, 0, len(p.entries))
+	for _, entry := range p.entries {
+		entries = append(entries, entry)
+	}
+	p.mu.Unlock()
+
+	for _, entry := range entries {
+		entry.mu.Lock()
+		client := entry.client
+		entry.mu.Unlock()
+		if client == nil {
+			continue
+		}
+		if err := probe(client); err != nil {
+			entry.closeClient(client)
+		}
+	}
+}
+
+// closeClient 关闭条目上的连接；client 不为 nil 时仅在仍是同一连接时关闭
+func (e *poolEntry) closeClient(client *ssh.Client) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.client == nil || (client != nil && e.client != client) {
+		return
+	}
+	e.client.Close()
+	e.client = nil
+}
+
+// probe 发送保活请求检测连接是否可用
+func probe(client *ssh.Client) error {
+	done := make(chan error, 1)
+	go func() {
+		_, _, err := client.SendRequest("keepalive@openssh.com", true, nil)
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(defaultTimeout):
+		return fmt.Errorf("保活探测超时")
+	}
+}
+
+// Release 归还连接
+func (l *Lease) Release() {
+	l.once.Do(func() {
+		l.entry.mu.Lock()
+		l.entry.lastUsed = time.Now()
+		l.entry.mu.Unlock()
+		<-l.entry.sem
+		l.pool.unref(l.entry)
+	})
+}
+
+// Close 归还连接（不关闭底层连接），便于替换原先直接关闭连接的调用
+func (l *Lease) Close() error {
+	l.Release()
+	return nil
+}
+
+// Invalidate 连接不可用时关闭底层连接并归还，下次租用时重新建立
+func (l *Lease) Invalidate() {
+	l.entry.closeClient(l.Client)
+	l.Release()
+}
@@ -0,0 +1,387 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package sshclient
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/pkg/sftp"
+)
+
+// 目录打包格式
+const (
+	ArchiveTarGz = "tar.gz"
+	ArchiveZip   = "zip"
+)
+
+// ErrChunkOffset 分片偏移与远程已上传的大小不一致
+var ErrChunkOffset = errors.New("分片偏移与已上传的大小不一致")
+
+// withSFTP 创建 SFTP 客户端执行 fn，结束后关闭
+func (c *Client) withSFTP(fn func(sftpClient *sftp.Client) error) error {
+	sftpClient, err := c.NewSFTPClient()
+	if err != nil {
+		return fmt.Errorf("创建SFTP客户端失败: %w", err)
+	}
+	defer sftpClient.Close()
+	return fn(sftpClient)
+}
+
+// Rename 重命名或移动文件，目标已存在时失败
+func (c *Client) Rename(oldPath, newPath string) error {
+	return c.withSFTP(func(sftpClient *sftp.Client) error {
+		if _, err := sftpClient.Lstat(newPath); err == nil {
+			return fmt.Errorf("目标已存在: %s", newPath)
+		}
+		if err := sftpClient.Rename(oldPath, newPath); err != nil {
+			return fmt.Errorf("重命名失败: %w", err)
+		}
+		return nil
+	})
+}
+
+// Chmod 修改文件权限，recursive 为 true 时同时修改目录下的所有文件
+func (c *Client) Chmod(remotePath string, mode os.FileMode, recursive bool) error {
+	return c.withSFTP(func(sftpClient *sftp.Client) error {
+		return walkPaths(sftpClient, remotePath, recursive, func(p string, info os.FileInfo) error {
+			// 符号链接的权限没有意义，修改会作用到链接目标
+			if info.Mode()&os.ModeSymlink != 0 {
+				return nil
+			}
+			if err := sftpClient.Chmod(p, mode); err != nil {
+				return fmt.Errorf("修改权限失败 %s: %w", p, err)
+			}
+			return nil
+		})
+	})
+}
+
+// Chown 修改文件属主，uid 或 gid 为 -1 时保持不变；recursive 为 true 时同时修改目录下的所有文件
+func (c *Client) Chown(remotePath string, uid, gid int, recursive bool) error {
+	return c.withSFTP(func(sftpClient *sftp.Client) error {
+		return walkPaths(sftpClient, remotePath, recursive, func(p string, info os.FileInfo) error {
+			if info.Mode()&os.ModeSymlink != 0 {
+				return nil
+			}
+			newUID, newGID := uid, gid
+			if stat, ok := info.Sys().(*sftp.FileStat); ok {
+				if newUID < 0 {
+					newUID = int(stat.UID)
+				}
+				if newGID < 0 {
+					newGID = int(stat.GID)
+				}
+			}
+			if err := sftpClient.Chown(p, newUID, newGID); err != nil {
+				return fmt.Errorf("修改属主失败 %s: %w", p, err)
+			}
+			return nil
+		})
+	})
+}
+
+// walkPaths 对 root 调用 fn，recursive 为 true 时遍历目录下的所有文件（不跟随符号链接）
+func walkPaths(sftpClient *sftp.Client, root string, recursive bool, fn func(p string, info os.FileInfo) error) error {
+	if !recursive {
+		info, err := sftpClient.Lstat(root)
+		if err != nil {
+			return fmt.Errorf("获取文件信息失败: %w", err)
+		}
+		return fn(root, info)
+	}
+	walker := sftpClient.Walk(root)
+	for walker.Step() {
+		if err := walker.Err(); err != nil {
+			return fmt.Errorf("遍历目录失败: %w", err)
+		}
+		if err := fn(walker.Path(), walker.Stat()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RealPath 返回服务器解析符号链接后的绝对路径
+func (c *Client) RealPath(remotePath string) (string, error) {
+	var realPath string
+	err := c.withSFTP(func(sftpClient *sftp.Client) error {
+		var err error
+		if realPath, err = sftpClient.RealPath(remotePath); err != nil {
+			return fmt.Errorf("解析路径失败: %w", err)
+		}
+		return nil
+	})
+	return realPath, err
+}
+
+// RemoveAll 递归删除文件或目录，符号链接只删除链接本身
+func (c *Client) RemoveAll(remotePath string) error {
+	return c.withSFTP(func(sftpClient *sftp.Client) error {
+		if err := removeAll(sftpClient, remotePath); err != nil {
+			return fmt.Errorf("删除失败: %w", err)
+		}
+		return nil
+	})
+}
+
+func removeAll(sftpClient *sftp.Client, p string) error {
+	info, err := sftpClient.Lstat(p)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		entries, err := sftpClient.ReadDir(p)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if err := removeAll(sftpClient, path.Join(p, entry.Name())); err != nil {
+				return err
+			}
+		}
+		return sftpClient.RemoveDirectory(p)
+	}
+	return sftpClient.Remove(p)
+}
+
+// ArchiveDir 将远程目录打包为 tar.gz 或 zip 写入 writer，边读取边输出
+func (c *Client) ArchiveDir(remotePath, format string, writer io.Writer) error {
+	return c.withSFTP(func(sftpClient *sftp.Client) error {
+		info, err := sftpClient.Stat(remotePath)
+		if err != nil {
+			return fmt.Errorf("获取目录信息失败: %w", err)
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("路径不是目录: %s", remotePath)
+		}
+
+		switch format {
+		case ArchiveTarGz:
+			return archiveTarGz(sftpClient, remotePath, writer)
+		case ArchiveZip:
+			return archiveZip(sftpClient, remotePath, writer)
+		default:
+			return fmt.Errorf("不支持的打包格式: %s", format)
+		}
+	})
+}
+
+// archiveEntries 遍历目录，name 为包内路径（以目录名开头）
+func archiveEntries(sftpClient *sftp.Client, root string, fn func(p, name string, info os.FileInfo) error) error {
+	root = strings.TrimSuffix(root, "/")
+	base := path.Base(root)
+	walker := sftpClient.Walk(root)
+	for walker.Step() {
+		if err := walker.Err(); err != nil {
+			return fmt.Errorf("遍历目录失败: %w", err)
+		}
+		rel := strings.TrimPrefix(strings.TrimPrefix(walker.Path(), root), "/")
+		if err := fn(walker.Path(), path.Join(base, rel), walker.Stat()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func archiveTarGz(sftpClient *sftp.Client, root string, writer io.Writer) error {
+	gw := gzip.NewWriter(writer)
+	tw := tar.NewWriter(gw)
+
+	err := archiveEntries(sftpClient, root, func(p, name string, info os.FileInfo) error {
+		link := ""
+		if info.Mode()&os.ModeSymlink != 0 {
+			target, err := sftpClient.ReadLink(p)
+			if err != nil {
+				return fmt.Errorf("读取符号链接失败 %s: %w", p, err)
+			}
+			link = target
+		} else if !info.IsDir() && !info.Mode().IsRegular() {
+			// 跳过设备、管道等特殊文件
+			return nil
+		}
+
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		header.Name = name
+		if info.IsDir() {
+			header.Name += "/"
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		return copyRemoteFile(sftpClient, p, tw)
+	})
+	if err != nil {
+		return err
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gw.Close()
+}
+
+func archiveZip(sftpClient *sftp.Client, root string, writer io.Writer) error {
+	zw := zip.NewWriter(writer)
+
+	err := archiveEntries(sftpClient, root, func(p, name string, info os.FileInfo) error {
+		// zip 不保留符号链接和特殊文件
+		if !info.IsDir() && !info.Mode().IsRegular() {
+			return nil
+		}
+		header, err := zip.FileInfoHeader(info)
+		if err != nil {
+			return err
+		}
+		header.Name = name
+		if info.IsDir() {
+			header.Name += "/"
+			_, err := zw.CreateHeader(header)
+			return err
+		}
+		header.Method = zip.Deflate
+		w, err := zw.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+		return copyRemoteFile(sftpClient, p, w)
+	})
+	if err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+func copyRemoteFile(sftpClient *sftp.Client, p string, w io.Writer) error {
+	f, err := sftpClient.Open(p)
+	if err != nil {
+		return fmt.Errorf("打开远程文件失败 %s: %w", p, err)
+	}
+	defer f.Close()
+	if _, err := io.Copy(w, f); err != nil {
+		return fmt.Errorf("读取远程文件失败 %s: %w", p, err)
+	}
+	return nil
+}
+
+// UploadChunk 将分片写入远程文件的 offset 处，返回写入后的文件大小。
+// offset 为 0 时重新开始上传；offset 与远程文件当前大小不一致时返回 ErrChunkOffset
+func (c *Client) UploadChunk(reader io.Reader, remotePath string, offset int64) (int64, error) {
+	var size int64
+	err := c.withSFTP(func(sftpClient *sftp.Client) error {
+		flags := os.O_WRONLY | os.O_CREATE
+		if offset == 0 {
+			flags |= os.O_TRUNC
+		}
+		f, err := sftpClient.OpenFile(remotePath, flags)
+		if err != nil {
+			return fmt.Errorf("打开远程文件失败: %w", err)
+		}
+		defer f.Close()
+
+		info, err := f.Stat()
+		if err != nil {
+			return fmt.Errorf("获取文件信息失败: %w", err)
+		}
+		if info.Size() != offset {
+			size = info.Size()
+			return ErrChunkOffset
+		}
+		if _, err := f.Seek(offset, io.SeekStart); err != nil {
+			return err
+		}
+		n, err := io.Copy(f, reader)
+		size = offset + n
+		if err != nil {
+			return fmt.Errorf("上传分片失败: %w", err)
+		}
+		return nil
+	})
+	return size, err
+}
+
+// ReadFile 读取远程文件内容，超过 limit 字节时返回错误
+func (c *Client) ReadFile(remotePath string, limit int64) ([]byte, *FileInfo, error) {
+	var data []byte
+	var fileInfo *FileInfo
+	err := c.withSFTP(func(sftpClient *sftp.Client) error {
+		f, err := sftpClient.Open(remotePath)
+		if err != nil {
+			return fmt.Errorf("打开远程文件失败: %w", err)
+		}
+		defer f.Close()
+
+		info, err := f.Stat()
+		if err != nil {
+			return fmt.Errorf("获取文件信息失败: %w", err)
+		}
+		if info.IsDir() {
+			return fmt.Errorf("路径不是文件: %s", remotePath)
+		}
+		if info.Size() > limit {
+			return fmt.Errorf("文件过大（%d 字节），在线编辑仅支持 %d 字节以内的文件", info.Size(), limit)
+		}
+		data, err = io.ReadAll(io.LimitReader(f, limit+1))
+		if err != nil {
+			return fmt.Errorf("读取远程文件失败: %w", err)
+		}
+		if int64(len(data)) > limit {
+			return fmt.Errorf("文件过大，在线编辑仅支持 %d 字节以内的文件", limit)
+		}
+		fileInfo = newFileInfo(path.Base(remotePath), info)
+		return nil
+	})
+	return data, fileInfo, err
+}
+
+// WriteFile 覆盖写入远程文件，保留原文件的权限和属主
+func (c *Client) WriteFile(remotePath string, data []byte) (*FileInfo, error) {
+	var fileInfo *FileInfo
+	err := c.withSFTP(func(sftpClient *sftp.Client) error {
+		f, err := sftpClient.OpenFile(remotePath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC)
+		if err != nil {
+			return fmt.Errorf("打开远程文件失败: %w", err)
+		}
+		defer f.Close()
+
+		if _, err := f.Write(data); err != nil {
+			return fmt.Errorf("写入远程文件失败: %w", err)
+		}
+		info, err := f.Stat()
+		if err != nil {
+			return fmt.Errorf("获取文件信息失败: %w", err)
+		}
+		fileInfo = newFileInfo(path.Base(remotePath), info)
+		return nil
+	})
+	return fileInfo, err
+}
@@ -0,0 +1,189 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package vault
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// ErrNotFound 密钥不存在或已被删除
+var ErrNotFound = errors.New("vault: 密钥不存在")
+
+// Config Vault 连接配置
+type Config struct {
+	Address       string        // Vault 地址，为空时读取环境变量 VAULT_ADDR
+	Token         string        // 访问令牌，为空时读取环境变量 VAULT_TOKEN
+	Namespace     string        // 企业版命名空间
+	Mount         string        // KV v2 引擎挂载路径，默认 secret
+	Timeout       time.Duration // 请求超时，默认10秒
+	TLSSkipVerify bool          // 跳过证书校验，仅用于测试环境
+}
+
+// Secret KV v2 密钥的一个版本
+type Secret struct {
+	Data    map[string]string
+	Version int
+}
+
+// Client KV v2 HTTP 客户端
+type Client struct {
+	address   string
+	token     string
+	namespace string
+	mount     string
+	http      *http.Client
+}
+
+// NewClient 创建客户端
+func NewClient(c Config) (*Client, error) {
+	if c.Address == "" {
+		c.Address = os.Getenv("VAULT_ADDR")
+	}
+	if c.Token == "" {
+		c.Token = os.Getenv("VAULT_TOKEN")
+	}
+	if c.Address == "" {
+		return nil, errors.New("vault: 未配置地址")
+	}
+	if c.Token == "" {
+		return nil, errors.New("vault: 未配置访问令牌")
+	}
+	if _, err := url.Parse(c.Address); err != nil {
+		return nil, fmt.Errorf("vault: 地址无效: %w", err)
+	}
+	if c.Mount == "" {
+		c.Mount = "secret"
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = 10 * time.Second
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if c.TLSSkipVerify {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+	return &Client{
+		address:   strings.TrimRight(c.Address, "/"),
+		token:     c.Token,
+		namespace: c.Namespace,
+		mount:     strings.Trim(c.Mount, "/"),
+		http:      &http.Client{Timeout: c.Timeout, Transport: transport},
+	}, nil
+}
+
+// Mount KV v2 引擎挂载路径
+func (c *Client) Mount() string {
+	return c.mount
+}
+
+// ReadKV 读取 KV v2 密钥的最新版本，非字符串的值按 JSON 编码返回
+func (c *Client) ReadKV(ctx context.Context, path string) (*Secret, error) {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return nil, errors.New("vault: 密钥路径为空")
+	}
+
+	endpoint := fmt.Sprintf("%s/v1/%s/data/%s", c.address, c.mount, escapePath(path))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", c.token)
+	if c.namespace != "" {
+		req.Header.Set("X-Vault-Namespace", c.namespace)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("vault: 请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("vault: 读取响应失败: %w", err)
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("vault: %s: %s", resp.Status, errorMessage(body))
+	}
+
+	var result struct {
+		Data struct {
+			Data     map[string]interface{} `json:"data"`
+			Metadata struct {
+				Version      int    `json:"version"`
+				DeletionTime string `json:"deletion_time"`
+				Destroyed    bool   `json:"destroyed"`
+			} `json:"metadata"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("vault: 解析响应失败: %w", err)
+	}
+	if result.Data.Data == nil || result.Data.Metadata.Destroyed || result.Data.Metadata.DeletionTime != "" {
+		return nil, ErrNotFound
+	}
+
+	secret := &Secret{Data: make(map[string]string, len(result.Data.Data)), Version: result.Data.Metadata.Version}
+	for k, v := range result.Data.Data {
+		switch val := v.(type) {
+		case string:
+			secret.Data[k] = val
+		case nil:
+		default:
+			encoded, _ := json.Marshal(val)
+			secret.Data[k] = string(encoded)
+		}
+	}
+	return secret, nil
+}
+
+// escapePath 逐段转义密钥路径，保留路径分隔符
+func escapePath(path string) string {
+	parts := strings.Split(path, "/")
+	for i, p := range parts {
+		parts[i] = url.PathEscape(p)
+	}
+	return strings.Join(parts, "/")
+}
+
+// errorMessage 提取 Vault 错误响应中的错误信息
+func errorMessage(body []byte) string {
+	var e struct {
+		Errors []string `json:"errors"`
+	}
+	if json.Unmarshal(body, &e) == nil && len(e.Errors) > 0 {
+		return strings.Join(e.Errors, "; ")
+	}
+	return strings.TrimSpace(string(body))
+}
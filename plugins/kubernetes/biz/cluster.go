@@ -21,9 +21,6 @@ package biz
 
 import (
 	"context"
-	"crypto/aes"
-	"crypto/cipher"
-	"encoding/base64"
 	"errors"
 	"fmt"
 
@@ -32,6 +29,7 @@ import (
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 
+	"github.com/ydcloud-dy/opshub/pkg/kms"
 	"github.com/ydcloud-dy/opshub/plugins/kubernetes/data/models"
 	"github.com/ydcloud-dy/opshub/plugins/kubernetes/data/repository"
 )
@@ -251,60 +249,14 @@ func (b *ClusterBiz) GetClusterClientset(ctx context.Context, id uint) (*kuberne
 	return clientset, nil
 }
 
-// 加密密钥（实际生产环境应该从配置中心获取）
-const encryptionKey = "opshub-k8s-encrypt-key-32bytes!!"
-
 // encryptKubeConfig 加密 kubeconfig
 func encryptKubeConfig(plainText string) (string, error) {
-	key := []byte(encryptionKey)
-	plaintext := []byte(plainText)
-
-	block, err := aes.NewCipher(key)
-	if err != nil {
-		return "", err
-	}
-
-	gcm, err := cipher.NewGCM(block)
-	if err != nil {
-		return "", err
-	}
-
-	nonce := make([]byte, gcm.NonceSize())
-	ciphertext := gcm.Seal(nonce, nonce, plaintext, nil)
-
-	return base64.StdEncoding.EncodeToString(ciphertext), nil
+	return kms.Encrypt(plainText)
 }
 
 // DecryptKubeConfig 解密 kubeconfig（导出供其他包使用）
 func DecryptKubeConfig(cipherText string) (string, error) {
-	key := []byte(encryptionKey)
-	ciphertext, err := base64.StdEncoding.DecodeString(cipherText)
-	if err != nil {
-		return "", err
-	}
-
-	block, err := aes.NewCipher(key)
-	if err != nil {
-		return "", err
-	}
-
-	gcm, err := cipher.NewGCM(block)
-	if err != nil {
-		return "", err
-	}
-
-	nonceSize := gcm.NonceSize()
-	if len(ciphertext) < nonceSize {
-		return "", errors.New("ciphertext too short")
-	}
-
-	nonce, ciphertext := ciphertext[:nonceSize], ciphertext[nonceSize:]
-	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
-	if err != nil {
-		return "", err
-	}
-
-	return string(plaintext), nil
+	return kms.Decrypt(cipherText)
 }
 
 // GetRepo 获取 repository 实例
@@ -20,16 +20,13 @@
 package repository
 
 import (
-	"crypto/aes"
-	"crypto/cipher"
-	"encoding/base64"
-	"errors"
 
 	"gorm.io/gorm"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/client-go/rest"
 
+	"github.com/ydcloud-dy/opshub/pkg/kms"
 	"github.com/ydcloud-dy/opshub/plugins/kubernetes/data/models"
 )
 
@@ -114,7 +111,7 @@ func (r *ClusterRepository) TestConnection(cluster *models.Cluster) (*kubernetes
 	var kubeConfig string
 
 	// 尝试解密，如果失败说明可能是未加密的（用于创建时的测试连接）
-	decryptedConfig, decryptErr := decryptKubeConfig(cluster.KubeConfig)
+	decryptedConfig, decryptErr := kms.Decrypt(cluster.KubeConfig)
 	if decryptErr != nil {
 		// 解密失败，直接使用原始值（可能是创建集群时传入的未加密数据）
 		kubeConfig = cluster.KubeConfig
@@ -149,7 +146,7 @@ func (r *ClusterRepository) GetClientset(cluster *models.Cluster) (*kubernetes.C
 	var kubeConfig string
 
 	// 尝试解密，如果失败说明可能是未加密的（用于创建时的测试连接）
-	decryptedConfig, decryptErr := decryptKubeConfig(cluster.KubeConfig)
+	decryptedConfig, decryptErr := kms.Decrypt(cluster.KubeConfig)
 	if decryptErr != nil {
 		// 解密失败，直接使用原始值
 		kubeConfig = cluster.KubeConfig
@@ -172,38 +169,3 @@ func (r *ClusterRepository) GetClientset(cluster *models.Cluster) (*kubernetes.C
 
 	return clientset, config, nil
 }
-
-// 加密密钥（必须与 biz 包中的密钥相同）
-const encryptionKey = "opshub-k8s-encrypt-key-32bytes!!"
-
-// decryptKubeConfig 解密 kubeconfig（内部使用）
-func decryptKubeConfig(cipherText string) (string, error) {
-	key := []byte(encryptionKey)
-	ciphertext, err := base64.StdEncoding.DecodeString(cipherText)
-	if err != nil {
-		return "", err
-	}
-
-	block, err := aes.NewCipher(key)
-	if err != nil {
-		return "", err
-	}
-
-	gcm, err := cipher.NewGCM(block)
-	if err != nil {
-		return "", err
-	}
-
-	nonceSize := gcm.NonceSize()
-	if len(ciphertext) < nonceSize {
-		return "", errors.New("ciphertext too short")
-	}
-
-	nonce, ciphertext := ciphertext[:nonceSize], ciphertext[nonceSize:]
-	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
-	if err != nil {
-		return "", err
-	}
-
-	return string(plaintext), nil
-}
@@ -21,6 +21,8 @@ package model
 
 import (
 	"time"
+
+	"github.com/ydcloud-dy/opshub/pkg/recording"
 )
 
 // TerminalSession 终端会话录制记录
@@ -33,6 +35,10 @@ type TerminalSession struct {
 	ClusterID   uint   `gorm:"not null;index:idx_cluster_id" json:"clusterId"`
 	ClusterName string `gorm:"size:100" json:"clusterName"`
 
+	// 会话类型：pod 为 Pod 终端，node 为通过 debug Pod 进入的节点终端
+	SessionType string `gorm:"size:20;default:'pod'" json:"sessionType"`
+	NodeName    string `gorm:"size:200" json:"nodeName"`
+
 	// Pod 信息
 	Namespace     string `gorm:"size:100;not null;index:idx_namespace" json:"namespace"`
 	PodName       string `gorm:"size:200;not null;index:idx_pod_name" json:"podName"`
@@ -44,8 +50,11 @@ type TerminalSession struct {
 
 	// 录制文件信息
 	RecordingPath string `gorm:"size:500;not null" json:"recordingPath"` // asciinema录制文件路径
-	Duration      int    `json:"duration"`                              // 会话时长（秒）
-	FileSize      int64  `json:"fileSize"`                              // 文件大小（字节）
+	Duration      int    `json:"duration"`                               // 会话时长（秒）
+	FileSize      int64  `json:"fileSize"`                               // 文件大小（字节）
+	StorageType   string `gorm:"size:20" json:"storageType"`             // 录制存储类型 local/s3，为空表示旧版本本地文件
+	Compressed    bool   `json:"compressed"`                             // 录制文件是否gzip压缩
+	Checksum      string `gorm:"size:64" json:"checksum"`                // 录制文件SHA-256校验值
 
 	// 状态
 	Status string `gorm:"size:20;default:'completed'" json:"status"` // recording, completed, failed
@@ -56,6 +65,23 @@ func (TerminalSession) TableName() string {
 	return "k8s_terminal_sessions"
 }
 
+// Recording 会话录制在存储中的位置
+func (s *TerminalSession) Recording() recording.Object {
+	return recording.Object{
+		Storage:    s.StorageType,
+		Key:        s.RecordingPath,
+		Size:       s.FileSize,
+		Checksum:   s.Checksum,
+		Compressed: s.Compressed,
+	}
+}
+
+// TerminalSessionType 会话类型常量
+const (
+	SessionTypePod  = "pod"  // Pod 终端
+	SessionTypeNode = "node" // 节点终端
+)
+
 // TerminalSessionStatus 会话状态常量
 const (
 	SessionStatusRecording = "recording" // 录制中
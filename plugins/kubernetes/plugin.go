@@ -26,6 +26,7 @@ import (
 	"k8s.io/client-go/tools/clientcmd"
 
 	"github.com/ydcloud-dy/opshub/internal/plugin"
+	"github.com/ydcloud-dy/opshub/pkg/recording"
 	"github.com/ydcloud-dy/opshub/plugins/kubernetes/model"
 	"github.com/ydcloud-dy/opshub/plugins/kubernetes/server"
 )
@@ -84,6 +85,14 @@ func (p *Plugin) Enable(db *gorm.DB) error {
 		}
 	}
 
+	// 已有的终端会话表补齐新增字段
+	if err := db.AutoMigrate(&model.TerminalSession{}); err != nil {
+		return err
+	}
+
+	// 按录制保留策略定期清理过期的终端会话
+	recording.StartRetention("kubernetes-terminal", server.PurgeTerminalSessions(db))
+
 	return nil
 }
 
@@ -20,20 +20,29 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
-	"path/filepath"
 	"sync"
 	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/ydcloud-dy/opshub/pkg/recording"
+	"github.com/ydcloud-dy/opshub/plugins/kubernetes/model"
 )
 
+// recordingCategory Pod 终端录制在存储中的目录
+const recordingCategory = "kubernetes"
+
 // AsciinemaRecorder 终端会话录制器
 type AsciinemaRecorder struct {
 	mu             sync.Mutex
 	file           *os.File
 	startTime      time.Time
-	recordingPath  string
+	recordingPath  string            // 录制中的临时文件路径
+	object         *recording.Object // 关闭后转存到录制存储的位置
 	lastTime       float64
 	cols           int
 	rows           int
@@ -52,21 +61,20 @@ type AsciinemaHeader struct {
 // 类型: "o" = 输出, "i" = 输入
 type AsciinemaEvent []interface{}
 
-// NewAsciinemaRecorder 创建录制器
-func NewAsciinemaRecorder(recordingDir string, cols, rows int) (*AsciinemaRecorder, error) {
+// NewAsciinemaRecorder 创建录制器，录制过程中写入临时目录，关闭时转存到配置的录制存储
+func NewAsciinemaRecorder(cols, rows int) (*AsciinemaRecorder, error) {
 	// 确保录制目录存在
-	if err := os.MkdirAll(recordingDir, 0755); err != nil {
+	spoolDir := recording.SpoolDir()
+	if err := os.MkdirAll(spoolDir, 0755); err != nil {
 		return nil, fmt.Errorf("创建录制目录失败: %w", err)
 	}
 
-	// 生成文件名
-	filename := filepath.Join(recordingDir,
-		fmt.Sprintf("%s.cast", time.Now().Format("20060102-150405")))
-
-	file, err := os.Create(filename)
+	// 生成文件名，加随机串避免同一秒内的会话互相覆盖
+	file, err := os.CreateTemp(spoolDir, time.Now().Format("20060102-150405")+"-*.cast")
 	if err != nil {
 		return nil, fmt.Errorf("创建录制文件失败: %w", err)
 	}
+	filename := file.Name()
 
 	now := float64(time.Now().UnixNano()) / 1e9
 	recorder := &AsciinemaRecorder{
@@ -151,20 +159,41 @@ func (r *AsciinemaRecorder) writeEvent(event AsciinemaEvent) error {
 	return err
 }
 
-// Close 关闭录制器并保存
+// Close 关闭录制器并将录制文件转存到录制存储
 func (r *AsciinemaRecorder) Close() error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	if r.file != nil {
-		return r.file.Close()
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	if err != nil {
+		return err
+	}
+
+	object, err := recording.Save(context.Background(), r.recordingPath, recordingCategory)
+	if err != nil {
+		return err
 	}
+	r.object = object
 	return nil
 }
 
-// GetRecordingPath 获取录制文件路径
+// GetRecording 获取转存后的录制文件位置，录制未成功保存时返回nil
+func (r *AsciinemaRecorder) GetRecording() *recording.Object {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.object
+}
+
+// GetRecordingPath 获取录制文件在存储中的路径
 func (r *AsciinemaRecorder) GetRecordingPath() string {
-	return r.recordingPath
+	if object := r.GetRecording(); object != nil {
+		return object.Key
+	}
+	return ""
 }
 
 // GetDuration 获取录制时长（秒）
@@ -172,13 +201,32 @@ func (r *AsciinemaRecorder) GetDuration() int {
 	return int(time.Since(r.startTime).Seconds())
 }
 
-// GetFileSize 获取文件大小
+// GetFileSize 获取录制文件在存储中的大小
 func (r *AsciinemaRecorder) GetFileSize() int64 {
-	info, err := os.Stat(r.recordingPath)
-	if err != nil {
-		return 0
+	if object := r.GetRecording(); object != nil {
+		return object.Size
+	}
+	return 0
+}
+
+// PurgeTerminalSessions 按录制保留策略清理过期的 Pod 终端会话及录制文件
+func PurgeTerminalSessions(db *gorm.DB) recording.PurgeFunc {
+	return func(ctx context.Context, cutoff time.Time) (int, error) {
+		var sessions []model.TerminalSession
+		if err := db.Where("created_at < ?", cutoff).Find(&sessions).Error; err != nil {
+			return 0, err
+		}
+		for i := range sessions {
+			// 录制文件删除失败时仍删除会话记录，避免反复重试
+			if err := recording.Remove(ctx, sessions[i].Recording()); err != nil {
+				fmt.Printf("⚠️ 删除录制文件失败: %v\n", err)
+			}
+			if err := db.Delete(&sessions[i]).Error; err != nil {
+				return i, err
+			}
+		}
+		return len(sessions), nil
 	}
-	return info.Size()
 }
 
 // sanitizeString 清理字符串，确保可以正确序列化为JSON
@@ -57,6 +57,7 @@ import (
 	"k8s.io/metrics/pkg/apis/metrics/v1beta1"
 	"sigs.k8s.io/yaml"
 
+	"github.com/ydcloud-dy/opshub/pkg/recording"
 	"github.com/ydcloud-dy/opshub/plugins/kubernetes/data/models"
 	"github.com/ydcloud-dy/opshub/plugins/kubernetes/model"
 	"github.com/ydcloud-dy/opshub/plugins/kubernetes/service"
@@ -3226,6 +3227,12 @@ func (h *ResourceHandler) NodeShellWebSocket(c *gin.Context) {
 		return
 	}
 
+	// 获取用户名
+	username := ""
+	if usernameVal, exists := c.Get("username"); exists {
+		username = usernameVal.(string)
+	}
+
 	// 升级到 WebSocket 连接
 	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
@@ -3363,12 +3370,25 @@ func (h *ResourceHandler) NodeShellWebSocket(c *gin.Context) {
 		return
 	}
 
-	// 创建 WebSocket 读写器
-	wsReader := &WebSocketReader{
-		conn: conn,
-		data: make(chan []byte, 256),
+	// 创建录制器，节点终端与 Pod 终端一样纳入终端审计
+	recorder, err := NewAsciinemaRecorder(120, 30)
+	if err != nil {
+		// 录制失败不影响终端使用，只是不录制
+		recorder = nil
+	}
+
+	// 创建 WebSocket 读写器（带录制功能）
+	wsReader := &RecordingWebSocketReader{
+		conn:      conn,
+		data:      make(chan []byte, 256),
+		recorder:  recorder,
+		startTime: time.Now(),
+	}
+	wsWriter := &RecordingWebSocketWriter{
+		conn:      conn,
+		recorder:  recorder,
+		startTime: time.Now(),
 	}
-	wsWriter := &WebSocketWriter{conn: conn}
 
 	// 处理 WebSocket 消息
 	done := make(chan struct{})
@@ -3405,34 +3425,20 @@ func (h *ResourceHandler) NodeShellWebSocket(c *gin.Context) {
 
 	<-done
 	fmt.Printf("🐚 WebSocket shell disconnected from node %s\n", nodeName)
-}
-
-// WebSocketReader 实现 io.Reader 接口
-type WebSocketReader struct {
-	conn *websocket.Conn
-	data chan []byte
-}
 
-func (r *WebSocketReader) Read(p []byte) (int, error) {
-	data, ok := <-r.data
-	if !ok {
-		return 0, io.EOF
-	}
-	n := copy(p, data)
-	return n, nil
-}
-
-// WebSocketWriter 实现 io.Writer 接口
-type WebSocketWriter struct {
-	conn *websocket.Conn
-}
-
-func (w *WebSocketWriter) Write(p []byte) (int, error) {
-	err := w.conn.WriteMessage(websocket.TextMessage, p)
-	if err != nil {
-		return 0, err
+	// 关闭录制器并保存会话记录
+	if recorder != nil {
+		h.saveTerminalSession(recorder, model.TerminalSession{
+			ClusterID:     uint(clusterID),
+			SessionType:   model.SessionTypeNode,
+			NodeName:      nodeName,
+			Namespace:     debugNamespace,
+			PodName:       debugPodName,
+			ContainerName: "debug",
+			UserID:        currentUserID.(uint),
+			Username:      username,
+		})
 	}
-	return len(p), nil
 }
 
 // waitForPodReady 等待 Pod 准备就绪
@@ -9063,9 +9069,8 @@ func (h *ResourceHandler) PodShellWebSocket(c *gin.Context) {
 		return
 	}
 
-	// 创建录制器（录制目录）
-	recordingDir := "./data/terminal-recordings"
-	recorder, err := NewAsciinemaRecorder(recordingDir, 120, 30)
+	// 创建录制器
+	recorder, err := NewAsciinemaRecorder(120, 30)
 	if err != nil {
 		// 录制失败不影响终端使用，只是不录制
 		recorder = nil
@@ -9114,41 +9119,48 @@ func (h *ResourceHandler) PodShellWebSocket(c *gin.Context) {
 
 	// 关闭录制器并保存会话记录
 	if recorder != nil {
-		duration := recorder.GetDuration()
-		fileSize := recorder.GetFileSize()
-		recordingPath := recorder.GetRecordingPath()
-
-		recorder.Close()
-
-		// 获取集群名称
-		var cluster models.Cluster
-		clusterName := ""
-		if err := h.db.First(&cluster, clusterID).Error; err == nil {
-			clusterName = cluster.Alias
-			if clusterName == "" {
-				clusterName = cluster.Name
-			}
-		} else {
-			clusterName = fmt.Sprintf("Cluster-%d", clusterID)
-		}
-
-		// 保存会话记录到数据库（所有会话都记录）
-		session := model.TerminalSession{
+		h.saveTerminalSession(recorder, model.TerminalSession{
 			ClusterID:     uint(clusterID),
-			ClusterName:   clusterName,
+			SessionType:   model.SessionTypePod,
 			Namespace:     namespace,
 			PodName:       podName,
 			ContainerName: containerName,
 			UserID:        currentUserID.(uint),
 			Username:      username,
-			RecordingPath: recordingPath,
-			Duration:      duration,
-			FileSize:      fileSize,
-			Status:        model.SessionStatusCompleted,
+		})
+	}
+}
+
+// saveTerminalSession 关闭录制器并保存终端会话记录（所有会话都记录）
+func (h *ResourceHandler) saveTerminalSession(recorder *AsciinemaRecorder, session model.TerminalSession) {
+	session.Duration = recorder.GetDuration()
+	if err := recorder.Close(); err != nil {
+		fmt.Printf("⚠️ 保存录制文件失败: %v\n", err)
+	}
+
+	// 获取集群名称
+	var cluster models.Cluster
+	if err := h.db.First(&cluster, session.ClusterID).Error; err == nil {
+		session.ClusterName = cluster.Alias
+		if session.ClusterName == "" {
+			session.ClusterName = cluster.Name
 		}
+	} else {
+		session.ClusterName = fmt.Sprintf("Cluster-%d", session.ClusterID)
+	}
 
-		h.db.Create(&session)
+	session.Status = model.SessionStatusCompleted
+	if object := recorder.GetRecording(); object != nil {
+		session.RecordingPath = object.Key
+		session.FileSize = object.Size
+		session.StorageType = object.Storage
+		session.Compressed = object.Compressed
+		session.Checksum = object.Checksum
+	} else {
+		session.Status = model.SessionStatusFailed
 	}
+
+	h.db.Create(&session)
 }
 
 // PauseWorkload 暂停/恢复工作负载
@@ -12729,6 +12741,8 @@ type TerminalSessionInfo struct {
 	Namespace     string `json:"namespace"`
 	PodName       string `json:"podName"`
 	ContainerName string `json:"containerName"`
+	SessionType   string `json:"sessionType"`
+	NodeName      string `json:"nodeName"`
 	UserID        uint   `json:"userId"`
 	Username      string `json:"username"`
 	Duration      int    `json:"duration"`
@@ -12775,6 +12789,8 @@ func (h *ResourceHandler) ListTerminalSessions(c *gin.Context) {
 			Namespace:     session.Namespace,
 			PodName:       session.PodName,
 			ContainerName: session.ContainerName,
+			SessionType:   session.SessionType,
+			NodeName:      session.NodeName,
 			UserID:        session.UserID,
 			Username:      session.Username,
 			Duration:      session.Duration,
@@ -12817,9 +12833,16 @@ func (h *ResourceHandler) PlayTerminalSession(c *gin.Context) {
 		return
 	}
 
-	// 读取录制文件
-	data, err := os.ReadFile(session.RecordingPath)
+	// 读取录制文件并校验完整性
+	data, err := recording.ReadAll(c.Request.Context(), session.Recording())
 	if err != nil {
+		if errors.Is(err, recording.ErrChecksumMismatch) {
+			c.JSON(http.StatusConflict, gin.H{
+				"code":    409,
+				"message": err.Error(),
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"code":    500,
 			"message": "读取录制文件失败: " + err.Error(),
@@ -12862,8 +12885,8 @@ func (h *ResourceHandler) DeleteTerminalSession(c *gin.Context) {
 	}
 
 	// 删除录制文件
-	if session.RecordingPath != "" {
-		os.Remove(session.RecordingPath)
+	if err := recording.Remove(c.Request.Context(), session.Recording()); err != nil {
+		fmt.Printf("⚠️ 删除录制文件失败: %v\n", err)
 	}
 
 	// 删除数据库记录
@@ -0,0 +1,305 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/smtp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ydcloud-dy/opshub/plugins/monitor/model"
+	"gorm.io/gorm"
+)
+
+// AlertTargets 启用的告警通道及接收人
+type AlertTargets struct {
+	Channels  []model.AlertChannel
+	Receivers []model.AlertReceiver
+	Config    AlertChannelConfig
+	Relations []ReceiverChannelRelation // 接收人-通道关联（用于@提醒）
+}
+
+// LoadAlertTargets 加载启用的告警通道、接收人及其关联关系。
+// userIDs 不为空时只保留关联了这些系统用户的接收人
+func LoadAlertTargets(db *gorm.DB, userIDs []uint) (*AlertTargets, error) {
+	targets := &AlertTargets{}
+
+	// 1. 获取启用的告警通道
+	if err := db.Where("enabled = ?", true).Find(&targets.Channels).Error; err != nil {
+		return nil, fmt.Errorf("获取告警通道失败: %v", err)
+	}
+
+	// 2. 获取告警接收人
+	query := db.Model(&model.AlertReceiver{})
+	if len(userIDs) > 0 {
+		query = query.Where("user_id IN ?", userIDs)
+	}
+	if err := query.Find(&targets.Receivers).Error; err != nil {
+		return nil, fmt.Errorf("获取告警接收人失败: %v", err)
+	}
+
+	// 3. 构建告警通道配置
+	for _, channel := range targets.Channels {
+		var config map[string]interface{}
+		if err := json.Unmarshal([]byte(channel.Config), &config); err != nil {
+			continue
+		}
+
+		switch channel.ChannelType {
+		case "email":
+			if smtpHost, ok := config["smtpHost"].(string); ok {
+				targets.Config.SMTPHost = smtpHost
+			}
+			if smtpPort, ok := config["smtpPort"].(float64); ok {
+				targets.Config.SMTPPort = int(smtpPort)
+			}
+			if smtpUser, ok := config["smtpUser"].(string); ok {
+				targets.Config.SMTPUser = smtpUser
+			}
+			if smtpPassword, ok := config["smtpPassword"].(string); ok {
+				targets.Config.SMTPPassword = smtpPassword
+			}
+			if fromEmail, ok := config["fromEmail"].(string); ok {
+				targets.Config.FromEmail = fromEmail
+			}
+			if fromName, ok := config["fromName"].(string); ok {
+				targets.Config.FromName = fromName
+			}
+		case "webhook":
+			if webhookURL, ok := config["webhookUrl"].(string); ok {
+				targets.Config.WebhookURL = webhookURL
+			}
+		case "wechat":
+			if wechatWebhook, ok := config["wechatWebhook"].(string); ok {
+				targets.Config.WeChatWebhook = wechatWebhook
+			}
+		case "dingtalk":
+			if dingtalkWebhook, ok := config["dingtalkWebhook"].(string); ok {
+				targets.Config.DingTalkWebhook = dingtalkWebhook
+			}
+			if dingtalkSecret, ok := config["dingtalkSecret"].(string); ok {
+				targets.Config.DingTalkSecret = dingtalkSecret
+			}
+		case "feishu":
+			if feishuWebhook, ok := config["feishuWebhook"].(string); ok {
+				targets.Config.FeishuWebhook = feishuWebhook
+			}
+		}
+	}
+
+	// 4. 获取接收人-通道关联关系（用于@提醒）
+	var receiverChannels []model.AlertReceiverChannel
+	if err := db.Find(&receiverChannels).Error; err != nil {
+		// 如果查询关联失败，继续使用旧的方式发送（向后兼容）
+		receiverChannels = []model.AlertReceiverChannel{}
+	}
+
+	// 5. 构建接收人-通道关联信息
+	receiverMap := make(map[uint]*model.AlertReceiver)
+	for i := range targets.Receivers {
+		receiverMap[targets.Receivers[i].ID] = &targets.Receivers[i]
+	}
+
+	channelMap := make(map[uint]*model.AlertChannel)
+	for i := range targets.Channels {
+		channelMap[targets.Channels[i].ID] = &targets.Channels[i]
+	}
+
+	for _, rc := range receiverChannels {
+		receiver, receiverExists := receiverMap[rc.ReceiverID]
+		channel, channelExists := channelMap[rc.ChannelID]
+
+		if !receiverExists || !channelExists {
+			continue
+		}
+
+		// 只添加有效的关联（接收人启用对应通道）
+		shouldAdd := false
+		switch channel.ChannelType {
+		case "email":
+			shouldAdd = receiver.EnableEmail && receiver.Email != ""
+		case "feishu":
+			shouldAdd = receiver.EnableFeishu && receiver.FeishuID != ""
+		case "dingtalk":
+			shouldAdd = receiver.EnableDingTalk && (receiver.DingTalkID != "" || receiver.Phone != "")
+		case "wechat":
+			shouldAdd = receiver.EnableWeChat && receiver.WeChatID != ""
+		}
+
+		if shouldAdd {
+			targets.Relations = append(targets.Relations, ReceiverChannelRelation{
+				ReceiverID:  rc.ReceiverID,
+				ChannelID:   rc.ChannelID,
+				ChannelType: channel.ChannelType,
+				Receiver: ReceiverInfo{
+					ID:         receiver.ID,
+					Name:       receiver.Name,
+					Email:      receiver.Email,
+					Phone:      receiver.Phone,
+					FeishuID:   receiver.FeishuID,
+					DingTalkID: receiver.DingTalkID,
+					WeChatID:   receiver.WeChatID,
+				},
+				ChannelConfig: rc.Config,
+			})
+		}
+	}
+
+	return targets, nil
+}
+
+// Notification 通用通知消息（如任务审批），复用告警通道发送
+type Notification struct {
+	Type      string   `json:"type"`  // 通知类型，如 task_approval
+	Title     string   `json:"title"` // 标题
+	Lines     []string `json:"lines"` // 正文，每项一行
+	Timestamp string   `json:"timestamp"`
+}
+
+// SendNotification 通过告警通道发送通用通知。
+// 有接收人-通道关联时向关联的接收人发送并@提醒，否则发送到各通道配置的群机器人
+func (s *AlertService) SendNotification(n Notification, targets *AlertTargets) error {
+	if n.Timestamp == "" {
+		n.Timestamp = time.Now().Format("2006-01-02 15:04:05")
+	}
+	config := targets.Config
+
+	var emails []string
+	var wechat, dingtalk, feishu []ReceiverInfo
+	for _, relation := range targets.Relations {
+		switch relation.ChannelType {
+		case "email":
+			if !slices.Contains(emails, relation.Receiver.Email) {
+				emails = append(emails, relation.Receiver.Email)
+			}
+		case "wechat":
+			wechat = append(wechat, relation.Receiver)
+		case "dingtalk":
+			dingtalk = append(dingtalk, relation.Receiver)
+		case "feishu":
+			feishu = append(feishu, relation.Receiver)
+		}
+	}
+	// 没有关联关系时群发，不@任何人
+	broadcast := len(targets.Relations) == 0
+
+	var errors []error
+	var successCount int
+	send := func(name string, enabled bool, fn func() error) {
+		if !enabled {
+			return
+		}
+		if err := fn(); err != nil {
+			errors = append(errors, fmt.Errorf("%s发送失败: %w", name, err))
+		} else {
+			successCount++
+		}
+	}
+
+	send("邮件", config.SMTPHost != "" && len(emails) > 0, func() error {
+		return s.sendNotificationEmail(n, config, emails)
+	})
+	send("企业微信", config.WeChatWebhook != "" && (broadcast || len(wechat) > 0), func() error {
+		content := "**" + n.Title + "**\n\n" + strings.Join(n.Lines, "\n")
+		for _, receiver := range wechat {
+			content += " <@" + receiver.WeChatID + ">"
+		}
+		return s.sendWebhookRequest(config.WeChatWebhook, map[string]interface{}{
+			"msgtype": "text",
+			"text":    map[string]string{"content": content},
+		})
+	})
+	send("钉钉", config.DingTalkWebhook != "" && (broadcast || len(dingtalk) > 0), func() error {
+		content := "## " + n.Title + "\n\n" + strings.Join(n.Lines, "\n\n")
+		var atMobiles []string
+		for _, receiver := range dingtalk {
+			if receiver.Phone != "" {
+				content += " @" + receiver.Phone
+				atMobiles = append(atMobiles, receiver.Phone)
+			}
+		}
+		data := map[string]interface{}{
+			"msgtype":  "markdown",
+			"markdown": map[string]interface{}{"title": n.Title, "text": content},
+		}
+		if len(atMobiles) > 0 {
+			data["at"] = map[string]interface{}{"atMobiles": atMobiles, "isAtAll": false}
+		}
+		return s.sendWebhookRequest(config.DingTalkWebhook, data)
+	})
+	send("飞书", config.FeishuWebhook != "" && (broadcast || len(feishu) > 0), func() error {
+		elements := []map[string]interface{}{
+			{"tag": "text", "text": strings.Join(n.Lines, "\n")},
+		}
+		for _, receiver := range feishu {
+			elements = append(elements, map[string]interface{}{"tag": "at", "user_id": receiver.FeishuID})
+		}
+		return s.sendWebhookRequest(config.FeishuWebhook, map[string]interface{}{
+			"msg_type": "post",
+			"content": map[string]interface{}{
+				"post": map[string]interface{}{
+					"zh_cn": map[string]interface{}{
+						"title":   n.Title,
+						"content": [][]map[string]interface{}{elements},
+					},
+				},
+			},
+		})
+	})
+	send("Webhook", config.WebhookURL != "", func() error {
+		return s.sendWebhookRequest(config.WebhookURL, n)
+	})
+
+	if successCount == 0 {
+		if len(errors) > 0 {
+			return fmt.Errorf("所有告警通道发送失败: %v", errors)
+		}
+		return fmt.Errorf("没有可用的告警通道或接收人")
+	}
+	return nil
+}
+
+// sendNotificationEmail 发送通知邮件
+func (s *AlertService) sendNotificationEmail(n Notification, config AlertChannelConfig, receivers []string) error {
+	var body strings.Builder
+	body.WriteString(`<!DOCTYPE html><html><head><meta charset="UTF-8"></head><body style="font-family: Arial, sans-serif; line-height: 1.6; color: #333;">`)
+	body.WriteString("<h3>" + html.EscapeString(n.Title) + "</h3>")
+	for _, line := range n.Lines {
+		body.WriteString("<p>" + html.EscapeString(line) + "</p>")
+	}
+	body.WriteString(`<p style="color: #999; font-size: 12px;">此邮件由系统自动发送，请勿回复。</p></body></html>`)
+
+	headers := []string{
+		fmt.Sprintf("From: %s <%s>", config.FromName, config.FromEmail),
+		"To: " + strings.Join(receivers, ", "),
+		"Subject: " + n.Title,
+		"MIME-Version: 1.0",
+		"Content-Type: text/html; charset=UTF-8",
+	}
+	msg := strings.Join(headers, "\r\n") + "\r\n\r\n" + body.String()
+
+	auth := smtp.PlainAuth("", config.SMTPUser, config.SMTPPassword, config.SMTPHost)
+	addr := fmt.Sprintf("%s:%d", config.SMTPHost, config.SMTPPort)
+	return smtp.SendMail(addr, auth, config.FromEmail, receivers, []byte(msg))
+}
@@ -21,9 +21,7 @@ package server
 
 import (
 	"bufio"
-	"crypto/aes"
-	"crypto/cipher"
-	"encoding/base64"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -34,14 +32,13 @@ import (
 
 	"github.com/gin-gonic/gin"
 	assetbiz "github.com/ydcloud-dy/opshub/internal/biz/asset"
+	assetdata "github.com/ydcloud-dy/opshub/internal/data/asset"
 	"github.com/ydcloud-dy/opshub/pkg/response"
+	sshclient "github.com/ydcloud-dy/opshub/pkg/ssh"
 	"github.com/ydcloud-dy/opshub/plugins/nginx/model"
 	"golang.org/x/crypto/ssh"
 )
 
-// 加密密钥（与凭证仓库相同）
-var encryptionKey = []byte("opshub-enc-key-32-bytes-long!!!!")
-
 // NginxLogEntry 解析后的日志条目
 type NginxLogEntry struct {
 	Timestamp     time.Time
@@ -169,12 +166,12 @@ func (h *Handler) collectHostLogs(source *model.NginxSource) (int, error) {
 	}
 
 	// 解密凭证
-	if err := decryptCredential(&credential); err != nil {
+	if err := assetdata.DecryptCredential(context.Background(), &credential); err != nil {
 		return 0, fmt.Errorf("解密凭证失败: %w", err)
 	}
 
 	// 创建SSH连接
-	sshClient, err := createSSHClient(&host, &credential)
+	sshClient, err := createSSHClient(h.hostKeys, &host, &credential)
 	if err != nil {
 		return 0, fmt.Errorf("SSH连接失败: %w", err)
 	}
@@ -854,10 +851,11 @@ func extractHost(uri, referer string) string {
 	return ""
 }
 
-// createSSHClient 创建SSH客户端
-func createSSHClient(host *assetbiz.Host, credential *assetbiz.Credential) (*ssh.Client, error) {
+// createSSHClient 从连接池获取SSH连接，使用完毕后调用 Close 归还
+func createSSHClient(hostKeys sshclient.HostKeyStore, host *assetbiz.Host, credential *assetbiz.Credential) (*sshclient.Lease, error) {
 	var authMethods []ssh.AuthMethod
 
+	user := credential.Username
 	switch credential.Type {
 	case "password":
 		authMethods = append(authMethods, ssh.Password(credential.Password))
@@ -867,73 +865,28 @@ func createSSHClient(host *assetbiz.Host, credential *assetbiz.Credential) (*ssh
 			return nil, fmt.Errorf("解析私钥失败: %w", err)
 		}
 		authMethods = append(authMethods, ssh.PublicKeys(signer))
+	case assetbiz.CredentialTypeCertificate:
+		user = host.SSHUser
+		signer, err := assetbiz.SSHCertSigner(context.Background(), host, user)
+		if err != nil {
+			return nil, fmt.Errorf("签发SSH证书失败: %w", err)
+		}
+		authMethods = append(authMethods, ssh.PublicKeys(signer))
 	default:
 		return nil, fmt.Errorf("不支持的凭证类型: %s", credential.Type)
 	}
 
 	config := &ssh.ClientConfig{
-		User:            credential.Username,
-		Auth:            authMethods,
-		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
-		Timeout:         30 * time.Second,
+		User:              user,
+		Auth:              authMethods,
+		HostKeyCallback:   sshclient.NewHostKeyCallback(hostKeys, host.ID),
+		HostKeyAlgorithms: sshclient.HostKeyAlgorithms(hostKeys, host.ID),
+		Timeout:           30 * time.Second,
 	}
 
 	addr := fmt.Sprintf("%s:%d", host.IP, host.Port)
-	return ssh.Dial("tcp", addr, config)
-}
-
-// decryptCredential 解密凭证
-func decryptCredential(credential *assetbiz.Credential) error {
-	if credential.Password != "" {
-		decrypted, err := decrypt(credential.Password)
-		if err != nil {
-			return fmt.Errorf("解密密码失败: %w", err)
-		}
-		credential.Password = decrypted
-	}
-
-	if credential.PrivateKey != "" {
-		decrypted, err := decrypt(credential.PrivateKey)
-		if err != nil {
-			return fmt.Errorf("解密私钥失败: %w", err)
-		}
-		credential.PrivateKey = decrypted
-	}
-
-	return nil
-}
-
-// decrypt AES-GCM 解密
-func decrypt(ciphertext string) (string, error) {
-	if ciphertext == "" {
-		return "", nil
-	}
-
-	data, err := base64.StdEncoding.DecodeString(ciphertext)
-	if err != nil {
-		return "", err
-	}
-
-	block, err := aes.NewCipher(encryptionKey)
-	if err != nil {
-		return "", err
-	}
-
-	gcm, err := cipher.NewGCM(block)
-	if err != nil {
-		return "", err
-	}
-
-	nonceSize := gcm.NonceSize()
-	if len(data) < nonceSize {
-		return "", fmt.Errorf("ciphertext too short")
-	}
-
-	nonce, cipherData := data[:nonceSize], data[nonceSize:]
-	plaintext, err := gcm.Open(nil, nonce, cipherData, nil)
-	if err != nil {
-		return "", err
-	}
-
-	return string(plaintext), nil
+	key := assetbiz.HostPoolKey(context.Background(), host, credential, user)
+	return sshclient.DefaultPool().Acquire(context.Background(), key, func() (*ssh.Client, error) {
+		return ssh.Dial("tcp", addr, config)
+	})
 }
@@ -21,6 +21,7 @@ package server
 
 import (
 	"bufio"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -35,7 +36,9 @@ import (
 
 	"github.com/gin-gonic/gin"
 	assetbiz "github.com/ydcloud-dy/opshub/internal/biz/asset"
+	assetdata "github.com/ydcloud-dy/opshub/internal/data/asset"
 	"github.com/ydcloud-dy/opshub/pkg/response"
+	sshclient "github.com/ydcloud-dy/opshub/pkg/ssh"
 	"github.com/ydcloud-dy/opshub/plugins/nginx/model"
 	"github.com/ydcloud-dy/opshub/plugins/nginx/repository"
 	"github.com/ydcloud-dy/opshub/plugins/nginx/service"
@@ -93,6 +96,7 @@ type Handler struct {
 	geoSvc   *service.GeolocationService
 	uaParser *service.UAParser
 	cache    *overviewCache // 概况数据缓存
+	hostKeys sshclient.HostKeyStore
 }
 
 func NewHandler(db *gorm.DB) *Handler {
@@ -102,6 +106,7 @@ func NewHandler(db *gorm.DB) *Handler {
 		geoSvc:   service.NewGeolocationService(),
 		uaParser: service.NewUAParser(),
 		cache:    newOverviewCache(100), // 最多缓存100个数据源的概况
+		hostKeys: assetdata.NewHostKeyStore(db),
 	}
 }
 
@@ -561,12 +566,12 @@ func (h *Handler) fetchNginxStatsFromHost(source *model.NginxSource, startDate,
 	}
 
 	// 解密凭证
-	if err := decryptCredential(&credential); err != nil {
+	if err := assetdata.DecryptCredential(context.Background(), &credential); err != nil {
 		return nil, fmt.Errorf("解密凭证失败: %w", err)
 	}
 
 	// 创建SSH连接
-	sshClient, err := createSSHClient(&host, &credential)
+	sshClient, err := createSSHClient(h.hostKeys, &host, &credential)
 	if err != nil {
 		return nil, fmt.Errorf("SSH连接失败: %w", err)
 	}
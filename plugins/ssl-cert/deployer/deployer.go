@@ -3,7 +3,9 @@ package deployer
 import (
 	"context"
 
+	sshclient "github.com/ydcloud-dy/opshub/pkg/ssh"
 	"github.com/ydcloud-dy/opshub/plugins/ssl-cert/model"
+	"golang.org/x/crypto/ssh"
 )
 
 // Deployer 部署器接口
@@ -61,6 +63,26 @@ type HostInfo struct {
 	Password   string
 	PrivateKey []byte
 	Passphrase string
+	// Signer 证书凭证签发的证书签名器，不为空时使用证书认证
+	Signer ssh.Signer
+	// HostKeyCallback 主机密钥校验回调
+	HostKeyCallback ssh.HostKeyCallback
+	// HostKeyAlgorithms 限定协商的主机密钥算法，为空时使用默认算法
+	HostKeyAlgorithms []string
+	// JumpHosts 跳板机链，为空时直连
+	JumpHosts []*sshclient.JumpHost
+}
+
+// Connect 建立SSH连接：证书凭证使用签发的证书，配置了跳板机时经跳板机连接
+func (h *HostInfo) Connect() (*sshclient.Client, error) {
+	if h.Signer != nil {
+		client, err := sshclient.DialClientWithSigner(h.Host, h.Port, h.Username, h.Signer, h.HostKeyCallback, h.HostKeyAlgorithms, h.JumpHosts...)
+		if err != nil {
+			return nil, err
+		}
+		return sshclient.WrapClient(client), nil
+	}
+	return sshclient.NewClient(h.Host, h.Port, h.Username, h.Password, h.PrivateKey, h.Passphrase, h.HostKeyCallback, h.HostKeyAlgorithms, h.JumpHosts...)
 }
 
 // K8sClient K8s客户端接口
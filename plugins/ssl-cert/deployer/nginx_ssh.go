@@ -8,7 +8,6 @@ import (
 	"strings"
 	"time"
 
-	sshclient "github.com/ydcloud-dy/opshub/pkg/ssh"
 	"github.com/ydcloud-dy/opshub/plugins/ssl-cert/model"
 )
 
@@ -42,14 +41,7 @@ func (d *NginxSSHDeployer) Deploy(ctx context.Context, cert *model.CertBundle, c
 	}
 
 	// 创建SSH客户端
-	client, err := sshclient.NewClient(
-		hostInfo.Host,
-		hostInfo.Port,
-		hostInfo.Username,
-		hostInfo.Password,
-		hostInfo.PrivateKey,
-		hostInfo.Passphrase,
-	)
+	client, err := hostInfo.Connect()
 	if err != nil {
 		return fmt.Errorf("create ssh client failed: %w", err)
 	}
@@ -154,14 +146,7 @@ func (d *NginxSSHDeployer) Test(ctx context.Context, config *model.DeployConfig)
 	}
 
 	// 创建SSH客户端并测试连接
-	client, err := sshclient.NewClient(
-		hostInfo.Host,
-		hostInfo.Port,
-		hostInfo.Username,
-		hostInfo.Password,
-		hostInfo.PrivateKey,
-		hostInfo.Passphrase,
-	)
+	client, err := hostInfo.Connect()
 	if err != nil {
 		return fmt.Errorf("create ssh client failed: %w", err)
 	}
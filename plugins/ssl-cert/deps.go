@@ -2,10 +2,6 @@ package sslcert
 
 import (
 	"context"
-	"crypto/aes"
-	"crypto/cipher"
-	"encoding/base64"
-	"errors"
 	"fmt"
 	"time"
 
@@ -15,95 +11,31 @@ import (
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/clientcmd"
 
+	assetbiz "github.com/ydcloud-dy/opshub/internal/biz/asset"
+	assetdata "github.com/ydcloud-dy/opshub/internal/data/asset"
+	"github.com/ydcloud-dy/opshub/pkg/kms"
+	sshclient "github.com/ydcloud-dy/opshub/pkg/ssh"
 	"github.com/ydcloud-dy/opshub/plugins/ssl-cert/deployer"
 )
 
-// 凭证加密密钥（与 internal/data/asset/host.go 保持一致）
-var encryptionKey = []byte("opshub-enc-key-32-bytes-long!!!!")
-
-// kubeconfig 加密密钥（与 kubernetes 插件保持一致）
-const kubeConfigEncryptionKey = "opshub-k8s-encrypt-key-32bytes!!"
-
 // HostGetter 主机信息获取器
 type HostGetter struct {
-	db *gorm.DB
+	db       *gorm.DB
+	hostKeys sshclient.HostKeyStore
 }
 
 // NewHostGetter 创建主机信息获取器
 func NewHostGetter(db *gorm.DB) *HostGetter {
-	return &HostGetter{db: db}
-}
-
-// Host 主机模型(简化版,与asset.Host对应)
-type Host struct {
-	ID           uint   `gorm:"primarykey"`
-	Name         string `gorm:"type:varchar(100)"`
-	IP           string `gorm:"column:ip;type:varchar(50)"`
-	Port         int    `gorm:"type:int;default:22"`
-	SSHUser      string `gorm:"column:ssh_user;type:varchar(50)"`
-	CredentialID uint   `gorm:"column:credential_id"`
-}
-
-// TableName 表名
-func (Host) TableName() string {
-	return "hosts"
-}
-
-// Credential 凭证模型
-type Credential struct {
-	ID         uint   `gorm:"primarykey"`
-	Name       string `gorm:"type:varchar(100)"`
-	Type       string `gorm:"type:varchar(20)"` // password/key
-	Username   string `gorm:"type:varchar(100)"`
-	Password   string `gorm:"type:text"`
-	PrivateKey string `gorm:"type:text"`
-	Passphrase string `gorm:"type:varchar(255)"`
-}
-
-// TableName 表名
-func (Credential) TableName() string {
-	return "credentials"
+	return &HostGetter{db: db, hostKeys: assetdata.NewHostKeyStore(db)}
 }
 
-// decrypt 解密凭证数据
-func decrypt(ciphertext string) (string, error) {
-	if ciphertext == "" {
-		return "", nil
-	}
-
-	data, err := base64.StdEncoding.DecodeString(ciphertext)
-	if err != nil {
-		return "", err
-	}
-
-	block, err := aes.NewCipher(encryptionKey)
-	if err != nil {
-		return "", err
-	}
-
-	gcm, err := cipher.NewGCM(block)
-	if err != nil {
-		return "", err
-	}
-
-	nonceSize := gcm.NonceSize()
-	if len(data) < nonceSize {
-		return "", fmt.Errorf("ciphertext too short")
-	}
+// GetHost 获取主机连接信息，与主机管理共用凭证解密（含外部密钥）、证书签发和跳板机解析
+func (g *HostGetter) GetHost(ctx context.Context, hostID uint) (*deployer.HostInfo, error) {
+	hostRepo := assetdata.NewHostRepo(g.db)
+	credentialRepo := assetdata.NewCredentialRepo(g.db)
 
-	nonce, ciphertextBytes := data[:nonceSize], data[nonceSize:]
-	plaintext, err := gcm.Open(nil, nonce, ciphertextBytes, nil)
+	host, err := hostRepo.GetByID(ctx, hostID)
 	if err != nil {
-		return "", err
-	}
-
-	return string(plaintext), nil
-}
-
-// GetHost 获取主机信息
-func (g *HostGetter) GetHost(ctx context.Context, hostID uint) (*deployer.HostInfo, error) {
-	var host Host
-	if err := g.db.WithContext(ctx).First(&host, hostID).Error; err != nil {
 		return nil, fmt.Errorf("host not found: %w", err)
 	}
 
@@ -112,42 +44,42 @@ func (g *HostGetter) GetHost(ctx context.Context, hostID uint) (*deployer.HostIn
 		Host:     host.IP,
 		Port:     host.Port,
 		Username: host.SSHUser,
+		// 与主机管理共用同一份主机密钥信任记录
+		HostKeyCallback:   sshclient.NewHostKeyCallback(g.hostKeys, host.ID),
+		HostKeyAlgorithms: sshclient.HostKeyAlgorithms(g.hostKeys, host.ID),
 	}
 
 	// 获取凭证信息
 	if host.CredentialID > 0 {
-		var cred Credential
-		if err := g.db.WithContext(ctx).First(&cred, host.CredentialID).Error; err == nil {
-			if cred.Type == "password" {
-				// 解密密码
-				password, err := decrypt(cred.Password)
-				if err != nil {
-					return nil, fmt.Errorf("decrypt password failed: %w", err)
-				}
-				info.Password = password
-			} else if cred.Type == "key" {
-				// 解密私钥
-				privateKey, err := decrypt(cred.PrivateKey)
-				if err != nil {
-					return nil, fmt.Errorf("decrypt private key failed: %w", err)
-				}
-				info.PrivateKey = []byte(privateKey)
-				// 解密私钥密码
-				if cred.Passphrase != "" {
-					passphrase, err := decrypt(cred.Passphrase)
-					if err != nil {
-						return nil, fmt.Errorf("decrypt passphrase failed: %w", err)
-					}
-					info.Passphrase = passphrase
-				}
-			}
-			// 如果凭证中有用户名，优先使用凭证的用户名
-			if cred.Username != "" {
-				info.Username = cred.Username
+		cred, err := credentialRepo.GetByIDDecrypted(ctx, host.CredentialID)
+		if err != nil {
+			return nil, fmt.Errorf("get credential failed: %w", err)
+		}
+		switch {
+		case cred.IsCertificate():
+			signer, err := assetbiz.SSHCertSigner(ctx, host, host.SSHUser)
+			if err != nil {
+				return nil, fmt.Errorf("issue ssh certificate failed: %w", err)
 			}
+			info.Signer = signer
+		case cred.Type == "key":
+			info.PrivateKey = []byte(cred.PrivateKey)
+			info.Passphrase = cred.Passphrase
+		default:
+			info.Password = cred.Password
+		}
+		// 如果凭证中有用户名，优先使用凭证的用户名；证书凭证按主机登录用户签发
+		if cred.Username != "" && !cred.IsCertificate() {
+			info.Username = cred.Username
 		}
 	}
 
+	jumpHosts, err := assetbiz.ResolveJumpHosts(ctx, hostRepo, credentialRepo, g.hostKeys, host)
+	if err != nil {
+		return nil, err
+	}
+	info.JumpHosts = jumpHosts
+
 	return info, nil
 }
 
@@ -173,38 +105,6 @@ func (Cluster) TableName() string {
 	return "k8s_clusters"
 }
 
-// decryptKubeConfig 解密 kubeconfig
-func decryptKubeConfig(cipherText string) (string, error) {
-	key := []byte(kubeConfigEncryptionKey)
-	ciphertext, err := base64.StdEncoding.DecodeString(cipherText)
-	if err != nil {
-		return "", err
-	}
-
-	block, err := aes.NewCipher(key)
-	if err != nil {
-		return "", err
-	}
-
-	gcm, err := cipher.NewGCM(block)
-	if err != nil {
-		return "", err
-	}
-
-	nonceSize := gcm.NonceSize()
-	if len(ciphertext) < nonceSize {
-		return "", errors.New("ciphertext too short")
-	}
-
-	nonce, ciphertext := ciphertext[:nonceSize], ciphertext[nonceSize:]
-	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
-	if err != nil {
-		return "", err
-	}
-
-	return string(plaintext), nil
-}
-
 // GetClusterClient 获取K8s客户端
 func (g *ClusterGetter) GetClusterClient(ctx context.Context, clusterID uint) (deployer.K8sClient, error) {
 	var cluster Cluster
@@ -213,7 +113,7 @@ func (g *ClusterGetter) GetClusterClient(ctx context.Context, clusterID uint) (d
 	}
 
 	// 解密 kubeconfig
-	kubeConfig, err := decryptKubeConfig(cluster.KubeConfig)
+	kubeConfig, err := kms.Decrypt(cluster.KubeConfig)
 	if err != nil {
 		// 如果解密失败，尝试直接使用（可能未加密）
 		kubeConfig = cluster.KubeConfig
@@ -29,7 +29,7 @@ type AnsibleTask struct {
 	Name            string     `json:"name" gorm:"size:255;not null" binding:"required"`
 	PlaybookContent string     `json:"playbookContent,omitempty" gorm:"type:longtext"`
 	PlaybookPath    string     `json:"playbookPath,omitempty" gorm:"size:500"`
-	Inventory       string     `json:"inventory,omitempty" gorm:"type:text"` // JSON字符串
+	Inventory       string     `json:"inventory,omitempty" gorm:"type:text"` // JSON字符串，见 AnsibleInventory
 	ExtraVars       string     `json:"extraVars,omitempty" gorm:"type:json"` // JSON
 	Tags            string     `json:"tags,omitempty" gorm:"size:500"` // 逗号分隔
 	Fork            int        `json:"fork" gorm:"default:5"`
@@ -37,7 +37,7 @@ type AnsibleTask struct {
 	Verbose         string     `json:"verbose" gorm:"size:20;default:v"` // v, vv, vvv
 	Status          string     `json:"status" gorm:"size:50;not null;default:pending;index"` // pending, running, success, failed, cancelled
 	LastRunTime     *time.Time `json:"lastRunTime,omitempty"`
-	LastRunResult   string     `json:"lastRunResult,omitempty" gorm:"type:json"` // JSON
+	LastRunResult   string     `json:"lastRunResult,omitempty" gorm:"type:json"` // JSON，见 AnsibleRunResult
 	CreatedBy       uint       `json:"createdBy" gorm:"not null"`
 	CreatedAt       time.Time  `json:"createdAt"`
 	UpdatedAt       time.Time  `json:"updatedAt"`
@@ -47,3 +47,36 @@ type AnsibleTask struct {
 func (AnsibleTask) TableName() string {
 	return "ansible_tasks"
 }
+
+// AnsibleInventory Ansible任务的目标主机，分组会展开为其下（含子分组）的所有主机
+type AnsibleInventory struct {
+	HostIDs  []uint `json:"hostIds"`
+	GroupIDs []uint `json:"groupIds"`
+}
+
+// AnsibleRunResult Ansible任务执行结果
+type AnsibleRunResult struct {
+	Status    string            `json:"status"` // success, failed, cancelled
+	ExitCode  int               `json:"exitCode"`
+	StartTime time.Time         `json:"startTime"`
+	EndTime   time.Time         `json:"endTime"`
+	Hosts     []AnsibleHostStat `json:"hosts"`
+	Output    string            `json:"output"`
+	Error     string            `json:"error,omitempty"`
+}
+
+// AnsibleHostStat PLAY RECAP 中单台主机的统计
+type AnsibleHostStat struct {
+	HostID      uint   `json:"hostId"`
+	HostName    string `json:"hostName"`
+	HostIP      string `json:"hostIp"`
+	Status      string `json:"status"` // ok, changed, failed, unreachable, skipped
+	Ok          int    `json:"ok"`
+	Changed     int    `json:"changed"`
+	Unreachable int    `json:"unreachable"`
+	Failed      int    `json:"failed"`
+	Skipped     int    `json:"skipped"`
+	Rescued     int    `json:"rescued"`
+	Ignored     int    `json:"ignored"`
+	Error       string `json:"error,omitempty"`
+}
@@ -0,0 +1,51 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package model
+
+import (
+	"time"
+)
+
+// 任务审批状态
+const (
+	JobStatusPendingApproval = "pending_approval" // 等待审批
+	JobStatusRejected        = "rejected"         // 审批驳回
+)
+
+// JobApprovalPolicy 任务审批策略，命中策略的任务执行需审批通过后才开始
+// 各条件之间为“或”关系：目标主机属于指定资产分组（含下级分组）、模板属于指定分类、命令策略结果为需审批
+type JobApprovalPolicy struct {
+	ID                 uint       `json:"id" gorm:"primaryKey"`
+	Name               string     `json:"name" gorm:"size:255;not null" binding:"required"`
+	Description        string     `json:"description" gorm:"type:text"`
+	AssetGroupIDs      string     `json:"assetGroupIds" gorm:"type:text"`      // JSON 资产分组ID数组
+	TemplateCategories string     `json:"templateCategories" gorm:"type:text"` // JSON 模板分类数组
+	OnCommandApproval  bool       `json:"onCommandApproval"`                   // 命令策略结果为需审批时触发
+	Approvers          string     `json:"approvers" gorm:"type:text"`          // JSON 审批人用户ID数组，为空时由管理员审批
+	Status             int        `json:"status" gorm:"default:1;index"`       // 0-禁用, 1-启用
+	CreatedBy          uint       `json:"createdBy" gorm:"not null"`
+	CreatedAt          time.Time  `json:"createdAt"`
+	UpdatedAt          time.Time  `json:"updatedAt"`
+	DeletedAt          *time.Time `json:"deletedAt,omitempty" gorm:"index"`
+}
+
+func (JobApprovalPolicy) TableName() string {
+	return "job_approval_policies"
+}
@@ -0,0 +1,59 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package model
+
+import (
+	"time"
+)
+
+// 错过执行时间的处理策略
+const (
+	MissedRunSkip    = "skip"    // 跳过错过的执行，等待下一次
+	MissedRunCatchUp = "catchup" // 立即补执行一次
+)
+
+// JobSchedule 定时任务，按 cron 表达式周期执行脚本，每次执行生成一条 TaskType 为 cron 的 JobTask
+type JobSchedule struct {
+	ID              uint       `json:"id" gorm:"primaryKey"`
+	Name            string     `json:"name" gorm:"size:255;not null" binding:"required"`
+	Description     string     `json:"description" gorm:"type:text"`
+	CronExpr        string     `json:"cronExpr" gorm:"size:100;not null" binding:"required"` // 分 时 日 月 周
+	TemplateID      *uint      `json:"templateId,omitempty" gorm:"index"`                    // 使用模板内容执行，为空时使用 Content
+	ScriptType      string     `json:"scriptType" gorm:"size:20;default:Shell"`              // Shell, Python
+	Content         string     `json:"content,omitempty" gorm:"type:longtext"`
+	Variables       string     `json:"variables,omitempty" gorm:"type:text"` // JSON 模板变量取值，敏感变量只能填写凭证ID
+	TargetHosts     string     `json:"targetHosts" gorm:"type:text"` // JSON 主机ID数组
+	Concurrency     int        `json:"concurrency" gorm:"default:10"`
+	Timeout         int        `json:"timeout" gorm:"default:600"`                  // 单主机超时（秒）
+	MissedRunPolicy string     `json:"missedRunPolicy" gorm:"size:20;default:skip"` // skip, catchup
+	Status          int        `json:"status" gorm:"default:1;index"`               // 0-禁用, 1-启用
+	NextRunTime     *time.Time `json:"nextRunTime,omitempty" gorm:"index"`
+	LastRunTime     *time.Time `json:"lastRunTime,omitempty"`
+	LastRunStatus   string     `json:"lastRunStatus,omitempty" gorm:"size:50"` // success, failed, cancelled, skipped
+	LastJobTaskID   *uint      `json:"lastJobTaskId,omitempty"`
+	CreatedBy       uint       `json:"createdBy" gorm:"not null"`
+	CreatedAt       time.Time  `json:"createdAt"`
+	UpdatedAt       time.Time  `json:"updatedAt"`
+	DeletedAt       *time.Time `json:"deletedAt,omitempty" gorm:"index"`
+}
+
+func (JobSchedule) TableName() string {
+	return "job_schedules"
+}
@@ -28,17 +28,26 @@ type JobTask struct {
 	ID           uint       `json:"id" gorm:"primaryKey"`
 	Name         string     `json:"name" gorm:"size:255;not null" binding:"required"`
 	TemplateID   *uint      `json:"templateId,omitempty" gorm:"index"`
+	ScheduleID   *uint      `json:"scheduleId,omitempty" gorm:"index"` // 由定时任务触发时对应的 JobSchedule
 	TaskType     string     `json:"taskType" gorm:"size:50;not null;index" binding:"required"` // manual, ansible, cron
-	Status       string     `json:"status" gorm:"size:50;not null;default:pending;index"` // pending, running, success, failed
+	Status       string     `json:"status" gorm:"size:50;not null;default:pending;index"` // pending_approval, pending, running, success, failed, cancelled, skipped, rejected
 	TargetHosts  string     `json:"targetHosts,omitempty" gorm:"type:text"` // JSON字符串
 	Parameters   string     `json:"parameters,omitempty" gorm:"type:text"` // JSON
 	ExecuteTime  *time.Time `json:"executeTime,omitempty"`
 	Result       string     `json:"result,omitempty" gorm:"type:text"` // JSON
 	ErrorMessage string     `json:"errorMessage,omitempty" gorm:"type:text"`
 	CreatedBy    uint       `json:"createdBy" gorm:"not null"`
-	CreatedAt    time.Time  `json:"createdAt"`
-	UpdatedAt    time.Time  `json:"updatedAt"`
-	DeletedAt    *time.Time `json:"deletedAt,omitempty" gorm:"index"`
+	// 审批信息，命中审批策略的执行先进入 pending_approval 状态，审批通过后才开始执行
+	ApprovalReason  string     `json:"approvalReason,omitempty" gorm:"type:text"`
+	Approvers       string     `json:"approvers,omitempty" gorm:"type:text"` // JSON 可审批的用户ID数组，为空时由管理员审批
+	ApproverID      *uint      `json:"approverId,omitempty"`
+	ApproverName    string     `json:"approverName,omitempty" gorm:"size:100"`
+	ApprovalTime    *time.Time `json:"approvalTime,omitempty"`
+	ApprovalComment string     `json:"approvalComment,omitempty" gorm:"type:text"`
+	ExecutionSpec   string     `json:"-" gorm:"type:longtext"` // 待审批执行的参数，审批通过后按此执行
+	CreatedAt       time.Time  `json:"createdAt"`
+	UpdatedAt       time.Time  `json:"updatedAt"`
+	DeletedAt       *time.Time `json:"deletedAt,omitempty" gorm:"index"`
 }
 
 func (JobTask) TableName() string {
@@ -30,7 +30,7 @@ type JobTemplate struct {
 	Code        string     `json:"code" gorm:"size:100;not null;uniqueIndex" binding:"required"`
 	Description string     `json:"description" gorm:"type:text"`
 	Content     string     `json:"content" gorm:"type:longtext;not null" binding:"required"`
-	Variables   string     `json:"variables,omitempty" gorm:"type:text"` // JSON字符串
+	Variables   string     `json:"variables,omitempty" gorm:"type:text"` // JSON字符串，见 TemplateVariable
 	Category    string     `json:"category" gorm:"size:50;not null;index" binding:"required"` // script, ansible, module
 	Platform    string     `json:"platform,omitempty" gorm:"size:50"` // linux, windows
 	Timeout     int        `json:"timeout" gorm:"default:300"` // 秒
@@ -45,3 +45,27 @@ type JobTemplate struct {
 func (JobTemplate) TableName() string {
 	return "job_templates"
 }
+
+// 模板变量类型
+const (
+	VarTypeString = "string"
+	VarTypeInt    = "int"
+	VarTypeEnum   = "enum"
+	VarTypeSecret = "secret" // 值来自加密存储的凭证，不会出现在任务参数与执行结果中
+	VarTypeHosts  = "hosts"  // 主机ID列表，渲染为以空格分隔的主机IP
+)
+
+// TemplateVariable 模板变量定义，脚本中以 {{varName}} 引用
+type TemplateVariable struct {
+	Name         string   `json:"name"`    // 显示名称
+	VarName      string   `json:"varName"` // 变量名
+	Type         string   `json:"type"`    // string, int, enum, secret, hosts
+	Required     bool     `json:"required"`
+	DefaultValue string   `json:"defaultValue,omitempty"`
+	HelpText     string   `json:"helpText,omitempty"`
+	Options      []string `json:"options,omitempty"`      // enum 可选值
+	Min          *int     `json:"min,omitempty"`          // int 最小值
+	Max          *int     `json:"max,omitempty"`          // int 最大值
+	Pattern      string   `json:"pattern,omitempty"`      // string 校验正则
+	CredentialID uint     `json:"credentialId,omitempty"` // secret 默认引用的凭证
+}
@@ -30,8 +30,10 @@ import (
 
 // Plugin 任务中心插件实现
 type Plugin struct {
-	db   *gorm.DB
-	name string
+	db        *gorm.DB
+	name      string
+	handler   *server.Handler
+	scheduler *server.Scheduler
 }
 
 // New 创建插件实例
@@ -66,32 +68,48 @@ func (p *Plugin) Enable(db *gorm.DB) error {
 	p.db = db
 
 	// 自动迁移所有插件相关的表
+	// GORM 的 AutoMigrate 会自动添加缺失的列，不会删除已有数据
 	models := []interface{}{
 		&model.JobTask{},
 		&model.JobTemplate{},
 		&model.AnsibleTask{},
+		&model.JobSchedule{},
+		&model.JobApprovalPolicy{},
 	}
 
 	for _, m := range models {
-		if !db.Migrator().HasTable(m) {
-			if err := db.AutoMigrate(m); err != nil {
-				return err
-			}
+		if err := db.AutoMigrate(m); err != nil {
+			return err
 		}
 	}
 
+	// 启动定时任务调度器
+	p.scheduler = server.NewScheduler(p.getHandler(db))
+	p.scheduler.Start()
+
 	return nil
 }
 
 // Disable 禁用插件
 func (p *Plugin) Disable(db *gorm.DB) error {
-	// 清理插件资源（如果需要）
+	// 停止定时任务调度器
+	if p.scheduler != nil {
+		p.scheduler.Stop()
+	}
 	return nil
 }
 
 // RegisterRoutes 注册路由
 func (p *Plugin) RegisterRoutes(router *gin.RouterGroup, db *gorm.DB) {
-	server.RegisterRoutes(router, db)
+	server.RegisterRoutes(router, p.getHandler(db))
+}
+
+// getHandler 获取插件共享的处理器，路由与调度器需使用同一个任务执行器才能查看和取消定时执行
+func (p *Plugin) getHandler(db *gorm.DB) *server.Handler {
+	if p.handler == nil {
+		p.handler = server.NewHandler(db)
+	}
+	return p.handler
 }
 
 // GetMenus 获取插件菜单配置
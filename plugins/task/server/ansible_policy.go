@@ -0,0 +1,498 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/ydcloud-dy/opshub/internal/conf"
+	"github.com/ydcloud-dy/opshub/plugins/task/model"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	defaultPlaybookDir    = "./data/playbooks"
+	defaultAnsibleRunUser = "nobody"
+)
+
+var (
+	// localTargetRegexp 指向服务器本机的主机名或地址
+	localTargetRegexp = regexp.MustCompile(`(?i)^(?:localhost(?:\.localdomain)?\.?|127(?:\.\d{1,3}){3}|0\.0\.0\.0|::1?|(?:0+:){7}0*1?)$`)
+	// controllerLookupRegexp 在控制端读取环境变量、文件或执行命令的 lookup 插件
+	controllerLookupRegexp = regexp.MustCompile(`\b(?:lookup|query|q)\s*\(\s*['"](?:ansible\.builtin\.)?(?:env|file|fileglob|first_found|ini|csvfile|lines|pipe|password|template|unvault|config|vars)['"]`)
+	// hostvarsRegexp 读取其他主机变量（包括 inventory 中的连接密码）的写法
+	hostvarsRegexp = regexp.MustCompile(`\bhostvars\b`)
+	// connectionVarRegexp 以 key=value 形式（如 set_fact 自由格式参数）设置的连接参数变量
+	connectionVarRegexp = regexp.MustCompile(`\b(ansible_(?:ssh_common_args|ssh_extra_args|ssh_args|ssh_executable|scp_extra_args|sftp_extra_args|ssh_private_key_file|private_key_file))\s*=`)
+)
+
+var (
+	// ansibleControllerKeys 在控制端执行或读取控制端数据的写法
+	ansibleControllerKeys = map[string]bool{
+		"local_action":                 true,
+		"add_host":                     true,
+		"ansible.builtin.add_host":     true,
+		"with_env":                     true,
+		"with_file":                    true,
+		"with_fileglob":                true,
+		"with_first_found":             true,
+		"with_ini":                     true,
+		"with_csvfile":                 true,
+		"with_lines":                   true,
+		"with_pipe":                    true,
+		"with_password":                true,
+		"include_vars":                 true,
+		"script":                       true,
+		"fetch":                        true,
+		"synchronize":                  true,
+		"ansible.builtin.include_vars": true,
+		"ansible.builtin.script":       true,
+		"ansible.builtin.fetch":        true,
+		"ansible.posix.synchronize":    true,
+	}
+	// ansibleConnectionVars 覆盖 SSH 参数的变量，可关闭主机密钥校验或通过 ProxyCommand 在服务器本机执行命令
+	ansibleConnectionVars = map[string]bool{
+		"ansible_ssh_common_args":      true,
+		"ansible_ssh_extra_args":       true,
+		"ansible_ssh_args":             true,
+		"ansible_ssh_executable":       true,
+		"ansible_scp_extra_args":       true,
+		"ansible_sftp_extra_args":      true,
+		"ansible_ssh_private_key_file": true,
+		"ansible_private_key_file":     true,
+	}
+	// ansibleSrcModules 从控制端读取 src 文件的模块，值表示是否可以通过 remote_src 改为读取目标主机上的文件
+	ansibleSrcModules = map[string]bool{
+		"copy":                      true,
+		"unarchive":                 true,
+		"template":                  false,
+		"ansible.builtin.copy":      true,
+		"ansible.builtin.unarchive": true,
+		"ansible.builtin.template":  false,
+		"ansible.legacy.copy":       true,
+		"ansible.legacy.unarchive":  true,
+		"ansible.legacy.template":   false,
+	}
+	// ansibleSSHConnections 允许的连接方式，主机密钥校验与跳板机通过 ssh 参数实现，不支持 paramiko
+	ansibleSSHConnections = map[string]bool{
+		"ssh":                 true,
+		"smart":               true,
+		"ansible.builtin.ssh": true,
+	}
+	// ansibleCommandModules 执行 shell 命令的模块
+	ansibleCommandModules = map[string]bool{
+		"shell":                   true,
+		"command":                 true,
+		"raw":                     true,
+		"ansible.builtin.shell":   true,
+		"ansible.builtin.command": true,
+		"ansible.builtin.raw":     true,
+		"ansible.legacy.shell":    true,
+		"ansible.legacy.command":  true,
+		"ansible.legacy.raw":      true,
+	}
+)
+
+// preparedAnsibleRun 通过检查的一次Ansible任务执行
+type preparedAnsibleRun struct {
+	playbookPath string               // 托管目录中的Playbook，使用内联Playbook时为空
+	contentHash  string               // Playbook、额外变量与目标主机的摘要，审批后校验内容未变更
+	hostIDs      []uint               // 全部目标主机，包括执行时会被跳过的主机
+	approval     *approvalRequirement // 不为空时需要审批后执行
+}
+
+// ansibleConfig 返回Ansible执行配置，未配置的项使用默认值
+func ansibleConfig() conf.AnsibleConfig {
+	var cfg conf.AnsibleConfig
+	if c := conf.Get(); c != nil {
+		cfg = c.Ansible
+	}
+	if cfg.PlaybookDir == "" {
+		cfg.PlaybookDir = defaultPlaybookDir
+	}
+	if cfg.RunAsUser == "" {
+		cfg.RunAsUser = defaultAnsibleRunUser
+	}
+	return cfg
+}
+
+// resolvePlaybookPath 校验Playbook文件位于托管目录下，返回解析符号链接后的绝对路径。相对路径相对托管目录
+func resolvePlaybookPath(path string) (string, error) {
+	dir, err := filepath.Abs(ansibleConfig().PlaybookDir)
+	if err == nil {
+		dir, err = filepath.EvalSymlinks(dir)
+	}
+	if err != nil {
+		return "", fmt.Errorf("Playbook托管目录不可用: %w", err)
+	}
+
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	resolved, err := filepath.EvalSymlinks(filepath.Clean(path))
+	if err != nil {
+		return "", fmt.Errorf("Playbook文件不存在: %s", path)
+	}
+	rel, err := filepath.Rel(dir, resolved)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("Playbook路径必须位于托管目录 %s 下", dir)
+	}
+	info, err := os.Stat(resolved)
+	if err != nil || !info.Mode().IsRegular() {
+		return "", fmt.Errorf("Playbook文件不存在: %s", path)
+	}
+	return resolved, nil
+}
+
+// validateAnsibleTask 保存Ansible任务前检查Playbook路径和内容
+func validateAnsibleTask(task *model.AnsibleTask) error {
+	content := task.PlaybookContent
+	if strings.TrimSpace(content) == "" && task.PlaybookPath != "" {
+		path, err := resolvePlaybookPath(task.PlaybookPath)
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("读取Playbook失败: %w", err)
+		}
+		content = string(data)
+	}
+	return checkPlaybookTargets(content, task.ExtraVars)
+}
+
+// prepareAnsibleRun 检查Playbook、命令策略并匹配审批策略，返回错误时附带HTTP状态码
+func (h *Handler) prepareAnsibleRun(ctx context.Context, task *model.AnsibleTask, userID uint) (*preparedAnsibleRun, int, error) {
+	run := &preparedAnsibleRun{}
+	content := task.PlaybookContent
+	if strings.TrimSpace(content) == "" {
+		if task.PlaybookPath == "" {
+			return nil, http.StatusBadRequest, fmt.Errorf("未配置Playbook")
+		}
+		path, err := resolvePlaybookPath(task.PlaybookPath)
+		if err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, http.StatusBadRequest, fmt.Errorf("读取Playbook失败: %w", err)
+		}
+		content = string(data)
+		run.playbookPath = path
+	}
+	if err := checkPlaybookTargets(content, task.ExtraVars); err != nil {
+		return nil, http.StatusForbidden, err
+	}
+
+	hosts, skipped, err := h.ansible.resolveHosts(ctx, task)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	for _, item := range hosts {
+		run.hostIDs = append(run.hostIDs, item.host.ID)
+	}
+	for _, stat := range skipped {
+		run.hostIDs = append(run.hostIDs, stat.HostID)
+	}
+
+	// 命令策略检查：Playbook 中 shell、command、raw 模块的命令按 Shell 脚本检查
+	commands, dynamic := playbookCommands(content)
+	commandReason, err := h.checkCommandPolicy(ctx, commandPolicyRequest{
+		ScriptType: "Shell",
+		Content:    strings.Join(commands, "\n"),
+		UserID:     userID,
+		HostIDs:    run.hostIDs,
+	})
+	if err != nil {
+		return nil, http.StatusForbidden, err
+	}
+	if dynamic && commandReason == "" {
+		commandReason = "Playbook 中的命令由变量生成，无法按命令策略检查"
+	}
+	run.approval, err = h.approvalRequirement(ctx, run.hostIDs, nil, commandReason)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+
+	sum := sha256.Sum256([]byte(strings.Join([]string{content, task.ExtraVars, task.Inventory, task.Tags}, "\x00")))
+	run.contentHash = hex.EncodeToString(sum[:])
+	return run, 0, nil
+}
+
+// checkPlaybookTargets 检查Playbook与额外变量，拒绝以服务器本机为目标、非 SSH 连接、覆盖 SSH 参数以及在控制端读取环境变量、文件或其他主机变量的写法
+func checkPlaybookTargets(playbook, extraVars string) error {
+	docs, err := decodeYAMLDocuments(playbook)
+	if err != nil {
+		return fmt.Errorf("解析Playbook失败: %w", err)
+	}
+	for _, doc := range docs {
+		if len(doc.Content) > 0 && doc.Content[0].Kind == yaml.SequenceNode {
+			for _, play := range doc.Content[0].Content {
+				if err := checkPlayHosts(yamlMappingValue(play, "hosts")); err != nil {
+					return err
+				}
+			}
+		}
+		if err := checkAnsibleNode(doc); err != nil {
+			return err
+		}
+	}
+
+	if strings.TrimSpace(extraVars) == "" {
+		return nil
+	}
+	vars, err := decodeYAMLDocuments(extraVars)
+	if err != nil {
+		return fmt.Errorf("解析额外变量失败: %w", err)
+	}
+	for _, doc := range vars {
+		if err := checkAnsibleNode(doc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkPlayHosts 检查 play 的 hosts，不允许使用变量或指向服务器本机
+func checkPlayHosts(node *yaml.Node) error {
+	if node == nil {
+		return nil
+	}
+	var patterns []string
+	switch node.Kind {
+	case yaml.ScalarNode:
+		patterns = append(patterns, node.Value)
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			patterns = append(patterns, item.Value)
+		}
+	}
+
+	for _, pattern := range patterns {
+		if strings.Contains(pattern, "{{") {
+			return fmt.Errorf("Playbook 的 hosts 不能使用变量: %s", pattern)
+		}
+		parts := append([]string{pattern}, strings.FieldsFunc(pattern, func(r rune) bool { return r == ',' || r == ':' })...)
+		for _, part := range parts {
+			if isLocalTarget(strings.TrimLeft(strings.TrimSpace(part), "!&")) {
+				return fmt.Errorf("Playbook 不允许以服务器本机为目标: hosts: %s", pattern)
+			}
+		}
+	}
+	return nil
+}
+
+// checkAnsibleNode 递归检查 YAML 节点中的连接方式、SSH 参数、委派目标、控制端文件和 lookup 插件
+func checkAnsibleNode(node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.DocumentNode, yaml.SequenceNode:
+		for _, child := range node.Content {
+			if err := checkAnsibleNode(child); err != nil {
+				return err
+			}
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i].Value, node.Content[i+1]
+			// 别名引用的节点在定义处已检查，这里只解析出值用于判断
+			if value.Kind == yaml.AliasNode && value.Alias != nil {
+				value = value.Alias
+			}
+			switch key {
+			case "connection", "ansible_connection":
+				if value.Kind != yaml.ScalarNode || !ansibleSSHConnections[value.Value] {
+					return fmt.Errorf("Playbook 只允许通过 SSH 连接目标主机，不支持 %s: %s", key, value.Value)
+				}
+			case "delegate_to":
+				if strings.Contains(value.Value, "{{") || isLocalTarget(value.Value) {
+					return fmt.Errorf("Playbook 不允许委派到服务器本机或由变量指定的主机: delegate_to: %s", value.Value)
+				}
+			case "ansible_host", "ansible_ssh_host":
+				if isLocalTarget(value.Value) {
+					return fmt.Errorf("Playbook 不允许以服务器本机为目标: %s: %s", key, value.Value)
+				}
+			default:
+				if ansibleControllerKeys[key] {
+					return fmt.Errorf("Playbook 不允许使用 %s", key)
+				}
+				if ansibleConnectionVars[key] {
+					return fmt.Errorf("Playbook 不允许覆盖 SSH 连接参数: %s", key)
+				}
+				if _, ok := ansibleSrcModules[key]; ok {
+					if err := checkControllerSrc(node, key, value); err != nil {
+						return err
+					}
+				}
+			}
+			if err := checkAnsibleNode(node.Content[i+1]); err != nil {
+				return err
+			}
+		}
+	case yaml.ScalarNode:
+		if controllerLookupRegexp.MatchString(node.Value) {
+			return fmt.Errorf("Playbook 不允许在控制端读取环境变量、文件或执行命令: %s", node.Value)
+		}
+		if hostvarsRegexp.MatchString(node.Value) {
+			return fmt.Errorf("Playbook 不允许读取 hostvars: %s", node.Value)
+		}
+		if m := connectionVarRegexp.FindStringSubmatch(node.Value); m != nil {
+			return fmt.Errorf("Playbook 不允许覆盖 SSH 连接参数: %s", m[1])
+		}
+	}
+	return nil
+}
+
+// checkControllerSrc 检查 copy、template、unarchive 模块的参数，不允许读取控制端的 src 文件
+// task 为包含模块的任务节点，参数可以是自由格式的 key=value 字符串、映射，或写在任务的 args 中
+func checkControllerSrc(task *yaml.Node, module string, args *yaml.Node) error {
+	params := make(map[string]string)
+	for _, node := range []*yaml.Node{args, yamlMappingValue(task, "args")} {
+		if node == nil {
+			continue
+		}
+		switch node.Kind {
+		case yaml.ScalarNode:
+			for _, field := range strings.Fields(node.Value) {
+				if key, value, found := strings.Cut(field, "="); found {
+					params[key] = strings.Trim(value, `'"`)
+				}
+			}
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				value := node.Content[i+1]
+				if value.Kind == yaml.AliasNode && value.Alias != nil {
+					value = value.Alias
+				}
+				params[node.Content[i].Value] = value.Value
+			}
+		}
+	}
+
+	if _, ok := params["src"]; !ok {
+		return nil
+	}
+	if ansibleSrcModules[module] {
+		switch strings.ToLower(params["remote_src"]) {
+		case "yes", "true", "on", "1", "y":
+			return nil
+		}
+	}
+	return fmt.Errorf("Playbook 不允许读取服务器本机的文件: %s 的 src 参数", module)
+}
+
+// playbookCommands 提取Playbook中 shell、command、raw 模块执行的命令，每条一个元素；存在整体由变量生成的命令时 dynamic 为 true
+func playbookCommands(playbook string) (commands []string, dynamic bool) {
+	docs, _ := decodeYAMLDocuments(playbook)
+	var walk func(node *yaml.Node)
+	walk = func(node *yaml.Node) {
+		if node.Kind == yaml.MappingNode {
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				if !ansibleCommandModules[node.Content[i].Value] {
+					continue
+				}
+				command := moduleCommand(node.Content[i+1])
+				if command == "" {
+					command = moduleCommand(yamlMappingValue(node, "args"))
+				}
+				if command == "" {
+					continue
+				}
+				if strings.HasPrefix(strings.TrimSpace(command), "{{") {
+					dynamic = true
+				}
+				commands = append(commands, command)
+			}
+		}
+		for _, child := range node.Content {
+			walk(child)
+		}
+	}
+	for _, doc := range docs {
+		walk(doc)
+	}
+	return commands, dynamic
+}
+
+// moduleCommand 读取命令模块的参数：自由格式字符串、cmd 参数或 argv 列表
+func moduleCommand(node *yaml.Node) string {
+	if node == nil {
+		return ""
+	}
+	switch node.Kind {
+	case yaml.ScalarNode:
+		return node.Value
+	case yaml.MappingNode:
+		if cmd := yamlMappingValue(node, "cmd"); cmd != nil && cmd.Kind == yaml.ScalarNode {
+			return cmd.Value
+		}
+		if argv := yamlMappingValue(node, "argv"); argv != nil && argv.Kind == yaml.SequenceNode {
+			var parts []string
+			for _, item := range argv.Content {
+				parts = append(parts, item.Value)
+			}
+			return strings.Join(parts, " ")
+		}
+	}
+	return ""
+}
+
+// isLocalTarget 判断主机名或地址是否指向服务器本机
+func isLocalTarget(value string) bool {
+	return localTargetRegexp.MatchString(strings.TrimSpace(value))
+}
+
+// yamlMappingValue 返回映射节点中指定键的值，不存在时返回 nil
+func yamlMappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// decodeYAMLDocuments 解析全部 YAML 文档，JSON 也按 YAML 解析
+func decodeYAMLDocuments(content string) ([]*yaml.Node, error) {
+	decoder := yaml.NewDecoder(strings.NewReader(content))
+	var docs []*yaml.Node
+	for {
+		var doc yaml.Node
+		if err := decoder.Decode(&doc); err != nil {
+			if errors.Is(err, io.EOF) {
+				return docs, nil
+			}
+			return nil, err
+		}
+		docs = append(docs, &doc)
+	}
+}
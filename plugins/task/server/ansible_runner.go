@@ -0,0 +1,784 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package server
+
+import (
+	"bufio"
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	assetbiz "github.com/ydcloud-dy/opshub/internal/biz/asset"
+	rbacbiz "github.com/ydcloud-dy/opshub/internal/biz/rbac"
+	assetdata "github.com/ydcloud-dy/opshub/internal/data/asset"
+	appLogger "github.com/ydcloud-dy/opshub/pkg/logger"
+	sshclient "github.com/ydcloud-dy/opshub/pkg/ssh"
+	"github.com/ydcloud-dy/opshub/plugins/task/model"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// ansiblePlaybookBin ansible-playbook 可执行文件名
+const ansiblePlaybookBin = "ansible-playbook"
+
+var (
+	// recapLineRegexp PLAY RECAP 中的主机统计行，如 web01 : ok=2 changed=1 unreachable=0 failed=0
+	recapLineRegexp = regexp.MustCompile(`^(\S+)\s+:\s+((?:\w+=\d+\s*)+)$`)
+	// inventoryNameRegexp 可直接作为 inventory 主机名的名称
+	inventoryNameRegexp = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+	// verboseRegexp 合法的详细级别
+	verboseRegexp = regexp.MustCompile(`^v{1,4}$`)
+	// sshConfigValueRegexp 可以写入 ssh 配置文件的主机地址和用户名
+	sshConfigValueRegexp = regexp.MustCompile(`^[A-Za-z0-9._:%@-]+$`)
+)
+
+// ansibleRunner Ansible任务执行器，每个任务同时只允许一次执行
+type ansibleRunner struct {
+	h    *Handler
+	mu   sync.Mutex
+	runs map[uint]*taskRun
+}
+
+func newAnsibleRunner(h *Handler) *ansibleRunner {
+	return &ansibleRunner{h: h, runs: make(map[uint]*taskRun)}
+}
+
+// inventoryHost 生成 inventory 时的单台主机
+type inventoryHost struct {
+	name string
+	host assetbiz.Host
+}
+
+// ansibleRunOptions Ansible任务执行参数
+type ansibleRunOptions struct {
+	UserID       uint   // 执行用户，证书凭证的主机按该用户签发证书
+	PlaybookPath string // 已校验的托管目录中的Playbook，使用内联Playbook时为空
+	JobTaskID    uint   // 审批通过后执行时对应的任务记录
+	// OnDone 不为空时在任务结束后以最终状态回调
+	OnDone func(status string)
+}
+
+// Start 异步执行Ansible任务，调用前需通过 prepareAnsibleRun 检查
+func (r *ansibleRunner) Start(task *model.AnsibleTask, opts ansibleRunOptions) error {
+	if _, err := exec.LookPath(ansiblePlaybookBin); err != nil {
+		return fmt.Errorf("服务器未安装 %s", ansiblePlaybookBin)
+	}
+	if strings.TrimSpace(task.PlaybookContent) == "" && opts.PlaybookPath == "" {
+		return fmt.Errorf("未配置Playbook")
+	}
+	credential, err := ansibleRunCredential()
+	if err != nil {
+		return err
+	}
+	if task.Verbose != "" && !verboseRegexp.MatchString(task.Verbose) {
+		return fmt.Errorf("无效的详细级别: %s", task.Verbose)
+	}
+
+	r.mu.Lock()
+	if _, running := r.runs[task.ID]; running {
+		r.mu.Unlock()
+		return fmt.Errorf("任务正在执行中")
+	}
+
+	timeout := time.Duration(task.Timeout) * time.Second
+	if timeout <= 0 {
+		timeout = defaultHostTimeout
+	}
+	// 证书凭证的主机按执行用户签发证书，有效期覆盖整个执行过程
+	identity := r.h.sshCertIdentity(opts.UserID, assetbiz.SSHCertPurposeAnsible, rbacbiz.PermissionTerminal)
+	identity.TTL = timeout
+	ctx, cancel := context.WithTimeout(assetbiz.WithSSHCertIdentity(context.Background(), identity), timeout)
+	run := &taskRun{
+		taskID:    task.ID,
+		jobTaskID: opts.JobTaskID,
+		cancel:    cancel,
+		subs:      make(map[chan TaskEvent]struct{}),
+	}
+	r.runs[task.ID] = run
+	r.mu.Unlock()
+
+	now := time.Now()
+	if err := r.h.db.Model(&model.AnsibleTask{}).Where("id = ?", task.ID).Updates(map[string]interface{}{
+		"status":        "running",
+		"last_run_time": &now,
+	}).Error; err != nil {
+		r.remove(run)
+		cancel()
+		return fmt.Errorf("更新任务状态失败: %w", err)
+	}
+
+	go r.run(ctx, run, task, opts, credential)
+	return nil
+}
+
+// Cancel 取消正在执行的任务，任务不在执行中时返回 false
+func (r *ansibleRunner) Cancel(taskID uint, username string) bool {
+	r.mu.Lock()
+	run, ok := r.runs[taskID]
+	r.mu.Unlock()
+	if !ok {
+		return false
+	}
+
+	run.mu.Lock()
+	if run.cancelledBy == "" {
+		run.cancelledBy = username
+	}
+	run.mu.Unlock()
+
+	run.cancel()
+	return true
+}
+
+// Subscribe 订阅正在执行的任务输出
+func (r *ansibleRunner) Subscribe(taskID uint) ([]TaskEvent, chan TaskEvent, func(), bool) {
+	r.mu.Lock()
+	run, ok := r.runs[taskID]
+	r.mu.Unlock()
+	if !ok {
+		return nil, nil, nil, false
+	}
+	return run.subscribe()
+}
+
+// Active 返回正在执行的Ansible任务，以及其中审批通过后执行对应的任务记录
+func (r *ansibleRunner) Active() (ansibleTaskIDs, jobTaskIDs []uint) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, run := range r.runs {
+		ansibleTaskIDs = append(ansibleTaskIDs, id)
+		if run.jobTaskID != 0 {
+			jobTaskIDs = append(jobTaskIDs, run.jobTaskID)
+		}
+	}
+	return ansibleTaskIDs, jobTaskIDs
+}
+
+func (r *ansibleRunner) remove(run *taskRun) {
+	r.mu.Lock()
+	delete(r.runs, run.taskID)
+	r.mu.Unlock()
+}
+
+// run 执行 ansible-playbook 并保存结果
+func (r *ansibleRunner) run(ctx context.Context, run *taskRun, task *model.AnsibleTask, opts ansibleRunOptions, credential *syscall.Credential) {
+	defer run.cancel()
+
+	result := &model.AnsibleRunResult{StartTime: time.Now(), Status: "failed", ExitCode: -1}
+	output := &outputBuffer{}
+
+	hosts, err := r.execute(ctx, run, task, opts.PlaybookPath, credential, result, output)
+	if err != nil {
+		result.Error = err.Error()
+		run.publish(TaskEvent{Type: EventStderr, TaskID: run.taskID, Data: err.Error() + "\n"})
+	}
+
+	result.EndTime = time.Now()
+	result.Output = output.String()
+	result.Hosts = mergeRecap(hosts, result.Hosts, result.Output)
+
+	switch {
+	case run.cancelledUser() != "":
+		result.Status = "cancelled"
+		result.Error = run.cancelMessage()
+	case ctx.Err() == context.DeadlineExceeded:
+		result.Status = "failed"
+		result.Error = fmt.Sprintf("执行超时（%d秒），已终止", task.Timeout)
+	case err == nil && result.ExitCode == 0:
+		result.Status = "success"
+	}
+
+	for _, stat := range result.Hosts {
+		run.publish(TaskEvent{
+			Type:     EventHostDone,
+			TaskID:   run.taskID,
+			HostID:   stat.HostID,
+			HostName: stat.HostName,
+			HostIP:   stat.HostIP,
+			Status:   stat.Status,
+			Error:    stat.Error,
+		})
+	}
+
+	resultJSON, _ := json.Marshal(result)
+	if err := r.h.db.Model(&model.AnsibleTask{}).Where("id = ?", task.ID).Updates(map[string]interface{}{
+		"status":          result.Status,
+		"last_run_result": string(resultJSON),
+	}).Error; err != nil {
+		appLogger.Error("保存Ansible任务结果失败", zap.Uint("taskId", task.ID), zap.Error(err))
+	}
+
+	run.publish(TaskEvent{Type: EventDone, TaskID: run.taskID, Status: result.Status, Error: result.Error})
+	run.close()
+	r.remove(run)
+
+	if opts.OnDone != nil {
+		opts.OnDone(result.Status)
+	}
+}
+
+// execute 准备工作目录并运行 ansible-playbook，返回参与执行的主机
+// credential 不为空时以该系统用户运行，工作目录归属该用户
+func (r *ansibleRunner) execute(ctx context.Context, run *taskRun, task *model.AnsibleTask, playbookPath string, credential *syscall.Credential, result *model.AnsibleRunResult, output *outputBuffer) ([]inventoryHost, error) {
+	// 独立的工作目录，执行结束后删除（包含凭证）
+	workDir, err := os.MkdirTemp("", "opshub-ansible-")
+	if err != nil {
+		return nil, fmt.Errorf("创建工作目录失败: %w", err)
+	}
+	defer os.RemoveAll(workDir)
+
+	hosts, skipped, err := r.resolveHosts(ctx, task)
+	if err != nil {
+		return nil, err
+	}
+	result.Hosts = append(result.Hosts, skipped...)
+	if len(hosts) == 0 {
+		return hosts, fmt.Errorf("没有可执行的目标主机")
+	}
+
+	inventoryPath, failed := r.writeInventory(ctx, workDir, hosts)
+	result.Hosts = append(result.Hosts, failed...)
+	if inventoryPath == "" {
+		return hosts, fmt.Errorf("生成 inventory 失败")
+	}
+
+	if strings.TrimSpace(task.PlaybookContent) != "" {
+		playbookPath = filepath.Join(workDir, "playbook.yml")
+		if err := os.WriteFile(playbookPath, []byte(task.PlaybookContent), 0600); err != nil {
+			return hosts, fmt.Errorf("写入Playbook失败: %w", err)
+		}
+	}
+
+	args := []string{"-i", inventoryPath, "-f", strconv.Itoa(max(task.Fork, 1))}
+	if task.Verbose != "" {
+		args = append(args, "-"+task.Verbose)
+	}
+	if strings.TrimSpace(task.Tags) != "" {
+		args = append(args, "--tags", strings.TrimSpace(task.Tags))
+	}
+	if strings.TrimSpace(task.ExtraVars) != "" && strings.TrimSpace(task.ExtraVars) != "null" {
+		extraVarsPath := filepath.Join(workDir, "extra_vars.json")
+		if err := os.WriteFile(extraVarsPath, []byte(task.ExtraVars), 0600); err != nil {
+			return hosts, fmt.Errorf("写入变量文件失败: %w", err)
+		}
+		args = append(args, "-e", "@"+extraVarsPath)
+	}
+	args = append(args, playbookPath)
+
+	if credential != nil {
+		if err := chownTree(workDir, int(credential.Uid), int(credential.Gid)); err != nil {
+			return hosts, fmt.Errorf("设置工作目录权限失败: %w", err)
+		}
+	}
+
+	cmd := exec.CommandContext(ctx, ansiblePlaybookBin, args...)
+	cmd.Dir = workDir
+	cmd.Env = ansibleEnv(workDir)
+	if credential != nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{Credential: credential}
+	}
+	// 取消或超时时先发送中断信号，让 ansible 清理工作进程，超时后强制结束
+	cmd.Cancel = func() error {
+		return cmd.Process.Signal(os.Interrupt)
+	}
+	cmd.WaitDelay = 15 * time.Second
+
+	pipeReader, pipeWriter, err := os.Pipe()
+	if err != nil {
+		return hosts, fmt.Errorf("创建输出管道失败: %w", err)
+	}
+	cmd.Stdout = pipeWriter
+	cmd.Stderr = pipeWriter
+
+	if err := cmd.Start(); err != nil {
+		pipeReader.Close()
+		pipeWriter.Close()
+		return hosts, fmt.Errorf("启动 %s 失败: %w", ansiblePlaybookBin, err)
+	}
+	pipeWriter.Close()
+
+	// 逐行推送输出
+	recapStarted := false
+	scanner := bufio.NewScanner(pipeReader)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		output.Write([]byte(line + "\n"))
+		run.publish(TaskEvent{Type: EventStdout, TaskID: run.taskID, Data: line + "\n"})
+
+		if strings.HasPrefix(line, "PLAY RECAP") {
+			recapStarted = true
+			continue
+		}
+		if recapStarted {
+			if stat, ok := parseRecapLine(line); ok {
+				result.Hosts = append(result.Hosts, stat)
+			}
+		}
+	}
+	pipeReader.Close()
+
+	err = cmd.Wait()
+	result.ExitCode = cmd.ProcessState.ExitCode()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			// 非零退出码由主机统计体现，不作为执行错误
+			return hosts, nil
+		}
+		return hosts, fmt.Errorf("执行 %s 失败: %w", ansiblePlaybookBin, err)
+	}
+	return hosts, nil
+}
+
+// 工作目录中由平台生成的 ssh 文件
+const (
+	ansibleKnownHostsFile = "known_hosts" // 由平台主机密钥信任记录生成
+	ansibleSSHConfigFile  = "ssh_config"  // 跳板机配置，ProxyJump 启动的 ssh 进程同样读取
+)
+
+// ansibleEnv 生成 ansible-playbook 的环境变量，不继承服务进程的环境，避免泄露密钥、数据库等配置。
+// 主机密钥校验通过 ANSIBLE_SSH_ARGS 设置，ansible 将其放在 ssh 命令行最前，优先于 inventory 等设置的参数
+func ansibleEnv(workDir string) []string {
+	env := []string{
+		"PATH=" + os.Getenv("PATH"),
+		"HOME=" + workDir,
+		"LANG=C.UTF-8",
+	}
+	// 保留部署时配置的 ansible 参数，平台必需的参数在后面覆盖
+	for _, item := range os.Environ() {
+		if strings.HasPrefix(item, "ANSIBLE_") {
+			env = append(env, item)
+		}
+	}
+	return append(env,
+		"ANSIBLE_HOST_KEY_CHECKING=True",
+		"ANSIBLE_SSH_ARGS=-F "+filepath.Join(workDir, ansibleSSHConfigFile)+
+			" -C -o ControlMaster=auto -o ControlPersist=60s -o StrictHostKeyChecking=yes"+
+			" -o UserKnownHostsFile="+filepath.Join(workDir, ansibleKnownHostsFile)+" -o GlobalKnownHostsFile=/dev/null",
+		"ANSIBLE_NOCOLOR=1",
+		"ANSIBLE_FORCE_COLOR=0",
+		"ANSIBLE_RETRY_FILES_ENABLED=False",
+		"ANSIBLE_LOCAL_TEMP="+filepath.Join(workDir, ".ansible", "tmp"),
+		"PYTHONUNBUFFERED=1",
+	)
+}
+
+// ansibleRunCredential 返回运行 ansible-playbook 的系统用户。服务以 root 运行时切换到配置的用户；
+// 非 root 运行时无法切换，要求配置的用户即为服务运行用户，返回 nil 表示无需切换
+func ansibleRunCredential() (*syscall.Credential, error) {
+	name := ansibleConfig().RunAsUser
+	runUser, err := user.Lookup(name)
+	if err != nil {
+		return nil, fmt.Errorf("执行 Ansible 的系统用户 %s 不存在", name)
+	}
+	uid, err := strconv.ParseUint(runUser.Uid, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("执行 Ansible 的系统用户 %s 无效", name)
+	}
+	gid, err := strconv.ParseUint(runUser.Gid, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("执行 Ansible 的系统用户 %s 无效", name)
+	}
+	if uid == 0 {
+		return nil, fmt.Errorf("不允许以 root 用户执行 Ansible")
+	}
+
+	switch os.Geteuid() {
+	case int(uid):
+		return nil, nil
+	case 0:
+		return &syscall.Credential{Uid: uint32(uid), Gid: uint32(gid)}, nil
+	}
+	return nil, fmt.Errorf("服务未以 root 运行，无法切换到系统用户 %s 执行 Ansible，请将 ansible.run_as_user 配置为服务运行用户", name)
+}
+
+// chownTree 将目录及其下的文件归属到指定用户
+func chownTree(root string, uid, gid int) error {
+	return filepath.WalkDir(root, func(path string, _ os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		return os.Lchown(path, uid, gid)
+	})
+}
+
+// resolveHosts 解析任务的目标主机，返回可执行的主机与被跳过的主机
+func (r *ansibleRunner) resolveHosts(ctx context.Context, task *model.AnsibleTask) ([]inventoryHost, []model.AnsibleHostStat, error) {
+	var inventory model.AnsibleInventory
+	if strings.TrimSpace(task.Inventory) != "" {
+		if err := json.Unmarshal([]byte(task.Inventory), &inventory); err != nil {
+			return nil, nil, fmt.Errorf("解析目标主机失败: %w", err)
+		}
+	}
+
+	hostIDs := append([]uint{}, inventory.HostIDs...)
+	if len(inventory.GroupIDs) > 0 {
+		groupRepo := assetdata.NewAssetGroupRepo(r.h.db)
+		var groupIDs []uint
+		for _, groupID := range inventory.GroupIDs {
+			groupIDs = append(groupIDs, groupID)
+			descendantIDs, err := groupRepo.GetDescendantIDs(ctx, groupID)
+			if err != nil {
+				return nil, nil, fmt.Errorf("获取分组失败: %w", err)
+			}
+			groupIDs = append(groupIDs, descendantIDs...)
+		}
+
+		var groupHostIDs []uint
+		if err := r.h.db.WithContext(ctx).Model(&assetbiz.Host{}).Where("group_id IN ?", groupIDs).Pluck("id", &groupHostIDs).Error; err != nil {
+			return nil, nil, fmt.Errorf("获取分组主机失败: %w", err)
+		}
+		hostIDs = append(hostIDs, groupHostIDs...)
+	}
+	if len(hostIDs) == 0 {
+		return nil, nil, fmt.Errorf("未选择目标主机")
+	}
+
+	var hosts []assetbiz.Host
+	if err := r.h.db.WithContext(ctx).Where("id IN ?", hostIDs).Order("id").Find(&hosts).Error; err != nil {
+		return nil, nil, fmt.Errorf("获取主机失败: %w", err)
+	}
+
+	var result []inventoryHost
+	var skipped []model.AnsibleHostStat
+	usedNames := make(map[string]bool)
+	for _, host := range hosts {
+		if host.CredentialID == 0 {
+			skipped = append(skipped, model.AnsibleHostStat{HostID: host.ID, HostName: host.Name, HostIP: host.IP, Status: "skipped", Error: "主机未配置凭证"})
+			continue
+		}
+
+		name := host.Name
+		if !inventoryNameRegexp.MatchString(name) || usedNames[name] {
+			name = fmt.Sprintf("host-%d", host.ID)
+		}
+		usedNames[name] = true
+		result = append(result, inventoryHost{name: name, host: host})
+	}
+	return result, skipped, nil
+}
+
+// writeInventory 生成 inventory、私钥、known_hosts 与跳板机 ssh 配置文件，返回 inventory 路径与准备失败的主机
+func (r *ansibleRunner) writeInventory(ctx context.Context, workDir string, hosts []inventoryHost) (string, []model.AnsibleHostStat) {
+	hostRepo := assetdata.NewHostRepo(r.h.db)
+	credentialRepo := assetdata.NewCredentialRepo(r.h.db)
+	knownHostsPath := filepath.Join(workDir, ansibleKnownHostsFile)
+	keyDir := filepath.Join(workDir, "keys")
+	if err := os.MkdirAll(keyDir, 0700); err != nil {
+		return "", nil
+	}
+
+	var failed []model.AnsibleHostStat
+	var knownHosts []string
+	var sshConfig strings.Builder
+	inventoryHosts := make(map[string]map[string]interface{})
+	fail := func(host assetbiz.Host, err error) {
+		failed = append(failed, model.AnsibleHostStat{HostID: host.ID, HostName: host.Name, HostIP: host.IP, Status: "unreachable", Error: err.Error()})
+	}
+
+	for _, item := range hosts {
+		host := item.host
+		credential, err := credentialRepo.GetByIDDecrypted(ctx, host.CredentialID)
+		if err != nil {
+			fail(host, fmt.Errorf("获取凭证失败: %w", err))
+			continue
+		}
+
+		jumpHosts, err := assetbiz.ResolveJumpHosts(ctx, hostRepo, credentialRepo, r.h.hostKeyStore, &host)
+		if err != nil {
+			fail(host, err)
+			continue
+		}
+		jumpConfig, jumpKnownHosts, jumpAlias, err := writeJumpHostConfig(keyDir, host.ID, jumpHosts)
+		if err != nil {
+			fail(host, err)
+			continue
+		}
+
+		// 使用平台的主机密钥信任记录校验并生成 known_hosts
+		key, err := sshclient.FetchHostKey(host.IP, host.Port,
+			sshclient.NewHostKeyCallback(r.h.hostKeyStore, host.ID), sshclient.HostKeyAlgorithms(r.h.hostKeyStore, host.ID), jumpHosts...)
+		if err != nil {
+			fail(host, err)
+			continue
+		}
+		address := knownhosts.Normalize(net.JoinHostPort(host.IP, strconv.Itoa(host.Port)))
+		knownHosts = append(knownHosts, jumpKnownHosts...)
+		knownHosts = append(knownHosts, knownhosts.Line([]string{address}, key))
+		sshConfig.WriteString(jumpConfig)
+
+		user := host.SSHUser
+		if user == "" {
+			user = credential.Username
+		}
+		vars := map[string]interface{}{
+			"ansible_host": host.IP,
+			"ansible_port": host.Port,
+			"ansible_user": user,
+		}
+		if jumpAlias != "" {
+			// Playbook 和额外变量不允许设置该变量，见 ansibleConnectionVars
+			vars["ansible_ssh_common_args"] = "-o ProxyJump=" + jumpAlias
+		}
+
+		if credential.IsCertificate() {
+			// 证书文件与私钥同名加 -cert.pub 后缀，ssh 会自动加载
+			keyPath := filepath.Join(keyDir, fmt.Sprintf("host-%d.pem", host.ID))
+			if err := writeCertificateKey(ctx, &host, user, keyPath); err != nil {
+				fail(host, err)
+				continue
+			}
+			vars["ansible_ssh_private_key_file"] = keyPath
+		} else if credential.Type == "key" {
+			keyPEM, err := unencryptedPrivateKey(credential.PrivateKey, credential.Passphrase)
+			if err != nil {
+				fail(host, err)
+				continue
+			}
+			keyPath := filepath.Join(keyDir, fmt.Sprintf("host-%d.pem", host.ID))
+			if err := os.WriteFile(keyPath, keyPEM, 0600); err != nil {
+				fail(host, fmt.Errorf("写入私钥失败: %w", err))
+				continue
+			}
+			vars["ansible_ssh_private_key_file"] = keyPath
+		} else {
+			vars["ansible_password"] = credential.Password
+		}
+
+		inventoryHosts[item.name] = vars
+	}
+
+	if len(inventoryHosts) == 0 {
+		return "", failed
+	}
+
+	if err := os.WriteFile(knownHostsPath, []byte(strings.Join(knownHosts, "\n")+"\n"), 0600); err != nil {
+		return "", failed
+	}
+	// 跳板机的 ssh 进程不继承命令行参数，主机密钥校验需要写在配置文件中
+	fmt.Fprintf(&sshConfig, "Host *\n  StrictHostKeyChecking yes\n  UserKnownHostsFile %s\n  GlobalKnownHostsFile /dev/null\n", knownHostsPath)
+	if err := os.WriteFile(filepath.Join(workDir, ansibleSSHConfigFile), []byte(sshConfig.String()), 0600); err != nil {
+		return "", failed
+	}
+
+	// JSON 是合法的 YAML，ansible 可直接作为 YAML inventory 读取
+	inventory := map[string]interface{}{
+		"all": map[string]interface{}{"hosts": inventoryHosts},
+	}
+	data, _ := json.MarshalIndent(inventory, "", "  ")
+	inventoryPath := filepath.Join(workDir, "inventory.yml")
+	if err := os.WriteFile(inventoryPath, data, 0600); err != nil {
+		return "", failed
+	}
+	return inventoryPath, failed
+}
+
+// writeJumpHostConfig 为经跳板机连接的主机写入跳板机私钥，返回 ssh 配置、跳板机的 known_hosts 记录以及最内层跳板机的别名。
+// ssh 的 ProxyJump 无法输入密码，跳板机需要使用密钥或证书凭证
+func writeJumpHostConfig(keyDir string, hostID uint, jumpHosts []*sshclient.JumpHost) (string, []string, string, error) {
+	var config strings.Builder
+	var knownHosts []string
+	alias := ""
+	for i, jump := range jumpHosts {
+		if len(jump.PrivateKey) == 0 {
+			return "", nil, "", fmt.Errorf("跳板机 %s 使用密码凭证，Ansible 经跳板机连接时跳板机需使用密钥或证书凭证", jump.Name)
+		}
+		for _, value := range []string{jump.Host, jump.Username} {
+			if !sshConfigValueRegexp.MatchString(value) {
+				return "", nil, "", fmt.Errorf("跳板机 %s 的地址或用户名无效: %s", jump.Name, value)
+			}
+		}
+
+		key, err := sshclient.FetchHostKey(jump.Host, jump.Port, jump.HostKeyCallback, jump.HostKeyAlgorithms, jumpHosts[:i]...)
+		if err != nil {
+			return "", nil, "", fmt.Errorf("跳板机 %s: %w", jump.Name, err)
+		}
+		knownHosts = append(knownHosts, knownhosts.Line([]string{knownhosts.Normalize(jump.Address())}, key))
+
+		keyPEM, err := unencryptedPrivateKey(string(jump.PrivateKey), jump.Passphrase)
+		if err != nil {
+			return "", nil, "", fmt.Errorf("跳板机 %s: %w", jump.Name, err)
+		}
+		keyPath := filepath.Join(keyDir, fmt.Sprintf("host-%d-jump-%d.pem", hostID, i))
+		if err := os.WriteFile(keyPath, keyPEM, 0600); err != nil {
+			return "", nil, "", fmt.Errorf("写入跳板机私钥失败: %w", err)
+		}
+		// 证书文件与私钥同名加 -cert.pub 后缀，ssh 会自动加载
+		if jump.Signer != nil {
+			if cert, ok := jump.Signer.PublicKey().(*ssh.Certificate); ok {
+				if err := os.WriteFile(keyPath+"-cert.pub", ssh.MarshalAuthorizedKey(cert), 0600); err != nil {
+					return "", nil, "", fmt.Errorf("写入跳板机证书失败: %w", err)
+				}
+			}
+		}
+
+		outer := alias
+		alias = fmt.Sprintf("opshub-jump-%d-%d", hostID, i)
+		fmt.Fprintf(&config, "Host %s\n  HostName %s\n  Port %d\n  User %s\n  IdentityFile %s\n  IdentitiesOnly yes\n",
+			alias, jump.Host, jump.Port, jump.Username, keyPath)
+		if outer != "" {
+			fmt.Fprintf(&config, "  ProxyJump %s\n", outer)
+		}
+	}
+	return config.String(), knownHosts, alias, nil
+}
+
+// unencryptedPrivateKey 解密带密码的私钥，ansible 无法交互输入私钥密码
+func unencryptedPrivateKey(privateKey, passphrase string) ([]byte, error) {
+	if passphrase == "" {
+		if _, err := ssh.ParseRawPrivateKey([]byte(privateKey)); err != nil {
+			return nil, fmt.Errorf("解析私钥失败: %w", err)
+		}
+		return []byte(privateKey), nil
+	}
+
+	rawKey, err := ssh.ParseRawPrivateKeyWithPassphrase([]byte(privateKey), []byte(passphrase))
+	if err != nil {
+		return nil, fmt.Errorf("解析私钥失败: %w", err)
+	}
+	block, err := ssh.MarshalPrivateKey(rawKey, "")
+	if err != nil {
+		if pkcs8, err2 := x509.MarshalPKCS8PrivateKey(rawKey); err2 == nil {
+			return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8}), nil
+		}
+		return nil, fmt.Errorf("转换私钥失败: %w", err)
+	}
+	return pem.EncodeToMemory(block), nil
+}
+
+// parseRecapLine 解析 PLAY RECAP 中的主机统计行
+func parseRecapLine(line string) (model.AnsibleHostStat, bool) {
+	matches := recapLineRegexp.FindStringSubmatch(strings.TrimSpace(line))
+	if matches == nil {
+		return model.AnsibleHostStat{}, false
+	}
+
+	stat := model.AnsibleHostStat{HostName: matches[1]}
+	for _, field := range strings.Fields(matches[2]) {
+		key, value, found := strings.Cut(field, "=")
+		if !found {
+			continue
+		}
+		n, _ := strconv.Atoi(value)
+		switch key {
+		case "ok":
+			stat.Ok = n
+		case "changed":
+			stat.Changed = n
+		case "unreachable":
+			stat.Unreachable = n
+		case "failed":
+			stat.Failed = n
+		case "skipped":
+			stat.Skipped = n
+		case "rescued":
+			stat.Rescued = n
+		case "ignored":
+			stat.Ignored = n
+		}
+	}
+
+	switch {
+	case stat.Unreachable > 0:
+		stat.Status = "unreachable"
+	case stat.Failed > 0:
+		stat.Status = "failed"
+	case stat.Changed > 0:
+		stat.Status = "changed"
+	default:
+		stat.Status = "ok"
+	}
+	return stat, true
+}
+
+// mergeRecap 将 PLAY RECAP 中的 inventory 主机名映射回平台主机；未出现在 RECAP 中的主机标记为失败
+func mergeRecap(hosts []inventoryHost, stats []model.AnsibleHostStat, output string) []model.AnsibleHostStat {
+	byName := make(map[string]inventoryHost, len(hosts))
+	for _, item := range hosts {
+		byName[item.name] = item
+	}
+
+	seen := make(map[uint]bool)
+	merged := make([]model.AnsibleHostStat, 0, len(stats))
+	for _, stat := range stats {
+		if stat.HostID == 0 {
+			item, ok := byName[stat.HostName]
+			if !ok {
+				continue
+			}
+			stat.HostID = item.host.ID
+			stat.HostName = item.host.Name
+			stat.HostIP = item.host.IP
+		}
+		if seen[stat.HostID] {
+			continue
+		}
+		seen[stat.HostID] = true
+		merged = append(merged, stat)
+	}
+
+	for _, item := range hosts {
+		if !seen[item.host.ID] {
+			merged = append(merged, model.AnsibleHostStat{
+				HostID:   item.host.ID,
+				HostName: item.host.Name,
+				HostIP:   item.host.IP,
+				Status:   "failed",
+				Error:    "未获取到执行统计",
+			})
+		}
+	}
+	return merged
+}
+
+// writeCertificateKey 签发证书，写入临时私钥与证书文件
+func writeCertificateKey(ctx context.Context, host *assetbiz.Host, user, keyPath string) error {
+	issued, err := assetbiz.IssueSSHCertificate(ctx, host, user)
+	if err != nil {
+		return fmt.Errorf("签发SSH证书失败: %w", err)
+	}
+	block, err := ssh.MarshalPrivateKey(issued.PrivateKey, "")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(keyPath, pem.EncodeToMemory(block), 0600); err != nil {
+		return fmt.Errorf("写入私钥失败: %w", err)
+	}
+	if err := os.WriteFile(keyPath+"-cert.pub", ssh.MarshalAuthorizedKey(issued.Certificate), 0600); err != nil {
+		return fmt.Errorf("写入证书失败: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,376 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	appLogger "github.com/ydcloud-dy/opshub/pkg/logger"
+	"github.com/ydcloud-dy/opshub/pkg/response"
+	monitorservice "github.com/ydcloud-dy/opshub/plugins/monitor/service"
+	"github.com/ydcloud-dy/opshub/plugins/task/model"
+	"go.uber.org/zap"
+)
+
+// executionSpec 任务执行参数。待审批的任务保存该参数，审批通过后按此重新渲染、检查并执行
+// 模板中的敏感变量只保存凭证ID，不保存敏感值
+type executionSpec struct {
+	ScriptType  string                 `json:"scriptType"`
+	Content     string                 `json:"content,omitempty"` // 未引用模板时直接执行的脚本
+	TemplateID  uint                   `json:"templateId,omitempty"`
+	Variables   map[string]interface{} `json:"variables,omitempty"`
+	ContentHash string                 `json:"contentHash,omitempty"` // 模板渲染结果（敏感值脱敏后）的摘要，审批后校验内容未变更
+	HostIDs     []uint                 `json:"hostIds"`
+	Concurrency int                    `json:"concurrency,omitempty"`
+	Timeout     int                    `json:"timeout,omitempty"` // 单主机超时（秒），引用模板时默认使用模板超时
+	// AnsibleTaskID 不为0时为Ansible任务的执行，审批通过后校验 ContentHash 并执行该任务
+	AnsibleTaskID uint `json:"ansibleTaskId,omitempty"`
+}
+
+// preparedRun 已渲染并通过命令策略检查的一次执行
+type preparedRun struct {
+	options    executeOptions
+	parameters string
+	template   *model.JobTemplate
+	approval   *approvalRequirement // 不为空时需要审批后执行
+}
+
+// approvalRequirement 命中的审批原因及可审批的用户
+type approvalRequirement struct {
+	reasons   []string
+	approvers []uint // 为空时由管理员审批
+}
+
+// prepareRun 渲染模板、检查命令策略并匹配审批策略，返回错误时附带HTTP状态码
+func (h *Handler) prepareRun(ctx context.Context, spec *executionSpec, userID uint) (*preparedRun, int, error) {
+	if spec.ScriptType == "" {
+		spec.ScriptType = "Shell"
+	}
+	if len(spec.HostIDs) == 0 {
+		return nil, http.StatusBadRequest, fmt.Errorf("请选择目标主机")
+	}
+
+	run := &preparedRun{
+		options: executeOptions{
+			ScriptType:  spec.ScriptType,
+			Content:     spec.Content,
+			Concurrency: spec.Concurrency,
+			HostTimeout: time.Duration(spec.Timeout) * time.Second,
+		},
+	}
+	policy := commandPolicyRequest{
+		ScriptType: spec.ScriptType,
+		Content:    spec.Content,
+		UserID:     userID,
+		HostIDs:    spec.HostIDs,
+	}
+
+	if spec.TemplateID != 0 {
+		var template model.JobTemplate
+		if err := h.db.WithContext(ctx).Where("id = ? AND deleted_at IS NULL", spec.TemplateID).First(&template).Error; err != nil {
+			return nil, http.StatusNotFound, fmt.Errorf("任务模板不存在")
+		}
+		if template.Status != 1 {
+			return nil, http.StatusBadRequest, fmt.Errorf("任务模板已禁用")
+		}
+		rendered, err := h.renderTemplate(ctx, &template, spec.Variables)
+		if err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+
+		sum := sha256.Sum256([]byte(newSecretRedactor(rendered.Secrets).Replace(rendered.Content)))
+		hash := hex.EncodeToString(sum[:])
+		if spec.ContentHash != "" && spec.ContentHash != hash {
+			return nil, http.StatusConflict, fmt.Errorf("任务模板或变量内容已变更，请重新发起执行")
+		}
+		spec.ContentHash = hash
+
+		run.template = &template
+		run.options.Content = rendered.Content
+		run.options.Secrets = rendered.Secrets
+		if spec.Timeout <= 0 {
+			run.options.HostTimeout = time.Duration(template.Timeout) * time.Second
+		}
+		if len(rendered.Parameters) > 0 {
+			data, _ := json.Marshal(rendered.Parameters)
+			run.parameters = string(data)
+		}
+		policy.Content = rendered.Content
+		policy.TemplateID = template.ID
+	}
+	if strings.TrimSpace(run.options.Content) == "" {
+		return nil, http.StatusBadRequest, fmt.Errorf("脚本内容为空")
+	}
+
+	// 命令策略检查：按执行用户的角色、目标主机分组和模板匹配规则
+	commandReason, err := h.checkCommandPolicy(ctx, policy)
+	if err != nil {
+		return nil, http.StatusForbidden, err
+	}
+	run.approval, err = h.approvalRequirement(ctx, spec.HostIDs, run.template, commandReason)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	return run, 0, nil
+}
+
+// approvalRequirement 匹配启用的审批策略，不需要审批时返回 nil
+// 命令策略结果为需审批时总是需要审批，开启了 OnCommandApproval 的策略提供审批人
+func (h *Handler) approvalRequirement(ctx context.Context, hostIDs []uint, template *model.JobTemplate, commandReason string) (*approvalRequirement, error) {
+	var policies []model.JobApprovalPolicy
+	if err := h.db.WithContext(ctx).Where("status = 1 AND deleted_at IS NULL").Order("id").Find(&policies).Error; err != nil {
+		return nil, fmt.Errorf("查询审批策略失败: %w", err)
+	}
+
+	var groupIDs []uint
+	for _, policy := range policies {
+		if policy.AssetGroupIDs != "" && policy.AssetGroupIDs != "[]" {
+			ids, err := h.commandRules.HostGroupIDs(ctx, hostIDs)
+			if err != nil {
+				return nil, err
+			}
+			groupIDs = ids
+			break
+		}
+	}
+
+	var requirement approvalRequirement
+	matched := false
+	for _, policy := range policies {
+		var policyGroups, approvers []uint
+		var categories []string
+		parseJSONList(policy.AssetGroupIDs, &policyGroups)
+		parseJSONList(policy.TemplateCategories, &categories)
+		parseJSONList(policy.Approvers, &approvers)
+
+		hit := false
+		if slices.ContainsFunc(policyGroups, func(id uint) bool { return slices.Contains(groupIDs, id) }) {
+			hit = true
+			requirement.reasons = append(requirement.reasons, fmt.Sprintf("目标主机属于审批策略「%s」指定的资产分组", policy.Name))
+		}
+		if template != nil && slices.Contains(categories, template.Category) {
+			hit = true
+			requirement.reasons = append(requirement.reasons, fmt.Sprintf("模板分类 %s 命中审批策略「%s」", template.Category, policy.Name))
+		}
+		if policy.OnCommandApproval && commandReason != "" {
+			hit = true
+		}
+		if !hit {
+			continue
+		}
+
+		matched = true
+		for _, approver := range approvers {
+			if !slices.Contains(requirement.approvers, approver) {
+				requirement.approvers = append(requirement.approvers, approver)
+			}
+		}
+	}
+
+	if commandReason != "" {
+		matched = true
+		requirement.reasons = append(requirement.reasons, commandReason)
+	}
+	if !matched {
+		return nil, nil
+	}
+	return &requirement, nil
+}
+
+// submitRun 创建任务记录并开始执行；需要审批时保存执行参数并通知审批人，返回的主机结果为空
+func (h *Handler) submitRun(jobTask *model.JobTask, spec *executionSpec, run *preparedRun) ([]HostExecutionResult, error) {
+	jobTask.Parameters = run.parameters
+	if run.approval == nil {
+		jobTask.Status = "running"
+		jobTask.ExecuteTime = ptrTime(time.Now())
+		if err := h.db.Create(jobTask).Error; err != nil {
+			return nil, fmt.Errorf("创建任务记录失败: %w", err)
+		}
+		return h.executor.Start(jobTask, spec.HostIDs, run.options), nil
+	}
+
+	specJSON, err := json.Marshal(spec)
+	if err != nil {
+		return nil, fmt.Errorf("保存执行参数失败: %w", err)
+	}
+	jobTask.Status = model.JobStatusPendingApproval
+	jobTask.ApprovalReason = strings.Join(run.approval.reasons, "；")
+	jobTask.ExecutionSpec = string(specJSON)
+	jobTask.ExecuteTime = nil
+	if len(run.approval.approvers) > 0 {
+		data, _ := json.Marshal(run.approval.approvers)
+		jobTask.Approvers = string(data)
+	}
+	if err := h.db.Create(jobTask).Error; err != nil {
+		return nil, fmt.Errorf("创建任务记录失败: %w", err)
+	}
+
+	go h.notifyApprovers(*jobTask, len(spec.HostIDs))
+	return nil, nil
+}
+
+// submitAnsibleApproval 为需要审批的Ansible任务执行创建待审批记录并通知审批人
+func (h *Handler) submitAnsibleApproval(task *model.AnsibleTask, userID uint, run *preparedAnsibleRun) (*model.JobTask, error) {
+	specJSON, err := json.Marshal(executionSpec{
+		ScriptType:    "Ansible",
+		ContentHash:   run.contentHash,
+		HostIDs:       run.hostIDs,
+		AnsibleTaskID: task.ID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("保存执行参数失败: %w", err)
+	}
+	targetHosts, _ := json.Marshal(run.hostIDs)
+
+	jobTask := &model.JobTask{
+		Name:           "Ansible: " + task.Name,
+		TaskType:       "ansible",
+		Status:         model.JobStatusPendingApproval,
+		TargetHosts:    string(targetHosts),
+		CreatedBy:      userID,
+		ApprovalReason: strings.Join(run.approval.reasons, "；"),
+		ExecutionSpec:  string(specJSON),
+	}
+	if len(run.approval.approvers) > 0 {
+		data, _ := json.Marshal(run.approval.approvers)
+		jobTask.Approvers = string(data)
+	}
+	if err := h.db.Create(jobTask).Error; err != nil {
+		return nil, fmt.Errorf("创建任务记录失败: %w", err)
+	}
+
+	go h.notifyApprovers(*jobTask, len(run.hostIDs))
+	return jobTask, nil
+}
+
+// respondRun 返回执行结果，待审批的任务只返回任务ID和状态
+func respondRun(c *gin.Context, jobTask *model.JobTask, results []HostExecutionResult) {
+	resp := ExecuteTaskResponse{
+		TaskID:  jobTask.ID,
+		Status:  jobTask.Status,
+		Results: results,
+	}
+	if jobTask.Status == model.JobStatusPendingApproval {
+		response.SuccessWithMessage(c, "任务命中审批策略，已提交审批: "+jobTask.ApprovalReason, resp)
+		return
+	}
+	response.Success(c, resp)
+}
+
+// canApprove 判断用户能否审批任务：不能审批自己发起的任务，审批人列表为空时由管理员审批，管理员始终可以审批
+func (h *Handler) canApprove(ctx context.Context, jobTask *model.JobTask, userID uint) (bool, error) {
+	if userID == jobTask.CreatedBy {
+		return false, nil
+	}
+	var approvers []uint
+	parseJSONList(jobTask.Approvers, &approvers)
+	if slices.Contains(approvers, userID) {
+		return true, nil
+	}
+	return h.isAdmin(ctx, userID)
+}
+
+// isAdmin 判断用户是否拥有管理员角色
+func (h *Handler) isAdmin(ctx context.Context, userID uint) (bool, error) {
+	var count int64
+	err := h.db.WithContext(ctx).
+		Table("sys_user_role AS ur").
+		Joins("JOIN sys_role AS r ON ur.role_id = r.id").
+		Where("ur.user_id = ? AND r.code = ?", userID, "admin").
+		Count(&count).Error
+	return count > 0, err
+}
+
+// notifyApprovers 通过监控插件的告警通道通知审批人，接收人未关联系统用户时发送到通道配置的群机器人
+func (h *Handler) notifyApprovers(jobTask model.JobTask, hostCount int) {
+	var approvers []uint
+	parseJSONList(jobTask.Approvers, &approvers)
+	if len(approvers) == 0 {
+		h.db.Table("sys_user_role AS ur").
+			Joins("JOIN sys_role AS r ON ur.role_id = r.id").
+			Where("r.code = ?", "admin").
+			Distinct().Pluck("ur.user_id", &approvers)
+	}
+
+	targets, err := monitorservice.LoadAlertTargets(h.db, approvers)
+	if err != nil {
+		appLogger.Warn("发送任务审批通知失败", zap.Uint("taskId", jobTask.ID), zap.Error(err))
+		return
+	}
+	if len(targets.Channels) == 0 {
+		appLogger.Warn("未配置启用的告警通道，无法发送任务审批通知", zap.Uint("taskId", jobTask.ID))
+		return
+	}
+
+	notification := monitorservice.Notification{
+		Type:  "task_approval",
+		Title: "任务执行待审批",
+		Lines: []string{
+			"任务名称: " + jobTask.Name,
+			"申请人: " + h.userDisplayName(jobTask.CreatedBy),
+			fmt.Sprintf("目标主机: %d 台", hostCount),
+			"审批原因: " + jobTask.ApprovalReason,
+			fmt.Sprintf("任务ID: %d", jobTask.ID),
+			"请登录平台在任务审批中处理",
+		},
+	}
+	if err := monitorservice.NewAlertService().SendNotification(notification, targets); err != nil {
+		appLogger.Warn("发送任务审批通知失败", zap.Uint("taskId", jobTask.ID), zap.Error(err))
+	}
+}
+
+// userDisplayName 返回用户的真实姓名，没有时返回用户名
+func (h *Handler) userDisplayName(userID uint) string {
+	var user struct {
+		Username string
+		RealName string
+	}
+	if err := h.db.Table("sys_user").Select("username, real_name").Where("id = ?", userID).Take(&user).Error; err != nil {
+		return fmt.Sprintf("用户#%d", userID)
+	}
+	if user.RealName != "" {
+		return user.RealName
+	}
+	return user.Username
+}
+
+// updateScheduleRunStatus 更新定时任务最近一次执行的状态
+func (h *Handler) updateScheduleRunStatus(scheduleID, taskID uint, status string) {
+	h.db.Model(&model.JobSchedule{}).
+		Where("id = ? AND last_job_task_id = ?", scheduleID, taskID).
+		Update("last_run_status", status)
+}
+
+// parseJSONList 解析 JSON 数组字段，格式错误或为空时保持为空
+func parseJSONList[T any](raw string, out *[]T) {
+	if raw == "" {
+		return
+	}
+	_ = json.Unmarshal([]byte(raw), out)
+}
@@ -0,0 +1,581 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ydcloud-dy/opshub/pkg/response"
+	"github.com/ydcloud-dy/opshub/plugins/task/model"
+)
+
+// ==================== 任务审批 ====================
+
+// ApprovalRequest 审批请求
+type ApprovalRequest struct {
+	Comment string `json:"comment"` // 审批意见
+}
+
+// ApprovalDetail 审批详情，脚本内容中的敏感值已脱敏
+type ApprovalDetail struct {
+	model.JobTask
+	ScriptType string                 `json:"scriptType"`
+	Content    string                 `json:"content"`
+	Variables  map[string]interface{} `json:"variables,omitempty"`
+	HostIDs    []uint                 `json:"hostIds"`
+	CanApprove bool                   `json:"canApprove"`
+}
+
+// ListApprovals 获取任务审批列表
+// @Summary 获取任务审批列表
+// @Description 分页获取命中审批策略的任务，默认返回等待审批的任务
+// @Tags 任务管理-任务审批
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param page query int false "页码" default(1)
+// @Param pageSize query int false "每页数量" default(10)
+// @Param status query string false "任务状态，默认 pending_approval，传 all 返回全部"
+// @Success 200 {object} response.Response "获取成功"
+// @Router /task/approvals [get]
+func (h *Handler) ListApprovals(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "10"))
+	status := c.DefaultQuery("status", model.JobStatusPendingApproval)
+
+	var jobTasks []*model.JobTask
+	var total int64
+
+	query := h.db.Model(&model.JobTask{}).Where("deleted_at IS NULL AND approval_reason <> ''")
+	if status != "all" {
+		query = query.Where("status = ?", status)
+	}
+
+	query.Count(&total)
+	offset := (page - 1) * pageSize
+	query.Order("created_at DESC").Limit(pageSize).Offset(offset).Find(&jobTasks)
+
+	response.Success(c, gin.H{
+		"list":     jobTasks,
+		"total":    total,
+		"page":     page,
+		"pageSize": pageSize,
+	})
+}
+
+// GetApproval 获取任务审批详情
+// @Summary 获取任务审批详情
+// @Description 获取待审批任务将要执行的脚本、目标主机和审批原因，敏感变量已脱敏
+// @Tags 任务管理-任务审批
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "任务ID"
+// @Success 200 {object} response.Response "获取成功"
+// @Failure 404 {object} response.Response "审批不存在"
+// @Router /task/approvals/{id} [get]
+func (h *Handler) GetApproval(c *gin.Context) {
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	var jobTask model.JobTask
+	if err := h.db.Where("id = ? AND deleted_at IS NULL AND approval_reason <> ''", id).First(&jobTask).Error; err != nil {
+		response.ErrorCode(c, http.StatusNotFound, "审批不存在")
+		return
+	}
+
+	var spec executionSpec
+	_ = json.Unmarshal([]byte(jobTask.ExecutionSpec), &spec)
+	detail := ApprovalDetail{
+		JobTask:    jobTask,
+		ScriptType: spec.ScriptType,
+		Content:    spec.Content,
+		Variables:  spec.Variables,
+		HostIDs:    spec.HostIDs,
+	}
+	if spec.AnsibleTaskID != 0 {
+		var task model.AnsibleTask
+		if err := h.db.Where("id = ?", spec.AnsibleTaskID).First(&task).Error; err == nil {
+			detail.Content = task.PlaybookContent
+			if strings.TrimSpace(task.PlaybookContent) == "" {
+				if path, err := resolvePlaybookPath(task.PlaybookPath); err == nil {
+					data, _ := os.ReadFile(path)
+					detail.Content = string(data)
+				}
+			}
+		}
+	}
+	if spec.TemplateID != 0 {
+		var template model.JobTemplate
+		if err := h.db.Where("id = ?", spec.TemplateID).First(&template).Error; err == nil {
+			if rendered, err := h.renderTemplate(c.Request.Context(), &template, spec.Variables); err == nil {
+				detail.Content = newSecretRedactor(rendered.Secrets).Replace(rendered.Content)
+			} else {
+				detail.Content = template.Content
+			}
+		}
+	}
+	if jobTask.Status == model.JobStatusPendingApproval {
+		detail.CanApprove, _ = h.canApprove(c.Request.Context(), &jobTask, currentUserID(c))
+	}
+
+	response.Success(c, detail)
+}
+
+// ApproveJobTask 审批通过任务
+// @Summary 审批通过任务
+// @Description 审批通过后重新渲染模板并检查命令策略，内容未变更时立即开始执行；不能审批自己发起的任务
+// @Tags 任务管理-任务审批
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "任务ID"
+// @Param body body ApprovalRequest false "审批意见"
+// @Success 200 {object} response.Response "审批成功"
+// @Failure 403 {object} response.Response "无审批权限"
+// @Failure 404 {object} response.Response "审批不存在"
+// @Failure 409 {object} response.Response "任务已被处理或内容已变更"
+// @Router /task/approvals/{id}/approve [post]
+func (h *Handler) ApproveJobTask(c *gin.Context) {
+	jobTask, req, ok := h.loadPendingApproval(c)
+	if !ok {
+		return
+	}
+
+	var spec executionSpec
+	if err := json.Unmarshal([]byte(jobTask.ExecutionSpec), &spec); err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "执行参数格式错误: "+err.Error())
+		return
+	}
+
+	now := time.Now()
+	approval := map[string]interface{}{
+		"approver_id":      currentUserID(c),
+		"approver_name":    c.GetString("username"),
+		"approval_time":    &now,
+		"approval_comment": req.Comment,
+	}
+
+	if spec.AnsibleTaskID != 0 {
+		h.approveAnsibleRun(c, jobTask, &spec, approval)
+		return
+	}
+
+	// 以发起人身份重新检查，审批期间模板或命令规则可能已被修改
+	run, code, err := h.prepareRun(c.Request.Context(), &spec, jobTask.CreatedBy)
+	if err != nil {
+		h.failApproval(c, jobTask, approval, code, err)
+		return
+	}
+
+	approval["status"] = "running"
+	approval["execute_time"] = &now
+	if !h.claimApproval(jobTask, approval) {
+		response.ErrorCode(c, http.StatusConflict, "任务已被处理")
+		return
+	}
+	jobTask.Status = "running"
+	jobTask.ExecuteTime = &now
+
+	if jobTask.ScheduleID != nil {
+		scheduleID, taskID := *jobTask.ScheduleID, jobTask.ID
+		run.options.OnDone = func(status string) {
+			h.updateScheduleRunStatus(scheduleID, taskID, status)
+		}
+	}
+	results := h.executor.Start(jobTask, spec.HostIDs, run.options)
+
+	response.SuccessWithMessage(c, "审批通过，任务开始执行", ExecuteTaskResponse{
+		TaskID:  jobTask.ID,
+		Status:  jobTask.Status,
+		Results: results,
+	})
+}
+
+// approveAnsibleRun 审批通过Ansible任务执行，以发起人身份重新检查且内容未变更时开始执行
+func (h *Handler) approveAnsibleRun(c *gin.Context, jobTask *model.JobTask, spec *executionSpec, approval map[string]interface{}) {
+	var task model.AnsibleTask
+	if err := h.db.Where("id = ? AND deleted_at IS NULL", spec.AnsibleTaskID).First(&task).Error; err != nil {
+		h.failApproval(c, jobTask, approval, http.StatusNotFound, fmt.Errorf("Ansible任务不存在"))
+		return
+	}
+	run, code, err := h.prepareAnsibleRun(c.Request.Context(), &task, jobTask.CreatedBy)
+	if err == nil && run.contentHash != spec.ContentHash {
+		code, err = http.StatusConflict, fmt.Errorf("Ansible任务的Playbook、变量或目标主机已变更，请重新发起执行")
+	}
+	if err != nil {
+		h.failApproval(c, jobTask, approval, code, err)
+		return
+	}
+
+	now := time.Now()
+	approval["status"] = "running"
+	approval["execute_time"] = &now
+	if !h.claimApproval(jobTask, approval) {
+		response.ErrorCode(c, http.StatusConflict, "任务已被处理")
+		return
+	}
+
+	taskID := jobTask.ID
+	err = h.ansible.Start(&task, ansibleRunOptions{
+		UserID:       jobTask.CreatedBy,
+		PlaybookPath: run.playbookPath,
+		JobTaskID:    taskID,
+		OnDone: func(status string) {
+			h.db.Model(&model.JobTask{}).Where("id = ?", taskID).Update("status", status)
+		},
+	})
+	if err != nil {
+		h.db.Model(&model.JobTask{}).Where("id = ?", taskID).Updates(map[string]interface{}{
+			"status":        "failed",
+			"error_message": "审批通过但无法执行: " + err.Error(),
+		})
+		response.ErrorCode(c, http.StatusBadRequest, "任务无法执行: "+err.Error())
+		return
+	}
+
+	response.SuccessWithMessage(c, "审批通过，任务开始执行", ExecuteTaskResponse{
+		TaskID: jobTask.ID,
+		Status: "running",
+	})
+}
+
+// failApproval 审批通过但任务无法执行时将任务标记为失败，失败时已写入响应
+func (h *Handler) failApproval(c *gin.Context, jobTask *model.JobTask, approval map[string]interface{}, code int, err error) {
+	approval["status"] = "failed"
+	approval["error_message"] = "审批通过但无法执行: " + err.Error()
+	if h.claimApproval(jobTask, approval) {
+		response.ErrorCode(c, code, "任务无法执行: "+err.Error())
+	} else {
+		response.ErrorCode(c, http.StatusConflict, "任务已被处理")
+	}
+}
+
+// RejectJobTask 驳回任务
+// @Summary 驳回任务
+// @Description 驳回等待审批的任务，任务不会执行
+// @Tags 任务管理-任务审批
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "任务ID"
+// @Param body body ApprovalRequest false "驳回原因"
+// @Success 200 {object} response.Response "驳回成功"
+// @Failure 403 {object} response.Response "无审批权限"
+// @Failure 404 {object} response.Response "审批不存在"
+// @Failure 409 {object} response.Response "任务已被处理"
+// @Router /task/approvals/{id}/reject [post]
+func (h *Handler) RejectJobTask(c *gin.Context) {
+	jobTask, req, ok := h.loadPendingApproval(c)
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	if !h.claimApproval(jobTask, map[string]interface{}{
+		"status":           model.JobStatusRejected,
+		"approver_id":      currentUserID(c),
+		"approver_name":    c.GetString("username"),
+		"approval_time":    &now,
+		"approval_comment": req.Comment,
+	}) {
+		response.ErrorCode(c, http.StatusConflict, "任务已被处理")
+		return
+	}
+
+	response.SuccessWithMessage(c, "已驳回", nil)
+}
+
+// loadPendingApproval 读取审批请求和等待审批的任务并校验审批权限，失败时已写入响应
+func (h *Handler) loadPendingApproval(c *gin.Context) (*model.JobTask, ApprovalRequest, bool) {
+	var req ApprovalRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		response.ErrorCode(c, http.StatusBadRequest, "参数错误: "+err.Error())
+		return nil, req, false
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "无效的任务ID")
+		return nil, req, false
+	}
+	var jobTask model.JobTask
+	if err := h.db.Where("id = ? AND deleted_at IS NULL", id).First(&jobTask).Error; err != nil {
+		response.ErrorCode(c, http.StatusNotFound, "审批不存在")
+		return nil, req, false
+	}
+	if jobTask.Status != model.JobStatusPendingApproval {
+		response.ErrorCode(c, http.StatusConflict, "任务不在待审批状态")
+		return nil, req, false
+	}
+
+	allowed, err := h.canApprove(c.Request.Context(), &jobTask, currentUserID(c))
+	if err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "校验审批权限失败: "+err.Error())
+		return nil, req, false
+	}
+	if !allowed {
+		response.ErrorCode(c, http.StatusForbidden, "无权审批该任务，审批人不能是任务发起人")
+		return nil, req, false
+	}
+	return &jobTask, req, true
+}
+
+// claimApproval 以待审批状态为条件更新任务，避免同一任务被重复审批，返回是否更新成功
+func (h *Handler) claimApproval(jobTask *model.JobTask, updates map[string]interface{}) bool {
+	result := h.db.Model(&model.JobTask{}).
+		Where("id = ? AND status = ?", jobTask.ID, model.JobStatusPendingApproval).
+		Updates(updates)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return false
+	}
+	if jobTask.ScheduleID != nil {
+		h.updateScheduleRunStatus(*jobTask.ScheduleID, jobTask.ID, updates["status"].(string))
+	}
+	return true
+}
+
+// currentUserID 获取当前登录用户ID
+func currentUserID(c *gin.Context) uint {
+	if userID, exists := c.Get("user_id"); exists {
+		if uid, ok := userID.(uint); ok {
+			return uid
+		}
+	}
+	return 0
+}
+
+// requireAdmin 限制仅管理员可访问
+func (h *Handler) requireAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := currentUserID(c)
+		if userID == 0 {
+			response.ErrorCode(c, http.StatusUnauthorized, "未登录")
+			c.Abort()
+			return
+		}
+		admin, err := h.isAdmin(c.Request.Context(), userID)
+		if err != nil {
+			response.ErrorCode(c, http.StatusInternalServerError, "获取用户角色失败")
+			c.Abort()
+			return
+		}
+		if !admin {
+			response.ErrorCode(c, http.StatusForbidden, "权限不足：此操作仅限管理员执行")
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// ==================== 审批策略 ====================
+
+// ListApprovalPolicies 获取审批策略列表
+// @Summary 获取审批策略列表
+// @Description 分页获取任务审批策略
+// @Tags 任务管理-任务审批
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param page query int false "页码" default(1)
+// @Param pageSize query int false "每页数量" default(10)
+// @Param keyword query string false "搜索关键词"
+// @Success 200 {object} response.Response "获取成功"
+// @Router /task/approval-policies [get]
+func (h *Handler) ListApprovalPolicies(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "10"))
+	keyword := c.Query("keyword")
+
+	var policies []*model.JobApprovalPolicy
+	var total int64
+
+	query := h.db.Model(&model.JobApprovalPolicy{}).Where("deleted_at IS NULL")
+	if keyword != "" {
+		query = query.Where("name LIKE ?", "%"+keyword+"%")
+	}
+
+	query.Count(&total)
+	offset := (page - 1) * pageSize
+	query.Order("created_at DESC").Limit(pageSize).Offset(offset).Find(&policies)
+
+	response.Success(c, gin.H{
+		"list":     policies,
+		"total":    total,
+		"page":     page,
+		"pageSize": pageSize,
+	})
+}
+
+// GetApprovalPolicy 获取审批策略详情
+// @Summary 获取审批策略详情
+// @Description 获取指定审批策略的详细信息
+// @Tags 任务管理-任务审批
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "策略ID"
+// @Success 200 {object} response.Response "获取成功"
+// @Failure 404 {object} response.Response "审批策略不存在"
+// @Router /task/approval-policies/{id} [get]
+func (h *Handler) GetApprovalPolicy(c *gin.Context) {
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	var policy model.JobApprovalPolicy
+	if err := h.db.Where("id = ? AND deleted_at IS NULL", id).First(&policy).Error; err != nil {
+		response.ErrorCode(c, http.StatusNotFound, "审批策略不存在")
+		return
+	}
+	response.Success(c, policy)
+}
+
+// CreateApprovalPolicy 创建审批策略
+// @Summary 创建审批策略
+// @Description 按资产分组、模板分类或命令策略结果配置需要审批的任务执行及审批人
+// @Tags 任务管理-任务审批
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param body body model.JobApprovalPolicy true "审批策略"
+// @Success 200 {object} response.Response "创建成功"
+// @Failure 400 {object} response.Response "参数错误"
+// @Router /task/approval-policies [post]
+func (h *Handler) CreateApprovalPolicy(c *gin.Context) {
+	var policy model.JobApprovalPolicy
+	if err := c.ShouldBindJSON(&policy); err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "参数错误: "+err.Error())
+		return
+	}
+	if err := validateApprovalPolicy(&policy); err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	policy.ID = 0
+	policy.DeletedAt = nil
+	policy.CreatedBy = currentUserID(c)
+
+	if err := h.db.Create(&policy).Error; err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "创建失败: "+err.Error())
+		return
+	}
+	response.Success(c, policy)
+}
+
+// UpdateApprovalPolicy 更新审批策略
+// @Summary 更新审批策略
+// @Description 更新审批策略，只影响之后发起的任务
+// @Tags 任务管理-任务审批
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "策略ID"
+// @Param body body model.JobApprovalPolicy true "审批策略"
+// @Success 200 {object} response.Response "更新成功"
+// @Failure 400 {object} response.Response "参数错误"
+// @Failure 404 {object} response.Response "审批策略不存在"
+// @Router /task/approval-policies/{id} [put]
+func (h *Handler) UpdateApprovalPolicy(c *gin.Context) {
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	var existing model.JobApprovalPolicy
+	if err := h.db.Where("id = ? AND deleted_at IS NULL", id).First(&existing).Error; err != nil {
+		response.ErrorCode(c, http.StatusNotFound, "审批策略不存在")
+		return
+	}
+
+	policy := existing
+	if err := c.ShouldBindJSON(&policy); err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "参数错误: "+err.Error())
+		return
+	}
+	if err := validateApprovalPolicy(&policy); err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	policy.ID = existing.ID
+	policy.CreatedBy = existing.CreatedBy
+	policy.CreatedAt = existing.CreatedAt
+	policy.DeletedAt = nil
+
+	if err := h.db.Save(&policy).Error; err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "更新失败: "+err.Error())
+		return
+	}
+	response.Success(c, policy)
+}
+
+// DeleteApprovalPolicy 删除审批策略
+// @Summary 删除审批策略
+// @Description 删除审批策略，已提交的审批不受影响
+// @Tags 任务管理-任务审批
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "策略ID"
+// @Success 200 {object} response.Response "删除成功"
+// @Failure 404 {object} response.Response "审批策略不存在"
+// @Router /task/approval-policies/{id} [delete]
+func (h *Handler) DeleteApprovalPolicy(c *gin.Context) {
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	result := h.db.Model(&model.JobApprovalPolicy{}).Where("id = ? AND deleted_at IS NULL", id).Update("deleted_at", time.Now())
+	if result.Error != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "删除失败: "+result.Error.Error())
+		return
+	}
+	if result.RowsAffected == 0 {
+		response.ErrorCode(c, http.StatusNotFound, "审批策略不存在")
+		return
+	}
+	response.SuccessWithMessage(c, "删除成功", nil)
+}
+
+// validateApprovalPolicy 校验审批策略的 JSON 字段并规范化为数组格式
+func validateApprovalPolicy(policy *model.JobApprovalPolicy) error {
+	var groupIDs, approvers []uint
+	var categories []string
+	fields := []struct {
+		name  string
+		raw   *string
+		value interface{}
+	}{
+		{"资产分组", &policy.AssetGroupIDs, &groupIDs},
+		{"模板分类", &policy.TemplateCategories, &categories},
+		{"审批人", &policy.Approvers, &approvers},
+	}
+	for _, field := range fields {
+		if *field.raw == "" {
+			*field.raw = "[]"
+			continue
+		}
+		if err := json.Unmarshal([]byte(*field.raw), field.value); err != nil {
+			return fmt.Errorf("%s格式错误，应为JSON数组", field.name)
+		}
+	}
+	if len(groupIDs) == 0 && len(categories) == 0 && !policy.OnCommandApproval {
+		return fmt.Errorf("请至少配置一个审批条件")
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	assetbiz "github.com/ydcloud-dy/opshub/internal/biz/asset"
+)
+
+// commandPolicyRequest 命令策略检查参数
+type commandPolicyRequest struct {
+	ScriptType string
+	Content    string
+	UserID     uint   // 执行用户，匹配角色范围的规则
+	HostIDs    []uint // 目标主机，匹配资产分组范围的规则
+	TemplateID uint   // 作业模板，匹配模板范围的规则
+}
+
+const pythonStringPattern = `"""(?s:.*?)"""|'''(?s:.*?)'''|"(?:[^"\\\n]|\\.)*"|'(?:[^'\\\n]|\\.)*'`
+
+// pythonShellFuncs 执行 shell 命令的函数
+var pythonShellFuncs = map[string][]string{
+	"os":         {"system", "popen"},
+	"subprocess": {"run", "call", "check_call", "check_output", "Popen", "getoutput", "getstatusoutput"},
+}
+
+var (
+	// pythonLiteralArgRegexp 调用的第一个参数为字符串或列表，且参数到此结束
+	pythonLiteralArgRegexp = regexp.MustCompile(`^\s*(\[[^\]]*\]|[rRbBuU]{0,2}(?:` + pythonStringPattern + `))\s*[,)]`)
+	pythonStringRegexp     = regexp.MustCompile(`[rRbBuU]{0,2}(` + pythonStringPattern + `)`)
+	// pythonImportAsRegexp import os as o、import subprocess as sp
+	pythonImportAsRegexp = regexp.MustCompile(`(?m)^\s*import\s+(os|subprocess)\s+as\s+(\w+)`)
+	// pythonFromImportRegexp from subprocess import run, Popen as P
+	pythonFromImportRegexp = regexp.MustCompile(`(?m)^\s*from\s+(os|subprocess)\s+import\s+\(?([\w \t,]+)`)
+	// pythonIndirectExecRegexp 无法静态确定执行内容的写法：动态导入、反射调用、exec 系列函数和执行任意代码
+	pythonIndirectExecRegexp = regexp.MustCompile(`\b__import__\s*\(|\bgetattr\s*\(\s*(?:os|subprocess)\b|\bos\.(?:exec|spawn|posix_spawn)\w*\s*\(|\bpty\.spawn\s*\(|(?:^|[^.\w])(?:eval|exec)\s*\(`)
+)
+
+// checkCommandPolicy 按命令策略检查脚本，禁止执行时返回错误，需要审批时返回命中原因。
+// Python 脚本检查其中通过 os.system、subprocess 等执行的 shell 命令，命令不是字符串字面量时需要审批
+func (h *Handler) checkCommandPolicy(ctx context.Context, req commandPolicyRequest) (string, error) {
+	content := req.Content
+	dynamicReason := ""
+	if req.ScriptType == "Python" {
+		var dynamic bool
+		content, dynamic = pythonShellCommands(content)
+		if dynamic {
+			dynamicReason = "Python 脚本执行的命令由变量或表达式生成，无法按命令策略检查"
+		}
+	}
+	if strings.TrimSpace(content) == "" {
+		return dynamicReason, nil
+	}
+
+	result, err := h.commandRules.Check(ctx, &assetbiz.CommandCheckRequest{
+		Content:    content,
+		UserID:     req.UserID,
+		HostIDs:    req.HostIDs,
+		TemplateID: req.TemplateID,
+	})
+	if err != nil {
+		return "", fmt.Errorf("命令策略检查失败: %w", err)
+	}
+
+	switch result.Action {
+	case assetbiz.CommandActionDeny:
+		return "", fmt.Errorf("命令被策略拦截: %s", result.Reason)
+	case assetbiz.CommandActionApprove:
+		return result.Reason, nil
+	}
+	return dynamicReason, nil
+}
+
+// pythonShellCommands 提取 Python 脚本中交给 shell 执行的命令，每条一行。
+// 命令参数不是字符串字面量（变量、拼接、格式化等）或通过动态导入、eval 等方式执行时 dynamic 为 true
+func pythonShellCommands(content string) (string, bool) {
+	dynamic := pythonIndirectExecRegexp.MatchString(content)
+
+	var commands []string
+	for _, loc := range pythonShellCallRegexp(content).FindAllStringIndex(content, -1) {
+		match := pythonLiteralArgRegexp.FindStringSubmatch(content[loc[1]:])
+		if match == nil {
+			dynamic = true
+			continue
+		}
+		arg := match[1]
+		var parts []string
+		for _, literal := range pythonStringRegexp.FindAllStringSubmatch(arg, -1) {
+			parts = append(parts, unquotePython(literal[1]))
+		}
+		// 列表中除字符串外还有变量等其他元素
+		if strings.HasPrefix(arg, "[") && strings.Trim(pythonStringRegexp.ReplaceAllString(arg, ""), "[], \t\r\n") != "" {
+			dynamic = true
+		}
+		if len(parts) > 0 {
+			commands = append(commands, strings.Join(parts, " "))
+		}
+	}
+	return strings.Join(commands, "\n"), dynamic
+}
+
+// pythonShellCallRegexp 匹配脚本中执行 shell 命令的函数调用至左括号，包括 import as、from import 引入的名称
+func pythonShellCallRegexp(content string) *regexp.Regexp {
+	var names []string
+	for module, funcs := range pythonShellFuncs {
+		prefixes := []string{module}
+		for _, m := range pythonImportAsRegexp.FindAllStringSubmatch(content, -1) {
+			if m[1] == module {
+				prefixes = append(prefixes, m[2])
+			}
+		}
+		for _, prefix := range prefixes {
+			for _, fn := range funcs {
+				names = append(names, regexp.QuoteMeta(prefix+"."+fn))
+			}
+		}
+	}
+	for _, m := range pythonFromImportRegexp.FindAllStringSubmatch(content, -1) {
+		for _, item := range strings.Split(m[2], ",") {
+			// from os import system as run_cmd 以别名调用
+			fields := strings.Fields(item)
+			if len(fields) == 0 || !slices.Contains(pythonShellFuncs[m[1]], fields[0]) {
+				continue
+			}
+			names = append(names, regexp.QuoteMeta(fields[len(fields)-1]))
+		}
+	}
+	return regexp.MustCompile(`(?:^|[^.\w])(?:` + strings.Join(names, "|") + `)\s*\(`)
+}
+
+// unquotePython 去除 Python 字符串字面量的引号
+func unquotePython(literal string) string {
+	for _, quote := range []string{`"""`, `'''`, `"`, `'`} {
+		if len(literal) >= 2*len(quote) && strings.HasPrefix(literal, quote) && strings.HasSuffix(literal, quote) {
+			return literal[len(quote) : len(literal)-len(quote)]
+		}
+	}
+	return literal
+}
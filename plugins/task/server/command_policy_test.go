@@ -0,0 +1,66 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package server
+
+import "testing"
+
+func TestPythonShellCommands(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		commands string
+		dynamic  bool
+	}{
+		{"no shell", "print('hello')", "", false},
+		{"literal", `import os` + "\n" + `os.system("rm -rf /")`, "rm -rf /", false},
+		{"single quotes", `os.system('ls -l')`, "ls -l", false},
+		{"raw string", `os.system(r"echo \n")`, `echo \n`, false},
+		{"triple quotes", "os.system('''rm -rf /''')", "rm -rf /", false},
+		{"list", `subprocess.run(["rm", "-rf", "/"], check=True)`, "rm -rf /", false},
+		{"keyword args", `subprocess.run("ls", shell=True)`, "ls", false},
+		{"multiple calls", "os.system('ls')\nos.popen('reboot')", "ls\nreboot", false},
+		{"variable", "c = \"rm -rf /\"\nos.system(c)", "", true},
+		{"f-string", `os.system(f"rm -rf {path}")`, "", true},
+		{"concatenation", `os.system("rm -rf " + path)`, "", true},
+		{"percent format", `os.system("rm -rf %s" % path)`, "", true},
+		{"format method", `subprocess.call("rm -rf {}".format(path), shell=True)`, "", true},
+		{"list with variable", `subprocess.run(["rm", "-rf", path])`, "rm -rf", true},
+		{"import as", "import os as o\no.system(cmd)", "", true},
+		{"import as literal", "import subprocess as sp\nsp.check_output('reboot')", "reboot", false},
+		{"from import", "from os import system\nsystem('reboot')", "reboot", false},
+		{"from import alias", "from subprocess import getoutput as run_cmd\nrun_cmd(cmd)", "", true},
+		{"dunder import", `__import__("os").system("reboot")`, "", true},
+		{"getattr", `getattr(os, "system")("reboot")`, "", true},
+		{"exec", `exec("import os; os.system('reboot')")`, "reboot", true},
+		{"eval", `eval(code)`, "", true},
+		{"os.exec", `os.execvp("rm", ["rm", "-rf", "/"])`, "", true},
+		{"pty.spawn", `pty.spawn("/bin/sh")`, "", true},
+		{"method named eval", `model.eval()`, "", false},
+		{"other module", `mymodule.system(cmd)`, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			commands, dynamic := pythonShellCommands(tt.content)
+			if commands != tt.commands || dynamic != tt.dynamic {
+				t.Errorf("pythonShellCommands(%q) = (%q, %v), want (%q, %v)", tt.content, commands, dynamic, tt.commands, tt.dynamic)
+			}
+		})
+	}
+}
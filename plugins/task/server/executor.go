@@ -0,0 +1,620 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	assetbiz "github.com/ydcloud-dy/opshub/internal/biz/asset"
+	rbacbiz "github.com/ydcloud-dy/opshub/internal/biz/rbac"
+	appLogger "github.com/ydcloud-dy/opshub/pkg/logger"
+	"github.com/ydcloud-dy/opshub/plugins/task/model"
+	"go.uber.org/zap"
+)
+
+const (
+	// defaultConcurrency 默认并发主机数（fork）
+	defaultConcurrency = 10
+	// maxConcurrency 最大并发主机数
+	maxConcurrency = 200
+	// defaultHostTimeout 默认单主机执行超时
+	defaultHostTimeout = 10 * time.Minute
+	// maxHostOutput 单主机保存到任务结果中的最大输出字节数
+	maxHostOutput = 1 << 20
+	// maxRunEvents 为后加入的订阅者保留的输出事件数
+	maxRunEvents = 5000
+)
+
+// 任务执行事件类型
+const (
+	EventHostStart = "host_start"
+	EventStdout    = "stdout"
+	EventStderr    = "stderr"
+	EventHostDone  = "host_done"
+	EventDone      = "done"
+)
+
+// TaskEvent 任务执行事件，通过 WebSocket 实时推送
+type TaskEvent struct {
+	Type     string `json:"type"`
+	TaskID   uint   `json:"taskId"`
+	HostID   uint   `json:"hostId,omitempty"`
+	HostName string `json:"hostName,omitempty"`
+	HostIP   string `json:"hostIp,omitempty"`
+	Data     string `json:"data,omitempty"`
+	Status   string `json:"status,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// taskRun 正在执行的任务
+type taskRun struct {
+	taskID      uint
+	jobTaskID   uint // Ansible任务审批通过后执行时对应的任务记录
+	cancel      context.CancelFunc
+	cancelledBy string // 取消任务的用户，未取消时为空
+	mu          sync.Mutex
+	results     []HostExecutionResult
+	events      []TaskEvent
+	subs        map[chan TaskEvent]struct{}
+	done        bool
+	redact      *secretRedactor // 输出脱敏，为空时不处理
+}
+
+// taskExecutor 异步任务执行器：按并发限制在多台主机上执行，并实时推送输出
+type taskExecutor struct {
+	h    *Handler
+	mu   sync.Mutex
+	runs map[uint]*taskRun
+}
+
+func newTaskExecutor(h *Handler) *taskExecutor {
+	return &taskExecutor{h: h, runs: make(map[uint]*taskRun)}
+}
+
+// executeOptions 任务执行参数
+type executeOptions struct {
+	ScriptType  string
+	Content     string
+	Concurrency int
+	HostTimeout time.Duration
+	// Secrets 需要在输出中脱敏的敏感值
+	Secrets []string
+	// OnDone 不为空时在任务结束后以最终状态回调
+	OnDone func(status string)
+}
+
+// Start 异步执行任务，返回各主机的初始结果
+func (e *taskExecutor) Start(jobTask *model.JobTask, hostIDs []uint, opts executeOptions) []HostExecutionResult {
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = defaultConcurrency
+	}
+	if opts.Concurrency > maxConcurrency {
+		opts.Concurrency = maxConcurrency
+	}
+	if opts.HostTimeout <= 0 {
+		opts.HostTimeout = defaultHostTimeout
+	}
+
+	// 证书凭证的主机按任务创建人签发证书，执行脚本需要终端权限
+	ctx, cancel := context.WithCancel(assetbiz.WithSSHCertIdentity(context.Background(),
+		e.h.sshCertIdentity(jobTask.CreatedBy, assetbiz.SSHCertPurposeTask, rbacbiz.PermissionTerminal)))
+	run := &taskRun{
+		taskID:  jobTask.ID,
+		cancel:  cancel,
+		results: make([]HostExecutionResult, len(hostIDs)),
+		subs:    make(map[chan TaskEvent]struct{}),
+		redact:  newSecretRedactor(opts.Secrets),
+	}
+	for i, hostID := range hostIDs {
+		run.results[i] = HostExecutionResult{HostID: hostID, Status: "pending"}
+	}
+
+	e.mu.Lock()
+	e.runs[jobTask.ID] = run
+	e.mu.Unlock()
+
+	initial := run.snapshot()
+	go e.run(ctx, run, hostIDs, opts)
+	return initial
+}
+
+// run 执行任务并在结束后更新任务状态
+func (e *taskExecutor) run(ctx context.Context, run *taskRun, hostIDs []uint, opts executeOptions) {
+	defer run.cancel()
+
+	sem := make(chan struct{}, opts.Concurrency)
+	var wg sync.WaitGroup
+
+	for i, hostID := range hostIDs {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			run.finishHost(i, HostExecutionResult{HostID: hostID, Status: "cancelled", Error: run.cancelMessage()})
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, hostID uint) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			hostCtx, cancel := context.WithTimeout(ctx, opts.HostTimeout)
+			defer cancel()
+
+			stream := &hostStream{run: run, index: i}
+			result := e.h.executeOnHost(hostCtx, hostID, opts.ScriptType, opts.Content, stream)
+			stream.Flush()
+			run.finishHost(i, result)
+			e.persist(run)
+		}(i, hostID)
+	}
+	wg.Wait()
+
+	results := run.snapshot()
+	status := "success"
+	for _, result := range results {
+		if result.Status != "success" {
+			status = "failed"
+			break
+		}
+	}
+
+	updates := map[string]interface{}{"status": status}
+	if cancelledBy := run.cancelledUser(); cancelledBy != "" {
+		status = "cancelled"
+		updates["status"] = status
+		updates["error_message"] = run.cancelMessage()
+	}
+
+	resultJSON, _ := json.Marshal(results)
+	updates["result"] = string(resultJSON)
+	if err := e.h.db.Model(&model.JobTask{}).Where("id = ?", run.taskID).Updates(updates).Error; err != nil {
+		appLogger.Error("更新任务状态失败", zap.Uint("taskId", run.taskID), zap.Error(err))
+	}
+
+	run.publish(TaskEvent{Type: EventDone, TaskID: run.taskID, Status: status})
+	run.close()
+
+	e.mu.Lock()
+	delete(e.runs, run.taskID)
+	e.mu.Unlock()
+
+	if opts.OnDone != nil {
+		opts.OnDone(status)
+	}
+}
+
+// persist 增量保存已完成主机的执行结果
+func (e *taskExecutor) persist(run *taskRun) {
+	resultJSON, _ := json.Marshal(run.snapshot())
+	if err := e.h.db.Model(&model.JobTask{}).Where("id = ?", run.taskID).Update("result", string(resultJSON)).Error; err != nil {
+		appLogger.Error("保存任务执行结果失败", zap.Uint("taskId", run.taskID), zap.Error(err))
+	}
+}
+
+// Cancel 取消正在执行的任务：未开始的主机不再执行，执行中的主机终止远程进程组
+// 任务不在执行中时返回 false
+func (e *taskExecutor) Cancel(taskID uint, username string) bool {
+	e.mu.Lock()
+	run, ok := e.runs[taskID]
+	e.mu.Unlock()
+	if !ok {
+		return false
+	}
+
+	run.mu.Lock()
+	if run.cancelledBy == "" {
+		run.cancelledBy = username
+	}
+	run.mu.Unlock()
+
+	run.cancel()
+	return true
+}
+
+// Running 判断任务是否正在执行
+func (e *taskExecutor) Running(taskID uint) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	_, ok := e.runs[taskID]
+	return ok
+}
+
+// Active 返回正在执行的任务
+func (e *taskExecutor) Active() []uint {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	ids := make([]uint, 0, len(e.runs))
+	for id := range e.runs {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// Subscribe 订阅正在执行的任务，返回历史事件与后续事件通道；任务不在执行中时返回 false
+func (e *taskExecutor) Subscribe(taskID uint) ([]TaskEvent, chan TaskEvent, func(), bool) {
+	e.mu.Lock()
+	run, ok := e.runs[taskID]
+	e.mu.Unlock()
+	if !ok {
+		return nil, nil, nil, false
+	}
+
+	return run.subscribe()
+}
+
+// subscribe 订阅任务事件，返回历史事件、后续事件通道与取消订阅函数
+func (r *taskRun) subscribe() ([]TaskEvent, chan TaskEvent, func(), bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.done {
+		return nil, nil, nil, false
+	}
+
+	history := make([]TaskEvent, len(r.events))
+	copy(history, r.events)
+
+	ch := make(chan TaskEvent, 256)
+	r.subs[ch] = struct{}{}
+	unsubscribe := func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if _, ok := r.subs[ch]; ok {
+			delete(r.subs, ch)
+			close(ch)
+		}
+	}
+	return history, ch, unsubscribe, true
+}
+
+func (r *taskRun) cancelledUser() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.cancelledBy
+}
+
+func (r *taskRun) cancelMessage() string {
+	return cancelMessage(r.cancelledUser())
+}
+
+// cancelMessage 任务取消说明
+func cancelMessage(username string) string {
+	if username == "" {
+		return "任务已取消"
+	}
+	return fmt.Sprintf("任务已被 %s 取消", username)
+}
+
+func (r *taskRun) snapshot() []HostExecutionResult {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	results := make([]HostExecutionResult, len(r.results))
+	copy(results, r.results)
+	return results
+}
+
+func (r *taskRun) finishHost(index int, result HostExecutionResult) {
+	result.Output = r.redact.Replace(result.Output)
+	result.Error = r.redact.Replace(result.Error)
+
+	r.mu.Lock()
+	r.results[index] = result
+	r.mu.Unlock()
+
+	r.publish(TaskEvent{
+		Type:     EventHostDone,
+		TaskID:   r.taskID,
+		HostID:   result.HostID,
+		HostName: result.HostName,
+		HostIP:   result.HostIP,
+		Status:   result.Status,
+		Error:    result.Error,
+	})
+}
+
+// publish 记录事件并推送给订阅者，消费过慢的订阅者会被断开。
+// 主机输出由 hostStream 的 Writer 脱敏后推送
+func (r *taskRun) publish(event TaskEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	isOutput := event.Type == EventStdout || event.Type == EventStderr
+	if !isOutput || len(r.events) < maxRunEvents {
+		r.events = append(r.events, event)
+	}
+
+	for ch := range r.subs {
+		select {
+		case ch <- event:
+		default:
+			delete(r.subs, ch)
+			close(ch)
+		}
+	}
+}
+
+func (r *taskRun) close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.done = true
+	for ch := range r.subs {
+		delete(r.subs, ch)
+		close(ch)
+	}
+}
+
+// hostStream 单台主机的输出流
+type hostStream struct {
+	run     *taskRun
+	index   int
+	mu      sync.Mutex
+	writers []*redactWriter
+}
+
+// CancelMessage 任务被取消时的说明
+func (s *hostStream) CancelMessage() string {
+	return s.run.cancelMessage()
+}
+
+// Started 主机开始执行
+func (s *hostStream) Started(result HostExecutionResult) {
+	result.Status = "running"
+	s.run.mu.Lock()
+	s.run.results[s.index] = result
+	s.run.mu.Unlock()
+
+	s.run.publish(TaskEvent{
+		Type:     EventHostStart,
+		TaskID:   s.run.taskID,
+		HostID:   result.HostID,
+		HostName: result.HostName,
+		HostIP:   result.HostIP,
+		Status:   result.Status,
+	})
+}
+
+// Writer 返回推送指定输出流（stdout/stderr）的 Writer，输出脱敏后推送。
+// 输出结束后需要调用 Flush 推送暂存的末尾部分
+func (s *hostStream) Writer(eventType string, result *HostExecutionResult) io.Writer {
+	w := &redactWriter{redact: s.run.redact, emit: func(data string) {
+		s.run.publish(TaskEvent{
+			Type:     eventType,
+			TaskID:   s.run.taskID,
+			HostID:   result.HostID,
+			HostName: result.HostName,
+			HostIP:   result.HostIP,
+			Data:     data,
+		})
+	}}
+	s.mu.Lock()
+	s.writers = append(s.writers, w)
+	s.mu.Unlock()
+	return w
+}
+
+// Flush 推送各输出流暂存的输出
+func (s *hostStream) Flush() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, w := range s.writers {
+		w.Flush()
+	}
+}
+
+// redactWriter 脱敏后推送输出。敏感值可能被拆分在相邻的两次写入中，
+// 末尾可能属于未完整到达的敏感值的部分暂存到下次写入或 Flush 时处理
+type redactWriter struct {
+	mu      sync.Mutex
+	redact  *secretRedactor
+	pending string
+	emit    func(data string)
+}
+
+func (w *redactWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	buf := w.pending + string(p)
+	n := w.redact.SafeLen(buf)
+	w.pending = buf[n:]
+	if n > 0 {
+		w.emit(w.redact.Replace(buf[:n]))
+	}
+	return len(p), nil
+}
+
+// Flush 推送暂存的输出
+func (w *redactWriter) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.pending != "" {
+		w.emit(w.redact.Replace(w.pending))
+		w.pending = ""
+	}
+}
+
+// outputBuffer 合并保存 stdout/stderr 的输出，超出上限后截断
+type outputBuffer struct {
+	mu        sync.Mutex
+	buf       []byte
+	truncated bool
+}
+
+func (b *outputBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	remain := maxHostOutput - len(b.buf)
+	if remain <= 0 {
+		b.truncated = true
+		return len(p), nil
+	}
+	if len(p) > remain {
+		b.buf = append(b.buf, p[:remain]...)
+		b.truncated = true
+		return len(p), nil
+	}
+	b.buf = append(b.buf, p...)
+	return len(p), nil
+}
+
+func (b *outputBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.truncated {
+		return string(b.buf) + fmt.Sprintf("\n...（输出超过 %d 字节，已截断）", maxHostOutput)
+	}
+	return string(b.buf)
+}
+
+// pgidMarker 远程命令启动时输出进程组ID的标记
+// sshd 会为每个会话的 shell 调用 setsid，因此 shell 的 PID 即进程组ID，终止该进程组即可结束脚本派生的所有子进程
+const pgidMarker = "__OPSHUB_PGID__="
+
+// withPGIDReport 在命令前输出进程组ID（写入 stderr，由 pgidWriter 截获）
+func withPGIDReport(cmd string) string {
+	return fmt.Sprintf("echo %s$$ >&2; %s", pgidMarker, cmd)
+}
+
+// pgidWriter 截获 stderr 首行的进程组ID标记，其余输出原样转发
+type pgidWriter struct {
+	next io.Writer
+	mu   sync.Mutex
+	buf  []byte
+	done bool
+	pgid int
+}
+
+func (w *pgidWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	if w.done {
+		w.mu.Unlock()
+		return w.next.Write(p)
+	}
+
+	w.buf = append(w.buf, p...)
+	// 标记尚未完整到达
+	if idx := bytes.IndexByte(w.buf, '\n'); idx < 0 {
+		if len(w.buf) < len(pgidMarker)+20 && strings.HasPrefix(pgidMarker, string(w.buf[:min(len(w.buf), len(pgidMarker))])) {
+			w.mu.Unlock()
+			return len(p), nil
+		}
+	}
+
+	w.done = true
+	rest := w.buf
+	w.buf = nil
+	if line, remain, found := bytes.Cut(rest, []byte("\n")); found && bytes.HasPrefix(line, []byte(pgidMarker)) {
+		if pgid, err := strconv.Atoi(strings.TrimSpace(string(line[len(pgidMarker):]))); err == nil {
+			w.pgid = pgid
+		}
+		rest = remain
+	}
+	w.mu.Unlock()
+
+	if len(rest) > 0 {
+		if _, err := w.next.Write(rest); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// PGID 返回远程进程组ID，未获取到时返回 0
+func (w *pgidWriter) PGID() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.pgid
+}
+
+// killProcessGroupCommand 终止远程进程组：先发送 TERM，等待后仍存活则发送 KILL
+func killProcessGroupCommand(pgid int) string {
+	return fmt.Sprintf("kill -TERM -- -%[1]d 2>/dev/null; for i in 1 2 3 4 5; do sleep 1; kill -0 -- -%[1]d 2>/dev/null || exit 0; done; kill -KILL -- -%[1]d 2>/dev/null; true", pgid)
+}
+
+// secretRedactor 将输出中的敏感值替换为掩码
+type secretRedactor struct {
+	replacer *strings.Replacer
+	secrets  []string
+	maxLen   int // 最长敏感值的长度
+}
+
+func newSecretRedactor(secrets []string) *secretRedactor {
+	r := &secretRedactor{}
+	var pairs []string
+	for _, secret := range secrets {
+		if secret != "" {
+			pairs = append(pairs, secret, secretMask)
+			r.secrets = append(r.secrets, secret)
+			r.maxLen = max(r.maxLen, len(secret))
+		}
+	}
+	if len(pairs) == 0 {
+		return nil
+	}
+	r.replacer = strings.NewReplacer(pairs...)
+	return r
+}
+
+// SafeLen 返回 s 中可以单独脱敏输出的前缀长度，其余部分可能与后续输出组成敏感值。
+// 末尾保留最长敏感值长度减一的字节，且前缀不截断跨越边界的敏感值
+func (r *secretRedactor) SafeLen(s string) int {
+	if r == nil {
+		return len(s)
+	}
+	n := len(s) - (r.maxLen - 1)
+	if n <= 0 {
+		return 0
+	}
+	for moved := true; moved; {
+		moved = false
+		for i := max(0, n-r.maxLen+1); i < n; i++ {
+			if r.crosses(s[i:], n-i) {
+				n, moved = i, true
+				break
+			}
+		}
+	}
+	return n
+}
+
+// crosses 以 s 开头的敏感值（完整或未到达完）是否超出 s 的前 offset 字节
+func (r *secretRedactor) crosses(s string, offset int) bool {
+	for _, secret := range r.secrets {
+		if len(secret) > offset && (strings.HasPrefix(s, secret) || strings.HasPrefix(secret, s)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Replace 替换敏感值，redactor 为空时原样返回
+func (r *secretRedactor) Replace(s string) string {
+	if r == nil || s == "" {
+		return s
+	}
+	return r.replacer.Replace(s)
+}
@@ -21,9 +21,6 @@ package server
 
 import (
 	"context"
-	"crypto/aes"
-	"crypto/cipher"
-	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -34,26 +31,43 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
 	"github.com/pkg/sftp"
 	"golang.org/x/crypto/ssh"
 	assetbiz "github.com/ydcloud-dy/opshub/internal/biz/asset"
+	assetdata "github.com/ydcloud-dy/opshub/internal/data/asset"
+	rbacbiz "github.com/ydcloud-dy/opshub/internal/biz/rbac"
+	appLogger "github.com/ydcloud-dy/opshub/pkg/logger"
+	auditbiz "github.com/ydcloud-dy/opshub/internal/biz/audit"
+	auditdata "github.com/ydcloud-dy/opshub/internal/data/audit"
 	"github.com/ydcloud-dy/opshub/pkg/response"
+	sshclient "github.com/ydcloud-dy/opshub/pkg/ssh"
 	"github.com/ydcloud-dy/opshub/plugins/task/model"
+	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
 
 type Handler struct {
-	db            *gorm.DB
-	encryptionKey []byte
+	db           *gorm.DB
+	hostKeyStore sshclient.HostKeyStore
+	executor     *taskExecutor
+	ansible      *ansibleRunner
+	commandRules *assetbiz.CommandRuleUseCase
+	transferLogs *auditbiz.FileTransferLogUseCase
 }
 
 func NewHandler(db *gorm.DB) *Handler {
-	// 使用与凭证仓库相同的加密密钥
-	encryptionKey := []byte("opshub-enc-key-32-bytes-long!!!!")
-	return &Handler{
-		db:            db,
-		encryptionKey: encryptionKey,
-	}
+	h := &Handler{
+		db:           db,
+		hostKeyStore: assetdata.NewHostKeyStore(db),
+		commandRules: assetbiz.NewCommandRuleUseCase(
+			assetdata.NewCommandRuleRepo(db), assetdata.NewHostRepo(db), assetdata.NewAssetGroupRepo(db),
+		),
+		transferLogs: auditbiz.NewFileTransferLogUseCase(auditdata.NewFileTransferLogRepo(db)),
+	}
+	h.executor = newTaskExecutor(h)
+	h.ansible = newAnsibleRunner(h)
+	return h
 }
 
 // ==================== 任务作业 ====================
@@ -195,6 +209,66 @@ func (h *Handler) DeleteJobTask(c *gin.Context) {
 	response.ErrorCode(c, http.StatusForbidden, "删除任务记录功能已被禁用，如需删除请联系系统管理员")
 }
 
+// CancelJobTask 取消任务作业
+// @Summary 取消任务作业
+// @Description 取消正在执行的任务：未开始的主机不再执行，执行中的主机终止远程进程组，结果标记为已取消；等待审批的任务直接撤回
+// @Tags 任务管理-任务作业
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "任务ID"
+// @Success 200 {object} response.Response "取消成功"
+// @Failure 400 {object} response.Response "任务已结束"
+// @Failure 404 {object} response.Response "任务不存在"
+// @Router /task/jobs/{id}/cancel [post]
+func (h *Handler) CancelJobTask(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "无效的任务ID")
+		return
+	}
+
+	var jobTask model.JobTask
+	if err := h.db.Where("id = ?", id).First(&jobTask).Error; err != nil {
+		response.ErrorCode(c, http.StatusNotFound, "任务不存在")
+		return
+	}
+
+	if jobTask.Status != "pending" && jobTask.Status != "running" && jobTask.Status != model.JobStatusPendingApproval {
+		response.ErrorCode(c, http.StatusBadRequest, "任务已结束，无法取消")
+		return
+	}
+
+	username := c.GetString("username")
+	if jobTask.Status == model.JobStatusPendingApproval {
+		// 撤回审批，以待审批状态为条件避免与审批操作冲突
+		if !h.claimApproval(&jobTask, map[string]interface{}{
+			"status":        "cancelled",
+			"error_message": cancelMessage(username),
+		}) {
+			response.ErrorCode(c, http.StatusConflict, "任务已被审批处理")
+			return
+		}
+		response.SuccessWithMessage(c, "任务已取消", nil)
+		return
+	}
+	if h.executor.Cancel(jobTask.ID, username) {
+		response.SuccessWithMessage(c, "已发送取消指令", nil)
+		return
+	}
+
+	// 任务不在执行中（如服务重启前遗留的任务），直接标记为已取消
+	if err := h.db.Model(&model.JobTask{}).Where("id = ?", jobTask.ID).Updates(map[string]interface{}{
+		"status":        "cancelled",
+		"error_message": cancelMessage(username),
+	}).Error; err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "取消任务失败: "+err.Error())
+		return
+	}
+
+	response.SuccessWithMessage(c, "任务已取消", nil)
+}
+
 // ==================== 任务模板 ====================
 
 // ListJobTemplates 获取任务模板列表
@@ -301,10 +375,13 @@ func (h *Handler) CreateJobTemplate(c *gin.Context) {
 	}
 	template.Status = 1
 	template.CreatedBy = 1
-	// 处理空的 variables 字段，MySQL JSON 字段不能为空字符串
-	if template.Variables == "" {
-		template.Variables = "[]"
+	// 校验变量定义，空的 variables 规范化为 []
+	variables, err := validateTemplateVariables(template.Variables)
+	if err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, err.Error())
+		return
 	}
+	template.Variables = variables
 	if err := h.db.Create(&template).Error; err != nil {
 		response.ErrorCode(c, http.StatusInternalServerError, "创建失败: "+err.Error())
 		return
@@ -335,6 +412,12 @@ func (h *Handler) UpdateJobTemplate(c *gin.Context) {
 		response.ErrorCode(c, http.StatusBadRequest, "参数错误")
 		return
 	}
+	variables, err := validateTemplateVariables(template.Variables)
+	if err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	template.Variables = variables
 	h.db.Save(&template)
 	response.Success(c, template)
 }
@@ -354,6 +437,90 @@ func (h *Handler) DeleteJobTemplate(c *gin.Context) {
 	response.ErrorCode(c, http.StatusForbidden, "删除模板功能已被禁用，如需删除请联系系统管理员")
 }
 
+// ExecuteTemplateRequest 按模板执行请求
+type ExecuteTemplateRequest struct {
+	HostIDs     []uint                 `json:"hostIds" binding:"required"`
+	Variables   map[string]interface{} `json:"variables"`  // 变量取值，敏感变量填写凭证ID，主机列表填写主机ID数组
+	ScriptType  string                 `json:"scriptType"` // Shell, Python，默认 Shell
+	Name        string                 `json:"name"`
+	Concurrency int                    `json:"concurrency"`
+	Timeout     int                    `json:"timeout"` // 单主机超时（秒），默认使用模板超时
+}
+
+// ExecuteJobTemplate 按模板执行任务
+// @Summary 按模板执行任务
+// @Description 校验变量取值并渲染模板后在指定主机上异步执行，敏感变量从凭证中读取且不会出现在任务参数和执行结果中；命中审批策略时审批通过后执行
+// @Tags 任务管理-任务模板
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "模板ID"
+// @Param body body ExecuteTemplateRequest true "执行参数"
+// @Success 200 {object} response.Response "执行成功"
+// @Failure 400 {object} response.Response "参数错误"
+// @Failure 403 {object} response.Response "命令被拦截"
+// @Failure 404 {object} response.Response "模板不存在"
+// @Router /task/templates/{id}/execute [post]
+func (h *Handler) ExecuteJobTemplate(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "无效的模板ID")
+		return
+	}
+
+	var req ExecuteTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "参数错误: "+err.Error())
+		return
+	}
+	if len(req.HostIDs) == 0 {
+		response.ErrorCode(c, http.StatusBadRequest, "请选择目标主机")
+		return
+	}
+
+	var createdBy uint = 1
+	if userID, exists := c.Get("user_id"); exists {
+		if uid, ok := userID.(uint); ok {
+			createdBy = uid
+		}
+	}
+
+	spec := executionSpec{
+		ScriptType:  req.ScriptType,
+		TemplateID:  uint(id),
+		Variables:   req.Variables,
+		HostIDs:     req.HostIDs,
+		Concurrency: req.Concurrency,
+		Timeout:     req.Timeout,
+	}
+	run, code, err := h.prepareRun(c.Request.Context(), &spec, createdBy)
+	if err != nil {
+		response.ErrorCode(c, code, err.Error())
+		return
+	}
+
+	taskName := req.Name
+	if taskName == "" {
+		taskName = fmt.Sprintf("%s - %s", run.template.Name, time.Now().Format("2006-01-02 15:04:05"))
+	}
+
+	hostIDsJSON, _ := json.Marshal(req.HostIDs)
+	jobTask := model.JobTask{
+		Name:        taskName,
+		TemplateID:  &run.template.ID,
+		TaskType:    "manual",
+		TargetHosts: string(hostIDsJSON),
+		CreatedBy:   createdBy,
+	}
+	results, err := h.submitRun(&jobTask, &spec, run)
+	if err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondRun(c, &jobTask, results)
+}
+
 // ==================== Ansible任务 ====================
 
 // ListAnsibleTasks 获取Ansible任务列表
@@ -447,6 +614,10 @@ func (h *Handler) CreateAnsibleTask(c *gin.Context) {
 	if ansibleTask.Verbose == "" {
 		ansibleTask.Verbose = "v"
 	}
+	if err := validateAnsibleTask(&ansibleTask); err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, err.Error())
+		return
+	}
 	ansibleTask.CreatedBy = 1
 	if err := h.db.Create(&ansibleTask).Error; err != nil {
 		response.ErrorCode(c, http.StatusInternalServerError, "创建失败")
@@ -478,6 +649,10 @@ func (h *Handler) UpdateAnsibleTask(c *gin.Context) {
 		response.ErrorCode(c, http.StatusBadRequest, "参数错误")
 		return
 	}
+	if err := validateAnsibleTask(&ansibleTask); err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, err.Error())
+		return
+	}
 	h.db.Save(&ansibleTask)
 	response.Success(c, ansibleTask)
 }
@@ -497,6 +672,155 @@ func (h *Handler) DeleteAnsibleTask(c *gin.Context) {
 	response.ErrorCode(c, http.StatusForbidden, "删除Ansible任务功能已被禁用，如需删除请联系系统管理员")
 }
 
+// CancelAnsibleTask 取消Ansible任务
+// @Summary 取消Ansible任务
+// @Description 取消Ansible任务：执行中的任务会中断 ansible-playbook，未在执行的任务直接标记为已取消
+// @Tags 任务管理-Ansible任务
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "任务ID"
+// @Success 200 {object} response.Response "取消成功"
+// @Failure 400 {object} response.Response "任务已结束"
+// @Failure 404 {object} response.Response "任务不存在"
+// @Router /task/ansible/{id}/cancel [post]
+func (h *Handler) CancelAnsibleTask(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "无效的任务ID")
+		return
+	}
+
+	var task model.AnsibleTask
+	if err := h.db.Where("id = ?", id).First(&task).Error; err != nil {
+		response.ErrorCode(c, http.StatusNotFound, "任务不存在")
+		return
+	}
+
+	if task.Status != "pending" && task.Status != "running" {
+		response.ErrorCode(c, http.StatusBadRequest, "任务已结束，无法取消")
+		return
+	}
+
+	username := c.GetString("username")
+	if h.ansible.Cancel(task.ID, username) {
+		response.SuccessWithMessage(c, "已发送取消指令", nil)
+		return
+	}
+
+	// 任务不在执行中，直接标记为已取消
+	if err := h.db.Model(&model.AnsibleTask{}).Where("id = ?", task.ID).Update("status", "cancelled").Error; err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "取消任务失败: "+err.Error())
+		return
+	}
+
+	response.SuccessWithMessage(c, "任务已取消", nil)
+}
+
+// RunAnsibleTask 执行Ansible任务
+// @Summary 执行Ansible任务
+// @Description 检查Playbook与命令策略后，根据任务的目标主机生成 inventory 并异步执行 ansible-playbook，通过 /task/ansible/{id}/stream 查看实时输出；命中审批策略时提交审批，审批通过后执行
+// @Tags 任务管理-Ansible任务
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "任务ID"
+// @Success 200 {object} response.Response "已开始执行或已提交审批"
+// @Failure 400 {object} response.Response "任务正在执行或配置错误"
+// @Failure 403 {object} response.Response "Playbook或命令被策略拦截"
+// @Failure 404 {object} response.Response "任务不存在"
+// @Router /task/ansible/{id}/run [post]
+func (h *Handler) RunAnsibleTask(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "无效的任务ID")
+		return
+	}
+
+	var task model.AnsibleTask
+	if err := h.db.Where("id = ? AND deleted_at IS NULL", id).First(&task).Error; err != nil {
+		response.ErrorCode(c, http.StatusNotFound, "任务不存在")
+		return
+	}
+
+	userID := currentUserID(c)
+	run, code, err := h.prepareAnsibleRun(c.Request.Context(), &task, userID)
+	if err != nil {
+		response.ErrorCode(c, code, err.Error())
+		return
+	}
+	if run.approval != nil {
+		jobTask, err := h.submitAnsibleApproval(&task, userID, run)
+		if err != nil {
+			response.ErrorCode(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+		response.SuccessWithMessage(c, "任务命中审批策略，已提交审批: "+jobTask.ApprovalReason, gin.H{"taskId": task.ID, "approvalId": jobTask.ID, "status": jobTask.Status})
+		return
+	}
+
+	if err := h.ansible.Start(&task, ansibleRunOptions{UserID: userID, PlaybookPath: run.playbookPath}); err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	response.SuccessWithMessage(c, "任务已开始执行", gin.H{"taskId": task.ID})
+}
+
+// StreamAnsibleOutput 实时推送Ansible任务输出
+// @Summary 实时推送Ansible任务输出
+// @Description 通过WebSocket推送 ansible-playbook 输出、各主机统计和任务结束事件；任务已结束时推送保存的结果
+// @Tags 任务管理-Ansible任务
+// @Security Bearer
+// @Param id path int true "任务ID"
+// @Param token query string false "认证Token（WebSocket无法设置请求头时使用）"
+// @Success 101 {object} TaskEvent "WebSocket事件流"
+// @Router /task/ansible/{id}/stream [get]
+func (h *Handler) StreamAnsibleOutput(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "无效的任务ID")
+		return
+	}
+	taskID := uint(id)
+
+	var task model.AnsibleTask
+	if err := h.db.Where("id = ?", taskID).First(&task).Error; err != nil {
+		response.ErrorCode(c, http.StatusNotFound, "任务不存在")
+		return
+	}
+
+	conn, err := taskStreamUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	history, events, unsubscribe, running := h.ansible.Subscribe(taskID)
+	if !running {
+		h.sendFinishedAnsibleTask(conn, &task)
+		return
+	}
+	defer unsubscribe()
+
+	writeRunEvents(conn, history, events)
+}
+
+// sendFinishedAnsibleTask 推送已结束Ansible任务的最终结果
+func (h *Handler) sendFinishedAnsibleTask(conn *websocket.Conn, task *model.AnsibleTask) {
+	var result model.AnsibleRunResult
+	if task.LastRunResult != "" {
+		_ = json.Unmarshal([]byte(task.LastRunResult), &result)
+	}
+	if result.Output != "" {
+		conn.WriteJSON(TaskEvent{Type: EventStdout, TaskID: task.ID, Data: result.Output})
+	}
+	for _, stat := range result.Hosts {
+		conn.WriteJSON(TaskEvent{Type: EventHostDone, TaskID: task.ID, HostID: stat.HostID, HostName: stat.HostName, HostIP: stat.HostIP, Status: stat.Status, Error: stat.Error})
+	}
+	conn.WriteJSON(TaskEvent{Type: EventDone, TaskID: task.ID, Status: task.Status, Error: result.Error})
+}
+
 // ==================== 任务执行 ====================
 
 // ExecuteTaskRequest 执行任务请求
@@ -505,11 +829,14 @@ type ExecuteTaskRequest struct {
 	ScriptType  string `json:"scriptType" binding:"required"` // Shell, Python
 	Content     string `json:"content" binding:"required"`
 	Name        string `json:"name"`
+	Concurrency int    `json:"concurrency"` // 并发主机数，默认10，最大200
+	Timeout     int    `json:"timeout"`     // 单主机超时（秒），默认600
 }
 
 // ExecuteTaskResponse 执行任务响应
 type ExecuteTaskResponse struct {
 	TaskID  uint                    `json:"taskId"`
+	Status  string                  `json:"status"` // running, pending_approval
 	Results []HostExecutionResult   `json:"results"`
 }
 
@@ -518,14 +845,14 @@ type HostExecutionResult struct {
 	HostID   uint   `json:"hostId"`
 	HostName string `json:"hostName"`
 	HostIP   string `json:"hostIp"`
-	Status   string `json:"status"` // success, failed
+	Status   string `json:"status"` // pending, running, success, failed, cancelled
 	Output   string `json:"output"`
 	Error    string `json:"error,omitempty"`
 }
 
 // ExecuteTask 执行任务
 // @Summary 执行任务
-// @Description 在指定主机上执行Shell或Python脚本
+// @Description 在指定主机上异步执行Shell或Python脚本，立即返回任务ID，执行输出通过 /task/execute/{id}/stream 实时获取；命中审批策略时任务进入待审批状态，审批通过后执行
 // @Tags 任务管理-任务执行
 // @Accept json
 // @Produce json
@@ -542,13 +869,26 @@ func (h *Handler) ExecuteTask(c *gin.Context) {
 		return
 	}
 
-	// 安全检查：检查命令是否包含危险操作
-	if err := h.checkCommandSafety(req.Content); err != nil {
-		response.ErrorCode(c, http.StatusForbidden, err.Error())
-		return
+	// 从context获取当前用户ID
+	var createdBy uint = 1
+	if userID, exists := c.Get("user_id"); exists {
+		if uid, ok := userID.(uint); ok {
+			createdBy = uid
+		}
 	}
 
-	ctx := c.Request.Context()
+	spec := executionSpec{
+		ScriptType:  req.ScriptType,
+		Content:     req.Content,
+		HostIDs:     req.HostIDs,
+		Concurrency: req.Concurrency,
+		Timeout:     req.Timeout,
+	}
+	run, code, err := h.prepareRun(c.Request.Context(), &spec, createdBy)
+	if err != nil {
+		response.ErrorCode(c, code, err.Error())
+		return
+	}
 
 	// 创建任务记录
 	taskName := req.Name
@@ -556,401 +896,134 @@ func (h *Handler) ExecuteTask(c *gin.Context) {
 		taskName = fmt.Sprintf("手动执行任务 - %s", time.Now().Format("2006-01-02 15:04:05"))
 	}
 
-	// 从context获取当前用户ID
-	var createdBy uint = 1
-	if userID, exists := c.Get("user_id"); exists {
-		if uid, ok := userID.(uint); ok {
-			createdBy = uid
-		}
-	}
-
 	hostIDsJSON, _ := json.Marshal(req.HostIDs)
 	jobTask := model.JobTask{
 		Name:        taskName,
 		TaskType:    "manual",
-		Status:      "running",
 		TargetHosts: string(hostIDsJSON),
-		Parameters:  "", // 空字符串而不是nil
 		CreatedBy:   createdBy,
-		ExecuteTime: ptrTime(time.Now()),
 	}
 
-	if err := h.db.Create(&jobTask).Error; err != nil {
-		response.ErrorCode(c, http.StatusInternalServerError, fmt.Sprintf("创建任务记录失败: %v", err))
+	// 异步执行任务，输出通过 /task/execute/:id/stream 实时推送；命中审批策略时等待审批
+	results, err := h.submitRun(&jobTask, &spec, run)
+	if err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	// 执行任务
-	results := make([]HostExecutionResult, 0, len(req.HostIDs))
-	allSuccess := true
-
-	for _, hostID := range req.HostIDs {
-		result := h.executeOnHost(ctx, hostID, req.ScriptType, req.Content)
-		results = append(results, result)
-		if result.Status != "success" {
-			allSuccess = false
-		}
-	}
-
-	// 更新任务状态
-	resultJSON, _ := json.Marshal(results)
-	if allSuccess {
-		jobTask.Status = "success"
-	} else {
-		jobTask.Status = "failed"
-	}
-	jobTask.Result = string(resultJSON)
-	h.db.Save(&jobTask)
+	respondRun(c, &jobTask, results)
+}
 
-	response.Success(c, ExecuteTaskResponse{
-		TaskID:  jobTask.ID,
-		Results: results,
-	})
+// taskStreamUpgrader 任务输出推送的 WebSocket 升级器
+var taskStreamUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 4096,
+	CheckOrigin: func(r *http.Request) bool {
+		return true
+	},
 }
 
-// checkCommandSafety 检查命令安全性
-func (h *Handler) checkCommandSafety(content string) error {
-	// 转换为小写以便不区分大小写检查
-	contentLower := strings.ToLower(content)
-
-	// 移除空格和特殊字符，防止绕过检测
-	contentCompact := strings.ReplaceAll(contentLower, " ", "")
-	contentCompact = strings.ReplaceAll(contentCompact, "\t", "")
-	contentCompact = strings.ReplaceAll(contentCompact, "\n", "")
-
-	// ============ 完全禁止的命令（一刀切） ============
-	absoluteBannedCommands := []string{
-		"rm", "unlink", "shred",  // 任何形式的删除
-	}
-
-	for _, cmd := range absoluteBannedCommands {
-		// 检查命令是否作为独立词出现
-		if strings.Contains(contentLower, cmd+" ") ||
-		   strings.Contains(contentLower, cmd+"\t") ||
-		   strings.Contains(contentLower, cmd+"\n") ||
-		   strings.HasPrefix(contentLower, cmd+" ") ||
-		   strings.HasSuffix(contentLower, " "+cmd) ||
-		   contentLower == cmd {
-			return fmt.Errorf("命令【%s】已被完全禁用，系统不允许执行任何删除操作", cmd)
-		}
+// StreamTaskOutput 实时推送任务执行输出
+// @Summary 实时获取任务执行输出
+// @Description 通过 WebSocket 推送任务各主机的 stdout/stderr 与状态；任务已结束时推送最终结果后关闭
+// @Tags 任务管理-任务执行
+// @Security Bearer
+// @Param id path int true "任务ID"
+// @Param token query string false "认证Token（WebSocket无法设置请求头时使用）"
+// @Success 101 {object} TaskEvent "WebSocket事件流"
+// @Router /task/execute/{id}/stream [get]
+func (h *Handler) StreamTaskOutput(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "无效的任务ID")
+		return
 	}
+	taskID := uint(id)
 
-	// ============ 危险命令黑名单 ============
-	dangerousPatterns := []struct {
-		pattern string
-		desc    string
-	}{
-		// === 磁盘和文件系统操作 ===
-		{"dd", "磁盘数据复制命令"},
-		{"mkfs", "磁盘格式化命令"},
-		{"fdisk", "磁盘分区命令"},
-		{"parted", "磁盘分区命令"},
-		{"gdisk", "GPT磁盘分区"},
-		{"cfdisk", "磁盘分区工具"},
-		{"sfdisk", "脚本化分区工具"},
-		{"mkswap", "创建交换空间"},
-		{"swapoff", "关闭交换空间"},
-		{"fsck", "文件系统检查"},
-		{"e2fsck", "ext文件系统检查"},
-		{"xfs_repair", "XFS文件系统修复"},
-		{"tune2fs", "调整文件系统参数"},
-		{"resize2fs", "调整文件系统大小"},
-
-		// === 系统控制命令 ===
-		{"shutdown", "关机命令"},
-		{"reboot", "重启命令"},
-		{"halt", "停机命令"},
-		{"poweroff", "关机命令"},
-		{"init 0", "关机命令"},
-		{"init 6", "重启命令"},
-		{"telinit", "改变运行级别"},
-		{"systemctl reboot", "系统重启"},
-		{"systemctl poweroff", "系统关机"},
-		{"systemctl halt", "系统停机"},
-
-		// === 进程管理 ===
-		{"kill -9", "强制终止进程"},
-		{"kill -kill", "强制终止进程"},
-		{"killall", "批量终止进程"},
-		{"pkill", "批量终止进程"},
-		{"killall5", "终止所有进程"},
-		{"skill", "发送信号到进程"},
-
-		// === 权限和所有权修改 ===
-		{"chmod 777", "最宽松权限设置"},
-		{"chmod 666", "危险权限设置"},
-		{"chmod 000", "拒绝所有权限"},
-		{"chmod -r 777", "递归最宽松权限"},
-		{"chmod -r 666", "递归危险权限"},
-		{"chmod a+w", "添加写权限"},
-		{"chmod u+s", "设置SUID"},
-		{"chmod g+s", "设置SGID"},
-		{"chown -r", "递归修改所有者"},
-		{"chgrp -r", "递归修改组"},
-		{"chattr", "修改文件属性"},
-		{"setfacl", "设置ACL权限"},
-
-		// === 服务管理 ===
-		{"systemctl stop", "停止服务"},
-		{"systemctl disable", "禁用服务"},
-		{"systemctl mask", "屏蔽服务"},
-		{"systemctl kill", "终止服务"},
-		{"service stop", "停止服务"},
-		{"service restart", "重启服务"},
-		{"systemctl restart", "重启服务"},
-
-		// === 网络和防火墙 ===
-		{"iptables -f", "清空防火墙规则"},
-		{"iptables -x", "删除防火墙链"},
-		{"iptables -d", "删除防火墙规则"},
-		{"ip6tables -f", "清空IPv6防火墙"},
-		{"firewall-cmd --reload", "重载防火墙"},
-		{"ufw disable", "禁用防火墙"},
-		{"ufw reset", "重置防火墙"},
-		{"ifconfig down", "关闭网络接口"},
-		{"ip link set down", "关闭网络链路"},
-		{"ip addr del", "删除IP地址"},
-		{"ip route del", "删除路由"},
-
-		// === 用户和组管理 ===
-		{"userdel", "删除用户"},
-		{"groupdel", "删除用户组"},
-		{"passwd -d", "删除用户密码"},
-		{"passwd -l", "锁定用户"},
-		{"usermod -l", "修改用户"},
-		{"usermod -u 0", "修改用户UID为root"},
-		{"visudo", "编辑sudo配置"},
-
-		// === 定时任务 ===
-		{"crontab -r", "删除定时任务"},
-		{"crontab -e", "编辑定时任务"},
-		{"at -r", "删除at任务"},
-
-		// === 系统配置文件 ===
-		{"/etc/passwd", "系统密码文件"},
-		{"/etc/shadow", "系统影子文件"},
-		{"/etc/sudoers", "sudo配置文件"},
-		{"/etc/ssh/sshd_config", "SSH配置文件"},
-		{"/etc/fstab", "文件系统挂载表"},
-		{"/etc/hosts", "主机名解析文件"},
-		{"/etc/resolv.conf", "DNS解析配置"},
-		{"/etc/profile", "系统环境变量"},
-		{"/etc/bashrc", "bash配置"},
-		{"/root/.ssh", "root SSH配置"},
-		{"/root/.bashrc", "root bash配置"},
-		{"> /etc/", "重定向到系统配置目录"},
-		{">> /etc/", "追加到系统配置目录"},
-
-		// === 远程脚本执行 ===
-		{"curl | bash", "通过curl执行远程脚本"},
-		{"curl | sh", "通过curl执行远程脚本"},
-		{"curl|bash", "通过curl执行远程脚本（无空格）"},
-		{"curl|sh", "通过curl执行远程脚本（无空格）"},
-		{"wget | bash", "通过wget执行远程脚本"},
-		{"wget | sh", "通过wget执行远程脚本"},
-		{"wget|bash", "通过wget执行远程脚本（无空格）"},
-		{"wget|sh", "通过wget执行远程脚本（无空格）"},
-		{"wget -o- | sh", "通过wget执行远程脚本"},
-		{"curl -s | bash", "通过curl静默执行脚本"},
-		{"curl -fssl | sh", "通过curl执行脚本"},
-
-		// === 恶意代码模式 ===
-		{":(){ :|:& };:", "fork炸弹"},
-		{"fork", "fork炸弹相关"},
-		{".() { .", "bash函数炸弹"},
-		{"while true", "无限循环"},
-		{"for((;;))", "无限循环"},
-
-		// === 设备文件操作 ===
-		{"> /dev/sd", "写入磁盘设备"},
-		{"> /dev/hd", "写入磁盘设备"},
-		{"> /dev/vd", "写入虚拟磁盘"},
-		{"> /dev/nvme", "写入NVMe设备"},
-		{"> /dev/xvd", "写入Xen虚拟磁盘"},
-		{"> /dev/null", "重定向到空设备"},
-		{"< /dev/zero", "从zero设备读取"},
-		{"< /dev/random", "从随机设备读取"},
-
-		// === 内核和模块操作 ===
-		{"insmod", "加载内核模块"},
-		{"rmmod", "卸载内核模块"},
-		{"modprobe -r", "移除内核模块"},
-		{"depmod", "生成模块依赖"},
-		{"sysctl -w", "修改内核参数"},
-		{"echo > /proc/", "修改proc文件系统"},
-		{"echo > /sys/", "修改sys文件系统"},
-
-		// === 挂载操作 ===
-		{"mount -o remount", "重新挂载"},
-		{"mount --bind", "绑定挂载"},
-		{"umount -f", "强制卸载"},
-		{"umount -l", "懒卸载"},
-
-		// === 历史和日志清理 ===
-		{"history -c", "清除命令历史"},
-		{"history -w", "写入历史文件"},
-		{"> ~/.bash_history", "清空bash历史"},
-		{"> /var/log/", "清空日志文件"},
-		{"truncate", "截断文件"},
-		{"echo > /var/log", "清空日志"},
-		{"cat /dev/null >", "清空文件"},
-
-		// === 包管理器删除操作 ===
-		{"apt remove", "APT删除软件包"},
-		{"apt purge", "APT清除软件包"},
-		{"apt autoremove", "APT自动删除"},
-		{"yum remove", "YUM删除软件包"},
-		{"yum erase", "YUM删除软件包"},
-		{"dnf remove", "DNF删除软件包"},
-		{"rpm -e", "RPM删除软件包"},
-		{"dpkg -r", "DPKG删除软件包"},
-		{"dpkg --purge", "DPKG清除软件包"},
-		{"pip uninstall", "PIP卸载包"},
-		{"npm uninstall", "NPM卸载包"},
-		{"gem uninstall", "GEM卸载包"},
-
-		// === 容器和虚拟化 ===
-		{"docker rm", "删除Docker容器"},
-		{"docker rmi", "删除Docker镜像"},
-		{"docker system prune", "清理Docker系统"},
-		{"docker volume rm", "删除Docker卷"},
-		{"docker network rm", "删除Docker网络"},
-		{"kubectl delete", "删除Kubernetes资源"},
-		{"kubectl drain", "驱逐节点"},
-		{"virsh destroy", "销毁虚拟机"},
-		{"virsh undefine", "取消定义虚拟机"},
-		{"lxc delete", "删除LXC容器"},
-
-		// === 数据库危险操作 ===
-		{"drop database", "删除数据库"},
-		{"drop table", "删除数据表"},
-		{"truncate table", "清空数据表"},
-		{"delete from", "删除数据"},
-		{"drop user", "删除数据库用户"},
-
-		// === 编译和代码执行 ===
-		{"gcc -o", "编译C代码"},
-		{"g++ -o", "编译C++代码"},
-		{"python -c", "执行Python代码"},
-		{"python3 -c", "执行Python3代码"},
-		{"perl -e", "执行Perl代码"},
-		{"ruby -e", "执行Ruby代码"},
-		{"php -r", "执行PHP代码"},
-		{"node -e", "执行Node.js代码"},
-		{"eval", "执行动态代码"},
-		{"exec", "执行命令"},
-		{"source /dev/", "source设备文件"},
-		{". /dev/", "点命令执行设备文件"},
-
-		// === SELinux ===
-		{"setenforce 0", "禁用SELinux"},
-		{"setenforce permissive", "SELinux宽容模式"},
-		{"semanage", "SELinux策略管理"},
-
-		// === 加密和压缩 ===
-		{"openssl enc", "OpenSSL加密"},
-		{"gpg --encrypt", "GPG加密"},
-		{"7z a -p", "7z密码压缩"},
-		{"zip -P", "ZIP密码压缩"},
-
-		// === 网络扫描和攻击工具 ===
-		{"nmap", "网络扫描"},
-		{"masscan", "大规模扫描"},
-		{"nc -l", "监听端口"},
-		{"netcat -l", "监听端口"},
-		{"tcpdump", "抓包工具"},
-		{"wireshark", "抓包工具"},
-		{"tshark", "命令行抓包"},
-		{"arpspoof", "ARP欺骗"},
-		{"ettercap", "中间人攻击"},
-		{"hydra", "暴力破解"},
-		{"john", "密码破解"},
-		{"hashcat", "哈希破解"},
-		{"metasploit", "渗透测试框架"},
-		{"sqlmap", "SQL注入工具"},
-
-		// === 其他危险操作 ===
-		{"wget --no-check-certificate", "忽略证书验证"},
-		{"curl -k", "忽略SSL证书"},
-		{"ldconfig", "更新动态链接库缓存"},
-		{"updatedb", "更新文件数据库"},
-		{"grub-install", "安装GRUB"},
-		{"lilo", "LILO引导加载器"},
-		{"dd bs=", "指定块大小的dd"},
-		{"shred -n", "多次覆盖删除"},
-		{"wipe", "安全擦除"},
-		{"srm", "安全删除"},
-		{"dban", "磁盘擦除"},
-		{"badblocks -w", "写入测试坏块"},
-		{"hdparm", "硬盘参数设置"},
-		{"sdparm", "SCSI磁盘参数"},
-	}
-
-	// 检查是否包含危险命令
-	for _, dp := range dangerousPatterns {
-		if strings.Contains(contentLower, dp.pattern) {
-			return fmt.Errorf("命令包含危险操作【%s】，已被系统拦截", dp.desc)
-		}
+	var jobTask model.JobTask
+	if err := h.db.Where("id = ?", taskID).First(&jobTask).Error; err != nil {
+		response.ErrorCode(c, http.StatusNotFound, "任务不存在")
+		return
 	}
 
-	// ============ 检查危险路径模式 ============
-	dangerousPaths := []string{
-		"/boot", "/bin", "/sbin", "/lib", "/lib64",
-		"/usr/bin", "/usr/sbin", "/usr/lib",
-		"/var/lib", "/var/run", "/var/lock",
-		"/dev/sd", "/dev/hd", "/dev/vd", "/dev/nvme",
-		"/proc/sys", "/sys/class", "/sys/module",
+	conn, err := taskStreamUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		return
 	}
+	defer conn.Close()
 
-	for _, path := range dangerousPaths {
-		if strings.Contains(contentLower, path) {
-			return fmt.Errorf("命令涉及系统关键目录【%s】，已被系统拦截", path)
-		}
+	history, events, unsubscribe, running := h.executor.Subscribe(taskID)
+	if !running {
+		// 任务已结束，推送保存的结果
+		h.sendFinishedTask(conn, taskID)
+		return
 	}
+	defer unsubscribe()
 
-	// ============ 检查根目录操作 ============
-	rootPatterns := []string{
-		"/*", "/ ", "/\t", "/\n", "/;", "/|", "/&",
-	}
+	writeRunEvents(conn, history, events)
+}
 
-	for _, pattern := range rootPatterns {
-		if strings.Contains(contentLower, pattern) {
-			return fmt.Errorf("命令涉及根目录操作，已被系统拦截")
+// writeRunEvents 推送执行中任务的历史事件与后续事件，直到任务结束或客户端断开
+func writeRunEvents(conn *websocket.Conn, history []TaskEvent, events chan TaskEvent) {
+	// 客户端断开时结束推送
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
 		}
-	}
+	}()
 
-	// ============ 检查管道和重定向到系统目录 ============
-	if strings.Contains(contentCompact, ">/etc") ||
-	   strings.Contains(contentCompact, ">>/etc") ||
-	   strings.Contains(contentCompact, ">/usr") ||
-	   strings.Contains(contentCompact, ">/var") ||
-	   strings.Contains(contentCompact, ">/boot") {
-		return fmt.Errorf("命令包含重定向到系统目录的操作，已被系统拦截")
+	for _, event := range history {
+		if err := conn.WriteJSON(event); err != nil {
+			return
+		}
 	}
 
-	// ============ 检查别名劫持 ============
-	if strings.Contains(contentLower, "alias ") {
-		return fmt.Errorf("命令包含别名定义，可能导致命令劫持，已被系统拦截")
+	for {
+		select {
+		case <-closed:
+			return
+		case event, ok := <-events:
+			if !ok {
+				// 推送过慢被断开，或任务已结束
+				return
+			}
+			if err := conn.WriteJSON(event); err != nil {
+				return
+			}
+			if event.Type == EventDone {
+				return
+			}
+		}
 	}
+}
 
-	// ============ 检查环境变量污染 ============
-	dangerousEnvPatterns := []string{
-		"export path=",
-		"export ld_library_path=",
-		"export ld_preload=",
+// sendFinishedTask 推送已结束任务的最终结果
+func (h *Handler) sendFinishedTask(conn *websocket.Conn, taskID uint) {
+	var jobTask model.JobTask
+	if err := h.db.Where("id = ?", taskID).First(&jobTask).Error; err != nil {
+		return
 	}
 
-	for _, pattern := range dangerousEnvPatterns {
-		if strings.Contains(contentCompact, pattern) {
-			return fmt.Errorf("命令包含环境变量修改，可能导致系统异常，已被系统拦截")
+	var results []HostExecutionResult
+	if jobTask.Result != "" {
+		_ = json.Unmarshal([]byte(jobTask.Result), &results)
+	}
+	for _, result := range results {
+		if result.Output != "" {
+			conn.WriteJSON(TaskEvent{Type: EventStdout, TaskID: taskID, HostID: result.HostID, HostName: result.HostName, HostIP: result.HostIP, Data: result.Output})
 		}
+		conn.WriteJSON(TaskEvent{Type: EventHostDone, TaskID: taskID, HostID: result.HostID, HostName: result.HostName, HostIP: result.HostIP, Status: result.Status, Error: result.Error})
 	}
-
-	return nil
+	conn.WriteJSON(TaskEvent{Type: EventDone, TaskID: taskID, Status: jobTask.Status})
 }
 
-// executeOnHost 在单个主机上执行任务
-func (h *Handler) executeOnHost(ctx context.Context, hostID uint, scriptType, content string) HostExecutionResult {
+// executeOnHost 在单个主机上执行任务，输出实时写入 stream
+// ctx 超时或取消时终止远程命令
+func (h *Handler) executeOnHost(ctx context.Context, hostID uint, scriptType, content string, stream *hostStream) HostExecutionResult {
 	result := HostExecutionResult{
 		HostID: hostID,
 		Status: "failed",
@@ -965,6 +1038,7 @@ func (h *Handler) executeOnHost(ctx context.Context, hostID uint, scriptType, co
 
 	result.HostName = host.Name
 	result.HostIP = host.IP
+	stream.Started(result)
 
 	// 获取凭证
 	if host.CredentialID == 0 {
@@ -979,13 +1053,13 @@ func (h *Handler) executeOnHost(ctx context.Context, hostID uint, scriptType, co
 	}
 
 	// 解密凭证
-	if err := h.decryptCredential(&credential); err != nil {
+	if err := assetdata.DecryptCredential(ctx, &credential); err != nil {
 		result.Error = fmt.Sprintf("解密凭证失败: %v", err)
 		return result
 	}
 
 	// 建立SSH连接
-	sshClient, err := h.createSSHClient(&host, &credential)
+	sshClient, err := h.createSSHClient(ctx, &host, &credential)
 	if err != nil {
 		result.Error = fmt.Sprintf("SSH连接失败: %v", err)
 		return result
@@ -1009,111 +1083,143 @@ func (h *Handler) executeOnHost(ctx context.Context, hostID uint, scriptType, co
 		cmd = content
 	}
 
+	// 合并保存输出，同时实时推送；stderr 首行的进程组ID标记会被截获
+	var output outputBuffer
+	pgid := &pgidWriter{next: io.MultiWriter(&output, stream.Writer(EventStderr, &result))}
+	session.Stdout = io.MultiWriter(&output, stream.Writer(EventStdout, &result))
+	session.Stderr = pgid
+
 	// 执行命令
-	output, err := session.CombinedOutput(cmd)
+	if err := session.Start(withPGIDReport(cmd)); err != nil {
+		result.Error = fmt.Sprintf("执行失败: %v", err)
+		return result
+	}
+
+	waitCh := make(chan error, 1)
+	go func() {
+		waitCh <- session.Wait()
+	}()
+
+	select {
+	case err = <-waitCh:
+	case <-ctx.Done():
+		// 超时或取消：终止远程进程组并关闭会话
+		h.terminateRemote(sshClient, session, pgid.PGID())
+		<-waitCh
+		result.Output = output.String()
+		if ctx.Err() == context.DeadlineExceeded {
+			result.Error = "执行超时，已终止远程进程"
+			return result
+		}
+		result.Status = "cancelled"
+		result.Error = stream.CancelMessage()
+		return result
+	}
+
+	result.Output = output.String()
 	if err != nil {
 		result.Error = fmt.Sprintf("执行失败: %v", err)
-		result.Output = string(output)
 		return result
 	}
 
 	result.Status = "success"
-	result.Output = string(output)
 	return result
 }
 
-// createSSHClient 创建SSH客户端
-func (h *Handler) createSSHClient(host *assetbiz.Host, credential *assetbiz.Credential) (*ssh.Client, error) {
+// terminateRemote 终止远程命令：发送信号（新版本 sshd 支持），并通过新会话终止整个进程组
+func (h *Handler) terminateRemote(client *sshclient.Lease, session *ssh.Session, pgid int) {
+	session.Signal(ssh.SIGTERM)
+
+	if pgid > 0 {
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			killSession, err := client.NewSession()
+			if err != nil {
+				return
+			}
+			defer killSession.Close()
+			killSession.Run(killProcessGroupCommand(pgid))
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(15 * time.Second):
+		}
+	}
+
+	session.Signal(ssh.SIGKILL)
+	session.Close()
+}
+
+// createSSHClient 从连接池获取SSH连接，使用完毕后调用 Close 归还
+func (h *Handler) createSSHClient(ctx context.Context, host *assetbiz.Host, credential *assetbiz.Credential) (*sshclient.Lease, error) {
 	var authMethods []ssh.AuthMethod
 
 	// 根据凭证类型选择认证方式
+	user := credential.Username
 	switch credential.Type {
 	case "password":
 		authMethods = append(authMethods, ssh.Password(credential.Password))
-	case "private_key":
-		signer, err := ssh.ParsePrivateKey([]byte(credential.PrivateKey))
+	case "key", "private_key":
+		var signer ssh.Signer
+		var err error
+		if credential.Passphrase != "" {
+			signer, err = ssh.ParsePrivateKeyWithPassphrase([]byte(credential.PrivateKey), []byte(credential.Passphrase))
+		} else {
+			signer, err = ssh.ParsePrivateKey([]byte(credential.PrivateKey))
+		}
 		if err != nil {
 			return nil, fmt.Errorf("解析私钥失败: %w", err)
 		}
 		authMethods = append(authMethods, ssh.PublicKeys(signer))
+	case assetbiz.CredentialTypeCertificate:
+		// 证书凭证不保存用户名，使用主机的登录用户
+		if user == "" {
+			user = host.SSHUser
+		}
+		signer, err := assetbiz.SSHCertSigner(ctx, host, user)
+		if err != nil {
+			return nil, fmt.Errorf("签发SSH证书失败: %w", err)
+		}
+		authMethods = append(authMethods, ssh.PublicKeys(signer))
 	default:
 		return nil, fmt.Errorf("不支持的凭证类型: %s", credential.Type)
 	}
 
 	// SSH 配置
 	config := &ssh.ClientConfig{
-		User:            credential.Username,
-		Auth:            authMethods,
-		HostKeyCallback: ssh.InsecureIgnoreHostKey(), // 生产环境应该验证host key
-		Timeout:         30 * time.Second,
+		User:              user,
+		Auth:              authMethods,
+		HostKeyCallback:   sshclient.NewHostKeyCallback(h.hostKeyStore, host.ID),
+		HostKeyAlgorithms: sshclient.HostKeyAlgorithms(h.hostKeyStore, host.ID),
+		Timeout:           30 * time.Second,
 	}
 
-	// 连接
-	addr := fmt.Sprintf("%s:%d", host.IP, host.Port)
-	client, err := ssh.Dial("tcp", addr, config)
+	// 解析跳板机链
+	jumpHosts, err := assetbiz.ResolveJumpHosts(ctx, assetdata.NewHostRepo(h.db), assetdata.NewCredentialRepo(h.db), h.hostKeyStore, host)
 	if err != nil {
 		return nil, err
 	}
 
-	return client, nil
-}
-
-// decryptCredential 解密凭证
-func (h *Handler) decryptCredential(credential *assetbiz.Credential) error {
-	// 解密密码
-	if credential.Password != "" {
-		decrypted, err := h.decrypt(credential.Password)
-		if err != nil {
-			return fmt.Errorf("解密密码失败: %w", err)
-		}
-		credential.Password = decrypted
-	}
-
-	// 解密私钥
-	if credential.PrivateKey != "" {
-		decrypted, err := h.decrypt(credential.PrivateKey)
-		if err != nil {
-			return fmt.Errorf("解密私钥失败: %w", err)
-		}
-		credential.PrivateKey = decrypted
-	}
-
-	return nil
+	// 连接（配置了跳板机时经跳板机建立隧道），同一主机的并发执行共用一条连接
+	addr := fmt.Sprintf("%s:%d", host.IP, host.Port)
+	key := assetbiz.HostPoolKey(ctx, host, credential, user)
+	return sshclient.DefaultPool().Acquire(ctx, key, func() (*ssh.Client, error) {
+		return sshclient.Dial(addr, config, jumpHosts)
+	})
 }
 
-// decrypt 解密
-func (h *Handler) decrypt(ciphertext string) (string, error) {
-	if ciphertext == "" {
-		return "", nil
-	}
-
-	data, err := base64.StdEncoding.DecodeString(ciphertext)
-	if err != nil {
-		return "", err
-	}
-
-	block, err := aes.NewCipher(h.encryptionKey)
-	if err != nil {
-		return "", err
-	}
-
-	gcm, err := cipher.NewGCM(block)
-	if err != nil {
-		return "", err
-	}
-
-	nonceSize := gcm.NonceSize()
-	if len(data) < nonceSize {
-		return "", fmt.Errorf("ciphertext too short")
-	}
-
-	nonce, cipherData := data[:nonceSize], data[nonceSize:]
-	plaintext, err := gcm.Open(nil, nonce, cipherData, nil)
-	if err != nil {
-		return "", err
+// sshCertIdentity 任务创建人的证书身份
+func (h *Handler) sshCertIdentity(userID uint, purpose string, operation uint) assetbiz.SSHCertIdentity {
+	var username string
+	h.db.Table("sys_user").Select("username").Where("id = ?", userID).Scan(&username)
+	return assetbiz.SSHCertIdentity{
+		UserID:    userID,
+		Username:  username,
+		Purpose:   purpose,
+		Operation: operation,
 	}
-
-	return string(plaintext), nil
 }
 
 // shellescape 转义shell命令
@@ -1607,12 +1713,30 @@ func (h *Handler) DistributeFiles(c *gin.Context) {
 		return
 	}
 
+	// 文件传输审计日志的操作人信息
+	operator := auditbiz.SysFileTransferLog{
+		UserID:    createdBy,
+		Direction: auditbiz.TransferUpload,
+		Source:    auditbiz.TransferSourceDistribute,
+		IP:        c.ClientIP(),
+	}
+	if username, exists := c.Get("username"); exists {
+		operator.Username, _ = username.(string)
+	}
+
 	// 执行分发
 	results := make([]FileDistributionResult, 0, len(hostIDs))
 	allSuccess := true
 
+	// 证书凭证的主机按操作人签发证书，需要文件管理权限
+	ctx = assetbiz.WithSSHCertIdentity(ctx, assetbiz.SSHCertIdentity{
+		UserID:    createdBy,
+		Username:  operator.Username,
+		Purpose:   assetbiz.SSHCertPurposeTask,
+		Operation: rbacbiz.PermissionFile,
+	})
 	for _, hostID := range hostIDs {
-		result := h.distributeToHost(ctx, hostID, files, targetPath)
+		result := h.distributeToHost(ctx, hostID, files, targetPath, operator)
 		results = append(results, result)
 		if result.Status != "success" {
 			allSuccess = false
@@ -1635,13 +1759,35 @@ func (h *Handler) DistributeFiles(c *gin.Context) {
 	})
 }
 
-// distributeToHost 分发文件到单个主机
-func (h *Handler) distributeToHost(ctx context.Context, hostID uint, files []*multipart.FileHeader, targetPath string) FileDistributionResult {
+// distributeToHost 分发文件到单个主机，每个文件记录一条文件传输日志
+func (h *Handler) distributeToHost(ctx context.Context, hostID uint, files []*multipart.FileHeader, targetPath string, operator auditbiz.SysFileTransferLog) FileDistributionResult {
 	result := FileDistributionResult{
 		HostID: hostID,
 		Status: "failed",
 	}
 
+	transferLogs := make([]*auditbiz.SysFileTransferLog, len(files))
+	for i, fileHeader := range files {
+		log := operator
+		log.HostID = hostID
+		log.RemotePath = targetPath + "/" + fileHeader.Filename
+		log.Status = auditbiz.TransferFailed
+		transferLogs[i] = &log
+	}
+	// 未传输成功的文件以分发结果的错误信息记为失败
+	defer func() {
+		for _, log := range transferLogs {
+			log.HostName = result.HostName
+			log.HostIP = result.HostIP
+			if log.Status == auditbiz.TransferFailed && log.ErrorMsg == "" {
+				log.ErrorMsg = result.Error
+			}
+			if err := h.transferLogs.Create(context.Background(), log); err != nil {
+				appLogger.Error("保存文件传输日志失败", zap.Error(err), zap.String("path", log.RemotePath))
+			}
+		}
+	}()
+
 	// 获取主机信息
 	var host assetbiz.Host
 	if err := h.db.WithContext(ctx).Where("id = ? AND deleted_at IS NULL", hostID).First(&host).Error; err != nil {
@@ -1665,13 +1811,13 @@ func (h *Handler) distributeToHost(ctx context.Context, hostID uint, files []*mu
 	}
 
 	// 解密凭证
-	if err := h.decryptCredential(&credential); err != nil {
+	if err := assetdata.DecryptCredential(ctx, &credential); err != nil {
 		result.Error = fmt.Sprintf("解密凭证失败: %v", err)
 		return result
 	}
 
 	// 建立SSH连接
-	sshClient, err := h.createSSHClient(&host, &credential)
+	sshClient, err := h.createSSHClient(ctx, &host, &credential)
 	if err != nil {
 		result.Error = fmt.Sprintf("SSH连接失败: %v", err)
 		return result
@@ -1679,7 +1825,7 @@ func (h *Handler) distributeToHost(ctx context.Context, hostID uint, files []*mu
 	defer sshClient.Close()
 
 	// 创建SFTP客户端
-	sftpClient, err := sftp.NewClient(sshClient)
+	sftpClient, err := sftp.NewClient(sshClient.Client)
 	if err != nil {
 		result.Error = fmt.Sprintf("创建SFTP客户端失败: %v", err)
 		return result
@@ -1692,14 +1838,14 @@ func (h *Handler) distributeToHost(ctx context.Context, hostID uint, files []*mu
 	}
 
 	// 上传每个文件
-	for _, fileHeader := range files {
+	for i, fileHeader := range files {
 		srcFile, err := fileHeader.Open()
 		if err != nil {
 			result.Error = fmt.Sprintf("打开文件 %s 失败: %v", fileHeader.Filename, err)
 			return result
 		}
 
-		remotePath := targetPath + "/" + fileHeader.Filename
+		remotePath := transferLogs[i].RemotePath
 		dstFile, err := sftpClient.Create(remotePath)
 		if err != nil {
 			srcFile.Close()
@@ -1707,14 +1853,19 @@ func (h *Handler) distributeToHost(ctx context.Context, hostID uint, files []*mu
 			return result
 		}
 
-		_, err = io.Copy(dstFile, srcFile)
+		// 传输的同时计算内容哈希
+		digest := auditbiz.NewTransferDigest()
+		_, err = io.Copy(dstFile, io.TeeReader(srcFile, digest))
 		srcFile.Close()
 		dstFile.Close()
 
+		transferLogs[i].FileSize = digest.Size()
+		transferLogs[i].SHA256 = digest.Sum()
 		if err != nil {
 			result.Error = fmt.Sprintf("传输文件 %s 失败: %v", fileHeader.Filename, err)
 			return result
 		}
+		transferLogs[i].Status = auditbiz.TransferSuccess
 	}
 
 	result.Status = "success"
@@ -25,14 +25,15 @@ import (
 	"gorm.io/gorm"
 )
 
-func RegisterRoutes(router *gin.RouterGroup, db *gorm.DB) {
-	handler := NewHandler(db)
+// RegisterRoutes 注册路由，handler 与定时任务调度器共享同一个任务执行器
+func RegisterRoutes(router *gin.RouterGroup, handler *Handler) {
 
 	// 任务插件路由组 - 使用 /task 前缀
 	taskGroup := router.Group("/task")
 	{
 		// 任务执行
 		taskGroup.POST("/execute", handler.ExecuteTask)
+		taskGroup.GET("/execute/:id/stream", handler.StreamTaskOutput)
 
 		// 文件分发
 		taskGroup.POST("/distribute", handler.DistributeFiles)
@@ -45,6 +46,7 @@ func RegisterRoutes(router *gin.RouterGroup, db *gorm.DB) {
 			jobs.POST("", handler.CreateJobTask)
 			jobs.PUT("/:id", handler.UpdateJobTask)
 			jobs.DELETE("/:id", handler.DeleteJobTask)
+			jobs.POST("/:id/cancel", handler.CancelJobTask)
 		}
 
 		// 任务模板
@@ -56,6 +58,38 @@ func RegisterRoutes(router *gin.RouterGroup, db *gorm.DB) {
 			templates.POST("", handler.CreateJobTemplate)
 			templates.PUT("/:id", handler.UpdateJobTemplate)
 			templates.DELETE("/:id", handler.DeleteJobTemplate)
+			templates.POST("/:id/execute", handler.ExecuteJobTemplate)
+		}
+
+		// 定时任务
+		schedules := taskGroup.Group("/schedules")
+		{
+			schedules.GET("", handler.ListJobSchedules)
+			schedules.GET("/next-runs", handler.PreviewCronExpr)
+			schedules.GET("/:id", handler.GetJobSchedule)
+			schedules.POST("", handler.CreateJobSchedule)
+			schedules.PUT("/:id", handler.UpdateJobSchedule)
+			schedules.DELETE("/:id", handler.DeleteJobSchedule)
+			schedules.GET("/:id/runs", handler.ListJobScheduleRuns)
+		}
+
+		// 任务审批
+		approvals := taskGroup.Group("/approvals")
+		{
+			approvals.GET("", handler.ListApprovals)
+			approvals.GET("/:id", handler.GetApproval)
+			approvals.POST("/:id/approve", handler.ApproveJobTask)
+			approvals.POST("/:id/reject", handler.RejectJobTask)
+		}
+
+		// 审批策略 - 修改仅管理员
+		approvalPolicies := taskGroup.Group("/approval-policies")
+		{
+			approvalPolicies.GET("", handler.ListApprovalPolicies)
+			approvalPolicies.GET("/:id", handler.GetApprovalPolicy)
+			approvalPolicies.POST("", handler.requireAdmin(), handler.CreateApprovalPolicy)
+			approvalPolicies.PUT("/:id", handler.requireAdmin(), handler.UpdateApprovalPolicy)
+			approvalPolicies.DELETE("/:id", handler.requireAdmin(), handler.DeleteApprovalPolicy)
 		}
 
 		// Ansible任务
@@ -66,6 +100,9 @@ func RegisterRoutes(router *gin.RouterGroup, db *gorm.DB) {
 			ansible.POST("", handler.CreateAnsibleTask)
 			ansible.PUT("/:id", handler.UpdateAnsibleTask)
 			ansible.DELETE("/:id", handler.DeleteAnsibleTask)
+			ansible.POST("/:id/cancel", handler.CancelAnsibleTask)
+			ansible.POST("/:id/run", handler.RunAnsibleTask)
+			ansible.GET("/:id/stream", handler.StreamAnsibleOutput)
 		}
 
 		// 执行记录
@@ -86,5 +123,7 @@ func AutoMigrate(db *gorm.DB) error {
 		&model.JobTask{},
 		&model.JobTemplate{},
 		&model.AnsibleTask{},
+		&model.JobSchedule{},
+		&model.JobApprovalPolicy{},
 	)
 }
@@ -0,0 +1,375 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ydcloud-dy/opshub/pkg/cron"
+	"github.com/ydcloud-dy/opshub/pkg/response"
+	"github.com/ydcloud-dy/opshub/plugins/task/model"
+)
+
+// maxPreviewRuns 预览下次执行时间的最大次数
+const maxPreviewRuns = 20
+
+// ==================== 定时任务 ====================
+
+// ListJobSchedules 获取定时任务列表
+// @Summary 获取定时任务列表
+// @Description 分页获取定时任务列表，支持按关键词、状态筛选
+// @Tags 任务管理-定时任务
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param page query int false "页码" default(1)
+// @Param pageSize query int false "每页数量" default(10)
+// @Param keyword query string false "搜索关键词"
+// @Param status query int false "状态 0-禁用 1-启用"
+// @Success 200 {object} response.Response "获取成功"
+// @Router /task/schedules [get]
+func (h *Handler) ListJobSchedules(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "10"))
+	keyword := c.Query("keyword")
+	status := c.Query("status")
+
+	var schedules []*model.JobSchedule
+	var total int64
+
+	query := h.db.Model(&model.JobSchedule{}).Where("deleted_at IS NULL")
+
+	if keyword != "" {
+		query = query.Where("name LIKE ?", "%"+keyword+"%")
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	query.Count(&total)
+	offset := (page - 1) * pageSize
+	query.Order("created_at DESC").Limit(pageSize).Offset(offset).Find(&schedules)
+
+	response.Success(c, gin.H{
+		"list":     schedules,
+		"total":    total,
+		"page":     page,
+		"pageSize": pageSize,
+	})
+}
+
+// GetJobSchedule 获取定时任务详情
+// @Summary 获取定时任务详情
+// @Description 获取指定定时任务的详细信息
+// @Tags 任务管理-定时任务
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "定时任务ID"
+// @Success 200 {object} response.Response "获取成功"
+// @Failure 404 {object} response.Response "定时任务不存在"
+// @Router /task/schedules/{id} [get]
+func (h *Handler) GetJobSchedule(c *gin.Context) {
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	var schedule model.JobSchedule
+	if err := h.db.Where("id = ? AND deleted_at IS NULL", id).First(&schedule).Error; err != nil {
+		response.ErrorCode(c, http.StatusNotFound, "定时任务不存在")
+		return
+	}
+	response.Success(c, schedule)
+}
+
+// CreateJobSchedule 创建定时任务
+// @Summary 创建定时任务
+// @Description 创建按 cron 表达式周期执行的任务，可直接填写脚本或引用任务模板
+// @Tags 任务管理-定时任务
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param body body model.JobSchedule true "定时任务信息"
+// @Success 200 {object} response.Response "创建成功"
+// @Failure 400 {object} response.Response "参数错误"
+// @Failure 403 {object} response.Response "命令被拦截"
+// @Router /task/schedules [post]
+func (h *Handler) CreateJobSchedule(c *gin.Context) {
+	var schedule model.JobSchedule
+	if err := c.ShouldBindJSON(&schedule); err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "参数错误: "+err.Error())
+		return
+	}
+	schedule.ID = 0
+	schedule.Status = 1
+	schedule.DeletedAt = nil
+	schedule.LastRunTime = nil
+	schedule.LastRunStatus = ""
+	schedule.LastJobTaskID = nil
+
+	schedule.CreatedBy = 1
+	if userID, exists := c.Get("user_id"); exists {
+		if uid, ok := userID.(uint); ok {
+			schedule.CreatedBy = uid
+		}
+	}
+
+	if code, err := h.prepareSchedule(&schedule); err != nil {
+		response.ErrorCode(c, code, err.Error())
+		return
+	}
+
+	if err := h.db.Create(&schedule).Error; err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "创建失败: "+err.Error())
+		return
+	}
+	response.Success(c, schedule)
+}
+
+// UpdateJobSchedule 更新定时任务
+// @Summary 更新定时任务
+// @Description 更新定时任务的配置，下次执行时间按新的表达式重新计算
+// @Tags 任务管理-定时任务
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "定时任务ID"
+// @Param body body model.JobSchedule true "定时任务信息"
+// @Success 200 {object} response.Response "更新成功"
+// @Failure 400 {object} response.Response "参数错误"
+// @Failure 404 {object} response.Response "定时任务不存在"
+// @Router /task/schedules/{id} [put]
+func (h *Handler) UpdateJobSchedule(c *gin.Context) {
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	var existing model.JobSchedule
+	if err := h.db.Where("id = ? AND deleted_at IS NULL", id).First(&existing).Error; err != nil {
+		response.ErrorCode(c, http.StatusNotFound, "定时任务不存在")
+		return
+	}
+
+	schedule := existing
+	if err := c.ShouldBindJSON(&schedule); err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "参数错误: "+err.Error())
+		return
+	}
+	// 执行状态由调度器维护
+	schedule.ID = existing.ID
+	schedule.CreatedBy = existing.CreatedBy
+	schedule.CreatedAt = existing.CreatedAt
+	schedule.DeletedAt = nil
+	schedule.LastRunTime = existing.LastRunTime
+	schedule.LastRunStatus = existing.LastRunStatus
+	schedule.LastJobTaskID = existing.LastJobTaskID
+
+	if code, err := h.prepareSchedule(&schedule); err != nil {
+		response.ErrorCode(c, code, err.Error())
+		return
+	}
+
+	if err := h.db.Save(&schedule).Error; err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "更新失败: "+err.Error())
+		return
+	}
+	response.Success(c, schedule)
+}
+
+// DeleteJobSchedule 删除定时任务
+// @Summary 删除定时任务
+// @Description 删除定时任务，已经开始的执行不受影响，执行记录保留
+// @Tags 任务管理-定时任务
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "定时任务ID"
+// @Success 200 {object} response.Response "删除成功"
+// @Failure 404 {object} response.Response "定时任务不存在"
+// @Router /task/schedules/{id} [delete]
+func (h *Handler) DeleteJobSchedule(c *gin.Context) {
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	result := h.db.Model(&model.JobSchedule{}).Where("id = ? AND deleted_at IS NULL", id).Updates(map[string]interface{}{
+		"deleted_at":    time.Now(),
+		"next_run_time": nil,
+	})
+	if result.Error != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "删除失败: "+result.Error.Error())
+		return
+	}
+	if result.RowsAffected == 0 {
+		response.ErrorCode(c, http.StatusNotFound, "定时任务不存在")
+		return
+	}
+	response.SuccessWithMessage(c, "删除成功", nil)
+}
+
+// ListJobScheduleRuns 获取定时任务执行记录
+// @Summary 获取定时任务执行记录
+// @Description 分页获取定时任务每次触发生成的任务记录，包括被跳过的执行
+// @Tags 任务管理-定时任务
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "定时任务ID"
+// @Param page query int false "页码" default(1)
+// @Param pageSize query int false "每页数量" default(10)
+// @Param status query string false "执行状态"
+// @Success 200 {object} response.Response "获取成功"
+// @Router /task/schedules/{id}/runs [get]
+func (h *Handler) ListJobScheduleRuns(c *gin.Context) {
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "10"))
+	status := c.Query("status")
+
+	var jobTasks []*model.JobTask
+	var total int64
+
+	query := h.db.Model(&model.JobTask{}).Where("schedule_id = ? AND deleted_at IS NULL", id)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	query.Count(&total)
+	offset := (page - 1) * pageSize
+	query.Order("created_at DESC").Limit(pageSize).Offset(offset).Find(&jobTasks)
+
+	response.Success(c, gin.H{
+		"list":     jobTasks,
+		"total":    total,
+		"page":     page,
+		"pageSize": pageSize,
+	})
+}
+
+// PreviewCronExpr 预览 cron 表达式的执行时间
+// @Summary 预览执行时间
+// @Description 校验 cron 表达式并返回接下来的若干次执行时间（服务器时区）
+// @Tags 任务管理-定时任务
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param cronExpr query string true "cron 表达式（分 时 日 月 周）"
+// @Param count query int false "返回次数" default(5)
+// @Success 200 {object} response.Response "获取成功"
+// @Failure 400 {object} response.Response "表达式无效"
+// @Router /task/schedules/next-runs [get]
+func (h *Handler) PreviewCronExpr(c *gin.Context) {
+	count, _ := strconv.Atoi(c.DefaultQuery("count", "5"))
+	if count <= 0 {
+		count = 5
+	}
+	if count > maxPreviewRuns {
+		count = maxPreviewRuns
+	}
+
+	schedule, err := cron.Parse(c.Query("cronExpr"))
+	if err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	response.Success(c, gin.H{
+		"cronExpr": schedule.String(),
+		"nextRuns": schedule.NextN(time.Now(), count),
+	})
+}
+
+// prepareSchedule 校验定时任务配置并计算下次执行时间，返回错误时附带HTTP状态码
+func (h *Handler) prepareSchedule(schedule *model.JobSchedule) (int, error) {
+	if err := cron.Validate(schedule.CronExpr); err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	switch schedule.MissedRunPolicy {
+	case "":
+		schedule.MissedRunPolicy = model.MissedRunSkip
+	case model.MissedRunSkip, model.MissedRunCatchUp:
+	default:
+		return http.StatusBadRequest, fmt.Errorf("无效的错过执行策略: %s", schedule.MissedRunPolicy)
+	}
+
+	switch schedule.ScriptType {
+	case "":
+		schedule.ScriptType = "Shell"
+	case "Shell", "Python":
+	default:
+		return http.StatusBadRequest, fmt.Errorf("不支持的脚本类型: %s", schedule.ScriptType)
+	}
+
+	var hostIDs []uint
+	if err := json.Unmarshal([]byte(schedule.TargetHosts), &hostIDs); err != nil || len(hostIDs) == 0 {
+		return http.StatusBadRequest, fmt.Errorf("请选择目标主机")
+	}
+	policy := commandPolicyRequest{
+		ScriptType: schedule.ScriptType,
+		Content:    schedule.Content,
+		UserID:     schedule.CreatedBy,
+		HostIDs:    hostIDs,
+	}
+
+	if schedule.TemplateID != nil {
+		var template model.JobTemplate
+		if err := h.db.Where("id = ? AND deleted_at IS NULL", *schedule.TemplateID).First(&template).Error; err != nil {
+			return http.StatusBadRequest, fmt.Errorf("任务模板不存在")
+		}
+		// 预先渲染一次，校验保存的变量取值
+		var values map[string]interface{}
+		if schedule.Variables != "" {
+			if err := json.Unmarshal([]byte(schedule.Variables), &values); err != nil {
+				return http.StatusBadRequest, fmt.Errorf("模板变量取值格式错误")
+			}
+		}
+		rendered, err := h.renderTemplate(context.Background(), &template, values)
+		if err != nil {
+			return http.StatusBadRequest, err
+		}
+		schedule.Content = ""
+		policy.Content = rendered.Content
+		policy.TemplateID = template.ID
+	} else if schedule.Content == "" {
+		return http.StatusBadRequest, fmt.Errorf("请填写脚本内容或选择任务模板")
+	}
+	// 定时任务以创建人的身份执行，需要审批的命令在每次执行时提交审批
+	if _, err := h.checkCommandPolicy(context.Background(), policy); err != nil {
+		return http.StatusForbidden, err
+	}
+
+	if schedule.Concurrency <= 0 {
+		schedule.Concurrency = defaultConcurrency
+	}
+	if schedule.Timeout <= 0 {
+		schedule.Timeout = int(defaultHostTimeout / time.Second)
+	}
+
+	schedule.NextRunTime = nil
+	if schedule.Status == 1 {
+		next, err := nextRunTime(schedule.CronExpr, time.Now())
+		if err != nil {
+			return http.StatusBadRequest, err
+		}
+		if next == nil {
+			return http.StatusBadRequest, fmt.Errorf("cron 表达式在未来没有执行时间")
+		}
+		schedule.NextRunTime = next
+	}
+	return 0, nil
+}
@@ -0,0 +1,314 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ydcloud-dy/opshub/pkg/cron"
+	appLogger "github.com/ydcloud-dy/opshub/pkg/logger"
+	"github.com/ydcloud-dy/opshub/plugins/task/model"
+	"go.uber.org/zap"
+)
+
+const (
+	// scheduleCheckInterval 检查到期定时任务的间隔
+	scheduleCheckInterval = 15 * time.Second
+	// missedRunGrace 超过计划时间该时长仍未执行视为错过（如服务停机期间）
+	missedRunGrace = 2 * time.Minute
+)
+
+// Scheduler 定时任务调度器
+// 下次执行时间保存在数据库中，服务重启后按错过执行策略跳过或补执行
+type Scheduler struct {
+	h        *Handler
+	interval time.Duration
+	stopCh   chan struct{}
+	wg       sync.WaitGroup
+	running  bool
+	mu       sync.Mutex
+}
+
+// NewScheduler 创建定时任务调度器
+func NewScheduler(h *Handler) *Scheduler {
+	return &Scheduler{
+		h:        h,
+		interval: scheduleCheckInterval,
+		stopCh:   make(chan struct{}),
+	}
+}
+
+// Start 启动调度器
+func (s *Scheduler) Start() {
+	s.mu.Lock()
+	if s.running {
+		s.mu.Unlock()
+		return
+	}
+	s.running = true
+	s.stopCh = make(chan struct{})
+	s.mu.Unlock()
+
+	// 启动时清理因重启中断的执行记录，并补全缺失的下次执行时间
+	s.cleanupStuckRuns()
+	s.fillNextRunTimes()
+
+	s.wg.Add(1)
+	go s.run()
+
+	appLogger.Info("定时任务调度器已启动", zap.Duration("interval", s.interval))
+}
+
+// Stop 停止调度器，不会中断已经开始执行的任务
+func (s *Scheduler) Stop() {
+	s.mu.Lock()
+	if !s.running {
+		s.mu.Unlock()
+		return
+	}
+	s.running = false
+	close(s.stopCh)
+	s.mu.Unlock()
+
+	s.wg.Wait()
+	appLogger.Info("定时任务调度器已停止")
+}
+
+// run 运行调度循环
+func (s *Scheduler) run() {
+	defer s.wg.Done()
+
+	s.checkDue()
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.checkDue()
+		case <-s.stopCh:
+			return
+		}
+	}
+}
+
+// cleanupStuckRuns 将状态为执行中、但没有在本服务中执行的记录标记为失败，
+// 包括服务重启前未结束的手动、定时和审批通过后的执行，以及Ansible任务
+func (s *Scheduler) cleanupStuckRuns() {
+	ansibleTaskIDs, ansibleJobTaskIDs := s.h.ansible.Active()
+	jobTaskIDs := append(s.h.executor.Active(), ansibleJobTaskIDs...)
+
+	query := s.h.db.Model(&model.JobTask{}).Where("status = ?", "running")
+	if len(jobTaskIDs) > 0 {
+		query = query.Where("id NOT IN ?", jobTaskIDs)
+	}
+	result := query.Updates(map[string]interface{}{
+		"status":        "failed",
+		"error_message": "任务因服务重启而中断",
+	})
+	if result.Error != nil {
+		appLogger.Error("清理中断的任务执行记录失败", zap.Error(result.Error))
+	} else if result.RowsAffected > 0 {
+		appLogger.Info("已清理中断的任务执行记录", zap.Int64("count", result.RowsAffected))
+	}
+
+	query = s.h.db.Model(&model.AnsibleTask{}).Where("status = ?", "running")
+	if len(ansibleTaskIDs) > 0 {
+		query = query.Where("id NOT IN ?", ansibleTaskIDs)
+	}
+	result = query.Update("status", "failed")
+	if result.Error != nil {
+		appLogger.Error("清理中断的Ansible任务失败", zap.Error(result.Error))
+	} else if result.RowsAffected > 0 {
+		appLogger.Info("已清理中断的Ansible任务", zap.Int64("count", result.RowsAffected))
+	}
+}
+
+// fillNextRunTimes 为启用但没有下次执行时间的定时任务计算下次执行时间
+func (s *Scheduler) fillNextRunTimes() {
+	var schedules []model.JobSchedule
+	if err := s.h.db.Where("status = 1 AND deleted_at IS NULL AND next_run_time IS NULL").Find(&schedules).Error; err != nil {
+		appLogger.Error("查询定时任务失败", zap.Error(err))
+		return
+	}
+	now := time.Now()
+	for _, schedule := range schedules {
+		next, err := nextRunTime(schedule.CronExpr, now)
+		if err != nil {
+			appLogger.Warn("定时任务表达式无效", zap.Uint("scheduleId", schedule.ID), zap.Error(err))
+			continue
+		}
+		s.h.db.Model(&model.JobSchedule{}).Where("id = ?", schedule.ID).Update("next_run_time", next)
+	}
+}
+
+// checkDue 执行所有到期的定时任务
+func (s *Scheduler) checkDue() {
+	now := time.Now()
+	var schedules []model.JobSchedule
+	if err := s.h.db.Where("status = 1 AND deleted_at IS NULL AND next_run_time IS NOT NULL AND next_run_time <= ?", now).
+		Order("next_run_time").Find(&schedules).Error; err != nil {
+		appLogger.Error("查询到期定时任务失败", zap.Error(err))
+		return
+	}
+
+	for i := range schedules {
+		s.fire(&schedules[i], now)
+	}
+}
+
+// fire 触发一次定时任务
+func (s *Scheduler) fire(schedule *model.JobSchedule, now time.Time) {
+	next, err := nextRunTime(schedule.CronExpr, now)
+	if err != nil {
+		appLogger.Warn("定时任务表达式无效，已停止调度", zap.Uint("scheduleId", schedule.ID), zap.Error(err))
+	}
+
+	// 以原下次执行时间为条件推进，避免同一次计划被重复触发
+	claim := s.h.db.Model(&model.JobSchedule{}).
+		Where("id = ? AND next_run_time = ?", schedule.ID, schedule.NextRunTime).
+		Update("next_run_time", next)
+	if claim.Error != nil || claim.RowsAffected == 0 || err != nil {
+		return
+	}
+
+	scheduledAt := *schedule.NextRunTime
+	switch {
+	case now.Sub(scheduledAt) > missedRunGrace && schedule.MissedRunPolicy != model.MissedRunCatchUp:
+		s.h.recordSkippedRun(schedule, fmt.Sprintf("错过计划执行时间 %s，按策略跳过", scheduledAt.Format("2006-01-02 15:04:05")))
+	case schedule.LastJobTaskID != nil && s.h.executor.Running(*schedule.LastJobTaskID):
+		s.h.recordSkippedRun(schedule, fmt.Sprintf("上一次执行（任务ID %d）尚未结束，跳过本次执行", *schedule.LastJobTaskID))
+	case schedule.LastJobTaskID != nil && schedule.LastRunStatus == model.JobStatusPendingApproval:
+		s.h.recordSkippedRun(schedule, fmt.Sprintf("上一次执行（任务ID %d）等待审批中，跳过本次执行", *schedule.LastJobTaskID))
+	default:
+		if _, err := s.h.runSchedule(schedule); err != nil {
+			appLogger.Error("执行定时任务失败", zap.Uint("scheduleId", schedule.ID), zap.Error(err))
+		}
+	}
+}
+
+// nextRunTime 计算 after 之后的下次执行时间，没有下次执行时间时返回 nil
+func nextRunTime(cronExpr string, after time.Time) (*time.Time, error) {
+	schedule, err := cron.Parse(cronExpr)
+	if err != nil {
+		return nil, err
+	}
+	next := schedule.Next(after)
+	if next.IsZero() {
+		return nil, nil
+	}
+	return &next, nil
+}
+
+// runSchedule 执行一次定时任务，生成一条 TaskType 为 cron 的任务记录，命中审批策略时等待审批
+func (h *Handler) runSchedule(schedule *model.JobSchedule) (*model.JobTask, error) {
+	now := time.Now()
+	jobTask := model.JobTask{
+		Name:        fmt.Sprintf("%s - %s", schedule.Name, now.Format("2006-01-02 15:04:05")),
+		TemplateID:  schedule.TemplateID,
+		ScheduleID:  &schedule.ID,
+		TaskType:    "cron",
+		TargetHosts: schedule.TargetHosts,
+		Parameters:  "",
+		CreatedBy:   schedule.CreatedBy,
+	}
+
+	spec, run, err := h.resolveSchedule(schedule)
+	if err != nil {
+		jobTask.Status = "failed"
+		jobTask.ErrorMessage = err.Error()
+		jobTask.ExecuteTime = &now
+		if err := h.db.Create(&jobTask).Error; err != nil {
+			return nil, fmt.Errorf("创建任务记录失败: %w", err)
+		}
+	} else {
+		// 任务ID在创建记录后才确定，回调中通过 jobTask 读取
+		run.options.OnDone = func(status string) {
+			h.updateScheduleRunStatus(schedule.ID, jobTask.ID, status)
+		}
+		if _, err := h.submitRun(&jobTask, spec, run); err != nil {
+			return nil, err
+		}
+	}
+
+	updates := map[string]interface{}{
+		"last_run_time":    &now,
+		"last_run_status":  jobTask.Status,
+		"last_job_task_id": jobTask.ID,
+	}
+	h.db.Model(&model.JobSchedule{}).Where("id = ?", schedule.ID).Updates(updates)
+	return &jobTask, nil
+}
+
+// recordSkippedRun 记录被跳过的一次定时执行
+func (h *Handler) recordSkippedRun(schedule *model.JobSchedule, reason string) {
+	now := time.Now()
+	jobTask := model.JobTask{
+		Name:         fmt.Sprintf("%s - %s", schedule.Name, now.Format("2006-01-02 15:04:05")),
+		TemplateID:   schedule.TemplateID,
+		ScheduleID:   &schedule.ID,
+		TaskType:     "cron",
+		Status:       "skipped",
+		TargetHosts:  schedule.TargetHosts,
+		Parameters:   "",
+		ErrorMessage: reason,
+		CreatedBy:    schedule.CreatedBy,
+		ExecuteTime:  &now,
+	}
+	if err := h.db.Create(&jobTask).Error; err != nil {
+		appLogger.Error("记录定时任务跳过失败", zap.Uint("scheduleId", schedule.ID), zap.Error(err))
+	}
+	appLogger.Info("定时任务本次执行已跳过", zap.Uint("scheduleId", schedule.ID), zap.String("reason", reason))
+}
+
+// resolveSchedule 按定时任务配置生成执行参数，引用模板时按保存的变量取值渲染
+// 模板内容和命令规则可能在创建定时任务后被修改，每次执行前重新检查
+func (h *Handler) resolveSchedule(schedule *model.JobSchedule) (*executionSpec, *preparedRun, error) {
+	spec := &executionSpec{
+		ScriptType:  schedule.ScriptType,
+		Content:     schedule.Content,
+		Concurrency: schedule.Concurrency,
+		Timeout:     schedule.Timeout,
+	}
+	if err := json.Unmarshal([]byte(schedule.TargetHosts), &spec.HostIDs); err != nil || len(spec.HostIDs) == 0 {
+		return nil, nil, fmt.Errorf("未配置目标主机")
+	}
+
+	if schedule.TemplateID != nil {
+		spec.TemplateID = *schedule.TemplateID
+		spec.Content = ""
+		if schedule.Variables != "" {
+			if err := json.Unmarshal([]byte(schedule.Variables), &spec.Variables); err != nil {
+				return nil, nil, fmt.Errorf("模板变量取值格式错误: %w", err)
+			}
+		}
+	}
+
+	run, _, err := h.prepareRun(context.Background(), spec, schedule.CreatedBy)
+	if err != nil {
+		return nil, nil, err
+	}
+	return spec, run, nil
+}
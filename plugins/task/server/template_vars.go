@@ -0,0 +1,365 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	assetbiz "github.com/ydcloud-dy/opshub/internal/biz/asset"
+	assetdata "github.com/ydcloud-dy/opshub/internal/data/asset"
+	"github.com/ydcloud-dy/opshub/plugins/task/model"
+)
+
+// secretMask 敏感值的掩码
+const secretMask = "******"
+
+var (
+	// varNameRegexp 合法的变量名
+	varNameRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	// placeholderRegexp 模板中的变量占位符 {{varName}}
+	placeholderRegexp = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+)
+
+// legacyVarTypes 旧版模板参数类型与变量类型的对应关系
+var legacyVarTypes = map[string]string{
+	"":         model.VarTypeString,
+	"text":     model.VarTypeString,
+	"password": model.VarTypeSecret,
+	"select":   model.VarTypeEnum,
+}
+
+// renderedTemplate 渲染后的模板
+type renderedTemplate struct {
+	Content string
+	// Parameters 变量取值，敏感变量只记录凭证引用，可直接保存到任务参数
+	Parameters map[string]interface{}
+	// Secrets 渲染进脚本的敏感值，用于输出脱敏
+	Secrets []string
+}
+
+// parseTemplateVariables 解析模板变量定义，兼容旧版的 text/password/select 类型
+func parseTemplateVariables(raw string) ([]model.TemplateVariable, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" || raw == "null" || raw == "[]" {
+		return nil, nil
+	}
+
+	var variables []model.TemplateVariable
+	if err := json.Unmarshal([]byte(raw), &variables); err != nil {
+		return nil, fmt.Errorf("模板变量格式错误: %w", err)
+	}
+	for i := range variables {
+		if t, ok := legacyVarTypes[variables[i].Type]; ok {
+			variables[i].Type = t
+		}
+	}
+	return variables, nil
+}
+
+// validateTemplateVariables 校验模板变量定义，返回规范化后的 JSON
+func validateTemplateVariables(raw string) (string, error) {
+	variables, err := parseTemplateVariables(raw)
+	if err != nil {
+		return "", err
+	}
+
+	seen := make(map[string]bool, len(variables))
+	for _, v := range variables {
+		if !varNameRegexp.MatchString(v.VarName) {
+			return "", fmt.Errorf("变量名无效: %q，只能包含字母、数字和下划线且不能以数字开头", v.VarName)
+		}
+		if seen[v.VarName] {
+			return "", fmt.Errorf("变量名重复: %s", v.VarName)
+		}
+		seen[v.VarName] = true
+
+		switch v.Type {
+		case model.VarTypeString:
+			if v.Pattern != "" {
+				re, err := regexp.Compile(v.Pattern)
+				if err != nil {
+					return "", fmt.Errorf("变量 %s 的校验正则无效: %w", v.VarName, err)
+				}
+				if v.DefaultValue != "" && !re.MatchString(v.DefaultValue) {
+					return "", fmt.Errorf("变量 %s 的默认值不符合校验规则", v.VarName)
+				}
+			}
+		case model.VarTypeInt:
+			if v.Min != nil && v.Max != nil && *v.Min > *v.Max {
+				return "", fmt.Errorf("变量 %s 的最小值大于最大值", v.VarName)
+			}
+			if v.DefaultValue != "" {
+				if _, err := parseIntVariable(v, v.DefaultValue); err != nil {
+					return "", err
+				}
+			}
+		case model.VarTypeEnum:
+			if len(v.Options) == 0 {
+				return "", fmt.Errorf("枚举变量 %s 未配置可选值", v.VarName)
+			}
+			if v.DefaultValue != "" && !slices.Contains(v.Options, v.DefaultValue) {
+				return "", fmt.Errorf("变量 %s 的默认值不在可选值中", v.VarName)
+			}
+		case model.VarTypeSecret:
+			// 敏感值只能引用加密存储的凭证，不允许明文写在模板中
+			if v.DefaultValue != "" {
+				return "", fmt.Errorf("敏感变量 %s 不能设置明文默认值，请选择凭证", v.VarName)
+			}
+		case model.VarTypeHosts:
+			if v.DefaultValue != "" {
+				if _, err := parseHostIDs(v.DefaultValue); err != nil {
+					return "", fmt.Errorf("变量 %s 的默认值无效: %w", v.VarName, err)
+				}
+			}
+		default:
+			return "", fmt.Errorf("变量 %s 的类型不支持: %s", v.VarName, v.Type)
+		}
+	}
+
+	if len(variables) == 0 {
+		return "[]", nil
+	}
+	data, _ := json.Marshal(variables)
+	return string(data), nil
+}
+
+// renderTemplate 校验变量取值并渲染模板内容，未传入的变量使用默认值
+func (h *Handler) renderTemplate(ctx context.Context, template *model.JobTemplate, values map[string]interface{}) (*renderedTemplate, error) {
+	variables, err := parseTemplateVariables(template.Variables)
+	if err != nil {
+		return nil, err
+	}
+
+	defined := make(map[string]bool, len(variables))
+	for _, v := range variables {
+		defined[v.VarName] = true
+	}
+	for name := range values {
+		if !defined[name] {
+			return nil, fmt.Errorf("模板未定义变量: %s", name)
+		}
+	}
+
+	rendered := &renderedTemplate{Parameters: make(map[string]interface{}, len(variables))}
+	resolved := make(map[string]string, len(variables))
+	for _, v := range variables {
+		value, err := variableValue(values[v.VarName])
+		if err != nil {
+			return nil, fmt.Errorf("变量 %s: %w", v.VarName, err)
+		}
+		if value == "" {
+			value = v.DefaultValue
+			if v.Type == model.VarTypeSecret && v.CredentialID > 0 {
+				value = strconv.FormatUint(uint64(v.CredentialID), 10)
+			}
+		}
+		if value == "" {
+			if v.Required {
+				return nil, fmt.Errorf("请填写变量: %s", displayName(v))
+			}
+			resolved[v.VarName] = ""
+			continue
+		}
+
+		switch v.Type {
+		case model.VarTypeString:
+			if strings.ContainsAny(value, "\r\n\x00") {
+				return nil, fmt.Errorf("变量 %s 不能包含换行等控制字符", displayName(v))
+			}
+			if v.Pattern != "" {
+				re, err := regexp.Compile(v.Pattern)
+				if err != nil || !re.MatchString(value) {
+					return nil, fmt.Errorf("变量 %s 的值不符合校验规则", displayName(v))
+				}
+			}
+			resolved[v.VarName] = value
+			rendered.Parameters[v.VarName] = value
+		case model.VarTypeInt:
+			n, err := parseIntVariable(v, value)
+			if err != nil {
+				return nil, err
+			}
+			resolved[v.VarName] = strconv.Itoa(n)
+			rendered.Parameters[v.VarName] = n
+		case model.VarTypeEnum:
+			if !slices.Contains(v.Options, value) {
+				return nil, fmt.Errorf("变量 %s 的值不在可选值中", displayName(v))
+			}
+			resolved[v.VarName] = value
+			rendered.Parameters[v.VarName] = value
+		case model.VarTypeSecret:
+			credentialID, err := strconv.ParseUint(value, 10, 32)
+			if err != nil {
+				return nil, fmt.Errorf("敏感变量 %s 只能引用凭证", displayName(v))
+			}
+			secret, err := h.credentialSecret(ctx, uint(credentialID))
+			if err != nil {
+				return nil, fmt.Errorf("变量 %s: %w", displayName(v), err)
+			}
+			resolved[v.VarName] = secret
+			rendered.Parameters[v.VarName] = fmt.Sprintf("%s(凭证#%d)", secretMask, credentialID)
+			rendered.Secrets = append(rendered.Secrets, secret)
+		case model.VarTypeHosts:
+			hostIDs, err := parseHostIDs(value)
+			if err != nil {
+				return nil, fmt.Errorf("变量 %s: %w", displayName(v), err)
+			}
+			ips, err := h.hostIPs(ctx, hostIDs)
+			if err != nil {
+				return nil, fmt.Errorf("变量 %s: %w", displayName(v), err)
+			}
+			resolved[v.VarName] = strings.Join(ips, " ")
+			rendered.Parameters[v.VarName] = hostIDs
+		default:
+			return nil, fmt.Errorf("变量 %s 的类型不支持: %s", v.VarName, v.Type)
+		}
+	}
+
+	rendered.Content = placeholderRegexp.ReplaceAllStringFunc(template.Content, func(placeholder string) string {
+		name := placeholderRegexp.FindStringSubmatch(placeholder)[1]
+		if value, ok := resolved[name]; ok {
+			return value
+		}
+		return placeholder
+	})
+	return rendered, nil
+}
+
+// credentialSecret 获取凭证中的敏感值：密码凭证返回密码，密钥凭证返回私钥
+func (h *Handler) credentialSecret(ctx context.Context, credentialID uint) (string, error) {
+	var credential assetbiz.Credential
+	if err := h.db.WithContext(ctx).Where("id = ?", credentialID).First(&credential).Error; err != nil {
+		return "", fmt.Errorf("凭证不存在")
+	}
+	if credential.IsCertificate() {
+		return "", fmt.Errorf("证书凭证不保存密钥，无法作为变量引用")
+	}
+	if err := assetdata.DecryptCredential(ctx, &credential); err != nil {
+		return "", err
+	}
+
+	secret := credential.Password
+	if credential.Type == "key" {
+		secret = credential.PrivateKey
+	}
+	if secret == "" {
+		return "", fmt.Errorf("凭证内容为空")
+	}
+	return secret, nil
+}
+
+// hostIPs 按传入顺序获取主机IP
+func (h *Handler) hostIPs(ctx context.Context, hostIDs []uint) ([]string, error) {
+	var hosts []assetbiz.Host
+	if err := h.db.WithContext(ctx).Where("id IN ?", hostIDs).Find(&hosts).Error; err != nil {
+		return nil, fmt.Errorf("获取主机失败: %w", err)
+	}
+	byID := make(map[uint]string, len(hosts))
+	for _, host := range hosts {
+		byID[host.ID] = host.IP
+	}
+
+	ips := make([]string, 0, len(hostIDs))
+	for _, id := range hostIDs {
+		ip, ok := byID[id]
+		if !ok {
+			return nil, fmt.Errorf("主机 %d 不存在", id)
+		}
+		ips = append(ips, ip)
+	}
+	return ips, nil
+}
+
+// variableValue 将请求中的变量值转换为字符串，主机列表转换为逗号分隔的ID
+func variableValue(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return strings.TrimSpace(v), nil
+	case float64:
+		if v != float64(int64(v)) {
+			return "", fmt.Errorf("不支持小数")
+		}
+		return strconv.FormatInt(int64(v), 10), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case []interface{}:
+		parts := make([]string, 0, len(v))
+		for _, item := range v {
+			s, err := variableValue(item)
+			if err != nil {
+				return "", err
+			}
+			parts = append(parts, s)
+		}
+		return strings.Join(parts, ","), nil
+	default:
+		return "", fmt.Errorf("不支持的取值类型")
+	}
+}
+
+// parseIntVariable 解析整数变量并校验范围
+func parseIntVariable(v model.TemplateVariable, value string) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("变量 %s 必须是整数", displayName(v))
+	}
+	if v.Min != nil && n < *v.Min {
+		return 0, fmt.Errorf("变量 %s 不能小于 %d", displayName(v), *v.Min)
+	}
+	if v.Max != nil && n > *v.Max {
+		return 0, fmt.Errorf("变量 %s 不能大于 %d", displayName(v), *v.Max)
+	}
+	return n, nil
+}
+
+// parseHostIDs 解析逗号分隔的主机ID列表
+func parseHostIDs(value string) ([]uint, error) {
+	var hostIDs []uint
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		id, err := strconv.ParseUint(part, 10, 32)
+		if err != nil || id == 0 {
+			return nil, fmt.Errorf("无效的主机ID: %s", part)
+		}
+		hostIDs = append(hostIDs, uint(id))
+	}
+	if len(hostIDs) == 0 {
+		return nil, fmt.Errorf("主机列表为空")
+	}
+	return hostIDs, nil
+}
+
+func displayName(v model.TemplateVariable) string {
+	if v.Name != "" {
+		return v.Name
+	}
+	return v.VarName
+}
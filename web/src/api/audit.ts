@@ -74,3 +74,32 @@ export const deleteDataLog = (id: number) => {
 export const deleteDataLogsBatch = (ids: number[]) => {
   return request.post('/api/v1/audit/data-logs/batch-delete', { ids })
 }
+
+// 文件传输日志相关接口
+export interface FileTransferLogQuery {
+  page?: number
+  pageSize?: number
+  username?: string
+  host?: string
+  direction?: string
+  status?: string
+  remotePath?: string
+  sha256?: string
+  startTime?: string
+  endTime?: string
+}
+
+export const getFileTransferLogList = (params: FileTransferLogQuery) => {
+  return request.get('/api/v1/audit/file-transfer-logs', { params })
+}
+
+export const getFileTransferLogDetail = (id: number) => {
+  return request.get(`/api/v1/audit/file-transfer-logs/${id}`)
+}
+
+export const exportFileTransferLogs = (params: FileTransferLogQuery) => {
+  return request.get('/api/v1/audit/file-transfer-logs/export', {
+    params,
+    responseType: 'blob'
+  })
+}
@@ -0,0 +1,73 @@
+import request from '@/utils/request'
+
+export type CommandRuleAction = 'allow' | 'deny' | 'approve'
+export type CommandRuleMatchType = 'command' | 'regex' | 'redirect' | 'pipeline' | 'dynamic'
+export type CommandRuleScopeType = 'global' | 'role' | 'asset_group' | 'template'
+
+export interface CommandRule {
+  id?: number
+  name: string
+  action: CommandRuleAction
+  matchType: CommandRuleMatchType
+  pattern: string
+  scopeType: CommandRuleScopeType
+  scopeId: number
+  priority: number
+  status: number
+  description?: string
+}
+
+export interface CommandCheckRequest {
+  content: string
+  userId?: number
+  hostIds?: number[]
+  templateId?: number
+}
+
+export interface CommandCheckItem {
+  line: number
+  command: string
+  action: CommandRuleAction
+  ruleId?: number
+  ruleName?: string
+  matchType?: CommandRuleMatchType
+  pattern?: string
+  scopeType?: CommandRuleScopeType
+  scopeId?: number
+}
+
+export interface CommandCheckResult {
+  action: CommandRuleAction
+  reason: string
+  commands: CommandCheckItem[]
+}
+
+// 获取命令规则列表
+export const getCommandRules = (params: any) => {
+  return request.get('/api/v1/command-rules', { params })
+}
+
+// 获取命令规则详情
+export const getCommandRule = (id: number) => {
+  return request.get(`/api/v1/command-rules/${id}`)
+}
+
+// 创建命令规则
+export const createCommandRule = (data: CommandRule) => {
+  return request.post('/api/v1/command-rules', data)
+}
+
+// 更新命令规则
+export const updateCommandRule = (id: number, data: CommandRule) => {
+  return request.put(`/api/v1/command-rules/${id}`, data)
+}
+
+// 删除命令规则
+export const deleteCommandRule = (id: number) => {
+  return request.delete(`/api/v1/command-rules/${id}`)
+}
+
+// 命令策略试运行：返回每条命令命中的规则
+export const checkCommand = (data: CommandCheckRequest) => {
+  return request.post('/api/v1/command-rules/check', data)
+}
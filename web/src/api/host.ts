@@ -46,6 +46,47 @@ export const deleteCredential = (id: number) => {
   return request.delete(`/api/v1/credentials/${id}`)
 }
 
+export const getCredentialSecretAccesses = (id: number, params: any) => {
+  return request.get(`/api/v1/credentials/${id}/secret-accesses`, { params })
+}
+
+export const rotateCredential = (id: number) => {
+  return request.post(`/api/v1/credentials/${id}/rotate`)
+}
+
+export const getCredentialRotations = (id: number, params: any) => {
+  return request.get(`/api/v1/credentials/${id}/rotations`, { params })
+}
+
+export const getCredentialRotation = (rotationId: number) => {
+  return request.get(`/api/v1/credentials/rotations/${rotationId}`)
+}
+
+export const retryCredentialRotationRollback = (rotationId: number) => {
+  return request.post(`/api/v1/credentials/rotations/${rotationId}/rollback`)
+}
+
+// SSH证书签发
+export const getSSHCAs = () => {
+  return request.get('/api/v1/ssh-ca')
+}
+
+export const createSSHCA = (data: any) => {
+  return request.post('/api/v1/ssh-ca', data)
+}
+
+export const activateSSHCA = (id: number) => {
+  return request.post(`/api/v1/ssh-ca/${id}/activate`)
+}
+
+export const deleteSSHCA = (id: number) => {
+  return request.delete(`/api/v1/ssh-ca/${id}`)
+}
+
+export const getSSHCertificates = (params: any) => {
+  return request.get('/api/v1/ssh-ca/certificates', { params })
+}
+
 // 云平台账号管理
 export const getCloudAccountList = (params: any) => {
   return request.get('/api/v1/cloud-accounts', { params })
@@ -83,6 +124,18 @@ export const getCloudRegions = (accountId: number) => {
   return request.get(`/api/v1/cloud-accounts/${accountId}/regions`)
 }
 
+export const syncCloudAccount = (id: number) => {
+  return request.post(`/api/v1/cloud-accounts/${id}/sync`)
+}
+
+export const getCloudSyncRuns = (id: number, params: any) => {
+  return request.get(`/api/v1/cloud-accounts/${id}/sync-runs`, { params })
+}
+
+export const getCloudSyncRun = (runId: number) => {
+  return request.get(`/api/v1/cloud-accounts/sync-runs/${runId}`)
+}
+
 // 采集主机信息
 export const collectHostInfo = (id: number) => {
   return request.post(`/api/v1/hosts/${id}/collect`)
@@ -140,6 +193,80 @@ export const downloadHostFile = (hostId: number, path: string) => {
   })
 }
 
-export const deleteHostFile = (hostId: number, path: string) => {
-  return request.delete(`/api/v1/hosts/${hostId}/files`, { data: { path } })
+// 删除文件；递归删除目录时 confirm 需为目录名
+export const deleteHostFile = (hostId: number, path: string, options?: { recursive?: boolean; confirm?: string }) => {
+  return request.delete(`/api/v1/hosts/${hostId}/files`, { data: { path, ...options } })
+}
+
+// 打包下载目录
+export const downloadHostArchive = (hostId: number, path: string, format: 'tar.gz' | 'zip' = 'tar.gz') => {
+  return request.get(`/api/v1/hosts/${hostId}/files/archive`, {
+    params: { path, format },
+    responseType: 'blob'
+  })
+}
+
+// 重命名或移动文件
+export const renameHostFile = (hostId: number, oldPath: string, newPath: string) => {
+  return request.put(`/api/v1/hosts/${hostId}/files/rename`, { oldPath, newPath })
+}
+
+// 修改文件权限，mode 为八进制字符串
+export const chmodHostFile = (hostId: number, path: string, mode: string, recursive = false) => {
+  return request.put(`/api/v1/hosts/${hostId}/files/chmod`, { path, mode, recursive })
+}
+
+// 修改文件属主，owner/group 可以是名称或数字ID
+export const chownHostFile = (hostId: number, path: string, owner: string, group: string, recursive = false) => {
+  return request.put(`/api/v1/hosts/${hostId}/files/chown`, { path, owner, group, recursive })
+}
+
+// 查询分片上传进度，用于断点续传
+export const getHostUploadStatus = (hostId: number, path: string, filename: string) => {
+  return request.get(`/api/v1/hosts/${hostId}/files/upload/status`, { params: { path, filename } })
+}
+
+// 上传文件分片
+export const uploadHostFileChunk = (hostId: number, chunk: Blob, path: string, filename: string, offset: number, total: number) => {
+  return request.post(`/api/v1/hosts/${hostId}/files/upload/chunk`, chunk, {
+    params: { path, filename, offset, total },
+    headers: { 'Content-Type': 'application/octet-stream' },
+    timeout: 300000 // 5分钟超时
+  })
+}
+
+// 读取文本文件用于在线编辑
+export const getHostFileContent = (hostId: number, path: string) => {
+  return request.get(`/api/v1/hosts/${hostId}/files/content`, { params: { path } })
+}
+
+// 保存在线编辑的文件，mtime 为读取时返回的修改时间
+export const saveHostFileContent = (hostId: number, path: string, content: string, mtime: number) => {
+  return request.put(`/api/v1/hosts/${hostId}/files/content`, { path, content, mtime })
+}
+
+// 监控指标粒度：raw 原始样本，5m/1h 为降采样数据，不传时按时间范围自动选择
+export type MetricResolution = 'raw' | '5m' | '1h'
+
+export interface HostMetricQuery {
+  start?: number // 秒级时间戳
+  end?: number
+  resolution?: MetricResolution
+}
+
+// 主机监控指标历史
+export const getHostMetrics = (hostId: number, params: HostMetricQuery) => {
+  return request.get(`/api/v1/hosts/${hostId}/metrics`, { params })
+}
+
+// 多主机同一指标对比，metric 可选 cpu/cpuMax/memory/disk/load1/load5/load15/netRx/netTx
+export const compareHostMetrics = (hostIds: number[], metric: string, params: HostMetricQuery) => {
+  return request.get('/api/v1/hosts/metrics/compare', {
+    params: { hostIds: hostIds.join(','), metric, ...params }
+  })
+}
+
+// 主机可用性：在线率、状态区间和状态变化记录，默认最近24小时
+export const getHostAvailability = (hostId: number, params?: { start?: number; end?: number }) => {
+  return request.get(`/api/v1/hosts/${hostId}/availability`, { params })
 }
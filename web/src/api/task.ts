@@ -7,19 +7,22 @@ export interface ExecuteTaskRequest {
   scriptType: string // Shell, Python
   content: string
   name?: string
+  concurrency?: number // 并发主机数，默认10
+  timeout?: number // 单主机超时（秒），默认600
 }
 
 export interface HostExecutionResult {
   hostId: number
   hostName: string
   hostIp: string
-  status: string // success, failed
+  status: string // pending, running, success, failed
   output: string
   error?: string
 }
 
 export interface ExecuteTaskResponse {
   taskId: number
+  status: string // running, pending_approval（命中审批策略，审批通过后执行）
   results: HostExecutionResult[]
 }
 
@@ -27,6 +30,28 @@ export const executeTask = (data: ExecuteTaskRequest) => {
   return request.post<any, ExecuteTaskResponse>('/api/v1/plugins/task/execute', data)
 }
 
+// 任务执行事件（WebSocket 推送）
+export interface TaskEvent {
+  type: 'host_start' | 'stdout' | 'stderr' | 'host_done' | 'done'
+  taskId: number
+  hostId?: number
+  hostName?: string
+  hostIp?: string
+  data?: string
+  status?: string
+  error?: string
+}
+
+// 任务执行输出的 WebSocket 地址（直接连接后端，不通过 Vite 代理）
+export const getTaskStreamUrl = (taskId: number) => {
+  const token = localStorage.getItem('token') || ''
+  const isDev = window.location.hostname === 'localhost' || window.location.hostname === '127.0.0.1'
+  const protocol = window.location.protocol === 'https:' ? 'wss:' : 'ws:'
+  const backendHost = window.location.hostname
+  const backendPort = isDev ? ':9876' : (window.location.port ? ':' + window.location.port : '')
+  return `${protocol}//${backendHost}${backendPort}/api/v1/plugins/task/execute/${taskId}/stream?token=${token}`
+}
+
 // ==================== 任务作业 ====================
 
 export interface JobTask {
@@ -69,6 +94,10 @@ export const updateJobTask = (id: number, data: any) => {
   return request.put<any, JobTask>(`/api/v1/plugins/task/jobs/${id}`, data)
 }
 
+export const cancelJobTask = (id: number) => {
+  return request.post<any, any>(`/api/v1/plugins/task/jobs/${id}/cancel`)
+}
+
 export const deleteJobTask = (id: number) => {
   return request.delete<any, any>(`/api/v1/plugins/task/jobs/${id}`)
 }
@@ -125,6 +154,165 @@ export const deleteJobTemplate = (id: number) => {
   return request.delete<any, any>(`/api/v1/plugins/task/templates/${id}`)
 }
 
+// 模板变量定义，脚本中以 {{varName}} 引用
+export interface TemplateVariable {
+  name: string
+  varName: string
+  type: 'string' | 'int' | 'enum' | 'secret' | 'hosts'
+  required: boolean
+  defaultValue?: string
+  helpText?: string
+  options?: string[]
+  min?: number
+  max?: number
+  pattern?: string
+  credentialId?: number
+}
+
+export interface ExecuteTemplateRequest {
+  hostIds: number[]
+  variables?: Record<string, string | number | number[]>
+  scriptType?: string
+  name?: string
+  concurrency?: number
+  timeout?: number
+}
+
+// 按模板执行任务，敏感变量传凭证ID，主机列表变量传主机ID数组
+export const executeJobTemplate = (id: number, data: ExecuteTemplateRequest) => {
+  return request.post<any, any>(`/api/v1/plugins/task/templates/${id}/execute`, data)
+}
+
+// ==================== 定时任务 ====================
+
+export interface JobSchedule {
+  id: number
+  name: string
+  description?: string
+  cronExpr: string
+  templateId?: number
+  scriptType: string
+  content?: string
+  targetHosts: string
+  concurrency: number
+  timeout: number
+  missedRunPolicy: 'skip' | 'catchup'
+  status: number
+  nextRunTime?: string
+  lastRunTime?: string
+  lastRunStatus?: string
+  lastJobTaskId?: number
+  createdBy: number
+  createdAt: string
+  updatedAt: string
+}
+
+export interface JobScheduleListParams {
+  page?: number
+  pageSize?: number
+  keyword?: string
+  status?: number
+}
+
+export const getJobScheduleList = (params: JobScheduleListParams) => {
+  return request.get<any, any>('/api/v1/plugins/task/schedules', { params })
+}
+
+export const getJobScheduleDetail = (id: number) => {
+  return request.get<any, JobSchedule>(`/api/v1/plugins/task/schedules/${id}`)
+}
+
+export const createJobSchedule = (data: any) => {
+  return request.post<any, JobSchedule>('/api/v1/plugins/task/schedules', data)
+}
+
+export const updateJobSchedule = (id: number, data: any) => {
+  return request.put<any, JobSchedule>(`/api/v1/plugins/task/schedules/${id}`, data)
+}
+
+export const deleteJobSchedule = (id: number) => {
+  return request.delete<any, any>(`/api/v1/plugins/task/schedules/${id}`)
+}
+
+export const getJobScheduleRuns = (id: number, params: { page?: number; pageSize?: number; status?: string }) => {
+  return request.get<any, any>(`/api/v1/plugins/task/schedules/${id}/runs`, { params })
+}
+
+export const previewCronExpr = (cronExpr: string, count = 5) => {
+  return request.get<any, { cronExpr: string; nextRuns: string[] }>('/api/v1/plugins/task/schedules/next-runs', { params: { cronExpr, count } })
+}
+
+// ==================== 任务审批 ====================
+
+export interface ApprovalDetail {
+  id: number
+  name: string
+  status: string // pending_approval, running, rejected, failed, cancelled ...
+  approvalReason: string
+  approvers?: string
+  approverId?: number
+  approverName?: string
+  approvalTime?: string
+  approvalComment?: string
+  createdBy: number
+  createdAt: string
+  scriptType: string
+  content: string
+  variables?: Record<string, any>
+  hostIds: number[]
+  canApprove: boolean
+}
+
+export const getApprovalList = (params: { page?: number; pageSize?: number; status?: string }) => {
+  return request.get<any, any>('/api/v1/plugins/task/approvals', { params })
+}
+
+export const getApprovalDetail = (id: number) => {
+  return request.get<any, ApprovalDetail>(`/api/v1/plugins/task/approvals/${id}`)
+}
+
+export const approveJobTask = (id: number, comment?: string) => {
+  return request.post<any, ExecuteTaskResponse>(`/api/v1/plugins/task/approvals/${id}/approve`, { comment })
+}
+
+export const rejectJobTask = (id: number, comment?: string) => {
+  return request.post<any, any>(`/api/v1/plugins/task/approvals/${id}/reject`, { comment })
+}
+
+export interface JobApprovalPolicy {
+  id: number
+  name: string
+  description?: string
+  assetGroupIds: string // JSON 资产分组ID数组
+  templateCategories: string // JSON 模板分类数组
+  onCommandApproval: boolean
+  approvers: string // JSON 审批人用户ID数组，为空时由管理员审批
+  status: number
+  createdBy: number
+  createdAt: string
+  updatedAt: string
+}
+
+export const getApprovalPolicyList = (params: { page?: number; pageSize?: number; keyword?: string }) => {
+  return request.get<any, any>('/api/v1/plugins/task/approval-policies', { params })
+}
+
+export const getApprovalPolicyDetail = (id: number) => {
+  return request.get<any, JobApprovalPolicy>(`/api/v1/plugins/task/approval-policies/${id}`)
+}
+
+export const createApprovalPolicy = (data: any) => {
+  return request.post<any, JobApprovalPolicy>('/api/v1/plugins/task/approval-policies', data)
+}
+
+export const updateApprovalPolicy = (id: number, data: any) => {
+  return request.put<any, JobApprovalPolicy>(`/api/v1/plugins/task/approval-policies/${id}`, data)
+}
+
+export const deleteApprovalPolicy = (id: number) => {
+  return request.delete<any, any>(`/api/v1/plugins/task/approval-policies/${id}`)
+}
+
 // ==================== Ansible任务 ====================
 
 export interface AnsibleTask {
@@ -173,6 +361,19 @@ export const deleteAnsibleTask = (id: number) => {
   return request.delete<any, any>(`/api/v1/plugins/task/ansible/${id}`)
 }
 
+export const runAnsibleTask = (id: number) => {
+  return request.post<any, any>(`/api/v1/plugins/task/ansible/${id}/run`)
+}
+
+export const cancelAnsibleTask = (id: number) => {
+  return request.post<any, any>(`/api/v1/plugins/task/ansible/${id}/cancel`)
+}
+
+// 获取Ansible任务实时输出的WebSocket地址
+export const getAnsibleStreamUrl = (taskId: number) => {
+  return getTaskStreamUrl(taskId).replace(`/execute/${taskId}/stream`, `/ansible/${taskId}/stream`)
+}
+
 // ==================== 执行记录 ====================
 
 export interface ExecutionHistory {
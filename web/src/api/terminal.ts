@@ -22,9 +22,125 @@ export const playTerminalSession = (id: number) => {
   })
 }
 
+/**
+ * 终端会话导出格式：text 纯文本记录，html 自包含回放页面，input 仅输入事件的 JSON
+ */
+export type TerminalExportFormat = 'text' | 'html' | 'input'
+
+/**
+ * 导出主机终端会话
+ */
+export const exportTerminalSession = (id: number, format: TerminalExportFormat) => {
+  return request.get(`/api/v1/terminal-sessions/${id}/export`, {
+    params: { format },
+    responseType: 'blob'
+  })
+}
+
+/**
+ * 导出 Kubernetes Pod/节点终端会话
+ */
+export const exportK8sTerminalSession = (id: number, format: TerminalExportFormat) => {
+  return request.get(`/api/v1/terminal-sessions/k8s/${id}/export`, {
+    params: { format },
+    responseType: 'blob'
+  })
+}
+
+/**
+ * 保存导出的终端会话文件
+ */
+export const saveTerminalExport = (blob: Blob, filename: string, format: TerminalExportFormat) => {
+  const ext = { text: 'txt', html: 'html', input: 'json' }[format]
+  const url = window.URL.createObjectURL(blob)
+  const link = document.createElement('a')
+  link.href = url
+  link.download = `${filename}.${ext}`
+  document.body.appendChild(link)
+  link.click()
+  document.body.removeChild(link)
+  window.URL.revokeObjectURL(url)
+}
+
 /**
  * 删除终端会话
  */
 export const deleteTerminalSession = (id: number) => {
   return request.delete(`/api/v1/terminal-sessions/${id}`)
 }
+
+/**
+ * 检索终端命令，返回的 sessionId 和 offset（秒）用于跳转回放
+ */
+export const searchTerminalCommands = (params: {
+  page: number
+  pageSize: number
+  command?: string
+  host?: string
+  hostId?: number
+  username?: string
+  sessionId?: number
+  action?: string
+  startTime?: string
+  endTime?: string
+}) => {
+  return request.get('/api/v1/terminal-commands', { params })
+}
+
+/**
+ * 获取终端会话的命令列表
+ */
+export const getSessionCommands = (id: number) => {
+  return request.get(`/api/v1/terminal-sessions/${id}/commands`)
+}
+
+/**
+ * 获取终端命令审批列表
+ */
+export const getTerminalApprovals = (params: {
+  page: number
+  pageSize: number
+  status?: string
+  username?: string
+}) => {
+  return request.get('/api/v1/terminal-approvals', { params })
+}
+
+/**
+ * 审批通过终端命令，命令在等待中的终端会话里继续执行
+ */
+export const approveTerminalCommand = (id: number, comment?: string) => {
+  return request.post(`/api/v1/terminal-approvals/${id}/approve`, { comment })
+}
+
+/**
+ * 驳回终端命令
+ */
+export const rejectTerminalCommand = (id: number, comment?: string) => {
+  return request.post(`/api/v1/terminal-approvals/${id}/reject`, { comment })
+}
+
+/**
+ * 获取在线终端会话（仅管理员）
+ */
+export const getActiveTerminalSessions = () => {
+  return request.get('/api/v1/terminal-sessions/active')
+}
+
+/**
+ * 强制终止在线终端会话，原因显示在用户终端并保存到会话记录
+ */
+export const terminateTerminalSession = (id: string, reason: string) => {
+  return request.post(`/api/v1/terminal-sessions/active/${id}/kill`, { reason })
+}
+
+/**
+ * 旁观在线终端会话的 WebSocket 地址，mode 为 readonly（只读）或 collaborate（协作输入）
+ */
+export const getWatchSessionUrl = (id: string, mode: 'readonly' | 'collaborate') => {
+  const token = localStorage.getItem('token') || ''
+  const isDev = window.location.hostname === 'localhost' || window.location.hostname === '127.0.0.1'
+  const protocol = window.location.protocol === 'https:' ? 'wss:' : 'ws:'
+  const backendPort = isDev ? ':9876' : (window.location.port ? ':' + window.location.port : '')
+  return `${protocol}//${window.location.hostname}${backendPort}/api/v1/terminal-sessions/active/${encodeURIComponent(id)}/watch?token=${token}&mode=${mode}`
+}
@@ -89,6 +89,12 @@ const router = createRouter({
           component: () => import('@/views/audit/DataLogs.vue'),
           meta: { title: '数据日志' }
         },
+        {
+          path: 'audit/file-transfer-logs',
+          name: 'FileTransferLogs',
+          component: () => import('@/views/audit/FileTransferLogs.vue'),
+          meta: { title: '文件传输日志' }
+        },
         {
           path: 'asset/hosts',
           name: 'AssetHosts',